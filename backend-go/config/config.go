@@ -2,28 +2,47 @@ package config
 
 import (
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
+// RetryPolicy configures how failed notifications of a channel are retried
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
 type Config struct {
-	Port              string
-	DatabaseURL       string
-	SendGridAPIKey    string
-	TwilioAccountSID  string
-	TwilioAuthToken   string
-	TwilioPhoneNumber string
-	FromEmail         string
+	Port                 string
+	DatabaseURL          string
+	SendGridAPIKey       string
+	TwilioAccountSID     string
+	TwilioAuthToken      string
+	TwilioPhoneNumber    string
+	FromEmail            string
+	NotificationChannels []string
+	NotificationRetry    map[string]RetryPolicy
+	ReminderCatchUp      time.Duration
+	MigrateOnStart       bool
 }
 
 func LoadConfig() *Config {
-	return &Config{
-		Port:              getEnv("PORT", "8080"),
-		DatabaseURL:       getEnv("DATABASE_URL", "calendar.db"),
-		SendGridAPIKey:    getEnv("SENDGRID_API_KEY", ""),
-		TwilioAccountSID:  getEnv("TWILIO_ACCOUNT_SID", ""),
-		TwilioAuthToken:   getEnv("TWILIO_AUTH_TOKEN", ""),
-		TwilioPhoneNumber: getEnv("TWILIO_PHONE_NUMBER", ""),
-		FromEmail:         getEnv("FROM_EMAIL", "noreply@calendar.com"),
+	cfg := &Config{
+		Port:                 getEnv("PORT", "8080"),
+		DatabaseURL:          getEnv("DATABASE_URL", "calendar.db"),
+		SendGridAPIKey:       getEnv("SENDGRID_API_KEY", ""),
+		TwilioAccountSID:     getEnv("TWILIO_ACCOUNT_SID", ""),
+		TwilioAuthToken:      getEnv("TWILIO_AUTH_TOKEN", ""),
+		TwilioPhoneNumber:    getEnv("TWILIO_PHONE_NUMBER", ""),
+		FromEmail:            getEnv("FROM_EMAIL", "noreply@calendar.com"),
+		NotificationChannels: getListEnv("NOTIFICATION_CHANNELS", "email,whatsapp"),
+		ReminderCatchUp:      getDurationEnv("REMINDER_CATCH_UP_WINDOW", 24*time.Hour),
+		MigrateOnStart:       getBoolEnv("MIGRATE_ON_START", true),
 	}
+	cfg.NotificationRetry = loadRetryPolicies(cfg.NotificationChannels)
+	return cfg
 }
 
 func getEnv(key, defaultValue string) string {
@@ -32,3 +51,63 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// getListEnv parses a comma-separated list, lowercased and without blanks
+func getListEnv(key, defaultValue string) []string {
+	var list []string
+	for _, item := range strings.Split(getEnv(key, defaultValue), ",") {
+		if item = strings.ToLower(strings.TrimSpace(item)); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// loadRetryPolicies reads NOTIFICATION_MAX_ATTEMPTS, NOTIFICATION_RETRY_BASE_DELAY and
+// NOTIFICATION_RETRY_MAX_DELAY, overridable per channel, e.g. NOTIFICATION_WHATSAPP_MAX_ATTEMPTS
+func loadRetryPolicies(channels []string) map[string]RetryPolicy {
+	defaults := RetryPolicy{
+		MaxAttempts: getIntEnv("NOTIFICATION_MAX_ATTEMPTS", 5),
+		BaseDelay:   getDurationEnv("NOTIFICATION_RETRY_BASE_DELAY", 30*time.Second),
+		MaxDelay:    getDurationEnv("NOTIFICATION_RETRY_MAX_DELAY", time.Hour),
+	}
+
+	policies := make(map[string]RetryPolicy, len(channels))
+	for _, channel := range channels {
+		prefix := "NOTIFICATION_" + strings.ToUpper(channel) + "_"
+		policies[channel] = RetryPolicy{
+			MaxAttempts: getIntEnv(prefix+"MAX_ATTEMPTS", defaults.MaxAttempts),
+			BaseDelay:   getDurationEnv(prefix+"RETRY_BASE_DELAY", defaults.BaseDelay),
+			MaxDelay:    getDurationEnv(prefix+"RETRY_MAX_DELAY", defaults.MaxDelay),
+		}
+	}
+	return policies
+}
+
+func getIntEnv(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			return n
+		}
+	}
+	return defaultValue
+}
+
+func getDurationEnv(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if d, err := time.ParseDuration(value); err == nil {
+			return d
+		}
+	}
+	return defaultValue
+}
+
+// getBoolEnv parses true/false, 1/0 and the other values accepted by strconv.ParseBool
+func getBoolEnv(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return defaultValue
+}
@@ -2,7 +2,7 @@ package database
 
 import (
 	"calendar-backend/config"
-	"calendar-backend/models"
+	"calendar-backend/migrations"
 	"fmt"
 	"log"
 	"os"
@@ -16,7 +16,8 @@ import (
 
 var DB *gorm.DB
 
-func InitDB() (*gorm.DB, error) {
+// Connect opens the configured database without migrating it
+func Connect() (*gorm.DB, error) {
 	cfg := config.LoadConfig()
 
 	var err error
@@ -35,16 +36,46 @@ func InitDB() (*gorm.DB, error) {
 	if err != nil {
 		return nil, err
 	}
+	return DB, nil
+}
+
+func InitDB() (*gorm.DB, error) {
+	cfg := config.LoadConfig()
 
-	// Auto migrate the schema
-	err = DB.AutoMigrate(&models.Event{})
+	db, err := Connect()
 	if err != nil {
-		log.Printf("Error migrating database: %v", err)
 		return nil, err
 	}
 
-	log.Printf("Database connected and migrated successfully using %s", getDBType(cfg.DatabaseURL))
-	return DB, nil
+	migrator, err := migrations.New(db)
+	if err != nil {
+		log.Printf("Error preparing migrations: %v", err)
+		return nil, err
+	}
+
+	// Refuse to run against a schema migrated by a newer version of the application
+	if err := migrator.Check(); err != nil {
+		return nil, err
+	}
+
+	if cfg.MigrateOnStart {
+		if _, err := migrator.Up(); err != nil {
+			log.Printf("Error migrating database: %v", err)
+			return nil, err
+		}
+	} else {
+		pending, err := migrator.Pending()
+		if err != nil {
+			return nil, err
+		}
+		if len(pending) > 0 {
+			return nil, fmt.Errorf("database has %d pending migrations, run \"calendar-backend migrate up\"", len(pending))
+		}
+	}
+
+	current, _ := migrator.Current()
+	log.Printf("Database connected at schema version %d using %s", current, getDBType(cfg.DatabaseURL))
+	return db, nil
 }
 
 func connectPostgreSQL(databaseURL string) (*gorm.DB, error) {
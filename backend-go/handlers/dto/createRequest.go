@@ -26,12 +26,9 @@ type CreateEventRequest struct {
 	Priority          string `json:"priority" validate:"oneof=low medium high"`
 	Category          string `json:"category" validate:"max=50"`
 	// Campos de notificación familiar
-	NotifyFamily     bool   `json:"notify_family"`
-	NotifyPapa       bool   `json:"notify_papa"`
-	NotifyMama       bool   `json:"notify_mama"`
-	ChildTag         string `json:"child_tag"`
-	SelectedChildren string `json:"selected_children"`
-	FamilyMembers    string `json:"family_members"`
+	HouseholdID *uint  `json:"household_id"`
+	MemberIDs   []uint `json:"member_ids"` // Miembros del hogar a notificar
+	ChildIDs    []uint `json:"child_ids"`  // Hijos para los que es el evento
 }
 
 // ToEvent convierte el DTO a un modelo Event
@@ -94,12 +91,9 @@ func (req *CreateEventRequest) ToEvent() (*models.Event, error) {
 		Color:             req.Color,
 		Priority:          req.Priority,
 		Category:          req.Category,
-		NotifyFamily:      req.NotifyFamily,
-		NotifyPapa:        req.NotifyPapa,
-		NotifyMama:        req.NotifyMama,
-		ChildTag:          req.ChildTag,
-		SelectedChildren:  req.SelectedChildren,
-		FamilyMembers:     req.FamilyMembers,
+		HouseholdID:       req.HouseholdID,
+		Members:           membersFromIDs(req.MemberIDs),
+		Children:          childrenFromIDs(req.ChildIDs),
 	}, nil
 }
 
@@ -199,10 +193,8 @@ func (req *CreateEventRequest) ProcessRequest(c *gin.Context) (*models.Event, er
 
 	// Log the bound request data
 	fmt.Printf("🔍 Bound request data: %+v\n", req)
-	fmt.Printf("🔍 Family fields in request - NotifyFamily: %v, NotifyPapa: %v, NotifyMama: %v\n",
-		req.NotifyFamily, req.NotifyPapa, req.NotifyMama)
-	fmt.Printf("🔍 FamilyMembers in request: %s\n", req.FamilyMembers)
-	fmt.Printf("🔍 SelectedChildren in request: %s\n", req.SelectedChildren)
+	fmt.Printf("🔍 Family fields in request - HouseholdID: %v, MemberIDs: %v, ChildIDs: %v\n",
+		req.HouseholdID, req.MemberIDs, req.ChildIDs)
 
 	req.Sanitize()
 
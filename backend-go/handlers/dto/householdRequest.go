@@ -0,0 +1,135 @@
+package dto
+
+import (
+	"calendar-backend/models"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HouseholdRequest DTO para crear o actualizar un hogar
+type HouseholdRequest struct {
+	Name  string `json:"name" binding:"required"`
+	Email string `json:"email"`
+}
+
+// ProcessRequest maneja binding, limpieza y conversión
+func (req *HouseholdRequest) ProcessRequest(c *gin.Context) (*models.Household, error) {
+	if err := c.ShouldBindJSON(req); err != nil {
+		return nil, err
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	req.Email = strings.TrimSpace(strings.ToLower(req.Email))
+	if req.Name == "" {
+		return nil, errors.New("name is required")
+	}
+	if len(req.Name) > 100 {
+		return nil, errors.New("name must be less than 100 characters")
+	}
+
+	return &models.Household{Name: req.Name, Email: req.Email}, nil
+}
+
+// MemberRequest DTO para crear o actualizar un miembro del hogar
+type MemberRequest struct {
+	Name  string `json:"name" binding:"required"`
+	Email string `json:"email"`
+	Phone string `json:"phone"`
+	Role  string `json:"role"` // Rol libre, por ejemplo "papá", "abuela" o "niñera"
+}
+
+// ProcessRequest maneja binding, limpieza, validación y conversión
+func (req *MemberRequest) ProcessRequest(c *gin.Context) (*models.Member, error) {
+	if err := c.ShouldBindJSON(req); err != nil {
+		return nil, err
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	req.Email = strings.TrimSpace(strings.ToLower(req.Email))
+	req.Phone = strings.TrimSpace(req.Phone)
+	req.Role = strings.TrimSpace(strings.ToLower(req.Role))
+
+	if req.Name == "" {
+		return nil, errors.New("name is required")
+	}
+	if req.Email != "" && (len(req.Email) < 5 || !strings.Contains(req.Email, "@")) {
+		return nil, errors.New("invalid email format")
+	}
+	if req.Phone != "" && (len(req.Phone) < 10 || len(req.Phone) > 20) {
+		return nil, errors.New("phone must be between 10 and 20 characters")
+	}
+	if len(req.Role) > 50 {
+		return nil, errors.New("role must be less than 50 characters")
+	}
+
+	return &models.Member{
+		Name:  req.Name,
+		Email: req.Email,
+		Phone: req.Phone,
+		Role:  req.Role,
+	}, nil
+}
+
+// ChildRequest DTO para crear o actualizar un hijo del hogar
+type ChildRequest struct {
+	Name      string `json:"name" binding:"required"`
+	BirthDate string `json:"birth_date"` // Format: "2006-01-02"
+	Color     string `json:"color"`
+}
+
+// ProcessRequest maneja binding, limpieza, validación y conversión
+func (req *ChildRequest) ProcessRequest(c *gin.Context) (*models.Child, error) {
+	if err := c.ShouldBindJSON(req); err != nil {
+		return nil, err
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	req.Color = strings.TrimSpace(req.Color)
+	if req.Name == "" {
+		return nil, errors.New("name is required")
+	}
+
+	child := &models.Child{Name: req.Name, Color: req.Color}
+	if req.BirthDate != "" {
+		birthDate, err := time.Parse("2006-01-02", req.BirthDate)
+		if err != nil {
+			return nil, errors.New("invalid birth_date format, use YYYY-MM-DD")
+		}
+		child.BirthDate = &birthDate
+	}
+
+	return child, nil
+}
+
+// membersFromIDs arma los miembros a vincular a un evento a partir de sus IDs
+// (sin repetir); el servicio los completa desde la base. Nunca devuelve nil.
+func membersFromIDs(ids []uint) []models.Member {
+	members := []models.Member{}
+	seen := make(map[uint]bool)
+	for _, id := range ids {
+		if id == 0 || seen[id] {
+			continue
+		}
+		seen[id] = true
+		members = append(members, models.Member{ID: id})
+	}
+	return members
+}
+
+// childrenFromIDs arma los hijos a vincular a un evento a partir de sus IDs
+// (sin repetir); el servicio los completa desde la base. Nunca devuelve nil.
+func childrenFromIDs(ids []uint) []models.Child {
+	children := []models.Child{}
+	seen := make(map[uint]bool)
+	for _, id := range ids {
+		if id == 0 || seen[id] {
+			continue
+		}
+		seen[id] = true
+		children = append(children, models.Child{ID: id})
+	}
+	return children
+}
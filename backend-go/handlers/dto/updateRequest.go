@@ -24,6 +24,10 @@ type UpdateEventRequest struct {
 	Color             *string `json:"color" validate:"omitempty,hexcolor"`
 	Priority          *string `json:"priority" validate:"omitempty,oneof=low medium high"`
 	Category          *string `json:"category" validate:"omitempty,max=50"`
+	// Campos de notificación familiar
+	HouseholdID  *uint   `json:"household_id"`
+	MemberIDs    *[]uint `json:"member_ids"` // Reemplaza los miembros vinculados
+	ChildIDs     *[]uint `json:"child_ids"`  // Reemplaza los hijos vinculados
 }
 
 // ToEvent convierte el DTO a un modelo Event para actualización
@@ -167,6 +171,15 @@ func (req *UpdateEventRequest) ToEvent() (*models.Event, error) {
 		req.applyCategoryColors(event)
 	}
 
+	// Procesar vínculos familiares: una lista vacía (no nil) desvincula a todos
+	event.HouseholdID = req.HouseholdID
+	if req.MemberIDs != nil {
+		event.Members = membersFromIDs(*req.MemberIDs)
+	}
+	if req.ChildIDs != nil {
+		event.Children = childrenFromIDs(*req.ChildIDs)
+	}
+
 	return event, nil
 }
 
@@ -176,7 +189,8 @@ func (req *UpdateEventRequest) Validate() error {
 	if req.Title == nil && req.Description == nil && req.Date == nil && 
 	   req.Time == nil && req.Location == nil && req.Email == nil && 
 	   req.Phone == nil && req.ReminderDay == nil && req.ReminderDayBefore == nil &&
-	   req.IsAllDay == nil && req.Color == nil && req.Priority == nil && req.Category == nil {
+	   req.IsAllDay == nil && req.Color == nil && req.Priority == nil && req.Category == nil &&
+	   req.HouseholdID == nil && req.MemberIDs == nil && req.ChildIDs == nil {
 		return errors.New("at least one field must be provided for update")
 	}
 
@@ -39,10 +39,8 @@ func (h *EventController) CreateEvent(c *gin.Context) {
 
 	// Log the processed event data
 	fmt.Printf("🔍 Processed event data: %+v\n", event)
-	fmt.Printf("🔍 Family fields - NotifyFamily: %v, NotifyPapa: %v, NotifyMama: %v\n",
-		event.NotifyFamily, event.NotifyPapa, event.NotifyMama)
-	fmt.Printf("🔍 FamilyMembers: %s\n", event.FamilyMembers)
-	fmt.Printf("🔍 SelectedChildren: %s\n", event.SelectedChildren)
+	fmt.Printf("🔍 Family fields - HouseholdID: %v, Members: %d, Children: %d\n",
+		event.HouseholdID, len(event.Members), len(event.Children))
 
 	if err := h.eventService.CreateEvent(event); err != nil {
 		fmt.Printf("❌ Error creating event: %v\n", err)
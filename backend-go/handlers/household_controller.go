@@ -0,0 +1,252 @@
+package handlers
+
+import (
+	"calendar-backend/handlers/dto"
+	"calendar-backend/services"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type HouseholdController struct {
+	householdService *services.HouseholdService
+}
+
+func NewHouseholdController(householdService *services.HouseholdService) *HouseholdController {
+	return &HouseholdController{householdService: householdService}
+}
+
+// CreateHousehold creates a household
+func (h *HouseholdController) CreateHousehold(c *gin.Context) {
+	var req dto.HouseholdRequest
+	household, err := req.ProcessRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.householdService.CreateHousehold(household); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, household)
+}
+
+// GetHouseholds lists households, optionally filtered by ?email=
+func (h *HouseholdController) GetHouseholds(c *gin.Context) {
+	households, err := h.householdService.GetHouseholds(c.Query("email"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch households"})
+		return
+	}
+
+	c.JSON(http.StatusOK, households)
+}
+
+// GetHousehold returns a household with its members and children
+func (h *HouseholdController) GetHousehold(c *gin.Context) {
+	id, err := parseIDParam(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid household ID"})
+		return
+	}
+
+	household, err := h.householdService.GetHousehold(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, household)
+}
+
+// UpdateHousehold updates the name and contact email of a household
+func (h *HouseholdController) UpdateHousehold(c *gin.Context) {
+	id, err := parseIDParam(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid household ID"})
+		return
+	}
+
+	var req dto.HouseholdRequest
+	household, err := req.ProcessRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	updated, err := h.householdService.UpdateHousehold(id, household)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+// DeleteHousehold deletes a household with its members and children
+func (h *HouseholdController) DeleteHousehold(c *gin.Context) {
+	id, err := parseIDParam(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid household ID"})
+		return
+	}
+
+	if err := h.householdService.DeleteHousehold(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Household deleted successfully"})
+}
+
+// AddMember adds an adult member to a household
+func (h *HouseholdController) AddMember(c *gin.Context) {
+	householdID, err := parseIDParam(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid household ID"})
+		return
+	}
+
+	var req dto.MemberRequest
+	member, err := req.ProcessRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.householdService.AddMember(householdID, member); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, member)
+}
+
+// UpdateMember replaces the data of a household member
+func (h *HouseholdController) UpdateMember(c *gin.Context) {
+	householdID, memberID, err := parseNestedIDParams(c, "member_id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req dto.MemberRequest
+	member, err := req.ProcessRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	updated, err := h.householdService.UpdateMember(householdID, memberID, member)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+// RemoveMember removes a member from a household and its events
+func (h *HouseholdController) RemoveMember(c *gin.Context) {
+	householdID, memberID, err := parseNestedIDParams(c, "member_id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.householdService.RemoveMember(householdID, memberID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Member deleted successfully"})
+}
+
+// AddChild adds a child to a household
+func (h *HouseholdController) AddChild(c *gin.Context) {
+	householdID, err := parseIDParam(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid household ID"})
+		return
+	}
+
+	var req dto.ChildRequest
+	child, err := req.ProcessRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.householdService.AddChild(householdID, child); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, child)
+}
+
+// UpdateChild replaces the data of a household child
+func (h *HouseholdController) UpdateChild(c *gin.Context) {
+	householdID, childID, err := parseNestedIDParams(c, "child_id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req dto.ChildRequest
+	child, err := req.ProcessRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	updated, err := h.householdService.UpdateChild(householdID, childID, child)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+// RemoveChild removes a child from a household and its events
+func (h *HouseholdController) RemoveChild(c *gin.Context) {
+	householdID, childID, err := parseNestedIDParams(c, "child_id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.householdService.RemoveChild(householdID, childID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Child deleted successfully"})
+}
+
+// parseIDParam converts a numeric path parameter to uint
+func parseIDParam(c *gin.Context, name string) (uint, error) {
+	id, err := strconv.ParseUint(c.Param(name), 10, 32)
+	if err != nil || id == 0 {
+		return 0, errors.New("invalid ID")
+	}
+	return uint(id), nil
+}
+
+// parseNestedIDParams parses the household ID and the ID of one of its members or children
+func parseNestedIDParams(c *gin.Context, name string) (uint, uint, error) {
+	householdID, err := parseIDParam(c, "id")
+	if err != nil {
+		return 0, 0, errors.New("Invalid household ID")
+	}
+	id, err := parseIDParam(c, name)
+	if err != nil {
+		return 0, 0, errors.New("Invalid " + name)
+	}
+	return householdID, id, nil
+}
@@ -3,7 +3,9 @@ package handlers
 import (
 	"calendar-backend/models"
 	"calendar-backend/services"
+	"errors"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -11,27 +13,29 @@ import (
 
 type NotificationController struct {
 	notificationService *services.NotificationService
-	scheduler           *services.NotificationScheduler
+	reminders           *services.ReminderService
+	outbox              *services.NotificationOutbox
 }
 
-func NewNotificationController(notificationService *services.NotificationService, scheduler *services.NotificationScheduler) *NotificationController {
+func NewNotificationController(notificationService *services.NotificationService, reminders *services.ReminderService, outbox *services.NotificationOutbox) *NotificationController {
 	return &NotificationController{
 		notificationService: notificationService,
-		scheduler:           scheduler,
+		reminders:           reminders,
+		outbox:              outbox,
 	}
 }
 
-// CheckNotificationsNow ejecuta manualmente la verificación de notificaciones
+// CheckNotificationsNow recalcula los recordatorios pendientes y envía los vencidos
 func (h *NotificationController) CheckNotificationsNow(c *gin.Context) {
-	if h.scheduler == nil {
+	if h.reminders == nil {
 		c.JSON(http.StatusOK, gin.H{
-			"message": "Notification scheduler is not available",
+			"message": "Reminder engine is not available",
 			"status":  "warning",
 		})
 		return
 	}
 
-	h.scheduler.CheckNotificationsNow()
+	h.reminders.Reschedule()
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Notification check triggered successfully",
@@ -53,6 +57,112 @@ func (h *NotificationController) GetNotificationStatus(c *gin.Context) {
 	})
 }
 
+// GetDeliveries devuelve el historial del outbox, filtrable por ?event_id=, ?status=, ?channel= y ?limit=
+func (h *NotificationController) GetDeliveries(c *gin.Context) {
+	filter, err := parseDeliveryFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.listDeliveries(c, filter)
+}
+
+// GetDeadLetters devuelve las entregas que fallaron definitivamente, filtrable por ?event_id=, ?channel= y ?limit=
+func (h *NotificationController) GetDeadLetters(c *gin.Context) {
+	filter, err := parseDeliveryFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	filter.Status = models.DeliveryStatusDead
+
+	h.listDeliveries(c, filter)
+}
+
+// RedriveDeadLetters vuelve a encolar las entregas en dead-letter, filtrables por ?event_id= y ?channel=
+func (h *NotificationController) RedriveDeadLetters(c *gin.Context) {
+	filter, err := parseDeliveryFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	redriven, err := h.outbox.Redrive(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to re-drive notifications"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Dead-lettered notifications re-queued",
+		"redriven": redriven,
+	})
+}
+
+// RedriveDeadLetter vuelve a encolar una entrega en dead-letter
+func (h *NotificationController) RedriveDeadLetter(c *gin.Context) {
+	id, err := parseIDParam(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid delivery ID"})
+		return
+	}
+
+	redriven, err := h.outbox.Redrive(models.DeliveryFilter{ID: id})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to re-drive notification"})
+		return
+	}
+	if redriven == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Dead-lettered notification not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Notification re-queued",
+		"redriven": redriven,
+	})
+}
+
+func (h *NotificationController) listDeliveries(c *gin.Context, filter models.DeliveryFilter) {
+	deliveries, err := h.outbox.History(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch notification deliveries"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"deliveries": deliveries,
+		"count":      len(deliveries),
+	})
+}
+
+// parseDeliveryFilter lee los filtros de entregas de la query string
+func parseDeliveryFilter(c *gin.Context) (models.DeliveryFilter, error) {
+	filter := models.DeliveryFilter{
+		Status:  c.Query("status"),
+		Channel: c.Query("channel"),
+		Limit:   100,
+	}
+
+	if eventID := c.Query("event_id"); eventID != "" {
+		id, err := strconv.ParseUint(eventID, 10, 32)
+		if err != nil {
+			return filter, errors.New("invalid event_id")
+		}
+		filter.EventID = uint(id)
+	}
+	if limit := c.Query("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n <= 0 || n > 1000 {
+			return filter, errors.New("limit must be between 1 and 1000")
+		}
+		filter.Limit = n
+	}
+
+	return filter, nil
+}
+
 // SendTestNotification envía una notificación de prueba
 func (h *NotificationController) SendTestNotification(c *gin.Context) {
 	var req struct {
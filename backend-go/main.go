@@ -5,9 +5,10 @@ import (
 	"os"
 	"time"
 
+	"calendar-backend/config"
 	"calendar-backend/database"
 	"calendar-backend/handlers"
-	"calendar-backend/models"
+	"calendar-backend/notifications"
 	"calendar-backend/repositories"
 	"calendar-backend/routes"
 	"calendar-backend/services"
@@ -34,33 +35,45 @@ func main() {
 		}
 	}
 
+	// "calendar-backend migrate ..." manages the schema migrations instead of starting the server
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	// Initialize database
 	db, err := database.InitDB()
 	if err != nil {
 		log.Fatal("Failed to connect to database:", err)
 	}
 
-	// Auto-migrate the schema
-	if err := db.AutoMigrate(&models.Event{}); err != nil {
-		log.Fatal("Failed to migrate database:", err)
-	}
-	log.Println("Database migration completed successfully")
-
 	// Initialize repositories
 	eventRepo := repositories.NewEventRepository(db)
+	householdRepo := repositories.NewHouseholdRepository(db)
+	deliveryRepo := repositories.NewNotificationDeliveryRepository(db)
+	checkpointRepo := repositories.NewReminderCheckpointRepository(db)
 
 	// Initialize services
-	eventService := services.NewEventService(eventRepo)
-	notificationService := services.NewNotificationService()
-	notificationScheduler := services.NewNotificationScheduler(eventRepo, notificationService)
+	cfg := config.LoadConfig()
+	notificationRegistry := notifications.NewRegistryFromConfig(cfg)
+	notificationService := services.NewNotificationService(notificationRegistry)
+	notificationOutbox := services.NewNotificationOutbox(deliveryRepo, notificationRegistry)
+	reminderService := services.NewReminderService(eventRepo, notificationOutbox, checkpointRepo, cfg.ReminderCatchUp)
+	eventService := services.NewEventService(eventRepo, householdRepo, reminderService)
+	householdService := services.NewHouseholdService(householdRepo)
+
+	// Start the outbox worker before the reminder engine that feeds it
+	notificationOutbox.Start()
 
-	// Start notification scheduler in background
-	log.Println("🔔 Initializing notification scheduler...")
-	notificationScheduler.Start()
-	log.Println("✅ Notification scheduler initialized and running")
+	// Start the reminder engine, catching up on reminders missed while the server was down
+	reminderService.Start()
+	log.Println("✅ Reminder engine initialized and running")
 
 	// Initialize handlers
 	eventController := handlers.NewEventController(eventService)
+	householdController := handlers.NewHouseholdController(householdService)
 	mobileHandler := handlers.NewMobileHandler(db)
 
 	// Setup routes
@@ -98,12 +111,12 @@ func main() {
 
 	// Setup all routes
 	log.Println("🔧 Setting up all routes...")
-	routes.SetupAllRoutes(router, eventController, mobileHandler)
+	routes.SetupAllRoutes(router, eventController, householdController, mobileHandler)
 	log.Println("✅ All routes setup completed")
 
 	// Setup notification routes
 	log.Println("🔧 Setting up notification routes...")
-	routes.SetupNotificationRoutes(router, notificationService, notificationScheduler)
+	routes.SetupNotificationRoutes(router, notificationService, reminderService, notificationOutbox)
 	log.Println("✅ Notification routes setup completed")
 
 	// Test notification endpoint (direct) - AFTER all other routes
@@ -0,0 +1,159 @@
+package migrations
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Columnas de la notificación familiar de los eventos, anteriores a los hogares.
+// child_tag ya estaba en desuso.
+var legacyFamilyColumns = []string{"notify_family", "notify_papa", "notify_mama", "child_tag", "selected_children", "family_members"}
+
+// Las tablas de los hogares como estaban en la migración 2: los modelos pueden cambiar después
+type household struct {
+	ID        uint
+	Name      string
+	Email     string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type member struct {
+	ID          uint
+	HouseholdID uint
+	Name        string
+	Email       string
+	Phone       string
+	Role        string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+type child struct {
+	ID          uint
+	HouseholdID uint
+	Name        string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// legacyFamilyMember es un elemento del JSON de family_members
+type legacyFamilyMember struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+	Phone string `json:"phone"`
+	Role  string `json:"role"`
+}
+
+// migrateFamilyJSON convierte el JSON de family_members y selected_children de los eventos en
+// un hogar por email del evento, con los miembros que se notificaban (rol "papa"/"mama" con
+// notify_papa/notify_mama) y los hijos. Los eventos migrados quedan con household_id.
+func migrateFamilyJSON(tx *gorm.DB, dialect string) error {
+	if !tx.Migrator().HasColumn("events", "family_members") {
+		return nil
+	}
+
+	var rows []struct {
+		ID               uint
+		Email            string
+		NotifyFamily     bool
+		NotifyPapa       bool
+		NotifyMama       bool
+		SelectedChildren string
+		FamilyMembers    string
+	}
+	if err := tx.Table("events").
+		Select("id, email, notify_family, notify_papa, notify_mama, selected_children, family_members").
+		Where("household_id IS NULL AND deleted_at IS NULL").
+		Where("(family_members IS NOT NULL AND family_members <> '') OR (selected_children IS NOT NULL AND selected_children <> '')").
+		Find(&rows).Error; err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+
+	households := make(map[string]*household)
+	for _, row := range rows {
+		var legacyMembers []legacyFamilyMember
+		var childNames []string
+		if row.FamilyMembers != "" {
+			if err := json.Unmarshal([]byte(row.FamilyMembers), &legacyMembers); err != nil {
+				log.Printf("Skipping family members of event %d: %v", row.ID, err)
+			}
+		}
+		if row.SelectedChildren != "" {
+			if err := json.Unmarshal([]byte(row.SelectedChildren), &childNames); err != nil {
+				log.Printf("Skipping selected children of event %d: %v", row.ID, err)
+			}
+		}
+
+		h, ok := households[row.Email]
+		if !ok {
+			h = &household{Name: "Familia " + row.Email, Email: row.Email}
+			if err := tx.Table("households").Create(h).Error; err != nil {
+				return err
+			}
+			households[row.Email] = h
+		}
+
+		for _, legacy := range legacyMembers {
+			m := member{HouseholdID: h.ID, Name: legacy.Name, Email: legacy.Email, Phone: legacy.Phone, Role: legacy.Role}
+			if err := tx.Table("members").
+				Where("household_id = ? AND name = ? AND email = ? AND phone = ?", h.ID, legacy.Name, legacy.Email, legacy.Phone).
+				FirstOrCreate(&m).Error; err != nil {
+				return err
+			}
+			notified := row.NotifyFamily &&
+				((row.NotifyPapa && legacy.Role == "papa") || (row.NotifyMama && legacy.Role == "mama"))
+			if notified {
+				if err := link(tx, "event_members", "member_id", row.ID, m.ID); err != nil {
+					return err
+				}
+			}
+		}
+
+		for _, name := range childNames {
+			c := child{HouseholdID: h.ID, Name: name}
+			if err := tx.Table("children").Where("household_id = ? AND name = ?", h.ID, name).FirstOrCreate(&c).Error; err != nil {
+				return err
+			}
+			if err := link(tx, "event_children", "child_id", row.ID, c.ID); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Table("events").Where("id = ?", row.ID).Update("household_id", h.ID).Error; err != nil {
+			return err
+		}
+	}
+
+	log.Printf("Migrated family data of %d events into %d households", len(rows), len(households))
+	return nil
+}
+
+// link relaciona el evento con un miembro o un hijo, si no estaban relacionados
+func link(tx *gorm.DB, table, column string, eventID, id uint) error {
+	var count int64
+	if err := tx.Table(table).Where("event_id = ? AND "+column+" = ?", eventID, id).Count(&count).Error; err != nil || count > 0 {
+		return err
+	}
+	return tx.Table(table).Create(map[string]interface{}{"event_id": eventID, column: id}).Error
+}
+
+// dropLegacyFamilyColumns quita de events las columnas de la notificación familiar, ya
+// migradas a los hogares. Las bases creadas después de los hogares no las tienen.
+func dropLegacyFamilyColumns(tx *gorm.DB, dialect string) error {
+	for _, column := range legacyFamilyColumns {
+		if !tx.Migrator().HasColumn("events", column) {
+			continue
+		}
+		if err := tx.Exec("ALTER TABLE events DROP COLUMN " + column).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,18 @@
+package migrations
+
+import "gorm.io/gorm"
+
+// initialSchemaUp crea el esquema inicial. Si la base ya tiene eventos (la creó AutoMigrate),
+// la adopta: le agrega household_id a los eventos de antes de los hogares.
+func initialSchemaUp(tx *gorm.DB, dialect string) error {
+	if tx.Migrator().HasTable("events") && !tx.Migrator().HasColumn("events", "household_id") {
+		columnType := "integer"
+		if dialect == "postgres" {
+			columnType = "bigint"
+		}
+		if err := tx.Exec("ALTER TABLE events ADD COLUMN household_id " + columnType).Error; err != nil {
+			return err
+		}
+	}
+	return execFile(tx, dialect, "0001_initial_schema.up.sql")
+}
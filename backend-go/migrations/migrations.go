@@ -0,0 +1,16 @@
+package migrations
+
+import "gorm.io/gorm"
+
+// all son las migraciones del esquema. Una migración aplicada no se modifica: los cambios
+// van en una migración nueva, con la versión siguiente y sus archivos SQL para cada dialecto.
+var all = []Migration{
+	{Version: 1, Name: "initial_schema", Up: initialSchemaUp, Down: sqlFile("0001_initial_schema.down.sql")},
+	// Revertirla no borra los hogares creados: las columnas viejas siguen hasta la migración 3
+	{Version: 2, Name: "family_households", Up: migrateFamilyJSON, Down: noop},
+	{Version: 3, Name: "drop_legacy_family_columns", Up: dropLegacyFamilyColumns, Down: sqlFile("0003_drop_legacy_family_columns.down.sql")},
+}
+
+func noop(tx *gorm.DB, dialect string) error {
+	return nil
+}
@@ -0,0 +1,288 @@
+// Package migrations versiona el esquema de la base: cada migración tiene su versión, un paso
+// para aplicarla (up) y otro para revertirla (down), en SQL de cada dialecto (PostgreSQL y
+// SQLite) y, si hace falta, en Go (ej: para completar datos). La tabla schema_migrations
+// registra las migraciones aplicadas.
+package migrations
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrSchemaTooNew indica que la base tiene migraciones que este binario no conoce: la migró
+// una versión más nueva de la aplicación
+var ErrSchemaTooNew = errors.New("database schema is newer than this binary")
+
+// ErrUnknownVersion indica una versión que no es de ninguna migración
+var ErrUnknownVersion = errors.New("unknown migration version")
+
+// Migration es un cambio versionado del esquema y su reversión
+type Migration struct {
+	Version int
+	Name    string
+	Up      Step
+	Down    Step
+}
+
+// Step aplica o revierte una migración dentro de una transacción
+type Step func(tx *gorm.DB, dialect string) error
+
+// Status es el estado de una migración en la base
+type Status struct {
+	Version   int
+	Name      string
+	Applied   bool
+	AppliedAt *time.Time
+	Unknown   bool // Aplicada en la base pero desconocida para este binario
+}
+
+// schemaMigration es una fila de schema_migrations
+type schemaMigration struct {
+	Version   int `gorm:"primaryKey;autoIncrement:false"`
+	Name      string
+	AppliedAt time.Time
+}
+
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// Clave del lock de PostgreSQL que evita que dos instancias migren a la vez
+const advisoryLockKey = 7305520190
+
+// Migrator aplica y revierte las migraciones sobre una base
+type Migrator struct {
+	db         *gorm.DB
+	dialect    string
+	migrations []Migration
+}
+
+// New devuelve el migrador de la base, con la tabla schema_migrations creada
+func New(db *gorm.DB) (*Migrator, error) {
+	dialect := db.Dialector.Name()
+	if dialect != "postgres" && dialect != "sqlite" {
+		return nil, fmt.Errorf("migrations do not support the %s dialect", dialect)
+	}
+	if err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at TIMESTAMP NOT NULL
+	)`).Error; err != nil {
+		return nil, err
+	}
+
+	sorted := append([]Migration(nil), all...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+	return &Migrator{db: db, dialect: dialect, migrations: sorted}, nil
+}
+
+// Latest devuelve la versión de la última migración: la del esquema que espera el binario
+func Latest() int {
+	latest := 0
+	for _, migration := range all {
+		if migration.Version > latest {
+			latest = migration.Version
+		}
+	}
+	return latest
+}
+
+// Latest devuelve la versión de la última migración que conoce el binario
+func (m *Migrator) Latest() int {
+	return Latest()
+}
+
+// Current devuelve la versión de la base: la de la última migración aplicada (0 si ninguna)
+func (m *Migrator) Current() (int, error) {
+	var version *int
+	err := m.db.Model(&schemaMigration{}).Select("MAX(version)").Scan(&version).Error
+	if err != nil || version == nil {
+		return 0, err
+	}
+	return *version, nil
+}
+
+// Check verifica que el binario conozca la versión de la base
+func (m *Migrator) Check() error {
+	current, err := m.Current()
+	if err != nil {
+		return err
+	}
+	if current > m.Latest() {
+		return fmt.Errorf("%w: database is at version %d, this binary knows up to version %d", ErrSchemaTooNew, current, m.Latest())
+	}
+	return nil
+}
+
+// Pending devuelve las migraciones que faltan aplicar, en orden
+func (m *Migrator) Pending() ([]Migration, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	var pending []Migration
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; !ok {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+// Status devuelve el estado de cada migración, incluidas las aplicadas que el binario no conoce
+func (m *Migrator) Status() ([]Status, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	known := make(map[int]bool, len(m.migrations))
+	for _, migration := range m.migrations {
+		known[migration.Version] = true
+		status := Status{Version: migration.Version, Name: migration.Name}
+		if row, ok := applied[migration.Version]; ok {
+			appliedAt := row.AppliedAt
+			status.Applied, status.AppliedAt = true, &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	for version, row := range applied {
+		if !known[version] {
+			appliedAt := row.AppliedAt
+			statuses = append(statuses, Status{Version: version, Name: row.Name, Applied: true, AppliedAt: &appliedAt, Unknown: true})
+		}
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses, nil
+}
+
+// Up aplica las migraciones pendientes y devuelve cuántas aplicó
+func (m *Migrator) Up() (int, error) {
+	return m.To(m.Latest())
+}
+
+// Down revierte la última migración aplicada. Devuelve 0 si no había ninguna.
+func (m *Migrator) Down() (int, error) {
+	current, err := m.Current()
+	if err != nil || current == 0 {
+		return 0, err
+	}
+	target := 0
+	for _, migration := range m.migrations {
+		if migration.Version < current {
+			target = migration.Version
+		}
+	}
+	return m.To(target)
+}
+
+// To aplica o revierte migraciones hasta que la base quede en la versión indicada (0 revierte
+// todas) y devuelve cuántas aplicó o revirtió. Cada migración corre en su propia transacción.
+func (m *Migrator) To(version int) (int, error) {
+	if version != 0 && m.find(version) == nil {
+		return 0, fmt.Errorf("%w %d", ErrUnknownVersion, version)
+	}
+	if err := m.Check(); err != nil {
+		return 0, err
+	}
+	applied, err := m.applied()
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	// Aplicar en orden las pendientes hasta la versión
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; ok || migration.Version > version {
+			continue
+		}
+		if err := m.run(migration, true); err != nil {
+			return count, err
+		}
+		count++
+	}
+	// Revertir, de la más nueva a la más vieja, las aplicadas posteriores a la versión
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		migration := m.migrations[i]
+		if _, ok := applied[migration.Version]; !ok || migration.Version <= version {
+			continue
+		}
+		if err := m.run(migration, false); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+// run aplica (up) o revierte una migración y lo registra en schema_migrations, todo en una
+// transacción: si falla, la base queda como estaba
+func (m *Migrator) run(migration Migration, up bool) error {
+	direction := "down"
+	if up {
+		direction = "up"
+	}
+
+	err := m.db.Transaction(func(tx *gorm.DB) error {
+		if m.dialect == "postgres" {
+			if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", advisoryLockKey).Error; err != nil {
+				return err
+			}
+		}
+
+		// Otra instancia pudo haberla aplicado o revertido mientras esperábamos el lock
+		var count int64
+		if err := tx.Model(&schemaMigration{}).Where("version = ?", migration.Version).Count(&count).Error; err != nil {
+			return err
+		}
+		if (count > 0) == up {
+			return nil
+		}
+
+		step := migration.Down
+		if up {
+			step = migration.Up
+		}
+		if err := step(tx, m.dialect); err != nil {
+			return err
+		}
+
+		if !up {
+			return tx.Where("version = ?", migration.Version).Delete(&schemaMigration{}).Error
+		}
+		return tx.Create(&schemaMigration{Version: migration.Version, Name: migration.Name, AppliedAt: time.Now().UTC()}).Error
+	})
+	if err != nil {
+		return fmt.Errorf("migration %d %s (%s): %w", migration.Version, migration.Name, direction, err)
+	}
+	log.Printf("Migration %d %s %s", migration.Version, migration.Name, direction)
+	return nil
+}
+
+// applied devuelve las migraciones aplicadas en la base, por versión
+func (m *Migrator) applied() (map[int]schemaMigration, error) {
+	var rows []schemaMigration
+	if err := m.db.Order("version").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	applied := make(map[int]schemaMigration, len(rows))
+	for _, row := range rows {
+		applied[row.Version] = row
+	}
+	return applied, nil
+}
+
+func (m *Migrator) find(version int) *Migration {
+	for i := range m.migrations {
+		if m.migrations[i].Version == version {
+			return &m.migrations[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS "reminder_checkpoints" CASCADE;
+DROP TABLE IF EXISTS "notification_deliveries" CASCADE;
+DROP TABLE IF EXISTS "event_members" CASCADE;
+DROP TABLE IF EXISTS "event_children" CASCADE;
+DROP TABLE IF EXISTS "events" CASCADE;
+DROP TABLE IF EXISTS "children" CASCADE;
+DROP TABLE IF EXISTS "members" CASCADE;
+DROP TABLE IF EXISTS "households" CASCADE;
@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS `reminder_checkpoints`;
+DROP TABLE IF EXISTS `notification_deliveries`;
+DROP TABLE IF EXISTS `event_members`;
+DROP TABLE IF EXISTS `event_children`;
+DROP TABLE IF EXISTS `events`;
+DROP TABLE IF EXISTS `children`;
+DROP TABLE IF EXISTS `members`;
+DROP TABLE IF EXISTS `households`;
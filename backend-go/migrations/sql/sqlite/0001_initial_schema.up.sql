@@ -0,0 +1,29 @@
+-- Esquema inicial: el que creaba AutoMigrate. Con IF NOT EXISTS, adopta también las bases
+-- creadas por AutoMigrate antes de las migraciones versionadas.
+
+CREATE TABLE IF NOT EXISTS `households` (`id` integer PRIMARY KEY AUTOINCREMENT,`name` text NOT NULL,`email` text,`created_at` datetime,`updated_at` datetime,`deleted_at` datetime);
+CREATE INDEX IF NOT EXISTS `idx_households_deleted_at` ON `households`(`deleted_at`);
+CREATE INDEX IF NOT EXISTS `idx_households_email` ON `households`(`email`);
+
+CREATE TABLE IF NOT EXISTS `members` (`id` integer PRIMARY KEY AUTOINCREMENT,`household_id` integer NOT NULL,`name` text NOT NULL,`email` text,`phone` text,`role` text,`created_at` datetime,`updated_at` datetime,`deleted_at` datetime,CONSTRAINT `fk_households_members` FOREIGN KEY (`household_id`) REFERENCES `households`(`id`));
+CREATE INDEX IF NOT EXISTS `idx_members_deleted_at` ON `members`(`deleted_at`);
+CREATE INDEX IF NOT EXISTS `idx_members_household_id` ON `members`(`household_id`);
+
+CREATE TABLE IF NOT EXISTS `children` (`id` integer PRIMARY KEY AUTOINCREMENT,`household_id` integer NOT NULL,`name` text NOT NULL,`birth_date` datetime,`color` text,`created_at` datetime,`updated_at` datetime,`deleted_at` datetime,CONSTRAINT `fk_households_children` FOREIGN KEY (`household_id`) REFERENCES `households`(`id`));
+CREATE INDEX IF NOT EXISTS `idx_children_household_id` ON `children`(`household_id`);
+CREATE INDEX IF NOT EXISTS `idx_children_deleted_at` ON `children`(`deleted_at`);
+
+CREATE TABLE IF NOT EXISTS `events` (`id` integer PRIMARY KEY AUTOINCREMENT,`title` text NOT NULL,`description` text,`date` datetime NOT NULL,`time` text,`location` text,`email` text NOT NULL,`phone` text NOT NULL,`reminder_day` numeric DEFAULT true,`reminder_day_before` numeric DEFAULT true,`is_all_day` numeric DEFAULT false,`color` text DEFAULT '#007AFF',`priority` text DEFAULT 'medium',`category` text,`household_id` integer,`created_at` datetime,`updated_at` datetime,`deleted_at` datetime);
+CREATE INDEX IF NOT EXISTS `idx_events_deleted_at` ON `events`(`deleted_at`);
+CREATE INDEX IF NOT EXISTS `idx_events_household_id` ON `events`(`household_id`);
+
+CREATE TABLE IF NOT EXISTS `event_children` (`event_id` integer,`child_id` integer,PRIMARY KEY (`event_id`,`child_id`),CONSTRAINT `fk_event_children_event` FOREIGN KEY (`event_id`) REFERENCES `events`(`id`),CONSTRAINT `fk_event_children_child` FOREIGN KEY (`child_id`) REFERENCES `children`(`id`));
+CREATE TABLE IF NOT EXISTS `event_members` (`event_id` integer,`member_id` integer,PRIMARY KEY (`event_id`,`member_id`),CONSTRAINT `fk_event_members_event` FOREIGN KEY (`event_id`) REFERENCES `events`(`id`),CONSTRAINT `fk_event_members_member` FOREIGN KEY (`member_id`) REFERENCES `members`(`id`));
+
+CREATE TABLE IF NOT EXISTS `notification_deliveries` (`id` integer PRIMARY KEY AUTOINCREMENT,`dedup_key` text NOT NULL,`event_id` integer NOT NULL,`member_id` integer,`reminder_type` text NOT NULL,`channel` text NOT NULL,`recipient` text NOT NULL,`recipient_name` text,`subject` text,`body` text,`text` text,`scheduled_at` datetime,`status` text NOT NULL DEFAULT 'pending',`attempts` integer NOT NULL DEFAULT 0,`last_error` text,`next_attempt_at` datetime,`provider_message_id` text,`sent_at` datetime,`created_at` datetime,`updated_at` datetime);
+CREATE INDEX IF NOT EXISTS `idx_notification_deliveries_event_id` ON `notification_deliveries`(`event_id`);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_notification_deliveries_dedup_key` ON `notification_deliveries`(`dedup_key`);
+CREATE INDEX IF NOT EXISTS `idx_notification_deliveries_status` ON `notification_deliveries`(`status`);
+CREATE INDEX IF NOT EXISTS `idx_notification_deliveries_scheduled_at` ON `notification_deliveries`(`scheduled_at`);
+
+CREATE TABLE IF NOT EXISTS `reminder_checkpoints` (`name` text,`processed_until` datetime,`updated_at` datetime,PRIMARY KEY (`name`));
@@ -0,0 +1,8 @@
+-- Vuelve a agregar las columnas de la notificación familiar, vacías: sus datos ya están en
+-- los hogares (migración 2)
+ALTER TABLE `events` ADD COLUMN `notify_family` numeric DEFAULT false;
+ALTER TABLE `events` ADD COLUMN `notify_papa` numeric DEFAULT false;
+ALTER TABLE `events` ADD COLUMN `notify_mama` numeric DEFAULT false;
+ALTER TABLE `events` ADD COLUMN `child_tag` text;
+ALTER TABLE `events` ADD COLUMN `selected_children` text;
+ALTER TABLE `events` ADD COLUMN `family_members` text;
@@ -23,15 +23,12 @@ type Event struct {
 	Priority string `json:"priority" gorm:"default:'medium'"` // Prioridad: low, medium, high
 	Category string `json:"category"`                         // Categoría del evento
 	// Campos de notificación familiar
-	NotifyFamily     bool           `json:"notify_family" gorm:"default:false"` // Notificar a la familia
-	NotifyPapa       bool           `json:"notify_papa" gorm:"default:false"`   // Notificar al papá
-	NotifyMama       bool           `json:"notify_mama" gorm:"default:false"`   // Notificar a la mamá
-	ChildTag         string         `json:"child_tag"`                          // Etiqueta del hijo (deprecated)
-	SelectedChildren string         `json:"selected_children" gorm:"type:text"` // JSON array de hijos seleccionados
-	FamilyMembers    string         `json:"family_members" gorm:"type:text"`    // JSON de miembros de familia
-	CreatedAt        time.Time      `json:"created_at"`
-	UpdatedAt        time.Time      `json:"updated_at"`
-	DeletedAt        gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+	HouseholdID *uint          `json:"household_id" gorm:"index"`                 // Hogar al que pertenece el evento
+	Members     []Member       `json:"members" gorm:"many2many:event_members;"`   // Miembros a notificar
+	Children    []Child        `json:"children" gorm:"many2many:event_children;"` // Hijos para los que es el evento
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
 }
 
 type CreateEventRequest struct {
@@ -48,12 +45,9 @@ type CreateEventRequest struct {
 	Color             string `json:"color"`
 	Priority          string `json:"priority"`
 	Category          string `json:"category"`
-	NotifyFamily      bool   `json:"notify_family"`
-	NotifyPapa        bool   `json:"notify_papa"`
-	NotifyMama        bool   `json:"notify_mama"`
-	ChildTag          string `json:"child_tag"`
-	SelectedChildren  string `json:"selected_children"`
-	FamilyMembers     string `json:"family_members"`
+	HouseholdID       *uint  `json:"household_id"`
+	MemberIDs         []uint `json:"member_ids"`
+	ChildIDs          []uint `json:"child_ids"`
 }
 
 type UpdateEventRequest struct {
@@ -72,12 +66,9 @@ type UpdateEventRequest struct {
 	Priority *string `json:"priority"`
 	Category *string `json:"category"`
 	// Campos de notificación familiar
-	NotifyFamily     *bool   `json:"notify_family"`
-	NotifyPapa       *bool   `json:"notify_papa"`
-	NotifyMama       *bool   `json:"notify_mama"`
-	ChildTag         *string `json:"child_tag"`
-	SelectedChildren *string `json:"selected_children"`
-	FamilyMembers    *string `json:"family_members"`
+	HouseholdID *uint   `json:"household_id"`
+	MemberIDs   *[]uint `json:"member_ids"`
+	ChildIDs    *[]uint `json:"child_ids"`
 }
 
 // EventResponse es la respuesta optimizada para apps móviles
@@ -97,14 +88,11 @@ type EventResponse struct {
 	Priority          string `json:"priority"`
 	Category          string `json:"category"`
 	// Campos de notificación familiar
-	NotifyFamily     bool      `json:"notify_family"`
-	NotifyPapa       bool      `json:"notify_papa"`
-	NotifyMama       bool      `json:"notify_mama"`
-	ChildTag         string    `json:"child_tag"`
-	SelectedChildren string    `json:"selected_children"`
-	FamilyMembers    string    `json:"family_members"`
-	CreatedAt        time.Time `json:"created_at"`
-	UpdatedAt        time.Time `json:"updated_at"`
+	HouseholdID *uint     `json:"household_id"`
+	Members     []Member  `json:"members"`
+	Children    []Child   `json:"children"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
 }
 
 // ToResponse convierte un Event a EventResponse
@@ -124,12 +112,9 @@ func (e *Event) ToResponse() EventResponse {
 		Color:             e.Color,
 		Priority:          e.Priority,
 		Category:          e.Category,
-		NotifyFamily:      e.NotifyFamily,
-		NotifyPapa:        e.NotifyPapa,
-		NotifyMama:        e.NotifyMama,
-		ChildTag:          e.ChildTag,
-		SelectedChildren:  e.SelectedChildren,
-		FamilyMembers:     e.FamilyMembers,
+		HouseholdID:       e.HouseholdID,
+		Members:           e.Members,
+		Children:          e.Children,
 		CreatedAt:         e.CreatedAt,
 		UpdatedAt:         e.UpdatedAt,
 	}
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Household agrupa a los miembros adultos y a los hijos de una familia
+type Household struct {
+	ID        uint           `json:"id" gorm:"primaryKey"`
+	Name      string         `json:"name" gorm:"not null"`
+	Email     string         `json:"email" gorm:"index"` // Email de contacto del dueño del hogar
+	Members   []Member       `json:"members,omitempty"`
+	Children  []Child        `json:"children,omitempty"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+}
+
+// Member es un adulto del hogar que puede recibir notificaciones
+type Member struct {
+	ID          uint           `json:"id" gorm:"primaryKey"`
+	HouseholdID uint           `json:"household_id" gorm:"index;not null"`
+	Name        string         `json:"name" gorm:"not null"`
+	Email       string         `json:"email"`
+	Phone       string         `json:"phone"`
+	Role        string         `json:"role"` // Rol libre: "papá", "abuela", "niñera", etc.
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+}
+
+// Child es un hijo del hogar al que se le pueden asignar eventos
+type Child struct {
+	ID          uint           `json:"id" gorm:"primaryKey"`
+	HouseholdID uint           `json:"household_id" gorm:"index;not null"`
+	Name        string         `json:"name" gorm:"not null"`
+	BirthDate   *time.Time     `json:"birth_date,omitempty"`
+	Color       string         `json:"color"` // Color para distinguir sus eventos
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+)
+
+// Estados de una entrega del outbox de notificaciones
+const (
+	DeliveryStatusPending = "pending" // En cola (o esperando un reintento), a cargo del worker
+	DeliveryStatusSending = "sending" // Tomada por el worker
+	DeliveryStatusSent    = "sent"    // Entregada al proveedor
+	DeliveryStatusDead    = "dead"    // Falló definitivamente; se puede volver a encolar a mano
+)
+
+// NotificationDelivery es un recordatorio para un destinatario por un canal.
+// El scheduler las encola y el worker del outbox las envía; DedupKey garantiza
+// que el mismo recordatorio no se encole (ni se envíe) dos veces.
+type NotificationDelivery struct {
+	ID                uint       `json:"id" gorm:"primaryKey"`
+	DedupKey          string     `json:"dedup_key" gorm:"uniqueIndex;not null"`
+	EventID           uint       `json:"event_id" gorm:"index;not null"`
+	MemberID          *uint      `json:"member_id"`                     // Miembro del hogar destinatario; nil si es el contacto del evento
+	ReminderType      string     `json:"reminder_type" gorm:"not null"` // day_before, same_day
+	Channel           string     `json:"channel" gorm:"not null"`       // email, whatsapp, ...
+	Recipient         string     `json:"recipient" gorm:"not null"`     // Dirección en el canal (email o teléfono)
+	RecipientName     string     `json:"recipient_name"`
+	Subject           string     `json:"subject"`
+	Body              string     `json:"body"` // Texto largo (email)
+	Text              string     `json:"text"` // Texto corto (WhatsApp)
+	ScheduledAt       time.Time  `json:"scheduled_at" gorm:"index"`
+	Status            string     `json:"status" gorm:"index;not null;default:'pending'"`
+	Attempts          int        `json:"attempts" gorm:"not null;default:0"`
+	LastError         string     `json:"last_error"`
+	NextAttemptAt     *time.Time `json:"next_attempt_at"` // Próximo reintento; nil hasta el primer fallo
+	ProviderMessageID string     `json:"provider_message_id"`
+	SentAt            *time.Time `json:"sent_at"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
+
+// DeliveryFilter filtra el historial de entregas; los campos vacíos no filtran
+type DeliveryFilter struct {
+	ID      uint
+	EventID uint
+	Status  string
+	Channel string
+	Limit   int
+}
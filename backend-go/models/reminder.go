@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// Anticipación de los recordatorios respecto del inicio del evento
+const (
+	SameDayReminderOffset         = time.Hour      // reminder_day: una hora antes
+	DayBeforeReminderOffset       = 24 * time.Hour // reminder_day_before: un día antes
+	AllDayDayBeforeReminderOffset = 15 * time.Hour // reminder_day_before de todo el día: 9:00 del día anterior
+)
+
+// EventReminder es un recordatorio del evento con su instante exacto de envío
+type EventReminder struct {
+	Type  string // "same_day" o "day_before"
+	DueAt time.Time
+}
+
+// StartsAt combina Date y Time (UTC); los eventos de todo el día empiezan a medianoche
+func (e *Event) StartsAt() time.Time {
+	start := time.Date(e.Date.Year(), e.Date.Month(), e.Date.Day(), 0, 0, 0, 0, time.UTC)
+	if e.IsAllDay || e.Time == "" {
+		return start
+	}
+	clock, err := time.Parse("15:04", e.Time)
+	if err != nil {
+		return start
+	}
+	return start.Add(time.Duration(clock.Hour())*time.Hour + time.Duration(clock.Minute())*time.Minute)
+}
+
+// Reminders devuelve los recordatorios activos del evento. Los eventos de todo el día
+// no tienen recordatorio el mismo día: empiezan a medianoche.
+func (e *Event) Reminders() []EventReminder {
+	startsAt := e.StartsAt()
+
+	var reminders []EventReminder
+	if e.ReminderDayBefore {
+		offset := DayBeforeReminderOffset
+		if e.IsAllDay {
+			offset = AllDayDayBeforeReminderOffset
+		}
+		reminders = append(reminders, EventReminder{Type: "day_before", DueAt: startsAt.Add(-offset)})
+	}
+	if e.ReminderDay && !e.IsAllDay {
+		reminders = append(reminders, EventReminder{Type: "same_day", DueAt: startsAt.Add(-SameDayReminderOffset)})
+	}
+	return reminders
+}
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// ReminderCheckpoint guarda hasta qué instante el motor de recordatorios ya procesó los envíos,
+// para recuperar al arrancar los que vencieron con el proceso detenido
+type ReminderCheckpoint struct {
+	Name           string    `json:"name" gorm:"primaryKey"`
+	ProcessedUntil time.Time `json:"processed_until"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
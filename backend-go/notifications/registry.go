@@ -0,0 +1,122 @@
+package notifications
+
+import (
+	"calendar-backend/config"
+	"errors"
+	"log"
+	"strings"
+	"sync"
+)
+
+// Factory construye un canal a partir de la configuración
+type Factory func(cfg *config.Config) (Channel, error)
+
+var (
+	factoriesMu sync.RWMutex
+	factories   = make(map[string]Factory)
+)
+
+// RegisterFactory registra un proveedor de canal para que pueda habilitarse por configuración
+func RegisterFactory(name string, factory Factory) {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+	factories[strings.ToLower(name)] = factory
+}
+
+func init() {
+	RegisterFactory("email", NewSendGridChannel)
+	RegisterFactory("whatsapp", NewTwilioWhatsAppChannel)
+}
+
+// Registry guarda los canales habilitados, en el orden de la configuración,
+// y la política de reintentos de cada uno
+type Registry struct {
+	channels []Channel
+	policies map[string]RetryPolicy
+}
+
+// NewRegistry crea un registro con los canales dados
+func NewRegistry(channels ...Channel) *Registry {
+	return &Registry{
+		channels: channels,
+		policies: make(map[string]RetryPolicy),
+	}
+}
+
+// NewRegistryFromConfig habilita los canales listados en cfg.NotificationChannels.
+// Los canales sin credenciales o desconocidos se omiten con un aviso en el log.
+func NewRegistryFromConfig(cfg *config.Config) *Registry {
+	registry := NewRegistry()
+
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+
+	for _, name := range cfg.NotificationChannels {
+		factory, ok := factories[name]
+		if !ok {
+			log.Printf("⚠️ Unknown notification channel %q, skipping", name)
+			continue
+		}
+
+		channel, err := factory(cfg)
+		if errors.Is(err, ErrNotConfigured) {
+			log.Printf("⚠️ Notification channel %q is not configured, skipping", name)
+			continue
+		}
+		if err != nil {
+			log.Printf("❌ Failed to create notification channel %q: %v", name, err)
+			continue
+		}
+
+		registry.Register(channel)
+		if policy, ok := cfg.NotificationRetry[name]; ok {
+			registry.SetRetryPolicy(name, RetryPolicy{
+				MaxAttempts: policy.MaxAttempts,
+				BaseDelay:   policy.BaseDelay,
+				MaxDelay:    policy.MaxDelay,
+			})
+		}
+		log.Printf("✅ Notification channel %q enabled", name)
+	}
+
+	return registry
+}
+
+// Register agrega un canal al registro (reemplaza a otro con el mismo nombre)
+func (r *Registry) Register(channel Channel) {
+	for i, existing := range r.channels {
+		if existing.Name() == channel.Name() {
+			r.channels[i] = channel
+			return
+		}
+	}
+	r.channels = append(r.channels, channel)
+}
+
+// Get devuelve el canal con ese nombre, si está habilitado
+func (r *Registry) Get(name string) (Channel, bool) {
+	for _, channel := range r.channels {
+		if channel.Name() == name {
+			return channel, true
+		}
+	}
+	return nil, false
+}
+
+// Channels devuelve los canales habilitados
+func (r *Registry) Channels() []Channel {
+	return r.channels
+}
+
+// SetRetryPolicy define la política de reintentos del canal
+func (r *Registry) SetRetryPolicy(name string, policy RetryPolicy) {
+	r.policies[name] = policy
+}
+
+// RetryPolicy devuelve la política de reintentos del canal, o DefaultRetryPolicy si no tiene una
+func (r *Registry) RetryPolicy(name string) RetryPolicy {
+	if policy, ok := r.policies[name]; ok {
+		return policy
+	}
+	return DefaultRetryPolicy
+}
@@ -0,0 +1,78 @@
+package notifications
+
+import (
+	"errors"
+	"math/rand"
+	"time"
+)
+
+// RetryPolicy define cuántas veces se reintenta un envío fallido y cuánto se espera entre intentos
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// DefaultRetryPolicy se usa para los canales sin política configurada
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 5,
+	BaseDelay:   30 * time.Second,
+	MaxDelay:    time.Hour,
+}
+
+// Backoff devuelve la espera antes del próximo intento, luego de attempts intentos fallidos.
+// Crece exponencialmente desde BaseDelay hasta MaxDelay, con jitter entre la mitad y el total
+// para que los reintentos de muchos mensajes no lleguen juntos al proveedor.
+func (p RetryPolicy) Backoff(attempts int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempts && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(delay-half)+1))
+}
+
+// Exhausted indica si ya no quedan intentos
+func (p RetryPolicy) Exhausted(attempts int) bool {
+	return attempts >= p.MaxAttempts
+}
+
+// PermanentError es un error que no se soluciona reintentando (dirección inválida, credenciales rechazadas, ...)
+type PermanentError struct {
+	Err error
+}
+
+func (e *PermanentError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PermanentError) Unwrap() error {
+	return e.Err
+}
+
+// Permanent marca el error como no reintentable
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &PermanentError{Err: err}
+}
+
+// IsPermanent indica si el envío no debe reintentarse
+func IsPermanent(err error) bool {
+	var permanent *PermanentError
+	return errors.As(err, &permanent) || errors.Is(err, ErrNoAddress)
+}
+
+// isPermanentStatus indica si un status HTTP del proveedor no se soluciona reintentando:
+// los 4xx salvo 408 (timeout) y 429 (rate limit)
+func isPermanentStatus(status int) bool {
+	return status >= 400 && status < 500 && status != 408 && status != 429
+}
@@ -0,0 +1,71 @@
+package notifications
+
+import (
+	"calendar-backend/config"
+	"context"
+	"fmt"
+
+	"github.com/sendgrid/sendgrid-go"
+	"github.com/sendgrid/sendgrid-go/helpers/mail"
+)
+
+// SendGridChannel envía notificaciones por email usando SendGrid
+type SendGridChannel struct {
+	client *sendgrid.Client
+	from   *mail.Email
+}
+
+// NewSendGridChannel crea el canal de email; el cliente se reutiliza en cada envío
+func NewSendGridChannel(cfg *config.Config) (Channel, error) {
+	if cfg.SendGridAPIKey == "" {
+		return nil, ErrNotConfigured
+	}
+	return &SendGridChannel{
+		client: sendgrid.NewSendClient(cfg.SendGridAPIKey),
+		from:   mail.NewEmail("Calendar Reminder", cfg.FromEmail),
+	}, nil
+}
+
+func (c *SendGridChannel) Name() string {
+	return "email"
+}
+
+func (c *SendGridChannel) Address(recipient Recipient) string {
+	return recipient.Email
+}
+
+func (c *SendGridChannel) Send(ctx context.Context, recipient Recipient, message Message) error {
+	_, err := c.SendWithReceipt(ctx, recipient, message)
+	return err
+}
+
+// SendWithReceipt envía el email y devuelve el X-Message-Id de SendGrid
+func (c *SendGridChannel) SendWithReceipt(ctx context.Context, recipient Recipient, message Message) (string, error) {
+	if recipient.Email == "" {
+		return "", ErrNoAddress
+	}
+
+	name := recipient.Name
+	if name == "" {
+		name = "User"
+	}
+	email := mail.NewSingleEmail(c.from, message.Subject, mail.NewEmail(name, recipient.Email), message.Body, message.Body)
+
+	response, err := c.client.SendWithContext(ctx, email)
+	if err != nil {
+		return "", fmt.Errorf("failed to send email: %v", err)
+	}
+	if response.StatusCode >= 300 {
+		err := fmt.Errorf("failed to send email: sendgrid returned status %d: %s", response.StatusCode, response.Body)
+		if isPermanentStatus(response.StatusCode) {
+			return "", Permanent(err)
+		}
+		return "", err
+	}
+
+	var messageID string
+	if ids := response.Headers["X-Message-Id"]; len(ids) > 0 {
+		messageID = ids[0]
+	}
+	return messageID, nil
+}
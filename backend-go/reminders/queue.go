@@ -0,0 +1,37 @@
+package reminders
+
+import "container/heap"
+
+// queue es un min-heap de recordatorios ordenado por DueAt
+type queue []Reminder
+
+func (q queue) Len() int           { return len(q) }
+func (q queue) Less(i, j int) bool { return q[i].DueAt.Before(q[j].DueAt) }
+func (q queue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+
+func (q *queue) Push(x any) {
+	*q = append(*q, x.(Reminder))
+}
+
+func (q *queue) Pop() any {
+	old := *q
+	n := len(old)
+	item := old[n-1]
+	*q = old[:n-1]
+	return item
+}
+
+// newQueue arma el heap a partir de una lista de recordatorios
+func newQueue(reminders []Reminder) *queue {
+	q := queue(reminders)
+	heap.Init(&q)
+	return &q
+}
+
+// peek devuelve el próximo recordatorio sin sacarlo del heap
+func (q queue) peek() (Reminder, bool) {
+	if len(q) == 0 {
+		return Reminder{}, false
+	}
+	return q[0], true
+}
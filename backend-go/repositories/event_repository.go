@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type EventRepository interface {
@@ -20,6 +21,7 @@ type EventRepository interface {
 	GetEventsByDateRange(startDate, endDate time.Time) ([]*models.Event, error)
 	SearchEvents(query string) ([]models.Event, error)
 	GetEventStats() (map[string]interface{}, error)
+	ReplaceRecipients(event *models.Event) error
 }
 
 type eventRepository struct {
@@ -34,9 +36,14 @@ func (r *eventRepository) Create(event *models.Event) error {
 	return r.db.Create(event).Error
 }
 
+// withRecipients precarga los miembros y los hijos vinculados a los eventos
+func (r *eventRepository) withRecipients() *gorm.DB {
+	return r.db.Preload("Members").Preload("Children")
+}
+
 func (r *eventRepository) GetByID(id uint) (*models.Event, error) {
 	var event models.Event
-	err := r.db.First(&event, id).Error
+	err := r.withRecipients().First(&event, id).Error
 	if err != nil {
 		return nil, err
 	}
@@ -45,18 +52,28 @@ func (r *eventRepository) GetByID(id uint) (*models.Event, error) {
 
 func (r *eventRepository) GetAll() ([]models.Event, error) {
 	var events []models.Event
-	err := r.db.Order("date ASC, time ASC").Find(&events).Error
+	err := r.withRecipients().Order("date ASC, time ASC").Find(&events).Error
 	return events, err
 }
 
 func (r *eventRepository) GetByDate(date string) ([]models.Event, error) {
 	var events []models.Event
-	err := r.db.Where("date = ?", date).Order("time ASC").Find(&events).Error
+	err := r.withRecipients().Where("date = ?", date).Order("time ASC").Find(&events).Error
 	return events, err
 }
 
 func (r *eventRepository) Update(id uint, event *models.Event) error {
-	return r.db.Model(&models.Event{}).Where("id = ?", id).Updates(event).Error
+	return r.db.Model(&models.Event{}).Where("id = ?", id).Omit(clause.Associations).Updates(event).Error
+}
+
+// ReplaceRecipients reemplaza los miembros y los hijos vinculados al evento
+func (r *eventRepository) ReplaceRecipients(event *models.Event) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(event).Association("Members").Replace(event.Members); err != nil {
+			return err
+		}
+		return tx.Model(event).Association("Children").Replace(event.Children)
+	})
 }
 
 func (r *eventRepository) Delete(id uint) error {
@@ -66,26 +83,26 @@ func (r *eventRepository) Delete(id uint) error {
 func (r *eventRepository) GetTodayEvents() ([]models.Event, error) {
 	today := time.Now().Format("2006-01-02")
 	var events []models.Event
-	err := r.db.Where("date = ?", today).Order("time ASC").Find(&events).Error
+	err := r.withRecipients().Where("date = ?", today).Order("time ASC").Find(&events).Error
 	return events, err
 }
 
 func (r *eventRepository) GetUpcomingEvents() ([]models.Event, error) {
 	today := time.Now().Format("2006-01-02")
 	var events []models.Event
-	err := r.db.Where("date >= ?", today).Order("date ASC, time ASC").Limit(10).Find(&events).Error
+	err := r.withRecipients().Where("date >= ?", today).Order("date ASC, time ASC").Limit(10).Find(&events).Error
 	return events, err
 }
 
 func (r *eventRepository) GetEventsForDateRange(startDate, endDate string) ([]models.Event, error) {
 	var events []models.Event
-	err := r.db.Where("date BETWEEN ? AND ?", startDate, endDate).Order("date ASC, time ASC").Find(&events).Error
+	err := r.withRecipients().Where("date BETWEEN ? AND ?", startDate, endDate).Order("date ASC, time ASC").Find(&events).Error
 	return events, err
 }
 
 func (r *eventRepository) SearchEvents(query string) ([]models.Event, error) {
 	var events []models.Event
-	err := r.db.Where("title ILIKE ? OR description ILIKE ?", "%"+query+"%", "%"+query+"%").Order("date ASC, time ASC").Find(&events).Error
+	err := r.withRecipients().Where("title ILIKE ? OR description ILIKE ?", "%"+query+"%", "%"+query+"%").Order("date ASC, time ASC").Find(&events).Error
 	return events, err
 }
 
@@ -118,7 +135,8 @@ func (r *eventRepository) GetEventStats() (map[string]interface{}, error) {
 func (r *eventRepository) GetEventsByDateRange(startDate, endDate time.Time) ([]*models.Event, error) {
 	var events []*models.Event
 	
-	err := r.db.Where("date >= ? AND date < ?", startDate, endDate).Find(&events).Error
+	// Los destinatarios se resuelven desde la base al enviar las notificaciones
+	err := r.withRecipients().Where("date >= ? AND date < ?", startDate, endDate).Find(&events).Error
 	if err != nil {
 		return nil, err
 	}
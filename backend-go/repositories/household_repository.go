@@ -0,0 +1,145 @@
+package repositories
+
+import (
+	"calendar-backend/models"
+
+	"gorm.io/gorm"
+)
+
+type HouseholdRepository interface {
+	CreateHousehold(household *models.Household) error
+	GetHousehold(id uint) (*models.Household, error)
+	GetHouseholds(email string) ([]models.Household, error)
+	UpdateHousehold(household *models.Household) error
+	DeleteHousehold(id uint) error
+
+	CreateMember(member *models.Member) error
+	GetMember(householdID, id uint) (*models.Member, error)
+	UpdateMember(member *models.Member) error
+	DeleteMember(householdID, id uint) error
+	GetMembersByIDs(householdID uint, ids []uint) ([]models.Member, error)
+
+	CreateChild(child *models.Child) error
+	GetChild(householdID, id uint) (*models.Child, error)
+	UpdateChild(child *models.Child) error
+	DeleteChild(householdID, id uint) error
+	GetChildrenByIDs(householdID uint, ids []uint) ([]models.Child, error)
+}
+
+type householdRepository struct {
+	db *gorm.DB
+}
+
+func NewHouseholdRepository(db *gorm.DB) HouseholdRepository {
+	return &householdRepository{db: db}
+}
+
+func (r *householdRepository) CreateHousehold(household *models.Household) error {
+	return r.db.Create(household).Error
+}
+
+func (r *householdRepository) GetHousehold(id uint) (*models.Household, error) {
+	var household models.Household
+	err := r.db.Preload("Members").Preload("Children").First(&household, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &household, nil
+}
+
+func (r *householdRepository) GetHouseholds(email string) ([]models.Household, error) {
+	var households []models.Household
+	query := r.db.Preload("Members").Preload("Children").Order("name ASC")
+	if email != "" {
+		query = query.Where("email = ?", email)
+	}
+	err := query.Find(&households).Error
+	return households, err
+}
+
+func (r *householdRepository) UpdateHousehold(household *models.Household) error {
+	return r.db.Model(household).Select("name", "email").Updates(household).Error
+}
+
+// DeleteHousehold deletes the household with its members and children and
+// detaches its events
+func (r *householdRepository) DeleteHousehold(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Event{}).Where("household_id = ?", id).Update("household_id", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("household_id = ?", id).Delete(&models.Member{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("household_id = ?", id).Delete(&models.Child{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Household{}, id).Error
+	})
+}
+
+func (r *householdRepository) CreateMember(member *models.Member) error {
+	return r.db.Create(member).Error
+}
+
+func (r *householdRepository) GetMember(householdID, id uint) (*models.Member, error) {
+	var member models.Member
+	err := r.db.Where("household_id = ?", householdID).First(&member, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+func (r *householdRepository) UpdateMember(member *models.Member) error {
+	return r.db.Model(member).Select("name", "email", "phone", "role").Updates(member).Error
+}
+
+// DeleteMember deletes the member and unlinks it from its events
+func (r *householdRepository) DeleteMember(householdID, id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM event_members WHERE member_id = ?", id).Error; err != nil {
+			return err
+		}
+		return tx.Where("household_id = ?", householdID).Delete(&models.Member{}, id).Error
+	})
+}
+
+func (r *householdRepository) GetMembersByIDs(householdID uint, ids []uint) ([]models.Member, error) {
+	var members []models.Member
+	err := r.db.Where("household_id = ? AND id IN ?", householdID, ids).Find(&members).Error
+	return members, err
+}
+
+func (r *householdRepository) CreateChild(child *models.Child) error {
+	return r.db.Create(child).Error
+}
+
+func (r *householdRepository) GetChild(householdID, id uint) (*models.Child, error) {
+	var child models.Child
+	err := r.db.Where("household_id = ?", householdID).First(&child, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &child, nil
+}
+
+func (r *householdRepository) UpdateChild(child *models.Child) error {
+	return r.db.Model(child).Select("name", "birth_date", "color").Updates(child).Error
+}
+
+// DeleteChild deletes the child and unlinks it from its events
+func (r *householdRepository) DeleteChild(householdID, id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM event_children WHERE child_id = ?", id).Error; err != nil {
+			return err
+		}
+		return tx.Where("household_id = ?", householdID).Delete(&models.Child{}, id).Error
+	})
+}
+
+func (r *householdRepository) GetChildrenByIDs(householdID uint, ids []uint) ([]models.Child, error) {
+	var children []models.Child
+	err := r.db.Where("household_id = ? AND id IN ?", householdID, ids).Find(&children).Error
+	return children, err
+}
@@ -0,0 +1,141 @@
+package repositories
+
+import (
+	"calendar-backend/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type NotificationDeliveryRepository interface {
+	Enqueue(delivery *models.NotificationDelivery) (bool, error)
+	GetPending(limit int) ([]models.NotificationDelivery, error)
+	Claim(id uint) (bool, error)
+	MarkSent(id uint, providerMessageID string) error
+	MarkRetry(id uint, lastError string, nextAttemptAt time.Time) error
+	MarkDead(id uint, lastError string) error
+	ReleaseClaimed() (int64, error)
+	List(filter models.DeliveryFilter) ([]models.NotificationDelivery, error)
+	Redrive(filter models.DeliveryFilter) (int64, error)
+}
+
+type notificationDeliveryRepository struct {
+	db *gorm.DB
+}
+
+func NewNotificationDeliveryRepository(db *gorm.DB) NotificationDeliveryRepository {
+	return &notificationDeliveryRepository{db: db}
+}
+
+// Enqueue inserts the delivery unless one with the same dedup key exists.
+// It reports whether a new row was created.
+func (r *notificationDeliveryRepository) Enqueue(delivery *models.NotificationDelivery) (bool, error) {
+	delivery.Status = models.DeliveryStatusPending
+	result := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "dedup_key"}},
+		DoNothing: true,
+	}).Create(delivery)
+	return result.RowsAffected > 0, result.Error
+}
+
+// GetPending returns the oldest pending deliveries that are due and not
+// waiting for a retry
+func (r *notificationDeliveryRepository) GetPending(limit int) ([]models.NotificationDelivery, error) {
+	var deliveries []models.NotificationDelivery
+	now := time.Now()
+	err := r.db.Where("status = ? AND scheduled_at <= ?", models.DeliveryStatusPending, now).
+		Where("next_attempt_at IS NULL OR next_attempt_at <= ?", now).
+		Order("scheduled_at ASC, id ASC").
+		Limit(limit).
+		Find(&deliveries).Error
+	return deliveries, err
+}
+
+// Claim moves a pending delivery to "sending" and counts the attempt. It
+// returns false when another worker claimed it first.
+func (r *notificationDeliveryRepository) Claim(id uint) (bool, error) {
+	result := r.db.Model(&models.NotificationDelivery{}).
+		Where("id = ? AND status = ?", id, models.DeliveryStatusPending).
+		Updates(map[string]interface{}{
+			"status":   models.DeliveryStatusSending,
+			"attempts": gorm.Expr("attempts + 1"),
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *notificationDeliveryRepository) MarkSent(id uint, providerMessageID string) error {
+	return r.db.Model(&models.NotificationDelivery{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":              models.DeliveryStatusSent,
+		"provider_message_id": providerMessageID,
+		"next_attempt_at":     nil,
+		"sent_at":             time.Now(),
+	}).Error
+}
+
+// MarkRetry puts a failed delivery back in the queue until nextAttemptAt
+func (r *notificationDeliveryRepository) MarkRetry(id uint, lastError string, nextAttemptAt time.Time) error {
+	return r.db.Model(&models.NotificationDelivery{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":          models.DeliveryStatusPending,
+		"last_error":      lastError,
+		"next_attempt_at": nextAttemptAt,
+	}).Error
+}
+
+// MarkDead moves a delivery that will not be retried to the dead-letter state
+func (r *notificationDeliveryRepository) MarkDead(id uint, lastError string) error {
+	return r.db.Model(&models.NotificationDelivery{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":          models.DeliveryStatusDead,
+		"last_error":      lastError,
+		"next_attempt_at": nil,
+	}).Error
+}
+
+// ReleaseClaimed returns deliveries left in "sending" by a previous process
+// to the queue
+func (r *notificationDeliveryRepository) ReleaseClaimed() (int64, error) {
+	result := r.db.Model(&models.NotificationDelivery{}).
+		Where("status = ?", models.DeliveryStatusSending).
+		Update("status", models.DeliveryStatusPending)
+	return result.RowsAffected, result.Error
+}
+
+// List returns the delivery history, newest first
+func (r *notificationDeliveryRepository) List(filter models.DeliveryFilter) ([]models.NotificationDelivery, error) {
+	var deliveries []models.NotificationDelivery
+	query := r.filtered(filter).Order("scheduled_at DESC, id DESC")
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	err := query.Find(&deliveries).Error
+	return deliveries, err
+}
+
+// Redrive puts the dead-lettered deliveries matching the filter back in the
+// queue with a fresh attempt budget. The filter status is ignored.
+func (r *notificationDeliveryRepository) Redrive(filter models.DeliveryFilter) (int64, error) {
+	filter.Status = models.DeliveryStatusDead
+	result := r.filtered(filter).Model(&models.NotificationDelivery{}).Updates(map[string]interface{}{
+		"status":          models.DeliveryStatusPending,
+		"attempts":        0,
+		"next_attempt_at": nil,
+	})
+	return result.RowsAffected, result.Error
+}
+
+func (r *notificationDeliveryRepository) filtered(filter models.DeliveryFilter) *gorm.DB {
+	query := r.db
+	if filter.ID != 0 {
+		query = query.Where("id = ?", filter.ID)
+	}
+	if filter.EventID != 0 {
+		query = query.Where("event_id = ?", filter.EventID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Channel != "" {
+		query = query.Where("channel = ?", filter.Channel)
+	}
+	return query
+}
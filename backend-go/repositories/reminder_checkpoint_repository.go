@@ -0,0 +1,47 @@
+package repositories
+
+import (
+	"calendar-backend/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// reminderCheckpointName identifica la fila del motor de recordatorios
+const reminderCheckpointName = "reminders"
+
+type ReminderCheckpointRepository interface {
+	Load() (time.Time, error)
+	Save(processedUntil time.Time) error
+}
+
+type reminderCheckpointRepository struct {
+	db *gorm.DB
+}
+
+func NewReminderCheckpointRepository(db *gorm.DB) ReminderCheckpointRepository {
+	return &reminderCheckpointRepository{db: db}
+}
+
+// Load devuelve el último checkpoint guardado, o el instante cero si no hay
+func (r *reminderCheckpointRepository) Load() (time.Time, error) {
+	var checkpoints []models.ReminderCheckpoint
+	if err := r.db.Where("name = ?", reminderCheckpointName).Limit(1).Find(&checkpoints).Error; err != nil {
+		return time.Time{}, err
+	}
+	if len(checkpoints) == 0 {
+		return time.Time{}, nil
+	}
+	return checkpoints[0].ProcessedUntil, nil
+}
+
+func (r *reminderCheckpointRepository) Save(processedUntil time.Time) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"processed_until", "updated_at"}),
+	}).Create(&models.ReminderCheckpoint{
+		Name:           reminderCheckpointName,
+		ProcessedUntil: processedUntil.UTC(),
+	}).Error
+}
@@ -8,7 +8,7 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func SetupNotificationRoutes(router *gin.Engine, notificationService *services.NotificationService, scheduler *services.NotificationScheduler) {
+func SetupNotificationRoutes(router *gin.Engine, notificationService *services.NotificationService, reminders *services.ReminderService, outbox *services.NotificationOutbox) {
 	log.Println("🔧 Setting up notification routes...")
 
 	if notificationService == nil {
@@ -17,12 +17,12 @@ func SetupNotificationRoutes(router *gin.Engine, notificationService *services.N
 	}
 	log.Println("✅ Notification service is available")
 
-	notificationController := handlers.NewNotificationController(notificationService, scheduler)
+	notificationController := handlers.NewNotificationController(notificationService, reminders, outbox)
 	log.Println("✅ Notification controller created")
-	if scheduler == nil {
-		log.Println("⚠️ Warning: Scheduler is nil, CheckNotificationsNow endpoint will not work")
+	if reminders == nil {
+		log.Println("⚠️ Warning: Reminder engine is nil, CheckNotificationsNow endpoint will not work")
 	} else {
-		log.Println("✅ Scheduler is available")
+		log.Println("✅ Reminder engine is available")
 	}
 
 	notificationGroup := router.Group("/api/v1/notifications")
@@ -37,6 +37,14 @@ func SetupNotificationRoutes(router *gin.Engine, notificationService *services.N
 	notificationGroup.POST("/test", notificationController.SendTestNotification)
 	log.Println("✅ POST /api/v1/notifications/test route registered")
 
+	notificationGroup.GET("/deliveries", notificationController.GetDeliveries)
+	log.Println("✅ GET /api/v1/notifications/deliveries route registered")
+
+	notificationGroup.GET("/dead-letters", notificationController.GetDeadLetters)
+	notificationGroup.POST("/dead-letters/redrive", notificationController.RedriveDeadLetters)
+	notificationGroup.POST("/dead-letters/:id/redrive", notificationController.RedriveDeadLetter)
+	log.Println("✅ Dead-letter routes registered under /api/v1/notifications/dead-letters")
+
 	notificationGroup.GET("/ping", func(c *gin.Context) {
 		c.JSON(200, gin.H{
 			"message": "Notification service is working",
@@ -6,7 +6,7 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func SetupRoutes(router *gin.Engine, eventController *handlers.EventController) {
+func SetupRoutes(router *gin.Engine, eventController *handlers.EventController, householdController *handlers.HouseholdController) {
 	// API v1 group
 	v1 := router.Group("/api/v1")
 	{
@@ -19,6 +19,24 @@ func SetupRoutes(router *gin.Engine, eventController *handlers.EventController)
 			events.PUT("/:id", eventController.UpdateEvent)
 			events.DELETE("/:id", eventController.DeleteEvent)
 		}
+
+		// Households endpoints (members and children are nested)
+		households := v1.Group("/households")
+		{
+			households.POST("/", householdController.CreateHousehold)
+			households.GET("/", householdController.GetHouseholds)
+			households.GET("/:id", householdController.GetHousehold)
+			households.PUT("/:id", householdController.UpdateHousehold)
+			households.DELETE("/:id", householdController.DeleteHousehold)
+
+			households.POST("/:id/members", householdController.AddMember)
+			households.PUT("/:id/members/:member_id", householdController.UpdateMember)
+			households.DELETE("/:id/members/:member_id", householdController.RemoveMember)
+
+			households.POST("/:id/children", householdController.AddChild)
+			households.PUT("/:id/children/:child_id", householdController.UpdateChild)
+			households.DELETE("/:id/children/:child_id", householdController.RemoveChild)
+		}
 	}
 
 }
@@ -36,31 +54,12 @@ func SetupMobileRoutes(router *gin.Engine, mobileHandler *handlers.MobileHandler
 	}
 }
 
-func SetupAllRoutes(router *gin.Engine, eventController *handlers.EventController, mobileHandler *handlers.MobileHandler) {
+func SetupAllRoutes(router *gin.Engine, eventController *handlers.EventController, householdController *handlers.HouseholdController, mobileHandler *handlers.MobileHandler) {
 	// Setup regular routes
-	SetupRoutes(router, eventController)
+	SetupRoutes(router, eventController, householdController)
 
 	// Setup mobile routes
 	SetupMobileRoutes(router, mobileHandler)
 
-	// Health check endpoint
-	router.GET("/health", func(c *gin.Context) {
-		c.JSON(200, gin.H{
-			"status":  "ok",
-			"message": "Calendar API is running",
-		})
-	})
-
-	// Root endpoint
-	router.GET("/", func(c *gin.Context) {
-		c.JSON(200, gin.H{
-			"message": "Welcome to Calendar API",
-			"version": "1.0.0",
-			"endpoints": gin.H{
-				"events": "/api/v1/events",
-				"mobile": "/api/mobile",
-				"health": "/health",
-			},
-		})
-	})
+	// /health and / are registered in main before the CORS middleware
 }
@@ -9,12 +9,14 @@ import (
 
 // EventCreationService maneja la lógica específica de creación de eventos
 type EventCreationService struct {
-	eventRepo repositories.EventRepository
+	eventRepo     repositories.EventRepository
+	householdRepo repositories.HouseholdRepository
 }
 
-func NewEventCreationService(eventRepo repositories.EventRepository) *EventCreationService {
+func NewEventCreationService(eventRepo repositories.EventRepository, householdRepo repositories.HouseholdRepository) *EventCreationService {
 	return &EventCreationService{
-		eventRepo: eventRepo,
+		eventRepo:     eventRepo,
+		householdRepo: householdRepo,
 	}
 }
 
@@ -24,6 +26,11 @@ func (s *EventCreationService) CreateEvent(event *models.Event) error {
 		return err
 	}
 
+	// Los miembros e hijos vinculados tienen que ser del hogar del evento
+	if err := resolveEventRecipients(s.householdRepo, event); err != nil {
+		return err
+	}
+
 	s.applyBusinessRules(event)
 
 	return s.eventRepo.Create(event)
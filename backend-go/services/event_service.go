@@ -49,25 +49,32 @@ type EventService interface {
 	EventQueryHandler
 }
 
+// ReminderScheduler recibe el aviso de que cambiaron eventos para recalcular sus recordatorios
+type ReminderScheduler interface {
+	Reschedule()
+}
+
 type eventService struct {
 	eventRepo       repositories.EventRepository
 	creationService *EventCreationService
 	updateService   *EventUpdateService
 	deletionService *EventDeletionService
+	reminders       ReminderScheduler
 }
 
-func NewEventService(eventRepo repositories.EventRepository) EventService {
+func NewEventService(eventRepo repositories.EventRepository, householdRepo repositories.HouseholdRepository, reminders ReminderScheduler) EventService {
 	return &eventService{
 		eventRepo:       eventRepo,
-		creationService: NewEventCreationService(eventRepo),
-		updateService:   NewEventUpdateService(eventRepo),
+		creationService: NewEventCreationService(eventRepo, householdRepo),
+		updateService:   NewEventUpdateService(eventRepo, householdRepo),
 		deletionService: NewEventDeletionService(eventRepo),
+		reminders:       reminders,
 	}
 }
 
 func (s *eventService) CreateEvent(event *models.Event) error {
 	// Delegar al servicio específico de creación
-	return s.creationService.CreateEvent(event)
+	return s.rescheduled(s.creationService.CreateEvent(event))
 }
 
 func (s *eventService) GetEventByID(id uint) (*models.Event, error) {
@@ -92,12 +99,20 @@ func (s *eventService) GetEventsByDate(date string) ([]models.Event, error) {
 
 func (s *eventService) UpdateEvent(id uint, event *models.Event) error {
 	// Delegar al servicio específico de actualización
-	return s.updateService.UpdateEvent(id, event)
+	return s.rescheduled(s.updateService.UpdateEvent(id, event))
 }
 
 func (s *eventService) DeleteEvent(id uint) error {
 	// Delegar al servicio específico de eliminación
-	return s.deletionService.DeleteEvent(id)
+	return s.rescheduled(s.deletionService.DeleteEvent(id))
+}
+
+// rescheduled avisa al motor de recordatorios cuando una escritura tuvo éxito
+func (s *eventService) rescheduled(err error) error {
+	if err == nil && s.reminders != nil {
+		s.reminders.Reschedule()
+	}
+	return err
 }
 
 func (s *eventService) GetTodayEvents() ([]models.Event, error) {
@@ -9,12 +9,14 @@ import (
 
 // EventUpdateService maneja la lógica específica de actualización de eventos
 type EventUpdateService struct {
-	eventRepo repositories.EventRepository
+	eventRepo     repositories.EventRepository
+	householdRepo repositories.HouseholdRepository
 }
 
-func NewEventUpdateService(eventRepo repositories.EventRepository) *EventUpdateService {
+func NewEventUpdateService(eventRepo repositories.EventRepository, householdRepo repositories.HouseholdRepository) *EventUpdateService {
 	return &EventUpdateService{
-		eventRepo: eventRepo,
+		eventRepo:     eventRepo,
+		householdRepo: householdRepo,
 	}
 }
 
@@ -37,10 +39,21 @@ func (s *EventUpdateService) UpdateEvent(id uint, event *models.Event) error {
 	}
 
 	// 4. Aplicar reglas de negocio
-	s.applyUpdateRules(existingEvent, event)
+	recipientsChanged := s.applyUpdateRules(existingEvent, event)
+	if recipientsChanged {
+		if err := resolveEventRecipients(s.householdRepo, existingEvent); err != nil {
+			return err
+		}
+	}
 
 	// 5. Delegar al repositorio
-	return s.eventRepo.Update(id, existingEvent)
+	if err := s.eventRepo.Update(id, existingEvent); err != nil {
+		return err
+	}
+	if recipientsChanged {
+		return s.eventRepo.ReplaceRecipients(existingEvent)
+	}
+	return nil
 }
 
 // validateUpdate valida las reglas de negocio para una actualización
@@ -81,8 +94,9 @@ func (s *EventUpdateService) validateUpdate(event *models.Event) error {
 	return nil
 }
 
-// applyUpdateRules aplica reglas de negocio para la actualización
-func (s *EventUpdateService) applyUpdateRules(existingEvent, newEvent *models.Event) {
+// applyUpdateRules aplica reglas de negocio para la actualización.
+// Devuelve true si cambiaron el hogar o los miembros/hijos vinculados.
+func (s *EventUpdateService) applyUpdateRules(existingEvent, newEvent *models.Event) bool {
 	// Actualizar solo los campos proporcionados
 	if newEvent.Title != "" {
 		existingEvent.Title = newEvent.Title
@@ -125,6 +139,27 @@ func (s *EventUpdateService) applyUpdateRules(existingEvent, newEvent *models.Ev
 	if newEvent.Category != "" && newEvent.Category != existingEvent.Category {
 		s.applyCategoryColors(existingEvent)
 	}
+
+	// Vínculos familiares: Members/Children nil indica que no se enviaron
+	recipientsChanged := false
+	if newEvent.HouseholdID != nil {
+		if existingEvent.HouseholdID == nil || *existingEvent.HouseholdID != *newEvent.HouseholdID {
+			// Al cambiar de hogar los vínculos anteriores dejan de ser válidos
+			existingEvent.Members = []models.Member{}
+			existingEvent.Children = []models.Child{}
+			recipientsChanged = true
+		}
+		existingEvent.HouseholdID = newEvent.HouseholdID
+	}
+	if newEvent.Members != nil {
+		existingEvent.Members = newEvent.Members
+		recipientsChanged = true
+	}
+	if newEvent.Children != nil {
+		existingEvent.Children = newEvent.Children
+		recipientsChanged = true
+	}
+	return recipientsChanged
 }
 
 // applyCategoryColors aplica colores automáticos por categoría
@@ -0,0 +1,215 @@
+package services
+
+import (
+	"calendar-backend/models"
+	"calendar-backend/repositories"
+	"errors"
+	"fmt"
+)
+
+// HouseholdService maneja la lógica de hogares, miembros e hijos
+type HouseholdService struct {
+	householdRepo repositories.HouseholdRepository
+}
+
+func NewHouseholdService(householdRepo repositories.HouseholdRepository) *HouseholdService {
+	return &HouseholdService{
+		householdRepo: householdRepo,
+	}
+}
+
+// CreateHousehold crea un hogar vacío
+func (s *HouseholdService) CreateHousehold(household *models.Household) error {
+	if household.Name == "" {
+		return errors.New("name is required")
+	}
+	return s.householdRepo.CreateHousehold(household)
+}
+
+// GetHousehold devuelve el hogar con sus miembros e hijos
+func (s *HouseholdService) GetHousehold(id uint) (*models.Household, error) {
+	if id == 0 {
+		return nil, errors.New("invalid household ID")
+	}
+	household, err := s.householdRepo.GetHousehold(id)
+	if err != nil {
+		return nil, errors.New("household not found")
+	}
+	return household, nil
+}
+
+// GetHouseholds lista los hogares, opcionalmente filtrados por email de contacto
+func (s *HouseholdService) GetHouseholds(email string) ([]models.Household, error) {
+	return s.householdRepo.GetHouseholds(email)
+}
+
+// UpdateHousehold actualiza nombre y email del hogar
+func (s *HouseholdService) UpdateHousehold(id uint, household *models.Household) (*models.Household, error) {
+	existing, err := s.GetHousehold(id)
+	if err != nil {
+		return nil, err
+	}
+	if household.Name == "" {
+		return nil, errors.New("name is required")
+	}
+
+	existing.Name = household.Name
+	existing.Email = household.Email
+	if err := s.householdRepo.UpdateHousehold(existing); err != nil {
+		return nil, err
+	}
+	return existing, nil
+}
+
+// DeleteHousehold elimina el hogar con sus miembros e hijos
+func (s *HouseholdService) DeleteHousehold(id uint) error {
+	if _, err := s.GetHousehold(id); err != nil {
+		return err
+	}
+	return s.householdRepo.DeleteHousehold(id)
+}
+
+// AddMember agrega un miembro adulto al hogar
+func (s *HouseholdService) AddMember(householdID uint, member *models.Member) error {
+	if _, err := s.GetHousehold(householdID); err != nil {
+		return err
+	}
+	if err := validateMember(member); err != nil {
+		return err
+	}
+
+	member.ID = 0
+	member.HouseholdID = householdID
+	return s.householdRepo.CreateMember(member)
+}
+
+// UpdateMember reemplaza los datos de un miembro del hogar
+func (s *HouseholdService) UpdateMember(householdID, id uint, member *models.Member) (*models.Member, error) {
+	existing, err := s.householdRepo.GetMember(householdID, id)
+	if err != nil {
+		return nil, errors.New("member not found")
+	}
+	if err := validateMember(member); err != nil {
+		return nil, err
+	}
+
+	existing.Name = member.Name
+	existing.Email = member.Email
+	existing.Phone = member.Phone
+	existing.Role = member.Role
+	if err := s.householdRepo.UpdateMember(existing); err != nil {
+		return nil, err
+	}
+	return existing, nil
+}
+
+// RemoveMember elimina un miembro y lo desvincula de sus eventos
+func (s *HouseholdService) RemoveMember(householdID, id uint) error {
+	if _, err := s.householdRepo.GetMember(householdID, id); err != nil {
+		return errors.New("member not found")
+	}
+	return s.householdRepo.DeleteMember(householdID, id)
+}
+
+// AddChild agrega un hijo al hogar
+func (s *HouseholdService) AddChild(householdID uint, child *models.Child) error {
+	if _, err := s.GetHousehold(householdID); err != nil {
+		return err
+	}
+	if child.Name == "" {
+		return errors.New("name is required")
+	}
+
+	child.ID = 0
+	child.HouseholdID = householdID
+	return s.householdRepo.CreateChild(child)
+}
+
+// UpdateChild reemplaza los datos de un hijo del hogar
+func (s *HouseholdService) UpdateChild(householdID, id uint, child *models.Child) (*models.Child, error) {
+	existing, err := s.householdRepo.GetChild(householdID, id)
+	if err != nil {
+		return nil, errors.New("child not found")
+	}
+	if child.Name == "" {
+		return nil, errors.New("name is required")
+	}
+
+	existing.Name = child.Name
+	existing.BirthDate = child.BirthDate
+	existing.Color = child.Color
+	if err := s.householdRepo.UpdateChild(existing); err != nil {
+		return nil, err
+	}
+	return existing, nil
+}
+
+// RemoveChild elimina un hijo y lo desvincula de sus eventos
+func (s *HouseholdService) RemoveChild(householdID, id uint) error {
+	if _, err := s.householdRepo.GetChild(householdID, id); err != nil {
+		return errors.New("child not found")
+	}
+	return s.householdRepo.DeleteChild(householdID, id)
+}
+
+// validateMember valida que el miembro tenga nombre y al menos un medio de contacto
+func validateMember(member *models.Member) error {
+	if member.Name == "" {
+		return errors.New("name is required")
+	}
+	if member.Email == "" && member.Phone == "" {
+		return errors.New("email or phone is required to notify the member")
+	}
+	return nil
+}
+
+// resolveEventRecipients reemplaza los miembros e hijos del evento (que solo traen el ID)
+// por los registros del hogar, verificando que pertenezcan a él
+func resolveEventRecipients(householdRepo repositories.HouseholdRepository, event *models.Event) error {
+	if len(event.Members) == 0 && len(event.Children) == 0 {
+		return nil
+	}
+	if event.HouseholdID == nil {
+		return errors.New("household_id is required to link members or children")
+	}
+
+	if len(event.Members) > 0 {
+		members, err := householdRepo.GetMembersByIDs(*event.HouseholdID, memberIDs(event.Members))
+		if err != nil {
+			return err
+		}
+		if len(members) != len(event.Members) {
+			return fmt.Errorf("some members do not belong to household %d", *event.HouseholdID)
+		}
+		event.Members = members
+	}
+
+	if len(event.Children) > 0 {
+		children, err := householdRepo.GetChildrenByIDs(*event.HouseholdID, childIDs(event.Children))
+		if err != nil {
+			return err
+		}
+		if len(children) != len(event.Children) {
+			return fmt.Errorf("some children do not belong to household %d", *event.HouseholdID)
+		}
+		event.Children = children
+	}
+
+	return nil
+}
+
+func memberIDs(members []models.Member) []uint {
+	ids := make([]uint, len(members))
+	for i, member := range members {
+		ids[i] = member.ID
+	}
+	return ids
+}
+
+func childIDs(children []models.Child) []uint {
+	ids := make([]uint, len(children))
+	for i, child := range children {
+		ids[i] = child.ID
+	}
+	return ids
+}
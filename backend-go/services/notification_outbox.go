@@ -0,0 +1,234 @@
+package services
+
+import (
+	"calendar-backend/models"
+	"calendar-backend/notifications"
+	"calendar-backend/repositories"
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+const (
+	// outboxPollInterval es cada cuánto el worker revisa el outbox aunque nadie lo despierte;
+	// define la precisión de los reintentos
+	outboxPollInterval = 15 * time.Second
+	// outboxBatchSize es la cantidad máxima de entregas que toma el worker por vuelta
+	outboxBatchSize = 50
+)
+
+// NotificationOutbox persiste los recordatorios a enviar y los entrega con un worker.
+// El scheduler encola de forma idempotente: cada recordatorio se envía una sola vez
+// por destinatario y canal, y queda el historial de cada entrega. Los envíos fallidos
+// se reintentan según la política del canal y terminan en dead-letter si no salen.
+type NotificationOutbox struct {
+	deliveryRepo repositories.NotificationDeliveryRepository
+	registry     *notifications.Registry
+	wake         chan struct{}
+	done         chan struct{}
+}
+
+func NewNotificationOutbox(deliveryRepo repositories.NotificationDeliveryRepository, registry *notifications.Registry) *NotificationOutbox {
+	return &NotificationOutbox{
+		deliveryRepo: deliveryRepo,
+		registry:     registry,
+		wake:         make(chan struct{}, 1),
+		done:         make(chan struct{}),
+	}
+}
+
+// Enqueue encola el recordatorio del evento para cada destinatario y canal habilitado.
+// Los recordatorios ya encolados se ignoran. Devuelve cuántas entregas nuevas se crearon.
+func (o *NotificationOutbox) Enqueue(event *models.Event, reminderType string, scheduledAt time.Time) (int, error) {
+	created := 0
+	for _, reminder := range reminderRecipients(event, reminderType) {
+		for _, channel := range o.registry.Channels() {
+			address := channel.Address(reminder.recipient)
+			if address == "" {
+				continue
+			}
+
+			delivery := &models.NotificationDelivery{
+				DedupKey:      deliveryDedupKey(event, reminderType, channel.Name(), address),
+				EventID:       event.ID,
+				MemberID:      reminder.memberID,
+				ReminderType:  reminderType,
+				Channel:       channel.Name(),
+				Recipient:     address,
+				RecipientName: reminder.recipient.Name,
+				Subject:       reminder.message.Subject,
+				Body:          reminder.message.Body,
+				Text:          reminder.message.Text,
+				ScheduledAt:   scheduledAt,
+			}
+			ok, err := o.deliveryRepo.Enqueue(delivery)
+			if err != nil {
+				return created, fmt.Errorf("error enqueuing %s reminder for event %d: %v", channel.Name(), event.ID, err)
+			}
+			if ok {
+				created++
+			}
+		}
+	}
+
+	if created > 0 {
+		o.Wake()
+	}
+	return created, nil
+}
+
+// deliveryDedupKey identifica un recordatorio: el mismo evento, tipo, fecha del evento,
+// canal y dirección. Si el evento cambia de fecha, el recordatorio se vuelve a enviar.
+func deliveryDedupKey(event *models.Event, reminderType, channel, address string) string {
+	return fmt.Sprintf("%d|%s|%s|%s|%s", event.ID, reminderType, event.Date.Format("2006-01-02"), channel, address)
+}
+
+// Start inicia el worker que vacía el outbox
+func (o *NotificationOutbox) Start() {
+	// Las entregas que quedaron tomadas por un proceso anterior vuelven a la cola
+	if released, err := o.deliveryRepo.ReleaseClaimed(); err != nil {
+		log.Printf("❌ Error releasing claimed notifications: %v", err)
+	} else if released > 0 {
+		log.Printf("♻️ Released %d notifications left in flight", released)
+	}
+
+	go func() {
+		ticker := time.NewTicker(outboxPollInterval)
+		defer ticker.Stop()
+
+		o.processPending()
+		for {
+			select {
+			case <-ticker.C:
+				o.processPending()
+			case <-o.wake:
+				o.processPending()
+			case <-o.done:
+				log.Println("🛑 Notification outbox worker stopped")
+				return
+			}
+		}
+	}()
+
+	log.Println("✅ Notification outbox worker started")
+}
+
+// Stop detiene el worker
+func (o *NotificationOutbox) Stop() {
+	close(o.done)
+}
+
+// Wake despierta al worker para que procese el outbox sin esperar al próximo tick
+func (o *NotificationOutbox) Wake() {
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+}
+
+// History devuelve el historial de entregas
+func (o *NotificationOutbox) History(filter models.DeliveryFilter) ([]models.NotificationDelivery, error) {
+	return o.deliveryRepo.List(filter)
+}
+
+// Redrive vuelve a encolar las entregas en dead-letter que coinciden con el filtro,
+// con todos sus intentos disponibles. Devuelve cuántas se reencolaron.
+func (o *NotificationOutbox) Redrive(filter models.DeliveryFilter) (int64, error) {
+	redriven, err := o.deliveryRepo.Redrive(filter)
+	if err != nil {
+		return 0, err
+	}
+	if redriven > 0 {
+		log.Printf("♻️ Re-driving %d dead-lettered notifications", redriven)
+		o.Wake()
+	}
+	return redriven, nil
+}
+
+// processPending envía las entregas pendientes por lotes hasta vaciar la cola
+func (o *NotificationOutbox) processPending() {
+	for {
+		deliveries, err := o.deliveryRepo.GetPending(outboxBatchSize)
+		if err != nil {
+			log.Printf("❌ Error getting pending notifications: %v", err)
+			return
+		}
+
+		for i := range deliveries {
+			o.deliver(&deliveries[i])
+		}
+
+		if len(deliveries) < outboxBatchSize {
+			return
+		}
+	}
+}
+
+// deliver toma la entrega y la envía por su canal, registrando el resultado
+func (o *NotificationOutbox) deliver(delivery *models.NotificationDelivery) {
+	claimed, err := o.deliveryRepo.Claim(delivery.ID)
+	if err != nil {
+		log.Printf("❌ Error claiming notification %d: %v", delivery.ID, err)
+		return
+	}
+	if !claimed {
+		return
+	}
+
+	providerMessageID, err := o.send(delivery)
+	if err != nil {
+		o.recordFailure(delivery, err)
+		return
+	}
+
+	log.Printf("✅ %s notification %d sent to %s", delivery.Channel, delivery.ID, delivery.Recipient)
+	if err := o.deliveryRepo.MarkSent(delivery.ID, providerMessageID); err != nil {
+		log.Printf("❌ Error recording delivery of notification %d: %v", delivery.ID, err)
+	}
+}
+
+// recordFailure reprograma la entrega con backoff, o la pasa a dead-letter si el error es
+// permanente o se agotaron los intentos del canal. Attempts ya cuenta el intento actual.
+func (o *NotificationOutbox) recordFailure(delivery *models.NotificationDelivery, err error) {
+	attempts := delivery.Attempts + 1
+	policy := o.registry.RetryPolicy(delivery.Channel)
+
+	if notifications.IsPermanent(err) || policy.Exhausted(attempts) {
+		log.Printf("💀 %s notification %d to %s dead-lettered after %d attempts: %v", delivery.Channel, delivery.ID, delivery.Recipient, attempts, err)
+		if err := o.deliveryRepo.MarkDead(delivery.ID, err.Error()); err != nil {
+			log.Printf("❌ Error dead-lettering notification %d: %v", delivery.ID, err)
+		}
+		return
+	}
+
+	nextAttemptAt := time.Now().Add(policy.Backoff(attempts))
+	log.Printf("⏳ %s notification %d to %s failed (attempt %d/%d), retrying at %s: %v",
+		delivery.Channel, delivery.ID, delivery.Recipient, attempts, policy.MaxAttempts, nextAttemptAt.Format(time.RFC3339), err)
+	if err := o.deliveryRepo.MarkRetry(delivery.ID, err.Error(), nextAttemptAt); err != nil {
+		log.Printf("❌ Error scheduling retry of notification %d: %v", delivery.ID, err)
+	}
+}
+
+func (o *NotificationOutbox) send(delivery *models.NotificationDelivery) (string, error) {
+	channel, ok := o.registry.Get(delivery.Channel)
+	if !ok {
+		return "", fmt.Errorf("channel %q is not enabled", delivery.Channel)
+	}
+
+	// La dirección guardada es la del canal; se completa el campo que el canal lee
+	recipient := notifications.Recipient{
+		Name:  delivery.RecipientName,
+		Email: delivery.Recipient,
+		Phone: delivery.Recipient,
+	}
+	message := notifications.Message{
+		Subject: delivery.Subject,
+		Body:    delivery.Body,
+		Text:    delivery.Text,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), notificationSendTimeout)
+	defer cancel()
+	return notifications.Deliver(ctx, channel, recipient, message)
+}
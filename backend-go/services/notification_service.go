@@ -1,69 +1,124 @@
 package services
 
 import (
-	"calendar-backend/config"
 	"calendar-backend/models"
-	"encoding/json"
+	"calendar-backend/notifications"
+	"context"
+	"errors"
 	"fmt"
 	"log"
-
-	"github.com/sendgrid/sendgrid-go"
-	"github.com/sendgrid/sendgrid-go/helpers/mail"
-	"github.com/twilio/twilio-go"
-	twilioApi "github.com/twilio/twilio-go/rest/api/v2010"
+	"strings"
+	"time"
 )
 
+// notificationSendTimeout limita cuánto puede tardar un envío por canal
+const notificationSendTimeout = 30 * time.Second
+
 type NotificationService struct {
-	cfg *config.Config
+	registry *notifications.Registry
 }
 
-func NewNotificationService() *NotificationService {
-	// Cargar configuración con manejo de errores
-	cfg := config.LoadConfig()
-	if cfg == nil {
-		log.Println("⚠️ Warning: Config is nil, using default values")
-		// Crear configuración por defecto
-		cfg = &config.Config{}
-	}
-
+func NewNotificationService(registry *notifications.Registry) *NotificationService {
 	// Log configuration status
 	log.Println("📧 Notification Service Configuration:")
-	if cfg.SendGridAPIKey != "" {
-		log.Printf("  ✅ SendGrid API Key: Configured (from email: %s)", cfg.FromEmail)
-	} else {
-		log.Println("  ⚠️ SendGrid API Key: NOT configured - Email notifications will be skipped")
-		log.Println("  💡 To enable email notifications, set SENDGRID_API_KEY environment variable")
+	if len(registry.Channels()) == 0 {
+		log.Println("  ⚠️ No notification channels enabled - notifications will be skipped")
+		log.Println("  💡 Set NOTIFICATION_CHANNELS and the credentials of each channel (SENDGRID_API_KEY, TWILIO_*)")
 	}
-
-	if cfg.TwilioAccountSID != "" && cfg.TwilioAuthToken != "" {
-		log.Printf("  ✅ Twilio: Configured (phone: %s)", cfg.TwilioPhoneNumber)
-	} else {
-		log.Println("  ⚠️ Twilio: NOT configured - WhatsApp notifications will be skipped")
-		log.Println("  💡 To enable WhatsApp notifications, set TWILIO_ACCOUNT_SID, TWILIO_AUTH_TOKEN, and TWILIO_PHONE_NUMBER")
+	for _, channel := range registry.Channels() {
+		log.Printf("  ✅ Channel: %s", channel.Name())
 	}
 
 	return &NotificationService{
-		cfg: cfg,
+		registry: registry,
 	}
 }
 
-// SendEmailNotification sends an email reminder for an event
-func (s *NotificationService) SendEmailNotification(event *models.Event, reminderType string) error {
-	if s.cfg.SendGridAPIKey == "" {
-		log.Printf("⚠️ SendGrid API key not configured, skipping email notification for event: %s (ID: %d)", event.Title, event.ID)
-		return nil
+// SendNotification sends the reminder to the event contact and to the linked
+// household members through every enabled channel, right away and without
+// retries. It returns the errors of every failed send.
+func (s *NotificationService) SendNotification(event *models.Event, reminderType string) error {
+	if len(event.Members) == 0 {
+		log.Println("No household members linked to this event")
 	}
 
-	from := mail.NewEmail("Calendar Reminder", s.cfg.FromEmail)
-	to := mail.NewEmail("User", event.Email)
+	var errs []error
+	for _, reminder := range reminderRecipients(event, reminderType) {
+		if err := s.send(reminder.recipient, reminder.message); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// reminderRecipient es el recordatorio armado para un destinatario
+type reminderRecipient struct {
+	memberID  *uint // nil para el contacto del evento
+	recipient notifications.Recipient
+	message   notifications.Message
+}
+
+// reminderRecipients arma el recordatorio para el contacto del evento y para cada
+// miembro del hogar vinculado. Los miembros y los hijos vienen precargados.
+func reminderRecipients(event *models.Event, reminderType string) []reminderRecipient {
+	reminders := []reminderRecipient{{
+		recipient: notifications.Recipient{Email: event.Email, Phone: event.Phone},
+		message:   buildReminderMessage(event, reminderType),
+	}}
+
+	// Nombres de los hijos para los que es el evento
+	selectedChildren := make([]string, len(event.Children))
+	for i, child := range event.Children {
+		selectedChildren[i] = child.Name
+	}
+
+	for i := range event.Members {
+		member := &event.Members[i]
+		reminders = append(reminders, reminderRecipient{
+			memberID: &member.ID,
+			recipient: notifications.Recipient{
+				Name:  member.Name,
+				Email: member.Email,
+				Phone: member.Phone,
+			},
+			message: buildFamilyReminderMessage(event, member.Name, selectedChildren, reminderType),
+		})
+	}
+
+	return reminders
+}
 
-	var subject string
-	var body string
+// send envía el mensaje por cada canal; un fallo en un canal no impide los demás
+// y se devuelven todos los errores juntos
+func (s *NotificationService) send(recipient notifications.Recipient, message notifications.Message) error {
+	var errs []error
+	for _, channel := range s.registry.Channels() {
+		ctx, cancel := context.WithTimeout(context.Background(), notificationSendTimeout)
+		err := channel.Send(ctx, recipient, message)
+		cancel()
+
+		switch {
+		case errors.Is(err, notifications.ErrNoAddress):
+			continue
+		case err != nil:
+			log.Printf("❌ Failed to send %s notification: %v", channel.Name(), err)
+			errs = append(errs, fmt.Errorf("%s: %w", channel.Name(), err))
+		default:
+			log.Printf("✅ %s notification sent successfully to %s", channel.Name(), channel.Address(recipient))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// buildReminderMessage arma el recordatorio para el contacto del evento
+func buildReminderMessage(event *models.Event, reminderType string) notifications.Message {
+	var message notifications.Message
 
 	switch reminderType {
 	case "day_before":
-		subject = fmt.Sprintf("Recordatorio: %s mañana", event.Title)
-		body = fmt.Sprintf(`
+		message.Subject = fmt.Sprintf("Recordatorio: %s mañana", event.Title)
+		message.Body = fmt.Sprintf(`
 			Hola!
 			
 			Te recordamos que mañana tenés:
@@ -74,16 +129,11 @@ func (s *NotificationService) SendEmailNotification(event *models.Event, reminde
 			%s
 			
 			¡No te lo pierdas!
-		`, event.Title, event.Date.Format("02/01/2006"), event.Time,
-			func() string {
-				if event.Location != "" {
-					return fmt.Sprintf("Ubicación: %s", event.Location)
-				}
-				return ""
-			}())
+		`, event.Title, event.Date.Format("02/01/2006"), event.Time, locationLine(event))
+		message.Text = fmt.Sprintf("Recordatorio: Mañana tienes '%s' a las %s", event.Title, event.Time)
 	case "same_day":
-		subject = fmt.Sprintf("Recordatorio: %s hoy", event.Title)
-		body = fmt.Sprintf(`
+		message.Subject = fmt.Sprintf("Recordatorio: %s hoy", event.Title)
+		message.Body = fmt.Sprintf(`
 			Hola!
 			
 			Te recordamos que hoy tenés:
@@ -93,183 +143,31 @@ func (s *NotificationService) SendEmailNotification(event *models.Event, reminde
 			%s
 			
 			¡Que tengas un buen día!
-		`, event.Title, event.Time,
-			func() string {
-				if event.Location != "" {
-					return fmt.Sprintf("Ubicación: %s", event.Location)
-				}
-				return ""
-			}())
-	}
-
-	message := mail.NewSingleEmail(from, subject, to, body, body)
-	client := sendgrid.NewSendClient(s.cfg.SendGridAPIKey)
-
-	response, err := client.Send(message)
-	if err != nil {
-		return fmt.Errorf("failed to send email: %v", err)
-	}
-
-	log.Printf("✅ Email sent successfully to %s for event '%s', status: %d", event.Email, event.Title, response.StatusCode)
-	return nil
-}
-
-// SendWhatsAppNotification sends a WhatsApp reminder for an event
-func (s *NotificationService) SendWhatsAppNotification(event *models.Event, reminderType string) error {
-	if s.cfg.TwilioAccountSID == "" || s.cfg.TwilioAuthToken == "" {
-		log.Println("Twilio credentials not configured, skipping WhatsApp notification")
-		return nil
-	}
-
-	client := twilio.NewRestClientWithParams(twilio.ClientParams{
-		Username: s.cfg.TwilioAccountSID,
-		Password: s.cfg.TwilioAuthToken,
-	})
-
-	var message string
-	switch reminderType {
-	case "day_before":
-		message = fmt.Sprintf("Recordatorio: Mañana tienes '%s' a las %s", event.Title, event.Time)
-	case "same_day":
-		message = fmt.Sprintf("Recordatorio: Hoy tienes '%s' a las %s", event.Title, event.Time)
+		`, event.Title, event.Time, locationLine(event))
+		message.Text = fmt.Sprintf("Recordatorio: Hoy tienes '%s' a las %s", event.Title, event.Time)
 	}
 
 	if event.Location != "" {
-		message += fmt.Sprintf(" en %s", event.Location)
-	}
-
-	params := &twilioApi.CreateMessageParams{}
-	params.SetTo(event.Phone)
-	params.SetFrom(s.cfg.TwilioPhoneNumber)
-	params.SetBody(message)
-
-	_, err := client.Api.CreateMessage(params)
-	if err != nil {
-		return fmt.Errorf("failed to send WhatsApp message: %v", err)
-	}
-
-	log.Printf("WhatsApp message sent successfully to %s", event.Phone)
-	return nil
-}
-
-// SendNotification sends both email and WhatsApp notifications
-func (s *NotificationService) SendNotification(event *models.Event, reminderType string) error {
-	// Send email notification
-	if err := s.SendEmailNotification(event, reminderType); err != nil {
-		log.Printf("Failed to send email notification: %v", err)
-	}
-
-	// Send WhatsApp notification
-	if err := s.SendWhatsAppNotification(event, reminderType); err != nil {
-		log.Printf("Failed to send WhatsApp notification: %v", err)
-	}
-
-	// Send family notifications if enabled
-	if err := s.SendFamilyNotifications(event, reminderType); err != nil {
-		log.Printf("Failed to send family notifications: %v", err)
-	}
-
-	return nil
-}
-
-// FamilyMember representa un miembro de la familia
-type FamilyMember struct {
-	ID    string `json:"id"`
-	Name  string `json:"name"`
-	Email string `json:"email"`
-	Phone string `json:"phone"`
-	Role  string `json:"role"`
-}
-
-// SendFamilyNotifications envía notificaciones a los miembros de la familia seleccionados
-func (s *NotificationService) SendFamilyNotifications(event *models.Event, reminderType string) error {
-	if !event.NotifyFamily {
-		log.Println("Family notifications not enabled for this event")
-		return nil
-	}
-
-	// Parsear miembros de la familia
-	var familyMembers []FamilyMember
-	if event.FamilyMembers != "" {
-		if err := json.Unmarshal([]byte(event.FamilyMembers), &familyMembers); err != nil {
-			log.Printf("Error parsing family members: %v", err)
-			return err
-		}
-	}
-
-	// Parsear hijos seleccionados
-	var selectedChildren []string
-	if event.SelectedChildren != "" {
-		if err := json.Unmarshal([]byte(event.SelectedChildren), &selectedChildren); err != nil {
-			log.Printf("Error parsing selected children: %v", err)
-			return err
-		}
-	}
-
-	// Determinar a quién notificar
-	var recipients []FamilyMember
-
-	// Si se debe notificar al papá
-	if event.NotifyPapa {
-		for _, member := range familyMembers {
-			if member.Role == "papa" {
-				recipients = append(recipients, member)
-			}
-		}
-	}
-
-	// Si se debe notificar a la mamá
-	if event.NotifyMama {
-		for _, member := range familyMembers {
-			if member.Role == "mama" {
-				recipients = append(recipients, member)
-			}
-		}
-	}
-
-	// Enviar notificaciones a los destinatarios
-	for _, recipient := range recipients {
-		// Enviar email si tiene email
-		if recipient.Email != "" {
-			if err := s.sendFamilyEmailNotification(event, &recipient, selectedChildren, reminderType); err != nil {
-				log.Printf("Error sending email to %s: %v", recipient.Email, err)
-			}
-		}
-
-		// Enviar WhatsApp si tiene teléfono
-		if recipient.Phone != "" {
-			if err := s.sendFamilyWhatsAppNotification(event, &recipient, selectedChildren, reminderType); err != nil {
-				log.Printf("Error sending WhatsApp to %s: %v", recipient.Phone, err)
-			}
-		}
+		message.Text += fmt.Sprintf(" en %s", event.Location)
 	}
 
-	return nil
+	return message
 }
 
-// sendFamilyEmailNotification envía un email a un miembro de la familia
-func (s *NotificationService) sendFamilyEmailNotification(event *models.Event, recipient *FamilyMember, selectedChildren []string, reminderType string) error {
-	if s.cfg.SendGridAPIKey == "" {
-		log.Println("SendGrid API key not configured, skipping family email notification")
-		return nil
-	}
-
-	from := mail.NewEmail("Calendar Reminder", s.cfg.FromEmail)
-	to := mail.NewEmail(recipient.Name, recipient.Email)
+// buildFamilyReminderMessage arma el recordatorio personalizado para un miembro del hogar
+func buildFamilyReminderMessage(event *models.Event, name string, selectedChildren []string, reminderType string) notifications.Message {
+	var message notifications.Message
 
-	var subject string
-	var body string
-
-	// Crear mensaje personalizado con información de los hijos
+	// Información de los hijos para los que es el evento
 	childrenInfo := ""
 	if len(selectedChildren) > 0 {
-		childrenInfo = fmt.Sprintf("\n\nEste evento es para: %s", fmt.Sprintf("%v", selectedChildren))
+		childrenInfo = fmt.Sprintf("\n\nEste evento es para: %s", strings.Join(selectedChildren, ", "))
 	}
 
 	switch reminderType {
 	case "day_before":
-		subject = fmt.Sprintf("Recordatorio familiar: %s mañana", event.Title)
-		body = fmt.Sprintf(`
+		message.Subject = fmt.Sprintf("Recordatorio familiar: %s mañana", event.Title)
+		message.Body = fmt.Sprintf(`
 			Hola %s!
 			
 			Te recordamos que mañana tenés:
@@ -280,16 +178,12 @@ func (s *NotificationService) sendFamilyEmailNotification(event *models.Event, r
 			%s%s
 			
 			¡No te lo pierdas!
-		`, recipient.Name, event.Title, event.Date.Format("02/01/2006"), event.Time,
-			func() string {
-				if event.Location != "" {
-					return fmt.Sprintf("Ubicación: %s", event.Location)
-				}
-				return ""
-			}(), childrenInfo)
+		`, name, event.Title, event.Date.Format("02/01/2006"), event.Time, locationLine(event), childrenInfo)
+		message.Text = fmt.Sprintf("Hola %s! Te recordamos que mañana tenés: %s el %s a las %s%s. ¡No te lo pierdas!",
+			name, event.Title, event.Date.Format("02/01/2006"), event.Time, childrenInfo)
 	case "same_day":
-		subject = fmt.Sprintf("Recordatorio familiar: %s hoy", event.Title)
-		body = fmt.Sprintf(`
+		message.Subject = fmt.Sprintf("Recordatorio familiar: %s hoy", event.Title)
+		message.Body = fmt.Sprintf(`
 			Hola %s!
 			
 			Te recordamos que hoy tenés:
@@ -299,64 +193,18 @@ func (s *NotificationService) sendFamilyEmailNotification(event *models.Event, r
 			%s%s
 			
 			¡Que tengas un buen día!
-		`, recipient.Name, event.Title, event.Time,
-			func() string {
-				if event.Location != "" {
-					return fmt.Sprintf("Ubicación: %s", event.Location)
-				}
-				return ""
-			}(), childrenInfo)
+		`, name, event.Title, event.Time, locationLine(event), childrenInfo)
+		message.Text = fmt.Sprintf("Hola %s! Te recordamos que hoy tenés: %s a las %s%s. ¡Que tengas un buen día!",
+			name, event.Title, event.Time, childrenInfo)
 	}
 
-	message := mail.NewSingleEmail(from, subject, to, body, body)
-	client := sendgrid.NewSendClient(s.cfg.SendGridAPIKey)
-
-	response, err := client.Send(message)
-	if err != nil {
-		return fmt.Errorf("failed to send family email: %v", err)
-	}
-
-	log.Printf("Family email sent successfully to %s (%s), status: %d", recipient.Email, recipient.Name, response.StatusCode)
-	return nil
+	return message
 }
 
-// sendFamilyWhatsAppNotification envía un WhatsApp a un miembro de la familia
-func (s *NotificationService) sendFamilyWhatsAppNotification(event *models.Event, recipient *FamilyMember, selectedChildren []string, reminderType string) error {
-	if s.cfg.TwilioAccountSID == "" || s.cfg.TwilioAuthToken == "" {
-		log.Println("Twilio credentials not configured, skipping family WhatsApp notification")
-		return nil
-	}
-
-	client := twilio.NewRestClientWithParams(twilio.ClientParams{
-		Username: s.cfg.TwilioAccountSID,
-		Password: s.cfg.TwilioAuthToken,
-	})
-
-	var message string
-	childrenInfo := ""
-	if len(selectedChildren) > 0 {
-		childrenInfo = fmt.Sprintf("\n\nEste evento es para: %s", fmt.Sprintf("%v", selectedChildren))
-	}
-
-	switch reminderType {
-	case "day_before":
-		message = fmt.Sprintf("Hola %s! Te recordamos que mañana tenés: %s el %s a las %s%s. ¡No te lo pierdas!",
-			recipient.Name, event.Title, event.Date.Format("02/01/2006"), event.Time, childrenInfo)
-	case "same_day":
-		message = fmt.Sprintf("Hola %s! Te recordamos que hoy tenés: %s a las %s%s. ¡Que tengas un buen día!",
-			recipient.Name, event.Title, event.Time, childrenInfo)
-	}
-
-	params := &twilioApi.CreateMessageParams{}
-	params.SetTo("whatsapp:" + recipient.Phone)
-	params.SetFrom("whatsapp:" + s.cfg.TwilioPhoneNumber)
-	params.SetBody(message)
-
-	resp, err := client.Api.CreateMessage(params)
-	if err != nil {
-		return fmt.Errorf("failed to send family WhatsApp: %v", err)
+// locationLine devuelve la línea de ubicación del email, vacía si el evento no tiene
+func locationLine(event *models.Event) string {
+	if event.Location != "" {
+		return fmt.Sprintf("Ubicación: %s", event.Location)
 	}
-
-	log.Printf("Family WhatsApp sent successfully to %s (%s), SID: %s", recipient.Phone, recipient.Name, *resp.Sid)
-	return nil
+	return ""
 }
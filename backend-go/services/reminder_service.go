@@ -0,0 +1,87 @@
+package services
+
+import (
+	"calendar-backend/models"
+	"calendar-backend/reminders"
+	"calendar-backend/repositories"
+	"fmt"
+	"log"
+	"time"
+)
+
+// ReminderService calcula el instante exacto de cada recordatorio y, al vencer,
+// lo encola en el outbox a través del motor de recordatorios
+type ReminderService struct {
+	eventRepo repositories.EventRepository
+	outbox    *NotificationOutbox
+	engine    *reminders.Engine
+}
+
+func NewReminderService(eventRepo repositories.EventRepository, outbox *NotificationOutbox, checkpointRepo repositories.ReminderCheckpointRepository, catchUpWindow time.Duration) *ReminderService {
+	s := &ReminderService{
+		eventRepo: eventRepo,
+		outbox:    outbox,
+	}
+	s.engine = reminders.NewEngine(s.dueReminders, checkpointRepo, catchUpWindow)
+	return s
+}
+
+// Start arranca el motor, recuperando los recordatorios perdidos mientras el proceso estaba detenido
+func (s *ReminderService) Start() {
+	log.Println("🔔 Starting reminder engine...")
+	s.engine.Start()
+}
+
+// Stop detiene el motor
+func (s *ReminderService) Stop() {
+	s.engine.Stop()
+}
+
+// Reschedule recalcula los recordatorios pendientes luego de crear, modificar o eliminar
+// eventos; también envía en el momento los que ya vencieron
+func (s *ReminderService) Reschedule() {
+	s.engine.Reschedule()
+}
+
+// dueReminders devuelve los recordatorios que vencen en (from, to]
+func (s *ReminderService) dueReminders(from, to time.Time) ([]reminders.Reminder, error) {
+	// Un recordatorio se envía como mucho un día antes del evento
+	startDay := from.UTC().Truncate(24 * time.Hour)
+	endDay := to.Add(models.DayBeforeReminderOffset).UTC().Truncate(24*time.Hour).AddDate(0, 0, 1)
+
+	events, err := s.eventRepo.GetEventsByDateRange(startDay, endDay)
+	if err != nil {
+		return nil, fmt.Errorf("error getting events for reminders: %v", err)
+	}
+
+	var due []reminders.Reminder
+	for _, event := range events {
+		for _, reminder := range event.Reminders() {
+			if !reminder.DueAt.After(from) || reminder.DueAt.After(to) {
+				continue
+			}
+			due = append(due, s.newReminder(event, reminder))
+		}
+	}
+	return due, nil
+}
+
+// newReminder arma el envío de un recordatorio: encolarlo en el outbox, que lo entrega
+// con reintentos y descarta los ya encolados
+func (s *ReminderService) newReminder(event *models.Event, reminder models.EventReminder) reminders.Reminder {
+	return reminders.Reminder{
+		DueAt:    reminder.DueAt,
+		StartsAt: event.StartsAt(),
+		Label:    fmt.Sprintf("event %d (%s)", event.ID, event.Title),
+		Send: func() error {
+			created, err := s.outbox.Enqueue(event, reminder.Type, reminder.DueAt)
+			if err != nil {
+				return err
+			}
+			if created > 0 {
+				log.Printf("🔔 Enqueued %d %s notifications for event: %s", created, reminder.Type, event.Title)
+			}
+			return nil
+		},
+	}
+}
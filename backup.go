@@ -0,0 +1,96 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"calendar-backend/backup"
+	"calendar-backend/config"
+	"calendar-backend/database"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const backupUsage = `usage: calendar-backend backup <command>
+
+commands:
+  create        back up the database into BACKUP_DIR, keeping the last BACKUP_RETENTION backups
+  list          list the backups in BACKUP_DIR
+  verify FILE   check the backup against the checksum of its manifest
+  restore FILE  validate the backup and replace the contents of the database with it`
+
+// runBackup runs the backup command against the database configured by DATABASE_URL
+func runBackup(args []string) error {
+	cfg := config.LoadConfig()
+
+	switch {
+	case len(args) == 1 && args[0] == "list":
+		return printBackups(cfg.BackupDir)
+	case len(args) == 2 && args[0] == "verify":
+		manifest, err := backup.Verify(args[1])
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s is valid (%s, schema version %d, sha256 %s)\n", args[1], manifest.Format, manifest.SchemaVersion, manifest.SHA256)
+		return nil
+	case len(args) == 1 && args[0] == "create", len(args) == 2 && args[0] == "restore":
+	default:
+		return errors.New(backupUsage)
+	}
+
+	db, err := database.Connect()
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %v", err)
+	}
+	// Only the progress is printed, not every statement
+	db = db.Session(&gorm.Session{Logger: logger.Default.LogMode(logger.Warn)})
+
+	if args[0] == "create" {
+		manifest, err := backup.Create(db, cfg.BackupDir)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Backup created: %s (%d bytes, sha256 %s)\n", manifest.Path(cfg.BackupDir), manifest.Size, manifest.SHA256)
+		removed, err := backup.Prune(cfg.BackupDir, cfg.BackupRetention)
+		if err != nil {
+			return err
+		}
+		if removed > 0 {
+			fmt.Printf("Removed %d old backups\n", removed)
+		}
+		return nil
+	}
+
+	safety, err := backup.Restore(db, args[1])
+	if safety != nil {
+		fmt.Printf("The previous contents of the database were backed up to %s\n", safety.File)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Database restored from %s. Restart the server to reload it.\n", args[1])
+	return nil
+}
+
+// printBackups prints the backups of the directory, newest first
+func printBackups(dir string) error {
+	manifests, err := backup.List(dir)
+	if err != nil {
+		return err
+	}
+	if len(manifests) == 0 {
+		fmt.Printf("No backups in %s\n", dir)
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "FILE\tFORMAT\tSCHEMA\tCREATED AT\tSIZE")
+	for _, manifest := range manifests {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%d\n", manifest.Path(dir), manifest.Format, manifest.SchemaVersion, manifest.CreatedAt.Format(time.RFC3339), manifest.Size)
+	}
+	return w.Flush()
+}
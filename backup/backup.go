@@ -0,0 +1,283 @@
+// Package backup hace copias de seguridad de la base sin detener el servidor y las restaura.
+// En SQLite la copia es la base entera, tomada con la API de backup de SQLite; en PostgreSQL es
+// un volcado lógico de los datos en el formato de pg_dump (sentencias COPY), que también se
+// puede cargar con psql. Cada copia tiene un manifiesto (<copia>.json) con su checksum SHA-256
+// y la versión del esquema.
+package backup
+
+import (
+	"calendar-backend/migrations"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrChecksumMismatch = errors.New("backup checksum does not match its manifest")
+	ErrDialectMismatch  = errors.New("backup was taken from a different kind of database")
+	ErrSchemaMismatch   = errors.New("backup schema version does not match the database")
+)
+
+// Formatos de las copias
+const (
+	FormatSQLite   = "sqlite"       // Archivo de base SQLite
+	FormatPostgres = "postgres-sql" // Volcado SQL de los datos comprimido con gzip
+)
+
+// Prefijo de los archivos de las copias; las copias previas a una restauración usan otro
+const (
+	filePrefix       = "calendar-"
+	preRestorePrefix = "pre-restore-"
+)
+
+// Manifest describe una copia de seguridad
+type Manifest struct {
+	File          string    `json:"file"` // Nombre del archivo de la copia, en el mismo directorio
+	Format        string    `json:"format"`
+	SchemaVersion int       `json:"schema_version"` // Versión de las migraciones al hacer la copia
+	CreatedAt     time.Time `json:"created_at"`
+	Size          int64     `json:"size"`
+	SHA256        string    `json:"sha256"`
+}
+
+// Path devuelve la ruta del archivo de la copia dentro del directorio
+func (m Manifest) Path(dir string) string {
+	return filepath.Join(dir, m.File)
+}
+
+// Create hace una copia de la base en el directorio, sin interrumpir las conexiones abiertas.
+// La copia se escribe en un archivo temporal y se renombra solo si terminó bien.
+func Create(db *gorm.DB, dir string) (*Manifest, error) {
+	return create(db, dir, filePrefix)
+}
+
+func create(db *gorm.DB, dir, prefix string) (*Manifest, error) {
+	format, err := formatOf(db)
+	if err != nil {
+		return nil, err
+	}
+	version, err := schemaVersion(db)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create backup directory: %v", err)
+	}
+
+	createdAt := time.Now().UTC()
+	manifest := &Manifest{
+		File:          prefix + createdAt.Format("20060102T150405.000Z") + extension(format),
+		Format:        format,
+		SchemaVersion: version,
+		CreatedAt:     createdAt,
+	}
+	path := manifest.Path(dir)
+	tmp := path + ".tmp"
+	defer os.Remove(tmp)
+
+	switch format {
+	case FormatSQLite:
+		err = backupSQLite(db, tmp)
+	case FormatPostgres:
+		err = dumpPostgres(db, tmp, version)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if manifest.SHA256, manifest.Size, err = checksum(tmp); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return nil, err
+	}
+	if err := writeManifest(dir, manifest); err != nil {
+		os.Remove(path)
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// List devuelve las copias del directorio, de la más nueva a la más vieja
+func List(dir string) ([]Manifest, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	var manifests []Manifest
+	for _, path := range paths {
+		manifest, err := readManifest(path)
+		if err != nil {
+			continue // No es un manifiesto de copia
+		}
+		manifests = append(manifests, *manifest)
+	}
+	sort.Slice(manifests, func(i, j int) bool { return manifests[i].CreatedAt.After(manifests[j].CreatedAt) })
+	return manifests, nil
+}
+
+// Verify comprueba que la copia coincida con el checksum de su manifiesto y devuelve el manifiesto
+func Verify(path string) (*Manifest, error) {
+	manifest, err := readManifest(path + ".json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup manifest: %v", err)
+	}
+	sum, size, err := checksum(path)
+	if err != nil {
+		return nil, err
+	}
+	if sum != manifest.SHA256 || size != manifest.Size {
+		return nil, ErrChecksumMismatch
+	}
+	return manifest, nil
+}
+
+// Prune borra las copias programadas más viejas, dejando las keep más nuevas, y devuelve
+// cuántas borró. Las copias previas a una restauración no se borran.
+func Prune(dir string, keep int) (int, error) {
+	manifests, err := List(dir)
+	if err != nil {
+		return 0, err
+	}
+	removed, kept := 0, 0
+	for _, manifest := range manifests {
+		if !strings.HasPrefix(manifest.File, filePrefix) {
+			continue
+		}
+		if kept < keep {
+			kept++
+			continue
+		}
+		if err := os.Remove(manifest.Path(dir)); err != nil && !os.IsNotExist(err) {
+			return removed, err
+		}
+		if err := os.Remove(manifest.Path(dir) + ".json"); err != nil && !os.IsNotExist(err) {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// Restore reemplaza el contenido de la base por el de la copia. Antes valida la copia
+// (checksum, tipo de base, versión del esquema y contenido) y hace una copia de la base
+// actual en el directorio de la copia, que devuelve. El reemplazo es atómico: si falla,
+// la base queda como estaba.
+func Restore(db *gorm.DB, path string) (*Manifest, error) {
+	manifest, err := Verify(path)
+	if err != nil {
+		return nil, err
+	}
+	format, err := formatOf(db)
+	if err != nil {
+		return nil, err
+	}
+	if manifest.Format != format {
+		return nil, fmt.Errorf("%w: backup is %s, database is %s", ErrDialectMismatch, manifest.Format, format)
+	}
+
+	switch format {
+	case FormatSQLite:
+		err = validateSQLite(path, manifest)
+	case FormatPostgres:
+		err = validatePostgres(db, path, manifest)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid backup: %w", err)
+	}
+
+	safety, err := create(db, filepath.Dir(path), preRestorePrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to back up the database before restoring: %v", err)
+	}
+
+	switch format {
+	case FormatSQLite:
+		err = restoreSQLite(db, path)
+	case FormatPostgres:
+		err = restorePostgres(db, path)
+	}
+	return safety, err
+}
+
+func formatOf(db *gorm.DB) (string, error) {
+	switch db.Dialector.Name() {
+	case "sqlite":
+		return FormatSQLite, nil
+	case "postgres":
+		return FormatPostgres, nil
+	}
+	return "", fmt.Errorf("backups do not support the %s dialect", db.Dialector.Name())
+}
+
+func extension(format string) string {
+	if format == FormatPostgres {
+		return ".sql.gz"
+	}
+	return ".db"
+}
+
+// checkKnownVersion comprueba que este binario conozca la versión del esquema de una copia
+func checkKnownVersion(version int) error {
+	if version > migrations.Latest() {
+		return fmt.Errorf("%w: backup is at schema version %d, this binary knows up to version %d", migrations.ErrSchemaTooNew, version, migrations.Latest())
+	}
+	return nil
+}
+
+func schemaVersion(db *gorm.DB) (int, error) {
+	migrator, err := migrations.New(db)
+	if err != nil {
+		return 0, err
+	}
+	return migrator.Current()
+}
+
+// checksum devuelve el SHA-256 (en hexadecimal) y el tamaño del archivo
+func checksum(path string) (string, int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, file)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), size, nil
+}
+
+func writeManifest(dir string, manifest *Manifest) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(manifest.Path(dir)+".json", append(data, '\n'), 0644)
+}
+
+func readManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, err
+	}
+	if manifest.File == "" || manifest.SHA256 == "" {
+		return nil, errors.New("not a backup manifest")
+	}
+	return &manifest, nil
+}
@@ -0,0 +1,217 @@
+package backup
+
+import (
+	"calendar-backend/migrations"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestDB abre una base SQLite migrada en un directorio temporal, con un usuario
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "calendar.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	migrator, err := migrations.New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if err := db.Exec("INSERT INTO users (email, password_hash, created_at, updated_at) VALUES (?, ?, ?, ?)",
+		"ana@example.com", "x", time.Now(), time.Now()).Error; err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// countUsers devuelve la cantidad de usuarios de la base
+func countUsers(t *testing.T, db *gorm.DB) int64 {
+	t.Helper()
+	var count int64
+	if err := db.Table("users").Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	return count
+}
+
+func TestCreateAndRestore(t *testing.T) {
+	db := newTestDB(t)
+	dir := t.TempDir()
+
+	manifest, err := Create(db, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum, size, err := checksum(manifest.Path(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if manifest.Format != FormatSQLite || manifest.SchemaVersion != migrations.Latest() || manifest.SHA256 != sum || manifest.Size != size {
+		t.Errorf("manifest %+v, want sqlite at version %d with checksum %s and size %d", manifest, migrations.Latest(), sum, size)
+	}
+	if verified, err := Verify(manifest.Path(dir)); err != nil || *verified != *manifest {
+		t.Errorf("Verify = %+v, %v, want the manifest", verified, err)
+	}
+
+	// Cambios posteriores a la copia, que la restauración descarta
+	if err := db.Exec("INSERT INTO users (email, password_hash) VALUES ('bob@example.com', 'x')").Error; err != nil {
+		t.Fatal(err)
+	}
+	safety, err := Restore(db, manifest.Path(dir))
+	if err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	if count := countUsers(t, db); count != 1 {
+		t.Errorf("%d users after restoring, want the 1 of the backup", count)
+	}
+	if !strings.HasPrefix(safety.File, preRestorePrefix) {
+		t.Errorf("safety backup %q, want the %s prefix", safety.File, preRestorePrefix)
+	}
+	if _, err := Verify(safety.Path(dir)); err != nil {
+		t.Errorf("safety backup: %v", err)
+	}
+
+	// La copia previa a la restauración tiene los cambios descartados y la retención no la borra
+	if removed, err := Prune(dir, 0); err != nil || removed != 1 {
+		t.Errorf("Prune removed %d backups (%v), want only the scheduled one", removed, err)
+	}
+	if manifests, err := List(dir); err != nil || len(manifests) != 1 || manifests[0].File != safety.File {
+		t.Fatalf("backups after pruning %+v (%v), want the safety backup", manifests, err)
+	}
+	if _, err := Restore(db, safety.Path(dir)); err != nil {
+		t.Fatalf("restore the safety backup: %v", err)
+	}
+	if count := countUsers(t, db); count != 2 {
+		t.Errorf("%d users after restoring the safety backup, want 2", count)
+	}
+}
+
+func TestRestoreRejectsInvalidBackups(t *testing.T) {
+	tests := []struct {
+		name    string
+		tamper  func(t *testing.T, path string, manifest *Manifest)
+		wantErr error // nil = cualquier error
+	}{
+		{"modified file", func(t *testing.T, path string, manifest *Manifest) {
+			appendTo(t, path, "x")
+		}, ErrChecksumMismatch},
+		{"modified manifest", func(t *testing.T, path string, manifest *Manifest) {
+			manifest.SHA256 = strings.Repeat("0", 64)
+			rewriteManifest(t, path, manifest, false)
+		}, ErrChecksumMismatch},
+		{"missing manifest", func(t *testing.T, path string, manifest *Manifest) {
+			if err := os.Remove(path + ".json"); err != nil {
+				t.Fatal(err)
+			}
+		}, nil},
+		{"other database", func(t *testing.T, path string, manifest *Manifest) {
+			manifest.Format = FormatPostgres
+			rewriteManifest(t, path, manifest, false)
+		}, ErrDialectMismatch},
+		{"manifest with another schema version", func(t *testing.T, path string, manifest *Manifest) {
+			manifest.SchemaVersion--
+			rewriteManifest(t, path, manifest, false)
+		}, ErrSchemaMismatch},
+		{"schema newer than the binary", func(t *testing.T, path string, manifest *Manifest) {
+			execOn(t, path, "INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, 'from_the_future', ?)", migrations.Latest()+1, time.Now())
+			manifest.SchemaVersion = migrations.Latest() + 1
+			rewriteManifest(t, path, manifest, true)
+		}, migrations.ErrSchemaTooNew},
+		{"not a database", func(t *testing.T, path string, manifest *Manifest) {
+			if err := os.WriteFile(path, []byte(strings.Repeat("not a database ", 512)), 0644); err != nil {
+				t.Fatal(err)
+			}
+			rewriteManifest(t, path, manifest, true)
+		}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t)
+			dir := t.TempDir()
+			manifest, err := Create(db, dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := db.Exec("INSERT INTO users (email, password_hash) VALUES ('bob@example.com', 'x')").Error; err != nil {
+				t.Fatal(err)
+			}
+
+			tt.tamper(t, manifest.Path(dir), manifest)
+			_, err = Restore(db, manifest.Path(dir))
+			if err == nil || (tt.wantErr != nil && !errors.Is(err, tt.wantErr)) {
+				t.Fatalf("restore: %v, want %v", err, tt.wantErr)
+			}
+			// Una copia rechazada no toca la base ni deja una copia previa a la restauración
+			if count := countUsers(t, db); count != 2 {
+				t.Errorf("%d users after the rejected restore, want 2", count)
+			}
+			if matches, _ := filepath.Glob(filepath.Join(dir, preRestorePrefix+"*")); len(matches) != 0 {
+				t.Errorf("rejected restore left %v", matches)
+			}
+		})
+	}
+}
+
+func TestCopyEscaping(t *testing.T) {
+	for _, value := range []string{"", `C:\tmp`, "línea\ncon\ttab\r\n", "\\N", "\b\f\v"} {
+		if got := unescapeCopy(escapeCopy(value)); got != value {
+			t.Errorf("unescapeCopy(escapeCopy(%q)) = %q", value, got)
+		}
+	}
+	if got := unescapeCopy(`\101\x42\\`); got != `AB\` {
+		t.Errorf("unescapeCopy of octal and hex escapes = %q, want %q", got, `AB\`)
+	}
+}
+
+func appendTo(t *testing.T, path, text string) {
+	t.Helper()
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if _, err := file.WriteString(text); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// rewriteManifest guarda el manifiesto de la copia; con rehash, con el checksum del archivo actual
+func rewriteManifest(t *testing.T, path string, manifest *Manifest, rehash bool) {
+	t.Helper()
+	if rehash {
+		var err error
+		if manifest.SHA256, manifest.Size, err = checksum(path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writeManifest(filepath.Dir(path), manifest); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// execOn ejecuta una sentencia en la base SQLite del archivo
+func execOn(t *testing.T, path, statement string, args ...interface{}) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(path), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := db.DB()
+	defer sqlDB.Close()
+	if err := db.Exec(statement, args...).Error; err != nil {
+		t.Fatal(err)
+	}
+}
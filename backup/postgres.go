@@ -0,0 +1,500 @@
+package backup
+
+import (
+	"bufio"
+	"compress/gzip"
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// El volcado es de solo datos, como el de pg_dump --data-only: se carga sobre una base migrada
+// a la misma versión del esquema. Vacía las tablas, copia las filas con COPY (en el orden de
+// sus claves foráneas) y ajusta las secuencias, todo en una transacción.
+const dumpHeader = `--
+-- Calendar backend data dump (pg_dump compatible, data only)
+-- Schema version: %d
+--
+-- Load it into a database migrated to the same schema version:
+--   gunzip -c FILE | psql "$DATABASE_URL"
+--
+
+SET statement_timeout = 0;
+SET client_encoding = 'UTF8';
+SET standard_conforming_strings = on;
+
+BEGIN;
+
+`
+
+// Sentencias del volcado, además de los bloques COPY
+var (
+	copyPattern     = regexp.MustCompile(`^COPY ("(?:[^"]|"")+") \(((?:"(?:[^"]|"")+"(?:, )?)+)\) FROM stdin;$`)
+	truncatePattern = regexp.MustCompile(`^TRUNCATE TABLE ((?:"(?:[^"]|"")+"(?:, )?)+) RESTART IDENTITY CASCADE;$`)
+	setvalPattern   = regexp.MustCompile(`^SELECT pg_catalog\.setval\('(?:[^']|'')+', \d+, (?:true|false)\);$`)
+	versionPattern  = regexp.MustCompile(`^-- Schema version: (\d+)$`)
+	identPattern    = regexp.MustCompile(`"((?:[^"]|"")+)"`)
+)
+
+// Máximo de parámetros por INSERT al restaurar (PostgreSQL admite 65535)
+const maxInsertParams = 60000
+
+// pgTable es una tabla del esquema con sus columnas, sin las generadas
+type pgTable struct {
+	name    string
+	columns []string
+}
+
+// dumpPostgres escribe el volcado de los datos, comprimido con gzip, leyendo todas las tablas
+// en una transacción de solo lectura: es una foto consistente aunque haya escrituras
+func dumpPostgres(db *gorm.DB, path string, version int) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	gz := gzip.NewWriter(file)
+	w := bufio.NewWriter(gz)
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SET TRANSACTION ISOLATION LEVEL REPEATABLE READ, READ ONLY").Error; err != nil {
+			return err
+		}
+		tables, err := postgresTables(tx)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(w, dumpHeader, version)
+		names := make([]string, len(tables))
+		for i, table := range tables {
+			names[i] = quoteIdent(table.name)
+		}
+		fmt.Fprintf(w, "TRUNCATE TABLE %s RESTART IDENTITY CASCADE;\n\n", strings.Join(names, ", "))
+
+		for _, table := range tables {
+			if err := dumpTable(tx, w, table); err != nil {
+				return fmt.Errorf("failed to dump %s: %v", table.name, err)
+			}
+		}
+		for _, table := range tables {
+			if err := dumpSequences(tx, w, table); err != nil {
+				return err
+			}
+		}
+		_, err = w.WriteString("\nCOMMIT;\n")
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+	return file.Sync()
+}
+
+// dumpTable escribe las filas de la tabla en un bloque COPY, en el formato de texto de PostgreSQL
+func dumpTable(tx *gorm.DB, w *bufio.Writer, table pgTable) error {
+	quoted := make([]string, len(table.columns))
+	selects := make([]string, len(table.columns))
+	for i, column := range table.columns {
+		quoted[i] = quoteIdent(column)
+		selects[i] = quoteIdent(column) + "::text"
+	}
+
+	rows, err := tx.Raw("SELECT " + strings.Join(selects, ", ") + " FROM " + quoteIdent(table.name)).Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	fmt.Fprintf(w, "COPY %s (%s) FROM stdin;\n", quoteIdent(table.name), strings.Join(quoted, ", "))
+	values := make([]sql.NullString, len(table.columns))
+	pointers := make([]interface{}, len(values))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(pointers...); err != nil {
+			return err
+		}
+		for i, value := range values {
+			if i > 0 {
+				w.WriteByte('\t')
+			}
+			if !value.Valid {
+				w.WriteString(`\N`)
+				continue
+			}
+			w.WriteString(escapeCopy(value.String))
+		}
+		w.WriteByte('\n')
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	_, err = w.WriteString("\\.\n\n")
+	return err
+}
+
+// dumpSequences escribe el valor de las secuencias de las columnas de la tabla (ej: los IDs)
+func dumpSequences(tx *gorm.DB, w *bufio.Writer, table pgTable) error {
+	for _, column := range table.columns {
+		var sequence sql.NullString
+		if err := tx.Raw("SELECT pg_get_serial_sequence(?, ?)", quoteIdent(table.name), column).Scan(&sequence).Error; err != nil {
+			return err
+		}
+		if !sequence.Valid {
+			continue
+		}
+		var state struct {
+			LastValue int64
+			IsCalled  bool
+		}
+		if err := tx.Raw("SELECT last_value, is_called FROM " + sequence.String).Scan(&state).Error; err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "SELECT pg_catalog.setval(%s, %d, %t);\n", quoteLiteral(sequence.String), state.LastValue, state.IsCalled)
+	}
+	return nil
+}
+
+// postgresTables devuelve las tablas del esquema actual ordenadas de forma que cada una
+// aparezca después de las que referencian sus claves foráneas
+func postgresTables(tx *gorm.DB) ([]pgTable, error) {
+	var columns []struct {
+		TableName  string
+		ColumnName string
+	}
+	if err := tx.Raw(`SELECT c.table_name, c.column_name
+		FROM information_schema.columns c
+		JOIN information_schema.tables t ON t.table_schema = c.table_schema AND t.table_name = c.table_name
+		WHERE c.table_schema = current_schema() AND t.table_type = 'BASE TABLE' AND c.is_generated = 'NEVER'
+		ORDER BY c.table_name, c.ordinal_position`).Scan(&columns).Error; err != nil {
+		return nil, err
+	}
+	var references []struct {
+		TableName      string
+		ReferencedName string
+	}
+	if err := tx.Raw(`SELECT cl.relname AS table_name, ref.relname AS referenced_name
+		FROM pg_constraint con
+		JOIN pg_class cl ON cl.oid = con.conrelid
+		JOIN pg_class ref ON ref.oid = con.confrelid
+		JOIN pg_namespace ns ON ns.oid = cl.relnamespace
+		WHERE con.contype = 'f' AND ns.nspname = current_schema()`).Scan(&references).Error; err != nil {
+		return nil, err
+	}
+
+	byName := make(map[string]*pgTable)
+	var names []string
+	for _, column := range columns {
+		table, ok := byName[column.TableName]
+		if !ok {
+			table = &pgTable{name: column.TableName}
+			byName[column.TableName] = table
+			names = append(names, column.TableName)
+		}
+		table.columns = append(table.columns, column.ColumnName)
+	}
+	dependencies := make(map[string][]string)
+	for _, reference := range references {
+		if reference.TableName != reference.ReferencedName {
+			dependencies[reference.TableName] = append(dependencies[reference.TableName], reference.ReferencedName)
+		}
+	}
+
+	// Orden topológico; los ciclos (que el esquema no tiene) quedan en orden alfabético
+	var sorted []pgTable
+	state := make(map[string]int) // 1 = visitando, 2 = agregada
+	var visit func(name string)
+	visit = func(name string) {
+		if state[name] != 0 || byName[name] == nil {
+			return
+		}
+		state[name] = 1
+		for _, dependency := range dependencies[name] {
+			visit(dependency)
+		}
+		state[name] = 2
+		sorted = append(sorted, *byName[name])
+	}
+	for _, name := range names {
+		visit(name)
+	}
+	return sorted, nil
+}
+
+// dumpReader recorre las sentencias de un volcado
+type dumpReader struct {
+	version   int
+	statement func(sql string) error                     // TRUNCATE y setval
+	copyStart func(table string, columns []string) error // Inicio de un bloque COPY
+	row       func(values []interface{}) error           // Fila de un bloque COPY (nil = NULL)
+	copyEnd   func() error
+}
+
+// read recorre el volcado y falla ante cualquier sentencia que no sea de un volcado de datos
+func (r *dumpReader) read(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	reader := bufio.NewReader(gz)
+	lineNumber, columns, committed := 0, 0, false
+	r.version = -1
+	for {
+		line, err := reader.ReadString('\n')
+		if err == io.EOF && line == "" {
+			break
+		}
+		if err != nil && err != io.EOF {
+			return err
+		}
+		lineNumber++
+		line = strings.TrimSuffix(line, "\n")
+
+		// Dentro de un bloque COPY: filas hasta \.
+		if columns > 0 {
+			if line == `\.` {
+				columns = 0
+				if err := r.copyEnd(); err != nil {
+					return err
+				}
+				continue
+			}
+			fields := strings.Split(line, "\t")
+			if len(fields) != columns {
+				return fmt.Errorf("line %d: expected %d values, got %d", lineNumber, columns, len(fields))
+			}
+			values := make([]interface{}, len(fields))
+			for i, field := range fields {
+				if field != `\N` {
+					values[i] = unescapeCopy(field)
+				}
+			}
+			if err := r.row(values); err != nil {
+				return fmt.Errorf("line %d: %v", lineNumber, err)
+			}
+			continue
+		}
+
+		switch {
+		case versionPattern.MatchString(line):
+			r.version, _ = strconv.Atoi(versionPattern.FindStringSubmatch(line)[1])
+		case line == "" || strings.HasPrefix(line, "--"):
+		case strings.HasPrefix(line, "SET ") || line == "BEGIN;":
+			// La transacción y la sesión las maneja quien restaura
+		case line == "COMMIT;":
+			committed = true
+		case truncatePattern.MatchString(line), setvalPattern.MatchString(line):
+			if err := r.statement(line); err != nil {
+				return fmt.Errorf("line %d: %v", lineNumber, err)
+			}
+		case copyPattern.MatchString(line):
+			match := copyPattern.FindStringSubmatch(line)
+			table := unquoteIdents(match[1])[0]
+			copyColumns := unquoteIdents(match[2])
+			if err := r.copyStart(table, copyColumns); err != nil {
+				return fmt.Errorf("line %d: %v", lineNumber, err)
+			}
+			columns = len(copyColumns)
+		default:
+			return fmt.Errorf("line %d: unexpected statement", lineNumber)
+		}
+	}
+
+	if columns > 0 || !committed {
+		return fmt.Errorf("dump is truncated")
+	}
+	if r.version < 0 {
+		return fmt.Errorf("dump has no schema version")
+	}
+	return nil
+}
+
+// validatePostgres recorre el volcado completo y comprueba que sus tablas y columnas existan en
+// la base, que esté en la versión del esquema del volcado
+func validatePostgres(db *gorm.DB, path string, manifest *Manifest) error {
+	current, err := schemaVersion(db)
+	if err != nil {
+		return err
+	}
+	if current != manifest.SchemaVersion {
+		return fmt.Errorf("%w: backup is at version %d, database at version %d (migrate the database to version %d first)", ErrSchemaMismatch, manifest.SchemaVersion, current, manifest.SchemaVersion)
+	}
+	tables, err := postgresTables(db)
+	if err != nil {
+		return err
+	}
+	known := make(map[string]map[string]bool)
+	for _, table := range tables {
+		known[table.name] = make(map[string]bool)
+		for _, column := range table.columns {
+			known[table.name][column] = true
+		}
+	}
+
+	reader := &dumpReader{
+		statement: func(string) error { return nil },
+		copyStart: func(table string, columns []string) error {
+			if known[table] == nil {
+				return fmt.Errorf("unknown table %s", table)
+			}
+			for _, column := range columns {
+				if !known[table][column] {
+					return fmt.Errorf("unknown column %s.%s", table, column)
+				}
+			}
+			return nil
+		},
+		row:     func([]interface{}) error { return nil },
+		copyEnd: func() error { return nil },
+	}
+	if err := reader.read(path); err != nil {
+		return err
+	}
+	if reader.version != manifest.SchemaVersion {
+		return fmt.Errorf("%w: dump is at version %d, manifest says %d", ErrSchemaMismatch, reader.version, manifest.SchemaVersion)
+	}
+	return nil
+}
+
+// restorePostgres carga el volcado en una transacción: si algo falla, la base queda como estaba
+func restorePostgres(db *gorm.DB, path string) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var insert string
+		var columns, batchSize int
+		var batch []interface{}
+
+		flush := func() error {
+			if len(batch) == 0 {
+				return nil
+			}
+			rows := len(batch) / columns
+			placeholders := "(" + strings.TrimSuffix(strings.Repeat("?, ", columns), ", ") + ")"
+			statement := insert + strings.TrimSuffix(strings.Repeat(placeholders+", ", rows), ", ")
+			err := tx.Exec(statement, batch...).Error
+			batch = batch[:0]
+			return err
+		}
+
+		reader := &dumpReader{
+			statement: func(statement string) error {
+				return tx.Exec(statement).Error
+			},
+			copyStart: func(table string, copyColumns []string) error {
+				quoted := make([]string, len(copyColumns))
+				for i, column := range copyColumns {
+					quoted[i] = quoteIdent(column)
+				}
+				insert = "INSERT INTO " + quoteIdent(table) + " (" + strings.Join(quoted, ", ") + ") VALUES "
+				columns = len(copyColumns)
+				batchSize = maxInsertParams / columns
+				if batchSize > 500 {
+					batchSize = 500
+				}
+				return nil
+			},
+			row: func(values []interface{}) error {
+				batch = append(batch, values...)
+				if len(batch)/columns >= batchSize {
+					return flush()
+				}
+				return nil
+			},
+			copyEnd: flush,
+		}
+		return reader.read(path)
+	})
+}
+
+// Caracteres que el formato de texto de COPY escapa con una barra
+var (
+	copyEscaper   = strings.NewReplacer(`\`, `\\`, "\b", `\b`, "\f", `\f`, "\n", `\n`, "\r", `\r`, "\t", `\t`, "\v", `\v`)
+	copyUnescapes = map[byte]byte{'b': '\b', 'f': '\f', 'n': '\n', 'r': '\r', 't': '\t', 'v': '\v'}
+)
+
+func escapeCopy(value string) string {
+	return copyEscaper.Replace(value)
+}
+
+// unescapeCopy interpreta los escapes del formato de texto de COPY, incluidos los octales
+// (\NNN) y hexadecimales (\xHH) que puede escribir pg_dump
+func unescapeCopy(value string) string {
+	if !strings.Contains(value, `\`) {
+		return value
+	}
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if c != '\\' || i+1 == len(value) {
+			b.WriteByte(c)
+			continue
+		}
+		i++
+		next := value[i]
+		switch {
+		case copyUnescapes[next] != 0:
+			b.WriteByte(copyUnescapes[next])
+		case next >= '0' && next <= '7':
+			end := i + 1
+			for end < len(value) && end < i+3 && value[end] >= '0' && value[end] <= '7' {
+				end++
+			}
+			n, _ := strconv.ParseUint(value[i:end], 8, 8)
+			b.WriteByte(byte(n))
+			i = end - 1
+		case next == 'x' && i+1 < len(value):
+			end := i + 1
+			for end < len(value) && end < i+3 && strings.IndexByte("0123456789abcdefABCDEF", value[end]) >= 0 {
+				end++
+			}
+			if end == i+1 {
+				b.WriteByte(next)
+				continue
+			}
+			n, _ := strconv.ParseUint(value[i+1:end], 16, 8)
+			b.WriteByte(byte(n))
+			i = end - 1
+		default:
+			b.WriteByte(next)
+		}
+	}
+	return b.String()
+}
+
+func quoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func quoteLiteral(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
+
+// unquoteIdents devuelve los identificadores entre comillas de una lista
+func unquoteIdents(list string) []string {
+	var names []string
+	for _, match := range identPattern.FindAllStringSubmatch(list, -1) {
+		names = append(names, strings.ReplaceAll(match[1], `""`, `"`))
+	}
+	return names
+}
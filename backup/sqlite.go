@@ -0,0 +1,108 @@
+package backup
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/mattn/go-sqlite3"
+	"gorm.io/gorm"
+)
+
+// backupSQLite copia la base a un archivo nuevo con la API de backup de SQLite: la copia es
+// consistente aunque haya escrituras y no cierra las conexiones del servidor
+func backupSQLite(db *gorm.DB, path string) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	dest, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return err
+	}
+	defer dest.Close()
+	return copySQLite(dest, sqlDB)
+}
+
+// restoreSQLite reemplaza el contenido de la base por el del archivo, con la API de backup:
+// las páginas se copian en una transacción, así que los demás ven la base vieja o la nueva
+func restoreSQLite(db *gorm.DB, path string) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	src, err := openReadOnly(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	return copySQLite(sqlDB, src)
+}
+
+// copySQLite copia la base principal de src a dest en un solo paso
+func copySQLite(dest, src *sql.DB) error {
+	ctx := context.Background()
+	destConn, err := dest.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer destConn.Close()
+	srcConn, err := src.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer srcConn.Close()
+
+	return destConn.Raw(func(destDriver interface{}) error {
+		return srcConn.Raw(func(srcDriver interface{}) error {
+			destSQLite, ok := destDriver.(*sqlite3.SQLiteConn)
+			srcSQLite, ok2 := srcDriver.(*sqlite3.SQLiteConn)
+			if !ok || !ok2 {
+				return errors.New("backup requires the mattn/go-sqlite3 driver")
+			}
+
+			backup, err := destSQLite.Backup("main", srcSQLite, "main")
+			if err != nil {
+				return err
+			}
+			// -1 copia todas las páginas de una vez, sobre una foto consistente de la base
+			if _, err := backup.Step(-1); err != nil {
+				backup.Finish()
+				return err
+			}
+			return backup.Finish()
+		})
+	})
+}
+
+// validateSQLite comprueba que la copia sea una base íntegra, con la versión del esquema de
+// su manifiesto y que este binario conozca
+func validateSQLite(path string, manifest *Manifest) error {
+	db, err := openReadOnly(path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	var result string
+	if err := db.QueryRow("PRAGMA integrity_check").Scan(&result); err != nil {
+		return err
+	}
+	if result != "ok" {
+		return fmt.Errorf("integrity check failed: %s", result)
+	}
+
+	var version sql.NullInt64
+	if err := db.QueryRow("SELECT MAX(version) FROM schema_migrations").Scan(&version); err != nil {
+		return fmt.Errorf("failed to read schema version: %v", err)
+	}
+	if int(version.Int64) != manifest.SchemaVersion {
+		return fmt.Errorf("%w: file is at version %d, manifest says %d", ErrSchemaMismatch, version.Int64, manifest.SchemaVersion)
+	}
+	return checkKnownVersion(manifest.SchemaVersion)
+}
+
+func openReadOnly(path string) (*sql.DB, error) {
+	return sql.Open("sqlite3", "file:"+path+"?mode=ro")
+}
@@ -0,0 +1,129 @@
+package caldav
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Prefijos con los que se escriben los espacios de nombres conocidos
+var prefixes = map[string]string{
+	NamespaceDAV:            "d",
+	NamespaceCalDAV:         "c",
+	NamespaceCalendarServer: "cs",
+	NamespaceAppleICal:      "ic",
+}
+
+// Property es una propiedad con su valor XML ya armado
+type Property struct {
+	Name  xml.Name
+	Value string
+}
+
+// Response es el resultado de un recurso dentro de un multistatus
+type Response struct {
+	Href     string
+	Found    []Property
+	NotFound []xml.Name
+	// Status es el estado del recurso entero (ej: 404 de un recurso eliminado en
+	// sync-collection); si no es cero se ignoran las propiedades
+	Status int
+}
+
+// Multistatus es una respuesta 207 Multi-Status
+type Multistatus struct {
+	Responses []Response
+	SyncToken string // Solo en sync-collection
+}
+
+// Bytes serializa la respuesta
+func (m *Multistatus) Bytes() []byte {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	buf.WriteString(`<d:multistatus`)
+	for _, ns := range []string{NamespaceDAV, NamespaceCalDAV, NamespaceCalendarServer, NamespaceAppleICal} {
+		fmt.Fprintf(&buf, ` xmlns:%s="%s"`, prefixes[ns], ns)
+	}
+	buf.WriteString(">")
+
+	for _, response := range m.Responses {
+		buf.WriteString("<d:response>")
+		buf.WriteString(Href(response.Href))
+		if response.Status != 0 {
+			buf.WriteString(Element(xml.Name{Space: NamespaceDAV, Local: "status"}, Text(statusLine(response.Status))))
+		} else {
+			if len(response.Found) > 0 || len(response.NotFound) == 0 {
+				var props bytes.Buffer
+				for _, prop := range response.Found {
+					props.WriteString(Element(prop.Name, prop.Value))
+				}
+				writePropStat(&buf, props.String(), http.StatusOK)
+			}
+			if len(response.NotFound) > 0 {
+				var props bytes.Buffer
+				for _, name := range response.NotFound {
+					props.WriteString(Element(name, ""))
+				}
+				writePropStat(&buf, props.String(), http.StatusNotFound)
+			}
+		}
+		buf.WriteString("</d:response>")
+	}
+
+	if m.SyncToken != "" {
+		buf.WriteString(Element(xml.Name{Space: NamespaceDAV, Local: "sync-token"}, Text(m.SyncToken)))
+	}
+	buf.WriteString("</d:multistatus>")
+	return buf.Bytes()
+}
+
+func writePropStat(buf *bytes.Buffer, props string, status int) {
+	buf.WriteString("<d:propstat>")
+	buf.WriteString(Element(xml.Name{Space: NamespaceDAV, Local: "prop"}, props))
+	buf.WriteString(Element(xml.Name{Space: NamespaceDAV, Local: "status"}, Text(statusLine(status))))
+	buf.WriteString("</d:propstat>")
+}
+
+func statusLine(status int) string {
+	return fmt.Sprintf("HTTP/1.1 %d %s", status, http.StatusText(status))
+}
+
+// Element arma un elemento con su contenido XML; los espacios de nombres desconocidos
+// se declaran en el propio elemento
+func Element(name xml.Name, inner string) string {
+	tag, decl := name.Local, ""
+	if prefix, ok := prefixes[name.Space]; ok {
+		tag = prefix + ":" + name.Local
+	} else if name.Space != "" {
+		tag = "x:" + name.Local
+		decl = ` xmlns:x="` + Text(name.Space) + `"`
+	}
+	if inner == "" {
+		return "<" + tag + decl + "/>"
+	}
+	return "<" + tag + decl + ">" + inner + "</" + tag + ">"
+}
+
+// Elements arma una lista de elementos vacíos (ej: el resourcetype de un calendario)
+func Elements(names ...xml.Name) string {
+	var buf bytes.Buffer
+	for _, name := range names {
+		buf.WriteString(Element(name, ""))
+	}
+	return buf.String()
+}
+
+// Href arma un elemento <d:href>
+func Href(href string) string {
+	return Element(xml.Name{Space: NamespaceDAV, Local: "href"}, Text(href))
+}
+
+// Text escapa un valor de texto. Los saltos de línea se dejan literales para que el
+// calendar-data siga siendo legible.
+func Text(value string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(value))
+	return strings.ReplaceAll(buf.String(), "&#xA;", "\n")
+}
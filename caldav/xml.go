@@ -0,0 +1,125 @@
+// Package caldav lee los cuerpos XML de las peticiones WebDAV/CalDAV (RFC 4918, RFC 4791,
+// RFC 6578) y arma las respuestas multistatus.
+package caldav
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+	"time"
+)
+
+// Espacios de nombres usados por los clientes CalDAV
+const (
+	NamespaceDAV            = "DAV:"
+	NamespaceCalDAV         = "urn:ietf:params:xml:ns:caldav"
+	NamespaceCalendarServer = "http://calendarserver.org/ns/"
+	NamespaceAppleICal      = "http://apple.com/ns/ical/"
+)
+
+// Elementos raíz de los cuerpos de PROPFIND y REPORT
+var (
+	PropFind         = xml.Name{Space: NamespaceDAV, Local: "propfind"}
+	CalendarQuery    = xml.Name{Space: NamespaceCalDAV, Local: "calendar-query"}
+	CalendarMultiget = xml.Name{Space: NamespaceCalDAV, Local: "calendar-multiget"}
+	SyncCollection   = xml.Name{Space: NamespaceDAV, Local: "sync-collection"}
+)
+
+const timeRangeLayout = "20060102T150405Z"
+
+// Request es el cuerpo de un PROPFIND o REPORT
+type Request struct {
+	Kind      xml.Name   // Elemento raíz
+	AllProp   bool       // Se piden todas las propiedades (o el cuerpo estaba vacío)
+	PropName  bool       // Se piden solo los nombres de las propiedades
+	Props     []xml.Name // Propiedades pedidas en <prop>
+	Hrefs     []string   // Recursos pedidos (calendar-multiget)
+	SyncToken string     // Token de la sincronización anterior (sync-collection)
+	TimeRange *TimeRange // Filtro time-range de calendar-query
+}
+
+// TimeRange es un filtro por rango de tiempo; los extremos cero no limitan
+type TimeRange struct {
+	Start time.Time
+	End   time.Time
+}
+
+// ParseRequest lee el cuerpo de un PROPFIND o REPORT. Un cuerpo vacío equivale a allprop.
+func ParseRequest(r io.Reader) (*Request, error) {
+	req := &Request{}
+	decoder := xml.NewDecoder(r)
+
+	var stack []xml.Name
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.New("invalid XML body")
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			parent := xml.Name{}
+			if len(stack) > 0 {
+				parent = stack[len(stack)-1]
+			}
+			stack = append(stack, t.Name)
+
+			switch {
+			case len(stack) == 1:
+				req.Kind = t.Name
+			case parent == xml.Name{Space: NamespaceDAV, Local: "prop"} && len(stack) == 3:
+				req.Props = append(req.Props, t.Name)
+			case t.Name == xml.Name{Space: NamespaceDAV, Local: "allprop"}:
+				req.AllProp = true
+			case t.Name == xml.Name{Space: NamespaceDAV, Local: "propname"}:
+				req.PropName = true
+			case t.Name == xml.Name{Space: NamespaceCalDAV, Local: "time-range"} && req.TimeRange == nil:
+				timeRange, err := parseTimeRange(t.Attr)
+				if err != nil {
+					return nil, err
+				}
+				req.TimeRange = timeRange
+			}
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) != 2 {
+				continue
+			}
+			value := strings.TrimSpace(string(t))
+			switch stack[1] {
+			case xml.Name{Space: NamespaceDAV, Local: "href"}:
+				req.Hrefs = append(req.Hrefs, value)
+			case xml.Name{Space: NamespaceDAV, Local: "sync-token"}:
+				req.SyncToken = value
+			}
+		}
+	}
+
+	if req.Kind.Local == "" {
+		req.Kind = PropFind
+		req.AllProp = true
+	}
+	return req, nil
+}
+
+func parseTimeRange(attrs []xml.Attr) (*TimeRange, error) {
+	timeRange := &TimeRange{}
+	for _, attr := range attrs {
+		t, err := time.Parse(timeRangeLayout, attr.Value)
+		if err != nil {
+			return nil, errors.New("invalid time-range " + attr.Name.Local)
+		}
+		switch attr.Name.Local {
+		case "start":
+			timeRange.Start = t
+		case "end":
+			timeRange.End = t
+		}
+	}
+	return timeRange, nil
+}
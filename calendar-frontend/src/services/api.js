@@ -58,12 +58,25 @@ api.interceptors.response.use(
   }
 );
 
+// Obtener todas las páginas de GET /api/v1/events siguiendo el cursor de X-Next-Cursor
+const fetchAllEvents = async (params = {}) => {
+  const events = [];
+  let cursor = null;
+  do {
+    const response = await api.get('/api/v1/events/', {
+      params: cursor ? { ...params, cursor } : params
+    });
+    events.push(...response.data);
+    cursor = response.headers['x-next-cursor'];
+  } while (cursor);
+  return events;
+};
+
 // Servicios de eventos
 export const eventService = {
   // Obtener todos los eventos
   getAllEvents: async (params = {}) => {
-    const response = await api.get('/api/v1/events/', { params });
-    return response.data;
+    return fetchAllEvents(params);
   },
 
   // Obtener evento por ID
@@ -95,26 +108,17 @@ export const eventService = {
 
   // Buscar eventos
   searchEvents: async (query) => {
-    const response = await api.get('/api/v1/events/', {
-      params: { search: query }
-    });
-    return response.data;
+    return fetchAllEvents({ search: query });
   },
 
   // Obtener eventos por fecha
   getEventsByDate: async (date) => {
-    const response = await api.get('/api/v1/events/', {
-      params: { date }
-    });
-    return response.data;
+    return fetchAllEvents({ date });
   },
 
   // Obtener eventos por rango de fechas
   getEventsByDateRange: async (startDate, endDate) => {
-    const response = await api.get('/api/v1/events/', {
-      params: { start_date: startDate, end_date: endDate }
-    });
-    return response.data;
+    return fetchAllEvents({ start_date: startDate, end_date: endDate });
   },
 };
 
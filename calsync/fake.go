@@ -0,0 +1,142 @@
+package calsync
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// FakeProvider es un proveedor en memoria para probar la sincronización sin red.
+// Cada calendario guarda sus eventos y un número de secuencia por cambio, que se usa
+// como token y como versión de los eventos.
+type FakeProvider struct {
+	mu        sync.Mutex
+	calendars map[string]*fakeCalendar
+}
+
+type fakeCalendar struct {
+	seq     int64
+	nextID  int
+	events  map[string]RemoteEvent // Incluye los eliminados (Deleted), para informarlos en Changes
+	changed map[string]int64       // Secuencia del último cambio de cada evento
+}
+
+// NewFakeProvider crea un proveedor en memoria vacío
+func NewFakeProvider() *FakeProvider {
+	return &FakeProvider{calendars: make(map[string]*fakeCalendar)}
+}
+
+func (p *FakeProvider) Name() string {
+	return "fake"
+}
+
+func (p *FakeProvider) Changes(ctx context.Context, account Account, token string) ([]RemoteEvent, string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	since := int64(0)
+	if token != "" {
+		var err error
+		if since, err = strconv.ParseInt(token, 10, 64); err != nil {
+			return nil, "", ErrTokenExpired
+		}
+	}
+
+	calendar := p.calendar(account.CalendarID)
+	var changes []RemoteEvent
+	for id, event := range calendar.events {
+		if calendar.changed[id] > since && (token != "" || !event.Deleted) {
+			changes = append(changes, event)
+		}
+	}
+	return changes, strconv.FormatInt(calendar.seq, 10), nil
+}
+
+func (p *FakeProvider) Upsert(ctx context.Context, account Account, event RemoteEvent) (RemoteEvent, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	calendar := p.calendar(account.CalendarID)
+	if event.ID == "" {
+		calendar.nextID++
+		event.ID = fmt.Sprintf("fake-%d", calendar.nextID)
+	} else if existing, ok := calendar.events[event.ID]; !ok || existing.Deleted {
+		return RemoteEvent{}, ErrNotFound
+	}
+	return calendar.save(event, time.Now()), nil
+}
+
+func (p *FakeProvider) Delete(ctx context.Context, account Account, id string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	calendar := p.calendar(account.CalendarID)
+	event, ok := calendar.events[id]
+	if !ok || event.Deleted {
+		return ErrNotFound
+	}
+	event.Deleted = true
+	calendar.save(event, time.Now())
+	return nil
+}
+
+// Put simula que el usuario crea o edita un evento en el calendario externo en el instante at
+func (p *FakeProvider) Put(calendarID string, event RemoteEvent, at time.Time) RemoteEvent {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	calendar := p.calendar(calendarID)
+	if event.ID == "" {
+		calendar.nextID++
+		event.ID = fmt.Sprintf("fake-%d", calendar.nextID)
+	}
+	event.Deleted = false
+	return calendar.save(event, at)
+}
+
+// Remove simula que el usuario elimina un evento en el calendario externo en el instante at
+func (p *FakeProvider) Remove(calendarID, id string, at time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	calendar := p.calendar(calendarID)
+	if event, ok := calendar.events[id]; ok && !event.Deleted {
+		event.Deleted = true
+		calendar.save(event, at)
+	}
+}
+
+// Events devuelve los eventos vigentes del calendario
+func (p *FakeProvider) Events(calendarID string) []RemoteEvent {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var events []RemoteEvent
+	for _, event := range p.calendar(calendarID).events {
+		if !event.Deleted {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+func (p *FakeProvider) calendar(id string) *fakeCalendar {
+	calendar, ok := p.calendars[id]
+	if !ok {
+		calendar = &fakeCalendar{events: make(map[string]RemoteEvent), changed: make(map[string]int64)}
+		p.calendars[id] = calendar
+	}
+	return calendar
+}
+
+// save guarda el evento con una nueva versión
+func (c *fakeCalendar) save(event RemoteEvent, at time.Time) RemoteEvent {
+	c.seq++
+	event.Version = strconv.FormatInt(c.seq, 10)
+	event.UpdatedAt = at
+	c.events[event.ID] = event
+	c.changed[event.ID] = c.seq
+	return event
+}
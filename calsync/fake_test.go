@@ -0,0 +1,89 @@
+package calsync
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestFakeProviderChanges(t *testing.T) {
+	ctx := context.Background()
+	provider := NewFakeProvider()
+	account := Account{CalendarID: "primary"}
+	at := time.Date(2026, 7, 1, 9, 0, 0, 0, time.UTC)
+
+	dentist := provider.Put(account.CalendarID, RemoteEvent{Title: "Dentista"}, at)
+	meeting := provider.Put(account.CalendarID, RemoteEvent{Title: "Reunión"}, at)
+	provider.Remove(account.CalendarID, meeting.ID, at)
+	provider.Put("other", RemoteEvent{Title: "Otro calendario"}, at)
+
+	// Sin token se listan solo los eventos vigentes
+	changes, token, err := provider.Changes(ctx, account, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 || changes[0].ID != dentist.ID || changes[0].UpdatedAt != at {
+		t.Errorf("full listing %+v, want only %q", changes, dentist.Title)
+	}
+
+	moved, err := provider.Upsert(ctx, account, RemoteEvent{ID: dentist.ID, Title: "Dentista movido"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if moved.Version == dentist.Version {
+		t.Errorf("version %s did not change after the update", moved.Version)
+	}
+	if err := provider.Delete(ctx, account, dentist.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	// Con token se informan los cambios posteriores, incluidas las eliminaciones
+	changes, next, err := provider.Changes(ctx, account, token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 || changes[0].ID != dentist.ID || !changes[0].Deleted {
+		t.Errorf("changes since %s: %+v, want the deletion of %s", token, changes, dentist.ID)
+	}
+	if changes, _, _ := provider.Changes(ctx, account, next); len(changes) != 0 {
+		t.Errorf("changes since the last token: %+v, want none", changes)
+	}
+	if _, _, err := provider.Changes(ctx, account, "not-a-token"); !errors.Is(err, ErrTokenExpired) {
+		t.Errorf("invalid token: %v, want ErrTokenExpired", err)
+	}
+}
+
+func TestFakeProviderMissingEvents(t *testing.T) {
+	ctx := context.Background()
+	provider := NewFakeProvider()
+	account := Account{CalendarID: "primary"}
+	removed := provider.Put(account.CalendarID, RemoteEvent{Title: "Reunión"}, time.Now())
+	provider.Remove(account.CalendarID, removed.ID, time.Now())
+
+	tests := []struct {
+		name string
+		call func() error
+	}{
+		{"upsert unknown", func() error {
+			_, err := provider.Upsert(ctx, account, RemoteEvent{ID: "fake-99"})
+			return err
+		}},
+		{"upsert deleted", func() error {
+			_, err := provider.Upsert(ctx, account, RemoteEvent{ID: removed.ID})
+			return err
+		}},
+		{"delete unknown", func() error { return provider.Delete(ctx, account, "fake-99") }},
+		{"delete deleted", func() error { return provider.Delete(ctx, account, removed.ID) }},
+	}
+	for _, tt := range tests {
+		if err := tt.call(); !errors.Is(err, ErrNotFound) {
+			t.Errorf("%s: %v, want ErrNotFound", tt.name, err)
+		}
+	}
+
+	created, err := provider.Upsert(ctx, account, RemoteEvent{Title: "Nuevo"})
+	if err != nil || created.ID == "" || created.ID == removed.ID {
+		t.Errorf("create: %+v, %v, want a new ID", created, err)
+	}
+}
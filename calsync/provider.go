@@ -0,0 +1,56 @@
+// Package calsync define los proveedores de calendarios externos (Google Calendar,
+// Microsoft 365, ...) con los que se sincronizan los eventos en ambos sentidos.
+package calsync
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrNotFound indica que el evento no existe en el calendario externo
+var ErrNotFound = errors.New("remote event not found")
+
+// ErrTokenExpired indica que el proveedor ya no acepta el token de sincronización
+// (ej: 410 Gone de Google); hay que volver a sincronizar todo desde cero
+var ErrTokenExpired = errors.New("sync token expired")
+
+// ErrNotConfigured indica que faltan las credenciales de la aplicación en el proveedor
+var ErrNotConfigured = errors.New("sync provider is not configured")
+
+// Account es el calendario externo de un usuario y sus credenciales
+type Account struct {
+	CalendarID  string // Calendario dentro de la cuenta externa (ej: "primary")
+	Credentials string // Credenciales opacas para el proveedor (ej: token OAuth)
+}
+
+// RemoteEvent es un evento del calendario externo
+type RemoteEvent struct {
+	ID        string    // ID en el proveedor; vacío al crear
+	Version   string    // ETag o número de versión en el proveedor
+	UpdatedAt time.Time // Última modificación en el proveedor, para resolver conflictos
+	Deleted   bool      // El evento fue eliminado (solo en los cambios)
+
+	Title       string
+	Description string
+	Location    string
+	StartsAt    time.Time
+	EndsAt      time.Time
+	TimeZone    string // Zona IANA; vacía = UTC
+	AllDay      bool
+	RRule       string // Regla de recurrencia RFC 5545 sin el prefijo "RRULE:"
+}
+
+// Provider es un calendario externo con el que se sincronizan los eventos
+type Provider interface {
+	// Name identifica al proveedor en la configuración (SYNC_PROVIDERS) y en las cuentas
+	Name() string
+	// Changes devuelve los eventos creados, modificados o eliminados desde token, y el
+	// token para la próxima llamada. Con token vacío devuelve todos los eventos.
+	Changes(ctx context.Context, account Account, token string) ([]RemoteEvent, string, error)
+	// Upsert crea el evento (ID vacío) o lo reemplaza, y devuelve su estado en el proveedor.
+	// Devuelve ErrNotFound si el ID ya no existe.
+	Upsert(ctx context.Context, account Account, event RemoteEvent) (RemoteEvent, error)
+	// Delete elimina el evento; devuelve ErrNotFound si ya no existe
+	Delete(ctx context.Context, account Account, id string) error
+}
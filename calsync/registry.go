@@ -0,0 +1,96 @@
+package calsync
+
+import (
+	"calendar-backend/config"
+	"errors"
+	"log"
+	"strings"
+	"sync"
+)
+
+// Factory construye un proveedor a partir de la configuración
+type Factory func(cfg *config.Config) (Provider, error)
+
+var (
+	factoriesMu sync.RWMutex
+	factories   = make(map[string]Factory)
+)
+
+// RegisterFactory registra un proveedor para que pueda habilitarse por configuración
+func RegisterFactory(name string, factory Factory) {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+	factories[strings.ToLower(name)] = factory
+}
+
+func init() {
+	RegisterFactory("fake", func(cfg *config.Config) (Provider, error) {
+		return NewFakeProvider(), nil
+	})
+}
+
+// Registry guarda los proveedores habilitados
+type Registry struct {
+	providers map[string]Provider
+}
+
+// NewRegistry crea un registro con los proveedores dados
+func NewRegistry(providers ...Provider) *Registry {
+	registry := &Registry{providers: make(map[string]Provider)}
+	for _, provider := range providers {
+		registry.Register(provider)
+	}
+	return registry
+}
+
+// NewRegistryFromConfig habilita los proveedores listados en cfg.SyncProviders.
+// Los proveedores sin credenciales o desconocidos se omiten con un aviso en el log.
+func NewRegistryFromConfig(cfg *config.Config) *Registry {
+	registry := NewRegistry()
+
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+
+	for _, name := range cfg.SyncProviders {
+		factory, ok := factories[name]
+		if !ok {
+			log.Printf("⚠️ Unknown sync provider %q, skipping", name)
+			continue
+		}
+
+		provider, err := factory(cfg)
+		if errors.Is(err, ErrNotConfigured) {
+			log.Printf("⚠️ Sync provider %q is not configured, skipping", name)
+			continue
+		}
+		if err != nil {
+			log.Printf("❌ Failed to create sync provider %q: %v", name, err)
+			continue
+		}
+
+		registry.Register(provider)
+		log.Printf("✅ Sync provider %q enabled", name)
+	}
+
+	return registry
+}
+
+// Register agrega un proveedor al registro (reemplaza a otro con el mismo nombre)
+func (r *Registry) Register(provider Provider) {
+	r.providers[provider.Name()] = provider
+}
+
+// Get devuelve el proveedor con ese nombre, si está habilitado
+func (r *Registry) Get(name string) (Provider, bool) {
+	provider, ok := r.providers[name]
+	return provider, ok
+}
+
+// Names devuelve los nombres de los proveedores habilitados
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.providers))
+	for name := range r.providers {
+		names = append(names, name)
+	}
+	return names
+}
@@ -2,7 +2,8 @@ package database
 
 import (
 	"calendar-backend/config"
-	"calendar-backend/models"
+	"calendar-backend/migrations"
+	"calendar-backend/search"
 	"fmt"
 	"log"
 	"os"
@@ -16,7 +17,11 @@ import (
 
 var DB *gorm.DB
 
-func InitDB() (*gorm.DB, error) {
+// SearchEngine searches the events with the full-text index of the database
+var SearchEngine search.Engine
+
+// Connect opens the configured database without migrating it
+func Connect() (*gorm.DB, error) {
 	cfg := config.LoadConfig()
 
 	var err error
@@ -37,16 +42,63 @@ func InitDB() (*gorm.DB, error) {
 	if err != nil {
 		return nil, err
 	}
+	return DB, nil
+}
+
+// NewMigrator returns the schema migrator of the database, ready to migrate
+func NewMigrator(db *gorm.DB) (*migrations.Migrator, error) {
+	// A full-text index left by a build with different SQLite features must not break the migrations
+	if err := search.Prepare(db); err != nil {
+		return nil, fmt.Errorf("failed to prepare event search: %v", err)
+	}
+	return migrations.New(db)
+}
+
+func InitDB() (*gorm.DB, error) {
+	cfg := config.LoadConfig()
 
-	// Auto migrate the schema
-	err = DB.AutoMigrate(&models.Event{})
+	db, err := Connect()
 	if err != nil {
-		log.Printf("Error migrating database: %v", err)
 		return nil, err
 	}
 
-	log.Printf("Database connected and migrated successfully using %s", getDBType(cfg.DatabaseURL))
-	return DB, nil
+	migrator, err := NewMigrator(db)
+	if err != nil {
+		log.Printf("Error preparing migrations: %v", err)
+		return nil, err
+	}
+
+	// Refuse to run against a schema migrated by a newer version of the application
+	if err := migrator.Check(); err != nil {
+		return nil, err
+	}
+
+	if cfg.MigrateOnStart {
+		if _, err := migrator.Up(); err != nil {
+			log.Printf("Error migrating database: %v", err)
+			return nil, err
+		}
+	} else {
+		pending, err := migrator.Pending()
+		if err != nil {
+			return nil, err
+		}
+		if len(pending) > 0 {
+			return nil, fmt.Errorf("database has %d pending migrations, run \"calendar-backend migrate up\"", len(pending))
+		}
+	}
+
+	// Create or refresh the full-text search index over the events
+	SearchEngine, err = search.Setup(db)
+	if err != nil {
+		log.Printf("Error setting up event search: %v", err)
+		return nil, err
+	}
+	log.Printf("Event search using %s", SearchEngine.Name())
+
+	current, _ := migrator.Current()
+	log.Printf("Database connected at schema version %d using %s", current, getDBType(cfg.DatabaseURL))
+	return db, nil
 }
 
 func connectPostgreSQL(databaseURL string) (*gorm.DB, error) {
@@ -84,51 +136,6 @@ func GetDB() *gorm.DB {
 	return DB
 }
 
-// BackupSQLite creates a backup of the SQLite database
-func BackupSQLite(backupPath string) error {
-	if DB == nil {
-		return fmt.Errorf("database not initialized")
-	}
-
-	// Get the underlying SQLite connection
-	sqlDB, err := DB.DB()
-	if err != nil {
-		return fmt.Errorf("failed to get database connection: %v", err)
-	}
-
-	// Close the current connection
-	if err := sqlDB.Close(); err != nil {
-		return fmt.Errorf("failed to close database connection: %v", err)
-	}
-
-	// Create backup
-	cfg := config.LoadConfig()
-	if err := copyFile(cfg.DatabaseURL, backupPath); err != nil {
-		return fmt.Errorf("failed to create backup: %v", err)
-	}
-
-	// Reconnect to database
-	if _, err := InitDB(); err != nil {
-		return fmt.Errorf("failed to reconnect to database: %v", err)
-	}
-
-	log.Printf("Database backup created at: %s", backupPath)
-	return nil
-}
-
-func copyFile(src, dst string) error {
-	sourceFile, err := os.Open(src)
-	if err != nil {
-		return err
-	}
-	defer sourceFile.Close()
-
-	destFile, err := os.Create(dst)
-	if err != nil {
-		return err
-	}
-	defer destFile.Close()
-
-	_, err = destFile.ReadFrom(sourceFile)
-	return err
+func GetSearchEngine() search.Engine {
+	return SearchEngine
 }
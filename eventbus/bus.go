@@ -0,0 +1,174 @@
+// Package eventbus avisa en tiempo real los cambios de los eventos del calendario. Los
+// servicios publican un Change con cada alta, modificación o baja y el bus lo entrega a las
+// suscripciones de los usuarios que ven el evento. Un Backend lleva los cambios a todas las instancias del
+// servidor: en memoria si hay una sola, o con LISTEN/NOTIFY de PostgreSQL si hay varias.
+package eventbus
+
+import (
+	"calendar-backend/config"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrBusFull indica que el backend en memoria tiene demasiados cambios sin entregar
+var ErrBusFull = errors.New("event bus is full")
+
+// Tipos de cambio
+const (
+	Created = "created"
+	Updated = "updated"
+	Deleted = "deleted"
+)
+
+// subscriptionBuffer es la cantidad de cambios que espera una suscripción que no los lee
+const subscriptionBuffer = 64
+
+// Change es el aviso de que cambió un evento
+type Change struct {
+	Type       string    `json:"type"`
+	OwnerID    uint      `json:"owner_id"`
+	CalendarID uint      `json:"calendar_id,omitempty"`
+	UserIDs    []uint    `json:"user_ids,omitempty"` // Usuarios a los que se avisa; vacío = solo el dueño
+	EventID    uint      `json:"event_id"`
+	At         time.Time `json:"at"`
+}
+
+// Recipients devuelve los usuarios a los que se avisa el cambio
+func (c Change) Recipients() []uint {
+	if len(c.UserIDs) == 0 {
+		return []uint{c.OwnerID}
+	}
+	return c.UserIDs
+}
+
+// Backend lleva los cambios publicados en cualquier instancia del servidor a todas ellas
+type Backend interface {
+	// Name identifica el backend en los logs
+	Name() string
+	Publish(change Change) error
+	// Listen entrega a deliver los cambios publicados hasta que se cierre done
+	Listen(deliver func(Change), done <-chan struct{})
+}
+
+// Bus reparte los cambios que llegan del backend entre las suscripciones de cada usuario
+type Bus struct {
+	backend Backend
+
+	mu   sync.Mutex
+	subs map[uint]map[*Subscription]struct{}
+
+	done     chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+func NewBus(backend Backend) *Bus {
+	return &Bus{
+		backend: backend,
+		subs:    make(map[uint]map[*Subscription]struct{}),
+		done:    make(chan struct{}),
+	}
+}
+
+// NewFromConfig crea el bus con el backend de cfg.EventBus: "memory" (una sola instancia) o
+// "postgres" (varias instancias sobre la misma base PostgreSQL)
+func NewFromConfig(cfg *config.Config, db *gorm.DB) (*Bus, error) {
+	switch cfg.EventBus {
+	case "", "memory":
+		return NewBus(NewMemoryBackend()), nil
+	case "postgres":
+		backend, err := NewPostgresBackend(db, cfg.DatabaseURL)
+		if err != nil {
+			return nil, err
+		}
+		return NewBus(backend), nil
+	}
+	return nil, fmt.Errorf("unknown event bus %q, must be: memory or postgres", cfg.EventBus)
+}
+
+// Publish avisa el cambio a las suscripciones de sus destinatarios, en todas las instancias.
+// Un error solo se registra: el cambio ya está guardado y los clientes lo reciben con el
+// siguiente aviso o al reconectarse.
+func (b *Bus) Publish(change Change) {
+	if change.At.IsZero() {
+		change.At = time.Now().UTC()
+	}
+	if err := b.backend.Publish(change); err != nil {
+		log.Printf("❌ Error publishing %s change of event %d: %v", change.Type, change.EventID, err)
+	}
+}
+
+// Subscribe recibe los cambios de los eventos que ve el usuario hasta que se llame a Close
+func (b *Bus) Subscribe(userID uint) *Subscription {
+	sub := &Subscription{bus: b, userID: userID, changes: make(chan Change, subscriptionBuffer)}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.subs[userID] == nil {
+		b.subs[userID] = make(map[*Subscription]struct{})
+	}
+	b.subs[userID][sub] = struct{}{}
+	return sub
+}
+
+// Start empieza a recibir los cambios del backend
+func (b *Bus) Start() {
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		b.backend.Listen(b.deliver, b.done)
+	}()
+	log.Printf("Event bus started using %s", b.backend.Name())
+}
+
+// Stop deja de recibir los cambios del backend
+func (b *Bus) Stop() {
+	b.stopOnce.Do(func() {
+		close(b.done)
+	})
+	b.wg.Wait()
+}
+
+// deliver entrega el cambio a las suscripciones de sus destinatarios sin esperar a las que no
+// lo leen
+func (b *Bus) deliver(change Change) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, userID := range change.Recipients() {
+		for sub := range b.subs[userID] {
+			select {
+			case sub.changes <- change:
+			default:
+			}
+		}
+	}
+}
+
+// Subscription recibe los cambios de los eventos que ve un usuario. Si no los lee a tiempo se
+// descartan los que no entran en el buffer: los cambios son avisos, y lo que cambió se lee
+// de la base (ej: con la sincronización incremental).
+type Subscription struct {
+	bus     *Bus
+	userID  uint
+	changes chan Change
+}
+
+// C devuelve el canal por el que llegan los cambios
+func (s *Subscription) C() <-chan Change {
+	return s.changes
+}
+
+// Close deja de recibir cambios
+func (s *Subscription) Close() {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+	delete(s.bus.subs[s.userID], s)
+	if len(s.bus.subs[s.userID]) == 0 {
+		delete(s.bus.subs, s.userID)
+	}
+}
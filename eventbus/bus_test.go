@@ -0,0 +1,50 @@
+package eventbus
+
+import (
+	"testing"
+	"time"
+)
+
+func receive(sub *Subscription) (Change, bool) {
+	select {
+	case change := <-sub.C():
+		return change, true
+	case <-time.After(200 * time.Millisecond):
+		return Change{}, false
+	}
+}
+
+func TestBusDeliversToRecipients(t *testing.T) {
+	bus := NewBus(NewMemoryBackend())
+	bus.Start()
+	defer bus.Stop()
+
+	owner, viewer, other := bus.Subscribe(1), bus.Subscribe(2), bus.Subscribe(3)
+	defer owner.Close()
+	defer viewer.Close()
+	defer other.Close()
+
+	bus.Publish(Change{Type: Updated, OwnerID: 1, UserIDs: []uint{1, 2}, EventID: 10})
+	for name, sub := range map[string]*Subscription{"owner": owner, "viewer": viewer} {
+		if change, ok := receive(sub); !ok || change.EventID != 10 {
+			t.Errorf("%s did not receive the change", name)
+		}
+	}
+	if _, ok := receive(other); ok {
+		t.Error("a user who doesn't see the event received the change")
+	}
+}
+
+func TestBusDeliversToOwnerWithoutRecipients(t *testing.T) {
+	bus := NewBus(NewMemoryBackend())
+	bus.Start()
+	defer bus.Stop()
+
+	owner := bus.Subscribe(1)
+	defer owner.Close()
+
+	bus.Publish(Change{Type: Created, OwnerID: 1, EventID: 10})
+	if change, ok := receive(owner); !ok || change.Type != Created {
+		t.Error("owner did not receive the change")
+	}
+}
@@ -0,0 +1,39 @@
+package eventbus
+
+// memoryBuffer es la cantidad de cambios publicados que espera el backend en memoria
+const memoryBuffer = 1024
+
+// memoryBackend entrega los cambios solo dentro de la instancia que los publicó
+type memoryBackend struct {
+	changes chan Change
+}
+
+// NewMemoryBackend crea un backend para un servidor de una sola instancia
+func NewMemoryBackend() Backend {
+	return &memoryBackend{changes: make(chan Change, memoryBuffer)}
+}
+
+func (b *memoryBackend) Name() string {
+	return "memory"
+}
+
+// Publish encola el cambio sin esperar a que se entregue
+func (b *memoryBackend) Publish(change Change) error {
+	select {
+	case b.changes <- change:
+		return nil
+	default:
+		return ErrBusFull
+	}
+}
+
+func (b *memoryBackend) Listen(deliver func(Change), done <-chan struct{}) {
+	for {
+		select {
+		case change := <-b.changes:
+			deliver(change)
+		case <-done:
+			return
+		}
+	}
+}
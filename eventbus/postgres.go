@@ -0,0 +1,106 @@
+package eventbus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"gorm.io/gorm"
+)
+
+// notifyChannel es el canal de NOTIFY por el que las instancias se avisan los cambios
+const notifyChannel = "event_changes"
+
+// Espera antes de volver a conectarse después de perder la conexión de LISTEN
+const (
+	reconnectBaseDelay = time.Second
+	reconnectMaxDelay  = time.Minute
+)
+
+// postgresBackend entrega los cambios a todas las instancias conectadas a la misma base:
+// los publica con NOTIFY y los recibe con LISTEN en una conexión propia
+type postgresBackend struct {
+	db          *gorm.DB
+	databaseURL string
+}
+
+// NewPostgresBackend crea un backend para varias instancias sobre la base PostgreSQL db,
+// que escucha los cambios con otra conexión a databaseURL
+func NewPostgresBackend(db *gorm.DB, databaseURL string) (Backend, error) {
+	if db.Dialector.Name() != "postgres" {
+		return nil, errors.New("the postgres event bus requires a PostgreSQL database")
+	}
+	return &postgresBackend{db: db, databaseURL: databaseURL}, nil
+}
+
+func (b *postgresBackend) Name() string {
+	return "PostgreSQL LISTEN/NOTIFY"
+}
+
+// Publish envía el cambio con NOTIFY; también lo recibe la instancia que lo publica
+func (b *postgresBackend) Publish(change Change) error {
+	payload, err := json.Marshal(change)
+	if err != nil {
+		return err
+	}
+	return b.db.Exec("SELECT pg_notify(?, ?)", notifyChannel, string(payload)).Error
+}
+
+// Listen escucha los cambios y, si se pierde la conexión, se vuelve a conectar esperando cada
+// vez más. Los cambios publicados mientras tanto no se reciben.
+func (b *postgresBackend) Listen(deliver func(Change), done <-chan struct{}) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-done
+		cancel()
+	}()
+
+	delay := reconnectBaseDelay
+	for {
+		started := time.Now()
+		err := b.listen(ctx, deliver)
+		if ctx.Err() != nil {
+			return
+		}
+		if time.Since(started) > reconnectMaxDelay {
+			delay = reconnectBaseDelay
+		}
+		log.Printf("⚠️ Event bus lost its PostgreSQL connection, reconnecting in %s: %v", delay, err)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return
+		}
+		if delay *= 2; delay > reconnectMaxDelay {
+			delay = reconnectMaxDelay
+		}
+	}
+}
+
+func (b *postgresBackend) listen(ctx context.Context, deliver func(Change)) error {
+	conn, err := pgx.Connect(ctx, b.databaseURL)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+notifyChannel); err != nil {
+		return err
+	}
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		var change Change
+		if err := json.Unmarshal([]byte(notification.Payload), &change); err != nil {
+			log.Printf("⚠️ Ignoring invalid event bus notification %q: %v", notification.Payload, err)
+			continue
+		}
+		deliver(change)
+	}
+}
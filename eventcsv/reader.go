@@ -0,0 +1,124 @@
+// Package eventcsv lee y escribe eventos en CSV, el formato de las planillas de cálculo
+// (Excel, Google Sheets, LibreOffice).
+package eventcsv
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"io"
+	"strings"
+)
+
+// ErrEmptyFile indica que el archivo no tiene encabezado
+var ErrEmptyFile = errors.New("CSV file is empty")
+
+// utf8BOM es la marca que agrega Excel al guardar en UTF-8 (y que necesita para abrirlo)
+const utf8BOM = "\ufeff"
+
+// Record es una fila de datos del archivo
+type Record struct {
+	Line   int // Línea del archivo en la que empieza la fila
+	Values []string
+}
+
+// Reader lee un CSV con encabezado, fila por fila
+type Reader struct {
+	csv    *csv.Reader
+	header []string
+}
+
+// NewReader lee el encabezado del CSV. delimiter 0 lo detecta del encabezado entre
+// coma, punto y coma (Excel en configuraciones regionales que usan coma decimal) y tabulación.
+func NewReader(r io.Reader, delimiter rune) (*Reader, error) {
+	buffered := bufio.NewReader(r)
+	if bom, err := buffered.Peek(len(utf8BOM)); err == nil && string(bom) == utf8BOM {
+		buffered.Discard(len(utf8BOM))
+	}
+	if delimiter == 0 {
+		firstLine, err := buffered.Peek(buffered.Size())
+		if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, bufio.ErrBufferFull) {
+			return nil, err
+		}
+		delimiter = detectDelimiter(string(firstLine))
+	}
+
+	reader := csv.NewReader(buffered)
+	reader.Comma = delimiter
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, ErrEmptyFile
+	}
+	if err != nil {
+		return nil, err
+	}
+	for i := range header {
+		header[i] = strings.TrimSpace(header[i])
+	}
+	return &Reader{csv: reader, header: header}, nil
+}
+
+// Header devuelve los nombres de las columnas
+func (r *Reader) Header() []string {
+	return r.header
+}
+
+// Next devuelve la próxima fila con datos, salteando las vacías. Devuelve io.EOF al terminar.
+func (r *Reader) Next() (*Record, error) {
+	for {
+		values, err := r.csv.Read()
+		if err != nil {
+			return nil, err
+		}
+		line, _ := r.csv.FieldPos(0)
+		if isBlank(values) {
+			continue
+		}
+		return &Record{Line: line, Values: values}, nil
+	}
+}
+
+// Value devuelve el valor de la columna index, vacío si la fila es más corta
+func (rec *Record) Value(index int) string {
+	if index < 0 || index >= len(rec.Values) {
+		return ""
+	}
+	return strings.TrimSpace(rec.Values[index])
+}
+
+// detectDelimiter elige el separador más frecuente en la primera línea, fuera de comillas
+func detectDelimiter(text string) rune {
+	if i := strings.IndexAny(text, "\r\n"); i >= 0 {
+		text = text[:i]
+	}
+	counts := map[rune]int{}
+	quoted := false
+	for _, ch := range text {
+		switch {
+		case ch == '"':
+			quoted = !quoted
+		case !quoted && (ch == ',' || ch == ';' || ch == '\t'):
+			counts[ch]++
+		}
+	}
+
+	best := ','
+	for _, candidate := range []rune{';', '\t'} {
+		if counts[candidate] > counts[best] {
+			best = candidate
+		}
+	}
+	return best
+}
+
+func isBlank(values []string) bool {
+	for _, value := range values {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+	return true
+}
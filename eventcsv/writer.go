@@ -0,0 +1,85 @@
+package eventcsv
+
+import (
+	"calendar-backend/models"
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ContentType es el tipo MIME de los archivos CSV
+const ContentType = "text/csv; charset=utf-8"
+
+// Columns son las columnas de la exportación. Usan los nombres de campo que acepta la
+// importación, así que un archivo exportado puede volver a importarse sin mapeo.
+// Fechas y horas están en la zona del evento; en los eventos de todo el día end_date es
+// el último día (inclusive).
+var Columns = []string{
+	"id", "title", "description", "date", "time", "end_date", "end_time", "time_zone",
+	"is_all_day", "location", "category", "priority", "color", "email", "phone", "rrule", "exdates",
+}
+
+// Writer escribe eventos en CSV, una fila por evento u ocurrencia
+type Writer struct {
+	csv *csv.Writer
+}
+
+// NewWriter escribe la marca UTF-8 (para que Excel respete los acentos) y el encabezado
+func NewWriter(w io.Writer) (*Writer, error) {
+	if _, err := io.WriteString(w, utf8BOM); err != nil {
+		return nil, err
+	}
+	writer := &Writer{csv: csv.NewWriter(w)}
+	if err := writer.csv.Write(Columns); err != nil {
+		return nil, err
+	}
+	return writer, nil
+}
+
+// Write escribe la fila del evento
+func (w *Writer) Write(event *models.Event) error {
+	loc := event.Zone()
+	start := event.StartsAt.In(loc)
+	end := event.EndsAt.In(loc)
+
+	clock, endClock := start.Format("15:04"), end.Format("15:04")
+	endDate := models.CalendarDate(event.EndsAt, loc)
+	if event.IsAllDay {
+		clock, endClock = "", ""
+		if event.EndsAt.After(event.StartsAt) {
+			endDate = models.CalendarDate(event.EndsAt.Add(-1), loc)
+		}
+	}
+
+	id := event.ID
+	if event.RecurrenceID != nil {
+		id = *event.RecurrenceID
+	}
+
+	return w.csv.Write([]string{
+		strconv.FormatUint(uint64(id), 10),
+		event.Title,
+		event.Description,
+		start.Format("2006-01-02"),
+		clock,
+		endDate.Format("2006-01-02"),
+		endClock,
+		loc.String(),
+		strconv.FormatBool(event.IsAllDay),
+		event.Location,
+		event.Category,
+		event.Priority,
+		event.Color,
+		event.Email,
+		event.Phone,
+		event.RRule,
+		strings.ReplaceAll(event.ExDates, ",", " "),
+	})
+}
+
+// Flush envía al io.Writer las filas pendientes
+func (w *Writer) Flush() error {
+	w.csv.Flush()
+	return w.csv.Error()
+}
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRedactPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/api/v1/stream", "/api/v1/stream"},
+		{"/api/v1/stream?access_token=eyJ.abc.def", "/api/v1/stream?access_token=REDACTED"},
+		{"/api/v1/stream/ws?last_event_id=42&access_token=eyJ&x=1", "/api/v1/stream/ws?last_event_id=42&access_token=REDACTED&x=1"},
+		{"/api/v1/stream?access%5Ftoken=eyJ", "/api/v1/stream?access_token=REDACTED"},
+		{"/api/v1/stream?access_token", "/api/v1/stream?access_token=REDACTED"},
+		{"/api/v1/events?search=access_token", "/api/v1/events?search=access_token"},
+	}
+	for _, tt := range tests {
+		if got := redactPath(tt.path); got != tt.want {
+			t.Errorf("redactPath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestAccessLoggerRedactsAccessToken(t *testing.T) {
+	var logged bytes.Buffer
+	writer := gin.DefaultWriter
+	gin.DefaultWriter = &logged
+	defer func() { gin.DefaultWriter = writer }()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(AccessLogger())
+	router.GET("/stream", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/stream?access_token=secret-token", nil))
+
+	if line := logged.String(); strings.Contains(line, "secret-token") || !strings.Contains(line, "/stream?access_token=REDACTED") {
+		t.Errorf("access log %q, want the token redacted", line)
+	}
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"calendar-backend/handlers/dto"
+	"calendar-backend/services"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AppPasswordController struct {
+	appPasswordService *services.AppPasswordService
+	baseURL            string
+}
+
+// NewAppPasswordController creates the controller; baseURL is the public URL of the API shown
+// to CalDAV clients, or empty to derive it from each request
+func NewAppPasswordController(appPasswordService *services.AppPasswordService, baseURL string) *AppPasswordController {
+	return &AppPasswordController{appPasswordService: appPasswordService, baseURL: baseURL}
+}
+
+// CreateAppPassword creates an app password for CalDAV clients. The password is only
+// returned in this response
+func (h *AppPasswordController) CreateAppPassword(c *gin.Context) {
+	var req dto.CreateAppPasswordRequest
+	if err := req.ProcessRequest(c); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	appPassword, password, err := h.appPasswordService.CreateAppPassword(CurrentUserID(c), req.Name)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create app password"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":      "App password created successfully",
+		"app_password": appPassword,
+		"password":     password,
+		"username":     CurrentUserEmail(c),
+		"caldav_url":   publicBaseURL(c, h.baseURL) + davRoot,
+	})
+}
+
+// GetAppPasswords lists the app passwords of the authenticated user
+func (h *AppPasswordController) GetAppPasswords(c *gin.Context) {
+	appPasswords, err := h.appPasswordService.ListAppPasswords(CurrentUserID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get app passwords"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"app_passwords": appPasswords, "count": len(appPasswords)})
+}
+
+// RevokeAppPassword deletes an app password: clients using it can no longer sign in
+func (h *AppPasswordController) RevokeAppPassword(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid app password ID"})
+		return
+	}
+
+	if err := h.appPasswordService.RevokeAppPassword(CurrentUserID(c), uint(id)); err != nil {
+		if errors.Is(err, services.ErrAppPasswordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke app password"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "App password revoked successfully"})
+}
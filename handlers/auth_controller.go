@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"calendar-backend/handlers/dto"
+	"calendar-backend/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AuthController struct {
+	authService *services.AuthService
+}
+
+func NewAuthController(authService *services.AuthService) *AuthController {
+	return &AuthController{authService: authService}
+}
+
+// Register creates a user account and returns its tokens
+func (h *AuthController) Register(c *gin.Context) {
+	var req dto.RegisterRequest
+	if err := req.ProcessRequest(c); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, tokens, err := h.authService.Register(req.Email, req.Password, req.Name)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"user":   user,
+		"tokens": tokens,
+	})
+}
+
+// Login checks the credentials and returns new tokens
+func (h *AuthController) Login(c *gin.Context) {
+	var req dto.LoginRequest
+	if err := req.ProcessRequest(c); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, tokens, err := h.authService.Login(req.Email, req.Password)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"user":   user,
+		"tokens": tokens,
+	})
+}
+
+// Refresh exchanges a refresh token for a new token pair
+func (h *AuthController) Refresh(c *gin.Context) {
+	var req dto.RefreshRequest
+	if err := req.ProcessRequest(c); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tokens, err := h.authService.Refresh(req.RefreshToken)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tokens": tokens})
+}
+
+// Me returns the authenticated user
+func (h *AuthController) Me(c *gin.Context) {
+	user, err := h.authService.GetUser(CurrentUserID(c))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"calendar-backend/services"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Context keys set by AuthMiddleware and BasicAuthMiddleware
+const (
+	contextUserID    = "user_id"
+	contextUserEmail = "user_email"
+)
+
+// AuthMiddleware requires a valid "Authorization: Bearer <access token>" header
+// and stores the authenticated user in the request context
+func AuthMiddleware(tokenService *services.TokenService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		token := strings.TrimSpace(strings.TrimPrefix(header, "Bearer "))
+		if header == "" || token == header {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization header with Bearer token is required"})
+			return
+		}
+
+		claims, err := tokenService.Parse(token, services.TokenTypeAccess)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.Set(contextUserID, claims.UserID())
+		c.Set(contextUserEmail, claims.Email)
+		c.Next()
+	}
+}
+
+// StreamAuthMiddleware is AuthMiddleware for the change streams, which also accepts the access
+// token in the access_token query parameter: browsers cannot set headers on EventSource and
+// WebSocket connections
+func StreamAuthMiddleware(tokenService *services.TokenService) gin.HandlerFunc {
+	bearerAuth := AuthMiddleware(tokenService)
+	return func(c *gin.Context) {
+		if token := c.Query("access_token"); token != "" && c.GetHeader("Authorization") == "" {
+			c.Request.Header.Set("Authorization", "Bearer "+token)
+		}
+		bearerAuth(c)
+	}
+}
+
+// BasicAuthMiddleware requires HTTP Basic credentials made of the user's email and one of
+// their app passwords, for clients that cannot use bearer tokens (CalDAV)
+func BasicAuthMiddleware(appPasswordService *services.AppPasswordService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		email, password, ok := c.Request.BasicAuth()
+		if !ok {
+			c.Header("WWW-Authenticate", `Basic realm="Calendar", charset="UTF-8"`)
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+
+		user, err := appPasswordService.Authenticate(email, password)
+		if err != nil {
+			c.Header("WWW-Authenticate", `Basic realm="Calendar", charset="UTF-8"`)
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+
+		c.Set(contextUserID, user.ID)
+		c.Set(contextUserEmail, user.Email)
+		c.Next()
+	}
+}
+
+// CurrentUserID returns the ID of the authenticated user
+func CurrentUserID(c *gin.Context) uint {
+	return c.GetUint(contextUserID)
+}
+
+// CurrentUserEmail returns the email of the authenticated user
+func CurrentUserEmail(c *gin.Context) string {
+	return c.GetString(contextUserEmail)
+}
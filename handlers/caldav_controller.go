@@ -0,0 +1,566 @@
+package handlers
+
+import (
+	"bytes"
+	"calendar-backend/caldav"
+	"calendar-backend/ical"
+	"calendar-backend/models"
+	"calendar-backend/services"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CalDAV layout: every user sees a single calendar collection with one .ics resource per
+// series (or single event), named after its UID
+const (
+	davRoot          = "/dav/"
+	davPrincipalPath = "/dav/principal/"
+	davHomePath      = "/dav/calendars/"
+	davCalendarPath  = "/dav/calendars/events/"
+	davCalendarName  = "Calendar"
+	davCalendarColor = "#007AFF"
+	davContentType   = "text/calendar; charset=utf-8; component=vevent"
+	maxDAVBodySize   = 1 << 20
+)
+
+// CalDAVMethods are the HTTP methods served under /dav
+var CalDAVMethods = []string{http.MethodOptions, "PROPFIND", "REPORT", http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete}
+
+// Kinds of CalDAV paths
+type davTarget int
+
+const (
+	davTargetUnknown davTarget = iota
+	davTargetRoot
+	davTargetPrincipal
+	davTargetHome
+	davTargetCalendar
+	davTargetResource
+)
+
+func davName(space, local string) xml.Name {
+	return xml.Name{Space: space, Local: local}
+}
+
+var (
+	propResourceType        = davName(caldav.NamespaceDAV, "resourcetype")
+	propDisplayName         = davName(caldav.NamespaceDAV, "displayname")
+	propCurrentUserPrinc    = davName(caldav.NamespaceDAV, "current-user-principal")
+	propPrincipalURL        = davName(caldav.NamespaceDAV, "principal-URL")
+	propOwner               = davName(caldav.NamespaceDAV, "owner")
+	propGetETag             = davName(caldav.NamespaceDAV, "getetag")
+	propGetContentType      = davName(caldav.NamespaceDAV, "getcontenttype")
+	propGetLastModified     = davName(caldav.NamespaceDAV, "getlastmodified")
+	propSyncToken           = davName(caldav.NamespaceDAV, "sync-token")
+	propSupportedReportSet  = davName(caldav.NamespaceDAV, "supported-report-set")
+	propCurrentUserPrivSet  = davName(caldav.NamespaceDAV, "current-user-privilege-set")
+	propCalendarHomeSet     = davName(caldav.NamespaceCalDAV, "calendar-home-set")
+	propCalendarUserAddrSet = davName(caldav.NamespaceCalDAV, "calendar-user-address-set")
+	propSupportedComponents = davName(caldav.NamespaceCalDAV, "supported-calendar-component-set")
+	propCalendarData        = davName(caldav.NamespaceCalDAV, "calendar-data")
+	propGetCTag             = davName(caldav.NamespaceCalendarServer, "getctag")
+	propCalendarColor       = davName(caldav.NamespaceAppleICal, "calendar-color")
+)
+
+type CalDAVController struct {
+	caldavService   *services.CalDAVService
+	settingsService *services.SettingsService
+}
+
+func NewCalDAVController(caldavService *services.CalDAVService, settingsService *services.SettingsService) *CalDAVController {
+	return &CalDAVController{caldavService: caldavService, settingsService: settingsService}
+}
+
+// davResource is a calendar resource rendered as .ics
+type davResource struct {
+	href         string
+	data         []byte
+	etag         string
+	lastModified time.Time
+}
+
+// WellKnown redirects CalDAV discovery (/.well-known/caldav) to the DAV root
+func (h *CalDAVController) WellKnown(c *gin.Context) {
+	c.Redirect(http.StatusMovedPermanently, davRoot)
+}
+
+// ServeDAV handles every CalDAV request under /dav
+func (h *CalDAVController) ServeDAV(c *gin.Context) {
+	target, uid := parseDAVPath(c.Request.URL.Path)
+	if target == davTargetUnknown {
+		c.Status(http.StatusNotFound)
+		return
+	}
+
+	switch c.Request.Method {
+	case http.MethodOptions:
+		h.options(c)
+	case "PROPFIND":
+		h.propfind(c, target, uid)
+	case "REPORT":
+		h.report(c, target)
+	case http.MethodGet, http.MethodHead:
+		h.get(c, target, uid)
+	case http.MethodPut:
+		h.put(c, target, uid)
+	case http.MethodDelete:
+		h.delete(c, target, uid)
+	default:
+		c.Status(http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *CalDAVController) options(c *gin.Context) {
+	c.Header("DAV", "1, 3, calendar-access")
+	c.Header("Allow", strings.Join(CalDAVMethods, ", "))
+	c.Status(http.StatusOK)
+}
+
+// propfind returns the properties of a collection (and of its children with Depth: 1) or a resource
+func (h *CalDAVController) propfind(c *gin.Context, target davTarget, uid string) {
+	req, err := caldav.ParseRequest(io.LimitReader(c.Request.Body, maxDAVBodySize))
+	if err != nil || req.Kind != caldav.PropFind {
+		c.String(http.StatusBadRequest, "invalid PROPFIND body")
+		return
+	}
+	depthOne := c.GetHeader("Depth") != "0"
+
+	var responses []caldav.Response
+	switch target {
+	case davTargetResource:
+		resource, err := h.resource(c, uid)
+		if err != nil {
+			h.resourceError(c, err)
+			return
+		}
+		responses = append(responses, h.resourceResponse(resource, req))
+	case davTargetCalendar:
+		response, err := h.collectionResponse(c, target, req)
+		if err != nil {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		responses = append(responses, response)
+		if depthOne {
+			resources, err := h.resources(c)
+			if err != nil {
+				c.Status(http.StatusInternalServerError)
+				return
+			}
+			for _, resource := range resources {
+				responses = append(responses, h.resourceResponse(resource, req))
+			}
+		}
+	default:
+		response, err := h.collectionResponse(c, target, req)
+		if err != nil {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		responses = append(responses, response)
+		if target == davTargetHome && depthOne {
+			calendar, err := h.collectionResponse(c, davTargetCalendar, req)
+			if err != nil {
+				c.Status(http.StatusInternalServerError)
+				return
+			}
+			responses = append(responses, calendar)
+		}
+	}
+
+	writeMultistatus(c, &caldav.Multistatus{Responses: responses})
+}
+
+// report handles calendar-query, calendar-multiget and sync-collection on the calendar
+func (h *CalDAVController) report(c *gin.Context, target davTarget) {
+	req, err := caldav.ParseRequest(io.LimitReader(c.Request.Body, maxDAVBodySize))
+	if err != nil {
+		c.String(http.StatusBadRequest, err.Error())
+		return
+	}
+	if target != davTargetCalendar {
+		c.String(http.StatusForbidden, "reports are only supported on the calendar collection")
+		return
+	}
+
+	ownerID := CurrentUserID(c)
+	multistatus := &caldav.Multistatus{}
+	switch req.Kind {
+	case caldav.CalendarQuery:
+		resources, err := h.caldavService.ListResources(ownerID)
+		if err != nil {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		for _, resource := range resources {
+			if req.TimeRange != nil && !resource.Overlaps(req.TimeRange.Start, req.TimeRange.End) {
+				continue
+			}
+			multistatus.Responses = append(multistatus.Responses, h.resourceResponse(renderResource(&resource), req))
+		}
+	case caldav.CalendarMultiget:
+		for _, href := range req.Hrefs {
+			hrefTarget, uid := parseDAVPath(hrefPath(href))
+			if hrefTarget != davTargetResource {
+				multistatus.Responses = append(multistatus.Responses, caldav.Response{Href: href, Status: http.StatusNotFound})
+				continue
+			}
+			resource, err := h.resource(c, uid)
+			if err != nil {
+				multistatus.Responses = append(multistatus.Responses, caldav.Response{Href: href, Status: http.StatusNotFound})
+				continue
+			}
+			multistatus.Responses = append(multistatus.Responses, h.resourceResponse(resource, req))
+		}
+	case caldav.SyncCollection:
+		// An empty token is an initial sync: every resource is reported
+		token := h.caldavService.NewSyncToken()
+		var (
+			changed []services.CalendarResource
+			deleted []string
+		)
+		if req.SyncToken == "" {
+			changed, err = h.caldavService.ListResources(ownerID)
+		} else {
+			since, parseErr := h.caldavService.ParseSyncToken(req.SyncToken)
+			if parseErr != nil {
+				writePrecondition(c, http.StatusForbidden, davName(caldav.NamespaceDAV, "valid-sync-token"))
+				return
+			}
+			changed, deleted, err = h.caldavService.Changes(ownerID, since)
+		}
+		if err != nil {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		for i := range changed {
+			multistatus.Responses = append(multistatus.Responses, h.resourceResponse(renderResource(&changed[i]), req))
+		}
+		for _, uid := range deleted {
+			multistatus.Responses = append(multistatus.Responses, caldav.Response{Href: resourceHref(uid), Status: http.StatusNotFound})
+		}
+		multistatus.SyncToken = token
+	default:
+		writePrecondition(c, http.StatusForbidden, davName(caldav.NamespaceDAV, "supported-report"))
+		return
+	}
+
+	writeMultistatus(c, multistatus)
+}
+
+// get downloads a resource as .ics
+func (h *CalDAVController) get(c *gin.Context, target davTarget, uid string) {
+	if target != davTargetResource {
+		c.String(http.StatusOK, "CalDAV collection")
+		return
+	}
+	resource, err := h.resource(c, uid)
+	if err != nil {
+		h.resourceError(c, err)
+		return
+	}
+
+	c.Header("ETag", resource.etag)
+	c.Header("Last-Modified", resource.lastModified.UTC().Format(http.TimeFormat))
+	if etagMatches(c.GetHeader("If-None-Match"), resource.etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, davContentType, resource.data)
+}
+
+// put creates or replaces a resource with the .ics in the body. If-Match and
+// If-None-Match: * protect against overwriting changes made by other clients
+func (h *CalDAVController) put(c *gin.Context, target davTarget, uid string) {
+	if target != davTargetResource {
+		c.Status(http.StatusMethodNotAllowed)
+		return
+	}
+
+	existing, err := h.resource(c, uid)
+	if err != nil && !errors.Is(err, services.ErrResourceNotFound) {
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+	if !putPreconditionsMet(c, existing) {
+		c.Status(http.StatusPreconditionFailed)
+		return
+	}
+
+	loc, err := models.LoadLocation(h.settingsService.DefaultTimeZone(CurrentUserEmail(c)))
+	if err != nil {
+		loc = time.UTC
+	}
+	vevents, err := ical.Decode(io.LimitReader(c.Request.Body, maxDAVBodySize), loc)
+	if err != nil {
+		writePrecondition(c, http.StatusForbidden, davName(caldav.NamespaceCalDAV, "valid-calendar-data"))
+		return
+	}
+	if len(vevents) == 0 {
+		writePrecondition(c, http.StatusForbidden, davName(caldav.NamespaceCalDAV, "supported-calendar-component"))
+		return
+	}
+	// The resource name is the UID ({uid}.ics): a resource stored under another name could
+	// not be found again, and an existing resource cannot change its UID
+	if vevents[0].UID != uid {
+		writePrecondition(c, http.StatusConflict, davName(caldav.NamespaceCalDAV, "no-uid-conflict"))
+		return
+	}
+
+	created, err := h.caldavService.PutResource(CurrentUserID(c), CurrentUserEmail(c), uid, vevents)
+	if errors.Is(err, services.ErrCalendarForbidden) {
+		c.Status(http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		c.String(http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	if saved, err := h.resource(c, uid); err == nil {
+		c.Header("ETag", saved.etag)
+	}
+	if created {
+		c.Status(http.StatusCreated)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// delete removes a resource (the whole series)
+func (h *CalDAVController) delete(c *gin.Context, target davTarget, uid string) {
+	if target != davTargetResource {
+		c.Status(http.StatusForbidden)
+		return
+	}
+
+	existing, err := h.resource(c, uid)
+	if err != nil {
+		h.resourceError(c, err)
+		return
+	}
+	if match := c.GetHeader("If-Match"); match != "" && !etagMatches(match, existing.etag) {
+		c.Status(http.StatusPreconditionFailed)
+		return
+	}
+
+	if err := h.caldavService.DeleteResource(CurrentUserID(c), uid); err != nil {
+		h.resourceError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// collectionResponse returns the properties of the root, principal, home or calendar
+func (h *CalDAVController) collectionResponse(c *gin.Context, target davTarget, req *caldav.Request) (caldav.Response, error) {
+	email := CurrentUserEmail(c)
+	props := map[xml.Name]string{
+		propCurrentUserPrinc: caldav.Href(davPrincipalPath),
+		propResourceType:     caldav.Elements(davName(caldav.NamespaceDAV, "collection")),
+	}
+
+	var href string
+	switch target {
+	case davTargetRoot:
+		href = davRoot
+	case davTargetPrincipal:
+		href = davPrincipalPath
+		props[propResourceType] = caldav.Elements(davName(caldav.NamespaceDAV, "collection"), davName(caldav.NamespaceDAV, "principal"))
+		props[propDisplayName] = caldav.Text(email)
+		props[propPrincipalURL] = caldav.Href(davPrincipalPath)
+		props[propCalendarHomeSet] = caldav.Href(davHomePath)
+		props[propCalendarUserAddrSet] = caldav.Href("mailto:" + email)
+	case davTargetHome:
+		href = davHomePath
+		props[propCalendarHomeSet] = caldav.Href(davHomePath)
+	case davTargetCalendar:
+		href = davCalendarPath
+		ctag, err := h.caldavService.CollectionTag(CurrentUserID(c))
+		if err != nil {
+			return caldav.Response{}, err
+		}
+		props[propResourceType] = caldav.Elements(davName(caldav.NamespaceDAV, "collection"), davName(caldav.NamespaceCalDAV, "calendar"))
+		props[propDisplayName] = caldav.Text(davCalendarName)
+		props[propOwner] = caldav.Href(davPrincipalPath)
+		props[propGetCTag] = caldav.Text(ctag)
+		props[propSyncToken] = caldav.Text(h.caldavService.NewSyncToken())
+		props[propCalendarColor] = caldav.Text(davCalendarColor)
+		props[propSupportedComponents] = `<c:comp name="VEVENT"/>`
+		props[propCurrentUserPrivSet] = privileges()
+		props[propSupportedReportSet] = supportedReports()
+	}
+
+	return propResponse(href, props, req), nil
+}
+
+// resourceResponse returns the properties of a resource; calendar-data only when requested
+func (h *CalDAVController) resourceResponse(resource *davResource, req *caldav.Request) caldav.Response {
+	props := map[xml.Name]string{
+		propResourceType:       "",
+		propGetETag:            caldav.Text(resource.etag),
+		propGetContentType:     caldav.Text(davContentType),
+		propGetLastModified:    caldav.Text(resource.lastModified.UTC().Format(http.TimeFormat)),
+		propCurrentUserPrivSet: privileges(),
+	}
+	if !req.AllProp {
+		props[propCalendarData] = caldav.Text(string(resource.data))
+	}
+	return propResponse(resource.href, props, req)
+}
+
+// propResponse selects the requested properties: all of them for allprop, only their
+// names for propname, or the ones in <prop> (the unknown ones as not found)
+func propResponse(href string, props map[xml.Name]string, req *caldav.Request) caldav.Response {
+	response := caldav.Response{Href: href}
+	switch {
+	case req.PropName:
+		for name := range props {
+			response.Found = append(response.Found, caldav.Property{Name: name})
+		}
+	case req.AllProp:
+		for name, value := range props {
+			response.Found = append(response.Found, caldav.Property{Name: name, Value: value})
+		}
+	default:
+		for _, name := range req.Props {
+			if value, ok := props[name]; ok {
+				response.Found = append(response.Found, caldav.Property{Name: name, Value: value})
+			} else {
+				response.NotFound = append(response.NotFound, name)
+			}
+		}
+	}
+	return response
+}
+
+// resources renders every resource of the authenticated user
+func (h *CalDAVController) resources(c *gin.Context) ([]*davResource, error) {
+	resources, err := h.caldavService.ListResources(CurrentUserID(c))
+	if err != nil {
+		return nil, err
+	}
+	rendered := make([]*davResource, 0, len(resources))
+	for i := range resources {
+		rendered = append(rendered, renderResource(&resources[i]))
+	}
+	return rendered, nil
+}
+
+// resource renders the resource of a UID
+func (h *CalDAVController) resource(c *gin.Context, uid string) (*davResource, error) {
+	resource, err := h.caldavService.GetResource(CurrentUserID(c), uid)
+	if err != nil {
+		return nil, err
+	}
+	return renderResource(resource), nil
+}
+
+func (h *CalDAVController) resourceError(c *gin.Context, err error) {
+	if errors.Is(err, services.ErrResourceNotFound) {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	c.Status(http.StatusInternalServerError)
+}
+
+// renderResource serializes a resource; its ETag is the hash of the content
+func renderResource(resource *services.CalendarResource) *davResource {
+	var buf bytes.Buffer
+	calendar := &ical.Calendar{Events: resource.Events, ObjectResource: true}
+	calendar.WriteTo(&buf)
+
+	sum := sha256.Sum256(buf.Bytes())
+	lastModified := resource.Events[0].UpdatedAt
+	for _, event := range resource.Events[1:] {
+		if event.UpdatedAt.After(lastModified) {
+			lastModified = event.UpdatedAt
+		}
+	}
+	return &davResource{
+		href:         resourceHref(resource.UID),
+		data:         buf.Bytes(),
+		etag:         `"` + hex.EncodeToString(sum[:16]) + `"`,
+		lastModified: lastModified,
+	}
+}
+
+// putPreconditionsMet checks If-None-Match: * (create only) and If-Match (update only that version)
+func putPreconditionsMet(c *gin.Context, existing *davResource) bool {
+	if noneMatch := c.GetHeader("If-None-Match"); noneMatch != "" && existing != nil && etagMatches(noneMatch, existing.etag) {
+		return false
+	}
+	if match := c.GetHeader("If-Match"); match != "" {
+		return existing != nil && etagMatches(match, existing.etag)
+	}
+	return true
+}
+
+// parseDAVPath classifies a path under /dav and returns the UID of a resource
+func parseDAVPath(path string) (davTarget, string) {
+	switch strings.TrimSuffix(path, "/") + "/" {
+	case davRoot:
+		return davTargetRoot, ""
+	case davPrincipalPath:
+		return davTargetPrincipal, ""
+	case davHomePath:
+		return davTargetHome, ""
+	case davCalendarPath:
+		return davTargetCalendar, ""
+	}
+
+	name, ok := strings.CutPrefix(path, davCalendarPath)
+	if !ok || name == "" || strings.Contains(name, "/") || !strings.HasSuffix(name, ".ics") {
+		return davTargetUnknown, ""
+	}
+	return davTargetResource, strings.TrimSuffix(name, ".ics")
+}
+
+// hrefPath returns the decoded path of an href (absolute URL or path)
+func hrefPath(href string) string {
+	u, err := url.Parse(href)
+	if err != nil {
+		return ""
+	}
+	return u.Path
+}
+
+// resourceHref returns the href of the resource of a UID
+func resourceHref(uid string) string {
+	return davCalendarPath + url.PathEscape(uid) + ".ics"
+}
+
+func privileges() string {
+	var buf strings.Builder
+	for _, privilege := range []string{"read", "write", "write-content", "write-properties", "bind", "unbind"} {
+		buf.WriteString(caldav.Element(davName(caldav.NamespaceDAV, "privilege"), caldav.Element(davName(caldav.NamespaceDAV, privilege), "")))
+	}
+	return buf.String()
+}
+
+func supportedReports() string {
+	var buf strings.Builder
+	for _, report := range []xml.Name{caldav.CalendarQuery, caldav.CalendarMultiget, caldav.SyncCollection} {
+		buf.WriteString(caldav.Element(davName(caldav.NamespaceDAV, "supported-report"),
+			caldav.Element(davName(caldav.NamespaceDAV, "report"), caldav.Element(report, ""))))
+	}
+	return buf.String()
+}
+
+func writeMultistatus(c *gin.Context, multistatus *caldav.Multistatus) {
+	c.Data(http.StatusMultiStatus, "application/xml; charset=utf-8", multistatus.Bytes())
+}
+
+// writePrecondition responds with a DAV:error naming the failed precondition
+func writePrecondition(c *gin.Context, status int, precondition xml.Name) {
+	body := xml.Header + `<d:error xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">` + caldav.Element(precondition, "") + `</d:error>`
+	c.Data(status, "application/xml; charset=utf-8", []byte(body))
+}
@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"calendar-backend/handlers/dto"
+	"calendar-backend/models"
+	"calendar-backend/services"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CalendarController struct {
+	calendarService *services.CalendarService
+}
+
+func NewCalendarController(calendarService *services.CalendarService) *CalendarController {
+	return &CalendarController{calendarService: calendarService}
+}
+
+// CreateCalendar creates a calendar of the authenticated user
+func (h *CalendarController) CreateCalendar(c *gin.Context) {
+	var req dto.CalendarRequest
+	calendar, err := req.ProcessRequest(c, true)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.calendarService.CreateCalendar(CurrentUserID(c), calendar); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create calendar"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Calendar created successfully", "calendar": calendar})
+}
+
+// GetCalendars lists the calendars of the authenticated user, the default one first, followed
+// by the ones shared with them, each with the user's role
+func (h *CalendarController) GetCalendars(c *gin.Context) {
+	calendars, err := h.calendarService.ListCalendars(CurrentUserID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get calendars"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"calendars": calendars, "count": len(calendars)})
+}
+
+// GetCalendar returns a calendar the user owns or that is shared with them
+func (h *CalendarController) GetCalendar(c *gin.Context) {
+	id, ok := h.calendarID(c)
+	if !ok {
+		return
+	}
+
+	calendar, err := h.calendarService.GetCalendar(CurrentUserID(c), id, models.CalendarViewer)
+	if err != nil {
+		h.calendarError(c, err, "Failed to get calendar")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"calendar": calendar})
+}
+
+// UpdateCalendar renames or recolors a calendar. Requires the manager role
+func (h *CalendarController) UpdateCalendar(c *gin.Context) {
+	id, ok := h.calendarID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.CalendarRequest
+	changes, err := req.ProcessRequest(c, false)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	calendar, err := h.calendarService.UpdateCalendar(CurrentUserID(c), id, changes)
+	if err != nil {
+		h.calendarError(c, err, "Failed to update calendar")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Calendar updated successfully", "calendar": calendar})
+}
+
+// DeleteCalendar deletes a calendar with all its events. Only its owner can delete it, and
+// the default calendar cannot be deleted
+func (h *CalendarController) DeleteCalendar(c *gin.Context) {
+	id, ok := h.calendarID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.calendarService.DeleteCalendar(CurrentUserID(c), id); err != nil {
+		h.calendarError(c, err, "Failed to delete calendar")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Calendar deleted successfully"})
+}
+
+// GetShares lists the users a calendar is shared with and their roles. Requires the manager role
+func (h *CalendarController) GetShares(c *gin.Context) {
+	id, ok := h.calendarID(c)
+	if !ok {
+		return
+	}
+
+	shares, err := h.calendarService.Shares(CurrentUserID(c), id)
+	if err != nil {
+		h.calendarError(c, err, "Failed to get shares")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"shares": shares, "count": len(shares)})
+}
+
+// ShareCalendar shares a calendar with the user of an email as viewer, editor or manager, or
+// changes their role if it was already shared with them. Requires the manager role
+func (h *CalendarController) ShareCalendar(c *gin.Context) {
+	id, ok := h.calendarID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.ShareCalendarRequest
+	if err := req.ProcessRequest(c); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	share, err := h.calendarService.ShareCalendar(CurrentUserID(c), id, req.Email, req.Role)
+	if err != nil {
+		h.calendarError(c, err, "Failed to share calendar")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Calendar shared successfully", "share": share})
+}
+
+// UnshareCalendar stops sharing a calendar with a user. Managers can remove anyone; any user
+// can remove themselves to leave a calendar shared with them
+func (h *CalendarController) UnshareCalendar(c *gin.Context) {
+	id, ok := h.calendarID(c)
+	if !ok {
+		return
+	}
+	userID, err := strconv.ParseUint(c.Param("userId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	if err := h.calendarService.UnshareCalendar(CurrentUserID(c), id, uint(userID)); err != nil {
+		h.calendarError(c, err, "Failed to unshare calendar")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Calendar unshared successfully"})
+}
+
+func (h *CalendarController) calendarID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid calendar ID"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func (h *CalendarController) calendarError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrCalendarNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrCalendarForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrDefaultCalendar), errors.Is(err, services.ErrShareUserNotFound),
+		errors.Is(err, services.ErrShareWithOwner), errors.Is(err, services.ErrNotShared):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
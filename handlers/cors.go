@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CORSMiddleware lets the browser origins of the allowlist call the API ("*" allows any)
+func CORSMiddleware(allowedOrigins []string) gin.HandlerFunc {
+	anyOrigin := originAllowed(allowedOrigins, "*")
+	return func(c *gin.Context) {
+		if anyOrigin {
+			c.Header("Access-Control-Allow-Origin", "*")
+		} else if origin := c.GetHeader("Origin"); origin != "" {
+			c.Header("Vary", "Origin")
+			if originAllowed(allowedOrigins, origin) {
+				c.Header("Access-Control-Allow-Origin", origin)
+			}
+		}
+		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization, If-Match, Last-Event-ID")
+		c.Header("Access-Control-Expose-Headers", "X-Next-Cursor, Link, ETag")
+
+		// CalDAV clients send OPTIONS to discover the server capabilities
+		if c.Request.Method == "OPTIONS" && !strings.HasPrefix(c.Request.URL.Path, "/dav/") {
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// originAllowed reports whether the Origin header is in the allowlist, or the list has "*"
+func originAllowed(allowedOrigins []string, origin string) bool {
+	origin = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(origin), "/"))
+	for _, allowed := range allowedOrigins {
+		if allowed == "*" || strings.TrimSuffix(allowed, "/") == origin {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestOriginAllowed(t *testing.T) {
+	allowlist := []string{"https://app.example.com", "http://localhost:3000/"}
+	tests := []struct {
+		origins []string
+		origin  string
+		want    bool
+	}{
+		{allowlist, "https://app.example.com", true},
+		{allowlist, "https://App.Example.com/", true},
+		{allowlist, "http://localhost:3000", true},
+		{allowlist, "https://evil.example.com", false},
+		{allowlist, "http://app.example.com", false},
+		{allowlist, "null", false},
+		{[]string{"*"}, "https://evil.example.com", true},
+		{nil, "https://app.example.com", false},
+	}
+	for _, tt := range tests {
+		if got := originAllowed(tt.origins, tt.origin); got != tt.want {
+			t.Errorf("originAllowed(%v, %q) = %v, want %v", tt.origins, tt.origin, got, tt.want)
+		}
+	}
+}
+
+func TestCORSMiddleware(t *testing.T) {
+	tests := []struct {
+		name    string
+		origins []string
+		origin  string
+		want    string // Access-Control-Allow-Origin
+	}{
+		{"any origin", []string{"*"}, "https://evil.example.com", "*"},
+		{"allowed origin", []string{"https://app.example.com"}, "https://app.example.com", "https://app.example.com"},
+		{"other origin", []string{"https://app.example.com"}, "https://evil.example.com", ""},
+		{"without origin", []string{"https://app.example.com"}, "", ""},
+	}
+	gin.SetMode(gin.TestMode)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(CORSMiddleware(tt.origins))
+			router.GET("/api/v1/events", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			req := httptest.NewRequest(http.MethodOptions, "/api/v1/events", nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+			if recorder.Code != http.StatusNoContent {
+				t.Errorf("preflight status %d, want 204", recorder.Code)
+			}
+			if got := recorder.Header().Get("Access-Control-Allow-Origin"); got != tt.want {
+				t.Errorf("Access-Control-Allow-Origin %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStreamWebSocketChecksOrigin(t *testing.T) {
+	controller := NewStreamController(nil, []string{"https://app.example.com"})
+	tests := []struct {
+		origin  string
+		allowed bool
+	}{
+		{"", true}, // Apps nativas
+		{"https://app.example.com", true},
+		{"https://evil.example.com", false},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/stream/ws", nil)
+		if tt.origin != "" {
+			req.Header.Set("Origin", tt.origin)
+		}
+		if err := controller.checkOrigin(req); (err == nil) != tt.allowed {
+			t.Errorf("origin %q: %v, want allowed %v", tt.origin, err, tt.allowed)
+		}
+	}
+
+	// El handshake de un origen no permitido se rechaza antes de seguir los cambios
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/api/v1/stream/ws", controller.WebSocket)
+	server := httptest.NewServer(router)
+	defer server.Close()
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/api/v1/stream/ws", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+	req.Header.Set("Origin", "https://evil.example.com")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("handshake from another origin: status %d, want 403", resp.StatusCode)
+	}
+}
@@ -0,0 +1,27 @@
+package dto
+
+import (
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateAppPasswordRequest DTO para crear una contraseña de aplicación (CalDAV)
+type CreateAppPasswordRequest struct {
+	Name string `json:"name"` // Ej: "iPhone", "Thunderbird"; vacío = "CalDAV"
+}
+
+// ProcessRequest maneja el binding y la validación; el cuerpo es opcional
+func (req *CreateAppPasswordRequest) ProcessRequest(c *gin.Context) error {
+	if err := c.ShouldBindJSON(req); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if len(req.Name) > 100 {
+		return errors.New("name must be at most 100 characters")
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package dto
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterRequest DTO para crear una cuenta
+type RegisterRequest struct {
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required,min=8,max=72"` // bcrypt ignora más de 72 bytes
+	Name     string `json:"name"`
+}
+
+// ProcessRequest maneja el binding y la limpieza
+func (req *RegisterRequest) ProcessRequest(c *gin.Context) error {
+	if err := c.ShouldBindJSON(req); err != nil {
+		return err
+	}
+
+	req.Email = strings.TrimSpace(strings.ToLower(req.Email))
+	req.Name = strings.TrimSpace(req.Name)
+	return nil
+}
+
+// LoginRequest DTO para iniciar sesión
+type LoginRequest struct {
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required"`
+}
+
+// ProcessRequest maneja el binding y la limpieza
+func (req *LoginRequest) ProcessRequest(c *gin.Context) error {
+	if err := c.ShouldBindJSON(req); err != nil {
+		return err
+	}
+
+	req.Email = strings.TrimSpace(strings.ToLower(req.Email))
+	return nil
+}
+
+// RefreshRequest DTO para renovar los tokens
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// ProcessRequest maneja el binding
+func (req *RefreshRequest) ProcessRequest(c *gin.Context) error {
+	return c.ShouldBindJSON(req)
+}
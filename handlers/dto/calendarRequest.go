@@ -0,0 +1,65 @@
+package dto
+
+import (
+	"calendar-backend/models"
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// hexColorPattern valida los colores "#RRGGBB"
+var hexColorPattern = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+
+// CalendarRequest DTO para crear o modificar un calendario
+type CalendarRequest struct {
+	Name  string `json:"name"`
+	Color string `json:"color"` // "#RRGGBB"; vacío = el predeterminado o el actual
+}
+
+// ProcessRequest maneja el binding, la limpieza y la validación. Al crear, el nombre es
+// obligatorio; al modificar, los campos vacíos no cambian.
+func (req *CalendarRequest) ProcessRequest(c *gin.Context, create bool) (*models.Calendar, error) {
+	if err := c.ShouldBindJSON(req); err != nil {
+		return nil, err
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if create && req.Name == "" {
+		return nil, errors.New("name is required")
+	}
+	if len(req.Name) > 100 {
+		return nil, errors.New("name must be at most 100 characters")
+	}
+
+	req.Color = strings.TrimSpace(req.Color)
+	if req.Color != "" && !hexColorPattern.MatchString(req.Color) {
+		return nil, errors.New("color must be a hex color like #007AFF")
+	}
+	if !create && req.Name == "" && req.Color == "" {
+		return nil, errors.New("at least one field must be provided for update")
+	}
+
+	return &models.Calendar{Name: req.Name, Color: strings.ToUpper(req.Color)}, nil
+}
+
+// ShareCalendarRequest DTO para compartir un calendario con un usuario o cambiar su rol
+type ShareCalendarRequest struct {
+	Email string `json:"email" binding:"required,email"`
+	Role  string `json:"role" binding:"required"`
+}
+
+// ProcessRequest maneja el binding, la limpieza y la validación
+func (req *ShareCalendarRequest) ProcessRequest(c *gin.Context) error {
+	if err := c.ShouldBindJSON(req); err != nil {
+		return err
+	}
+
+	req.Email = strings.TrimSpace(strings.ToLower(req.Email))
+	req.Role = strings.ToLower(strings.TrimSpace(req.Role))
+	if !models.IsCalendarShareRole(req.Role) {
+		return errors.New("invalid role, must be: " + strings.Join(models.CalendarShareRoles, ", "))
+	}
+	return nil
+}
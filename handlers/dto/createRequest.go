@@ -3,50 +3,80 @@ package dto
 import (
 	"calendar-backend/models"
 	"errors"
+	"regexp"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+// uuidPattern valida los client_id (UUID en minúsculas, ya normalizado)
+var uuidPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+
 // CreateEventRequest DTO para la creación de eventos
 type CreateEventRequest struct {
 	Title             string `json:"title" binding:"required" validate:"min=1,max=100"`
 	Description       string `json:"description" validate:"max=500"`
-	Date              string `json:"date" binding:"required" validate:"date_format"`
-	Time              string `json:"time" validate:"time_format"`
+	Date              string `json:"date" validate:"date_format"` // Legacy: usar starts_at
+	Time              string `json:"time" validate:"time_format"` // Legacy: usar starts_at
+	StartsAt          string `json:"starts_at"`                   // RFC 3339
+	EndsAt            string `json:"ends_at"`                     // RFC 3339
+	EndDate           string `json:"end_date" validate:"omitempty,date_format"`
+	EndTime           string `json:"end_time" validate:"omitempty,time_format"`
+	DurationMinutes   int    `json:"duration_minutes"`
+	TimeZone          string `json:"time_zone"` // Zona IANA; por defecto la del dueño
 	Location          string `json:"location" validate:"max=200"`
 	Email             string `json:"email" binding:"required,email" validate:"email"`
 	Phone             string `json:"phone" binding:"required" validate:"min=10,max=20"`
-	ReminderDay       bool   `json:"reminder_day"`
-	ReminderDayBefore bool   `json:"reminder_day_before"`
+	ReminderDay       bool   `json:"reminder_day"`        // Legacy: usar reminders
+	ReminderDayBefore bool   `json:"reminder_day_before"` // Legacy: usar reminders
 	IsAllDay          bool   `json:"is_all_day"`
 	Color             string `json:"color" validate:"hexcolor"`
 	Priority          string `json:"priority" validate:"oneof=low medium high"`
 	Category          string `json:"category" validate:"max=50"`
+	// Recurrencia (RFC 5545)
+	RRule   string   `json:"rrule"`   // Ej: "FREQ=WEEKLY;BYDAY=MO,WE;UNTIL=20251231"
+	ExDates []string `json:"exdates"` // Fechas excluidas, formato "2006-01-02"
+	// Recordatorios; si no se envían se derivan de reminder_day y reminder_day_before
+	Reminders []ReminderRequest `json:"reminders"`
+	// UUID generado por la app (ej: al crear el evento sin conexión): reenviar el alta con el
+	// mismo client_id no crea otro evento
+	ClientID string `json:"client_id" validate:"omitempty,uuid"`
+	// Calendario del evento; sin calendario va al predeterminado del usuario
+	CalendarID uint `json:"calendar_id"`
 }
 
 // ToEvent convierte el DTO a un modelo Event
 func (req *CreateEventRequest) ToEvent() (*models.Event, error) {
-	// Validar y parsear fecha
-	date, err := time.Parse("2006-01-02", req.Date)
+	// Resolver zona, inicio y fin del evento
+	timeZone, loc, err := resolveTimeZone(req.TimeZone)
 	if err != nil {
-		return nil, errors.New("invalid date format, use YYYY-MM-DD")
+		return nil, err
 	}
-
-	// Validar formato de hora si no es evento de todo el día
-	if !req.IsAllDay && req.Time != "" {
-		_, err := time.Parse("15:04", req.Time)
-		if err != nil {
-			return nil, errors.New("invalid time format, use HH:MM")
-		}
+	startsAt, err := resolveStartsAt(req.StartsAt, req.Date, req.Time, req.IsAllDay, loc)
+	if err != nil {
+		return nil, err
 	}
+	endsAt, err := resolveEndsAt(startsAt, req.EndsAt, req.EndDate, req.EndTime, req.DurationMinutes, req.IsAllDay, loc)
+	if err != nil {
+		return nil, err
+	}
+	if endsAt.IsZero() {
+		endsAt = models.DefaultEndsAt(startsAt, req.IsAllDay, loc)
+	}
+	date := models.CalendarDate(startsAt, loc)
 
-	// Validar que la fecha no sea en el pasado
-	if date.Before(time.Now().Truncate(24 * time.Hour)) {
+	// Validar que la fecha no sea en el pasado (en la zona del evento)
+	if date.Before(models.CalendarDate(time.Now(), loc)) {
 		return nil, errors.New("cannot create events in the past")
 	}
 
+	// Validar recurrencia
+	rrule, exDates, err := normalizeRecurrence(req.RRule, req.ExDates)
+	if err != nil {
+		return nil, err
+	}
+
 	// Aplicar valores por defecto
 	if req.Color == "" {
 		req.Color = "#007AFF"
@@ -55,38 +85,51 @@ func (req *CreateEventRequest) ToEvent() (*models.Event, error) {
 		req.Priority = "medium"
 	}
 
-	// Si es evento de todo el día, limpiar la hora
-	time := req.Time
-	if req.IsAllDay {
-		time = ""
-	}
-
 	// Aplicar colores por categoría
 	req.applyCategoryColors()
 
-	// Configurar recordatorios por defecto
-	reminderDay := req.ReminderDay
-	reminderDayBefore := req.ReminderDayBefore
-	if !reminderDay && !reminderDayBefore {
-		reminderDay = true
-		reminderDayBefore = true
-	}
-
-	return &models.Event{
-		Title:             req.Title,
-		Description:       req.Description,
-		Date:              date,
-		Time:              time,
-		Location:          req.Location,
-		Email:             req.Email,
-		Phone:             req.Phone,
-		ReminderDay:       reminderDay,
-		ReminderDayBefore: reminderDayBefore,
-		IsAllDay:          req.IsAllDay,
-		Color:             req.Color,
-		Priority:          req.Priority,
-		Category:          req.Category,
-	}, nil
+	// Configurar recordatorios: los explícitos, o los equivalentes a los flags legacy
+	var reminders []models.Reminder
+	if req.Reminders != nil {
+		reminders, err = toReminders(req.Reminders)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		reminderDay := req.ReminderDay
+		reminderDayBefore := req.ReminderDayBefore
+		if !reminderDay && !reminderDayBefore {
+			reminderDay = true
+			reminderDayBefore = true
+		}
+		reminders = models.LegacyReminders(reminderDay, reminderDayBefore, req.IsAllDay)
+	}
+
+	event := &models.Event{
+		Title:       req.Title,
+		Description: req.Description,
+		StartsAt:    startsAt,
+		EndsAt:      endsAt,
+		TimeZone:    timeZone,
+		Location:    req.Location,
+		Email:       req.Email,
+		Phone:       req.Phone,
+		Reminders:   reminders,
+		IsAllDay:    req.IsAllDay,
+		Color:       req.Color,
+		Priority:    req.Priority,
+		Category:    req.Category,
+		RRule:       rrule,
+		ExDates:     exDates,
+		ClientID:    req.ClientID,
+		CalendarID:  req.CalendarID,
+	}
+
+	// Completar fecha, hora y flags de recordatorio legacy para clientes antiguos
+	event.SyncLegacyFields()
+	event.SyncReminderFlags()
+
+	return event, nil
 }
 
 // Validate realiza validaciones adicionales del DTO
@@ -99,6 +142,11 @@ func (req *CreateEventRequest) Validate() error {
 		return errors.New("title must be less than 100 characters")
 	}
 
+	// Validar inicio
+	if req.Date == "" && req.StartsAt == "" {
+		return errors.New("date or starts_at is required")
+	}
+
 	// Validar descripción
 	if len(req.Description) > 500 {
 		return errors.New("description must be less than 500 characters")
@@ -145,6 +193,11 @@ func (req *CreateEventRequest) Validate() error {
 		return errors.New("location must be less than 200 characters")
 	}
 
+	// Validar client_id
+	if req.ClientID != "" && !uuidPattern.MatchString(req.ClientID) {
+		return errors.New("client_id must be a UUID")
+	}
+
 	return nil
 }
 
@@ -171,18 +224,29 @@ func (req *CreateEventRequest) Sanitize() {
 	req.Email = strings.TrimSpace(strings.ToLower(req.Email))
 	req.Phone = strings.TrimSpace(req.Phone)
 	req.Category = strings.TrimSpace(strings.ToLower(req.Category))
+	req.ClientID = strings.TrimSpace(strings.ToLower(req.ClientID))
 
 	if req.Priority != "" {
 		req.Priority = strings.ToLower(req.Priority)
 	}
 }
 
-func (req *CreateEventRequest) ProcessRequest(c *gin.Context) (*models.Event, error) {
+// ProcessRequest maneja binding, validación y conversión. defaultTimeZone es la zona
+// del dueño, usada cuando el request no indica una.
+func (req *CreateEventRequest) ProcessRequest(c *gin.Context, defaultTimeZone string) (*models.Event, error) {
 	if err := c.ShouldBindJSON(req); err != nil {
 		return nil, err
 	}
 
+	return req.Prepare(defaultTimeZone)
+}
+
+// Prepare limpia, valida y convierte un request ya cargado (del JSON o de una fila de un CSV)
+func (req *CreateEventRequest) Prepare(defaultTimeZone string) (*models.Event, error) {
 	req.Sanitize()
+	if req.TimeZone == "" {
+		req.TimeZone = defaultTimeZone
+	}
 
 	if err := req.Validate(); err != nil {
 		return nil, err
@@ -0,0 +1,261 @@
+package dto
+
+import (
+	"calendar-backend/eventcsv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Campos de CreateEventRequest que pueden venir de una columna del CSV
+var csvFields = map[string]bool{
+	"title": true, "description": true, "date": true, "time": true, "starts_at": true,
+	"ends_at": true, "end_date": true, "end_time": true, "duration_minutes": true,
+	"time_zone": true, "location": true, "email": true, "phone": true, "is_all_day": true,
+	"color": true, "priority": true, "category": true, "rrule": true, "exdates": true,
+	"reminder_day": true, "reminder_day_before": true,
+}
+
+// Otros nombres de encabezado reconocidos sin mapeo
+var csvAliases = map[string]string{
+	"all_day":    "is_all_day",
+	"start_date": "date",
+	"start_time": "time",
+	"duration":   "duration_minutes",
+	"timezone":   "time_zone",
+}
+
+// Formatos de fecha aceptados en date_format y su layout de Go
+var csvDateFormats = map[string]string{
+	"YYYY-MM-DD": "2006-1-2",
+	"DD/MM/YYYY": "2/1/2006",
+	"MM/DD/YYYY": "1/2/2006",
+	"DD-MM-YYYY": "2-1-2006",
+	"DD.MM.YYYY": "2.1.2006",
+}
+
+// Formatos de hora aceptados en las columnas time y end_time
+var csvTimeLayouts = []string{"15:04", "15:04:05", "3:04 PM", "3:04PM", "3:04 pm", "3:04pm"}
+
+// CSVImportRequest DTO para importar eventos desde un CSV (multipart/form-data: "file",
+// "mapping" con el JSON de abajo y "dry_run")
+type CSVImportRequest struct {
+	Columns    map[string]string `json:"columns"`     // Campo del evento -> encabezado de la columna; vacío = columnas con el nombre del campo
+	Defaults   map[string]string `json:"defaults"`    // Valor de los campos sin columna o con la celda vacía
+	DateFormat string            `json:"date_format"` // Formato de date y end_date; por defecto YYYY-MM-DD
+	Delimiter  string            `json:"delimiter"`   // Separador; vacío = detectarlo
+	DryRun     bool              `json:"-"`           // Validar sin guardar
+}
+
+// ProcessRequest lee el mapeo y el modo de prueba. ownerEmail es el email por defecto de los eventos.
+func (req *CSVImportRequest) ProcessRequest(c *gin.Context, ownerEmail string) error {
+	if mapping := strings.TrimSpace(c.PostForm("mapping")); mapping != "" {
+		if err := json.Unmarshal([]byte(mapping), req); err != nil {
+			return errors.New("invalid mapping: " + err.Error())
+		}
+	}
+
+	dryRun := c.PostForm("dry_run")
+	if dryRun == "" {
+		dryRun = c.Query("dry_run")
+	}
+	if dryRun != "" {
+		value, err := strconv.ParseBool(dryRun)
+		if err != nil {
+			return errors.New("dry_run must be true or false")
+		}
+		req.DryRun = value
+	}
+
+	for field := range req.Columns {
+		if !csvFields[field] {
+			return fmt.Errorf("unknown field %q in columns", field)
+		}
+	}
+	defaults := make(map[string]string, len(req.Defaults)+1)
+	for field, value := range req.Defaults {
+		if !csvFields[field] {
+			return fmt.Errorf("unknown field %q in defaults", field)
+		}
+		defaults[field] = strings.TrimSpace(value)
+	}
+	if defaults["email"] == "" {
+		defaults["email"] = ownerEmail
+	}
+	req.Defaults = defaults
+
+	if req.DateFormat == "" {
+		req.DateFormat = "YYYY-MM-DD"
+	}
+	req.DateFormat = strings.ToUpper(req.DateFormat)
+	if _, ok := csvDateFormats[req.DateFormat]; !ok {
+		return errors.New("invalid date_format, use YYYY-MM-DD, DD/MM/YYYY, MM/DD/YYYY, DD-MM-YYYY or DD.MM.YYYY")
+	}
+	if req.Delimiter == `\t` {
+		req.Delimiter = "\t"
+	}
+	if utf8.RuneCountInString(req.Delimiter) > 1 {
+		return errors.New("delimiter must be a single character")
+	}
+	return nil
+}
+
+// DelimiterRune devuelve el separador pedido, o 0 para detectarlo
+func (req *CSVImportRequest) DelimiterRune() rune {
+	r, _ := utf8.DecodeRuneInString(req.Delimiter)
+	if r == utf8.RuneError {
+		return 0
+	}
+	return r
+}
+
+// ResolveColumns devuelve la columna de cada campo según el mapeo o, sin mapeo, según los
+// nombres del encabezado
+func (req *CSVImportRequest) ResolveColumns(header []string) (map[string]int, error) {
+	positions := make(map[string]int, len(header))
+	for i, name := range header {
+		positions[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	columns := make(map[string]int)
+	if len(req.Columns) > 0 {
+		for field, name := range req.Columns {
+			i, ok := positions[strings.ToLower(strings.TrimSpace(name))]
+			if !ok {
+				return nil, fmt.Errorf("column %q mapped to %s is not in the header", name, field)
+			}
+			columns[field] = i
+		}
+	} else {
+		for name, i := range positions {
+			field := strings.ReplaceAll(name, " ", "_")
+			if alias, ok := csvAliases[field]; ok {
+				field = alias
+			}
+			if csvFields[field] {
+				columns[field] = i
+			}
+		}
+	}
+
+	if _, ok := columns["title"]; !ok && req.Defaults["title"] == "" {
+		return nil, errors.New("a title column is required")
+	}
+	_, hasDate := columns["date"]
+	_, hasStartsAt := columns["starts_at"]
+	if !hasDate && !hasStartsAt {
+		return nil, errors.New("a date or starts_at column is required")
+	}
+	return columns, nil
+}
+
+// EventRequest convierte una fila en el request de creación del evento
+func (req *CSVImportRequest) EventRequest(record *eventcsv.Record, columns map[string]int) (*CreateEventRequest, error) {
+	value := func(field string) string {
+		if i, ok := columns[field]; ok {
+			if v := record.Value(i); v != "" {
+				return v
+			}
+		}
+		return req.Defaults[field]
+	}
+
+	event := &CreateEventRequest{
+		Title:       value("title"),
+		Description: value("description"),
+		StartsAt:    value("starts_at"),
+		EndsAt:      value("ends_at"),
+		TimeZone:    value("time_zone"),
+		Location:    value("location"),
+		Email:       value("email"),
+		Phone:       value("phone"),
+		Color:       value("color"),
+		Priority:    value("priority"),
+		Category:    value("category"),
+		RRule:       value("rrule"),
+	}
+
+	var err error
+	layout := csvDateFormats[req.DateFormat]
+	if event.Date, err = csvDate(value("date"), layout, "date"); err != nil {
+		return nil, err
+	}
+	if event.EndDate, err = csvDate(value("end_date"), layout, "end_date"); err != nil {
+		return nil, err
+	}
+	if event.Time, err = csvTime(value("time"), "time"); err != nil {
+		return nil, err
+	}
+	if event.EndTime, err = csvTime(value("end_time"), "end_time"); err != nil {
+		return nil, err
+	}
+	if event.IsAllDay, err = csvBool(value("is_all_day"), "is_all_day"); err != nil {
+		return nil, err
+	}
+	if event.ReminderDay, err = csvBool(value("reminder_day"), "reminder_day"); err != nil {
+		return nil, err
+	}
+	if event.ReminderDayBefore, err = csvBool(value("reminder_day_before"), "reminder_day_before"); err != nil {
+		return nil, err
+	}
+	if duration := value("duration_minutes"); duration != "" {
+		if event.DurationMinutes, err = strconv.Atoi(duration); err != nil {
+			return nil, errors.New("invalid duration_minutes, use a whole number of minutes")
+		}
+	}
+	for _, exDate := range strings.FieldsFunc(value("exdates"), func(r rune) bool { return r == ' ' || r == ',' || r == ';' }) {
+		date, err := csvDate(exDate, layout, "exdates")
+		if err != nil {
+			return nil, err
+		}
+		event.ExDates = append(event.ExDates, date)
+	}
+
+	// Sin hora el evento es de todo el día
+	if event.Time == "" && event.StartsAt == "" {
+		event.IsAllDay = true
+	}
+	return event, nil
+}
+
+// csvDate normaliza una fecha al formato YYYY-MM-DD
+func csvDate(value, layout, field string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	date, err := time.Parse(layout, value)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s %q", field, value)
+	}
+	return date.Format("2006-01-02"), nil
+}
+
+// csvTime normaliza una hora al formato HH:MM
+func csvTime(value, field string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	for _, layout := range csvTimeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.Format("15:04"), nil
+		}
+	}
+	return "", fmt.Errorf("invalid %s %q, use HH:MM", field, value)
+}
+
+// csvBool interpreta las marcas habituales de una planilla (true, 1, yes, sí, x)
+func csvBool(value, field string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "", "false", "0", "no", "n":
+		return false, nil
+	case "true", "1", "yes", "y", "si", "sí", "s", "x":
+		return true, nil
+	}
+	return false, fmt.Errorf("invalid %s %q, use true or false", field, value)
+}
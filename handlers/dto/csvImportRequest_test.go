@@ -0,0 +1,116 @@
+package dto
+
+import (
+	"calendar-backend/eventcsv"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCSVImportRequestResolveColumns(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     CSVImportRequest
+		header  []string
+		want    map[string]int
+		wantErr string
+	}{
+		{
+			name:   "field names and aliases",
+			header: []string{"Title", "Start Date", "start_time", "All Day", "Notes"},
+			want:   map[string]int{"title": 0, "date": 1, "time": 2, "is_all_day": 3},
+		},
+		{
+			name:   "explicit mapping",
+			req:    CSVImportRequest{Columns: map[string]string{"title": "Asunto", "date": " fecha ", "location": "Lugar"}},
+			header: []string{"Fecha", "Asunto", "Lugar", "title"},
+			want:   map[string]int{"title": 1, "date": 0, "location": 2},
+		},
+		{
+			name:   "title from the defaults",
+			req:    CSVImportRequest{Defaults: map[string]string{"title": "Turno"}},
+			header: []string{"starts_at"},
+			want:   map[string]int{"starts_at": 0},
+		},
+		{
+			name:    "mapped column missing",
+			req:     CSVImportRequest{Columns: map[string]string{"title": "Asunto", "date": "Día"}},
+			header:  []string{"Asunto", "Fecha"},
+			wantErr: `column "Día" mapped to date is not in the header`,
+		},
+		{name: "without title", header: []string{"date"}, wantErr: "a title column is required"},
+		{name: "without date", header: []string{"title", "time"}, wantErr: "a date or starts_at column is required"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.req.ResolveColumns(tt.header)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("columns %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCSVImportRequestEventRequest(t *testing.T) {
+	columns := map[string]int{"title": 0, "date": 1, "time": 2, "end_time": 3, "is_all_day": 4, "exdates": 5, "phone": 6}
+	defaults := map[string]string{"email": "ana@example.com", "phone": "+5491122334455", "category": "work"}
+
+	tests := []struct {
+		name       string
+		dateFormat string
+		values     []string
+		want       CreateEventRequest
+		wantErr    string
+	}{
+		{
+			name:       "timed event with defaults",
+			dateFormat: "YYYY-MM-DD",
+			values:     []string{"Reunión", "2030-07-01", "9:30 AM", "10:15", "", "", ""},
+			want: CreateEventRequest{Title: "Reunión", Date: "2030-07-01", Time: "09:30", EndTime: "10:15",
+				Email: "ana@example.com", Phone: "+5491122334455", Category: "work"},
+		},
+		{
+			name:       "day first dates and exdates",
+			dateFormat: "DD/MM/YYYY",
+			values:     []string{"Clase", "1/7/2030", "18:00", "", "no", "8/7/2030; 15/07/2030", "+5491100000000"},
+			want: CreateEventRequest{Title: "Clase", Date: "2030-07-01", Time: "18:00", ExDates: []string{"2030-07-08", "2030-07-15"},
+				Email: "ana@example.com", Phone: "+5491100000000", Category: "work"},
+		},
+		{
+			name:       "without time is all day",
+			dateFormat: "MM/DD/YYYY",
+			values:     []string{"Feriado", "07/04/2030"},
+			want:       CreateEventRequest{Title: "Feriado", Date: "2030-07-04", IsAllDay: true, Email: "ana@example.com", Phone: "+5491122334455", Category: "work"},
+		},
+		{name: "invalid date", dateFormat: "YYYY-MM-DD", values: []string{"Reunión", "01/07/2030"}, wantErr: `invalid date "01/07/2030"`},
+		{name: "invalid time", dateFormat: "YYYY-MM-DD", values: []string{"Reunión", "2030-07-01", "9h"}, wantErr: `invalid time "9h", use HH:MM`},
+		{name: "invalid flag", dateFormat: "YYYY-MM-DD", values: []string{"Reunión", "2030-07-01", "", "", "maybe"}, wantErr: `invalid is_all_day "maybe", use true or false`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := CSVImportRequest{Defaults: defaults, DateFormat: tt.dateFormat}
+			got, err := req.EventRequest(&eventcsv.Record{Values: tt.values}, columns)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("request %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
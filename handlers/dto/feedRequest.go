@@ -0,0 +1,69 @@
+package dto
+
+import (
+	"calendar-backend/models"
+	"errors"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateFeedRequest DTO para crear una suscripción webcal, opcionalmente filtrada
+type CreateFeedRequest struct {
+	Name       string   `json:"name"`
+	Categories []string `json:"categories"` // Vacío = todas las categorías
+	Priorities []string `json:"priorities"` // Vacío = todas las prioridades
+}
+
+// ProcessRequest maneja el binding, la limpieza y la validación
+func (req *CreateFeedRequest) ProcessRequest(c *gin.Context) (*models.Feed, error) {
+	if err := c.ShouldBindJSON(req); err != nil {
+		return nil, err
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if len(req.Name) > 100 {
+		return nil, errors.New("name must be at most 100 characters")
+	}
+
+	categories, err := cleanList(req.Categories)
+	if err != nil {
+		return nil, errors.New("invalid categories: " + err.Error())
+	}
+	priorities, err := cleanList(req.Priorities)
+	if err != nil {
+		return nil, errors.New("invalid priorities: " + err.Error())
+	}
+
+	// Validar prioridades
+	validPriorities := map[string]bool{"low": true, "medium": true, "high": true}
+	for _, p := range priorities {
+		if !validPriorities[p] {
+			return nil, errors.New("invalid priority, must be: low, medium, or high")
+		}
+	}
+
+	return &models.Feed{
+		Name:       req.Name,
+		Categories: strings.Join(categories, ","),
+		Priorities: strings.Join(priorities, ","),
+	}, nil
+}
+
+// cleanList normaliza los valores de un filtro (minúsculas, sin vacíos ni repetidos)
+func cleanList(values []string) ([]string, error) {
+	var list []string
+	seen := make(map[string]bool)
+	for _, value := range values {
+		value = strings.TrimSpace(strings.ToLower(value))
+		if value == "" || seen[value] {
+			continue
+		}
+		if strings.Contains(value, ",") {
+			return nil, errors.New("values cannot contain commas")
+		}
+		seen[value] = true
+		list = append(list, value)
+	}
+	return list, nil
+}
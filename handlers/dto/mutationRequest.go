@@ -0,0 +1,120 @@
+package dto
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MaxMutations es la cantidad máxima de escrituras de un lote
+const MaxMutations = 100
+
+// Operaciones de una mutación
+const (
+	MutationCreate = "create"
+	MutationUpdate = "update"
+	MutationDelete = "delete"
+)
+
+// MutationRequest es una escritura de la cola offline de una app
+type MutationRequest struct {
+	Op string `json:"op"` // create, update o delete
+	// Evento a modificar o eliminar: su ID, o su client_id si la app lo creó sin conexión y
+	// todavía no conoce el ID. En create, el client_id del alta.
+	ID       uint   `json:"id"`
+	ClientID string `json:"client_id"`
+	// Versión del evento que modificó la app; 0 aplica la escritura sin comprobarla
+	Version int `json:"version"`
+	// Alcance en series recurrentes, como en los query parameters de PUT y DELETE
+	Scope          string `json:"scope"`
+	OccurrenceDate string `json:"occurrence_date"`
+	// Campos del evento: los de CreateEventRequest en create, los de UpdateEventRequest en update
+	Event json.RawMessage `json:"event"`
+}
+
+// MutationBatchRequest DTO para aplicar en orden las escrituras encoladas sin conexión
+type MutationBatchRequest struct {
+	Mutations []MutationRequest `json:"mutations"`
+}
+
+// ProcessRequest maneja binding y validación del lote. Cada mutación se valida en detalle
+// al aplicarla, para que una inválida no impida aplicar las demás.
+func (req *MutationBatchRequest) ProcessRequest(c *gin.Context) error {
+	if err := c.ShouldBindJSON(req); err != nil {
+		return err
+	}
+
+	if len(req.Mutations) == 0 {
+		return errors.New("mutations is required")
+	}
+	if len(req.Mutations) > MaxMutations {
+		return fmt.Errorf("a batch can have at most %d mutations", MaxMutations)
+	}
+	return nil
+}
+
+// Validate valida la operación y el evento al que se refiere la mutación
+func (m *MutationRequest) Validate() error {
+	m.Op = strings.TrimSpace(strings.ToLower(m.Op))
+	m.ClientID = strings.TrimSpace(strings.ToLower(m.ClientID))
+
+	switch m.Op {
+	case MutationCreate:
+		if len(m.Event) == 0 {
+			return errors.New("event is required to create an event")
+		}
+	case MutationUpdate, MutationDelete:
+		if m.ID == 0 && m.ClientID == "" {
+			return errors.New("id or client_id is required")
+		}
+		if m.Op == MutationUpdate && len(m.Event) == 0 {
+			return errors.New("event is required to update an event")
+		}
+	default:
+		return errors.New("invalid op, must be: create, update, or delete")
+	}
+
+	if m.ClientID != "" && !uuidPattern.MatchString(m.ClientID) {
+		return errors.New("client_id must be a UUID")
+	}
+	if m.Version < 0 {
+		return errors.New("version must be a positive number")
+	}
+	return nil
+}
+
+// CreateRequest devuelve el alta de la mutación; el client_id de la mutación vale como el del evento
+func (m *MutationRequest) CreateRequest() (*CreateEventRequest, error) {
+	var req CreateEventRequest
+	if err := json.Unmarshal(m.Event, &req); err != nil {
+		return nil, fmt.Errorf("invalid event: %w", err)
+	}
+	if req.ClientID == "" {
+		req.ClientID = m.ClientID
+	}
+	return &req, nil
+}
+
+// UpdateRequest devuelve la actualización de la mutación, con su versión
+func (m *MutationRequest) UpdateRequest() (*UpdateEventRequest, error) {
+	var req UpdateEventRequest
+	if err := json.Unmarshal(m.Event, &req); err != nil {
+		return nil, fmt.Errorf("invalid event: %w", err)
+	}
+	if m.Version != 0 {
+		req.Version = &m.Version
+	}
+	return &req, nil
+}
+
+// ScopeRequest devuelve el alcance de la mutación en series recurrentes, ya validado
+func (m *MutationRequest) ScopeRequest() (*OccurrenceScopeRequest, error) {
+	req := OccurrenceScopeRequest{Scope: m.Scope, OccurrenceDate: m.OccurrenceDate}
+	if err := req.Prepare(); err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
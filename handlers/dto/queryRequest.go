@@ -1,18 +1,46 @@
 package dto
 
 import (
+	"calendar-backend/models"
+	"calendar-backend/search"
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
-// GetEventsQueryRequest DTO para los query parameters de GetEvents
+// Tamaño de página de GetEvents
+const (
+	DefaultEventsPageSize = 100
+	MaxEventsPageSize     = 500
+)
+
+// GetEventsQueryRequest DTO para los query parameters de GetEvents. Los filtros se combinan;
+// category, priority y color aceptan varios valores separados por coma.
 type GetEventsQueryRequest struct {
-	Date      string `form:"date" validate:"omitempty,date_format"`
-	StartDate string `form:"start_date" validate:"omitempty,date_format"`
-	EndDate   string `form:"end_date" validate:"omitempty,date_format"`
-	Search    string `form:"search" validate:"omitempty,min=1,max=100"`
+	Date         string `form:"date" validate:"omitempty,date_format"`
+	StartDate    string `form:"start_date" validate:"omitempty,date_format"`
+	EndDate      string `form:"end_date" validate:"omitempty,date_format"`
+	Search       string `form:"search" validate:"omitempty,min=1,max=100"`
+	Category     string `form:"category"`
+	Priority     string `form:"priority"`
+	Color        string `form:"color"`
+	IsAllDay     *bool  `form:"is_all_day"`
+	HasReminders *bool  `form:"has_reminders"`
+	Location     string `form:"location"`
+	Sort         string `form:"sort"`  // date (por defecto), priority, created_at o title
+	Order        string `form:"order"` // asc (por defecto) o desc
+	Cursor       string `form:"cursor"`
+	Limit        int    `form:"limit"`
+
+	// Campos procesados
+	SearchQuery *search.Query       `form:"-"`
+	Categories  []string            `form:"-"`
+	Priorities  []string            `form:"-"`
+	Colors      []string            `form:"-"`
+	After       *models.EventCursor `form:"-"`
 }
 
 // ProcessQueryRequest procesa los query parameters
@@ -32,6 +60,87 @@ func (req *GetEventsQueryRequest) ProcessQueryRequest(c *gin.Context) error {
 		return err
 	}
 
+	// Validar filtros
+	if err := req.ValidateFilters(); err != nil {
+		return err
+	}
+
+	// Validar orden y paginación
+	if err := req.ValidatePage(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Desc indica si se pidió orden descendente
+func (req *GetEventsQueryRequest) Desc() bool {
+	return req.Order == "desc"
+}
+
+// ValidateFilters valida y normaliza los filtros
+func (req *GetEventsQueryRequest) ValidateFilters() error {
+	var err error
+	if req.Categories, err = cleanList(strings.Split(req.Category, ",")); err != nil {
+		return errors.New("invalid category: " + err.Error())
+	}
+	if req.Priorities, err = cleanList(strings.Split(req.Priority, ",")); err != nil {
+		return errors.New("invalid priority: " + err.Error())
+	}
+	for _, p := range req.Priorities {
+		if models.PriorityRank(p) == 0 {
+			return errors.New("invalid priority, must be: low, medium, or high")
+		}
+	}
+	if req.Colors, err = cleanList(strings.Split(req.Color, ",")); err != nil {
+		return errors.New("invalid color: " + err.Error())
+	}
+
+	req.Location = strings.TrimSpace(req.Location)
+	if len(req.Location) > 100 {
+		return errors.New("location filter must be less than 100 characters")
+	}
+	return nil
+}
+
+// ValidatePage valida el orden, el límite y el cursor
+func (req *GetEventsQueryRequest) ValidatePage() error {
+	if req.Sort == "" {
+		req.Sort = models.EventSortDate
+	}
+	validSort := false
+	for _, field := range models.EventSortFields {
+		validSort = validSort || req.Sort == field
+	}
+	if !validSort {
+		return errors.New("invalid sort, must be: " + strings.Join(models.EventSortFields, ", "))
+	}
+
+	if req.Order == "" {
+		req.Order = "asc"
+	}
+	if req.Order != "asc" && req.Order != "desc" {
+		return errors.New("invalid order, must be: asc or desc")
+	}
+
+	if req.Limit == 0 {
+		req.Limit = DefaultEventsPageSize
+	}
+	if req.Limit < 0 || req.Limit > MaxEventsPageSize {
+		return fmt.Errorf("limit must be between 1 and %d", MaxEventsPageSize)
+	}
+
+	if req.Cursor != "" {
+		cursor, err := models.ParseEventCursor(req.Cursor)
+		if err != nil {
+			return err
+		}
+		// El cursor es una posición en un orden: no sirve para otro
+		if cursor.Sort != req.Sort || cursor.Desc != req.Desc() {
+			return errors.New("cursor does not match the requested sort and order")
+		}
+		req.After = cursor
+	}
 	return nil
 }
 
@@ -62,6 +171,10 @@ func (req *GetEventsQueryRequest) ValidateDates() error {
 		return errors.New("both start_date and end_date must be provided for date range")
 	}
 
+	if req.Date != "" && req.StartDate != "" {
+		return errors.New("use either date or start_date and end_date")
+	}
+
 	return nil
 }
 
@@ -74,6 +187,11 @@ func (req *GetEventsQueryRequest) ValidateSearch() error {
 		if len(req.Search) > 100 {
 			return errors.New("search query must be less than 100 characters")
 		}
+		query, err := search.Parse(req.Search)
+		if err != nil {
+			return err
+		}
+		req.SearchQuery = query
 	}
 	return nil
 }
@@ -0,0 +1,31 @@
+package dto
+
+import (
+	"calendar-backend/models"
+	"errors"
+	"strings"
+	"time"
+)
+
+// normalizeRecurrence valida el RRULE y las excepciones y los devuelve en el formato del modelo
+func normalizeRecurrence(rrule string, exDates []string) (string, string, error) {
+	rrule = strings.TrimSpace(rrule)
+	if rrule != "" {
+		rule, err := models.ParseRRule(rrule)
+		if err != nil {
+			return "", "", errors.New("invalid rrule: " + err.Error())
+		}
+		rrule = rule.String()
+	}
+
+	normalized := make([]string, 0, len(exDates))
+	for _, value := range exDates {
+		date, err := time.Parse("2006-01-02", strings.TrimSpace(value))
+		if err != nil {
+			return "", "", errors.New("invalid exdate format, use YYYY-MM-DD")
+		}
+		normalized = append(normalized, date.Format("2006-01-02"))
+	}
+
+	return rrule, strings.Join(normalized, ","), nil
+}
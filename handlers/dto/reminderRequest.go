@@ -0,0 +1,24 @@
+package dto
+
+import (
+	"calendar-backend/models"
+	"strings"
+)
+
+// ReminderRequest DTO de un recordatorio del evento
+type ReminderRequest struct {
+	OffsetMinutes int    `json:"offset_minutes"` // Minutos antes del inicio (ej: 10, 120, 10080)
+	Channel       string `json:"channel"`        // email, whatsapp; vacío = todos los canales
+}
+
+// toReminders convierte y valida los recordatorios del request
+func toReminders(requests []ReminderRequest) ([]models.Reminder, error) {
+	reminders := make([]models.Reminder, len(requests))
+	for i, req := range requests {
+		reminders[i] = models.Reminder{
+			OffsetMinutes: req.OffsetMinutes,
+			Channel:       strings.ToLower(strings.TrimSpace(req.Channel)),
+		}
+	}
+	return models.NormalizeReminders(reminders)
+}
@@ -0,0 +1,91 @@
+package dto
+
+import (
+	"calendar-backend/models"
+	"errors"
+	"strings"
+	"time"
+)
+
+// resolveStartsAt obtiene el instante de inicio desde starts_at (RFC 3339)
+// o, para clientes antiguos, desde date + time interpretados en loc
+func resolveStartsAt(startsAt, date, clock string, isAllDay bool, loc *time.Location) (time.Time, error) {
+	if startsAt = strings.TrimSpace(startsAt); startsAt != "" {
+		t, err := time.Parse(time.RFC3339, startsAt)
+		if err != nil {
+			return time.Time{}, errors.New("invalid starts_at format, use RFC 3339")
+		}
+		if isAllDay {
+			return models.StartOfDay(models.CalendarDate(t, loc), loc), nil
+		}
+		return t.UTC(), nil
+	}
+
+	d, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return time.Time{}, errors.New("invalid date format, use YYYY-MM-DD")
+	}
+	if !isAllDay && clock != "" {
+		if _, err := time.Parse("15:04", clock); err != nil {
+			return time.Time{}, errors.New("invalid time format, use HH:MM")
+		}
+	}
+	if isAllDay {
+		clock = ""
+	}
+	return models.ComposeInstant(d, clock, loc), nil
+}
+
+// resolveEndsAt obtiene el instante de fin desde ends_at, end_date + end_time o
+// duration_minutes. Devuelve el instante cero si no se indicó ninguno.
+// Para eventos de todo el día end_date es el último día (inclusive).
+// end_date y end_time se interpretan en loc.
+func resolveEndsAt(startsAt time.Time, endsAt, endDate, endClock string, durationMinutes int, isAllDay bool, loc *time.Location) (time.Time, error) {
+	var end time.Time
+
+	switch {
+	case strings.TrimSpace(endsAt) != "":
+		t, err := time.Parse(time.RFC3339, strings.TrimSpace(endsAt))
+		if err != nil {
+			return time.Time{}, errors.New("invalid ends_at format, use RFC 3339")
+		}
+		end = t.UTC()
+	case endDate != "":
+		d, err := time.Parse("2006-01-02", endDate)
+		if err != nil {
+			return time.Time{}, errors.New("invalid end_date format, use YYYY-MM-DD")
+		}
+		if isAllDay {
+			end = models.StartOfDay(d.AddDate(0, 0, 1), loc)
+			break
+		}
+		if endClock == "" {
+			endClock = startsAt.In(loc).Format("15:04")
+		}
+		if _, err := time.Parse("15:04", endClock); err != nil {
+			return time.Time{}, errors.New("invalid end_time format, use HH:MM")
+		}
+		end = models.ComposeInstant(d, endClock, loc)
+	case durationMinutes < 0:
+		return time.Time{}, errors.New("duration_minutes cannot be negative")
+	case durationMinutes > 0:
+		end = startsAt.Add(time.Duration(durationMinutes) * time.Minute)
+	default:
+		return time.Time{}, nil
+	}
+
+	if !startsAt.IsZero() && end.Before(startsAt) {
+		return time.Time{}, errors.New("event cannot end before it starts")
+	}
+	return end, nil
+}
+
+// resolveTimeZone valida la zona IANA pedida
+func resolveTimeZone(timeZone string) (string, *time.Location, error) {
+	timeZone = strings.TrimSpace(timeZone)
+	loc, err := models.LoadLocation(timeZone)
+	if err != nil {
+		return "", nil, err
+	}
+	return loc.String(), loc, nil
+}
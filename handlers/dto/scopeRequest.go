@@ -0,0 +1,57 @@
+package dto
+
+import (
+	"calendar-backend/models"
+	"errors"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OccurrenceScopeRequest DTO para los query parameters de edición/eliminación de eventos recurrentes
+type OccurrenceScopeRequest struct {
+	Scope          string `form:"scope" validate:"omitempty,oneof=this following all"`
+	OccurrenceDate string `form:"occurrence_date" validate:"omitempty,date_format"`
+
+	date time.Time
+}
+
+// ProcessQueryRequest procesa los query parameters
+func (req *OccurrenceScopeRequest) ProcessQueryRequest(c *gin.Context) error {
+	if err := c.ShouldBindQuery(req); err != nil {
+		return err
+	}
+
+	return req.Prepare()
+}
+
+// Prepare valida el alcance y la fecha ya cargados (de la URL o de una mutación de un lote)
+func (req *OccurrenceScopeRequest) Prepare() error {
+	if req.Scope == "" {
+		req.Scope = models.ScopeAll
+	}
+
+	switch req.Scope {
+	case models.ScopeAll:
+		return nil
+	case models.ScopeThis, models.ScopeFollowing:
+	default:
+		return errors.New("invalid scope, must be: this, following, or all")
+	}
+
+	if req.OccurrenceDate == "" {
+		return errors.New("occurrence_date is required when scope is this or following")
+	}
+	date, err := time.Parse("2006-01-02", req.OccurrenceDate)
+	if err != nil {
+		return errors.New("invalid occurrence_date format, use YYYY-MM-DD")
+	}
+	req.date = date
+
+	return nil
+}
+
+// Date devuelve la fecha de la ocurrencia parseada
+func (req *OccurrenceScopeRequest) Date() time.Time {
+	return req.date
+}
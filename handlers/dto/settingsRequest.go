@@ -0,0 +1,32 @@
+package dto
+
+import (
+	"calendar-backend/models"
+	"errors"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UpdateSettingsRequest DTO para actualizar las preferencias del usuario autenticado
+type UpdateSettingsRequest struct {
+	TimeZone string `json:"time_zone" binding:"required"`
+}
+
+// ProcessRequest maneja el binding, la limpieza y la validación
+func (req *UpdateSettingsRequest) ProcessRequest(c *gin.Context) error {
+	if err := c.ShouldBindJSON(req); err != nil {
+		return err
+	}
+
+	req.TimeZone = strings.TrimSpace(req.TimeZone)
+
+	if req.TimeZone == "" {
+		return errors.New("time_zone is required")
+	}
+	if _, err := models.LoadLocation(req.TimeZone); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,32 @@
+package dto
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateSyncAccountRequest DTO para conectar un calendario externo
+type CreateSyncAccountRequest struct {
+	Provider    string `json:"provider" binding:"required"` // Ej: "google", "microsoft"
+	CalendarID  string `json:"calendar_id"`                 // Vacío = "primary"
+	Credentials string `json:"credentials"`                 // Token OAuth u otras credenciales del proveedor
+}
+
+// ProcessRequest maneja el binding, la limpieza y la validación
+func (req *CreateSyncAccountRequest) ProcessRequest(c *gin.Context) error {
+	if err := c.ShouldBindJSON(req); err != nil {
+		return err
+	}
+
+	req.Provider = strings.ToLower(strings.TrimSpace(req.Provider))
+	req.CalendarID = strings.TrimSpace(req.CalendarID)
+	if req.CalendarID == "" {
+		req.CalendarID = "primary"
+	}
+	if len(req.CalendarID) > 255 {
+		return errors.New("calendar_id must be at most 255 characters")
+	}
+	return nil
+}
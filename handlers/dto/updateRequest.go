@@ -13,23 +13,54 @@ import (
 type UpdateEventRequest struct {
 	Title             *string `json:"title" validate:"omitempty,min=1,max=100"`
 	Description       *string `json:"description" validate:"omitempty,max=500"`
-	Date              *string `json:"date" validate:"omitempty,date_format"`
-	Time              *string `json:"time" validate:"omitempty,time_format"`
+	Date              *string `json:"date" validate:"omitempty,date_format"` // Legacy: usar starts_at
+	Time              *string `json:"time" validate:"omitempty,time_format"` // Legacy: usar starts_at
+	StartsAt          *string `json:"starts_at"`                             // RFC 3339
+	EndsAt            *string `json:"ends_at"`                               // RFC 3339
+	EndDate           *string `json:"end_date" validate:"omitempty,date_format"`
+	EndTime           *string `json:"end_time" validate:"omitempty,time_format"`
+	TimeZone          *string `json:"time_zone"`
 	Location          *string `json:"location" validate:"omitempty,max=200"`
 	Email             *string `json:"email" validate:"omitempty,email"`
 	Phone             *string `json:"phone" validate:"omitempty,min=10,max=20"`
-	ReminderDay       *bool   `json:"reminder_day"`
-	ReminderDayBefore *bool   `json:"reminder_day_before"`
+	ReminderDay       *bool   `json:"reminder_day"`        // Legacy: usar reminders
+	ReminderDayBefore *bool   `json:"reminder_day_before"` // Legacy: usar reminders
 	IsAllDay          *bool   `json:"is_all_day"`
 	Color             *string `json:"color" validate:"omitempty,hexcolor"`
 	Priority          *string `json:"priority" validate:"omitempty,oneof=low medium high"`
 	Category          *string `json:"category" validate:"omitempty,max=50"`
+	// Recurrencia (RFC 5545)
+	RRule   *string   `json:"rrule"`
+	ExDates *[]string `json:"exdates"`
+	// true quita la recurrencia (rrule y exdates): la serie pasa a ser un evento simple
+	ClearRecurrence *bool `json:"clear_recurrence"`
+	// Recordatorios: reemplazan a los actuales; una lista vacía los elimina
+	Reminders *[]ReminderRequest `json:"reminders"`
+	// Calendario al que se mueve el evento (con sus ocurrencias separadas, si es una serie)
+	CalendarID *uint `json:"calendar_id"`
+	// Versión del evento que modificó el cliente; si el evento cambió desde entonces, la
+	// actualización se rechaza. Sin versión, la actualización se aplica siempre.
+	Version *int `json:"version"`
 }
 
-// ToEvent convierte el DTO a un modelo Event para actualización
-func (req *UpdateEventRequest) ToEvent() (*models.Event, error) {
+// ToEvent convierte el DTO a un modelo Event para actualización.
+// current es el evento actual: su zona se usa si el request no trae otra.
+func (req *UpdateEventRequest) ToEvent(current *models.Event) (*models.Event, error) {
 	event := &models.Event{}
 
+	// Procesar zona horaria
+	timeZone := current.TimeZone
+	if req.TimeZone != nil {
+		timeZone = *req.TimeZone
+	}
+	timeZone, loc, err := resolveTimeZone(timeZone)
+	if err != nil {
+		return nil, err
+	}
+	if req.TimeZone != nil {
+		event.TimeZone = timeZone
+	}
+
 	// Procesar título
 	if req.Title != nil {
 		title := strings.TrimSpace(*req.Title)
@@ -57,8 +88,8 @@ func (req *UpdateEventRequest) ToEvent() (*models.Event, error) {
 		if err != nil {
 			return nil, errors.New("invalid date format, use YYYY-MM-DD")
 		}
-		// Validar que la fecha no sea en el pasado
-		if date.Before(time.Now().Truncate(24 * time.Hour)) {
+		// Validar que la fecha no sea en el pasado (en la zona del evento)
+		if date.Before(models.CalendarDate(time.Now(), loc)) {
 			return nil, errors.New("cannot update events to past dates")
 		}
 		event.Date = date
@@ -76,6 +107,36 @@ func (req *UpdateEventRequest) ToEvent() (*models.Event, error) {
 		event.Time = timeStr
 	}
 
+	// Procesar inicio y fin explícitos
+	isAllDay := req.IsAllDay != nil && *req.IsAllDay
+	if req.StartsAt != nil {
+		startsAt, err := resolveStartsAt(*req.StartsAt, "", "", isAllDay, loc)
+		if err != nil {
+			return nil, err
+		}
+		if models.CalendarDate(startsAt, loc).Before(models.CalendarDate(time.Now(), loc)) {
+			return nil, errors.New("cannot update events to past dates")
+		}
+		event.StartsAt = startsAt
+	}
+	if req.EndsAt != nil || req.EndDate != nil {
+		var endsAt, endDate, endTime string
+		if req.EndsAt != nil {
+			endsAt = *req.EndsAt
+		}
+		if req.EndDate != nil {
+			endDate = *req.EndDate
+		}
+		if req.EndTime != nil {
+			endTime = *req.EndTime
+		}
+		end, err := resolveEndsAt(event.StartsAt, endsAt, endDate, endTime, 0, isAllDay, loc)
+		if err != nil {
+			return nil, err
+		}
+		event.EndsAt = end
+	}
+
 	// Procesar ubicación
 	if req.Location != nil {
 		location := strings.TrimSpace(*req.Location)
@@ -109,12 +170,29 @@ func (req *UpdateEventRequest) ToEvent() (*models.Event, error) {
 		event.Phone = phone
 	}
 
-	// Procesar recordatorios
-	if req.ReminderDay != nil {
-		event.ReminderDay = *req.ReminderDay
-	}
-	if req.ReminderDayBefore != nil {
-		event.ReminderDayBefore = *req.ReminderDayBefore
+	// Procesar recordatorios. Los flags legacy reemplazan los recordatorios por sus
+	// equivalentes, conservando el valor actual del flag que no se envía.
+	// Un slice nil indica que los recordatorios no cambian.
+	if req.Reminders != nil {
+		reminders, err := toReminders(*req.Reminders)
+		if err != nil {
+			return nil, err
+		}
+		event.Reminders = reminders
+	} else if req.ReminderDay != nil || req.ReminderDayBefore != nil {
+		reminderDay := current.ReminderDay
+		if req.ReminderDay != nil {
+			reminderDay = *req.ReminderDay
+		}
+		reminderDayBefore := current.ReminderDayBefore
+		if req.ReminderDayBefore != nil {
+			reminderDayBefore = *req.ReminderDayBefore
+		}
+		isAllDay := current.IsAllDay
+		if req.IsAllDay != nil {
+			isAllDay = *req.IsAllDay
+		}
+		event.Reminders = models.LegacyReminders(reminderDay, reminderDayBefore, isAllDay)
 	}
 
 	// Procesar evento de todo el día
@@ -167,16 +245,59 @@ func (req *UpdateEventRequest) ToEvent() (*models.Event, error) {
 		req.applyCategoryColors(event)
 	}
 
+	// Procesar recurrencia
+	if req.ClearRecurrence != nil && *req.ClearRecurrence {
+		if req.RRule != nil || req.ExDates != nil {
+			return nil, errors.New("clear_recurrence cannot be combined with rrule or exdates")
+		}
+		event.ClearRecurrence = true
+	}
+	if req.RRule != nil || req.ExDates != nil {
+		var rrule string
+		var exDates []string
+		if req.RRule != nil {
+			rrule = *req.RRule
+		}
+		if req.ExDates != nil {
+			exDates = *req.ExDates
+		}
+		normalizedRule, normalizedExDates, err := normalizeRecurrence(rrule, exDates)
+		if err != nil {
+			return nil, err
+		}
+		event.RRule = normalizedRule
+		event.ExDates = normalizedExDates
+	}
+
+	// Procesar calendario
+	if req.CalendarID != nil {
+		if *req.CalendarID == 0 {
+			return nil, errors.New("calendar_id must be a positive number")
+		}
+		event.CalendarID = *req.CalendarID
+	}
+
+	// Procesar versión
+	if req.Version != nil {
+		if *req.Version <= 0 {
+			return nil, errors.New("version must be a positive number")
+		}
+		event.Version = *req.Version
+	}
+
 	return event, nil
 }
 
 // Validate realiza validaciones adicionales del DTO
 func (req *UpdateEventRequest) Validate() error {
 	// Validar que al menos un campo sea proporcionado
-	if req.Title == nil && req.Description == nil && req.Date == nil && 
-	   req.Time == nil && req.Location == nil && req.Email == nil && 
-	   req.Phone == nil && req.ReminderDay == nil && req.ReminderDayBefore == nil &&
-	   req.IsAllDay == nil && req.Color == nil && req.Priority == nil && req.Category == nil {
+	if req.Title == nil && req.Description == nil && req.Date == nil &&
+		req.Time == nil && req.Location == nil && req.Email == nil &&
+		req.Phone == nil && req.ReminderDay == nil && req.ReminderDayBefore == nil &&
+		req.IsAllDay == nil && req.Color == nil && req.Priority == nil && req.Category == nil &&
+		req.RRule == nil && req.ExDates == nil && req.Reminders == nil &&
+		req.StartsAt == nil && req.EndsAt == nil && req.EndDate == nil && req.TimeZone == nil &&
+		req.CalendarID == nil && req.ClearRecurrence == nil {
 		return errors.New("at least one field must be provided for update")
 	}
 
@@ -201,20 +322,26 @@ func (req *UpdateEventRequest) applyCategoryColors(event *models.Event) {
 	}
 }
 
-// ProcessRequest maneja todo el proceso: binding, validación y conversión
-func (req *UpdateEventRequest) ProcessRequest(c *gin.Context) (*models.Event, error) {
+// ProcessRequest maneja todo el proceso: binding, validación y conversión.
+// current es el evento que se actualiza.
+func (req *UpdateEventRequest) ProcessRequest(c *gin.Context, current *models.Event) (*models.Event, error) {
 	// 1. Binding del JSON
 	if err := c.ShouldBindJSON(req); err != nil {
 		return nil, err
 	}
 
+	return req.Prepare(current)
+}
+
+// Prepare valida y convierte un request ya cargado (del JSON o de una mutación de un lote)
+func (req *UpdateEventRequest) Prepare(current *models.Event) (*models.Event, error) {
 	// 2. Validar datos
 	if err := req.Validate(); err != nil {
 		return nil, err
 	}
 
 	// 3. Convertir a modelo Event
-	event, err := req.ToEvent()
+	event, err := req.ToEvent(current)
 	if err != nil {
 		return nil, err
 	}
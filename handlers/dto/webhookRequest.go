@@ -0,0 +1,72 @@
+package dto
+
+import (
+	"calendar-backend/models"
+	"calendar-backend/netguard"
+	"errors"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateWebhookRequest DTO para registrar un webhook
+type CreateWebhookRequest struct {
+	URL         string   `json:"url" binding:"required"`
+	Secret      string   `json:"secret"` // Vacío = se genera uno
+	Description string   `json:"description"`
+	EventTypes  []string `json:"event_types"` // Vacío = todos los tipos
+}
+
+// ProcessRequest maneja el binding, la limpieza y la validación
+func (req *CreateWebhookRequest) ProcessRequest(c *gin.Context) (*models.Webhook, error) {
+	if err := c.ShouldBindJSON(req); err != nil {
+		return nil, err
+	}
+
+	req.URL = strings.TrimSpace(req.URL)
+	if len(req.URL) > 2000 {
+		return nil, errors.New("url must be at most 2000 characters")
+	}
+	parsed, err := url.Parse(req.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, errors.New("url must be an absolute http or https URL")
+	}
+	// Los avisos salen del servidor: la URL no puede apuntar a su red interna
+	if err := netguard.CheckHost(c.Request.Context(), parsed.Hostname()); err != nil {
+		return nil, errors.New("url host is not allowed: " + err.Error())
+	}
+
+	req.Secret = strings.TrimSpace(req.Secret)
+	if req.Secret != "" && (len(req.Secret) < 16 || len(req.Secret) > 200) {
+		return nil, errors.New("secret must be between 16 and 200 characters")
+	}
+
+	req.Description = strings.TrimSpace(req.Description)
+	if len(req.Description) > 200 {
+		return nil, errors.New("description must be at most 200 characters")
+	}
+
+	eventTypes, err := cleanList(req.EventTypes)
+	if err != nil {
+		return nil, errors.New("invalid event_types: " + err.Error())
+	}
+
+	// Validar tipos de aviso
+	validTypes := make(map[string]bool, len(models.WebhookEventTypes))
+	for _, t := range models.WebhookEventTypes {
+		validTypes[t] = true
+	}
+	for _, t := range eventTypes {
+		if !validTypes[t] {
+			return nil, errors.New("invalid event type, must be: " + strings.Join(models.WebhookEventTypes, ", "))
+		}
+	}
+
+	return &models.Webhook{
+		URL:         req.URL,
+		Secret:      req.Secret,
+		Description: req.Description,
+		EventTypes:  strings.Join(eventTypes, ","),
+	}, nil
+}
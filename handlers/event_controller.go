@@ -1,36 +1,61 @@
 package handlers
 
 import (
+	"bytes"
+	"calendar-backend/eventcsv"
 	"calendar-backend/handlers/dto"
+	"calendar-backend/ical"
+	"calendar-backend/models"
+	"calendar-backend/search"
 	"calendar-backend/services"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
 type EventController struct {
-	eventService services.EventService
+	eventService    services.EventService
+	settingsService *services.SettingsService
 }
 
-func NewEventController(eventService services.EventService) *EventController {
-	return &EventController{eventService: eventService}
+func NewEventController(eventService services.EventService, settingsService *services.SettingsService) *EventController {
+	return &EventController{eventService: eventService, settingsService: settingsService}
 }
 
 func (h *EventController) CreateEvent(c *gin.Context) {
 	var req dto.CreateEventRequest
 
-	event, err := req.ProcessRequest(c)
+	// Events without an explicit time zone use the owner's default
+	event, err := req.ProcessRequest(c, h.settingsService.DefaultTimeZone(CurrentUserEmail(c)))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	if err := h.eventService.CreateEvent(event); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	// Resending a create with the same client_id returns the event created the first time
+	err = h.eventService.ForUser(CurrentUserID(c)).CreateEvent(event)
+	if errors.Is(err, services.ErrAlreadyCreated) {
+		c.Header("ETag", eventETag(event))
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Event already created",
+			"event":   event,
+		})
+		return
+	}
+	if err != nil {
+		writeEventError(c, err)
 		return
 	}
 
+	c.Header("ETag", eventETag(event))
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "Event created successfully",
 		"event":   event,
@@ -45,13 +70,70 @@ func (h *EventController) GetEvents(c *gin.Context) {
 		return
 	}
 
-	events, err := h.eventService.GetEvents(&queryReq)
+	page, err := h.eventService.ForUser(CurrentUserID(c)).GetEvents(&queryReq)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, events)
+	// The body stays a plain array for existing clients; the next page goes in the headers
+	if page.NextCursor != "" {
+		next := *c.Request.URL
+		query := next.Query()
+		query.Set("cursor", page.NextCursor)
+		next.RawQuery = query.Encode()
+		c.Header("X-Next-Cursor", page.NextCursor)
+		c.Header("Link", "<"+next.RequestURI()+`>; rel="next"`)
+	}
+
+	c.JSON(http.StatusOK, page.Events)
+}
+
+// Number of results returned by the search endpoints
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+// SearchEvents runs a full-text search (?q=) over the title, description, location and
+// category of the events. Quoted words match a phrase and a trailing * a prefix. Results
+// are ranked by relevance and carry the matching snippets of each field highlighted.
+func (h *EventController) SearchEvents(c *gin.Context) {
+	query := c.Query("q")
+	if query == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Search query 'q' is required"})
+		return
+	}
+	limit, ok := searchLimit(c)
+	if !ok {
+		return
+	}
+
+	results, err := h.eventService.ForUser(CurrentUserID(c)).SearchEvents(query, limit)
+	if err != nil {
+		if errors.Is(err, search.ErrEmptyQuery) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search events"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"results": results, "count": len(results), "query": query})
+}
+
+// searchLimit reads the ?limit= of a search, responding with an error if it is out of range
+func searchLimit(c *gin.Context) (int, bool) {
+	limit := defaultSearchLimit
+	if value := c.Query("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 || n > maxSearchLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and " + strconv.Itoa(maxSearchLimit)})
+			return 0, false
+		}
+		limit = n
+	}
+	return limit, true
 }
 
 func (h *EventController) GetEvent(c *gin.Context) {
@@ -64,16 +146,19 @@ func (h *EventController) GetEvent(c *gin.Context) {
 		return
 	}
 
-	event, err := h.eventService.GetEventByID(uint(id))
+	event, err := h.eventService.ForUser(CurrentUserID(c)).GetEventByID(uint(id))
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
 	}
 
+	c.Header("ETag", eventETag(event))
 	c.JSON(http.StatusOK, event)
 }
 
-// UpdateEvent updates an existing event
+// UpdateEvent updates an existing event. With an If-Match header (the ETag of a previous
+// response) or a version in the body, the update is rejected with 409 and the stored event
+// if someone else modified it in the meantime.
 func (h *EventController) UpdateEvent(c *gin.Context) {
 	idStr := c.Param("id")
 
@@ -84,32 +169,59 @@ func (h *EventController) UpdateEvent(c *gin.Context) {
 		return
 	}
 
+	var scopeReq dto.OccurrenceScopeRequest
+	if err := scopeReq.ProcessQueryRequest(c); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	eventService := h.eventService.ForUser(CurrentUserID(c))
+
+	// Dates in the request are interpreted in the event's current time zone,
+	// and legacy reminder flags are merged with its current reminders
+	existingEvent, err := eventService.GetEventByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
 	var req dto.UpdateEventRequest
 
 	// Procesar request completo en el DTO
-	event, err := req.ProcessRequest(c)
+	event, err := req.ProcessRequest(c, existingEvent)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if version != 0 {
+		event.Version = version
+	}
 
-	// Use service to update event
-	if err := h.eventService.UpdateEvent(uint(id), event); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	// Use service to update event (or the selected occurrences of a series)
+	if err := eventService.UpdateOccurrence(uint(id), scopeReq.Date(), scopeReq.Scope, event); err != nil {
+		writeEventError(c, err)
 		return
 	}
 
 	// Get updated event to return
-	updatedEvent, err := h.eventService.GetEventByID(uint(id))
+	updatedEvent, err := eventService.GetEventByID(uint(id))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch updated event"})
 		return
 	}
 
+	c.Header("ETag", eventETag(updatedEvent))
 	c.JSON(http.StatusOK, updatedEvent)
 }
 
-// DeleteEvent deletes an event
+// DeleteEvent deletes an event. Like UpdateEvent, it is rejected with 409 if the If-Match
+// header no longer matches the stored event.
 func (h *EventController) DeleteEvent(c *gin.Context) {
 	idStr := c.Param("id")
 
@@ -120,11 +232,386 @@ func (h *EventController) DeleteEvent(c *gin.Context) {
 		return
 	}
 
-	// Use service to delete event
-	if err := h.eventService.DeleteEvent(uint(id)); err != nil {
+	var scopeReq dto.OccurrenceScopeRequest
+	if err := scopeReq.ProcessQueryRequest(c); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Use service to delete event (or the selected occurrences of a series)
+	if err := h.eventService.ForUser(CurrentUserID(c)).DeleteOccurrence(uint(id), scopeReq.Date(), scopeReq.Scope, version); err != nil {
+		writeEventError(c, err)
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{"message": "Event deleted successfully"})
 }
+
+// mutationResult is the outcome of a mutation of a batch, with the HTTP status the same
+// write would get from its own endpoint
+type mutationResult struct {
+	Index  int           `json:"index"`
+	Op     string        `json:"op"`
+	Status int           `json:"status"`
+	Event  *models.Event `json:"event,omitempty"`
+	Error  string        `json:"error,omitempty"`
+}
+
+// ApplyMutations applies the writes an app queued while offline, in order. Each mutation
+// succeeds or fails on its own, so the response has a result for every one of them: the
+// app drops the applied ones from its queue and resolves the conflicts (409, with the
+// stored event) before sending them again.
+func (h *EventController) ApplyMutations(c *gin.Context) {
+	var req dto.MutationBatchRequest
+	if err := req.ProcessRequest(c); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	eventService := h.eventService.ForUser(CurrentUserID(c))
+	defaultTimeZone := h.settingsService.DefaultTimeZone(CurrentUserEmail(c))
+
+	results := make([]mutationResult, len(req.Mutations))
+	for i := range req.Mutations {
+		results[i] = applyMutation(eventService, &req.Mutations[i], defaultTimeZone)
+		results[i].Index = i
+	}
+
+	c.JSON(http.StatusOK, gin.H{"results": results})
+}
+
+// applyMutation runs a create, update or delete of a batch
+func applyMutation(eventService services.EventService, m *dto.MutationRequest, defaultTimeZone string) mutationResult {
+	if err := m.Validate(); err != nil {
+		return mutationResult{Op: m.Op, Status: http.StatusBadRequest, Error: err.Error()}
+	}
+	result := mutationResult{Op: m.Op}
+
+	if m.Op == dto.MutationCreate {
+		createReq, err := m.CreateRequest()
+		if err != nil {
+			return result.failed(err)
+		}
+		event, err := createReq.Prepare(defaultTimeZone)
+		if err != nil {
+			return result.failed(err)
+		}
+		err = eventService.CreateEvent(event)
+		if err != nil && !errors.Is(err, services.ErrAlreadyCreated) {
+			return result.failed(err)
+		}
+		result.Status, result.Event = http.StatusCreated, event
+		if err != nil {
+			result.Status = http.StatusOK
+		}
+		return result
+	}
+
+	// Events created offline are referenced by their client_id until the app learns their ID
+	var current *models.Event
+	var err error
+	if m.ID != 0 {
+		current, err = eventService.GetEventByID(m.ID)
+	} else {
+		current, err = eventService.GetEventByClientID(m.ClientID)
+	}
+	if err != nil {
+		result.Status, result.Error = http.StatusNotFound, "event not found"
+		return result
+	}
+
+	scopeReq, err := m.ScopeRequest()
+	if err != nil {
+		return result.failed(err)
+	}
+
+	if m.Op == dto.MutationDelete {
+		if err := eventService.DeleteOccurrence(current.ID, scopeReq.Date(), scopeReq.Scope, m.Version); err != nil {
+			return result.failed(err)
+		}
+		result.Status = http.StatusOK
+		return result
+	}
+
+	updateReq, err := m.UpdateRequest()
+	if err != nil {
+		return result.failed(err)
+	}
+	event, err := updateReq.Prepare(current)
+	if err != nil {
+		return result.failed(err)
+	}
+	if err := eventService.UpdateOccurrence(current.ID, scopeReq.Date(), scopeReq.Scope, event); err != nil {
+		return result.failed(err)
+	}
+	updatedEvent, err := eventService.GetEventByID(current.ID)
+	if err != nil {
+		result.Status, result.Error = http.StatusInternalServerError, "Failed to fetch updated event"
+		return result
+	}
+	result.Status, result.Event = http.StatusOK, updatedEvent
+	return result
+}
+
+// failed records the error of a mutation: 409 with the stored event on version conflicts
+func (r mutationResult) failed(err error) mutationResult {
+	var conflict *services.VersionConflictError
+	if errors.As(err, &conflict) {
+		r.Status, r.Event = http.StatusConflict, conflict.Current
+	} else {
+		r.Status = eventErrorStatus(err)
+	}
+	r.Error = err.Error()
+	return r
+}
+
+// eventETag is the entity tag of an event: its version, which changes with every write
+func eventETag(event *models.Event) string {
+	return `"` + strconv.Itoa(event.Version) + `"`
+}
+
+// ifMatchVersion reads the version in the If-Match header ("3" or W/"3", as sent in the
+// ETag). Without the header, or with "*", it is 0: the write is not checked.
+func ifMatchVersion(c *gin.Context) (int, error) {
+	value := strings.TrimSpace(c.GetHeader("If-Match"))
+	if value == "" || value == "*" {
+		return 0, nil
+	}
+	value = strings.Trim(strings.TrimPrefix(value, "W/"), `"`)
+	version, err := strconv.Atoi(value)
+	if err != nil || version <= 0 {
+		return 0, errors.New("invalid If-Match header, use the ETag of the event")
+	}
+	return version, nil
+}
+
+// writeEventError responds with the error of an update or delete: 409 with the stored
+// event (and its ETag) if it was modified since the client read it, 400 otherwise
+func writeEventError(c *gin.Context, err error) {
+	var conflict *services.VersionConflictError
+	if errors.As(err, &conflict) {
+		c.Header("ETag", eventETag(conflict.Current))
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "event": conflict.Current})
+		return
+	}
+	c.JSON(eventErrorStatus(err), gin.H{"error": err.Error()})
+}
+
+// eventErrorStatus is the HTTP status of a failed event write other than a version conflict:
+// 403 without permission on the calendar, 404 for a calendar the user can't see, else 400
+func eventErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrCalendarForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrCalendarNotFound):
+		return http.StatusNotFound
+	}
+	return http.StatusBadRequest
+}
+
+// ExportEvent downloads an event as an .ics file, including the occurrences split from its series
+func (h *EventController) ExportEvent(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	events, err := h.eventService.ForUser(CurrentUserID(c)).GetEventSeries(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	writeCalendar(c, fmt.Sprintf("event-%d.ics", id), &ical.Calendar{Name: events[0].Title, Events: events})
+}
+
+// ExportCalendar downloads the user's events as an .ics file: the occurrences of a date range
+// (?start_date=&end_date=) or a day (?date=), or the whole calendar with its recurrence rules
+func (h *EventController) ExportCalendar(c *gin.Context) {
+	var queryReq dto.GetEventsQueryRequest
+	if err := queryReq.ProcessQueryRequest(c); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if (queryReq.StartDate == "") != (queryReq.EndDate == "") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "start_date and end_date must be provided together"})
+		return
+	}
+
+	// The file holds every matching event: series and their overrides must be written together
+	var events []models.Event
+	err := h.eventService.ForUser(CurrentUserID(c)).StreamEvents(&queryReq, func(batch []models.Event) error {
+		events = append(events, batch...)
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	writeCalendar(c, "calendar.ics", &ical.Calendar{
+		Name:     "Calendar " + CurrentUserEmail(c),
+		Events:   events,
+		Expanded: queryReq.Date != "" || queryReq.StartDate != "",
+	})
+}
+
+// maxImportSize limits the size of an uploaded .ics or CSV file
+const maxImportSize = 10 << 20
+
+// ImportCalendar imports the events of an .ics file uploaded as multipart/form-data (field "file").
+// Events are matched by their UID, so importing the same file again updates them instead of
+// creating duplicates. Responds with the result of every VEVENT.
+func (h *EventController) ImportCalendar(c *gin.Context) {
+	file, ok := openUpload(c, "an .ics file")
+	if !ok {
+		return
+	}
+	defer file.Close()
+
+	// Times without a time zone are read in the user's default zone
+	loc, err := models.LoadLocation(h.settingsService.DefaultTimeZone(CurrentUserEmail(c)))
+	if err != nil {
+		loc = time.UTC
+	}
+	events, err := ical.Decode(file, loc)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	report := h.eventService.ForUser(CurrentUserID(c)).ImportEvents(events, CurrentUserEmail(c))
+	c.JSON(http.StatusOK, report)
+}
+
+// ImportCSV creates events from the rows of a CSV file uploaded as multipart/form-data
+// (field "file"). The optional "mapping" field maps event fields to columns and sets
+// defaults; with dry_run=true rows are only validated. Responds with the result of every row.
+func (h *EventController) ImportCSV(c *gin.Context) {
+	file, ok := openUpload(c, "a CSV file")
+	if !ok {
+		return
+	}
+	defer file.Close()
+
+	var req dto.CSVImportRequest
+	if err := req.ProcessRequest(c, CurrentUserEmail(c)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	reader, err := eventcsv.NewReader(file, req.DelimiterRune())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	columns, err := req.ResolveColumns(reader.Header())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Each row goes through the same validation as a JSON create request
+	defaultTimeZone := h.settingsService.DefaultTimeZone(CurrentUserEmail(c))
+	var rows []services.ImportRow
+	for {
+		record, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		row := services.ImportRow{Line: record.Line}
+		eventReq, err := req.EventRequest(record, columns)
+		if err == nil {
+			row.Title = eventReq.Title
+			row.Event, err = eventReq.Prepare(defaultTimeZone)
+		}
+		row.Err = err
+		rows = append(rows, row)
+	}
+
+	report := h.eventService.ForUser(CurrentUserID(c)).ImportRows(rows, req.DryRun)
+	c.JSON(http.StatusOK, report)
+}
+
+// ExportCSV downloads the events of any GetEvents query as a CSV file. Rows are written
+// as they are read from the database, so large exports are not held in memory
+func (h *EventController) ExportCSV(c *gin.Context) {
+	var queryReq dto.GetEventsQueryRequest
+	if err := queryReq.ProcessQueryRequest(c); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Type", eventcsv.ContentType)
+	c.Header("Content-Disposition", `attachment; filename="calendar.csv"`)
+	c.Status(http.StatusOK)
+
+	writer, err := eventcsv.NewWriter(c.Writer)
+	if err == nil {
+		err = h.eventService.ForUser(CurrentUserID(c)).StreamEvents(&queryReq, func(batch []models.Event) error {
+			for i := range batch {
+				if err := writer.Write(&batch[i]); err != nil {
+					return err
+				}
+			}
+			if err := writer.Flush(); err != nil {
+				return err
+			}
+			c.Writer.Flush()
+			return nil
+		})
+	}
+	if err == nil {
+		err = writer.Flush()
+	}
+	// The status was already sent: a failure can only cut the file short
+	if err != nil {
+		log.Printf("Error exporting CSV for user %d: %v", CurrentUserID(c), err)
+	}
+}
+
+// openUpload opens the file uploaded in the multipart field "file", responding with an
+// error if it is missing or over maxImportSize
+func openUpload(c *gin.Context, description string) (multipart.File, bool) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize+1<<20)
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": description + ` is required in the multipart field "file"`})
+		return nil, false
+	}
+	if fileHeader.Size > maxImportSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "file is too large, the limit is 10 MB"})
+		return nil, false
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read file"})
+		return nil, false
+	}
+	return file, true
+}
+
+// writeCalendar responds with the calendar as a downloadable text/calendar file
+func writeCalendar(c *gin.Context, filename string, calendar *ical.Calendar) {
+	var buf bytes.Buffer
+	if _, err := calendar.WriteTo(&buf); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export calendar"})
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Data(http.StatusOK, ical.ContentType, buf.Bytes())
+}
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"calendar-backend/handlers/dto"
+	"calendar-backend/migrations"
+	"calendar-backend/models"
+	"calendar-backend/repositories"
+	"calendar-backend/search"
+	"calendar-backend/services"
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestEventService abre una base SQLite migrada con un usuario y devuelve su servicio de eventos
+func newTestEventService(t *testing.T) services.EventService {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	migrator, err := migrations.New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	engine, err := search.Setup(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := &models.User{Email: "ana@example.com", PasswordHash: "x"}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatal(err)
+	}
+	return services.NewEventService(repositories.NewEventRepository(db, engine), repositories.NewCalendarRepository(db), nil, nil).ForUser(user.ID)
+}
+
+func TestApplyMutationsWithClientIDs(t *testing.T) {
+	const (
+		planning = "0b5d3c1e-8f2a-4c6b-9d7e-1a2b3c4d5e6f"
+		lunch    = "7c9e6679-7425-40de-944b-e07fc1f90ae7"
+		unknown  = "f47ac10b-58cc-4372-a567-0e02b2c3d479"
+	)
+	created := `{"title": "Planning", "starts_at": "2030-07-01T09:00:00Z", "ends_at": "2030-07-01T10:00:00Z",
+		"email": "ana@example.com", "phone": "+5491122334455"}`
+
+	// El lote se aplica en orden: cada paso ve lo que escribieron los anteriores
+	tests := []struct {
+		name        string
+		mutation    dto.MutationRequest
+		wantStatus  int
+		wantTitle   string
+		wantVersion int
+	}{
+		{"create offline", dto.MutationRequest{Op: "create", ClientID: planning, Event: json.RawMessage(created)},
+			http.StatusCreated, "Planning", 1},
+		{"create sent again", dto.MutationRequest{Op: "Create", ClientID: planning, Event: json.RawMessage(created)},
+			http.StatusOK, "Planning", 1},
+		{"update by client_id", dto.MutationRequest{Op: "update", ClientID: planning, Version: 1, Event: json.RawMessage(`{"title": "Planning v2"}`)},
+			http.StatusOK, "Planning v2", 2},
+		{"update over a stale version", dto.MutationRequest{Op: "update", ClientID: planning, Version: 1, Event: json.RawMessage(`{"title": "Planning v3"}`)},
+			http.StatusConflict, "Planning v2", 2},
+		{"invalid op", dto.MutationRequest{Op: "move", ClientID: planning},
+			http.StatusBadRequest, "", 0},
+		{"invalid client_id", dto.MutationRequest{Op: "delete", ClientID: "planning"},
+			http.StatusBadRequest, "", 0},
+		{"create another", dto.MutationRequest{Op: "create", ClientID: lunch, Event: json.RawMessage(`{"title": "Lunch", "starts_at": "2030-07-01T13:00:00Z",
+			"email": "ana@example.com", "phone": "+5491122334455"}`)},
+			http.StatusCreated, "Lunch", 1},
+		{"delete by client_id", dto.MutationRequest{Op: "delete", ClientID: lunch, Version: 1},
+			http.StatusOK, "", 0},
+		{"delete again", dto.MutationRequest{Op: "delete", ClientID: lunch},
+			http.StatusNotFound, "", 0},
+		{"update an unknown client_id", dto.MutationRequest{Op: "update", ClientID: unknown, Event: json.RawMessage(`{"title": "Lost"}`)},
+			http.StatusNotFound, "", 0},
+	}
+
+	eventService := newTestEventService(t)
+	var planningID uint
+	for _, tt := range tests {
+		result := applyMutation(eventService, &tt.mutation, "UTC")
+		if result.Status != tt.wantStatus {
+			t.Fatalf("%s: status %d (%s), want %d", tt.name, result.Status, result.Error, tt.wantStatus)
+		}
+		if tt.wantTitle == "" {
+			if result.Event != nil {
+				t.Errorf("%s: returned event %+v", tt.name, result.Event)
+			}
+			continue
+		}
+		// Los conflictos devuelven el evento guardado, para que la app los resuelva
+		if result.Event == nil || result.Event.Title != tt.wantTitle || result.Event.Version != tt.wantVersion {
+			t.Fatalf("%s: event %+v, want %q at version %d", tt.name, result.Event, tt.wantTitle, tt.wantVersion)
+		}
+		if result.Event.ClientID == planning {
+			if planningID == 0 {
+				planningID = result.Event.ID
+			} else if result.Event.ID != planningID {
+				t.Errorf("%s: event %d, want the one created with the client_id (%d)", tt.name, result.Event.ID, planningID)
+			}
+		}
+	}
+
+	// El alta reenviada no duplicó el evento y la eliminación se aplicó
+	events, err := eventService.GetAllEvents()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].ID != planningID || events[0].Title != "Planning v2" {
+		t.Errorf("stored events %+v, want only Planning v2", events)
+	}
+	if _, err := eventService.GetEventByClientID(lunch); err == nil {
+		t.Error("the deleted event is still found by its client_id")
+	}
+}
@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"bytes"
+	"calendar-backend/handlers/dto"
+	"calendar-backend/ical"
+	"calendar-backend/services"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type FeedController struct {
+	feedService *services.FeedService
+	baseURL     string
+}
+
+// NewFeedController creates the controller; baseURL is the public URL of the API used in the
+// feed links, or empty to derive it from each request
+func NewFeedController(feedService *services.FeedService, baseURL string) *FeedController {
+	return &FeedController{feedService: feedService, baseURL: baseURL}
+}
+
+// CreateFeed creates a subscription feed for the authenticated user. The secret token is
+// only returned in this response
+func (h *FeedController) CreateFeed(c *gin.Context) {
+	var req dto.CreateFeedRequest
+	feed, err := req.ProcessRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	token, err := h.feedService.CreateFeed(CurrentUserID(c), feed)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create feed"})
+		return
+	}
+
+	url := h.feedURL(c, token)
+	c.JSON(http.StatusCreated, gin.H{
+		"message":    "Feed created successfully",
+		"feed":       feed,
+		"token":      token,
+		"url":        url,
+		"webcal_url": "webcal://" + url[strings.Index(url, "://")+3:],
+	})
+}
+
+// GetFeeds lists the feeds of the authenticated user, including revoked ones
+func (h *FeedController) GetFeeds(c *gin.Context) {
+	feeds, err := h.feedService.ListFeeds(CurrentUserID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get feeds"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"feeds": feeds, "count": len(feeds)})
+}
+
+// RevokeFeed revokes a feed: its URL stops working for every subscriber
+func (h *FeedController) RevokeFeed(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid feed ID"})
+		return
+	}
+
+	if err := h.feedService.RevokeFeed(CurrentUserID(c), uint(id)); err != nil {
+		if errors.Is(err, services.ErrFeedNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke feed"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Feed revoked successfully"})
+}
+
+// ServeFeed renders a feed (/feeds/{token}.ics) for calendar apps. It needs no access token:
+// the secret token in the URL identifies it. Subscribers sending the last ETag in
+// If-None-Match get a 304 while nothing changed
+func (h *FeedController) ServeFeed(c *gin.Context) {
+	feed, err := h.feedService.ResolveFeed(strings.TrimSuffix(c.Param("token"), ".ics"))
+	if err != nil {
+		if errors.Is(err, services.ErrFeedNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get feed"})
+		return
+	}
+
+	etag, err := h.feedService.FeedETag(feed)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get feed"})
+		return
+	}
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "private, no-cache")
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	events, err := h.feedService.FeedEvents(feed)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get feed"})
+		return
+	}
+
+	calendar := &ical.Calendar{Name: feed.Name, Events: events, RefreshInterval: services.FeedRefreshInterval}
+	var buf bytes.Buffer
+	if _, err := calendar.WriteTo(&buf); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export calendar"})
+		return
+	}
+	c.Data(http.StatusOK, ical.ContentType, buf.Bytes())
+}
+
+// feedURL builds the public https URL of a feed
+func (h *FeedController) feedURL(c *gin.Context, token string) string {
+	return publicBaseURL(c, h.baseURL) + "/feeds/" + token + ".ics"
+}
+
+// publicBaseURL returns the configured public URL of the API or, if empty, the one of the request
+func publicBaseURL(c *gin.Context, configured string) string {
+	if configured != "" {
+		return configured
+	}
+	scheme := "http"
+	if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + c.Request.Host
+}
+
+// etagMatches reports whether an If-None-Match header lists the ETag (or is "*")
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+	return false
+}
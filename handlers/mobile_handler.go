@@ -2,20 +2,29 @@ package handlers
 
 import (
 	"calendar-backend/models"
+	"calendar-backend/search"
+	"calendar-backend/services"
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
+)
+
+// Cantidad de cambios por respuesta de /api/mobile/sync
+const (
+	defaultSyncLimit = 500
+	maxSyncLimit     = 1000
 )
 
 type MobileHandler struct {
-	db *gorm.DB
+	eventService     services.EventService
+	deltaSyncService *services.DeltaSyncService
 }
 
-func NewMobileHandler(db *gorm.DB) *MobileHandler {
-	return &MobileHandler{db: db}
+func NewMobileHandler(eventService services.EventService, deltaSyncService *services.DeltaSyncService) *MobileHandler {
+	return &MobileHandler{eventService: eventService, deltaSyncService: deltaSyncService}
 }
 
 // GetEventsForDateRange returns events for a specific date range (mobile optimized)
@@ -28,18 +37,17 @@ func (h *MobileHandler) GetEventsForDateRange(c *gin.Context) {
 		return
 	}
 
-	start, err1 := time.Parse("2006-01-02", startDate)
-	end, err2 := time.Parse("2006-01-02", endDate)
+	_, err1 := time.Parse("2006-01-02", startDate)
+	_, err2 := time.Parse("2006-01-02", endDate)
 
 	if err1 != nil || err2 != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format. Use YYYY-MM-DD"})
 		return
 	}
 
-	var events []models.Event
-	if err := h.db.Where("date BETWEEN ? AND ?", start, end).
-		Order("date ASC, time ASC").
-		Find(&events).Error; err != nil {
+	// Recurring events come expanded into their occurrences
+	events, err := h.eventService.ForUser(CurrentUserID(c)).GetEventsForDateRange(startDate, endDate)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch events"})
 		return
 	}
@@ -61,11 +69,10 @@ func (h *MobileHandler) GetEventsForDateRange(c *gin.Context) {
 // GetTodayEvents returns events for today (mobile optimized)
 func (h *MobileHandler) GetTodayEvents(c *gin.Context) {
 	today := time.Now()
-	var events []models.Event
 
-	if err := h.db.Where("DATE(date) = DATE(?)", today).
-		Order("time ASC").
-		Find(&events).Error; err != nil {
+	// "Today" is evaluated in the time zone of each event
+	events, err := h.eventService.ForUser(CurrentUserID(c)).GetTodayEvents()
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch today's events"})
 		return
 	}
@@ -92,13 +99,8 @@ func (h *MobileHandler) GetUpcomingEvents(c *gin.Context) {
 		limit = parsedLimit
 	}
 
-	var events []models.Event
-	today := time.Now()
-
-	if err := h.db.Where("date >= ?", today).
-		Order("date ASC, time ASC").
-		Limit(limit).
-		Find(&events).Error; err != nil {
+	events, err := h.eventService.ForUser(CurrentUserID(c)).GetUpcomingEvents(limit)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch upcoming events"})
 		return
 	}
@@ -115,27 +117,41 @@ func (h *MobileHandler) GetUpcomingEvents(c *gin.Context) {
 	})
 }
 
-// SearchEvents searches events by title or description (mobile optimized)
+// SearchEvents searches the title, description, location and category of the events,
+// most relevant first (mobile optimized)
 func (h *MobileHandler) SearchEvents(c *gin.Context) {
 	query := c.Query("q")
 	if query == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Search query 'q' is required"})
 		return
 	}
+	limit, ok := searchLimit(c)
+	if !ok {
+		return
+	}
 
-	var events []models.Event
-	searchQuery := "%" + query + "%"
-
-	if err := h.db.Where("title LIKE ? OR description LIKE ?", searchQuery, searchQuery).
-		Order("date ASC").
-		Find(&events).Error; err != nil {
+	results, err := h.eventService.ForUser(CurrentUserID(c)).SearchEvents(query, limit)
+	if err != nil {
+		if errors.Is(err, search.ErrEmptyQuery) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search events"})
 		return
 	}
 
-	responses := make([]models.EventResponse, len(events))
-	for i, event := range events {
-		responses[i] = event.ToResponse()
+	type searchResponse struct {
+		models.EventResponse
+		Score      float64           `json:"score"`
+		Highlights map[string]string `json:"highlights"`
+	}
+	responses := make([]searchResponse, len(results))
+	for i, result := range results {
+		responses[i] = searchResponse{
+			EventResponse: result.Event.ToResponse(),
+			Score:         result.Score,
+			Highlights:    result.Highlights,
+		}
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -147,34 +163,38 @@ func (h *MobileHandler) SearchEvents(c *gin.Context) {
 
 // GetEventStats returns statistics for mobile dashboard
 func (h *MobileHandler) GetEventStats(c *gin.Context) {
-	today := time.Now()
-
-	var stats struct {
-		TotalEvents    int64 `json:"total_events"`
-		TodayEvents    int64 `json:"today_events"`
-		UpcomingEvents int64 `json:"upcoming_events"`
-		PastEvents     int64 `json:"past_events"`
-		HighPriority   int64 `json:"high_priority"`
-		WithReminders  int64 `json:"with_reminders"`
+	stats, err := h.eventService.ForUser(CurrentUserID(c)).GetEventStats()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch event stats"})
+		return
 	}
 
-	// Total events
-	h.db.Model(&models.Event{}).Count(&stats.TotalEvents)
-
-	// Today's events
-	h.db.Model(&models.Event{}).Where("DATE(date) = DATE(?)", today).Count(&stats.TodayEvents)
-
-	// Upcoming events
-	h.db.Model(&models.Event{}).Where("date > ?", today).Count(&stats.UpcomingEvents)
-
-	// Past events
-	h.db.Model(&models.Event{}).Where("date < ?", today).Count(&stats.PastEvents)
-
-	// High priority events
-	h.db.Model(&models.Event{}).Where("priority = ?", "high").Count(&stats.HighPriority)
+	c.JSON(http.StatusOK, stats)
+}
 
-	// Events with reminders
-	h.db.Model(&models.Event{}).Where("reminder_day = ? OR reminder_day_before = ?", true, true).Count(&stats.WithReminders)
+// Sync returns the events created, updated or deleted since the sync token. Without a token
+// it returns every event and the token to ask for the following changes.
+func (h *MobileHandler) Sync(c *gin.Context) {
+	limit := defaultSyncLimit
+	if value := c.Query("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 || n > maxSyncLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and " + strconv.Itoa(maxSyncLimit)})
+			return
+		}
+		limit = n
+	}
 
-	c.JSON(http.StatusOK, stats)
+	delta, err := h.deltaSyncService.Changes(CurrentUserID(c), c.Query("token"), limit)
+	switch {
+	case errors.Is(err, services.ErrInvalidSyncToken):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrResyncRequired):
+		// The app has to drop its events and sync again without a token
+		c.JSON(http.StatusGone, gin.H{"error": err.Error(), "resync_required": true})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch changes"})
+	default:
+		c.JSON(http.StatusOK, delta)
+	}
 }
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"calendar-backend/models"
+	"calendar-backend/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type NotificationController struct {
+	notificationService *services.NotificationService
+}
+
+func NewNotificationController(notificationService *services.NotificationService) *NotificationController {
+	return &NotificationController{notificationService: notificationService}
+}
+
+// GetDeliveries lists the reminder notifications of the authenticated user that failed on a
+// channel, newest first, with their attempts and last error. ?status=pending|succeeded|failed
+// filters them
+func (h *NotificationController) GetDeliveries(c *gin.Context) {
+	status := c.Query("status")
+	switch status {
+	case "", models.DeliveryPending, models.DeliverySucceeded, models.DeliveryFailed:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be pending, succeeded or failed"})
+		return
+	}
+
+	limit := defaultDeliveryLimit
+	if value := c.Query("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 || n > maxDeliveryLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and " + strconv.Itoa(maxDeliveryLimit)})
+			return
+		}
+		limit = n
+	}
+
+	deliveries, err := h.notificationService.Deliveries(CurrentUserID(c), status, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get notification deliveries"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"deliveries": deliveries, "count": len(deliveries)})
+}
+
+// RedriveDeliveries queues again every failed notification of the authenticated user
+func (h *NotificationController) RedriveDeliveries(c *gin.Context) {
+	redriven, err := h.notificationService.Redrive(CurrentUserID(c), 0)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to re-drive notifications"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Failed notifications re-queued", "redriven": redriven})
+}
+
+// RedriveDelivery queues again one failed notification of the authenticated user
+func (h *NotificationController) RedriveDelivery(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid delivery ID"})
+		return
+	}
+
+	redriven, err := h.notificationService.Redrive(CurrentUserID(c), uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to re-drive notification"})
+		return
+	}
+	if redriven == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Failed notification not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Notification re-queued", "redriven": redriven})
+}
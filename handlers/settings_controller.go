@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"calendar-backend/handlers/dto"
+	"calendar-backend/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SettingsController struct {
+	settingsService *services.SettingsService
+}
+
+func NewSettingsController(settingsService *services.SettingsService) *SettingsController {
+	return &SettingsController{settingsService: settingsService}
+}
+
+// GetSettings returns the preferences of the authenticated user
+func (h *SettingsController) GetSettings(c *gin.Context) {
+	settings, err := h.settingsService.GetSettings(CurrentUserEmail(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// UpdateSettings updates the default time zone of the authenticated user
+func (h *SettingsController) UpdateSettings(c *gin.Context) {
+	var req dto.UpdateSettingsRequest
+	if err := req.ProcessRequest(c); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	settings, err := h.settingsService.UpdateTimeZone(CurrentUserEmail(c), req.TimeZone)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
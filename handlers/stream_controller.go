@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"calendar-backend/services"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+)
+
+// sseRetry is the reconnection delay suggested to EventSource clients, in milliseconds
+const sseRetry = 5000
+
+// streamHeartbeat is the type of the WebSocket messages that keep an idle connection open
+const streamHeartbeat = "heartbeat"
+
+// streamFrame is a change stream message as sent over a WebSocket
+type streamFrame struct {
+	ID   string      `json:"id,omitempty"`
+	Type string      `json:"type"`
+	Data interface{} `json:"data,omitempty"`
+}
+
+type StreamController struct {
+	streamService  *services.ChangeStreamService
+	allowedOrigins []string
+}
+
+func NewStreamController(streamService *services.ChangeStreamService, allowedOrigins []string) *StreamController {
+	return &StreamController{streamService: streamService, allowedOrigins: allowedOrigins}
+}
+
+// Events streams the changes to the user's events as Server-Sent Events: "created" and
+// "updated" carry the event, "deleted" its tombstone and "resync" asks the app to sync
+// everything again. The id of the messages is a sync token, so a reconnecting EventSource
+// resumes where it left off (Last-Event-ID) without missing changes.
+func (h *StreamController) Events(c *gin.Context) {
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	// Proxies such as nginx must not buffer the stream
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	fmt.Fprintf(c.Writer, "retry: %d\n\n", sseRetry)
+	c.Writer.Flush()
+
+	err := h.streamService.Follow(c.Request.Context(), CurrentUserID(c), lastEventID(c), func(messages []services.StreamMessage) error {
+		for _, message := range messages {
+			if err := writeServerSentEvent(c.Writer, message); err != nil {
+				return err
+			}
+		}
+		c.Writer.Flush()
+		return nil
+	}, func() error {
+		// SSE comments keep the connection open through proxies without reaching the app
+		if _, err := io.WriteString(c.Writer, ": heartbeat\n\n"); err != nil {
+			return err
+		}
+		c.Writer.Flush()
+		return nil
+	})
+	if err != nil {
+		log.Printf("Change stream of user %d closed: %v", CurrentUserID(c), err)
+	}
+}
+
+// WebSocket streams the same messages as Events over a WebSocket, as JSON text messages
+// {"id", "type", "data"}, plus {"type": "heartbeat"} while there are no changes. Clients
+// resume with the last_event_id query parameter.
+func (h *StreamController) WebSocket(c *gin.Context) {
+	ownerID, from := CurrentUserID(c), lastEventID(c)
+
+	server := websocket.Server{
+		Handshake: func(_ *websocket.Config, req *http.Request) error {
+			return h.checkOrigin(req)
+		},
+		Handler: func(ws *websocket.Conn) {
+			defer ws.Close()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			// Messages from the client are ignored; reading detects when it disconnects
+			go func() {
+				defer cancel()
+				var discarded string
+				for websocket.Message.Receive(ws, &discarded) == nil {
+				}
+			}()
+
+			err := h.streamService.Follow(ctx, ownerID, from, func(messages []services.StreamMessage) error {
+				for _, message := range messages {
+					frame := streamFrame{ID: message.ID, Type: message.Type, Data: message.Data}
+					if err := websocket.JSON.Send(ws, frame); err != nil {
+						return err
+					}
+				}
+				return nil
+			}, func() error {
+				return websocket.JSON.Send(ws, streamFrame{Type: streamHeartbeat})
+			})
+			if err != nil {
+				log.Printf("Change stream of user %d closed: %v", ownerID, err)
+			}
+		},
+	}
+	server.ServeHTTP(c.Writer, c.Request)
+}
+
+// checkOrigin accepts the WebSocket connections of browsers whose Origin is in the CORS
+// allowlist, so other sites cannot open one. Native apps send no Origin.
+func (h *StreamController) checkOrigin(req *http.Request) error {
+	origin := req.Header.Get("Origin")
+	if origin == "" || originAllowed(h.allowedOrigins, origin) {
+		return nil
+	}
+	return fmt.Errorf("origin %q is not allowed", origin)
+}
+
+// lastEventID returns where a reconnecting client left off: the Last-Event-ID header sent by
+// EventSource, or the last_event_id query parameter
+func lastEventID(c *gin.Context) string {
+	if id := c.GetHeader("Last-Event-ID"); id != "" {
+		return id
+	}
+	return c.Query("last_event_id")
+}
+
+// writeServerSentEvent writes a message in the text/event-stream format
+func writeServerSentEvent(w io.Writer, message services.StreamMessage) error {
+	data, err := json.Marshal(message.Data)
+	if err != nil {
+		return err
+	}
+	if message.ID != "" {
+		if _, err := fmt.Fprintf(w, "id: %s\n", message.ID); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", message.Type, data)
+	return err
+}
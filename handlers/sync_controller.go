@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"calendar-backend/handlers/dto"
+	"calendar-backend/services"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Audit entries returned by default and at most
+const (
+	defaultAuditLimit = 50
+	maxAuditLimit     = 500
+)
+
+type SyncController struct {
+	syncService *services.SyncService
+}
+
+func NewSyncController(syncService *services.SyncService) *SyncController {
+	return &SyncController{syncService: syncService}
+}
+
+// ConnectAccount connects an external calendar of the authenticated user. Events are
+// mirrored both ways on the next periodic sync, or right away with SyncAccount
+func (h *SyncController) ConnectAccount(c *gin.Context) {
+	var req dto.CreateSyncAccountRequest
+	if err := req.ProcessRequest(c); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	account, err := h.syncService.ConnectAccount(CurrentUserID(c), req.Provider, req.CalendarID, req.Credentials)
+	if err != nil {
+		if errors.Is(err, services.ErrSyncProviderUnknown) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to connect account"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Account connected successfully", "account": account})
+}
+
+// GetAccounts lists the external calendars of the authenticated user with their sync state
+func (h *SyncController) GetAccounts(c *gin.Context) {
+	accounts, err := h.syncService.ListAccounts(CurrentUserID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get accounts"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"accounts": accounts, "count": len(accounts)})
+}
+
+// DisconnectAccount stops syncing an external calendar; already mirrored events are kept
+func (h *SyncController) DisconnectAccount(c *gin.Context) {
+	id, ok := h.accountID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.syncService.DisconnectAccount(CurrentUserID(c), id); err != nil {
+		h.accountError(c, err, "Failed to disconnect account")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Account disconnected successfully"})
+}
+
+// SyncAccount syncs an external calendar right away
+func (h *SyncController) SyncAccount(c *gin.Context) {
+	id, ok := h.accountID(c)
+	if !ok {
+		return
+	}
+
+	account, result, err := h.syncService.SyncNow(CurrentUserID(c), id)
+	if err != nil {
+		if account == nil {
+			h.accountError(c, err, "Failed to sync account")
+			return
+		}
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error(), "account": account, "result": result})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"account": account, "result": result})
+}
+
+// GetAudit lists the latest changes applied by the sync of an account, including the
+// conflicts and which side won them
+func (h *SyncController) GetAudit(c *gin.Context) {
+	id, ok := h.accountID(c)
+	if !ok {
+		return
+	}
+
+	limit := defaultAuditLimit
+	if value := c.Query("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 || n > maxAuditLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and " + strconv.Itoa(maxAuditLimit)})
+			return
+		}
+		limit = n
+	}
+
+	entries, err := h.syncService.Audit(CurrentUserID(c), id, limit)
+	if err != nil {
+		h.accountError(c, err, "Failed to get audit")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"audit": entries, "count": len(entries)})
+}
+
+func (h *SyncController) accountID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid account ID"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func (h *SyncController) accountError(c *gin.Context, err error, message string) {
+	if errors.Is(err, services.ErrSyncAccountNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+}
@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"calendar-backend/handlers/dto"
+	"calendar-backend/models"
+	"calendar-backend/services"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Deliveries returned by default and at most
+const (
+	defaultDeliveryLimit = 50
+	maxDeliveryLimit     = 200
+)
+
+type WebhookController struct {
+	webhookService *services.WebhookService
+}
+
+func NewWebhookController(webhookService *services.WebhookService) *WebhookController {
+	return &WebhookController{webhookService: webhookService}
+}
+
+// CreateWebhook registers a webhook of the authenticated user. The signing secret is only
+// returned in this response
+func (h *WebhookController) CreateWebhook(c *gin.Context) {
+	var req dto.CreateWebhookRequest
+	webhook, err := req.ProcessRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	secret, err := h.webhookService.CreateWebhook(CurrentUserID(c), webhook)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create webhook"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Webhook created successfully",
+		"webhook": webhook,
+		"secret":  secret,
+	})
+}
+
+// GetWebhooks lists the webhooks of the authenticated user, including disabled ones
+func (h *WebhookController) GetWebhooks(c *gin.Context) {
+	webhooks, err := h.webhookService.ListWebhooks(CurrentUserID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get webhooks"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"webhooks": webhooks, "count": len(webhooks)})
+}
+
+// TestWebhook sends a signed "ping" to the webhook right away and returns the delivery with
+// the receiver's response. Test deliveries are logged but not retried
+func (h *WebhookController) TestWebhook(c *gin.Context) {
+	id, ok := h.webhookID(c)
+	if !ok {
+		return
+	}
+
+	delivery, err := h.webhookService.TestWebhook(CurrentUserID(c), id)
+	if err != nil {
+		h.webhookError(c, err, "Failed to test webhook")
+		return
+	}
+
+	status := http.StatusOK
+	if delivery.Status != models.DeliverySucceeded {
+		status = http.StatusBadGateway
+	}
+	c.JSON(status, gin.H{"delivery": delivery})
+}
+
+// DisableWebhook stops sending notifications to a webhook, including pending retries
+func (h *WebhookController) DisableWebhook(c *gin.Context) {
+	h.setDisabled(c, true)
+}
+
+// EnableWebhook resumes sending notifications to a disabled webhook
+func (h *WebhookController) EnableWebhook(c *gin.Context) {
+	h.setDisabled(c, false)
+}
+
+func (h *WebhookController) setDisabled(c *gin.Context, disabled bool) {
+	id, ok := h.webhookID(c)
+	if !ok {
+		return
+	}
+
+	update, message := h.webhookService.EnableWebhook, "Webhook enabled successfully"
+	if disabled {
+		update, message = h.webhookService.DisableWebhook, "Webhook disabled successfully"
+	}
+	webhook, err := update(CurrentUserID(c), id)
+	if err != nil {
+		h.webhookError(c, err, "Failed to update webhook")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": message, "webhook": webhook})
+}
+
+// GetDeliveries lists the latest deliveries of a webhook, newest first, with the status,
+// attempts and last response of each one
+func (h *WebhookController) GetDeliveries(c *gin.Context) {
+	id, ok := h.webhookID(c)
+	if !ok {
+		return
+	}
+
+	limit := defaultDeliveryLimit
+	if value := c.Query("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 || n > maxDeliveryLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and " + strconv.Itoa(maxDeliveryLimit)})
+			return
+		}
+		limit = n
+	}
+
+	deliveries, err := h.webhookService.Deliveries(CurrentUserID(c), id, limit)
+	if err != nil {
+		h.webhookError(c, err, "Failed to get deliveries")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"deliveries": deliveries, "count": len(deliveries)})
+}
+
+func (h *WebhookController) webhookID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook ID"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func (h *WebhookController) webhookError(c *gin.Context, err error, message string) {
+	if errors.Is(err, services.ErrWebhookNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+}
@@ -0,0 +1,406 @@
+package ical
+
+import (
+	"calendar-backend/models"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNotCalendar indica que el archivo no contiene un VCALENDAR
+var ErrNotCalendar = errors.New("file is not an iCalendar (.ics) file")
+
+// Event es un VEVENT leído de un archivo .ics, con sus fechas ya resueltas
+type Event struct {
+	Line         int // Línea del BEGIN:VEVENT, para los reportes
+	UID          string
+	Summary      string
+	Description  string
+	Location     string
+	Categories   []string
+	Priority     int // 1 (máxima) a 9 (mínima); 0 = sin prioridad
+	Status       string
+	Start        time.Time   // Instante de inicio (UTC)
+	End          time.Time   // Instante de fin (UTC); cero si el VEVENT no lo indica
+	AllDay       bool        // DTSTART con VALUE=DATE
+	TimeZone     string      // Zona IANA en la que se interpretan las fechas del evento
+	RRule        string      // Regla de recurrencia, con UNTIL como fecha en la zona del evento
+	ExDates      []time.Time // Fechas de calendario excluidas de la serie
+	RecurrenceID *time.Time  // Fecha de calendario de la ocurrencia que reemplaza
+	Alarms       []Alarm
+	// Err es el motivo por el que el VEVENT no pudo leerse; el resto de los campos
+	// puede estar incompleto
+	Err error
+}
+
+// Alarm es un VALARM del evento
+type Alarm struct {
+	Action string        // DISPLAY, EMAIL, AUDIO
+	Before time.Duration // Anticipación respecto del inicio (negativa si es posterior)
+}
+
+// Decode lee los VEVENT de un archivo .ics. Las horas sin zona (flotantes) se
+// interpretan en la zona del calendario (X-WR-TIMEZONE) o, si no tiene, en defaultLoc.
+// Los VEVENT inválidos se devuelven con Err para poder informarlos uno por uno.
+func Decode(r io.Reader, defaultLoc *time.Location) ([]Event, error) {
+	lines, err := readLines(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNotCalendar, err)
+	}
+
+	calendarLoc := defaultLoc
+	found := false
+	for _, line := range lines {
+		switch {
+		case line.name == "BEGIN" && strings.EqualFold(line.value, "VCALENDAR"):
+			found = true
+		case line.name == "X-WR-TIMEZONE":
+			if loc, err := loadZone(line.value); err == nil {
+				calendarLoc = loc
+			}
+		}
+	}
+	if !found {
+		return nil, ErrNotCalendar
+	}
+
+	var events []Event
+	for i := 0; i < len(lines); i++ {
+		if lines[i].name != "BEGIN" || !strings.EqualFold(lines[i].value, "VEVENT") {
+			continue
+		}
+		end := componentEnd(lines, i)
+		events = append(events, decodeEvent(lines[i:end], calendarLoc))
+		i = end
+	}
+	return events, nil
+}
+
+// componentEnd devuelve el índice del END del componente que empieza en start
+// (o el final del archivo si no está cerrado)
+func componentEnd(lines []contentLine, start int) int {
+	depth := 0
+	for i := start; i < len(lines); i++ {
+		switch lines[i].name {
+		case "BEGIN":
+			depth++
+		case "END":
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return len(lines)
+}
+
+// decodeEvent lee un VEVENT (desde su BEGIN, sin el END)
+func decodeEvent(lines []contentLine, calendarLoc *time.Location) Event {
+	event := Event{Line: lines[0].num}
+
+	var (
+		props    = make(map[string]contentLine)
+		exDates  []contentLine
+		alarms   [][]contentLine
+		duration *contentLine
+	)
+	for i := 1; i < len(lines); i++ {
+		line := lines[i]
+		if line.name == "BEGIN" {
+			end := componentEnd(lines, i)
+			if strings.EqualFold(line.value, "VALARM") {
+				alarms = append(alarms, lines[i+1:end])
+			}
+			i = end
+			continue
+		}
+		switch line.name {
+		case "EXDATE":
+			exDates = append(exDates, line)
+		case "CATEGORIES":
+			for _, category := range splitList(line.value) {
+				if category = strings.TrimSpace(unescapeText(category)); category != "" {
+					event.Categories = append(event.Categories, category)
+				}
+			}
+		case "DURATION":
+			d := line
+			duration = &d
+		default:
+			if _, seen := props[line.name]; !seen {
+				props[line.name] = line
+			}
+		}
+	}
+
+	event.UID = strings.TrimSpace(props["UID"].value)
+	event.Summary = unescapeText(props["SUMMARY"].value)
+	event.Description = unescapeText(props["DESCRIPTION"].value)
+	event.Location = unescapeText(props["LOCATION"].value)
+	event.Status = strings.ToUpper(props["STATUS"].value)
+	if value := props["PRIORITY"].value; value != "" {
+		if p, err := strconv.Atoi(value); err == nil && p >= 0 && p <= 9 {
+			event.Priority = p
+		}
+	}
+
+	fail := func(err error) Event {
+		event.Err = err
+		return event
+	}
+	if event.UID == "" {
+		return fail(errors.New("missing UID"))
+	}
+	dtstart, ok := props["DTSTART"]
+	if !ok {
+		return fail(errors.New("missing DTSTART"))
+	}
+
+	// Zona del evento: la de DTSTART, o la del calendario para horas UTC, flotantes o fechas
+	loc := calendarLoc
+	if tzid := dtstart.param("TZID"); tzid != "" {
+		zone, err := loadZone(tzid)
+		if err != nil {
+			return fail(err)
+		}
+		loc = zone
+	}
+	event.TimeZone = loc.String()
+
+	start, allDay, err := parseDateTime(dtstart, loc)
+	if err != nil {
+		return fail(fmt.Errorf("invalid DTSTART: %w", err))
+	}
+	event.AllDay = allDay
+	event.Start = start
+	if allDay {
+		event.Start = models.StartOfDay(start, loc)
+	}
+
+	if dtend, ok := props["DTEND"]; ok {
+		end, endAllDay, err := parseDateTime(dtend, loc)
+		if err != nil {
+			return fail(fmt.Errorf("invalid DTEND: %w", err))
+		}
+		if endAllDay {
+			end = models.StartOfDay(end, loc)
+		}
+		event.End = end
+	} else if duration != nil {
+		d, err := parseDuration(duration.value)
+		if err != nil {
+			return fail(fmt.Errorf("invalid DURATION: %w", err))
+		}
+		event.End = event.Start.Add(d)
+	}
+	if !event.End.IsZero() && event.End.Before(event.Start) {
+		return fail(errors.New("DTEND is before DTSTART"))
+	}
+
+	if rrule, ok := props["RRULE"]; ok {
+		value, err := localizeUntil(rrule.value, loc)
+		if err != nil {
+			return fail(fmt.Errorf("invalid RRULE: %w", err))
+		}
+		event.RRule = value
+	}
+	for _, line := range exDates {
+		for _, value := range splitList(line.value) {
+			date, err := parseOccurrenceDate(contentLine{params: line.params, value: value}, loc)
+			if err != nil {
+				return fail(fmt.Errorf("invalid EXDATE: %w", err))
+			}
+			event.ExDates = append(event.ExDates, date)
+		}
+	}
+	if line, ok := props["RECURRENCE-ID"]; ok {
+		date, err := parseOccurrenceDate(line, loc)
+		if err != nil {
+			return fail(fmt.Errorf("invalid RECURRENCE-ID: %w", err))
+		}
+		event.RecurrenceID = &date
+	}
+
+	for _, alarm := range alarms {
+		if a, ok := decodeAlarm(alarm, &event); ok {
+			event.Alarms = append(event.Alarms, a)
+		}
+	}
+
+	return event
+}
+
+// decodeAlarm convierte el TRIGGER de un VALARM en anticipación respecto del inicio.
+// Los VALARM sin TRIGGER válido se ignoran.
+func decodeAlarm(lines []contentLine, event *Event) (Alarm, bool) {
+	alarm := Alarm{Action: "DISPLAY"}
+	var trigger *contentLine
+	for i := range lines {
+		switch lines[i].name {
+		case "ACTION":
+			alarm.Action = strings.ToUpper(lines[i].value)
+		case "TRIGGER":
+			trigger = &lines[i]
+		}
+	}
+	if trigger == nil {
+		return alarm, false
+	}
+
+	if strings.EqualFold(trigger.param("VALUE"), "DATE-TIME") {
+		at, _, err := parseDateTime(*trigger, time.UTC)
+		if err != nil {
+			return alarm, false
+		}
+		alarm.Before = event.Start.Sub(at)
+		return alarm, true
+	}
+
+	d, err := parseDuration(trigger.value)
+	if err != nil {
+		return alarm, false
+	}
+	alarm.Before = -d
+	if strings.EqualFold(trigger.param("RELATED"), "END") && !event.End.IsZero() {
+		alarm.Before -= event.End.Sub(event.Start)
+	}
+	return alarm, true
+}
+
+// parseDateTime lee un valor DATE o DATE-TIME. Las fechas se devuelven como medianoche UTC
+// (fecha de calendario); las horas con "Z" son UTC y las demás se interpretan en la zona
+// de su TZID o, si no tienen, en loc.
+func parseDateTime(line contentLine, loc *time.Location) (time.Time, bool, error) {
+	value := strings.TrimSpace(line.value)
+	if strings.EqualFold(line.param("VALUE"), "DATE") || len(value) == len(dateLayout) {
+		t, err := time.Parse(dateLayout, value)
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("invalid date %q", value)
+		}
+		return t, true, nil
+	}
+
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse(utcLayout, value)
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("invalid date-time %q", value)
+		}
+		return t, false, nil
+	}
+
+	if tzid := line.param("TZID"); tzid != "" {
+		zone, err := loadZone(tzid)
+		if err != nil {
+			return time.Time{}, false, err
+		}
+		loc = zone
+	}
+	t, err := time.ParseInLocation(localLayout, value, loc)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("invalid date-time %q", value)
+	}
+	return t.UTC(), false, nil
+}
+
+// parseOccurrenceDate lee la fecha de calendario (en loc) de un EXDATE o RECURRENCE-ID
+func parseOccurrenceDate(line contentLine, loc *time.Location) (time.Time, error) {
+	t, isDate, err := parseDateTime(line, loc)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if isDate {
+		return t, nil
+	}
+	return models.CalendarDate(t, loc), nil
+}
+
+// localizeUntil reescribe el UNTIL de un RRULE con hora como la fecha de calendario
+// (en loc) del último día incluido, que es como lo guarda el modelo
+func localizeUntil(rrule string, loc *time.Location) (string, error) {
+	parts := strings.Split(strings.TrimSpace(rrule), ";")
+	for i, part := range parts {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 || !strings.EqualFold(kv[0], "UNTIL") || len(kv[1]) == len(dateLayout) {
+			continue
+		}
+		until, _, err := parseDateTime(contentLine{value: kv[1]}, loc)
+		if err != nil {
+			return "", err
+		}
+		parts[i] = "UNTIL=" + models.CalendarDate(until, loc).Format(dateLayout)
+	}
+	return strings.Join(parts, ";"), nil
+}
+
+// parseDuration lee una duración RFC 5545 (ej: "PT15M", "-P1D", "P1W", "-PT1H30M")
+func parseDuration(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	sign := time.Duration(1)
+	switch {
+	case strings.HasPrefix(value, "-"):
+		sign = -1
+		value = value[1:]
+	case strings.HasPrefix(value, "+"):
+		value = value[1:]
+	}
+	if !strings.HasPrefix(value, "P") || len(value) < 3 {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+
+	var (
+		d      time.Duration
+		number string
+		inTime bool
+	)
+	units := map[bool]map[byte]time.Duration{
+		false: {'W': 7 * 24 * time.Hour, 'D': 24 * time.Hour},
+		true:  {'H': time.Hour, 'M': time.Minute, 'S': time.Second},
+	}
+	for i := 1; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case c >= '0' && c <= '9':
+			number += string(c)
+		case c == 'T' && !inTime && number == "":
+			inTime = true
+		default:
+			unit, ok := units[inTime][c]
+			if !ok || number == "" {
+				return 0, fmt.Errorf("invalid duration %q", value)
+			}
+			n, err := strconv.Atoi(number)
+			if err != nil {
+				return 0, fmt.Errorf("invalid duration %q", value)
+			}
+			d += time.Duration(n) * unit
+			number = ""
+		}
+	}
+	if number != "" {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+	return sign * d, nil
+}
+
+// loadZone carga la zona de un TZID. Acepta nombres IANA precedidos de un prefijo
+// (ej: "/mozilla.org/20050126_1/Europe/Berlin")
+func loadZone(tzid string) (*time.Location, error) {
+	tzid = strings.Trim(strings.TrimSpace(tzid), `"`)
+	if loc, err := models.LoadLocation(tzid); err == nil {
+		return loc, nil
+	}
+	parts := strings.Split(strings.Trim(tzid, "/"), "/")
+	for i := 1; i < len(parts); i++ {
+		if loc, err := models.LoadLocation(strings.Join(parts[i:], "/")); err == nil {
+			return loc, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown time zone %q", tzid)
+}
+
+func lineError(num int, err error) error {
+	return fmt.Errorf("line %d: %w", num, err)
+}
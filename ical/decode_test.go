@@ -0,0 +1,42 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDecodeFloatingTimesUseCalendarZone(t *testing.T) {
+	data := "BEGIN:VCALENDAR\r\nX-WR-TIMEZONE:America/New_York\r\nBEGIN:VEVENT\r\nUID:a@example.com\r\n" +
+		"DTSTART:20260701T090000\r\nDURATION:PT30M\r\nRRULE:FREQ=DAILY;UNTIL=20260705T130000Z\r\n" +
+		"SUMMARY:Long\r\n  folded\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"
+	decoded, err := Decode(strings.NewReader(data), time.UTC)
+	if err != nil || len(decoded) != 1 || decoded[0].Err != nil {
+		t.Fatalf("Decode: %+v, %v", decoded, err)
+	}
+	got := decoded[0]
+	if got.TimeZone != "America/New_York" || !got.Start.Equal(time.Date(2026, 7, 1, 13, 0, 0, 0, time.UTC)) || got.End.Sub(got.Start) != 30*time.Minute {
+		t.Errorf("decoded %s-%s in %s", got.Start, got.End, got.TimeZone)
+	}
+	// UNTIL con hora pasa a la fecha local del último día incluido
+	if got.RRule != "FREQ=DAILY;UNTIL=20260705" || got.Summary != "Long folded" {
+		t.Errorf("decoded rrule %q, summary %q", got.RRule, got.Summary)
+	}
+}
+
+func TestDecodeReportsInvalidEvents(t *testing.T) {
+	data := "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nSUMMARY:No UID\r\nDTSTART:20260701T090000Z\r\nEND:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\nUID:b@example.com\r\nDTSTART:20260701T090000Z\r\nDTEND:20260701T080000Z\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"
+	decoded, err := Decode(strings.NewReader(data), time.UTC)
+	if err != nil || len(decoded) != 2 {
+		t.Fatalf("Decode: %d events, %v", len(decoded), err)
+	}
+	for _, event := range decoded {
+		if event.Err == nil {
+			t.Errorf("VEVENT at line %d decoded without error", event.Line)
+		}
+	}
+	if _, err := Decode(strings.NewReader("not a calendar"), time.UTC); err == nil {
+		t.Error("decoded a file without VCALENDAR")
+	}
+}
@@ -0,0 +1,315 @@
+// Package ical serializa eventos en formato iCalendar (RFC 5545) para exportarlos
+// a Apple Calendar, Outlook o Google Calendar.
+package ical
+
+import (
+	"calendar-backend/models"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ContentType es el tipo MIME de los archivos .ics
+const ContentType = "text/calendar; charset=utf-8"
+
+const (
+	prodID      = "-//calendar-backend//Calendar API//ES"
+	uidDomain   = "calendar-backend"
+	utcLayout   = "20060102T150405Z"
+	localLayout = "20060102T150405"
+	dateLayout  = "20060102"
+)
+
+// Prioridades iCalendar (1 = máxima, 9 = mínima) de cada prioridad del evento
+var priorities = map[string]int{
+	"high":   1,
+	"medium": 5,
+	"low":    9,
+}
+
+// Calendar es un VCALENDAR con sus eventos
+type Calendar struct {
+	Name   string
+	Events []models.Event
+	// Expanded indica que Events son ocurrencias ya expandidas (ej: de un rango de fechas):
+	// se escriben sin RRULE, cada una con su propio UID
+	Expanded bool
+	// ObjectResource indica que el calendario es un recurso de una colección CalDAV
+	// (RFC 4791, 4.1): se omiten METHOD y el nombre del calendario
+	ObjectResource bool
+	// RefreshInterval sugiere a los clientes suscritos cada cuánto volver a descargarlo
+	RefreshInterval time.Duration
+}
+
+// WriteTo escribe el calendario en formato iCalendar
+func (c *Calendar) WriteTo(w io.Writer) (int64, error) {
+	lw := newLineWriter(w)
+
+	lw.prop("BEGIN", "", "VCALENDAR")
+	lw.prop("VERSION", "", "2.0")
+	lw.prop("PRODID", "", prodID)
+	lw.prop("CALSCALE", "", "GREGORIAN")
+	if !c.ObjectResource {
+		lw.prop("METHOD", "", "PUBLISH")
+		lw.text("X-WR-CALNAME", c.Name)
+	}
+	if minutes := int(c.RefreshInterval / time.Minute); minutes > 0 {
+		lw.prop("REFRESH-INTERVAL", "VALUE=DURATION", duration(minutes))
+		lw.prop("X-PUBLISHED-TTL", "", duration(minutes))
+	}
+
+	for _, tz := range c.timezones() {
+		writeTimezone(lw, tz.loc, tz.from, tz.to)
+	}
+
+	// Ocurrencias separadas de cada serie: se escriben con RECURRENCE-ID, así que
+	// su fecha no se incluye en el EXDATE de la serie
+	series := make(map[uint]*models.Event)
+	overridden := make(map[string]bool)
+	for i := range c.Events {
+		if c.Events[i].IsRecurring() {
+			series[c.Events[i].ID] = &c.Events[i]
+		}
+	}
+	for _, event := range c.Events {
+		if event.RecurrenceID != nil && event.OriginalDate != nil && series[*event.RecurrenceID] != nil {
+			overridden[overrideKey(*event.RecurrenceID, *event.OriginalDate)] = true
+		}
+	}
+
+	for i := range c.Events {
+		event := &c.Events[i]
+		var master *models.Event
+		if event.RecurrenceID != nil {
+			master = series[*event.RecurrenceID]
+		}
+		c.writeEvent(lw, event, master, overridden)
+	}
+
+	lw.prop("END", "", "VCALENDAR")
+	return lw.flush()
+}
+
+// writeEvent escribe el VEVENT de un evento. master es la serie de la que se separó
+// la ocurrencia, si está en el mismo calendario.
+func (c *Calendar) writeEvent(lw *lineWriter, event *models.Event, master *models.Event, overridden map[string]bool) {
+	loc := event.Zone()
+
+	lw.prop("BEGIN", "", "VEVENT")
+	lw.prop("UID", "", c.uid(event, master))
+	lw.prop("DTSTAMP", "", stamp(event).UTC().Format(utcLayout))
+	if !event.CreatedAt.IsZero() {
+		lw.prop("CREATED", "", event.CreatedAt.UTC().Format(utcLayout))
+	}
+	if !event.UpdatedAt.IsZero() {
+		lw.prop("LAST-MODIFIED", "", event.UpdatedAt.UTC().Format(utcLayout))
+	}
+
+	if event.IsAllDay {
+		lw.prop("DTSTART", "VALUE=DATE", models.CalendarDate(event.StartsAt, loc).Format(dateLayout))
+		lw.prop("DTEND", "VALUE=DATE", models.CalendarDate(event.EndsAt, loc).Format(dateLayout))
+	} else {
+		writeDateTime(lw, "DTSTART", event.StartsAt, loc)
+		writeDateTime(lw, "DTEND", event.EndsAt, loc)
+	}
+
+	if !c.Expanded && event.IsRecurring() {
+		lw.prop("RRULE", "", rrule(event, loc))
+		for _, date := range event.ExDateList() {
+			if !overridden[overrideKey(event.ID, date)] {
+				writeOccurrenceDate(lw, "EXDATE", event, date)
+			}
+		}
+	}
+	if !c.Expanded && master != nil && event.OriginalDate != nil {
+		writeOccurrenceDate(lw, "RECURRENCE-ID", master, *event.OriginalDate)
+	}
+
+	lw.text("SUMMARY", event.Title)
+	lw.text("DESCRIPTION", event.Description)
+	lw.text("LOCATION", event.Location)
+	lw.text("CATEGORIES", event.Category)
+	if priority, ok := priorities[event.Priority]; ok {
+		lw.prop("PRIORITY", "", fmt.Sprint(priority))
+	}
+
+	for _, reminder := range alarms(event) {
+		lw.prop("BEGIN", "", "VALARM")
+		lw.prop("ACTION", "", "DISPLAY")
+		lw.text("DESCRIPTION", event.Title)
+		lw.prop("TRIGGER", "", trigger(reminder.OffsetMinutes))
+		lw.prop("END", "", "VALARM")
+	}
+
+	lw.prop("END", "", "VEVENT")
+}
+
+// uid identifica el evento entre exportaciones: las ocurrencias separadas de una serie
+// comparten el UID de la serie (y se distinguen por RECURRENCE-ID); las ocurrencias
+// expandidas o sin su serie en el calendario llevan la fecha en el UID
+func (c *Calendar) uid(event *models.Event, master *models.Event) string {
+	switch {
+	case event.RecurrenceID != nil && event.OriginalDate != nil:
+		if master != nil && !c.Expanded {
+			return SeriesUID(master)
+		}
+		return occurrenceUID(*event.RecurrenceID, *event.OriginalDate)
+	case c.Expanded && event.IsRecurring():
+		return occurrenceUID(event.ID, models.CalendarDate(event.StartsAt, event.Zone()))
+	}
+	return SeriesUID(event)
+}
+
+// seriesUID devuelve el UID de un evento o serie: el original si se importó de un .ics
+func SeriesUID(event *models.Event) string {
+	if event.ExternalUID != "" {
+		return event.ExternalUID
+	}
+	return EventUID(event.ID)
+}
+
+// EventUID devuelve el UID iCalendar de un evento
+func EventUID(id uint) string {
+	return fmt.Sprintf("event-%d@%s", id, uidDomain)
+}
+
+// ParseEventUID devuelve el ID del evento de un UID generado por EventUID
+func ParseEventUID(uid string) (uint, bool) {
+	var id uint
+	if _, err := fmt.Sscanf(uid, "event-%d@"+uidDomain, &id); err != nil || EventUID(id) != uid {
+		return 0, false
+	}
+	return id, true
+}
+
+func occurrenceUID(seriesID uint, date time.Time) string {
+	return fmt.Sprintf("event-%d-%s@%s", seriesID, date.Format(dateLayout), uidDomain)
+}
+
+func overrideKey(seriesID uint, date time.Time) string {
+	return fmt.Sprintf("%d|%s", seriesID, date.Format(dateLayout))
+}
+
+// stamp devuelve la última modificación del evento; se usa como DTSTAMP para que
+// exportar dos veces los mismos datos produzca el mismo archivo
+func stamp(event *models.Event) time.Time {
+	if !event.UpdatedAt.IsZero() {
+		return event.UpdatedAt
+	}
+	if !event.CreatedAt.IsZero() {
+		return event.CreatedAt
+	}
+	return event.StartsAt
+}
+
+// writeDateTime escribe un DATE-TIME en UTC o, si el evento tiene zona, en hora local con TZID
+func writeDateTime(lw *lineWriter, name string, t time.Time, loc *time.Location) {
+	if loc == time.UTC {
+		lw.prop(name, "", t.UTC().Format(utcLayout))
+		return
+	}
+	lw.prop(name, "TZID="+loc.String(), t.In(loc).Format(localLayout))
+}
+
+// writeOccurrenceDate escribe EXDATE o RECURRENCE-ID: el inicio original de la ocurrencia
+// de series en la fecha date
+func writeOccurrenceDate(lw *lineWriter, name string, series *models.Event, date time.Time) {
+	loc := series.Zone()
+	if series.IsAllDay {
+		lw.prop(name, "VALUE=DATE", date.Format(dateLayout))
+		return
+	}
+	clock := series.StartsAt.In(loc).Format("15:04")
+	writeDateTime(lw, name, models.ComposeInstant(date, clock, loc), loc)
+}
+
+// rrule devuelve el RRULE del evento. UNTIL se guarda como fecha local inclusiva; para
+// eventos con hora el RFC exige un DATE-TIME UTC, así que se usa el fin de ese día.
+func rrule(event *models.Event, loc *time.Location) string {
+	rule, err := models.ParseRRule(event.RRule)
+	if err != nil {
+		return event.RRule
+	}
+	value := rule.String()
+	if rule.Until != nil && !event.IsAllDay {
+		until := models.StartOfDay(rule.Until.AddDate(0, 0, 1), loc).Add(-time.Second)
+		value = strings.Replace(value, "UNTIL="+rule.Until.Format(dateLayout), "UNTIL="+until.Format(utcLayout), 1)
+	}
+	return value
+}
+
+// alarms devuelve los recordatorios del evento; si no se cargaron, los deriva de los flags legacy
+func alarms(event *models.Event) []models.Reminder {
+	if len(event.Reminders) > 0 {
+		return event.Reminders
+	}
+	return models.LegacyReminders(event.ReminderDay, event.ReminderDayBefore, event.IsAllDay)
+}
+
+// trigger formatea la anticipación como duración negativa (ej: "-PT15M", "-P1D")
+func trigger(minutes int) string {
+	if minutes == 0 {
+		return "PT0M"
+	}
+	return "-" + duration(minutes)
+}
+
+// duration formatea minutos como duración iCalendar en la unidad más grande exacta
+func duration(minutes int) string {
+	switch {
+	case minutes%(7*24*60) == 0:
+		return fmt.Sprintf("P%dW", minutes/(7*24*60))
+	case minutes%(24*60) == 0:
+		return fmt.Sprintf("P%dD", minutes/(24*60))
+	case minutes%60 == 0:
+		return fmt.Sprintf("PT%dH", minutes/60)
+	}
+	return fmt.Sprintf("PT%dM", minutes)
+}
+
+// zoneRange es una zona usada por el calendario y el período que cubren sus eventos
+type zoneRange struct {
+	loc      *time.Location
+	from, to time.Time
+}
+
+// timezones devuelve las zonas (salvo UTC) de los eventos con hora, ordenadas por nombre.
+// Las series recurrentes se cubren hasta un año después de hoy.
+func (c *Calendar) timezones() []zoneRange {
+	zones := make(map[string]*zoneRange)
+	for i := range c.Events {
+		event := &c.Events[i]
+		loc := event.Zone()
+		if event.IsAllDay || loc == time.UTC {
+			continue
+		}
+
+		to := event.EndsAt
+		if !c.Expanded && event.IsRecurring() {
+			if horizon := time.Now().AddDate(1, 0, 0); horizon.After(to) {
+				to = horizon
+			}
+		}
+
+		zone, ok := zones[loc.String()]
+		if !ok {
+			zones[loc.String()] = &zoneRange{loc: loc, from: event.StartsAt, to: to}
+			continue
+		}
+		if event.StartsAt.Before(zone.from) {
+			zone.from = event.StartsAt
+		}
+		if to.After(zone.to) {
+			zone.to = to
+		}
+	}
+
+	list := make([]zoneRange, 0, len(zones))
+	for _, zone := range zones {
+		list = append(list, *zone)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].loc.String() < list[j].loc.String() })
+	return list
+}
@@ -0,0 +1,157 @@
+package ical
+
+import (
+	"bytes"
+	"calendar-backend/models"
+	"strings"
+	"testing"
+	"time"
+)
+
+// roundTrip exporta los eventos y vuelve a leer el archivo
+func roundTrip(t *testing.T, events ...models.Event) (string, []Event) {
+	t.Helper()
+	var buf bytes.Buffer
+	calendar := &Calendar{Name: "Test", Events: events}
+	if _, err := calendar.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo: %v", err)
+	}
+	decoded, err := Decode(bytes.NewReader(buf.Bytes()), time.UTC)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	for _, event := range decoded {
+		if event.Err != nil {
+			t.Fatalf("VEVENT at line %d: %v", event.Line, event.Err)
+		}
+	}
+	return buf.String(), decoded
+}
+
+// testEvent arma un evento de una hora que empieza a la hora local dada en su zona
+func testEvent(id uint, title string, start time.Time, rrule string) models.Event {
+	event := models.Event{
+		ID:       id,
+		Title:    title,
+		StartsAt: start.UTC(),
+		EndsAt:   start.Add(time.Hour).UTC(),
+		TimeZone: start.Location().String(),
+		RRule:    rrule,
+	}
+	event.SyncLegacyFields()
+	return event
+}
+
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("load location %s: %v", name, err)
+	}
+	return loc
+}
+
+func TestRoundTripSingleEvents(t *testing.T) {
+	madrid := mustLoadLocation(t, "Europe/Madrid")
+	tests := []struct {
+		name     string
+		event    models.Event
+		contains string
+	}{
+		{"utc", testEvent(1, "Call", time.Date(2026, 7, 1, 15, 0, 0, 0, time.UTC), ""), "DTSTART:20260701T150000Z"},
+		{"tzid", testEvent(1, "Meeting", time.Date(2026, 7, 1, 10, 0, 0, 0, madrid), ""), "DTSTART;TZID=Europe/Madrid:20260701T100000"},
+		{"tzid in winter", testEvent(1, "Meeting", time.Date(2026, 1, 15, 10, 0, 0, 0, madrid), ""), "DTSTART;TZID=Europe/Madrid:20260115T100000"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, decoded := roundTrip(t, tt.event)
+			if !strings.Contains(data, tt.contains+"\r\n") {
+				t.Errorf("export does not contain %q:\n%s", tt.contains, data)
+			}
+			if tt.event.TimeZone != "UTC" && !strings.Contains(data, "BEGIN:VTIMEZONE\r\nTZID:"+tt.event.TimeZone+"\r\n") {
+				t.Errorf("export does not define the VTIMEZONE of %s", tt.event.TimeZone)
+			}
+			if len(decoded) != 1 {
+				t.Fatalf("decoded %d events, want 1", len(decoded))
+			}
+			got := decoded[0]
+			if got.UID != SeriesUID(&tt.event) || got.Summary != tt.event.Title || got.AllDay {
+				t.Errorf("decoded UID %q, summary %q, all day %v", got.UID, got.Summary, got.AllDay)
+			}
+			if got.TimeZone != tt.event.TimeZone || !got.Start.Equal(tt.event.StartsAt) || !got.End.Equal(tt.event.EndsAt) {
+				t.Errorf("decoded %s-%s in %s, want %s-%s in %s", got.Start, got.End, got.TimeZone, tt.event.StartsAt, tt.event.EndsAt, tt.event.TimeZone)
+			}
+		})
+	}
+}
+
+func TestRoundTripAllDayEvent(t *testing.T) {
+	madrid := mustLoadLocation(t, "Europe/Madrid")
+	holiday := testEvent(1, "Holiday", time.Date(2026, 12, 25, 0, 0, 0, 0, madrid), "FREQ=YEARLY")
+	holiday.IsAllDay = true
+	holiday.EndsAt = time.Date(2026, 12, 26, 0, 0, 0, 0, madrid).UTC()
+
+	data, decoded := roundTrip(t, holiday)
+	for _, line := range []string{"DTSTART;VALUE=DATE:20261225", "DTEND;VALUE=DATE:20261226", "RRULE:FREQ=YEARLY"} {
+		if !strings.Contains(data, line+"\r\n") {
+			t.Errorf("export does not contain %q", line)
+		}
+	}
+	// Las fechas no tienen zona: se leen en la del calendario (UTC en el test)
+	if len(decoded) != 1 || !decoded[0].AllDay || decoded[0].RRule != "FREQ=YEARLY" {
+		t.Fatalf("decoded %+v, want one yearly all-day event", decoded)
+	}
+	if start, end := decoded[0].Start.Format("2006-01-02"), decoded[0].End.Format("2006-01-02"); start != "2026-12-25" || end != "2026-12-26" {
+		t.Errorf("decoded days %s to %s, want 2026-12-25 to 2026-12-26", start, end)
+	}
+}
+
+func TestRoundTripSeriesWithOverride(t *testing.T) {
+	madrid := mustLoadLocation(t, "Europe/Madrid")
+	series := testEvent(1, "Standup", time.Date(2026, 3, 2, 9, 0, 0, 0, madrid), "FREQ=WEEKLY;BYDAY=MO,WE;UNTIL=20260415")
+	series.ExDates = "2026-03-04,2026-03-09"
+	override := testEvent(2, "Standup (moved)", time.Date(2026, 3, 9, 11, 0, 0, 0, madrid), "")
+	override.RecurrenceID = &series.ID
+	originalDate := time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC)
+	override.OriginalDate = &originalDate
+
+	_, decoded := roundTrip(t, series, override)
+	if len(decoded) != 2 {
+		t.Fatalf("decoded %d events, want the series and its override", len(decoded))
+	}
+	master, moved := decoded[0], decoded[1]
+	if master.RRule != series.RRule {
+		t.Errorf("RRULE %q, want %q", master.RRule, series.RRule)
+	}
+	// La fecha separada se exporta como RECURRENCE-ID, no como EXDATE
+	if len(master.ExDates) != 1 || master.ExDates[0].Format("2006-01-02") != "2026-03-04" {
+		t.Errorf("EXDATE %v, want only 2026-03-04", master.ExDates)
+	}
+	if moved.UID != master.UID || moved.RecurrenceID == nil || !moved.RecurrenceID.Equal(originalDate) {
+		t.Errorf("override UID %q, RECURRENCE-ID %v, want %q on %s", moved.UID, moved.RecurrenceID, master.UID, originalDate)
+	}
+	if !moved.Start.Equal(override.StartsAt) || moved.RRule != "" {
+		t.Errorf("override starts %s with rrule %q, want %s without rrule", moved.Start, moved.RRule, override.StartsAt)
+	}
+}
+
+func TestRoundTripFoldsAndEscapesLongText(t *testing.T) {
+	event := testEvent(1, "Planificación; revisión, cierre", time.Date(2026, 7, 1, 15, 0, 0, 0, time.UTC), "")
+	event.Description = strings.Repeat("Reunión trimestral con el equipo de diseño; ", 6) + "\nsegunda línea, con comas"
+
+	data, decoded := roundTrip(t, event)
+	if !strings.Contains(data, "\r\n ") {
+		t.Error("the long description was not folded")
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(data, "\r\n"), "\r\n") {
+		if len(line) > maxLineOctets {
+			t.Errorf("line of %d octets: %q", len(line), line)
+		}
+		if !strings.HasPrefix(line, " ") && strings.ContainsRune(line, '\ufffd') {
+			t.Errorf("fold split a UTF-8 character: %q", line)
+		}
+	}
+	if len(decoded) != 1 || decoded[0].Summary != event.Title || decoded[0].Description != event.Description {
+		t.Errorf("decoded %+v, want the original summary and description", decoded)
+	}
+}
@@ -0,0 +1,152 @@
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strings"
+)
+
+// maxLineBytes limita el largo de una línea física al leer un archivo
+const maxLineBytes = 1 << 20
+
+// contentLine es una línea de contenido ya desplegada: NAME;PARAM=valor:VALOR
+type contentLine struct {
+	num    int // Número de la primera línea física
+	name   string
+	params map[string]string
+	value  string
+}
+
+// param devuelve el valor de un parámetro, sin comillas
+func (cl contentLine) param(name string) string {
+	return cl.params[name]
+}
+
+// readLines lee las líneas de contenido uniendo las continuaciones (RFC 5545, 3.1)
+func readLines(r io.Reader) ([]contentLine, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLineBytes)
+
+	var (
+		lines   []contentLine
+		current strings.Builder
+		start   int
+	)
+	flush := func() error {
+		if current.Len() == 0 {
+			return nil
+		}
+		line, err := parseContentLine(current.String())
+		if err != nil {
+			return lineError(start, err)
+		}
+		line.num = start
+		lines = append(lines, line)
+		current.Reset()
+		return nil
+	}
+
+	num := 0
+	for scanner.Scan() {
+		num++
+		text := strings.TrimSuffix(scanner.Text(), "\r")
+		if num == 1 {
+			text = strings.TrimPrefix(text, "\ufeff")
+		}
+		if strings.HasPrefix(text, " ") || strings.HasPrefix(text, "\t") {
+			current.WriteString(text[1:])
+			continue
+		}
+		if err := flush(); err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		current.WriteString(text)
+		start = num
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return lines, nil
+}
+
+// parseContentLine separa nombre, parámetros y valor; los valores de parámetro
+// entre comillas pueden contener ":" y ";"
+func parseContentLine(s string) (contentLine, error) {
+	line := contentLine{params: make(map[string]string)}
+
+	i := strings.IndexAny(s, ";:")
+	if i <= 0 {
+		return line, errors.New("invalid content line")
+	}
+	line.name = strings.ToUpper(s[:i])
+
+	for s[i] == ';' {
+		s = s[i+1:]
+		eq := strings.IndexByte(s, '=')
+		if eq <= 0 {
+			return line, errors.New("invalid parameter in " + line.name)
+		}
+		key := strings.ToUpper(s[:eq])
+		s = s[eq+1:]
+
+		var value string
+		if strings.HasPrefix(s, `"`) {
+			end := strings.IndexByte(s[1:], '"')
+			if end < 0 {
+				return line, errors.New("unterminated quoted parameter in " + line.name)
+			}
+			value = s[1 : end+1]
+			s = s[end+2:]
+			i = 0
+			if s == "" || (s[0] != ';' && s[0] != ':') {
+				return line, errors.New("invalid parameter in " + line.name)
+			}
+		} else {
+			i = strings.IndexAny(s, ";:")
+			if i < 0 {
+				return line, errors.New("missing value in " + line.name)
+			}
+			value = s[:i]
+		}
+		line.params[key] = value
+	}
+
+	line.value = s[i+1:]
+	return line, nil
+}
+
+var textUnescaper = strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n")
+
+// unescapeText revierte el escapado de un valor TEXT
+func unescapeText(value string) string {
+	return textUnescaper.Replace(value)
+}
+
+// splitList separa un valor de lista por las comas no escapadas
+func splitList(value string) []string {
+	var (
+		items   []string
+		current strings.Builder
+	)
+	for i := 0; i < len(value); i++ {
+		switch {
+		case value[i] == '\\' && i+1 < len(value):
+			current.WriteByte(value[i])
+			current.WriteByte(value[i+1])
+			i++
+		case value[i] == ',':
+			items = append(items, current.String())
+			current.Reset()
+		default:
+			current.WriteByte(value[i])
+		}
+	}
+	return append(items, current.String())
+}
@@ -0,0 +1,111 @@
+package ical
+
+import (
+	"fmt"
+	"time"
+)
+
+// maxTimezoneYears limita cuántos años de transiciones se escriben por zona
+const maxTimezoneYears = 30
+
+// transition es un cambio de desfase de una zona (ej: inicio del horario de verano)
+type transition struct {
+	at         time.Time // Instante UTC del cambio
+	offsetFrom int       // Desfase en segundos antes del cambio
+	offsetTo   int       // Desfase en segundos después del cambio
+	name       string    // Abreviatura después del cambio (ej: "CEST")
+	dst        bool      // Si después del cambio rige el horario de verano
+}
+
+// writeTimezone escribe el VTIMEZONE de loc con sus transiciones entre from y to,
+// generadas a partir de la base de zonas de Go
+func writeTimezone(lw *lineWriter, loc *time.Location, from, to time.Time) {
+	if to.Sub(from) > maxTimezoneYears*365*24*time.Hour {
+		from = to.AddDate(-maxTimezoneYears, 0, 0)
+	}
+	from = time.Date(from.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+	to = time.Date(to.Year()+1, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	lw.prop("BEGIN", "", "VTIMEZONE")
+	lw.prop("TZID", "", loc.String())
+
+	// Observancia vigente al comienzo del rango
+	name, offset := from.In(loc).Zone()
+	writeObservance(lw, transition{
+		at:         time.Date(1970, time.January, 1, 0, 0, 0, 0, time.UTC).Add(-time.Duration(offset) * time.Second),
+		offsetFrom: offset,
+		offsetTo:   offset,
+		name:       name,
+		dst:        from.In(loc).IsDST(),
+	})
+	for _, t := range transitions(loc, from, to) {
+		writeObservance(lw, t)
+	}
+
+	lw.prop("END", "", "VTIMEZONE")
+}
+
+func writeObservance(lw *lineWriter, t transition) {
+	kind := "STANDARD"
+	if t.dst {
+		kind = "DAYLIGHT"
+	}
+	lw.prop("BEGIN", "", kind)
+	// DTSTART es la hora local del cambio, expresada con el desfase anterior
+	lw.prop("DTSTART", "", t.at.Add(time.Duration(t.offsetFrom)*time.Second).Format(localLayout))
+	lw.prop("TZOFFSETFROM", "", formatOffset(t.offsetFrom))
+	lw.prop("TZOFFSETTO", "", formatOffset(t.offsetTo))
+	lw.text("TZNAME", t.name)
+	lw.prop("END", "", kind)
+}
+
+// transitions busca los cambios de desfase de loc entre from y to, recorriendo
+// el rango de a un día y refinando cada cambio por bisección hasta el segundo
+func transitions(loc *time.Location, from, to time.Time) []transition {
+	var found []transition
+	_, prevOffset := from.In(loc).Zone()
+	prev := from
+	for t := from.Add(24 * time.Hour); !t.After(to); t = t.Add(24 * time.Hour) {
+		_, offset := t.In(loc).Zone()
+		if offset != prevOffset {
+			at := bisectTransition(loc, prev, t, prevOffset)
+			name, _ := at.In(loc).Zone()
+			found = append(found, transition{
+				at:         at,
+				offsetFrom: prevOffset,
+				offsetTo:   offset,
+				name:       name,
+				dst:        at.In(loc).IsDST(),
+			})
+			prevOffset = offset
+		}
+		prev = t
+	}
+	return found
+}
+
+// bisectTransition devuelve el primer segundo de (lo, hi] cuyo desfase difiere de offset
+func bisectTransition(loc *time.Location, lo, hi time.Time, offset int) time.Time {
+	for hi.Sub(lo) > time.Second {
+		mid := lo.Add(hi.Sub(lo) / 2).Truncate(time.Second)
+		if _, o := mid.In(loc).Zone(); o == offset {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return hi
+}
+
+// formatOffset formatea un desfase en segundos como "+HHMM" (o "+HHMMSS" si tiene segundos)
+func formatOffset(seconds int) string {
+	sign := "+"
+	if seconds < 0 {
+		sign = "-"
+		seconds = -seconds
+	}
+	if seconds%60 != 0 {
+		return fmt.Sprintf("%s%02d%02d%02d", sign, seconds/3600, seconds/60%60, seconds%60)
+	}
+	return fmt.Sprintf("%s%02d%02d", sign, seconds/3600, seconds/60%60)
+}
@@ -0,0 +1,77 @@
+package ical
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxLineOctets es el largo máximo de una línea antes de plegarla (RFC 5545, 3.1)
+const maxLineOctets = 75
+
+// lineWriter escribe líneas de contenido iCalendar con CRLF y plegado a 75 octetos
+type lineWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+func newLineWriter(w io.Writer) *lineWriter {
+	return &lineWriter{w: bufio.NewWriter(w)}
+}
+
+// line escribe una línea ya armada, plegándola sin cortar caracteres UTF-8
+func (lw *lineWriter) line(s string) {
+	limit := maxLineOctets
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		lw.write(s[:cut] + "\r\n ")
+		s = s[cut:]
+		// Las continuaciones empiezan con un espacio, que cuenta para el largo
+		limit = maxLineOctets - 1
+	}
+	lw.write(s + "\r\n")
+}
+
+// prop escribe una propiedad; params va sin el ";" inicial (ej: "TZID=Europe/Madrid")
+func (lw *lineWriter) prop(name, params, value string) {
+	if params != "" {
+		name += ";" + params
+	}
+	lw.line(name + ":" + value)
+}
+
+// text escribe una propiedad de texto escapando su valor; los valores vacíos se omiten
+func (lw *lineWriter) text(name, value string) {
+	if value == "" {
+		return
+	}
+	lw.prop(name, "", escapeText(value))
+}
+
+func (lw *lineWriter) write(s string) {
+	if lw.err != nil {
+		return
+	}
+	n, err := lw.w.WriteString(s)
+	lw.n += int64(n)
+	lw.err = err
+}
+
+func (lw *lineWriter) flush() (int64, error) {
+	if lw.err == nil {
+		lw.err = lw.w.Flush()
+	}
+	return lw.n, lw.err
+}
+
+var textEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`)
+
+// escapeText escapa un valor TEXT (RFC 5545, 3.3.11)
+func escapeText(value string) string {
+	return textEscaper.Replace(value)
+}
@@ -4,8 +4,12 @@ import (
 	"log"
 	"os"
 
+	"calendar-backend/calsync"
+	"calendar-backend/config"
 	"calendar-backend/database"
+	"calendar-backend/eventbus"
 	"calendar-backend/handlers"
+	"calendar-backend/notifications"
 	"calendar-backend/repositories"
 	"calendar-backend/routes"
 	"calendar-backend/services"
@@ -20,6 +24,16 @@ func main() {
 		log.Println("No .env file found, using system environment variables")
 	}
 
+	// "calendar-backend migrate ...", "calendar-backend backup ..." and "calendar-backend users ..."
+	// manage the schema migrations, the backups and the accounts instead of starting the server
+	commands := map[string]func(args []string) error{"migrate": runMigrate, "backup": runBackup, "users": runUsers}
+	if run, ok := commands[firstArg()]; ok {
+		if err := run(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	// Log environment info for debugging
 	log.Printf("Environment PORT: %s", os.Getenv("PORT"))
 	log.Printf("Environment DATABASE_URL: %s", os.Getenv("DATABASE_URL"))
@@ -33,40 +47,97 @@ func main() {
 	}
 
 	// Initialize repositories
-	eventRepo := repositories.NewEventRepository(db)
+	eventRepo := repositories.NewEventRepository(db, database.GetSearchEngine())
+	settingsRepo := repositories.NewOwnerSettingsRepository(db)
+	userRepo := repositories.NewUserRepository(db)
+	checkpointRepo := repositories.NewReminderCheckpointRepository(db)
+	feedRepo := repositories.NewFeedRepository(db)
+	appPasswordRepo := repositories.NewAppPasswordRepository(db)
+	syncRepo := repositories.NewSyncRepository(db)
+	webhookRepo := repositories.NewWebhookRepository(db)
+	calendarRepo := repositories.NewCalendarRepository(db)
+	notificationDeliveryRepo := repositories.NewNotificationDeliveryRepository(db)
 
 	// Initialize services
-	eventService := services.NewEventService(eventRepo)
-	notificationService := services.NewNotificationService()
-	schedulerService := services.NewSchedulerService(db, notificationService)
+	cfg := config.LoadConfig()
+	eventBus, err := eventbus.NewFromConfig(cfg, db)
+	if err != nil {
+		log.Fatal("Failed to create event bus:", err)
+	}
+	webhookService := services.NewWebhookService(webhookRepo, eventRepo, notifications.RetryPolicy{
+		MaxAttempts: cfg.WebhookRetry.MaxAttempts,
+		BaseDelay:   cfg.WebhookRetry.BaseDelay,
+		MaxDelay:    cfg.WebhookRetry.MaxDelay,
+	}, cfg.WebhookTimeout)
+	// Every event change goes to the real-time streams and to the webhooks of the users who
+	// see the event's calendar: its owner and the users it is shared with
+	changes := services.NewCalendarAudience(calendarRepo, services.ChangePublishers{eventBus, webhookService})
+	notificationService := services.NewNotificationService(notifications.NewRegistryFromConfig(cfg), notificationDeliveryRepo)
+	reminderService := services.NewReminderService(db, notificationService, webhookService, checkpointRepo, cfg.ReminderCatchUp)
+	eventService := services.NewEventService(eventRepo, calendarRepo, reminderService, changes)
+	calendarService := services.NewCalendarService(calendarRepo, userRepo, reminderService, changes)
+	settingsService := services.NewSettingsService(settingsRepo)
+	tokenService, err := services.NewTokenService()
+	if err != nil {
+		log.Fatal("Failed to create token service:", err)
+	}
+	authService := services.NewAuthService(userRepo, tokenService)
+	feedService := services.NewFeedService(feedRepo, eventRepo)
+	appPasswordService := services.NewAppPasswordService(appPasswordRepo, userRepo)
+	caldavService := services.NewCalDAVService(eventRepo, calendarRepo, eventService)
+	syncService := services.NewSyncService(syncRepo, eventRepo, userRepo, calsync.NewRegistryFromConfig(cfg), reminderService, changes, cfg.SyncInterval)
+	backupService := services.NewBackupService(db, cfg.BackupDir, cfg.BackupInterval, cfg.BackupRetention)
+	deltaSyncService := services.NewDeltaSyncService(eventRepo, cfg.TombstoneRetention)
+	changeStreamService := services.NewChangeStreamService(eventBus, deltaSyncService, cfg.StreamHeartbeat)
+
+	// Start delivering event changes to the real-time streams
+	eventBus.Start()
+
+	// Start delivering the queued webhook notifications, with their retries
+	webhookService.Start()
 
-	// Start scheduler
-	go schedulerService.Start()
+	// Start retrying the reminder notifications that failed, with the retry policy of each channel
+	notificationService.Start()
+
+	// Start the reminder engine, catching up on reminders missed while the server was down
+	reminderService.Start()
+
+	// Start the periodic sync with external calendars
+	syncService.Start()
+
+	// Start the scheduled online backups
+	backupService.Start()
+
+	// Start purging the deleted events that mobile apps no longer need to learn about
+	deltaSyncService.Start()
 
 	// Initialize handlers
-	eventController := handlers.NewEventController(eventService)
+	eventController := handlers.NewEventController(eventService, settingsService)
+	settingsController := handlers.NewSettingsController(settingsService)
+	authController := handlers.NewAuthController(authService)
+	feedController := handlers.NewFeedController(feedService, cfg.PublicBaseURL)
+	appPasswordController := handlers.NewAppPasswordController(appPasswordService, cfg.PublicBaseURL)
+	caldavController := handlers.NewCalDAVController(caldavService, settingsService)
+	syncController := handlers.NewSyncController(syncService)
+	streamController := handlers.NewStreamController(changeStreamService, cfg.CORSAllowedOrigins)
+	webhookController := handlers.NewWebhookController(webhookService)
+	calendarController := handlers.NewCalendarController(calendarService)
+	notificationController := handlers.NewNotificationController(notificationService)
+	authMiddleware := handlers.AuthMiddleware(tokenService)
+	streamAuthMiddleware := handlers.StreamAuthMiddleware(tokenService)
+	basicAuthMiddleware := handlers.BasicAuthMiddleware(appPasswordService)
 
 	// Initialize mobile handler
-	mobileHandler := handlers.NewMobileHandler(db)
+	mobileHandler := handlers.NewMobileHandler(eventService, deltaSyncService)
 
-	// Setup routes
-	router := gin.Default()
+	// Setup routes, logging requests without the stream access tokens
+	router := gin.New()
+	router.Use(handlers.AccessLogger(), gin.Recovery())
 
 	// Add CORS middleware
-	router.Use(func(c *gin.Context) {
-		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization")
-
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(204)
-			return
-		}
+	router.Use(handlers.CORSMiddleware(cfg.CORSAllowedOrigins))
 
-		c.Next()
-	})
-
-	routes.SetupAllRoutes(router, eventController, mobileHandler)
+	routes.SetupAllRoutes(router, authController, eventController, settingsController, feedController, appPasswordController, caldavController, syncController, webhookController, calendarController, notificationController, streamController, mobileHandler, authMiddleware, streamAuthMiddleware, basicAuthMiddleware)
 
 	// Get port from environment or use default
 	port := os.Getenv("PORT")
@@ -79,3 +150,11 @@ func main() {
 		log.Fatal("Failed to start server:", err)
 	}
 }
+
+// firstArg returns the command line argument after the program name, if any
+func firstArg() string {
+	if len(os.Args) < 2 {
+		return ""
+	}
+	return os.Args[1]
+}
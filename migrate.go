@@ -0,0 +1,100 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"calendar-backend/database"
+	"calendar-backend/migrations"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const migrateUsage = `usage: calendar-backend migrate <command>
+
+commands:
+  up          apply all pending migrations
+  down        revert the last applied migration
+  status      list the migrations and whether they are applied
+  to VERSION  apply or revert migrations until the schema is at VERSION (0 reverts all)`
+
+// runMigrate runs the migrate command against the database configured by DATABASE_URL
+func runMigrate(args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	db, err := database.Connect()
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %v", err)
+	}
+	// Only the migration progress is printed, not every statement
+	db = db.Session(&gorm.Session{Logger: logger.Default.LogMode(logger.Warn)})
+	migrator, err := database.NewMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	var count int
+	switch {
+	case args[0] == "up" && len(args) == 1:
+		count, err = migrator.Up()
+	case args[0] == "down" && len(args) == 1:
+		count, err = migrator.Down()
+	case args[0] == "to" && len(args) == 2:
+		version, convErr := strconv.Atoi(args[1])
+		if convErr != nil || version < 0 {
+			return fmt.Errorf("invalid version %q", args[1])
+		}
+		count, err = migrator.To(version)
+	case args[0] == "status" && len(args) == 1:
+		return printMigrationStatus(migrator)
+	default:
+		return errors.New(migrateUsage)
+	}
+	if err != nil {
+		return err
+	}
+
+	current, err := migrator.Current()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%d migrations run, schema is at version %d (latest %d)\n", count, current, migrator.Latest())
+	return nil
+}
+
+// printMigrationStatus prints every migration with the time it was applied
+func printMigrationStatus(migrator *migrations.Migrator) error {
+	statuses, err := migrator.Status()
+	if err != nil {
+		return err
+	}
+	current, err := migrator.Current()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+	for _, status := range statuses {
+		appliedAt := "pending"
+		if status.AppliedAt != nil {
+			appliedAt = status.AppliedAt.Format(time.RFC3339)
+		}
+		if status.Unknown {
+			appliedAt += " (unknown to this binary)"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\n", status.Version, status.Name, appliedAt)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Printf("\nSchema version %d, this binary knows up to version %d\n", current, migrator.Latest())
+	return nil
+}
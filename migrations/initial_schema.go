@@ -0,0 +1,144 @@
+package migrations
+
+import (
+	"calendar-backend/models"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Columnas que se agregaron a events mientras el esquema se migraba con AutoMigrate, con su
+// tipo en cada dialecto. Una base creada por una versión anterior puede no tenerlas.
+var legacyEventColumns = []struct {
+	name             string
+	sqlite, postgres string
+}{
+	{"owner_id", "integer", "bigint"},
+	{"starts_at", "datetime", "timestamptz"},
+	{"ends_at", "datetime", "timestamptz"},
+	{"time_zone", "text DEFAULT 'UTC'", "text DEFAULT 'UTC'"},
+	{"rrule", "text", "text"},
+	{"ex_dates", "text", "text"},
+	{"recurrence_id", "integer", "bigint"},
+	{"original_date", "datetime", "timestamptz"},
+	{"external_uid", "text", "text"},
+}
+
+// initialSchemaUp crea el esquema inicial. Si la base ya tiene eventos (la creó AutoMigrate),
+// la adopta: agrega las columnas que le falten y completa los datos que antes se completaban
+// al iniciar (recordatorios a partir de los flags e instantes a partir de fecha y hora).
+func initialSchemaUp(tx *gorm.DB, dialect string) error {
+	legacy := tx.Migrator().HasTable("events")
+	hadReminders := tx.Migrator().HasTable("reminders")
+
+	if legacy {
+		for _, column := range legacyEventColumns {
+			if tx.Migrator().HasColumn("events", column.name) {
+				continue
+			}
+			definition := column.sqlite
+			if dialect == "postgres" {
+				definition = column.postgres
+			}
+			if err := tx.Exec("ALTER TABLE events ADD COLUMN " + column.name + " " + definition).Error; err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := execFile(tx, dialect, "0001_initial_schema.up.sql"); err != nil {
+		return err
+	}
+	if !legacy {
+		return nil
+	}
+
+	if !hadReminders {
+		if err := migrateReminderFlags(tx); err != nil {
+			return err
+		}
+	}
+	return backfillEventTimestamps(tx)
+}
+
+// migrateReminderFlags crea los recordatorios equivalentes a los flags reminder_day y
+// reminder_day_before de los eventos existentes
+func migrateReminderFlags(tx *gorm.DB) error {
+	var rows []struct {
+		ID                uint
+		ReminderDay       bool
+		ReminderDayBefore bool
+		IsAllDay          bool
+	}
+	if err := tx.Table("events").
+		Select("id, reminder_day, reminder_day_before, is_all_day").
+		Where("reminder_day = ? OR reminder_day_before = ?", true, true).
+		Find(&rows).Error; err != nil {
+		return err
+	}
+
+	var reminders []map[string]interface{}
+	now := time.Now()
+	for _, row := range rows {
+		for _, reminder := range models.LegacyReminders(row.ReminderDay, row.ReminderDayBefore, row.IsAllDay) {
+			reminders = append(reminders, map[string]interface{}{
+				"event_id":       row.ID,
+				"offset_minutes": reminder.OffsetMinutes,
+				"channel":        reminder.Channel,
+				"created_at":     now,
+				"updated_at":     now,
+			})
+		}
+	}
+	if len(reminders) == 0 {
+		return nil
+	}
+
+	if err := tx.Table("reminders").CreateInBatches(reminders, 500).Error; err != nil {
+		return err
+	}
+	log.Printf("Migrated reminder flags of %d events into %d reminders", len(rows), len(reminders))
+	return nil
+}
+
+// backfillEventTimestamps completa starts_at y ends_at de los eventos anteriores a ellos,
+// interpretando la fecha y la hora "HH:MM" en la zona del evento
+func backfillEventTimestamps(tx *gorm.DB) error {
+	var rows []struct {
+		ID       uint
+		Date     time.Time
+		Time     string
+		IsAllDay bool
+		TimeZone string
+	}
+	if err := tx.Table("events").
+		Select("id, date, time, is_all_day, time_zone").
+		Where("starts_at IS NULL").
+		Find(&rows).Error; err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		loc, err := models.LoadLocation(row.TimeZone)
+		if err != nil {
+			loc = time.UTC
+		}
+		clock := row.Time
+		if row.IsAllDay {
+			clock = ""
+		}
+		startsAt := models.ComposeInstant(row.Date, clock, loc)
+		if err := tx.Table("events").Where("id = ?", row.ID).UpdateColumns(map[string]interface{}{
+			"starts_at": startsAt,
+			"ends_at":   models.DefaultEndsAt(startsAt, row.IsAllDay, loc),
+		}).Error; err != nil {
+			return err
+		}
+	}
+
+	if len(rows) > 0 {
+		log.Printf("Backfilled starts_at/ends_at for %d events", len(rows))
+	}
+	return nil
+}
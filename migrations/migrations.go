@@ -0,0 +1,13 @@
+package migrations
+
+// all son las migraciones del esquema. Una migración aplicada no se modifica: los cambios
+// van en una migración nueva, con la versión siguiente y sus archivos SQL para cada dialecto.
+var all = []Migration{
+	{Version: 1, Name: "initial_schema", Up: initialSchemaUp, Down: sqlFile("0001_initial_schema.down.sql")},
+	{Version: 2, Name: "event_change_seq", Up: sqlFile("0002_event_change_seq.up.sql"), Down: sqlFile("0002_event_change_seq.down.sql")},
+	{Version: 3, Name: "event_versions", Up: sqlFile("0003_event_versions.up.sql"), Down: sqlFile("0003_event_versions.down.sql")},
+	{Version: 4, Name: "webhooks", Up: sqlFile("0004_webhooks.up.sql"), Down: sqlFile("0004_webhooks.down.sql")},
+	{Version: 5, Name: "calendars", Up: sqlFile("0005_calendars.up.sql"), Down: sqlFile("0005_calendars.down.sql")},
+	{Version: 6, Name: "event_removals", Up: sqlFile("0006_event_removals.up.sql"), Down: sqlFile("0006_event_removals.down.sql")},
+	{Version: 7, Name: "notification_deliveries", Up: sqlFile("0007_notification_deliveries.up.sql"), Down: sqlFile("0007_notification_deliveries.down.sql")},
+}
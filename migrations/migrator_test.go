@@ -0,0 +1,233 @@
+package migrations
+
+import (
+	"calendar-backend/models"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestDB abre una base SQLite vacía en un directorio temporal, sin migrar
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	return db
+}
+
+func newTestMigrator(t *testing.T, db *gorm.DB) *Migrator {
+	t.Helper()
+	migrator, err := New(db)
+	if err != nil {
+		t.Fatalf("prepare migrations: %v", err)
+	}
+	return migrator
+}
+
+// mustCurrent devuelve la versión de la base o termina el test
+func mustCurrent(t *testing.T, migrator *Migrator) int {
+	t.Helper()
+	current, err := migrator.Current()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return current
+}
+
+func TestMigratorUpAndDown(t *testing.T) {
+	db := newTestDB(t)
+	migrator := newTestMigrator(t, db)
+
+	if count, err := migrator.Up(); err != nil || count != Latest() {
+		t.Fatalf("Up applied %d migrations (%v), want %d", count, err, Latest())
+	}
+	if current := mustCurrent(t, migrator); current != Latest() {
+		t.Errorf("version %d after Up, want %d", current, Latest())
+	}
+	// Volver a migrar no aplica nada
+	if count, err := migrator.Up(); err != nil || count != 0 {
+		t.Errorf("second Up applied %d migrations (%v), want 0", count, err)
+	}
+	if pending, err := migrator.Pending(); err != nil || len(pending) != 0 {
+		t.Errorf("pending %v (%v), want none", pending, err)
+	}
+
+	if count, err := migrator.Down(); err != nil || count != 1 {
+		t.Fatalf("Down reverted %d migrations (%v), want 1", count, err)
+	}
+	if current := mustCurrent(t, migrator); current != Latest()-1 {
+		t.Errorf("version %d after Down, want %d", current, Latest()-1)
+	}
+	statuses, err := migrator.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last := statuses[len(statuses)-1]; last.Version != Latest() || last.Applied {
+		t.Errorf("status of the last migration %+v, want it pending", last)
+	}
+
+	// Revertir todo deja solo schema_migrations, y el esquema se puede volver a crear
+	if count, err := migrator.To(0); err != nil || count != Latest()-1 {
+		t.Fatalf("To(0) reverted %d migrations (%v), want %d", count, err, Latest()-1)
+	}
+	for _, table := range []string{"events", "reminders", "users", "calendars"} {
+		if db.Migrator().HasTable(table) {
+			t.Errorf("table %s remains after reverting every migration", table)
+		}
+	}
+	if count, err := migrator.To(3); err != nil || count != 3 || mustCurrent(t, migrator) != 3 {
+		t.Errorf("To(3) applied %d migrations (%v), want 3", count, err)
+	}
+	if _, err := migrator.Up(); err != nil {
+		t.Fatalf("Up after reverting: %v", err)
+	}
+	if _, err := migrator.To(Latest() + 1); !errors.Is(err, ErrUnknownVersion) {
+		t.Errorf("To(%d): %v, want ErrUnknownVersion", Latest()+1, err)
+	}
+}
+
+func TestMigratorRefusesNewerSchema(t *testing.T) {
+	db := newTestDB(t)
+	migrator := newTestMigrator(t, db)
+	if _, err := migrator.Up(); err != nil {
+		t.Fatal(err)
+	}
+	// Una versión más nueva de la aplicación migró la base
+	newer := schemaMigration{Version: Latest() + 1, Name: "from_the_future", AppliedAt: time.Now().UTC()}
+	if err := db.Create(&newer).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if err := migrator.Check(); !errors.Is(err, ErrSchemaTooNew) {
+		t.Errorf("Check: %v, want ErrSchemaTooNew", err)
+	}
+	for name, run := range map[string]func() (int, error){"Up": migrator.Up, "Down": migrator.Down} {
+		if count, err := run(); !errors.Is(err, ErrSchemaTooNew) || count != 0 {
+			t.Errorf("%s: %d migrations, %v, want ErrSchemaTooNew", name, count, err)
+		}
+	}
+	statuses, err := migrator.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last := statuses[len(statuses)-1]; last.Version != newer.Version || !last.Unknown || !last.Applied {
+		t.Errorf("status of the newer migration %+v, want it applied and unknown", last)
+	}
+}
+
+func TestInitialSchemaAdoptsLegacyDatabase(t *testing.T) {
+	db := newTestDB(t)
+	// Tabla events como la dejaba AutoMigrate antes de los instantes, las zonas y las series
+	if err := db.Exec("CREATE TABLE `events` (`id` integer PRIMARY KEY AUTOINCREMENT,`title` text NOT NULL,`description` text," +
+		"`date` datetime NOT NULL,`time` text,`location` text,`email` text NOT NULL,`phone` text NOT NULL," +
+		"`reminder_day` numeric DEFAULT true,`reminder_day_before` numeric DEFAULT true,`is_all_day` numeric DEFAULT false," +
+		"`color` text DEFAULT '#007AFF',`priority` text DEFAULT 'medium',`category` text," +
+		"`created_at` datetime,`updated_at` datetime,`deleted_at` datetime)").Error; err != nil {
+		t.Fatal(err)
+	}
+	day := time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC)
+	legacy := []struct {
+		title               string
+		clock               string
+		allDay, day, before bool
+		wantStart, wantEnd  time.Time
+	}{
+		{"Dentista", "10:30", false, true, false, day.Add(10*time.Hour + 30*time.Minute), day.Add(11*time.Hour + 30*time.Minute)},
+		{"Feriado", "", true, true, true, day, day.AddDate(0, 0, 1)},
+	}
+	for _, event := range legacy {
+		if err := db.Exec("INSERT INTO events (title, date, time, email, phone, reminder_day, reminder_day_before, is_all_day) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+			event.title, day, event.clock, "ana@example.com", "+5491122334455", event.day, event.before, event.allDay).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := newTestMigrator(t, db).Up(); err != nil {
+		t.Fatalf("migrate legacy database: %v", err)
+	}
+
+	for _, column := range legacyEventColumns {
+		if !db.Migrator().HasColumn("events", column.name) {
+			t.Errorf("column %s was not added", column.name)
+		}
+	}
+	for _, event := range legacy {
+		var row struct {
+			ID       uint
+			StartsAt time.Time
+			EndsAt   time.Time
+			TimeZone string
+		}
+		if err := db.Table("events").Select("id, starts_at, ends_at, time_zone").Where("title = ?", event.title).Take(&row).Error; err != nil {
+			t.Fatal(err)
+		}
+		if !row.StartsAt.Equal(event.wantStart) || !row.EndsAt.Equal(event.wantEnd) || row.TimeZone != "UTC" {
+			t.Errorf("%s: %s-%s in %q, want %s-%s in UTC", event.title, row.StartsAt, row.EndsAt, row.TimeZone, event.wantStart, event.wantEnd)
+		}
+		var offsets []int
+		if err := db.Table("reminders").Where("event_id = ?", row.ID).Order("offset_minutes").Pluck("offset_minutes", &offsets).Error; err != nil {
+			t.Fatal(err)
+		}
+		// Los flags pasan a los recordatorios equivalentes
+		var want []int
+		for _, reminder := range models.LegacyReminders(event.day, event.before, event.allDay) {
+			want = append(want, reminder.OffsetMinutes)
+		}
+		sort.Ints(want)
+		if len(want) == 0 || !reflect.DeepEqual(offsets, want) {
+			t.Errorf("%s: reminders %v, want %v", event.title, offsets, want)
+		}
+	}
+}
+
+func TestSplitStatements(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{
+			"statements and comments",
+			"-- Comentario\nCREATE TABLE a (id integer);\n\n  -- Otro; con punto y coma\nCREATE INDEX i ON a (id);\nDROP TABLE b",
+			[]string{"CREATE TABLE a (id integer);", "CREATE INDEX i ON a (id);", "DROP TABLE b"},
+		},
+		{
+			"multi-line statement",
+			"CREATE TABLE a (\n\tid integer,\n\tname text\n);",
+			[]string{"CREATE TABLE a (\n\tid integer,\n\tname text\n);"},
+		},
+		{
+			"quoted text",
+			"INSERT INTO a VALUES ('uno; dos', 'it''s');\nINSERT INTO a VALUES ('línea;\n-- no es comentario\n\nfin');\nSELECT 1;",
+			[]string{"INSERT INTO a VALUES ('uno; dos', 'it''s');", "INSERT INTO a VALUES ('línea;\n-- no es comentario\n\nfin');", "SELECT 1;"},
+		},
+		{
+			"postgres function body",
+			"CREATE FUNCTION f() RETURNS trigger AS $$\nBEGIN\n\tNEW.x := 1;\n\tRETURN NEW;\nEND;\n$$ LANGUAGE plpgsql;\nSELECT 1;",
+			[]string{"CREATE FUNCTION f() RETURNS trigger AS $$\nBEGIN\n\tNEW.x := 1;\n\tRETURN NEW;\nEND;\n$$ LANGUAGE plpgsql;", "SELECT 1;"},
+		},
+		{
+			"sqlite trigger",
+			"CREATE TRIGGER t AFTER INSERT ON a BEGIN\n\tUPDATE a SET x = 1;\n\tUPDATE a SET y = 2;\nEND;\nSELECT 1;",
+			[]string{"CREATE TRIGGER t AFTER INSERT ON a BEGIN\n\tUPDATE a SET x = 1;\n\tUPDATE a SET y = 2;\nEND;", "SELECT 1;"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitStatements(tt.content); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitStatements = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
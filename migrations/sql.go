@@ -0,0 +1,80 @@
+package migrations
+
+import (
+	"embed"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Archivos SQL de las migraciones: sql/<dialecto>/<versión>_<nombre>.<up|down>.sql
+//
+//go:embed sql
+var files embed.FS
+
+// sqlFile devuelve el paso que ejecuta el archivo SQL del dialecto
+func sqlFile(name string) Step {
+	return func(tx *gorm.DB, dialect string) error {
+		return execFile(tx, dialect, name)
+	}
+}
+
+// execFile ejecuta, una a una, las sentencias del archivo SQL del dialecto
+func execFile(tx *gorm.DB, dialect, name string) error {
+	content, err := files.ReadFile("sql/" + dialect + "/" + name)
+	if err != nil {
+		return err
+	}
+	for _, statement := range splitStatements(string(content)) {
+		if err := tx.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// splitStatements divide un archivo SQL en sentencias: cada una termina con ; al final de una
+// línea, salvo dentro de un texto entre comillas simples que sigue en la línea siguiente, de
+// un cuerpo entre $$ (funciones de PostgreSQL) o entre una línea que termina en BEGIN y una
+// línea END; (triggers de SQLite). Las líneas de comentario (--) fuera de un texto se
+// descartan.
+func splitStatements(content string) []string {
+	var statements []string
+	var current strings.Builder
+	text, quoted, block := false, false, false
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if !text && (trimmed == "" || strings.HasPrefix(trimmed, "--")) {
+			continue
+		}
+		current.WriteString(line)
+		current.WriteString("\n")
+
+		// Las comillas escapadas ('') no cambian la cuenta
+		if strings.Count(line, "'")%2 == 1 {
+			text = !text
+		}
+		if strings.Count(line, "$$")%2 == 1 {
+			quoted = !quoted
+		}
+		upper := strings.ToUpper(trimmed)
+		switch {
+		case text || quoted:
+			continue
+		case strings.HasSuffix(upper, "BEGIN"):
+			block = true
+			continue
+		case block && upper != "END;":
+			continue
+		}
+		if strings.HasSuffix(trimmed, ";") {
+			block = false
+			statements = append(statements, strings.TrimSpace(current.String()))
+			current.Reset()
+		}
+	}
+	if rest := strings.TrimSpace(current.String()); rest != "" {
+		statements = append(statements, rest)
+	}
+	return statements
+}
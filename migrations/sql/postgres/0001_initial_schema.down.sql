@@ -0,0 +1,10 @@
+DROP TABLE IF EXISTS "sync_audits" CASCADE;
+DROP TABLE IF EXISTS "sync_links" CASCADE;
+DROP TABLE IF EXISTS "sync_accounts" CASCADE;
+DROP TABLE IF EXISTS "app_passwords" CASCADE;
+DROP TABLE IF EXISTS "feeds" CASCADE;
+DROP TABLE IF EXISTS "owner_settings" CASCADE;
+DROP TABLE IF EXISTS "reminder_checkpoints" CASCADE;
+DROP TABLE IF EXISTS "reminders" CASCADE;
+DROP TABLE IF EXISTS "events" CASCADE;
+DROP TABLE IF EXISTS "users" CASCADE;
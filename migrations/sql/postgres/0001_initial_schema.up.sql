@@ -0,0 +1,41 @@
+-- Esquema inicial: el que creaba AutoMigrate. Con IF NOT EXISTS, adopta también las bases
+-- creadas por AutoMigrate antes de las migraciones versionadas.
+
+CREATE TABLE IF NOT EXISTS "users" ("id" bigserial,"email" text NOT NULL,"name" text,"password_hash" text NOT NULL,"created_at" timestamptz,"updated_at" timestamptz,"deleted_at" timestamptz,PRIMARY KEY ("id"));
+CREATE INDEX IF NOT EXISTS "idx_users_deleted_at" ON "users" ("deleted_at");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_users_email" ON "users" ("email");
+
+CREATE TABLE IF NOT EXISTS "events" ("id" bigserial,"owner_id" bigint,"title" text NOT NULL,"description" text,"starts_at" timestamptz,"ends_at" timestamptz,"date" timestamptz NOT NULL,"time" text,"time_zone" text DEFAULT 'UTC',"location" text,"email" text NOT NULL,"phone" text NOT NULL,"reminder_day" boolean DEFAULT true,"reminder_day_before" boolean DEFAULT true,"is_all_day" boolean DEFAULT false,"color" text DEFAULT '#007AFF',"priority" text DEFAULT 'medium',"category" text,"rrule" text,"ex_dates" text,"recurrence_id" bigint,"original_date" timestamptz,"external_uid" text,"created_at" timestamptz,"updated_at" timestamptz,"deleted_at" timestamptz,PRIMARY KEY ("id"));
+CREATE INDEX IF NOT EXISTS "idx_events_deleted_at" ON "events" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_events_external_uid" ON "events" ("external_uid");
+CREATE INDEX IF NOT EXISTS "idx_events_recurrence_id" ON "events" ("recurrence_id");
+CREATE INDEX IF NOT EXISTS "idx_events_ends_at" ON "events" ("ends_at");
+CREATE INDEX IF NOT EXISTS "idx_events_starts_at" ON "events" ("starts_at");
+CREATE INDEX IF NOT EXISTS "idx_events_owner_id" ON "events" ("owner_id");
+
+CREATE TABLE IF NOT EXISTS "reminders" ("id" bigserial,"event_id" bigint NOT NULL,"offset_minutes" bigint NOT NULL,"channel" text,"created_at" timestamptz,"updated_at" timestamptz,PRIMARY KEY ("id"),CONSTRAINT "fk_events_reminders" FOREIGN KEY ("event_id") REFERENCES "events"("id") ON DELETE CASCADE);
+CREATE INDEX IF NOT EXISTS "idx_reminders_event_id" ON "reminders" ("event_id");
+
+CREATE TABLE IF NOT EXISTS "reminder_checkpoints" ("name" text,"processed_until" timestamptz,"updated_at" timestamptz,PRIMARY KEY ("name"));
+
+CREATE TABLE IF NOT EXISTS "owner_settings" ("id" bigserial,"email" text NOT NULL,"time_zone" text NOT NULL,"created_at" timestamptz,"updated_at" timestamptz,PRIMARY KEY ("id"));
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_owner_settings_email" ON "owner_settings" ("email");
+
+CREATE TABLE IF NOT EXISTS "feeds" ("id" bigserial,"owner_id" bigint NOT NULL,"name" text,"token_hash" text NOT NULL,"token_hint" text,"categories" text,"priorities" text,"last_used_at" timestamptz,"revoked_at" timestamptz,"created_at" timestamptz,"updated_at" timestamptz,PRIMARY KEY ("id"));
+CREATE INDEX IF NOT EXISTS "idx_feeds_owner_id" ON "feeds" ("owner_id");
+CREATE INDEX IF NOT EXISTS "idx_feeds_revoked_at" ON "feeds" ("revoked_at");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_feeds_token_hash" ON "feeds" ("token_hash");
+
+CREATE TABLE IF NOT EXISTS "app_passwords" ("id" bigserial,"user_id" bigint NOT NULL,"name" text,"password_hash" text NOT NULL,"last_used_at" timestamptz,"created_at" timestamptz,PRIMARY KEY ("id"));
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_app_passwords_password_hash" ON "app_passwords" ("password_hash");
+CREATE INDEX IF NOT EXISTS "idx_app_passwords_user_id" ON "app_passwords" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "sync_accounts" ("id" bigserial,"owner_id" bigint NOT NULL,"provider" text NOT NULL,"calendar_id" text NOT NULL,"credentials" text,"sync_token" text,"last_synced_at" timestamptz,"last_error" text,"created_at" timestamptz,"updated_at" timestamptz,PRIMARY KEY ("id"));
+CREATE INDEX IF NOT EXISTS "idx_sync_accounts_owner_id" ON "sync_accounts" ("owner_id");
+
+CREATE TABLE IF NOT EXISTS "sync_links" ("id" bigserial,"account_id" bigint NOT NULL,"event_id" bigint NOT NULL,"remote_id" text NOT NULL,"remote_version" text,"local_updated_at" timestamptz,"created_at" timestamptz,"updated_at" timestamptz,PRIMARY KEY ("id"));
+CREATE INDEX IF NOT EXISTS "idx_sync_link_event" ON "sync_links" ("account_id","event_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_sync_link_remote" ON "sync_links" ("account_id","remote_id");
+
+CREATE TABLE IF NOT EXISTS "sync_audits" ("id" bigserial,"account_id" bigint NOT NULL,"event_id" bigint,"remote_id" text,"action" text,"conflict" boolean,"winner" text,"local_updated_at" timestamptz,"remote_updated_at" timestamptz,"detail" text,"created_at" timestamptz,PRIMARY KEY ("id"));
+CREATE INDEX IF NOT EXISTS "idx_sync_audits_account_id" ON "sync_audits" ("account_id");
@@ -0,0 +1,6 @@
+DROP TRIGGER IF EXISTS events_change_seq ON "events";
+DROP FUNCTION IF EXISTS events_change_seq();
+DROP TABLE IF EXISTS "event_sync_horizon";
+DROP INDEX IF EXISTS "idx_events_owner_change_seq";
+-- Borra también la secuencia event_change_seq, que pertenece a la columna
+ALTER TABLE "events" DROP COLUMN IF EXISTS "change_seq";
@@ -0,0 +1,32 @@
+-- Número de cambio de los eventos: cada alta, modificación o baja lógica le asigna al evento el
+-- siguiente número de la secuencia, así las apps piden los cambios posteriores al último que vieron.
+
+ALTER TABLE "events" ADD COLUMN "change_seq" bigint NOT NULL DEFAULT 0;
+CREATE SEQUENCE "event_change_seq" OWNED BY "events"."change_seq";
+
+-- Los eventos existentes se numeran en el orden en que cambiaron por última vez
+UPDATE "events" SET "change_seq" = numbered.seq
+FROM (SELECT "id", row_number() OVER (ORDER BY COALESCE("deleted_at", "updated_at"), "id") AS seq FROM "events") AS numbered
+WHERE "events"."id" = numbered."id";
+SELECT setval('event_change_seq', (SELECT COALESCE(MAX("change_seq"), 0) + 1 FROM "events"), false);
+CREATE INDEX "idx_events_owner_change_seq" ON "events" ("owner_id", "change_seq");
+
+-- Mayor número de cambio de las bajas ya borradas: un token anterior requiere sincronizar todo
+CREATE TABLE "event_sync_horizon" ("id" integer PRIMARY KEY CHECK ("id" = 1), "purged_seq" bigint NOT NULL);
+INSERT INTO "event_sync_horizon" ("id", "purged_seq") VALUES (1, 0);
+
+CREATE FUNCTION events_change_seq() RETURNS trigger AS $$
+BEGIN
+	-- Las filas que restaura un backup conservan su número
+	IF TG_OP = 'INSERT' AND NEW.change_seq <> 0 THEN
+		RETURN NEW;
+	END IF;
+	-- Los cambios de un mismo dueño se confirman en el orden de sus números: sin el lock, una
+	-- app podría leer el cambio N+1 antes de que se confirme el N y no ver nunca el N
+	PERFORM pg_advisory_xact_lock(730552021, COALESCE(NEW.owner_id, 0)::integer);
+	NEW.change_seq := nextval('event_change_seq');
+	RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER events_change_seq BEFORE INSERT OR UPDATE ON "events" FOR EACH ROW EXECUTE FUNCTION events_change_seq();
@@ -0,0 +1,8 @@
+-- Versión de los eventos, que aumenta con cada modificación para rechazar las escrituras hechas
+-- sobre una copia vieja, e ID que generó la app al crear el evento sin conexión (vacío si no)
+
+ALTER TABLE "events" ADD COLUMN "version" bigint NOT NULL DEFAULT 1;
+ALTER TABLE "events" ADD COLUMN "client_id" text NOT NULL DEFAULT '';
+
+-- Reenviar el alta con el mismo client_id no crea otro evento
+CREATE UNIQUE INDEX "idx_events_owner_client_id" ON "events" ("owner_id", "client_id") WHERE "client_id" <> '';
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS "idx_events_calendar_id";
+ALTER TABLE "events" DROP COLUMN IF EXISTS "calendar_id";
+DROP TABLE IF EXISTS "calendar_shares";
+DROP TABLE IF EXISTS "calendars";
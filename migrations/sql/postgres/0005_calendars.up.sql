@@ -0,0 +1,25 @@
+-- Calendarios: cada evento pertenece a un calendario de su dueño, que puede compartirlo con otros
+-- usuarios como viewer (solo lectura), editor (modifica eventos) o manager (además administra el
+-- calendario y con quién se comparte)
+
+CREATE TABLE IF NOT EXISTS "calendars" ("id" bigserial,"owner_id" bigint NOT NULL,"name" text NOT NULL,"color" text,"is_default" boolean NOT NULL DEFAULT false,"created_at" timestamptz,"updated_at" timestamptz,PRIMARY KEY ("id"));
+CREATE INDEX IF NOT EXISTS "idx_calendars_owner_id" ON "calendars" ("owner_id");
+-- Cada usuario tiene un solo calendario predeterminado, donde van los eventos sin calendario
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_calendars_owner_default" ON "calendars" ("owner_id") WHERE "is_default";
+
+CREATE TABLE IF NOT EXISTS "calendar_shares" ("id" bigserial,"calendar_id" bigint NOT NULL,"user_id" bigint NOT NULL,"role" text NOT NULL,"created_at" timestamptz,"updated_at" timestamptz,PRIMARY KEY ("id"),CONSTRAINT "fk_calendar_shares_calendar" FOREIGN KEY ("calendar_id") REFERENCES "calendars"("id") ON DELETE CASCADE,CONSTRAINT "fk_calendar_shares_user" FOREIGN KEY ("user_id") REFERENCES "users"("id") ON DELETE CASCADE);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_calendar_shares_calendar_user" ON "calendar_shares" ("calendar_id", "user_id");
+CREATE INDEX IF NOT EXISTS "idx_calendar_shares_user_id" ON "calendar_shares" ("user_id");
+
+-- Los eventos sin dueño (anteriores a las cuentas) quedan sin calendario hasta que se les asigna uno
+ALTER TABLE "events" ADD COLUMN "calendar_id" bigint NOT NULL DEFAULT 0;
+CREATE INDEX "idx_events_calendar_id" ON "events" ("calendar_id");
+
+-- Los eventos existentes pasan al calendario predeterminado de su dueño. Cambian su número de
+-- cambio, así que las apps los reciben con el calendario en la próxima sincronización.
+INSERT INTO "calendars" ("owner_id", "name", "color", "is_default", "created_at", "updated_at")
+SELECT owners."owner_id", 'Calendar', '#007AFF', true, NOW(), NOW()
+FROM (SELECT "id" AS "owner_id" FROM "users" UNION SELECT DISTINCT "owner_id" FROM "events" WHERE "owner_id" <> 0) AS owners;
+UPDATE "events" SET "calendar_id" = "calendars"."id"
+FROM "calendars"
+WHERE "calendars"."owner_id" = "events"."owner_id" AND "calendars"."is_default";
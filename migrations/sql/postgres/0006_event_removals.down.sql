@@ -0,0 +1,21 @@
+DROP TRIGGER IF EXISTS events_calendar_removals ON "events";
+DROP FUNCTION IF EXISTS events_calendar_removals();
+DROP TRIGGER IF EXISTS calendar_shares_access ON "calendar_shares";
+DROP FUNCTION IF EXISTS calendar_shares_access();
+DROP TABLE IF EXISTS "event_removals";
+DROP FUNCTION IF EXISTS event_removals_change_seq();
+
+-- Vuelve al lock por dueño de la migración 2
+CREATE OR REPLACE FUNCTION events_change_seq() RETURNS trigger AS $$
+BEGIN
+	-- Las filas que restaura un backup conservan su número
+	IF TG_OP = 'INSERT' AND NEW.change_seq <> 0 THEN
+		RETURN NEW;
+	END IF;
+	-- Los cambios de un mismo dueño se confirman en el orden de sus números: sin el lock, una
+	-- app podría leer el cambio N+1 antes de que se confirme el N y no ver nunca el N
+	PERFORM pg_advisory_xact_lock(730552021, COALESCE(NEW.owner_id, 0)::integer);
+	NEW.change_seq := nextval('event_change_seq');
+	RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
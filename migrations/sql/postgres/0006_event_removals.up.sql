@@ -0,0 +1,73 @@
+-- Bajas de eventos para los usuarios que dejan de verlos sin que se eliminen: cuando se deja de
+-- compartirles el calendario o cuando el evento pasa a un calendario que no comparten. Las apps
+-- las reciben como eliminados en la sincronización incremental. Numeran sus cambios con la
+-- misma secuencia que los eventos.
+
+CREATE TABLE "event_removals" ("id" bigserial,"user_id" bigint NOT NULL,"event_id" bigint NOT NULL,"change_seq" bigint NOT NULL DEFAULT 0,"removed_at" timestamptz NOT NULL,PRIMARY KEY ("id"));
+CREATE INDEX "idx_event_removals_user_change_seq" ON "event_removals" ("user_id", "change_seq");
+
+-- Los cambios que ve cada usuario se confirman en el orden de sus números, como los de sus
+-- eventos (ver events_change_seq)
+CREATE FUNCTION event_removals_change_seq() RETURNS trigger AS $$
+BEGIN
+	PERFORM pg_advisory_xact_lock(730552021, NEW.user_id::integer);
+	NEW.change_seq := nextval('event_change_seq');
+	RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER event_removals_change_seq BEFORE INSERT ON "event_removals" FOR EACH ROW EXECUTE FUNCTION event_removals_change_seq();
+
+-- Un evento lo ven su dueño y los usuarios con quienes se comparte su calendario: el número de
+-- cambio se asigna con el lock de cada uno, tomados en orden para no trabarse entre sí
+CREATE OR REPLACE FUNCTION events_change_seq() RETURNS trigger AS $$
+DECLARE
+	viewer bigint;
+BEGIN
+	-- Las filas que restaura un backup conservan su número
+	IF TG_OP = 'INSERT' AND NEW.change_seq <> 0 THEN
+		RETURN NEW;
+	END IF;
+	FOR viewer IN
+		SELECT COALESCE(NEW.owner_id, 0)
+		UNION SELECT "user_id" FROM "calendar_shares" WHERE "calendar_id" = NEW.calendar_id
+		ORDER BY 1
+	LOOP
+		PERFORM pg_advisory_xact_lock(730552021, viewer::integer);
+	END LOOP;
+	NEW.change_seq := nextval('event_change_seq');
+	RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Al compartir un calendario sus eventos cambian de número, así el usuario los recibe en su
+-- próxima sincronización aunque su token sea posterior al último cambio de cada uno. Al dejar
+-- de compartirlo, el usuario recibe la baja de cada evento.
+CREATE FUNCTION calendar_shares_access() RETURNS trigger AS $$
+BEGIN
+	IF TG_OP = 'INSERT' THEN
+		UPDATE "events" SET "updated_at" = NOW() WHERE "calendar_id" = NEW.calendar_id AND "deleted_at" IS NULL;
+		RETURN NEW;
+	END IF;
+	INSERT INTO "event_removals" ("user_id", "event_id", "removed_at")
+	SELECT OLD.user_id, "id", NOW() FROM "events" WHERE "calendar_id" = OLD.calendar_id AND "deleted_at" IS NULL;
+	RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER calendar_shares_access AFTER INSERT OR DELETE ON "calendar_shares" FOR EACH ROW EXECUTE FUNCTION calendar_shares_access();
+
+-- El dueño de los dos calendarios es el mismo: solo pierden el evento los usuarios con quienes
+-- se compartía el calendario anterior y no se comparte el nuevo
+CREATE FUNCTION events_calendar_removals() RETURNS trigger AS $$
+BEGIN
+	INSERT INTO "event_removals" ("user_id", "event_id", "removed_at")
+	SELECT "user_id", NEW.id, NOW() FROM "calendar_shares"
+	WHERE "calendar_id" = OLD.calendar_id
+	AND "user_id" NOT IN (SELECT "user_id" FROM "calendar_shares" WHERE "calendar_id" = NEW.calendar_id);
+	RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER events_calendar_removals AFTER UPDATE OF "calendar_id" ON "events" FOR EACH ROW
+WHEN (NEW.calendar_id <> OLD.calendar_id AND NEW.deleted_at IS NULL) EXECUTE FUNCTION events_calendar_removals();
@@ -0,0 +1,8 @@
+-- Notificaciones que fallaron al enviarse por un canal: se reintentan con la política del canal
+-- y, al agotarse los intentos, quedan registradas como fallidas hasta que se reenvíen
+
+CREATE TABLE IF NOT EXISTS "notification_deliveries" ("id" bigserial,"owner_id" bigint NOT NULL,"event_id" bigint NOT NULL,"channel" text NOT NULL,"email" text,"phone" text,"subject" text,"body" text,"text" text,"status" text NOT NULL,"attempts" bigint NOT NULL DEFAULT 0,"next_attempt_at" timestamptz,"last_error" text,"provider_message_id" text,"delivered_at" timestamptz,"created_at" timestamptz,"updated_at" timestamptz,PRIMARY KEY ("id"));
+CREATE INDEX IF NOT EXISTS "idx_notification_deliveries_owner_id" ON "notification_deliveries" ("owner_id");
+
+-- Los envíos pendientes se buscan por el próximo intento
+CREATE INDEX IF NOT EXISTS "idx_notification_deliveries_pending" ON "notification_deliveries" ("next_attempt_at") WHERE "status" = 'pending';
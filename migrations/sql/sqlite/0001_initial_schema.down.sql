@@ -0,0 +1,10 @@
+DROP TABLE IF EXISTS `sync_audits`;
+DROP TABLE IF EXISTS `sync_links`;
+DROP TABLE IF EXISTS `sync_accounts`;
+DROP TABLE IF EXISTS `app_passwords`;
+DROP TABLE IF EXISTS `feeds`;
+DROP TABLE IF EXISTS `owner_settings`;
+DROP TABLE IF EXISTS `reminder_checkpoints`;
+DROP TABLE IF EXISTS `reminders`;
+DROP TABLE IF EXISTS `events`;
+DROP TABLE IF EXISTS `users`;
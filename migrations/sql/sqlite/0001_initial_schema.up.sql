@@ -0,0 +1,41 @@
+-- Esquema inicial: el que creaba AutoMigrate. Con IF NOT EXISTS, adopta también las bases
+-- creadas por AutoMigrate antes de las migraciones versionadas.
+
+CREATE TABLE IF NOT EXISTS `users` (`id` integer PRIMARY KEY AUTOINCREMENT,`email` text NOT NULL,`name` text,`password_hash` text NOT NULL,`created_at` datetime,`updated_at` datetime,`deleted_at` datetime);
+CREATE INDEX IF NOT EXISTS `idx_users_deleted_at` ON `users`(`deleted_at`);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_users_email` ON `users`(`email`);
+
+CREATE TABLE IF NOT EXISTS `events` (`id` integer PRIMARY KEY AUTOINCREMENT,`owner_id` integer,`title` text NOT NULL,`description` text,`starts_at` datetime,`ends_at` datetime,`date` datetime NOT NULL,`time` text,`time_zone` text DEFAULT 'UTC',`location` text,`email` text NOT NULL,`phone` text NOT NULL,`reminder_day` numeric DEFAULT true,`reminder_day_before` numeric DEFAULT true,`is_all_day` numeric DEFAULT false,`color` text DEFAULT '#007AFF',`priority` text DEFAULT 'medium',`category` text,`rrule` text,`ex_dates` text,`recurrence_id` integer,`original_date` datetime,`external_uid` text,`created_at` datetime,`updated_at` datetime,`deleted_at` datetime);
+CREATE INDEX IF NOT EXISTS `idx_events_deleted_at` ON `events`(`deleted_at`);
+CREATE INDEX IF NOT EXISTS `idx_events_external_uid` ON `events`(`external_uid`);
+CREATE INDEX IF NOT EXISTS `idx_events_recurrence_id` ON `events`(`recurrence_id`);
+CREATE INDEX IF NOT EXISTS `idx_events_ends_at` ON `events`(`ends_at`);
+CREATE INDEX IF NOT EXISTS `idx_events_starts_at` ON `events`(`starts_at`);
+CREATE INDEX IF NOT EXISTS `idx_events_owner_id` ON `events`(`owner_id`);
+
+CREATE TABLE IF NOT EXISTS `reminders` (`id` integer PRIMARY KEY AUTOINCREMENT,`event_id` integer NOT NULL,`offset_minutes` integer NOT NULL,`channel` text,`created_at` datetime,`updated_at` datetime,CONSTRAINT `fk_events_reminders` FOREIGN KEY (`event_id`) REFERENCES `events`(`id`) ON DELETE CASCADE);
+CREATE INDEX IF NOT EXISTS `idx_reminders_event_id` ON `reminders`(`event_id`);
+
+CREATE TABLE IF NOT EXISTS `reminder_checkpoints` (`name` text,`processed_until` datetime,`updated_at` datetime,PRIMARY KEY (`name`));
+
+CREATE TABLE IF NOT EXISTS `owner_settings` (`id` integer PRIMARY KEY AUTOINCREMENT,`email` text NOT NULL,`time_zone` text NOT NULL,`created_at` datetime,`updated_at` datetime);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_owner_settings_email` ON `owner_settings`(`email`);
+
+CREATE TABLE IF NOT EXISTS `feeds` (`id` integer PRIMARY KEY AUTOINCREMENT,`owner_id` integer NOT NULL,`name` text,`token_hash` text NOT NULL,`token_hint` text,`categories` text,`priorities` text,`last_used_at` datetime,`revoked_at` datetime,`created_at` datetime,`updated_at` datetime);
+CREATE INDEX IF NOT EXISTS `idx_feeds_owner_id` ON `feeds`(`owner_id`);
+CREATE INDEX IF NOT EXISTS `idx_feeds_revoked_at` ON `feeds`(`revoked_at`);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_feeds_token_hash` ON `feeds`(`token_hash`);
+
+CREATE TABLE IF NOT EXISTS `app_passwords` (`id` integer PRIMARY KEY AUTOINCREMENT,`user_id` integer NOT NULL,`name` text,`password_hash` text NOT NULL,`last_used_at` datetime,`created_at` datetime);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_app_passwords_password_hash` ON `app_passwords`(`password_hash`);
+CREATE INDEX IF NOT EXISTS `idx_app_passwords_user_id` ON `app_passwords`(`user_id`);
+
+CREATE TABLE IF NOT EXISTS `sync_accounts` (`id` integer PRIMARY KEY AUTOINCREMENT,`owner_id` integer NOT NULL,`provider` text NOT NULL,`calendar_id` text NOT NULL,`credentials` text,`sync_token` text,`last_synced_at` datetime,`last_error` text,`created_at` datetime,`updated_at` datetime);
+CREATE INDEX IF NOT EXISTS `idx_sync_accounts_owner_id` ON `sync_accounts`(`owner_id`);
+
+CREATE TABLE IF NOT EXISTS `sync_links` (`id` integer PRIMARY KEY AUTOINCREMENT,`account_id` integer NOT NULL,`event_id` integer NOT NULL,`remote_id` text NOT NULL,`remote_version` text,`local_updated_at` datetime,`created_at` datetime,`updated_at` datetime);
+CREATE INDEX IF NOT EXISTS `idx_sync_link_event` ON `sync_links`(`account_id`,`event_id`);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_sync_link_remote` ON `sync_links`(`account_id`,`remote_id`);
+
+CREATE TABLE IF NOT EXISTS `sync_audits` (`id` integer PRIMARY KEY AUTOINCREMENT,`account_id` integer NOT NULL,`event_id` integer,`remote_id` text,`action` text,`conflict` numeric,`winner` text,`local_updated_at` datetime,`remote_updated_at` datetime,`detail` text,`created_at` datetime);
+CREATE INDEX IF NOT EXISTS `idx_sync_audits_account_id` ON `sync_audits`(`account_id`);
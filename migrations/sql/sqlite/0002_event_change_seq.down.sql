@@ -0,0 +1,6 @@
+DROP TRIGGER IF EXISTS `events_change_seq_update`;
+DROP TRIGGER IF EXISTS `events_change_seq_insert`;
+DROP TABLE IF EXISTS `event_sync_horizon`;
+DROP TABLE IF EXISTS `event_change_seq`;
+DROP INDEX IF EXISTS `idx_events_owner_change_seq`;
+ALTER TABLE `events` DROP COLUMN `change_seq`;
@@ -0,0 +1,29 @@
+-- Número de cambio de los eventos: cada alta, modificación o baja lógica le asigna al evento el
+-- siguiente número, así las apps piden los cambios posteriores al último que vieron. SQLite no
+-- tiene secuencias: el último número asignado se guarda en event_change_seq.
+
+ALTER TABLE `events` ADD COLUMN `change_seq` integer NOT NULL DEFAULT 0;
+
+-- Los eventos existentes se numeran en el orden en que cambiaron por última vez
+UPDATE `events` SET `change_seq` = numbered.seq
+FROM (SELECT `id`, row_number() OVER (ORDER BY COALESCE(`deleted_at`, `updated_at`), `id`) AS seq FROM `events`) AS numbered
+WHERE `events`.`id` = numbered.`id`;
+CREATE INDEX `idx_events_owner_change_seq` ON `events` (`owner_id`, `change_seq`);
+
+CREATE TABLE `event_change_seq` (`id` integer PRIMARY KEY CHECK (`id` = 1), `value` integer NOT NULL);
+INSERT INTO `event_change_seq` (`id`, `value`) SELECT 1, COALESCE(MAX(`change_seq`), 0) FROM `events`;
+
+-- Mayor número de cambio de las bajas ya borradas: un token anterior requiere sincronizar todo
+CREATE TABLE `event_sync_horizon` (`id` integer PRIMARY KEY CHECK (`id` = 1), `purged_seq` integer NOT NULL);
+INSERT INTO `event_sync_horizon` (`id`, `purged_seq`) VALUES (1, 0);
+
+-- SQLite serializa las escrituras, así que los números se confirman en orden. El WHEN evita que
+-- el UPDATE del propio trigger vuelva a numerar la fila.
+CREATE TRIGGER `events_change_seq_insert` AFTER INSERT ON `events` BEGIN
+	UPDATE `event_change_seq` SET `value` = `value` + 1;
+	UPDATE `events` SET `change_seq` = (SELECT `value` FROM `event_change_seq`) WHERE `id` = NEW.`id`;
+END;
+CREATE TRIGGER `events_change_seq_update` AFTER UPDATE ON `events` WHEN NEW.`change_seq` = OLD.`change_seq` BEGIN
+	UPDATE `event_change_seq` SET `value` = `value` + 1;
+	UPDATE `events` SET `change_seq` = (SELECT `value` FROM `event_change_seq`) WHERE `id` = NEW.`id`;
+END;
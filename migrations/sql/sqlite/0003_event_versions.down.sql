@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS `idx_events_owner_client_id`;
+ALTER TABLE `events` DROP COLUMN `client_id`;
+ALTER TABLE `events` DROP COLUMN `version`;
@@ -0,0 +1,11 @@
+-- Webhooks: suscripciones de cada usuario a los cambios de su calendario y registro de los
+-- envíos, con sus reintentos
+
+CREATE TABLE IF NOT EXISTS `webhooks` (`id` integer PRIMARY KEY AUTOINCREMENT,`owner_id` integer NOT NULL,`url` text NOT NULL,`secret` text NOT NULL,`description` text,`event_types` text,`disabled_at` datetime,`created_at` datetime,`updated_at` datetime);
+CREATE INDEX IF NOT EXISTS `idx_webhooks_owner_id` ON `webhooks`(`owner_id`);
+
+CREATE TABLE IF NOT EXISTS `webhook_deliveries` (`id` integer PRIMARY KEY AUTOINCREMENT,`webhook_id` integer NOT NULL,`event_type` text NOT NULL,`payload` text NOT NULL,`status` text NOT NULL,`attempts` integer NOT NULL DEFAULT 0,`next_attempt_at` datetime,`response_status` integer,`last_error` text,`delivered_at` datetime,`created_at` datetime,`updated_at` datetime,CONSTRAINT `fk_webhook_deliveries_webhook` FOREIGN KEY (`webhook_id`) REFERENCES `webhooks`(`id`) ON DELETE CASCADE);
+CREATE INDEX IF NOT EXISTS `idx_webhook_deliveries_webhook_id` ON `webhook_deliveries`(`webhook_id`);
+
+-- Los envíos pendientes se buscan por el próximo intento
+CREATE INDEX IF NOT EXISTS `idx_webhook_deliveries_pending` ON `webhook_deliveries`(`next_attempt_at`) WHERE `status` = 'pending';
@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS `events_calendar_removals`;
+DROP TRIGGER IF EXISTS `calendar_shares_delete_access`;
+DROP TRIGGER IF EXISTS `calendar_shares_insert_access`;
+DROP TABLE IF EXISTS `event_removals`;
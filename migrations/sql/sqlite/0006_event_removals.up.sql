@@ -0,0 +1,36 @@
+-- Bajas de eventos para los usuarios que dejan de verlos sin que se eliminen: cuando se deja de
+-- compartirles el calendario o cuando el evento pasa a un calendario que no comparten. Las apps
+-- las reciben como eliminados en la sincronización incremental. Numeran sus cambios con el
+-- mismo contador que los eventos.
+
+CREATE TABLE `event_removals` (`id` integer PRIMARY KEY AUTOINCREMENT,`user_id` integer NOT NULL,`event_id` integer NOT NULL,`change_seq` integer NOT NULL DEFAULT 0,`removed_at` datetime NOT NULL);
+CREATE INDEX `idx_event_removals_user_change_seq` ON `event_removals` (`user_id`, `change_seq`);
+
+CREATE TRIGGER `event_removals_change_seq` AFTER INSERT ON `event_removals` BEGIN
+	UPDATE `event_change_seq` SET `value` = `value` + 1;
+	UPDATE `event_removals` SET `change_seq` = (SELECT `value` FROM `event_change_seq`) WHERE `id` = NEW.`id`;
+END;
+
+-- Al compartir un calendario sus eventos cambian de número, así el usuario los recibe en su
+-- próxima sincronización aunque su token sea posterior al último cambio de cada uno
+CREATE TRIGGER `calendar_shares_insert_access` AFTER INSERT ON `calendar_shares` BEGIN
+	UPDATE `events` SET `updated_at` = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')
+	WHERE `calendar_id` = NEW.`calendar_id` AND `deleted_at` IS NULL;
+END;
+
+CREATE TRIGGER `calendar_shares_delete_access` AFTER DELETE ON `calendar_shares` BEGIN
+	INSERT INTO `event_removals` (`user_id`, `event_id`, `removed_at`)
+	SELECT OLD.`user_id`, `id`, strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')
+	FROM `events` WHERE `calendar_id` = OLD.`calendar_id` AND `deleted_at` IS NULL;
+END;
+
+-- El dueño de los dos calendarios es el mismo: solo pierden el evento los usuarios con quienes
+-- se compartía el calendario anterior y no se comparte el nuevo
+CREATE TRIGGER `events_calendar_removals` AFTER UPDATE OF `calendar_id` ON `events`
+WHEN NEW.`calendar_id` <> OLD.`calendar_id` AND NEW.`deleted_at` IS NULL BEGIN
+	INSERT INTO `event_removals` (`user_id`, `event_id`, `removed_at`)
+	SELECT `user_id`, NEW.`id`, strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')
+	FROM `calendar_shares`
+	WHERE `calendar_id` = OLD.`calendar_id`
+	AND `user_id` NOT IN (SELECT `user_id` FROM `calendar_shares` WHERE `calendar_id` = NEW.`calendar_id`);
+END;
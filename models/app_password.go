@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// AppPassword es una contraseña de aplicación para clientes que usan HTTP Basic (CalDAV).
+// Solo se muestra al crearla: se guarda su hash SHA-256
+type AppPassword struct {
+	ID           uint       `json:"id" gorm:"primaryKey"`
+	UserID       uint       `json:"user_id" gorm:"index;not null"`
+	Name         string     `json:"name"` // Ej: "iPhone", "Thunderbird"
+	PasswordHash string     `json:"-" gorm:"uniqueIndex;not null"`
+	LastUsedAt   *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
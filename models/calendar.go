@@ -0,0 +1,61 @@
+package models
+
+import "time"
+
+// Roles de un usuario en un calendario, de menor a mayor permiso
+const (
+	CalendarViewer  = "viewer"  // Ve los eventos
+	CalendarEditor  = "editor"  // Además crea, modifica y elimina eventos
+	CalendarManager = "manager" // Además modifica el calendario y con quién se comparte
+	CalendarOwner   = "owner"   // Además elimina el calendario; no se puede asignar
+)
+
+// CalendarShareRoles son los roles con los que se puede compartir un calendario
+var CalendarShareRoles = []string{CalendarViewer, CalendarEditor, CalendarManager}
+
+var calendarRoleRank = map[string]int{
+	CalendarViewer:  1,
+	CalendarEditor:  2,
+	CalendarManager: 3,
+	CalendarOwner:   4,
+}
+
+// RoleAllows indica si el rol tiene al menos los permisos de required
+func RoleAllows(role, required string) bool {
+	rank, ok := calendarRoleRank[role]
+	return ok && rank >= calendarRoleRank[required]
+}
+
+// IsCalendarShareRole indica si se puede compartir un calendario con el rol
+func IsCalendarShareRole(role string) bool {
+	for _, r := range CalendarShareRoles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// Calendar agrupa eventos de un usuario. Todos sus eventos tienen como dueño al del calendario,
+// aunque los cree otro usuario con quien está compartido.
+type Calendar struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	OwnerID   uint      `json:"owner_id" gorm:"index;not null"`
+	Name      string    `json:"name" gorm:"not null"`
+	Color     string    `json:"color"`
+	IsDefault bool      `json:"is_default" gorm:"not null;default:false"` // Donde van los eventos creados sin calendario
+	Role      string    `json:"role,omitempty" gorm:"->;-:migration"`     // Rol del usuario que lo consulta (solo lectura)
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CalendarShare da acceso a un calendario a otro usuario
+type CalendarShare struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	CalendarID uint      `json:"calendar_id" gorm:"index;not null"`
+	UserID     uint      `json:"user_id" gorm:"index;not null"`
+	Role       string    `json:"role" gorm:"not null"`
+	Email      string    `json:"email,omitempty" gorm:"->;-:migration"` // Email del usuario (solo lectura)
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
@@ -7,24 +7,42 @@ import (
 )
 
 type Event struct {
-	ID                uint      `json:"id" gorm:"primaryKey"`
-	Title             string    `json:"title" gorm:"not null"`
-	Description       string    `json:"description"`
-	Date              time.Time `json:"date" gorm:"not null"`
-	Time              string    `json:"time"` // Format: "HH:MM"
-	Location          string    `json:"location"`
-	Email             string    `json:"email" gorm:"not null"`
-	Phone             string    `json:"phone" gorm:"not null"`
-	ReminderDay       bool      `json:"reminder_day" gorm:"default:true"`        // Reminder on the same day
-	ReminderDayBefore bool      `json:"reminder_day_before" gorm:"default:true"` // Reminder one day before
+	ID                uint       `json:"id" gorm:"primaryKey"`
+	OwnerID           uint       `json:"owner_id" gorm:"index"`    // Usuario dueño del evento: el dueño de su calendario
+	CalendarID        uint       `json:"calendar_id" gorm:"index"` // Calendario al que pertenece
+	Title             string     `json:"title" gorm:"not null"`
+	Description       string     `json:"description"`
+	StartsAt          time.Time  `json:"starts_at" gorm:"index"`         // Instante de inicio (UTC)
+	EndsAt            time.Time  `json:"ends_at" gorm:"index"`           // Instante de fin (UTC, exclusivo)
+	Date              time.Time  `json:"date" gorm:"not null"`           // Legacy: fecha de inicio, derivada de StartsAt
+	Time              string     `json:"time"`                           // Legacy: "HH:MM", derivada de StartsAt
+	TimeZone          string     `json:"time_zone" gorm:"default:'UTC'"` // Zona IANA en la que se interpretan fecha y hora
+	Location          string     `json:"location"`
+	Email             string     `json:"email" gorm:"not null"`
+	Phone             string     `json:"phone" gorm:"not null"`
+	ReminderDay       bool       `json:"reminder_day" gorm:"default:true"`             // Legacy: hay un recordatorio el mismo día, derivado de Reminders
+	ReminderDayBefore bool       `json:"reminder_day_before" gorm:"default:true"`      // Legacy: hay un recordatorio un día anterior, derivado de Reminders
+	Reminders         []Reminder `json:"reminders" gorm:"constraint:OnDelete:CASCADE"` // Recordatorios con anticipación y canal
 	// Campos móviles adicionales
-	IsAllDay  bool           `json:"is_all_day" gorm:"default:false"`  // Evento de todo el día
-	Color     string         `json:"color" gorm:"default:'#007AFF'"`   // Color del evento
-	Priority  string         `json:"priority" gorm:"default:'medium'"` // Prioridad: low, medium, high
-	Category  string         `json:"category"`                         // Categoría del evento
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+	IsAllDay bool   `json:"is_all_day" gorm:"default:false"`  // Evento de todo el día
+	Color    string `json:"color" gorm:"default:'#007AFF'"`   // Color del evento
+	Priority string `json:"priority" gorm:"default:'medium'"` // Prioridad: low, medium, high
+	Category string `json:"category"`                         // Categoría del evento
+	// Campos de recurrencia (RFC 5545)
+	RRule        string         `json:"rrule" gorm:"column:rrule"`            // Ej: "FREQ=WEEKLY;BYDAY=MO,WE"
+	ExDates      string         `json:"exdates" gorm:"type:text"`             // Fechas excluidas "YYYY-MM-DD" separadas por coma
+	RecurrenceID *uint          `json:"recurrence_id,omitempty" gorm:"index"` // Serie de la que se separó esta ocurrencia
+	OriginalDate *time.Time     `json:"original_date,omitempty"`              // Fecha de la ocurrencia reemplazada
+	ExternalUID  string         `json:"external_uid,omitempty" gorm:"index"`  // UID del VEVENT importado de un .ics, para actualizarlo al reimportar
+	ChangeSeq    int64          `json:"-" gorm:"->"`                          // Número del último cambio, lo asigna la base con cada escritura
+	Version      int            `json:"version" gorm:"not null;default:1"`    // Aumenta con cada modificación; las escrituras sobre una versión vieja se rechazan
+	ClientID     string         `json:"client_id,omitempty"`                  // UUID que generó la app al crear el evento sin conexión
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+
+	// En una actualización, quita RRule y ExDates (sus valores vacíos no se aplican)
+	ClearRecurrence bool `json:"-" gorm:"-"`
 }
 
 type CreateEventRequest struct {
@@ -63,42 +81,89 @@ type UpdateEventRequest struct {
 
 // EventResponse es la respuesta optimizada para apps móviles
 type EventResponse struct {
-	ID                uint      `json:"id"`
-	Title             string    `json:"title"`
-	Description       string    `json:"description"`
-	Date              string    `json:"date"` // Format: "2006-01-02"
-	Time              string    `json:"time"`
-	Location          string    `json:"location"`
-	Email             string    `json:"email"`
-	Phone             string    `json:"phone"`
-	ReminderDay       bool      `json:"reminder_day"`
-	ReminderDayBefore bool      `json:"reminder_day_before"`
-	IsAllDay          bool      `json:"is_all_day"`
-	Color             string    `json:"color"`
-	Priority          string    `json:"priority"`
-	Category          string    `json:"category"`
-	CreatedAt         time.Time `json:"created_at"`
-	UpdatedAt         time.Time `json:"updated_at"`
+	ID                uint       `json:"id"`
+	CalendarID        uint       `json:"calendar_id"`
+	Title             string     `json:"title"`
+	Description       string     `json:"description"`
+	StartsAt          time.Time  `json:"starts_at"`
+	EndsAt            time.Time  `json:"ends_at"`
+	DurationMinutes   int        `json:"duration_minutes"`
+	TimeZone          string     `json:"time_zone"`
+	Date              string     `json:"date"` // Legacy, format: "2006-01-02" en la zona del evento
+	Time              string     `json:"time"` // Legacy, format: "HH:MM" en la zona del evento
+	Location          string     `json:"location"`
+	Email             string     `json:"email"`
+	Phone             string     `json:"phone"`
+	ReminderDay       bool       `json:"reminder_day"`
+	ReminderDayBefore bool       `json:"reminder_day_before"`
+	Reminders         []Reminder `json:"reminders"`
+	IsAllDay          bool       `json:"is_all_day"`
+	Color             string     `json:"color"`
+	Priority          string     `json:"priority"`
+	Category          string     `json:"category"`
+	RRule             string     `json:"rrule,omitempty"`
+	ExDates           []string   `json:"exdates,omitempty"`
+	RecurrenceID      *uint      `json:"recurrence_id,omitempty"`
+	ExternalUID       string     `json:"external_uid,omitempty"`
+	IsRecurring       bool       `json:"is_recurring"`
+	Version           int        `json:"version"`
+	ClientID          string     `json:"client_id,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
 }
 
 // ToResponse convierte un Event a EventResponse
 func (e *Event) ToResponse() EventResponse {
+	// Fecha y hora legacy siempre expresadas en la zona del evento
+	legacy := *e
+	legacy.SyncLegacyFields()
+
 	return EventResponse{
 		ID:                e.ID,
+		CalendarID:        e.CalendarID,
 		Title:             e.Title,
 		Description:       e.Description,
-		Date:              e.Date.Format("2006-01-02"),
-		Time:              e.Time,
+		StartsAt:          e.StartsAt,
+		EndsAt:            e.EndsAt,
+		DurationMinutes:   int(e.Duration().Minutes()),
+		TimeZone:          e.Zone().String(),
+		Date:              legacy.Date.Format("2006-01-02"),
+		Time:              legacy.Time,
 		Location:          e.Location,
 		Email:             e.Email,
 		Phone:             e.Phone,
 		ReminderDay:       e.ReminderDay,
 		ReminderDayBefore: e.ReminderDayBefore,
+		Reminders:         e.reminderList(),
 		IsAllDay:          e.IsAllDay,
 		Color:             e.Color,
 		Priority:          e.Priority,
 		Category:          e.Category,
+		RRule:             e.RRule,
+		ExDates:           e.exDateStrings(),
+		RecurrenceID:      e.RecurrenceID,
+		ExternalUID:       e.ExternalUID,
+		IsRecurring:       e.IsRecurring(),
+		Version:           e.Version,
+		ClientID:          e.ClientID,
 		CreatedAt:         e.CreatedAt,
 		UpdatedAt:         e.UpdatedAt,
 	}
 }
+
+func (e *Event) exDateStrings() []string {
+	dates := e.ExDateList()
+	values := make([]string, len(dates))
+	for i, d := range dates {
+		values[i] = d.Format("2006-01-02")
+	}
+	return values
+}
+
+// reminderList devuelve los recordatorios como lista vacía (y no null) si no hay
+func (e *Event) reminderList() []Reminder {
+	if e.Reminders == nil {
+		return []Reminder{}
+	}
+	return e.Reminders
+}
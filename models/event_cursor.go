@@ -0,0 +1,168 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Campos por los que se pueden ordenar los eventos
+const (
+	EventSortDate      = "date"
+	EventSortPriority  = "priority"
+	EventSortCreatedAt = "created_at"
+	EventSortTitle     = "title"
+)
+
+// EventSortFields son los campos de orden aceptados
+var EventSortFields = []string{EventSortDate, EventSortPriority, EventSortCreatedAt, EventSortTitle}
+
+// priorityRanks ordena las prioridades de menor a mayor; las desconocidas van primero
+var priorityRanks = map[string]int{"low": 1, "medium": 2, "high": 3}
+
+// PriorityRank devuelve el orden de la prioridad (0 si no es válida)
+func PriorityRank(priority string) int {
+	return priorityRanks[priority]
+}
+
+// EventCursor es la posición del último evento de una página en un orden: el valor del
+// campo de orden, el inicio y el ID. El inicio desempata las ocurrencias de una serie,
+// que comparten el ID.
+type EventCursor struct {
+	Sort     string    `json:"s"`
+	Desc     bool      `json:"d,omitempty"`
+	Key      string    `json:"k,omitempty"` // Valor del campo de orden; vacío al ordenar por fecha
+	StartsAt time.Time `json:"t"`
+	ID       uint      `json:"i"`
+}
+
+// NewEventCursor devuelve el cursor que apunta al evento en el orden dado
+func NewEventCursor(event *Event, field string, desc bool) EventCursor {
+	cursor := EventCursor{Sort: field, Desc: desc, StartsAt: event.StartsAt, ID: event.ID}
+	switch field {
+	case EventSortPriority:
+		cursor.Key = strconv.Itoa(PriorityRank(event.Priority))
+	case EventSortCreatedAt:
+		cursor.Key = event.CreatedAt.Format(time.RFC3339Nano)
+	case EventSortTitle:
+		cursor.Key = event.Title
+	}
+	return cursor
+}
+
+// Encode devuelve el cursor como texto opaco para la API
+func (c EventCursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// ParseEventCursor decodifica un cursor generado por Encode
+func ParseEventCursor(value string) (*EventCursor, error) {
+	invalid := errors.New("invalid cursor")
+
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, invalid
+	}
+	var cursor EventCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID == 0 {
+		return nil, invalid
+	}
+	if _, err := cursor.SortValue(); err != nil {
+		return nil, invalid
+	}
+	return &cursor, nil
+}
+
+// SortValue devuelve el valor del campo de orden del cursor con su tipo: el orden de la
+// prioridad, la fecha de creación o el título (nil al ordenar por fecha)
+func (c *EventCursor) SortValue() (interface{}, error) {
+	switch c.Sort {
+	case EventSortDate:
+		return nil, nil
+	case EventSortPriority:
+		return strconv.Atoi(c.Key)
+	case EventSortCreatedAt:
+		return time.Parse(time.RFC3339Nano, c.Key)
+	case EventSortTitle:
+		return c.Key, nil
+	}
+	return nil, errors.New("unknown sort field")
+}
+
+// Precedes indica si el evento va después del cursor en su orden
+func (c *EventCursor) Precedes(event *Event) bool {
+	value, _ := c.SortValue()
+	cmp := positionOf(event, c.Sort).compare(eventPosition{value: value, startsAt: c.StartsAt, id: c.ID})
+	if c.Desc {
+		return cmp < 0
+	}
+	return cmp > 0
+}
+
+// SortEvents ordena los eventos por el campo dado y, a igual valor, por inicio e ID
+func SortEvents(events []Event, field string, desc bool) {
+	sort.SliceStable(events, func(i, j int) bool {
+		cmp := positionOf(&events[i], field).compare(positionOf(&events[j], field))
+		if desc {
+			return cmp > 0
+		}
+		return cmp < 0
+	})
+}
+
+// eventPosition es la posición de un evento en un orden
+type eventPosition struct {
+	value    interface{}
+	startsAt time.Time
+	id       uint
+}
+
+func positionOf(event *Event, field string) eventPosition {
+	position := eventPosition{startsAt: event.StartsAt, id: event.ID}
+	switch field {
+	case EventSortPriority:
+		position.value = PriorityRank(event.Priority)
+	case EventSortCreatedAt:
+		position.value = event.CreatedAt
+	case EventSortTitle:
+		position.value = event.Title
+	}
+	return position
+}
+
+// compare compara dos posiciones en orden ascendente; los títulos sin distinguir mayúsculas
+func (p eventPosition) compare(other eventPosition) int {
+	switch value := p.value.(type) {
+	case int:
+		if cmp := compareInts(value, other.value.(int)); cmp != 0 {
+			return cmp
+		}
+	case time.Time:
+		if cmp := value.Compare(other.value.(time.Time)); cmp != 0 {
+			return cmp
+		}
+	case string:
+		if cmp := strings.Compare(strings.ToLower(value), strings.ToLower(other.value.(string))); cmp != 0 {
+			return cmp
+		}
+	}
+	if cmp := p.startsAt.Compare(other.startsAt); cmp != 0 {
+		return cmp
+	}
+	return compareInts(int(p.id), int(other.id))
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,102 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEventCursorEncodeParse(t *testing.T) {
+	event := &Event{
+		ID:        42,
+		Title:     "Reunión de equipo",
+		Priority:  "high",
+		StartsAt:  time.Date(2026, 7, 1, 9, 30, 0, 0, time.UTC),
+		CreatedAt: time.Date(2026, 6, 1, 12, 0, 0, 123456789, time.UTC),
+	}
+	tests := []struct {
+		field   string
+		desc    bool
+		wantKey interface{}
+	}{
+		{EventSortDate, false, nil},
+		{EventSortDate, true, nil},
+		{EventSortPriority, false, 3},
+		{EventSortCreatedAt, true, event.CreatedAt},
+		{EventSortTitle, false, event.Title},
+	}
+	for _, tt := range tests {
+		cursor := NewEventCursor(event, tt.field, tt.desc)
+		encoded := cursor.Encode()
+		// El mismo evento en el mismo orden da siempre el mismo cursor
+		if again := NewEventCursor(event, tt.field, tt.desc).Encode(); again != encoded {
+			t.Errorf("%s: cursor %q, then %q", tt.field, encoded, again)
+		}
+
+		parsed, err := ParseEventCursor(encoded)
+		if err != nil {
+			t.Fatalf("%s: parse %q: %v", tt.field, encoded, err)
+		}
+		if parsed.Sort != tt.field || parsed.Desc != tt.desc || parsed.ID != event.ID || !parsed.StartsAt.Equal(event.StartsAt) {
+			t.Errorf("%s: parsed %+v, want the position of event %d", tt.field, parsed, event.ID)
+		}
+		value, err := parsed.SortValue()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want, ok := tt.wantKey.(time.Time); ok {
+			if got, _ := value.(time.Time); !got.Equal(want) {
+				t.Errorf("%s: sort value %v, want %v", tt.field, value, want)
+			}
+		} else if value != tt.wantKey {
+			t.Errorf("%s: sort value %v, want %v", tt.field, value, tt.wantKey)
+		}
+		if parsed.Encode() != encoded {
+			t.Errorf("%s: re-encoded cursor %q, want %q", tt.field, parsed.Encode(), encoded)
+		}
+	}
+}
+
+func TestParseEventCursorRejectsInvalid(t *testing.T) {
+	tests := map[string]string{
+		"not base64":       "%%%",
+		"not json":         "bm90IGpzb24",
+		"without id":       EventCursor{Sort: EventSortDate}.Encode(),
+		"unknown sort":     EventCursor{Sort: "color", ID: 1}.Encode(),
+		"invalid priority": EventCursor{Sort: EventSortPriority, Key: "high", ID: 1}.Encode(),
+		"invalid created":  EventCursor{Sort: EventSortCreatedAt, Key: "yesterday", ID: 1}.Encode(),
+	}
+	for name, value := range tests {
+		if cursor, err := ParseEventCursor(value); err == nil {
+			t.Errorf("%s: parsed %+v", name, cursor)
+		}
+	}
+}
+
+func TestEventCursorPrecedesFollowsSortEvents(t *testing.T) {
+	start := time.Date(2026, 7, 1, 9, 0, 0, 0, time.UTC)
+	created := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	events := []Event{
+		{ID: 1, Title: "b", Priority: "low", StartsAt: start, CreatedAt: created},
+		{ID: 2, Title: "A", Priority: "high", StartsAt: start, CreatedAt: created.Add(time.Hour)},
+		{ID: 3, Title: "a", Priority: "high", StartsAt: start.Add(-time.Hour), CreatedAt: created},
+		// Dos ocurrencias de una serie: mismo ID, distinto inicio
+		{ID: 4, Title: "c", Priority: "medium", StartsAt: start.AddDate(0, 0, 1), CreatedAt: created},
+		{ID: 4, Title: "c", Priority: "medium", StartsAt: start.AddDate(0, 0, 2), CreatedAt: created},
+	}
+
+	for _, field := range EventSortFields {
+		for _, desc := range []bool{false, true} {
+			sorted := append([]Event(nil), events...)
+			SortEvents(sorted, field, desc)
+			// Cada evento va después del cursor de los anteriores y no del de los siguientes
+			for i := range sorted {
+				cursor := NewEventCursor(&sorted[i], field, desc)
+				for j := range sorted {
+					if got := cursor.Precedes(&sorted[j]); got != (j > i) {
+						t.Errorf("sort %s desc %v: cursor of #%d precedes #%d = %v", field, desc, i, j, got)
+					}
+				}
+			}
+		}
+	}
+}
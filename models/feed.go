@@ -0,0 +1,48 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// Feed es una suscripción webcal (/feeds/{token}.ics) al calendario de un usuario.
+// El token solo se muestra al crearla: se guarda su hash SHA-256
+type Feed struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	OwnerID    uint       `json:"owner_id" gorm:"index;not null"` // Usuario cuyo calendario se publica
+	Name       string     `json:"name"`
+	TokenHash  string     `json:"-" gorm:"uniqueIndex;not null"`
+	TokenHint  string     `json:"token_hint"`                        // Últimos caracteres del token, para reconocerlo
+	Categories string     `json:"categories"`                        // Categorías incluidas separadas por coma (vacío = todas)
+	Priorities string     `json:"priorities"`                        // Prioridades incluidas separadas por coma (vacío = todas)
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`            // Última descarga del feed
+	RevokedAt  *time.Time `json:"revoked_at,omitempty" gorm:"index"` // Las suscripciones revocadas dejan de responder
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// CategoryList devuelve las categorías del filtro
+func (f *Feed) CategoryList() []string {
+	return splitList(f.Categories)
+}
+
+// PriorityList devuelve las prioridades del filtro
+func (f *Feed) PriorityList() []string {
+	return splitList(f.Priorities)
+}
+
+// IsRevoked indica si la suscripción fue revocada
+func (f *Feed) IsRevoked() bool {
+	return f.RevokedAt != nil
+}
+
+// splitList separa una lista separada por comas descartando los elementos vacíos
+func splitList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
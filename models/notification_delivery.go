@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// NotificationDelivery es el envío de una notificación por un canal que falló en el primer
+// intento: guarda el mensaje para reintentarlo y el resultado del último intento. Los estados
+// son los de los envíos a webhooks; uno fallido ya no se reintenta salvo que se reenvíe.
+type NotificationDelivery struct {
+	ID                uint       `json:"id" gorm:"primaryKey"`
+	OwnerID           uint       `json:"owner_id" gorm:"index;not null"`
+	EventID           uint       `json:"event_id" gorm:"not null"`
+	Channel           string     `json:"channel" gorm:"not null"`
+	Email             string     `json:"email,omitempty"`
+	Phone             string     `json:"phone,omitempty"`
+	Subject           string     `json:"subject,omitempty"`
+	Body              string     `json:"-" gorm:"type:text"`
+	Text              string     `json:"text,omitempty" gorm:"type:text"`
+	Status            string     `json:"status" gorm:"not null"`
+	Attempts          int        `json:"attempts" gorm:"not null;default:0"`
+	NextAttemptAt     *time.Time `json:"next_attempt_at,omitempty"` // Solo en los envíos pendientes
+	LastError         string     `json:"last_error,omitempty"`
+	ProviderMessageID string     `json:"provider_message_id,omitempty"` // ID del mensaje en el proveedor, si lo informa
+	DeliveredAt       *time.Time `json:"delivered_at,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
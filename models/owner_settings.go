@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// OwnerSettings guarda las preferencias del dueño de los eventos (identificado por email)
+type OwnerSettings struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Email     string    `json:"email" gorm:"uniqueIndex;not null"`
+	TimeZone  string    `json:"time_zone" gorm:"not null"` // Zona IANA por defecto para sus eventos
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -0,0 +1,507 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Frecuencias soportadas del RFC 5545
+const (
+	FreqDaily   = "DAILY"
+	FreqWeekly  = "WEEKLY"
+	FreqMonthly = "MONTHLY"
+	FreqYearly  = "YEARLY"
+)
+
+// Alcances de edición para eventos recurrentes
+const (
+	ScopeThis      = "this"
+	ScopeFollowing = "following"
+	ScopeAll       = "all"
+)
+
+// maxOccurrences limita las ocurrencias que devuelve una expansión
+const maxOccurrences = 1000
+
+var weekdayCodes = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// ByDay representa un valor de BYDAY, p.ej. "MO" o "-1FR"
+type ByDay struct {
+	Ordinal int // 0 significa "todos" dentro del período
+	Weekday time.Weekday
+}
+
+// RecurrenceRule es la representación parseada de un RRULE
+type RecurrenceRule struct {
+	Freq     string
+	Interval int
+	ByDay    []ByDay
+	Until    *time.Time
+	Count    int
+}
+
+// ParseRRule parsea un RRULE como "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE;UNTIL=20250630"
+func ParseRRule(value string) (*RecurrenceRule, error) {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "RRULE:")
+	if value == "" {
+		return nil, errors.New("rrule is empty")
+	}
+
+	rule := &RecurrenceRule{Interval: 1}
+	for _, part := range strings.Split(value, ";") {
+		if part == "" {
+			continue
+		}
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid rrule part %q", part)
+		}
+		key, val := strings.ToUpper(kv[0]), strings.ToUpper(kv[1])
+
+		switch key {
+		case "FREQ":
+			switch val {
+			case FreqDaily, FreqWeekly, FreqMonthly, FreqYearly:
+				rule.Freq = val
+			default:
+				return nil, fmt.Errorf("unsupported FREQ %q", val)
+			}
+		case "INTERVAL":
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 1 {
+				return nil, errors.New("INTERVAL must be a positive integer")
+			}
+			rule.Interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 1 {
+				return nil, errors.New("COUNT must be a positive integer")
+			}
+			rule.Count = n
+		case "UNTIL":
+			until, err := parseRRuleDate(val)
+			if err != nil {
+				return nil, err
+			}
+			rule.Until = &until
+		case "BYDAY":
+			for _, day := range strings.Split(val, ",") {
+				byDay, err := parseByDay(day)
+				if err != nil {
+					return nil, err
+				}
+				rule.ByDay = append(rule.ByDay, byDay)
+			}
+		case "WKST":
+			// Solo se soporta semanas que empiezan el lunes
+		default:
+			return nil, fmt.Errorf("unsupported rrule part %q", key)
+		}
+	}
+
+	if rule.Freq == "" {
+		return nil, errors.New("FREQ is required")
+	}
+	if rule.Count > 0 && rule.Until != nil {
+		return nil, errors.New("COUNT and UNTIL cannot be used together")
+	}
+	// En una regla anual BYDAY expande a todos esos días del año, lo que no se soporta
+	if len(rule.ByDay) > 0 && rule.Freq == FreqYearly {
+		return nil, errors.New("BYDAY is not supported with FREQ=YEARLY")
+	}
+	for _, d := range rule.ByDay {
+		if d.Ordinal != 0 && rule.Freq != FreqMonthly {
+			return nil, errors.New("BYDAY ordinals are only supported with FREQ=MONTHLY")
+		}
+	}
+
+	return rule, nil
+}
+
+func parseByDay(value string) (ByDay, error) {
+	value = strings.TrimSpace(value)
+	if len(value) < 2 {
+		return ByDay{}, fmt.Errorf("invalid BYDAY %q", value)
+	}
+	code := value[len(value)-2:]
+	weekday, ok := weekdayCodes[code]
+	if !ok {
+		return ByDay{}, fmt.Errorf("invalid BYDAY %q", value)
+	}
+	ordinal := 0
+	if prefix := value[:len(value)-2]; prefix != "" {
+		n, err := strconv.Atoi(prefix)
+		if err != nil || n == 0 || n < -5 || n > 5 {
+			return ByDay{}, fmt.Errorf("invalid BYDAY %q", value)
+		}
+		ordinal = n
+	}
+	return ByDay{Ordinal: ordinal, Weekday: weekday}, nil
+}
+
+func parseRRuleDate(value string) (time.Time, error) {
+	for _, layout := range []string{"20060102T150405Z", "20060102T150405", "20060102"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return truncateDay(t), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid UNTIL %q, use YYYYMMDD", value)
+}
+
+// String serializa la regla en formato RFC 5545
+func (r *RecurrenceRule) String() string {
+	parts := []string{"FREQ=" + r.Freq}
+	if r.Interval > 1 {
+		parts = append(parts, fmt.Sprintf("INTERVAL=%d", r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, len(r.ByDay))
+		for i, d := range r.ByDay {
+			days[i] = d.String()
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if r.Count > 0 {
+		parts = append(parts, fmt.Sprintf("COUNT=%d", r.Count))
+	}
+	if r.Until != nil {
+		parts = append(parts, "UNTIL="+r.Until.Format("20060102"))
+	}
+	return strings.Join(parts, ";")
+}
+
+func (d ByDay) String() string {
+	for code, weekday := range weekdayCodes {
+		if weekday == d.Weekday {
+			if d.Ordinal != 0 {
+				return strconv.Itoa(d.Ordinal) + code
+			}
+			return code
+		}
+	}
+	return ""
+}
+
+// Dates genera las fechas de ocurrencia entre from y to (inclusive). COUNT y UNTIL se cuentan
+// desde dtstart; maxOccurrences limita solo las fechas devueltas.
+func (r *RecurrenceRule) Dates(dtstart, from, to time.Time) []time.Time {
+	dtstart = truncateDay(dtstart)
+	from = truncateDay(from)
+	to = truncateDay(to)
+
+	var dates []time.Time
+	counted := 0
+	emit := func(d time.Time) bool {
+		if d.Before(dtstart) {
+			return true
+		}
+		if r.Until != nil && d.After(*r.Until) {
+			return false
+		}
+		if r.Count > 0 && counted >= r.Count {
+			return false
+		}
+		if d.After(to) || len(dates) >= maxOccurrences {
+			return false
+		}
+		counted++
+		if !d.Before(from) {
+			dates = append(dates, d)
+		}
+		return true
+	}
+
+	// Sin COUNT no hace falta contar desde dtstart: se empieza por el período que contiene from
+	first := 0
+	if r.Count == 0 {
+		first = r.periodContaining(dtstart, from)
+	}
+	for period := first; !r.periodStart(dtstart, period).After(to); period++ {
+		for _, d := range r.periodDates(dtstart, period) {
+			if !emit(d) {
+				return dates
+			}
+		}
+	}
+	return dates
+}
+
+// periodStart devuelve el primer día del n-ésimo período de la regla
+func (r *RecurrenceRule) periodStart(dtstart time.Time, n int) time.Time {
+	step := n * r.Interval
+	switch r.Freq {
+	case FreqWeekly:
+		return mondayOf(dtstart).AddDate(0, 0, 7*step)
+	case FreqMonthly:
+		return time.Date(dtstart.Year(), dtstart.Month()+time.Month(step), 1, 0, 0, 0, 0, time.UTC)
+	case FreqYearly:
+		return time.Date(dtstart.Year()+step, time.January, 1, 0, 0, 0, 0, time.UTC)
+	}
+	return dtstart.AddDate(0, 0, step)
+}
+
+// periodContaining devuelve el período de la regla que contiene day (0 si day es anterior a dtstart)
+func (r *RecurrenceRule) periodContaining(dtstart, day time.Time) int {
+	if !day.After(dtstart) {
+		return 0
+	}
+	var units int
+	switch r.Freq {
+	case FreqDaily:
+		units = int(day.Sub(dtstart).Hours() / 24)
+	case FreqWeekly:
+		units = int(day.Sub(mondayOf(dtstart)).Hours()/24) / 7
+	case FreqMonthly:
+		units = (day.Year()-dtstart.Year())*12 + int(day.Month()-dtstart.Month())
+	case FreqYearly:
+		units = day.Year() - dtstart.Year()
+	}
+	return units / r.Interval
+}
+
+// periodDates devuelve las fechas candidatas (ordenadas) del n-ésimo período de la regla; un
+// período sin fechas (ej: un mes sin quinto viernes) devuelve una lista vacía
+func (r *RecurrenceRule) periodDates(dtstart time.Time, n int) []time.Time {
+	step := n * r.Interval
+	switch r.Freq {
+	case FreqDaily:
+		// En una regla diaria BYDAY filtra los días: FREQ=DAILY;BYDAY=MO,TU,WE,TH,FR son los días hábiles
+		d := dtstart.AddDate(0, 0, step)
+		if !r.onByDay(d) {
+			return []time.Time{}
+		}
+		return []time.Time{d}
+	case FreqWeekly:
+		if len(r.ByDay) == 0 {
+			return []time.Time{dtstart.AddDate(0, 0, 7*step)}
+		}
+		monday := r.periodStart(dtstart, n)
+		dates := []time.Time{}
+		for _, d := range r.ByDay {
+			dates = append(dates, monday.AddDate(0, 0, (int(d.Weekday)+6)%7))
+		}
+		sortDates(dates)
+		return dates
+	case FreqMonthly:
+		first := r.periodStart(dtstart, n)
+		if len(r.ByDay) == 0 {
+			d := first.AddDate(0, 0, dtstart.Day()-1)
+			if d.Month() != first.Month() {
+				return []time.Time{}
+			}
+			return []time.Time{d}
+		}
+		dates := []time.Time{}
+		for _, d := range r.ByDay {
+			dates = append(dates, weekdaysInMonth(first, d)...)
+		}
+		sortDates(dates)
+		return dates
+	case FreqYearly:
+		d := time.Date(dtstart.Year()+step, dtstart.Month(), dtstart.Day(), 0, 0, 0, 0, time.UTC)
+		if d.Month() != dtstart.Month() {
+			return []time.Time{}
+		}
+		return []time.Time{d}
+	}
+	return []time.Time{}
+}
+
+// onByDay indica si el día cae en alguno de los días de la semana de BYDAY (siempre, sin BYDAY)
+func (r *RecurrenceRule) onByDay(day time.Time) bool {
+	if len(r.ByDay) == 0 {
+		return true
+	}
+	for _, d := range r.ByDay {
+		if d.Weekday == day.Weekday() {
+			return true
+		}
+	}
+	return false
+}
+
+// mondayOf devuelve el lunes de la semana del día
+func mondayOf(day time.Time) time.Time {
+	offset := (int(day.Weekday()) + 6) % 7 // lunes = 0
+	return day.AddDate(0, 0, -offset)
+}
+
+// weekdaysInMonth resuelve un BYDAY (con ordinal opcional) dentro del mes que empieza en first
+func weekdaysInMonth(first time.Time, byDay ByDay) []time.Time {
+	var all []time.Time
+	for d := first; d.Month() == first.Month(); d = d.AddDate(0, 0, 1) {
+		if d.Weekday() == byDay.Weekday {
+			all = append(all, d)
+		}
+	}
+	switch {
+	case byDay.Ordinal == 0:
+		return all
+	case byDay.Ordinal > 0 && byDay.Ordinal <= len(all):
+		return []time.Time{all[byDay.Ordinal-1]}
+	case byDay.Ordinal < 0 && -byDay.Ordinal <= len(all):
+		return []time.Time{all[len(all)+byDay.Ordinal]}
+	}
+	return nil
+}
+
+func sortDates(dates []time.Time) {
+	sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
+}
+
+func truncateDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// IsRecurring indica si el evento tiene una regla de recurrencia
+func (e *Event) IsRecurring() bool {
+	return e.RRule != ""
+}
+
+// ExDateList devuelve las excepciones (EXDATE) del evento
+func (e *Event) ExDateList() []time.Time {
+	var dates []time.Time
+	for _, value := range strings.Split(e.ExDates, ",") {
+		if d, err := time.Parse("2006-01-02", strings.TrimSpace(value)); err == nil {
+			dates = append(dates, d)
+		}
+	}
+	return dates
+}
+
+// AddExDate agrega una fecha a las excepciones si no estaba ya
+func (e *Event) AddExDate(date time.Time) {
+	value := date.Format("2006-01-02")
+	for _, existing := range strings.Split(e.ExDates, ",") {
+		if existing == value {
+			return
+		}
+	}
+	if e.ExDates == "" {
+		e.ExDates = value
+	} else {
+		e.ExDates += "," + value
+	}
+}
+
+// OccurrenceOn devuelve la ocurrencia de la serie que empieza en la fecha dada (en la zona del evento)
+func (e *Event) OccurrenceOn(date time.Time) (Event, bool) {
+	loc := e.Zone()
+	for _, occurrence := range e.Occurrences(date, date) {
+		if CalendarDate(occurrence.StartsAt, loc).Equal(truncateDay(date)) {
+			return occurrence, true
+		}
+	}
+	return Event{}, false
+}
+
+// StartsOn indica si el evento empieza en la fecha de calendario dada, en la zona del evento
+func (e *Event) StartsOn(date time.Time) bool {
+	return CalendarDate(e.StartsAt, e.Zone()).Equal(truncateDay(date))
+}
+
+// HasOccurrenceOn indica si la serie produce una ocurrencia que empieza en la fecha dada
+func (e *Event) HasOccurrenceOn(date time.Time) bool {
+	_, ok := e.OccurrenceOn(date)
+	return ok
+}
+
+// Occurrences expande el evento en las ocurrencias que se superponen con los días
+// entre start y end (inclusive), interpretados en la zona del evento.
+// Un evento no recurrente devuelve solo a sí mismo.
+func (e *Event) Occurrences(start, end time.Time) []Event {
+	loc := e.Zone()
+	windowStart, windowEnd := DayWindow(start, end, loc)
+
+	event := *e
+	event.ApplyLegacySchedule()
+
+	if !event.IsRecurring() {
+		if !event.Overlaps(windowStart, windowEnd) {
+			return nil
+		}
+		return []Event{event}
+	}
+
+	rule, err := ParseRRule(event.RRule)
+	if err != nil {
+		return nil
+	}
+
+	excluded := make(map[string]bool)
+	for _, d := range event.ExDateList() {
+		excluded[d.Format("2006-01-02")] = true
+	}
+
+	// Las ocurrencias que empiezan antes del rango pueden durar hasta entrar en él
+	firstDay := CalendarDate(event.StartsAt, loc)
+	spanDays := int(event.Duration()/(24*time.Hour)) + 1
+	var occurrences []Event
+	for _, d := range rule.Dates(firstDay, truncateDay(start).AddDate(0, 0, -spanDays), end) {
+		if excluded[d.Format("2006-01-02")] {
+			continue
+		}
+		occurrence := event.occurrenceOn(firstDay, d, loc)
+		if occurrence.Overlaps(windowStart, windowEnd) {
+			occurrences = append(occurrences, occurrence)
+		}
+	}
+	return occurrences
+}
+
+// occurrenceOn devuelve una copia del evento desplazada al día de la ocurrencia,
+// conservando la hora local de inicio (también a través de cambios de horario) y la duración
+func (e Event) occurrenceOn(firstDay, day time.Time, loc *time.Location) Event {
+	days := int(day.Sub(firstDay).Hours() / 24)
+	duration := e.Duration()
+	e.StartsAt = e.StartsAt.In(loc).AddDate(0, 0, days).UTC()
+	if e.IsAllDay {
+		spanDays := int((duration + 12*time.Hour) / (24 * time.Hour))
+		e.EndsAt = StartOfDay(day.AddDate(0, 0, spanDays), loc)
+	} else {
+		e.EndsAt = e.StartsAt.Add(duration)
+	}
+	e.SyncLegacyFields()
+	return e
+}
+
+// ExpandOccurrences expande una lista de eventos en sus ocurrencias dentro del rango
+// (días interpretados en la zona de cada evento), ordenadas por inicio
+func ExpandOccurrences(events []Event, start, end time.Time) []Event {
+	var expanded []Event
+	for i := range events {
+		expanded = append(expanded, events[i].Occurrences(start, end)...)
+	}
+	sortByStart(expanded)
+	return expanded
+}
+
+// ExpandToday expande una lista de eventos en sus ocurrencias de "hoy",
+// calculando el día actual en la zona de cada evento
+func ExpandToday(events []Event, now time.Time) []Event {
+	var expanded []Event
+	for i := range events {
+		today := events[i].Today(now)
+		expanded = append(expanded, events[i].Occurrences(today, today)...)
+	}
+	sortByStart(expanded)
+	return expanded
+}
+
+func sortByStart(expanded []Event) {
+	sort.SliceStable(expanded, func(i, j int) bool {
+		return expanded[i].StartsAt.Before(expanded[j].StartsAt)
+	})
+}
@@ -0,0 +1,181 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func day(value string) time.Time {
+	d, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func formatDates(dates []time.Time) []string {
+	values := make([]string, len(dates))
+	for i, d := range dates {
+		values[i] = d.Format("2006-01-02")
+	}
+	return values
+}
+
+func TestRecurrenceRuleDates(t *testing.T) {
+	tests := []struct {
+		name     string
+		rrule    string
+		dtstart  string
+		from, to string
+		want     []string
+	}{
+		{
+			name:    "daily series years after its start",
+			rrule:   "FREQ=DAILY",
+			dtstart: "2022-01-01",
+			from:    "2026-10-01", to: "2026-10-03",
+			want: []string{"2026-10-01", "2026-10-02", "2026-10-03"},
+		},
+		{
+			name:    "weekly series years after its start",
+			rrule:   "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE",
+			dtstart: "2020-01-06",
+			from:    "2026-10-01", to: "2026-10-31",
+			want: []string{"2026-10-05", "2026-10-07", "2026-10-19", "2026-10-21"},
+		},
+		{
+			name:    "fifth friday skips months without one",
+			rrule:   "FREQ=MONTHLY;BYDAY=5FR",
+			dtstart: "2026-01-30",
+			from:    "2026-01-01", to: "2026-12-31",
+			want: []string{"2026-01-30", "2026-05-29", "2026-07-31", "2026-10-30"},
+		},
+		{
+			name:    "last weekday of the month",
+			rrule:   "FREQ=MONTHLY;BYDAY=-1MO",
+			dtstart: "2026-01-26",
+			from:    "2026-01-01", to: "2026-03-31",
+			want: []string{"2026-01-26", "2026-02-23", "2026-03-30"},
+		},
+		{
+			name:    "monthly on the 31st skips short months",
+			rrule:   "FREQ=MONTHLY",
+			dtstart: "2026-01-31",
+			from:    "2026-01-01", to: "2026-05-31",
+			want: []string{"2026-01-31", "2026-03-31", "2026-05-31"},
+		},
+		{
+			name:    "count is taken from the start of the series",
+			rrule:   "FREQ=DAILY;COUNT=5",
+			dtstart: "2026-01-01",
+			from:    "2026-01-04", to: "2026-01-31",
+			want: []string{"2026-01-04", "2026-01-05"},
+		},
+		{
+			name:    "until is inclusive",
+			rrule:   "FREQ=WEEKLY;UNTIL=20260115",
+			dtstart: "2026-01-01",
+			from:    "2026-01-01", to: "2026-12-31",
+			want: []string{"2026-01-01", "2026-01-08", "2026-01-15"},
+		},
+		{
+			name:    "yearly on february 29th",
+			rrule:   "FREQ=YEARLY",
+			dtstart: "2024-02-29",
+			from:    "2024-01-01", to: "2032-12-31",
+			want: []string{"2024-02-29", "2028-02-29", "2032-02-29"},
+		},
+		{
+			name:    "daily on weekdays skips weekends",
+			rrule:   "FREQ=DAILY;BYDAY=MO,TU,WE,TH,FR",
+			dtstart: "2026-10-01",
+			from:    "2026-10-01", to: "2026-10-07",
+			want: []string{"2026-10-01", "2026-10-02", "2026-10-05", "2026-10-06", "2026-10-07"},
+		},
+		{
+			name:    "count of a daily series counts only the matching weekdays",
+			rrule:   "FREQ=DAILY;BYDAY=SA,SU;COUNT=3",
+			dtstart: "2026-10-01",
+			from:    "2026-10-01", to: "2026-10-31",
+			want: []string{"2026-10-03", "2026-10-04", "2026-10-10"},
+		},
+		{
+			name:    "window before the start of the series",
+			rrule:   "FREQ=DAILY",
+			dtstart: "2026-03-01",
+			from:    "2026-02-01", to: "2026-03-02",
+			want: []string{"2026-03-01", "2026-03-02"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := ParseRRule(tt.rrule)
+			if err != nil {
+				t.Fatalf("ParseRRule(%q): %v", tt.rrule, err)
+			}
+			got := formatDates(rule.Dates(day(tt.dtstart), day(tt.from), day(tt.to)))
+			if len(got) != len(tt.want) {
+				t.Fatalf("Dates = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("Dates = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestRecurrenceRuleDatesLimitsWindow(t *testing.T) {
+	rule, err := ParseRRule("FREQ=DAILY")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dates := rule.Dates(day("2020-01-01"), day("2026-01-01"), day("2030-12-31"))
+	if len(dates) != maxOccurrences {
+		t.Fatalf("got %d dates, want %d", len(dates), maxOccurrences)
+	}
+	if first := dates[0].Format("2006-01-02"); first != "2026-01-01" {
+		t.Errorf("first date = %s, want 2026-01-01", first)
+	}
+}
+
+func TestOccurrencesOfLongRunningSeries(t *testing.T) {
+	event := Event{
+		Title:    "Standup",
+		StartsAt: time.Date(2022, 1, 1, 9, 0, 0, 0, time.UTC),
+		EndsAt:   time.Date(2022, 1, 1, 9, 15, 0, 0, time.UTC),
+		TimeZone: "UTC",
+		RRule:    "FREQ=DAILY",
+	}
+	occurrences := event.Occurrences(day("2026-10-01"), day("2026-10-31"))
+	if len(occurrences) != 31 {
+		t.Fatalf("got %d occurrences in October 2026, want 31", len(occurrences))
+	}
+	if start := occurrences[0].StartsAt; !start.Equal(time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)) {
+		t.Errorf("first occurrence starts at %s", start)
+	}
+}
+
+func TestOccurrencesIncludeMultiDayOccurrenceStartedBeforeWindow(t *testing.T) {
+	event := Event{
+		Title:    "Conference",
+		StartsAt: time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC),
+		EndsAt:   time.Date(2026, 1, 8, 18, 0, 0, 0, time.UTC),
+		TimeZone: "UTC",
+		RRule:    "FREQ=MONTHLY",
+	}
+	occurrences := event.Occurrences(day("2026-03-07"), day("2026-03-07"))
+	if len(occurrences) != 1 || !occurrences[0].StartsAt.Equal(time.Date(2026, 3, 5, 9, 0, 0, 0, time.UTC)) {
+		t.Fatalf("occurrences = %v, want the one starting 2026-03-05", occurrences)
+	}
+}
+
+func TestParseRRuleRejectsUnsupportedByDay(t *testing.T) {
+	for _, value := range []string{"FREQ=YEARLY;BYDAY=MO", "FREQ=WEEKLY;BYDAY=1MO", "FREQ=DAILY;BYDAY=-1FR"} {
+		if _, err := ParseRRule(value); err == nil {
+			t.Errorf("ParseRRule(%q) succeeded", value)
+		}
+	}
+}
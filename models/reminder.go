@@ -0,0 +1,139 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// MaxRemindersPerEvent limita la cantidad de recordatorios de un evento
+const MaxRemindersPerEvent = 10
+
+// MaxReminderOffsetMinutes es la mayor anticipación permitida: cuatro semanas
+const MaxReminderOffsetMinutes = 4 * 7 * 24 * 60
+
+// Anticipaciones equivalentes a los antiguos flags reminder_day y reminder_day_before
+const (
+	LegacySameDayOffsetMinutes         = 60      // Una hora antes, como el recordatorio "same_day"
+	LegacyDayBeforeOffsetMinutes       = 24 * 60 // Un día antes
+	LegacyAllDayDayBeforeOffsetMinutes = 15 * 60 // 9:00 del día anterior para eventos de todo el día
+)
+
+var reminderChannelPattern = regexp.MustCompile(`^[a-z0-9_-]{0,20}$`)
+
+// Reminder es un recordatorio de un evento, enviado OffsetMinutes antes de su inicio
+type Reminder struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	EventID       uint      `json:"event_id" gorm:"index;not null"`
+	OffsetMinutes int       `json:"offset_minutes" gorm:"not null"` // Minutos antes del inicio (ej: 10, 120, 10080)
+	Channel       string    `json:"channel"`                        // Canal de envío (email, whatsapp); vacío = todos los habilitados
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// Offset devuelve la anticipación del recordatorio
+func (r Reminder) Offset() time.Duration {
+	return time.Duration(r.OffsetMinutes) * time.Minute
+}
+
+// DueAt devuelve el instante en que se envía el recordatorio para un inicio dado
+func (r Reminder) DueAt(startsAt time.Time) time.Time {
+	return startsAt.Add(-r.Offset())
+}
+
+// Validate valida la anticipación y el canal del recordatorio
+func (r Reminder) Validate() error {
+	if r.OffsetMinutes < 0 {
+		return errors.New("reminder offset_minutes cannot be negative")
+	}
+	if r.OffsetMinutes > MaxReminderOffsetMinutes {
+		return fmt.Errorf("reminder offset_minutes must be at most %d (4 weeks)", MaxReminderOffsetMinutes)
+	}
+	if !reminderChannelPattern.MatchString(r.Channel) {
+		return fmt.Errorf("invalid reminder channel %q", r.Channel)
+	}
+	return nil
+}
+
+// NormalizeReminders valida los recordatorios y descarta los repetidos (misma anticipación y canal).
+// Siempre devuelve un slice no nil: una lista vacía significa "sin recordatorios".
+func NormalizeReminders(reminders []Reminder) ([]Reminder, error) {
+	if len(reminders) > MaxRemindersPerEvent {
+		return nil, fmt.Errorf("an event can have at most %d reminders", MaxRemindersPerEvent)
+	}
+
+	normalized := make([]Reminder, 0, len(reminders))
+	seen := make(map[string]bool)
+	for _, reminder := range reminders {
+		if err := reminder.Validate(); err != nil {
+			return nil, err
+		}
+		key := fmt.Sprintf("%d|%s", reminder.OffsetMinutes, reminder.Channel)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		normalized = append(normalized, Reminder{OffsetMinutes: reminder.OffsetMinutes, Channel: reminder.Channel})
+	}
+	return normalized, nil
+}
+
+// CopyReminders copia los recordatorios sin sus IDs, para asignarlos a otro evento
+func CopyReminders(reminders []Reminder) []Reminder {
+	copies := make([]Reminder, len(reminders))
+	for i, reminder := range reminders {
+		copies[i] = Reminder{OffsetMinutes: reminder.OffsetMinutes, Channel: reminder.Channel}
+	}
+	return copies
+}
+
+// LegacyReminders traduce los flags reminder_day y reminder_day_before a recordatorios.
+// Como antes, los eventos de todo el día no tienen recordatorio el mismo día.
+func LegacyReminders(reminderDay, reminderDayBefore, isAllDay bool) []Reminder {
+	reminders := []Reminder{}
+	if reminderDayBefore {
+		offset := LegacyDayBeforeOffsetMinutes
+		if isAllDay {
+			offset = LegacyAllDayDayBeforeOffsetMinutes
+		}
+		reminders = append(reminders, Reminder{OffsetMinutes: offset})
+	}
+	if reminderDay && !isAllDay {
+		reminders = append(reminders, Reminder{OffsetMinutes: LegacySameDayOffsetMinutes})
+	}
+	return reminders
+}
+
+// SyncReminderFlags mantiene ReminderDay y ReminderDayBefore alineados con los recordatorios
+// para los clientes antiguos: el primero indica un recordatorio el mismo día del evento y el
+// segundo uno en un día anterior (en la zona del evento)
+func (e *Event) SyncReminderFlags() {
+	e.ReminderDay = false
+	e.ReminderDayBefore = false
+
+	loc := e.Zone()
+	eventDay := CalendarDate(e.StartsAt, loc)
+	for _, reminder := range e.Reminders {
+		if CalendarDate(reminder.DueAt(e.StartsAt), loc).Equal(eventDay) {
+			e.ReminderDay = true
+		} else {
+			e.ReminderDayBefore = true
+		}
+	}
+}
+
+// ReminderType devuelve el tipo de mensaje que corresponde a un recordatorio enviado en dueAt:
+// "same_day" si cae el mismo día del evento, "day_before" si cae el día anterior y
+// "upcoming" si cae antes (días en la zona del evento)
+func (e *Event) ReminderType(dueAt time.Time) string {
+	loc := e.Zone()
+	eventDay := CalendarDate(e.StartsAt, loc)
+	switch CalendarDate(dueAt, loc) {
+	case eventDay:
+		return "same_day"
+	case eventDay.AddDate(0, 0, -1):
+		return "day_before"
+	}
+	return "upcoming"
+}
@@ -0,0 +1,104 @@
+package models
+
+import (
+	"time"
+)
+
+// DefaultEventDuration es la duración asignada a un evento con hora que no indica fin
+const DefaultEventDuration = time.Hour
+
+// MaxZoneOffset es el mayor desfase posible entre un huso horario y UTC; se usa para
+// ampliar las consultas por fecha antes de filtrar cada evento en su propia zona
+const MaxZoneOffset = 14 * time.Hour
+
+// ComposeInstant combina una fecha y una hora "HH:MM" interpretadas en loc y devuelve el instante UTC.
+// Una hora vacía o inválida se interpreta como el comienzo del día.
+func ComposeInstant(date time.Time, clock string, loc *time.Location) time.Time {
+	hour, minute := 0, 0
+	if t, err := time.Parse("15:04", clock); err == nil {
+		hour, minute = t.Hour(), t.Minute()
+	}
+	return time.Date(date.Year(), date.Month(), date.Day(), hour, minute, 0, 0, loc).UTC()
+}
+
+// CalendarDate devuelve la fecha de calendario de t en loc (como medianoche UTC)
+func CalendarDate(t time.Time, loc *time.Location) time.Time {
+	return truncateDay(t.In(loc))
+}
+
+// StartOfDay devuelve el instante UTC en que empieza, en loc, el día de calendario date
+func StartOfDay(date time.Time, loc *time.Location) time.Time {
+	return ComposeInstant(date, "", loc)
+}
+
+// DefaultEndsAt calcula el fin por defecto de un evento: la medianoche siguiente (en loc)
+// para eventos de todo el día o DefaultEventDuration para eventos con hora
+func DefaultEndsAt(startsAt time.Time, isAllDay bool, loc *time.Location) time.Time {
+	if isAllDay {
+		return StartOfDay(CalendarDate(startsAt, loc).AddDate(0, 0, 1), loc)
+	}
+	return startsAt.Add(DefaultEventDuration)
+}
+
+// ApplyLegacySchedule completa StartsAt/EndsAt a partir de Date y Time
+// cuando el evento todavía no tiene instantes explícitos
+func (e *Event) ApplyLegacySchedule() {
+	loc := e.Zone()
+	if e.StartsAt.IsZero() && !e.Date.IsZero() {
+		e.StartsAt = ComposeInstant(e.Date, e.Time, loc)
+	}
+	if e.EndsAt.IsZero() && !e.StartsAt.IsZero() {
+		e.EndsAt = DefaultEndsAt(e.StartsAt, e.IsAllDay, loc)
+	}
+}
+
+// SyncLegacyFields mantiene Date y Time (en la zona del evento) alineados con StartsAt
+// para los clientes antiguos
+func (e *Event) SyncLegacyFields() {
+	if e.StartsAt.IsZero() {
+		return
+	}
+	loc := e.Zone()
+	e.Date = CalendarDate(e.StartsAt, loc)
+	if e.IsAllDay {
+		e.Time = ""
+	} else {
+		e.Time = e.StartsAt.In(loc).Format("15:04")
+	}
+}
+
+// Duration devuelve la duración del evento
+func (e *Event) Duration() time.Duration {
+	if e.StartsAt.IsZero() || e.EndsAt.Before(e.StartsAt) {
+		return 0
+	}
+	return e.EndsAt.Sub(e.StartsAt)
+}
+
+// Overlaps indica si el evento se superpone con la ventana [start, end)
+func (e *Event) Overlaps(start, end time.Time) bool {
+	endsAt := e.EndsAt
+	if !endsAt.After(e.StartsAt) {
+		// Un evento sin duración ocupa solo su instante de inicio
+		return !e.StartsAt.Before(start) && e.StartsAt.Before(end)
+	}
+	return e.StartsAt.Before(end) && endsAt.After(start)
+}
+
+// Today devuelve la fecha de calendario actual en la zona del evento
+func (e *Event) Today(now time.Time) time.Time {
+	return CalendarDate(now, e.Zone())
+}
+
+// DayWindow devuelve la ventana [inicio del primer día, inicio del día siguiente al último)
+// con los días interpretados en loc
+func DayWindow(startDate, endDate time.Time, loc *time.Location) (time.Time, time.Time) {
+	return StartOfDay(startDate, loc), StartOfDay(truncateDay(endDate).AddDate(0, 0, 1), loc)
+}
+
+// QueryWindow amplía la ventana de días en MaxZoneOffset para que una consulta SQL
+// incluya los eventos de cualquier zona; el filtro exacto se hace luego por evento
+func QueryWindow(startDate, endDate time.Time) (time.Time, time.Time) {
+	start, end := DayWindow(startDate, endDate, time.UTC)
+	return start.Add(-MaxZoneOffset), end.Add(MaxZoneOffset)
+}
@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+// SyncAccount es un calendario externo (Google Calendar, Microsoft 365, ...) conectado
+// por un usuario, con el estado de su sincronización
+type SyncAccount struct {
+	ID           uint       `json:"id" gorm:"primaryKey"`
+	OwnerID      uint       `json:"owner_id" gorm:"index;not null"`
+	Provider     string     `json:"provider" gorm:"not null"`    // Nombre del proveedor (ej: "google")
+	CalendarID   string     `json:"calendar_id" gorm:"not null"` // Calendario dentro de la cuenta externa
+	Credentials  string     `json:"-" gorm:"type:text"`          // Credenciales opacas para el proveedor
+	SyncToken    string     `json:"-" gorm:"type:text"`          // Token de cambios del proveedor (vacío = sincronizar todo)
+	LastSyncedAt *time.Time `json:"last_synced_at,omitempty"`    // Inicio de la última sincronización completa sin errores
+	LastError    string     `json:"last_error,omitempty"`        // Error de la última sincronización, vacío si terminó bien
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// SyncLink relaciona un evento con su copia en un calendario externo
+type SyncLink struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	AccountID      uint      `json:"account_id" gorm:"uniqueIndex:idx_sync_link_remote;index:idx_sync_link_event;not null"`
+	EventID        uint      `json:"event_id" gorm:"index:idx_sync_link_event;not null"`
+	RemoteID       string    `json:"remote_id" gorm:"uniqueIndex:idx_sync_link_remote;not null"`
+	RemoteVersion  string    `json:"remote_version"`   // Versión del evento externo en la última sincronización
+	LocalUpdatedAt time.Time `json:"local_updated_at"` // updated_at del evento en la última sincronización
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// Acciones registradas en la auditoría de la sincronización
+const (
+	SyncPulledCreate = "pulled_create" // Evento externo nuevo copiado al calendario
+	SyncPulledUpdate = "pulled_update"
+	SyncPulledDelete = "pulled_delete"
+	SyncPushedCreate = "pushed_create" // Evento del calendario copiado al externo
+	SyncPushedUpdate = "pushed_update"
+	SyncPushedDelete = "pushed_delete"
+	SyncFailed       = "failed" // Cambio que no pudo aplicarse
+)
+
+// SyncAudit registra cada cambio aplicado por la sincronización. Los conflictos (el evento
+// cambió de los dos lados) se resuelven a favor del último en modificarse y quedan marcados.
+type SyncAudit struct {
+	ID              uint       `json:"id" gorm:"primaryKey"`
+	AccountID       uint       `json:"account_id" gorm:"index;not null"`
+	EventID         uint       `json:"event_id,omitempty"`
+	RemoteID        string     `json:"remote_id,omitempty"`
+	Action          string     `json:"action"`
+	Conflict        bool       `json:"conflict"`
+	Winner          string     `json:"winner,omitempty"` // "local" o "remote" en los conflictos
+	LocalUpdatedAt  *time.Time `json:"local_updated_at,omitempty"`
+	RemoteUpdatedAt *time.Time `json:"remote_updated_at,omitempty"`
+	Detail          string     `json:"detail,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
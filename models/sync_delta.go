@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// Tombstone informa a las apps que un evento se eliminó
+type Tombstone struct {
+	ID        uint      `json:"id"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// SyncDelta son los cambios de los eventos de un usuario posteriores a un token de sincronización
+type SyncDelta struct {
+	Events    []EventResponse `json:"events"`     // Creados o modificados
+	Deleted   []Tombstone     `json:"deleted"`    // Eliminados
+	SyncToken string          `json:"sync_token"` // Token para pedir los cambios siguientes
+	HasMore   bool            `json:"has_more"`   // Quedan cambios: pedirlos ya con el token nuevo
+	FullSync  bool            `json:"full_sync"`  // Son todos los eventos: reemplazan a los guardados en la app
+}
+
+// EventRemoval registra que un usuario dejó de ver un evento sin que se eliminara (ej: le
+// dejaron de compartir el calendario). Las apps la reciben como la baja del evento.
+type EventRemoval struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"user_id" gorm:"not null"`
+	EventID   uint      `json:"event_id" gorm:"not null"`
+	ChangeSeq int64     `json:"-" gorm:"not null;default:0"`
+	RemovedAt time.Time `json:"removed_at" gorm:"not null"`
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	// Incluir la base de datos IANA para no depender de la del sistema
+	_ "time/tzdata"
+)
+
+var locationCache sync.Map
+
+// LoadLocation carga una zona IANA, con caché. Una zona vacía es UTC.
+func LoadLocation(name string) (*time.Location, error) {
+	if name == "" || name == "UTC" {
+		return time.UTC, nil
+	}
+	if loc, ok := locationCache.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, errors.New("invalid time zone " + name + ", use an IANA name like America/Argentina/Buenos_Aires")
+	}
+	locationCache.Store(name, loc)
+	return loc, nil
+}
+
+// Zone devuelve la zona horaria del evento, o UTC si no tiene una válida
+func (e *Event) Zone() *time.Location {
+	loc, err := LoadLocation(e.TimeZone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
@@ -0,0 +1,77 @@
+package models
+
+import "time"
+
+// Tipos de aviso que recibe un webhook
+const (
+	WebhookEventCreated  = "event.created"
+	WebhookEventUpdated  = "event.updated"
+	WebhookEventDeleted  = "event.deleted"
+	WebhookReminderFired = "reminder.fired"
+	// WebhookPing es el aviso de prueba: lo reciben todos los webhooks, sin importar sus tipos
+	WebhookPing = "ping"
+)
+
+// WebhookEventTypes son los tipos a los que se puede suscribir un webhook
+var WebhookEventTypes = []string{WebhookEventCreated, WebhookEventUpdated, WebhookEventDeleted, WebhookReminderFired}
+
+// Estados de un envío
+const (
+	DeliveryPending   = "pending"   // Esperando el primer intento o un reintento
+	DeliverySucceeded = "succeeded" // El receptor respondió 2xx
+	DeliveryFailed    = "failed"    // Se agotaron los intentos o el error no se soluciona reintentando
+)
+
+// Webhook es una URL a la que se envían los cambios del calendario de un usuario,
+// firmados con el secreto del webhook
+type Webhook struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	OwnerID     uint       `json:"owner_id" gorm:"index;not null"`
+	URL         string     `json:"url" gorm:"not null"`
+	Secret      string     `json:"-" gorm:"not null"` // Clave HMAC de las firmas; solo se muestra al crearlo
+	Description string     `json:"description"`
+	EventTypes  string     `json:"event_types"`           // Tipos suscritos separados por coma (vacío = todos)
+	DisabledAt  *time.Time `json:"disabled_at,omitempty"` // Los webhooks desactivados no reciben avisos
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// EventTypeList devuelve los tipos suscritos
+func (w *Webhook) EventTypeList() []string {
+	return splitList(w.EventTypes)
+}
+
+// Subscribes indica si el webhook recibe los avisos del tipo
+func (w *Webhook) Subscribes(eventType string) bool {
+	types := w.EventTypeList()
+	if len(types) == 0 || eventType == WebhookPing {
+		return true
+	}
+	for _, t := range types {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// IsDisabled indica si el webhook fue desactivado
+func (w *Webhook) IsDisabled() bool {
+	return w.DisabledAt != nil
+}
+
+// WebhookDelivery es el envío de un aviso a un webhook, con el resultado del último intento
+type WebhookDelivery struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	WebhookID      uint       `json:"webhook_id" gorm:"index;not null"`
+	EventType      string     `json:"event_type" gorm:"not null"`
+	Payload        string     `json:"payload" gorm:"type:text;not null"` // Cuerpo JSON que se firma y envía
+	Status         string     `json:"status" gorm:"not null"`
+	Attempts       int        `json:"attempts" gorm:"not null;default:0"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at,omitempty"` // Solo en los envíos pendientes
+	ResponseStatus int        `json:"response_status,omitempty"` // Status HTTP del último intento
+	LastError      string     `json:"last_error,omitempty"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,93 @@
+// Package netguard impide que las URLs que eligen los usuarios (ej: los webhooks) lleguen a
+// la red interna del servidor: loopback, link-local (incluida la metadata de la nube) y las
+// redes privadas.
+package netguard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+	"time"
+)
+
+// ErrForbiddenAddress indica que la dirección es de la red interna del servidor
+var ErrForbiddenAddress = errors.New("loopback, link-local and private addresses are not allowed")
+
+// lookupTimeout limita cuánto se espera la resolución del host al validar una URL
+const lookupTimeout = 5 * time.Second
+
+// blocked son los rangos que no cubren los métodos de net.IP: "esta red", CGNAT,
+// asignaciones de IETF, benchmarking y las IPv6 que traducen a IPv4
+var blocked = mustParseCIDRs(
+	"0.0.0.0/8",
+	"100.64.0.0/10",
+	"192.0.0.0/24",
+	"198.18.0.0/15",
+	"64:ff9b::/96",
+)
+
+// Allowed indica si se puede conectar a la IP: una dirección pública unicast
+func Allowed(ip net.IP) bool {
+	if ip == nil || ip.IsUnspecified() || ip.IsLoopback() || ip.IsPrivate() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return false
+	}
+	for _, network := range blocked {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckHost resuelve el host (nombre o IP) y devuelve ErrForbiddenAddress si alguna de sus
+// direcciones no está permitida
+func CheckHost(ctx context.Context, host string) error {
+	if ip := net.ParseIP(host); ip != nil {
+		if !Allowed(ip) {
+			return ErrForbiddenAddress
+		}
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, lookupTimeout)
+	defer cancel()
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return fmt.Errorf("cannot resolve %s: %w", host, err)
+	}
+	for _, addr := range addrs {
+		if !Allowed(addr.IP) {
+			return ErrForbiddenAddress
+		}
+	}
+	return nil
+}
+
+// Control es el Control de un net.Dialer que rechaza las conexiones a direcciones no
+// permitidas. Se aplica a la IP ya resuelta de cada conexión, así que también frena a un
+// host que después de validado pasa a resolver a la red interna.
+func Control(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if !Allowed(net.ParseIP(host)) {
+		return fmt.Errorf("dial %s: %w", address, ErrForbiddenAddress)
+	}
+	return nil
+}
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
@@ -0,0 +1,51 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrNoAddress indica que el destinatario no tiene dirección para el canal
+// (por ejemplo, un email sin teléfono para WhatsApp); no es un fallo del envío
+var ErrNoAddress = errors.New("recipient has no address for this channel")
+
+// ErrNotConfigured indica que faltan las credenciales del proveedor del canal
+var ErrNotConfigured = errors.New("channel is not configured")
+
+// Recipient es el destinatario de una notificación
+type Recipient struct {
+	Name  string
+	Email string
+	Phone string
+}
+
+// Message es el contenido de una notificación. Los canales de texto corto
+// (WhatsApp, SMS) usan Text; los de correo usan Subject y Body.
+type Message struct {
+	Subject string
+	Body    string
+	Text    string
+}
+
+// Channel es un medio de envío de notificaciones (email, WhatsApp, ...)
+type Channel interface {
+	// Name identifica al canal en la configuración (NOTIFICATION_CHANNELS)
+	Name() string
+	// Address devuelve la dirección del destinatario en este canal, vacía si no tiene
+	Address(recipient Recipient) string
+	// Send envía el mensaje al destinatario; devuelve ErrNoAddress si no puede alcanzarlo por este canal
+	Send(ctx context.Context, recipient Recipient, message Message) error
+}
+
+// ReceiptSender es implementado por los canales que informan el ID del mensaje en el proveedor
+type ReceiptSender interface {
+	SendWithReceipt(ctx context.Context, recipient Recipient, message Message) (string, error)
+}
+
+// Deliver envía el mensaje por el canal y devuelve el ID del proveedor cuando el canal lo informa
+func Deliver(ctx context.Context, channel Channel, recipient Recipient, message Message) (string, error) {
+	if sender, ok := channel.(ReceiptSender); ok {
+		return sender.SendWithReceipt(ctx, recipient, message)
+	}
+	return "", channel.Send(ctx, recipient, message)
+}
@@ -0,0 +1,84 @@
+package notifications
+
+import (
+	"calendar-backend/config"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/twilio/twilio-go"
+	"github.com/twilio/twilio-go/client"
+	twilioApi "github.com/twilio/twilio-go/rest/api/v2010"
+)
+
+// TwilioWhatsAppChannel envía notificaciones por WhatsApp usando Twilio
+type TwilioWhatsAppChannel struct {
+	client *twilio.RestClient
+	from   string
+}
+
+// NewTwilioWhatsAppChannel crea el canal de WhatsApp; el cliente se reutiliza en cada envío
+func NewTwilioWhatsAppChannel(cfg *config.Config) (Channel, error) {
+	if cfg.TwilioAccountSID == "" || cfg.TwilioAuthToken == "" || cfg.TwilioPhoneNumber == "" {
+		return nil, ErrNotConfigured
+	}
+	return &TwilioWhatsAppChannel{
+		client: twilio.NewRestClientWithParams(twilio.ClientParams{
+			Username: cfg.TwilioAccountSID,
+			Password: cfg.TwilioAuthToken,
+		}),
+		from: whatsAppAddress(cfg.TwilioPhoneNumber),
+	}, nil
+}
+
+func (c *TwilioWhatsAppChannel) Name() string {
+	return "whatsapp"
+}
+
+func (c *TwilioWhatsAppChannel) Address(recipient Recipient) string {
+	return recipient.Phone
+}
+
+func (c *TwilioWhatsAppChannel) Send(ctx context.Context, recipient Recipient, message Message) error {
+	_, err := c.SendWithReceipt(ctx, recipient, message)
+	return err
+}
+
+// SendWithReceipt envía el mensaje y devuelve el SID de Twilio
+func (c *TwilioWhatsAppChannel) SendWithReceipt(ctx context.Context, recipient Recipient, message Message) (string, error) {
+	if recipient.Phone == "" {
+		return "", ErrNoAddress
+	}
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	params := &twilioApi.CreateMessageParams{}
+	params.SetTo(whatsAppAddress(recipient.Phone))
+	params.SetFrom(c.from)
+	params.SetBody(message.Text)
+
+	response, err := c.client.Api.CreateMessage(params)
+	if err != nil {
+		var restErr *client.TwilioRestError
+		if errors.As(err, &restErr) && isPermanentStatus(restErr.Status) {
+			return "", Permanent(fmt.Errorf("failed to send WhatsApp message: %v", err))
+		}
+		return "", fmt.Errorf("failed to send WhatsApp message: %v", err)
+	}
+
+	var messageID string
+	if response.Sid != nil {
+		messageID = *response.Sid
+	}
+	return messageID, nil
+}
+
+// whatsAppAddress agrega el prefijo "whatsapp:" que Twilio requiere
+func whatsAppAddress(phone string) string {
+	if strings.HasPrefix(phone, "whatsapp:") {
+		return phone
+	}
+	return "whatsapp:" + phone
+}
@@ -0,0 +1,233 @@
+package reminders
+
+import (
+	"container/heap"
+	"log"
+	"sync"
+	"time"
+)
+
+// Reminder es un envío concreto: el recordatorio de una ocurrencia de un evento
+type Reminder struct {
+	DueAt    time.Time    // Instante exacto de envío
+	StartsAt time.Time    // Inicio de la ocurrencia recordada
+	Label    string       // Descripción para los logs
+	Send     func() error // Envía el recordatorio; un error indica que nadie más lo reintenta
+	attempts int          // Envíos fallidos hasta ahora
+}
+
+// Source devuelve los recordatorios cuyo envío cae en (from, to]
+type Source func(from, to time.Time) ([]Reminder, error)
+
+// Checkpoint persiste hasta qué instante ya se procesaron los recordatorios,
+// para recuperar los que vencieron mientras el proceso estaba detenido
+type Checkpoint interface {
+	Load() (time.Time, error) // Instante cero si nunca se guardó
+	Save(processedUntil time.Time) error
+}
+
+const (
+	// DefaultHorizon es cuánto hacia adelante se cargan recordatorios en memoria
+	DefaultHorizon = time.Hour
+	// DefaultCatchUpWindow limita cuánto hacia atrás se recuperan recordatorios al arrancar
+	DefaultCatchUpWindow = 24 * time.Hour
+	// lateTolerance es el atraso a partir del cual un recordatorio se considera perdido
+	lateTolerance = time.Minute
+	// maxSendAttempts limita los envíos de un recordatorio cuyo Send falla
+	maxSendAttempts = 5
+	// retryBackoff es la espera antes del primer reintento; se duplica en cada uno
+	retryBackoff = time.Minute
+)
+
+// Engine mantiene en un min-heap los recordatorios que vencen dentro del horizonte
+// y duerme con un único timer hasta el próximo. Al arrancar recupera los que vencieron
+// desde el último checkpoint y, ante cambios en los eventos, recalcula el heap.
+type Engine struct {
+	source        Source
+	checkpoint    Checkpoint
+	horizon       time.Duration
+	catchUpWindow time.Duration
+
+	queue          *queue
+	retries        []Reminder // Reintentos de envíos fallidos, que no vienen del Source
+	retryBackoff   time.Duration
+	processedUntil time.Time // Los recordatorios con DueAt <= processedUntil ya se procesaron
+	horizonEnd     time.Time // Fin de la ventana cargada en el heap
+
+	reschedule chan struct{}
+	done       chan struct{}
+	stopOnce   sync.Once
+	wg         sync.WaitGroup
+}
+
+// NewEngine crea el motor; catchUpWindow <= 0 usa DefaultCatchUpWindow
+func NewEngine(source Source, checkpoint Checkpoint, catchUpWindow time.Duration) *Engine {
+	if catchUpWindow <= 0 {
+		catchUpWindow = DefaultCatchUpWindow
+	}
+	return &Engine{
+		source:        source,
+		checkpoint:    checkpoint,
+		horizon:       DefaultHorizon,
+		catchUpWindow: catchUpWindow,
+		retryBackoff:  retryBackoff,
+		queue:         newQueue(nil),
+		reschedule:    make(chan struct{}, 1),
+		done:          make(chan struct{}),
+	}
+}
+
+// Start arranca el motor en background
+func (e *Engine) Start() {
+	e.wg.Add(1)
+	go e.run()
+	log.Println("Reminder engine started")
+}
+
+// Stop detiene el motor y espera a que termine el envío en curso
+func (e *Engine) Stop() {
+	e.stopOnce.Do(func() { close(e.done) })
+	e.wg.Wait()
+	log.Println("Reminder engine stopped")
+}
+
+// Reschedule avisa que cambiaron eventos: el heap se vuelve a calcular.
+// No bloquea; varios avisos seguidos se agrupan en un solo recálculo.
+func (e *Engine) Reschedule() {
+	select {
+	case e.reschedule <- struct{}{}:
+	default:
+	}
+}
+
+func (e *Engine) run() {
+	defer e.wg.Done()
+
+	now := time.Now()
+	e.processedUntil = e.catchUpStart(now)
+	e.load(now)
+
+	for {
+		timer := time.NewTimer(time.Until(e.nextWake()))
+
+		select {
+		case <-timer.C:
+			now := time.Now()
+			e.fireDue(now)
+			if !now.Before(e.horizonEnd) {
+				e.load(now)
+			}
+		case <-e.reschedule:
+			timer.Stop()
+			e.load(time.Now())
+		case <-e.done:
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// catchUpStart devuelve desde dónde recuperar recordatorios: el último checkpoint,
+// limitado a la ventana de recuperación
+func (e *Engine) catchUpStart(now time.Time) time.Time {
+	from, err := e.checkpoint.Load()
+	if err != nil {
+		log.Printf("Error loading reminder checkpoint: %v", err)
+	}
+	if from.IsZero() || from.After(now) {
+		return now
+	}
+	if earliest := now.Add(-e.catchUpWindow); from.Before(earliest) {
+		log.Printf("Reminder checkpoint %s is older than the catch-up window, skipping reminders before %s",
+			from.Format(time.RFC3339), earliest.Format(time.RFC3339))
+		return earliest
+	}
+	if from.Before(now) {
+		log.Printf("Catching up on reminders due since %s", from.Format(time.RFC3339))
+	}
+	return from
+}
+
+// load recalcula el heap con los recordatorios pendientes hasta now + horizonte y los
+// reintentos programados. Si falla, conserva el heap anterior y reintenta al cumplirse el horizonte.
+func (e *Engine) load(now time.Time) {
+	to := now.Add(e.horizon)
+	reminders, err := e.source(e.processedUntil, to)
+	if err != nil {
+		log.Printf("Error loading reminders: %v", err)
+		e.horizonEnd = now.Add(lateTolerance)
+		return
+	}
+
+	pending := reminders[:0]
+	for _, reminder := range reminders {
+		if reminder.DueAt.After(e.processedUntil) && !reminder.DueAt.After(to) {
+			pending = append(pending, reminder)
+		}
+	}
+	e.queue = newQueue(append(pending, e.retries...))
+	e.horizonEnd = to
+}
+
+// nextWake devuelve cuándo despertar: el próximo recordatorio o el fin del horizonte
+func (e *Engine) nextWake() time.Time {
+	if next, ok := e.queue.peek(); ok && next.DueAt.Before(e.horizonEnd) {
+		return next.DueAt
+	}
+	return e.horizonEnd
+}
+
+// fireDue envía los recordatorios vencidos y avanza el checkpoint.
+// Los que vencieron hace rato (ej: con el proceso detenido) solo se envían si el evento no empezó.
+// Un envío fallido se reprograma con espera exponencial hasta maxSendAttempts.
+func (e *Engine) fireDue(now time.Time) {
+	sent := 0
+	// Los reintentos vencidos salen del heap en esta pasada; los demás siguen programados
+	retries := e.retries[:0]
+	for _, retry := range e.retries {
+		if retry.DueAt.After(now) {
+			retries = append(retries, retry)
+		}
+	}
+	e.retries = retries
+
+	for {
+		next, ok := e.queue.peek()
+		if !ok || next.DueAt.After(now) {
+			break
+		}
+		heap.Pop(e.queue)
+
+		if now.Sub(next.DueAt) > lateTolerance && !next.StartsAt.After(now) {
+			log.Printf("Skipping missed reminder for %s: it already started", next.Label)
+			continue
+		}
+		if err := next.Send(); err != nil {
+			e.retry(next, now, err)
+			continue
+		}
+		sent++
+	}
+
+	e.processedUntil = now
+	if err := e.checkpoint.Save(now); err != nil {
+		log.Printf("Error saving reminder checkpoint: %v", err)
+	}
+	if sent > 0 {
+		log.Printf("Processed %d reminders", sent)
+	}
+}
+
+// retry reprograma el recordatorio cuyo envío falló, salvo que se hayan agotado los intentos
+func (e *Engine) retry(reminder Reminder, now time.Time, err error) {
+	reminder.attempts++
+	if reminder.attempts >= maxSendAttempts {
+		log.Printf("Error sending reminder for %s, giving up after %d attempts: %v", reminder.Label, reminder.attempts, err)
+		return
+	}
+	reminder.DueAt = now.Add(e.retryBackoff << (reminder.attempts - 1))
+	log.Printf("Error sending reminder for %s (attempt %d), retrying at %s: %v",
+		reminder.Label, reminder.attempts, reminder.DueAt.Format(time.RFC3339), err)
+	e.retries = append(e.retries, reminder)
+	heap.Push(e.queue, reminder)
+}
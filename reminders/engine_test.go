@@ -0,0 +1,180 @@
+package reminders
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryCheckpoint guarda el checkpoint en memoria
+type memoryCheckpoint struct {
+	mu    sync.Mutex
+	value time.Time
+}
+
+func (c *memoryCheckpoint) Load() (time.Time, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.value, nil
+}
+
+func (c *memoryCheckpoint) Save(processedUntil time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.value = processedUntil
+	return nil
+}
+
+// memorySource devuelve los recordatorios guardados, que el test puede cambiar
+type memorySource struct {
+	mu        sync.Mutex
+	reminders []Reminder
+}
+
+func (s *memorySource) set(reminders ...Reminder) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reminders = reminders
+}
+
+func (s *memorySource) load(from, to time.Time) ([]Reminder, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Reminder(nil), s.reminders...), nil
+}
+
+// sentReminder arma un recordatorio que al enviarse manda su label al canal
+func sentReminder(sent chan<- string, label string, dueAt, startsAt time.Time) Reminder {
+	return Reminder{DueAt: dueAt, StartsAt: startsAt, Label: label, Send: func() error {
+		sent <- label
+		return nil
+	}}
+}
+
+// collect espera los envíos durante el tiempo dado
+func collect(sent <-chan string, wait time.Duration) map[string]int {
+	got := map[string]int{}
+	timeout := time.After(wait)
+	for {
+		select {
+		case label := <-sent:
+			got[label]++
+		case <-timeout:
+			return got
+		}
+	}
+}
+
+func TestEngineCatchesUpSinceCheckpoint(t *testing.T) {
+	now := time.Now()
+	sent := make(chan string, 10)
+	source := &memorySource{}
+	source.set(
+		sentReminder(sent, "processed", now.Add(-20*time.Minute), now.Add(time.Hour)),
+		sentReminder(sent, "missed", now.Add(-5*time.Minute), now.Add(time.Hour)),
+		sentReminder(sent, "started", now.Add(-5*time.Minute), now.Add(-time.Minute)),
+		sentReminder(sent, "upcoming", now.Add(100*time.Millisecond), now.Add(time.Hour)),
+		sentReminder(sent, "later", now.Add(30*time.Minute), now.Add(time.Hour)),
+	)
+	checkpoint := &memoryCheckpoint{value: now.Add(-10 * time.Minute)}
+
+	engine := NewEngine(source.load, checkpoint, 0)
+	engine.Start()
+	got := collect(sent, 500*time.Millisecond)
+	engine.Stop()
+
+	if len(got) != 2 || got["missed"] != 1 || got["upcoming"] != 1 {
+		t.Errorf("sent %v, want missed and upcoming once each", got)
+	}
+	if saved, _ := checkpoint.Load(); saved.Before(now.Add(100 * time.Millisecond)) {
+		t.Errorf("checkpoint %s does not cover the sent reminders", saved)
+	}
+}
+
+func TestEngineLimitsCatchUpToWindow(t *testing.T) {
+	now := time.Now()
+	engine := NewEngine(nil, &memoryCheckpoint{value: now.Add(-48 * time.Hour)}, time.Hour)
+	if from := engine.catchUpStart(now); !from.Equal(now.Add(-time.Hour)) {
+		t.Errorf("catch-up starts at %s, want one hour ago", from)
+	}
+
+	engine = NewEngine(nil, &memoryCheckpoint{}, time.Hour)
+	if from := engine.catchUpStart(now); !from.Equal(now) {
+		t.Errorf("without a checkpoint catch-up starts at %s, want now", from)
+	}
+}
+
+func TestEngineReschedulesOnChanges(t *testing.T) {
+	sent := make(chan string, 10)
+	source := &memorySource{}
+	engine := NewEngine(source.load, &memoryCheckpoint{}, 0)
+	engine.Start()
+	defer engine.Stop()
+
+	source.set(sentReminder(sent, "added", time.Now().Add(100*time.Millisecond), time.Now().Add(time.Hour)))
+	engine.Reschedule()
+	if got := collect(sent, 400*time.Millisecond); got["added"] != 1 {
+		t.Errorf("sent %v, want the reminder added after starting", got)
+	}
+}
+
+func TestEngineRetriesFailedSend(t *testing.T) {
+	sent := make(chan string, 10)
+	var mu sync.Mutex
+	attempts := 0
+	flaky := Reminder{DueAt: time.Now().Add(50 * time.Millisecond), StartsAt: time.Now().Add(time.Hour), Label: "flaky", Send: func() error {
+		mu.Lock()
+		defer mu.Unlock()
+		attempts++
+		if attempts < 3 {
+			return errors.New("provider unavailable")
+		}
+		sent <- "flaky"
+		return nil
+	}}
+	source := &memorySource{}
+	source.set(flaky)
+
+	engine := NewEngine(source.load, &memoryCheckpoint{}, 0)
+	engine.retryBackoff = 50 * time.Millisecond
+	engine.Start()
+	defer engine.Stop()
+
+	// Un recálculo del heap entre reintentos no pierde el reintento programado
+	time.Sleep(75 * time.Millisecond)
+	engine.Reschedule()
+
+	if got := collect(sent, 600*time.Millisecond); got["flaky"] != 1 {
+		t.Errorf("sent %v, want the reminder once after two failures", got)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if attempts != 3 {
+		t.Errorf("Send called %d times, want 3", attempts)
+	}
+}
+
+func TestEngineGivesUpAfterMaxAttempts(t *testing.T) {
+	var mu sync.Mutex
+	attempts := 0
+	source := &memorySource{}
+	source.set(Reminder{DueAt: time.Now().Add(20 * time.Millisecond), StartsAt: time.Now().Add(time.Hour), Label: "broken", Send: func() error {
+		mu.Lock()
+		defer mu.Unlock()
+		attempts++
+		return errors.New("provider unavailable")
+	}})
+
+	engine := NewEngine(source.load, &memoryCheckpoint{}, 0)
+	engine.retryBackoff = 10 * time.Millisecond
+	engine.Start()
+	time.Sleep(600 * time.Millisecond)
+	engine.Stop()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if attempts != maxSendAttempts {
+		t.Errorf("Send called %d times, want %d", attempts, maxSendAttempts)
+	}
+}
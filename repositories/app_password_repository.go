@@ -0,0 +1,58 @@
+package repositories
+
+import (
+	"calendar-backend/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type AppPasswordRepository interface {
+	Create(password *models.AppPassword) error
+	GetByUser(userID uint) ([]models.AppPassword, error)
+	GetByHash(userID uint, passwordHash string) (*models.AppPassword, error)
+	Delete(userID, id uint) error
+	TouchLastUsed(id uint, at time.Time) error
+}
+
+type appPasswordRepository struct {
+	db *gorm.DB
+}
+
+func NewAppPasswordRepository(db *gorm.DB) AppPasswordRepository {
+	return &appPasswordRepository{db: db}
+}
+
+func (r *appPasswordRepository) Create(password *models.AppPassword) error {
+	return r.db.Create(password).Error
+}
+
+func (r *appPasswordRepository) GetByUser(userID uint) ([]models.AppPassword, error) {
+	var passwords []models.AppPassword
+	err := r.db.Where("user_id = ?", userID).Order("created_at ASC").Find(&passwords).Error
+	return passwords, err
+}
+
+func (r *appPasswordRepository) GetByHash(userID uint, passwordHash string) (*models.AppPassword, error) {
+	var password models.AppPassword
+	err := r.db.Where("user_id = ? AND password_hash = ?", userID, passwordHash).First(&password).Error
+	if err != nil {
+		return nil, err
+	}
+	return &password, nil
+}
+
+func (r *appPasswordRepository) Delete(userID, id uint) error {
+	result := r.db.Where("user_id = ? AND id = ?", userID, id).Delete(&models.AppPassword{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *appPasswordRepository) TouchLastUsed(id uint, at time.Time) error {
+	return r.db.Model(&models.AppPassword{}).Where("id = ?", id).UpdateColumn("last_used_at", at).Error
+}
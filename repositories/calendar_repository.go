@@ -0,0 +1,186 @@
+package repositories
+
+import (
+	"calendar-backend/models"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Nombre y color del calendario predeterminado que se crea para cada usuario
+const (
+	DefaultCalendarName  = "Calendar"
+	DefaultCalendarColor = "#007AFF"
+)
+
+type CalendarRepository interface {
+	Create(calendar *models.Calendar) error
+	GetByID(id uint) (*models.Calendar, error)
+	GetAccessible(userID uint) ([]models.Calendar, error)
+	GetAccessibleByID(userID, id uint) (*models.Calendar, error)
+	GetDefault(ownerID uint) (*models.Calendar, error)
+	Update(calendar *models.Calendar) error
+	Delete(id uint) ([]models.Event, error)
+	GetEvents(calendarID uint) ([]models.Event, error)
+	GetViewerIDs(calendarID uint) ([]uint, error)
+	GetShares(calendarID uint) ([]models.CalendarShare, error)
+	SaveShare(share *models.CalendarShare) error
+	DeleteShare(calendarID, userID uint) error
+}
+
+type calendarRepository struct {
+	db *gorm.DB
+}
+
+func NewCalendarRepository(db *gorm.DB) CalendarRepository {
+	return &calendarRepository{db: db}
+}
+
+func (r *calendarRepository) Create(calendar *models.Calendar) error {
+	return r.db.Create(calendar).Error
+}
+
+func (r *calendarRepository) GetByID(id uint) (*models.Calendar, error) {
+	var calendar models.Calendar
+	if err := r.db.First(&calendar, id).Error; err != nil {
+		return nil, err
+	}
+	return &calendar, nil
+}
+
+// accessibleCalendars es la consulta de los calendarios del usuario y los compartidos con él,
+// con el rol que tiene en cada uno
+func (r *calendarRepository) accessibleCalendars(userID uint) *gorm.DB {
+	return r.db.Table("(?) AS calendars", r.db.Raw(
+		"SELECT calendars.*, '"+models.CalendarOwner+"' AS role FROM calendars WHERE owner_id = ? "+
+			"UNION ALL SELECT calendars.*, calendar_shares.role AS role FROM calendars "+
+			"JOIN calendar_shares ON calendar_shares.calendar_id = calendars.id WHERE calendar_shares.user_id = ?",
+		userID, userID))
+}
+
+// GetAccessible devuelve los calendarios del usuario (el predeterminado primero) y luego los
+// compartidos con él, cada uno con su rol
+func (r *calendarRepository) GetAccessible(userID uint) ([]models.Calendar, error) {
+	var calendars []models.Calendar
+	err := r.accessibleCalendars(userID).
+		Order("CASE WHEN role = '" + models.CalendarOwner + "' THEN 0 ELSE 1 END, is_default DESC, name ASC, id ASC").
+		Find(&calendars).Error
+	return calendars, err
+}
+
+// GetAccessibleByID devuelve el calendario con el rol del usuario, o gorm.ErrRecordNotFound si
+// no tiene acceso a él
+func (r *calendarRepository) GetAccessibleByID(userID, id uint) (*models.Calendar, error) {
+	var calendar models.Calendar
+	if err := r.accessibleCalendars(userID).Where("id = ?", id).First(&calendar).Error; err != nil {
+		return nil, err
+	}
+	return &calendar, nil
+}
+
+// GetDefault devuelve el calendario predeterminado del usuario, creándolo si todavía no tiene
+func (r *calendarRepository) GetDefault(ownerID uint) (*models.Calendar, error) {
+	return defaultCalendar(r.db, ownerID)
+}
+
+// Update guarda el nombre y el color del calendario
+func (r *calendarRepository) Update(calendar *models.Calendar) error {
+	calendar.UpdatedAt = time.Now()
+	return r.db.Model(&models.Calendar{}).Where("id = ?", calendar.ID).Updates(map[string]interface{}{
+		"name":       calendar.Name,
+		"color":      calendar.Color,
+		"updated_at": calendar.UpdatedAt,
+	}).Error
+}
+
+// Delete elimina el calendario con sus eventos (baja lógica, para que las apps se enteren) y
+// con quién estaba compartido. Devuelve los eventos eliminados.
+func (r *calendarRepository) Delete(id uint) ([]models.Event, error) {
+	var events []models.Event
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("calendar_id = ?", id).Find(&events).Error; err != nil {
+			return err
+		}
+		// Primero se deja de compartir: los usuarios reciben la baja de los eventos que todavía
+		// no estaban eliminados
+		if err := tx.Where("calendar_id = ?", id).Delete(&models.CalendarShare{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("calendar_id = ?", id).Delete(&models.Event{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Calendar{}, id).Error
+	})
+	return events, err
+}
+
+// GetEvents devuelve los eventos del calendario, sin los eliminados
+func (r *calendarRepository) GetEvents(calendarID uint) ([]models.Event, error) {
+	var events []models.Event
+	err := r.db.Where("calendar_id = ?", calendarID).Order("id ASC").Find(&events).Error
+	return events, err
+}
+
+// GetViewerIDs devuelve los usuarios que ven los eventos del calendario: su dueño y con quienes
+// está compartido
+func (r *calendarRepository) GetViewerIDs(calendarID uint) ([]uint, error) {
+	var ids []uint
+	err := r.db.Raw("SELECT owner_id FROM calendars WHERE id = ? UNION SELECT user_id FROM calendar_shares WHERE calendar_id = ?",
+		calendarID, calendarID).Scan(&ids).Error
+	return ids, err
+}
+
+// GetShares devuelve con quién está compartido el calendario, con el email de cada usuario
+func (r *calendarRepository) GetShares(calendarID uint) ([]models.CalendarShare, error) {
+	var shares []models.CalendarShare
+	err := r.db.Model(&models.CalendarShare{}).
+		Select("calendar_shares.*, users.email AS email").
+		Joins("JOIN users ON users.id = calendar_shares.user_id").
+		Where("calendar_shares.calendar_id = ?", calendarID).
+		Order("calendar_shares.created_at ASC").
+		Find(&shares).Error
+	return shares, err
+}
+
+// SaveShare comparte el calendario con el usuario o, si ya lo estaba, cambia su rol
+func (r *calendarRepository) SaveShare(share *models.CalendarShare) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "calendar_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"role", "updated_at"}),
+	}).Create(share).Error
+}
+
+// DeleteShare deja de compartir el calendario con el usuario
+func (r *calendarRepository) DeleteShare(calendarID, userID uint) error {
+	result := r.db.Where("calendar_id = ? AND user_id = ?", calendarID, userID).Delete(&models.CalendarShare{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// defaultCalendar devuelve el calendario predeterminado del usuario y lo crea si no existe. Si
+// otra escritura lo crea al mismo tiempo, el índice único lo rechaza y se lee el de ella.
+func defaultCalendar(db *gorm.DB, ownerID uint) (*models.Calendar, error) {
+	var calendar models.Calendar
+	err := db.Where("owner_id = ? AND is_default = ?", ownerID, true).First(&calendar).Error
+	if err == nil {
+		return &calendar, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	calendar = models.Calendar{OwnerID: ownerID, Name: DefaultCalendarName, Color: DefaultCalendarColor, IsDefault: true}
+	if createErr := db.Create(&calendar).Error; createErr != nil {
+		if err := db.Where("owner_id = ? AND is_default = ?", ownerID, true).First(&calendar).Error; err != nil {
+			return nil, createErr
+		}
+	}
+	return &calendar, nil
+}
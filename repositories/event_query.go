@@ -0,0 +1,125 @@
+package repositories
+
+import (
+	"calendar-backend/models"
+	"calendar-backend/search"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// EventQuery es una consulta de eventos con filtros combinables, orden y paginación por cursor
+type EventQuery struct {
+	Search             *search.Query // Búsqueda de texto completo
+	StartDate, EndDate string        // Días del rango (YYYY-MM-DD); con rango se expanden las series
+	Categories         []string
+	Priorities         []string
+	Colors             []string
+	IsAllDay           *bool
+	HasReminders       *bool
+	Location           string              // Texto en la ubicación
+	Sort               string              // Campo de orden (models.EventSort*)
+	Desc               bool                // Orden descendente
+	After              *models.EventCursor // Devolver los eventos posteriores al cursor
+	Limit              int                 // 0 = sin límite
+}
+
+// Expresiones SQL de los campos de orden; el inicio y el ID desempatan
+var sortExpressions = map[string]string{
+	models.EventSortDate:      "",
+	models.EventSortPriority:  "CASE priority WHEN 'high' THEN 3 WHEN 'medium' THEN 2 WHEN 'low' THEN 1 ELSE 0 END",
+	models.EventSortCreatedAt: "created_at",
+	models.EventSortTitle:     "LOWER(title)",
+}
+
+// apply agrega a la consulta los filtros de q; la búsqueda usa el índice de texto completo
+func (q EventQuery) apply(query *gorm.DB, engine search.Engine) *gorm.DB {
+	if q.Search != nil {
+		query = engine.Where(query, q.Search)
+	}
+	if len(q.Categories) > 0 {
+		query = query.Where("LOWER(category) IN ?", q.Categories)
+	}
+	if len(q.Priorities) > 0 {
+		query = query.Where("priority IN ?", q.Priorities)
+	}
+	if len(q.Colors) > 0 {
+		query = query.Where("LOWER(color) IN ?", q.Colors)
+	}
+	if q.IsAllDay != nil {
+		query = query.Where("is_all_day = ?", *q.IsAllDay)
+	}
+	if q.HasReminders != nil {
+		exists := "EXISTS (SELECT 1 FROM reminders WHERE reminders.event_id = events.id)"
+		if !*q.HasReminders {
+			exists = "NOT " + exists
+		}
+		query = query.Where(exists)
+	}
+	if q.Location != "" {
+		query = query.Where("LOWER(location) LIKE ?", "%"+strings.ToLower(q.Location)+"%")
+	}
+	return query
+}
+
+// page recorta los eventos al límite e indica si había más
+func (q EventQuery) page(events []models.Event) ([]models.Event, bool) {
+	if q.Limit > 0 && len(events) > q.Limit {
+		return events[:q.Limit], true
+	}
+	return events, false
+}
+
+func (q EventQuery) dateRange() (time.Time, time.Time, error) {
+	start, err := time.Parse("2006-01-02", q.StartDate)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	end, err := time.Parse("2006-01-02", q.EndDate)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return start, end, nil
+}
+
+// orderClause devuelve el ORDER BY del campo de orden, desempatando por inicio e ID
+func orderClause(field string, desc bool) string {
+	direction := " ASC"
+	if desc {
+		direction = " DESC"
+	}
+	order := "starts_at" + direction + ", id" + direction
+	if expression := sortExpressions[field]; expression != "" {
+		order = expression + direction + ", " + order
+	}
+	return order
+}
+
+// keysetCondition devuelve la condición que selecciona los eventos posteriores al cursor:
+// (campo, inicio, ID) mayor (o menor, si es descendente) que la posición del cursor
+func keysetCondition(cursor *models.EventCursor) (string, []interface{}, error) {
+	value, err := cursor.SortValue()
+	if err != nil {
+		return "", nil, err
+	}
+
+	op := " > "
+	if cursor.Desc {
+		op = " < "
+	}
+	condition := "starts_at" + op + "? OR (starts_at = ? AND id" + op + "?)"
+	args := []interface{}{cursor.StartsAt, cursor.StartsAt, cursor.ID}
+
+	expression := sortExpressions[cursor.Sort]
+	if expression == "" {
+		return condition, args, nil
+	}
+	// Los títulos se comparan en minúsculas, con la misma función de la base que el ORDER BY
+	placeholder := "?"
+	if cursor.Sort == models.EventSortTitle {
+		placeholder = "LOWER(?)"
+	}
+	condition = expression + op + placeholder + " OR (" + expression + " = " + placeholder + " AND (" + condition + "))"
+	return condition, append([]interface{}{value, value}, args...), nil
+}
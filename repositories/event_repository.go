@@ -2,112 +2,738 @@ package repositories
 
 import (
 	"calendar-backend/models"
+	"calendar-backend/search"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type EventRepository interface {
+	ForOwner(ownerID uint) EventRepository
+	ForUser(userID uint) EventRepository
+	Transaction(fn func(repo EventRepository) error) error
+	AssignOwnerByEmail(ownerID uint, email string) (int64, error)
 	Create(event *models.Event) error
 	GetByID(id uint) (*models.Event, error)
+	GetByClientID(clientID string) (*models.Event, error)
+	GetIncludingDeleted(id uint) (*models.Event, error)
+	GetSeries(id uint) ([]models.Event, error)
+	GetByExternalUID(uid string) ([]models.Event, error)
 	GetAll() ([]models.Event, error)
+	GetFiltered(categories, priorities []string) ([]models.Event, error)
+	ChangeStamp() (string, error)
+	GetChangedSince(since time.Time) ([]models.Event, error)
+	GetChangesAfter(seq int64, limit int) ([]models.Event, error)
+	GetRemovalsAfter(seq int64, limit int) ([]models.EventRemoval, error)
+	GetRemovedSince(since time.Time) ([]models.Event, error)
+	LastChangeSeq() (int64, error)
+	ChangeSeqBounds() (purged, current int64, err error)
+	PurgeDeletedBefore(before time.Time) (int64, error)
 	GetByDate(date string) ([]models.Event, error)
 	Update(id uint, event *models.Event) error
+	MoveOccurrences(seriesID, calendarID uint) error
+	Replace(event *models.Event) error
 	Delete(id uint) error
 	GetTodayEvents() ([]models.Event, error)
-	GetUpcomingEvents() ([]models.Event, error)
+	GetUpcomingEvents(limit int) ([]models.Event, error)
 	GetEventsForDateRange(startDate, endDate string) ([]models.Event, error)
-	SearchEvents(query string) ([]models.Event, error)
+	Search(q *search.Query, limit int) ([]SearchHit, error)
+	Find(q EventQuery) ([]models.Event, bool, error)
+	Stream(q EventQuery, batchSize int, fn func(batch []models.Event) error) error
 	GetEventStats() (map[string]interface{}, error)
+	ReplaceReminders(event *models.Event) error
+}
+
+// ErrStaleVersion indica que el evento cambió desde la versión con la que se leyó
+var ErrStaleVersion = errors.New("event was modified since it was read")
+
+// SearchHit es un evento encontrado por la búsqueda y su relevancia
+type SearchHit struct {
+	Event models.Event
+	Score float64
 }
 
 type eventRepository struct {
-	db *gorm.DB
+	db      *gorm.DB
+	search  search.Engine
+	ownerID uint // 0 = sin restricción de dueño (procesos internos)
+	userID  uint // Distinto de 0 = solo los calendarios a los que el usuario tiene acceso
+}
+
+func NewEventRepository(db *gorm.DB, searchEngine search.Engine) EventRepository {
+	return &eventRepository{db: db, search: searchEngine}
+}
+
+// ForOwner devuelve un repositorio cuyas consultas se limitan a los eventos del usuario
+func (r *eventRepository) ForOwner(ownerID uint) EventRepository {
+	return &eventRepository{db: r.db, search: r.search, ownerID: ownerID}
+}
+
+// ForUser devuelve un repositorio cuyas consultas se limitan a los eventos de los calendarios
+// del usuario y de los compartidos con él, con cualquier rol. Incluye las bajas de los eventos
+// que el usuario dejó de ver (ver GetRemovalsAfter).
+func (r *eventRepository) ForUser(userID uint) EventRepository {
+	return &eventRepository{db: r.db, search: r.search, userID: userID}
+}
+
+// Transaction ejecuta fn con un repositorio con las mismas restricciones cuyas operaciones
+// van en una misma transacción: si fn devuelve un error no se guarda ninguna
+func (r *eventRepository) Transaction(fn func(repo EventRepository) error) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return fn(&eventRepository{db: tx, search: r.search, ownerID: r.ownerID, userID: r.userID})
+	})
+}
+
+// AssignOwnerByEmail asigna al usuario los eventos sin dueño creados con su email,
+// anteriores a la existencia de cuentas, y los pone en su calendario predeterminado.
+// Devuelve cuántos eventos asignó.
+func (r *eventRepository) AssignOwnerByEmail(ownerID uint, email string) (int64, error) {
+	calendar, err := defaultCalendar(r.db, ownerID)
+	if err != nil {
+		return 0, err
+	}
+	result := r.db.Model(&models.Event{}).
+		Where("(owner_id = 0 OR owner_id IS NULL) AND LOWER(email) = ?", email).
+		Updates(map[string]interface{}{"owner_id": ownerID, "calendar_id": calendar.ID})
+	return result.RowsAffected, result.Error
+}
+
+// query devuelve una consulta limitada al dueño del repositorio
+func (r *eventRepository) query() *gorm.DB {
+	return r.scope(r.db)
+}
+
+// scope limita la consulta (ej: de una transacción) al dueño o a los calendarios del usuario
+// del repositorio. Los calendarios propios son los de los eventos de los que es dueño: así
+// conserva las bajas de los eventos de un calendario que eliminó.
+func (r *eventRepository) scope(db *gorm.DB) *gorm.DB {
+	if r.userID != 0 {
+		return db.Where("(owner_id = ? OR calendar_id IN (SELECT calendar_id FROM calendar_shares WHERE user_id = ?))", r.userID, r.userID)
+	}
+	if r.ownerID == 0 {
+		return db
+	}
+	return db.Where("owner_id = ?", r.ownerID)
 }
 
-func NewEventRepository(db *gorm.DB) EventRepository {
-	return &eventRepository{db: db}
+// withReminders devuelve una consulta limitada al dueño que precarga los recordatorios
+func (r *eventRepository) withReminders() *gorm.DB {
+	return r.query().Preload("Reminders")
 }
 
+// Create guarda el evento; sin calendario va al predeterminado de su dueño
 func (r *eventRepository) Create(event *models.Event) error {
-	return r.db.Create(event).Error
+	if r.ownerID != 0 {
+		event.OwnerID = r.ownerID
+	}
+	if event.CalendarID == 0 && event.OwnerID != 0 {
+		calendar, err := defaultCalendar(r.db, event.OwnerID)
+		if err != nil {
+			return err
+		}
+		event.CalendarID = calendar.ID
+	}
+
+	if event.Version == 0 {
+		event.Version = 1
+	}
+
+	reminderDay, reminderDayBefore := event.ReminderDay, event.ReminderDayBefore
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(event).Error; err != nil {
+			return err
+		}
+		// Create usa el default de la columna para los flags en false, así que se escriben aparte
+		event.ReminderDay, event.ReminderDayBefore = reminderDay, reminderDayBefore
+		return tx.Model(&models.Event{}).Where("id = ?", event.ID).Updates(map[string]interface{}{
+			"reminder_day":        reminderDay,
+			"reminder_day_before": reminderDayBefore,
+		}).Error
+	})
 }
 
 func (r *eventRepository) GetByID(id uint) (*models.Event, error) {
 	var event models.Event
-	err := r.db.First(&event, id).Error
+	err := r.withReminders().First(&event, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+// GetByClientID obtiene el evento que creó la app con ese client_id, aunque esté eliminado
+func (r *eventRepository) GetByClientID(clientID string) (*models.Event, error) {
+	var event models.Event
+	err := r.withReminders().Unscoped().Where("client_id = ?", clientID).First(&event).Error
+	if err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+// GetIncludingDeleted obtiene el evento aunque esté eliminado (DeletedAt indica si lo está)
+func (r *eventRepository) GetIncludingDeleted(id uint) (*models.Event, error) {
+	var event models.Event
+	err := r.withReminders().Unscoped().First(&event, id).Error
 	if err != nil {
 		return nil, err
 	}
 	return &event, nil
 }
 
+// GetSeries obtiene el evento junto con las ocurrencias separadas de él, si es una serie
+func (r *eventRepository) GetSeries(id uint) ([]models.Event, error) {
+	var events []models.Event
+	err := r.withReminders().Where("id = ? OR recurrence_id = ?", id, id).Order("starts_at ASC").Find(&events).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(events) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return events, nil
+}
+
+// GetByExternalUID obtiene los eventos importados con ese UID: la serie y sus ocurrencias separadas
+func (r *eventRepository) GetByExternalUID(uid string) ([]models.Event, error) {
+	var events []models.Event
+	err := r.withReminders().Where("external_uid = ?", uid).Order("id ASC").Find(&events).Error
+	return events, err
+}
+
 func (r *eventRepository) GetAll() ([]models.Event, error) {
 	var events []models.Event
-	err := r.db.Order("date ASC, time ASC").Find(&events).Error
+	err := r.withReminders().Order("starts_at ASC").Find(&events).Error
 	return events, err
 }
 
-func (r *eventRepository) GetByDate(date string) ([]models.Event, error) {
+// GetFiltered devuelve los eventos de las categorías y prioridades dadas (vacío = sin filtro)
+func (r *eventRepository) GetFiltered(categories, priorities []string) ([]models.Event, error) {
+	query := r.withReminders()
+	if len(categories) > 0 {
+		query = query.Where("category IN ?", categories)
+	}
+	if len(priorities) > 0 {
+		query = query.Where("priority IN ?", priorities)
+	}
+
 	var events []models.Event
-	err := r.db.Where("date = ?", date).Order("time ASC").Find(&events).Error
+	err := query.Order("starts_at ASC").Find(&events).Error
 	return events, err
 }
 
-func (r *eventRepository) Update(id uint, event *models.Event) error {
-	return r.db.Model(&models.Event{}).Where("id = ?", id).Updates(event).Error
+// ChangeStamp resume el estado de los eventos del dueño, incluidos los eliminados, para
+// detectar cambios sin cargarlos: cambia con cada alta, modificación o baja
+func (r *eventRepository) ChangeStamp() (string, error) {
+	var stamp struct {
+		Total      int64
+		LastUpdate sql.NullString
+		LastDelete sql.NullString
+	}
+	err := r.query().Unscoped().Model(&models.Event{}).
+		Select("COUNT(*) AS total, MAX(updated_at) AS last_update, MAX(deleted_at) AS last_delete").
+		Scan(&stamp).Error
+	if err != nil {
+		return "", err
+	}
+	removed, err := r.lastRemovalSeq()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%d|%s|%s|%d", stamp.Total, stamp.LastUpdate.String, stamp.LastDelete.String, removed), nil
 }
 
-func (r *eventRepository) Delete(id uint) error {
-	return r.db.Delete(&models.Event{}, id).Error
+// GetChangedSince obtiene los eventos creados, modificados o eliminados después de since,
+// incluidos los eliminados
+func (r *eventRepository) GetChangedSince(since time.Time) ([]models.Event, error) {
+	var events []models.Event
+	err := r.query().Unscoped().Where("updated_at > ? OR deleted_at > ?", since, since).Order("id ASC").Find(&events).Error
+	return events, err
 }
 
-func (r *eventRepository) GetTodayEvents() ([]models.Event, error) {
-	today := time.Now().Format("2006-01-02")
+// GetChangesAfter obtiene hasta limit eventos, incluidos los eliminados, cuyo último cambio
+// tiene un número mayor a seq, en el orden de los cambios
+func (r *eventRepository) GetChangesAfter(seq int64, limit int) ([]models.Event, error) {
 	var events []models.Event
-	err := r.db.Where("date = ?", today).Order("time ASC").Find(&events).Error
+	err := r.withReminders().Unscoped().Where("change_seq > ?", seq).Order("change_seq ASC").Limit(limit).Find(&events).Error
 	return events, err
 }
 
-func (r *eventRepository) GetUpcomingEvents() ([]models.Event, error) {
-	today := time.Now().Format("2006-01-02")
+// GetRemovalsAfter obtiene hasta limit bajas de eventos que el usuario del repositorio dejó de
+// ver, con un número de cambio mayor a seq, en el orden de los cambios. Sin usuario no hay bajas.
+func (r *eventRepository) GetRemovalsAfter(seq int64, limit int) ([]models.EventRemoval, error) {
+	removals := []models.EventRemoval{}
+	if r.userID == 0 {
+		return removals, nil
+	}
+	err := r.db.Where("user_id = ? AND change_seq > ?", r.userID, seq).Order("change_seq ASC").Limit(limit).Find(&removals).Error
+	return removals, err
+}
+
+// GetRemovedSince obtiene los eventos, incluidos los eliminados, que el usuario del repositorio
+// dejó de ver después de since. Sin usuario no hay ninguno.
+func (r *eventRepository) GetRemovedSince(since time.Time) ([]models.Event, error) {
 	var events []models.Event
-	err := r.db.Where("date >= ?", today).Order("date ASC, time ASC").Limit(10).Find(&events).Error
+	if r.userID == 0 {
+		return events, nil
+	}
+	removed := r.db.Model(&models.EventRemoval{}).Select("event_id").Where("user_id = ? AND removed_at > ?", r.userID, since.UTC())
+	err := r.db.Unscoped().Where("id IN (?)", removed).Order("id ASC").Find(&events).Error
 	return events, err
 }
 
+// LastChangeSeq devuelve el número del último cambio de los eventos del dueño, incluidas las
+// bajas y los eventos que el usuario dejó de ver
+func (r *eventRepository) LastChangeSeq() (int64, error) {
+	var seq sql.NullInt64
+	if err := r.query().Unscoped().Model(&models.Event{}).Select("MAX(change_seq)").Scan(&seq).Error; err != nil {
+		return 0, err
+	}
+	removed, err := r.lastRemovalSeq()
+	if err != nil || removed < seq.Int64 {
+		return seq.Int64, err
+	}
+	return removed, nil
+}
+
+// lastRemovalSeq devuelve el número de la última baja de un evento que el usuario del
+// repositorio dejó de ver
+func (r *eventRepository) lastRemovalSeq() (int64, error) {
+	var seq sql.NullInt64
+	if r.userID == 0 {
+		return 0, nil
+	}
+	err := r.db.Model(&models.EventRemoval{}).Where("user_id = ?", r.userID).Select("MAX(change_seq)").Scan(&seq).Error
+	return seq.Int64, err
+}
+
+// ChangeSeqBounds devuelve, para todos los dueños, el mayor número de cambio de las bajas ya
+// borradas por PurgeDeletedBefore y el último número asignado
+func (r *eventRepository) ChangeSeqBounds() (purged, current int64, err error) {
+	if err := r.db.Raw("SELECT purged_seq FROM event_sync_horizon WHERE id = 1").Scan(&purged).Error; err != nil {
+		return 0, 0, err
+	}
+	counter := "SELECT value FROM event_change_seq WHERE id = 1"
+	if r.db.Dialector.Name() == "postgres" {
+		counter = "SELECT last_value FROM event_change_seq"
+	}
+	if err := r.db.Raw(counter).Scan(&current).Error; err != nil {
+		return 0, 0, err
+	}
+	return purged, current, nil
+}
+
+// PurgeDeletedBefore borra definitivamente los eventos eliminados antes de before, con sus
+// recordatorios, y las bajas de los eventos que los usuarios dejaron de ver antes de before.
+// Devuelve cuántos eventos borró. Registra el mayor número de cambio borrado: los tokens
+// anteriores ya no pueden enterarse de esas bajas.
+func (r *eventRepository) PurgeDeletedBefore(before time.Time) (int64, error) {
+	var purged int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		deleted := tx.Unscoped().Model(&models.Event{}).Where("deleted_at IS NOT NULL AND deleted_at < ?", before)
+		removed := tx.Model(&models.EventRemoval{}).Where("removed_at < ?", before)
+		if r.ownerID != 0 {
+			deleted = deleted.Where("owner_id = ?", r.ownerID)
+			removed = removed.Where("user_id = ?", r.ownerID)
+		}
+
+		var horizon, removedHorizon sql.NullInt64
+		if err := deleted.Session(&gorm.Session{}).Select("MAX(change_seq)").Scan(&horizon).Error; err != nil {
+			return err
+		}
+		if err := removed.Session(&gorm.Session{}).Select("MAX(change_seq)").Scan(&removedHorizon).Error; err != nil {
+			return err
+		}
+		if !horizon.Valid && !removedHorizon.Valid {
+			return nil
+		}
+		if removedHorizon.Int64 > horizon.Int64 {
+			horizon = removedHorizon
+		}
+
+		if err := tx.Where("event_id IN (?)", deleted.Session(&gorm.Session{}).Select("id")).Delete(&models.Reminder{}).Error; err != nil {
+			return err
+		}
+		result := deleted.Session(&gorm.Session{}).Delete(&models.Event{})
+		if result.Error != nil {
+			return result.Error
+		}
+		purged = result.RowsAffected
+		if err := removed.Session(&gorm.Session{}).Delete(&models.EventRemoval{}).Error; err != nil {
+			return err
+		}
+		return tx.Exec("UPDATE event_sync_horizon SET purged_seq = ? WHERE id = 1 AND purged_seq < ?", horizon.Int64, horizon.Int64).Error
+	})
+	return purged, err
+}
+
+func (r *eventRepository) GetByDate(date string) ([]models.Event, error) {
+	day, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return nil, err
+	}
+	return r.occurrencesBetween(day, day)
+}
+
+// Update guarda los campos del evento y aumenta su versión; los recordatorios se guardan con
+// ReplaceReminders. Si event.Version no es 0 solo lo guarda si el evento sigue en esa versión:
+// si cambió, devuelve ErrStaleVersion.
+func (r *eventRepository) Update(id uint, event *models.Event) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		// Updates ignora los valores false y vacíos, así que los flags legacy y la recurrencia
+		// (que puede quitarse) se escriben siempre
+		if err := r.bumpVersion(tx, id, event.Version, map[string]interface{}{
+			"reminder_day":        event.ReminderDay,
+			"reminder_day_before": event.ReminderDayBefore,
+			"rrule":               event.RRule,
+			"ex_dates":            event.ExDates,
+		}); err != nil {
+			return err
+		}
+		if err := r.scope(tx).Model(&models.Event{}).Where("id = ?", id).Omit(clause.Associations, "version").Updates(event).Error; err != nil {
+			return err
+		}
+		if event.Version != 0 {
+			event.Version++
+		}
+		return nil
+	})
+}
+
+// Replace guarda todos los campos del evento, incluidos los vacíos (Update los ignora), y
+// aumenta su versión; como Update, con event.Version comprueba que no haya cambiado
+func (r *eventRepository) Replace(event *models.Event) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := r.bumpVersion(tx, event.ID, event.Version, map[string]interface{}{}); err != nil {
+			return err
+		}
+		if err := r.scope(tx).Model(&models.Event{}).Where("id = ?", event.ID).
+			Select("*").Omit(clause.Associations, "id", "owner_id", "calendar_id", "created_at", "deleted_at", "version", "client_id").
+			Updates(event).Error; err != nil {
+			return err
+		}
+		if event.Version != 0 {
+			event.Version++
+		}
+		return nil
+	})
+}
+
+// MoveOccurrences pasa las ocurrencias separadas de la serie al calendario de ella, aumentando
+// sus versiones
+func (r *eventRepository) MoveOccurrences(seriesID, calendarID uint) error {
+	return r.query().Model(&models.Event{}).Where("recurrence_id = ?", seriesID).Updates(map[string]interface{}{
+		"calendar_id": calendarID,
+		"version":     gorm.Expr("version + 1"),
+	}).Error
+}
+
+// bumpVersion aumenta la versión del evento junto con los campos dados. Con una versión
+// esperada (distinta de 0) solo lo hace si el evento sigue en ella; si no, ErrStaleVersion.
+func (r *eventRepository) bumpVersion(tx *gorm.DB, id uint, expected int, fields map[string]interface{}) error {
+	fields["version"] = gorm.Expr("version + 1")
+	query := r.scope(tx).Model(&models.Event{}).Where("id = ?", id)
+	if expected != 0 {
+		query = query.Where("version = ?", expected)
+	}
+	result := query.Updates(fields)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 && expected != 0 {
+		return ErrStaleVersion
+	}
+	return nil
+}
+
+// ReplaceReminders reemplaza los recordatorios guardados del evento por event.Reminders
+func (r *eventRepository) ReplaceReminders(event *models.Event) error {
+	reminders := models.CopyReminders(event.Reminders)
+	for i := range reminders {
+		reminders[i].EventID = event.ID
+	}
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("event_id = ?", event.ID).Delete(&models.Reminder{}).Error; err != nil {
+			return err
+		}
+		if len(reminders) == 0 {
+			return nil
+		}
+		return tx.Create(&reminders).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	event.Reminders = reminders
+	return nil
+}
+
+// Delete elimina el evento y, si es una serie recurrente, las ocurrencias separadas de ella
+func (r *eventRepository) Delete(id uint) error {
+	return r.query().Where("id = ? OR recurrence_id = ?", id, id).Delete(&models.Event{}).Error
+}
+
+// GetTodayEvents obtiene las ocurrencias de hoy, calculando "hoy" en la zona de cada evento
+func (r *eventRepository) GetTodayEvents() ([]models.Event, error) {
+	now := time.Now()
+	events, err := r.candidatesBetween(models.QueryWindow(now.UTC(), now.UTC()))
+	if err != nil {
+		return nil, err
+	}
+	return models.ExpandToday(events, now), nil
+}
+
+// upcomingHorizon es hasta cuántos días desde hoy se buscan las próximas ocurrencias de una serie
+const upcomingHorizon = 2 * 366
+
+// GetUpcomingEvents obtiene los próximos limit eventos desde el comienzo de hoy en la zona de
+// cada evento, con las series expandidas en sus ocurrencias
+func (r *eventRepository) GetUpcomingEvents(limit int) ([]models.Event, error) {
+	now := time.Now().UTC()
+	single := "(rrule = '' OR rrule IS NULL)"
+
+	var events []models.Event
+	if err := r.withReminders().Where(single+" AND ends_at > ?", now).Order("starts_at ASC").Limit(limit).Find(&events).Error; err != nil {
+		return nil, err
+	}
+
+	// Eventos ya terminados que siguen siendo de "hoy" en su propia zona
+	var endedToday []models.Event
+	if err := r.withReminders().Where(single+" AND ends_at <= ? AND ends_at > ?", now, now.Add(-24*time.Hour-models.MaxZoneOffset)).
+		Find(&endedToday).Error; err != nil {
+		return nil, err
+	}
+	for _, event := range endedToday {
+		if event.EndsAt.After(models.StartOfDay(event.Today(now), event.Zone())) {
+			events = append(events, event)
+		}
+	}
+
+	// Las series pueden haber empezado hace mucho: se expanden desde hoy
+	var series []models.Event
+	if err := r.withReminders().Where("rrule <> ''").Find(&series).Error; err != nil {
+		return nil, err
+	}
+	for i := range series {
+		events = append(events, upcomingOccurrences(&series[i], now, limit)...)
+	}
+
+	sort.SliceStable(events, func(i, j int) bool { return events[i].StartsAt.Before(events[j].StartsAt) })
+	if len(events) > limit {
+		events = events[:limit]
+	}
+	return events, nil
+}
+
+// upcomingOccurrences devuelve las primeras limit ocurrencias de la serie desde hoy en su zona,
+// buscándolas en ventanas cada vez más largas hasta upcomingHorizon
+func upcomingOccurrences(series *models.Event, now time.Time, limit int) []models.Event {
+	today := series.Today(now)
+	for days := 31; ; days *= 4 {
+		if days > upcomingHorizon {
+			days = upcomingHorizon
+		}
+		occurrences := series.Occurrences(today, today.AddDate(0, 0, days))
+		if len(occurrences) >= limit || days == upcomingHorizon {
+			if len(occurrences) > limit {
+				occurrences = occurrences[:limit]
+			}
+			return occurrences
+		}
+	}
+}
+
 func (r *eventRepository) GetEventsForDateRange(startDate, endDate string) ([]models.Event, error) {
+	start, err := time.Parse("2006-01-02", startDate)
+	if err != nil {
+		return nil, err
+	}
+	end, err := time.Parse("2006-01-02", endDate)
+	if err != nil {
+		return nil, err
+	}
+	return r.occurrencesBetween(start, end)
+}
+
+// occurrencesBetween obtiene las ocurrencias que se superponen con los días del rango,
+// interpretados en la zona de cada evento
+func (r *eventRepository) occurrencesBetween(start, end time.Time) ([]models.Event, error) {
+	events, err := r.candidatesBetween(models.QueryWindow(start, end))
+	if err != nil {
+		return nil, err
+	}
+	return models.ExpandOccurrences(events, start, end), nil
+}
+
+// candidatesBetween obtiene los eventos simples que se superponen con la ventana y las
+// series recurrentes que empiezan antes de su fin, para expandir en memoria
+func (r *eventRepository) candidatesBetween(windowStart, windowEnd time.Time) ([]models.Event, error) {
 	var events []models.Event
-	err := r.db.Where("date BETWEEN ? AND ?", startDate, endDate).Order("date ASC, time ASC").Find(&events).Error
+	err := r.candidatesQuery(windowStart, windowEnd).Order("starts_at ASC").Find(&events).Error
 	return events, err
 }
 
-func (r *eventRepository) SearchEvents(query string) ([]models.Event, error) {
+// candidatesQuery es la consulta de candidatesBetween
+func (r *eventRepository) candidatesQuery(windowStart, windowEnd time.Time) *gorm.DB {
+	return r.withReminders().Where("((rrule = '' OR rrule IS NULL) AND starts_at < ? AND ends_at >= ?) OR (rrule <> '' AND starts_at < ?)", windowEnd, windowStart, windowEnd)
+}
+
+// Search devuelve hasta limit eventos que coinciden con la búsqueda, de mayor a menor relevancia
+func (r *eventRepository) Search(q *search.Query, limit int) ([]SearchHit, error) {
+	matches, err := r.search.Rank(r.query().Model(&models.Event{}), q, limit)
+	if err != nil || len(matches) == 0 {
+		return nil, err
+	}
+
+	ids := make([]uint, len(matches))
+	for i, match := range matches {
+		ids[i] = match.ID
+	}
 	var events []models.Event
-	err := r.db.Where("title ILIKE ? OR description ILIKE ?", "%"+query+"%", "%"+query+"%").Order("date ASC, time ASC").Find(&events).Error
-	return events, err
+	if err := r.withReminders().Where("id IN ?", ids).Find(&events).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]models.Event, len(events))
+	for _, event := range events {
+		byID[event.ID] = event
+	}
+
+	hits := make([]SearchHit, 0, len(matches))
+	for _, match := range matches {
+		if event, ok := byID[match.ID]; ok {
+			hits = append(hits, SearchHit{Event: event, Score: match.Score})
+		}
+	}
+	return hits, nil
+}
+
+// Find devuelve los eventos de la consulta en su orden, a partir del cursor y hasta Limit;
+// more indica si quedan más. Con rango se devuelven las ocurrencias de los días del rango.
+func (r *eventRepository) Find(q EventQuery) ([]models.Event, bool, error) {
+	if q.StartDate != "" {
+		return r.findOccurrences(q)
+	}
+
+	query := q.apply(r.withReminders(), r.search)
+	if q.After != nil {
+		condition, args, err := keysetCondition(q.After)
+		if err != nil {
+			return nil, false, err
+		}
+		query = query.Where(condition, args...)
+	}
+	query = query.Order(orderClause(q.Sort, q.Desc))
+	if q.Limit > 0 {
+		query = query.Limit(q.Limit + 1)
+	}
+
+	events := []models.Event{}
+	if err := query.Find(&events).Error; err != nil {
+		return nil, false, err
+	}
+	events, more := q.page(events)
+	return events, more, nil
+}
+
+// findOccurrences expande en memoria las series del rango, así que ordena y pagina las ocurrencias
+func (r *eventRepository) findOccurrences(q EventQuery) ([]models.Event, bool, error) {
+	start, end, err := q.dateRange()
+	if err != nil {
+		return nil, false, err
+	}
+
+	var candidates []models.Event
+	if err := q.apply(r.candidatesQuery(models.QueryWindow(start, end)), r.search).Find(&candidates).Error; err != nil {
+		return nil, false, err
+	}
+	events := models.ExpandOccurrences(candidates, start, end)
+	models.SortEvents(events, q.Sort, q.Desc)
+	if q.After != nil {
+		first := sort.Search(len(events), func(i int) bool { return q.After.Precedes(&events[i]) })
+		events = events[first:]
+	}
+
+	events, more := q.page(append([]models.Event{}, events...))
+	return events, more, nil
+}
+
+// Stream recorre de a lotes todos los eventos de la consulta, sin cursor ni límite y ordenados
+// por ID. Con rango, cada lote expande las series de los eventos leídos, así que dentro de cada
+// lote las ocurrencias se ordenan por inicio.
+func (r *eventRepository) Stream(q EventQuery, batchSize int, fn func(batch []models.Event) error) error {
+	if q.StartDate == "" {
+		return inBatches(q.apply(r.withReminders(), r.search), batchSize, fn)
+	}
+
+	start, end, err := q.dateRange()
+	if err != nil {
+		return err
+	}
+	return inBatches(q.apply(r.candidatesQuery(models.QueryWindow(start, end)), r.search), batchSize, func(batch []models.Event) error {
+		if occurrences := models.ExpandOccurrences(batch, start, end); len(occurrences) > 0 {
+			return fn(occurrences)
+		}
+		return nil
+	})
+}
+
+// inBatches lee la consulta de a batchSize eventos y llama a fn con cada lote
+func inBatches(query *gorm.DB, batchSize int, fn func(batch []models.Event) error) error {
+	var batch []models.Event
+	var fnErr error
+	result := query.FindInBatches(&batch, batchSize, func(tx *gorm.DB, _ int) error {
+		fnErr = fn(batch)
+		return fnErr
+	})
+	if fnErr != nil {
+		return fnErr
+	}
+	return result.Error
 }
 
 func (r *eventRepository) GetEventStats() (map[string]interface{}, error) {
 	var totalEvents int64
 	var todayEvents int64
 	var upcomingEvents int64
+	var pastEvents int64
+	var highPriority int64
+	var withReminders int64
 
-	today := time.Now().Format("2006-01-02")
+	now := time.Now()
+	todayStart, todayEnd := models.DayWindow(now, now, time.Local)
 
 	// Total events
-	r.db.Model(&models.Event{}).Count(&totalEvents)
+	r.query().Model(&models.Event{}).Count(&totalEvents)
 
-	// Today's events
-	r.db.Model(&models.Event{}).Where("date = ?", today).Count(&todayEvents)
+	// Today's events (overlapping today)
+	r.query().Model(&models.Event{}).Where("starts_at < ? AND ends_at > ?", todayEnd, todayStart).Count(&todayEvents)
 
 	// Upcoming events
-	r.db.Model(&models.Event{}).Where("date >= ?", today).Count(&upcomingEvents)
+	r.query().Model(&models.Event{}).Where("starts_at >= ?", todayStart).Count(&upcomingEvents)
+
+	// Past events
+	r.query().Model(&models.Event{}).Where("ends_at < ?", now.UTC()).Count(&pastEvents)
+
+	// High priority events
+	r.query().Model(&models.Event{}).Where("priority = ?", "high").Count(&highPriority)
+
+	// Events with reminders
+	r.query().Model(&models.Event{}).Where("EXISTS (SELECT 1 FROM reminders WHERE reminders.event_id = events.id)").Count(&withReminders)
 
 	stats := map[string]interface{}{
 		"total_events":    totalEvents,
 		"today_events":    todayEvents,
 		"upcoming_events": upcomingEvents,
+		"past_events":     pastEvents,
+		"high_priority":   highPriority,
+		"with_reminders":  withReminders,
 	}
 
 	return stats, nil
@@ -0,0 +1,73 @@
+package repositories
+
+import (
+	"calendar-backend/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type FeedRepository interface {
+	Create(feed *models.Feed) error
+	GetByOwner(ownerID uint) ([]models.Feed, error)
+	GetByID(ownerID, id uint) (*models.Feed, error)
+	GetByTokenHash(tokenHash string) (*models.Feed, error)
+	Revoke(ownerID, id uint) error
+	TouchLastUsed(id uint, at time.Time) error
+}
+
+type feedRepository struct {
+	db *gorm.DB
+}
+
+func NewFeedRepository(db *gorm.DB) FeedRepository {
+	return &feedRepository{db: db}
+}
+
+func (r *feedRepository) Create(feed *models.Feed) error {
+	return r.db.Create(feed).Error
+}
+
+func (r *feedRepository) GetByOwner(ownerID uint) ([]models.Feed, error) {
+	var feeds []models.Feed
+	err := r.db.Where("owner_id = ?", ownerID).Order("created_at ASC").Find(&feeds).Error
+	return feeds, err
+}
+
+func (r *feedRepository) GetByID(ownerID, id uint) (*models.Feed, error) {
+	var feed models.Feed
+	err := r.db.Where("owner_id = ? AND id = ?", ownerID, id).First(&feed).Error
+	if err != nil {
+		return nil, err
+	}
+	return &feed, nil
+}
+
+// GetByTokenHash devuelve la suscripción activa (no revocada) del token
+func (r *feedRepository) GetByTokenHash(tokenHash string) (*models.Feed, error) {
+	var feed models.Feed
+	err := r.db.Where("token_hash = ? AND revoked_at IS NULL", tokenHash).First(&feed).Error
+	if err != nil {
+		return nil, err
+	}
+	return &feed, nil
+}
+
+// Revoke marca la suscripción como revocada; se conserva para el listado
+func (r *feedRepository) Revoke(ownerID, id uint) error {
+	result := r.db.Model(&models.Feed{}).
+		Where("owner_id = ? AND id = ? AND revoked_at IS NULL", ownerID, id).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// TouchLastUsed registra la última descarga sin modificar updated_at, que forma parte del ETag
+func (r *feedRepository) TouchLastUsed(id uint, at time.Time) error {
+	return r.db.Model(&models.Feed{}).Where("id = ?", id).UpdateColumn("last_used_at", at).Error
+}
@@ -0,0 +1,92 @@
+package repositories
+
+import (
+	"calendar-backend/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type NotificationDeliveryRepository interface {
+	Create(delivery *models.NotificationDelivery) error
+	GetByOwner(ownerID uint, status string, limit int) ([]models.NotificationDelivery, error)
+	ClaimDue(now time.Time, lease time.Duration, limit int) ([]models.NotificationDelivery, error)
+	Save(delivery *models.NotificationDelivery) error
+	Redrive(ownerID, id uint, at time.Time) (int64, error)
+}
+
+type notificationDeliveryRepository struct {
+	db *gorm.DB
+}
+
+func NewNotificationDeliveryRepository(db *gorm.DB) NotificationDeliveryRepository {
+	return &notificationDeliveryRepository{db: db}
+}
+
+func (r *notificationDeliveryRepository) Create(delivery *models.NotificationDelivery) error {
+	return r.db.Create(delivery).Error
+}
+
+// GetByOwner devuelve los últimos envíos del usuario, del más nuevo al más viejo;
+// status vacío devuelve todos los estados
+func (r *notificationDeliveryRepository) GetByOwner(ownerID uint, status string, limit int) ([]models.NotificationDelivery, error) {
+	query := r.db.Where("owner_id = ?", ownerID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var deliveries []models.NotificationDelivery
+	err := query.Order("id DESC").Limit(limit).Find(&deliveries).Error
+	return deliveries, err
+}
+
+// ClaimDue toma los envíos pendientes cuyo intento ya venció, corriendo su próximo intento
+// lease hacia adelante, igual que los envíos a webhooks: cada envío lo toma una sola instancia
+func (r *notificationDeliveryRepository) ClaimDue(now time.Time, lease time.Duration, limit int) ([]models.NotificationDelivery, error) {
+	// La consulta se repite cada pocos segundos: solo se registran sus errores
+	quiet := r.db.Session(&gorm.Session{Logger: r.db.Logger.LogMode(logger.Warn)})
+
+	var due []models.NotificationDelivery
+	err := quiet.Where("status = ? AND next_attempt_at <= ?", models.DeliveryPending, now).
+		Order("next_attempt_at ASC").Limit(limit).Find(&due).Error
+	if err != nil {
+		return nil, err
+	}
+
+	leaseUntil := now.Add(lease)
+	claimed := due[:0]
+	for _, delivery := range due {
+		result := r.db.Model(&models.NotificationDelivery{}).
+			Where("id = ? AND status = ? AND next_attempt_at = ?", delivery.ID, models.DeliveryPending, delivery.NextAttemptAt).
+			UpdateColumn("next_attempt_at", leaseUntil)
+		if result.Error != nil {
+			return claimed, result.Error
+		}
+		if result.RowsAffected == 1 {
+			delivery.NextAttemptAt = &leaseUntil
+			claimed = append(claimed, delivery)
+		}
+	}
+	return claimed, nil
+}
+
+// Save guarda el resultado de un intento
+func (r *notificationDeliveryRepository) Save(delivery *models.NotificationDelivery) error {
+	return r.db.Save(delivery).Error
+}
+
+// Redrive vuelve a poner pendientes, con los intentos en cero, los envíos fallidos del usuario:
+// uno si id no es cero, o todos. Devuelve cuántos se reencolaron.
+func (r *notificationDeliveryRepository) Redrive(ownerID, id uint, at time.Time) (int64, error) {
+	query := r.db.Model(&models.NotificationDelivery{}).Where("owner_id = ? AND status = ?", ownerID, models.DeliveryFailed)
+	if id != 0 {
+		query = query.Where("id = ?", id)
+	}
+	result := query.Updates(map[string]interface{}{
+		"status":          models.DeliveryPending,
+		"attempts":        0,
+		"next_attempt_at": at,
+		"updated_at":      at,
+	})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,37 @@
+package repositories
+
+import (
+	"calendar-backend/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type OwnerSettingsRepository interface {
+	GetByEmail(email string) (*models.OwnerSettings, error)
+	Upsert(settings *models.OwnerSettings) error
+}
+
+type ownerSettingsRepository struct {
+	db *gorm.DB
+}
+
+func NewOwnerSettingsRepository(db *gorm.DB) OwnerSettingsRepository {
+	return &ownerSettingsRepository{db: db}
+}
+
+func (r *ownerSettingsRepository) GetByEmail(email string) (*models.OwnerSettings, error) {
+	var settings models.OwnerSettings
+	err := r.db.Where("email = ?", email).First(&settings).Error
+	if err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+func (r *ownerSettingsRepository) Upsert(settings *models.OwnerSettings) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "email"}},
+		DoUpdates: clause.AssignmentColumns([]string{"time_zone", "updated_at"}),
+	}).Create(settings).Error
+}
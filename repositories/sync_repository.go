@@ -0,0 +1,125 @@
+package repositories
+
+import (
+	"calendar-backend/models"
+
+	"gorm.io/gorm"
+)
+
+// SyncRepository guarda las cuentas externas conectadas, la relación entre los eventos
+// y sus copias externas, y la auditoría de la sincronización
+type SyncRepository interface {
+	CreateAccount(account *models.SyncAccount) error
+	GetAccounts() ([]models.SyncAccount, error)
+	GetAccountsByOwner(ownerID uint) ([]models.SyncAccount, error)
+	GetAccount(ownerID, id uint) (*models.SyncAccount, error)
+	SaveAccountState(account *models.SyncAccount) error
+	DeleteAccount(ownerID, id uint) error
+
+	GetLinkByRemoteID(accountID uint, remoteID string) (*models.SyncLink, error)
+	GetLinkByEvent(accountID, eventID uint) (*models.SyncLink, error)
+	SaveLink(link *models.SyncLink) error
+	DeleteLink(id uint) error
+
+	AddAudit(entry *models.SyncAudit) error
+	GetAudit(accountID uint, limit int) ([]models.SyncAudit, error)
+}
+
+type syncRepository struct {
+	db *gorm.DB
+}
+
+func NewSyncRepository(db *gorm.DB) SyncRepository {
+	return &syncRepository{db: db}
+}
+
+func (r *syncRepository) CreateAccount(account *models.SyncAccount) error {
+	return r.db.Create(account).Error
+}
+
+// GetAccounts devuelve todas las cuentas, para la sincronización periódica
+func (r *syncRepository) GetAccounts() ([]models.SyncAccount, error) {
+	var accounts []models.SyncAccount
+	err := r.db.Order("id ASC").Find(&accounts).Error
+	return accounts, err
+}
+
+func (r *syncRepository) GetAccountsByOwner(ownerID uint) ([]models.SyncAccount, error) {
+	var accounts []models.SyncAccount
+	err := r.db.Where("owner_id = ?", ownerID).Order("created_at ASC").Find(&accounts).Error
+	return accounts, err
+}
+
+func (r *syncRepository) GetAccount(ownerID, id uint) (*models.SyncAccount, error) {
+	var account models.SyncAccount
+	err := r.db.Where("owner_id = ? AND id = ?", ownerID, id).First(&account).Error
+	if err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// SaveAccountState guarda el token, la última sincronización y el último error
+func (r *syncRepository) SaveAccountState(account *models.SyncAccount) error {
+	return r.db.Model(&models.SyncAccount{}).Where("id = ?", account.ID).Updates(map[string]interface{}{
+		"sync_token":     account.SyncToken,
+		"last_synced_at": account.LastSyncedAt,
+		"last_error":     account.LastError,
+	}).Error
+}
+
+// DeleteAccount elimina la cuenta junto con sus relaciones y su auditoría. Los eventos
+// ya copiados se conservan en los dos calendarios.
+func (r *syncRepository) DeleteAccount(ownerID, id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("owner_id = ? AND id = ?", ownerID, id).Delete(&models.SyncAccount{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		if err := tx.Where("account_id = ?", id).Delete(&models.SyncLink{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("account_id = ?", id).Delete(&models.SyncAudit{}).Error
+	})
+}
+
+func (r *syncRepository) GetLinkByRemoteID(accountID uint, remoteID string) (*models.SyncLink, error) {
+	var link models.SyncLink
+	err := r.db.Where("account_id = ? AND remote_id = ?", accountID, remoteID).First(&link).Error
+	if err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+func (r *syncRepository) GetLinkByEvent(accountID, eventID uint) (*models.SyncLink, error) {
+	var link models.SyncLink
+	err := r.db.Where("account_id = ? AND event_id = ?", accountID, eventID).First(&link).Error
+	if err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+// SaveLink crea o actualiza la relación
+func (r *syncRepository) SaveLink(link *models.SyncLink) error {
+	return r.db.Save(link).Error
+}
+
+func (r *syncRepository) DeleteLink(id uint) error {
+	return r.db.Delete(&models.SyncLink{}, id).Error
+}
+
+func (r *syncRepository) AddAudit(entry *models.SyncAudit) error {
+	return r.db.Create(entry).Error
+}
+
+// GetAudit devuelve las últimas entradas de la auditoría de la cuenta, la más reciente primero
+func (r *syncRepository) GetAudit(accountID uint, limit int) ([]models.SyncAudit, error) {
+	var entries []models.SyncAudit
+	err := r.db.Where("account_id = ?", accountID).Order("id DESC").Limit(limit).Find(&entries).Error
+	return entries, err
+}
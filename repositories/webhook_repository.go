@@ -0,0 +1,134 @@
+package repositories
+
+import (
+	"calendar-backend/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type WebhookRepository interface {
+	Create(webhook *models.Webhook) error
+	GetByOwner(ownerID uint) ([]models.Webhook, error)
+	GetByID(ownerID, id uint) (*models.Webhook, error)
+	GetWebhook(id uint) (*models.Webhook, error)
+	GetActiveByOwner(ownerID uint) ([]models.Webhook, error)
+	SetDisabled(ownerID, id uint, disabled bool) error
+	CreateDeliveries(deliveries []models.WebhookDelivery) error
+	GetDeliveries(webhookID uint, limit int) ([]models.WebhookDelivery, error)
+	ClaimDueDeliveries(now time.Time, lease time.Duration, limit int) ([]models.WebhookDelivery, error)
+	SaveDelivery(delivery *models.WebhookDelivery) error
+}
+
+type webhookRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhookRepository(db *gorm.DB) WebhookRepository {
+	return &webhookRepository{db: db}
+}
+
+func (r *webhookRepository) Create(webhook *models.Webhook) error {
+	return r.db.Create(webhook).Error
+}
+
+func (r *webhookRepository) GetByOwner(ownerID uint) ([]models.Webhook, error) {
+	var webhooks []models.Webhook
+	err := r.db.Where("owner_id = ?", ownerID).Order("created_at ASC").Find(&webhooks).Error
+	return webhooks, err
+}
+
+func (r *webhookRepository) GetByID(ownerID, id uint) (*models.Webhook, error) {
+	var webhook models.Webhook
+	err := r.db.Where("owner_id = ? AND id = ?", ownerID, id).First(&webhook).Error
+	if err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+// GetWebhook devuelve el webhook de cualquier usuario, para enviarle sus avisos
+func (r *webhookRepository) GetWebhook(id uint) (*models.Webhook, error) {
+	var webhook models.Webhook
+	if err := r.db.First(&webhook, id).Error; err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+// GetActiveByOwner devuelve los webhooks del usuario que no están desactivados
+func (r *webhookRepository) GetActiveByOwner(ownerID uint) ([]models.Webhook, error) {
+	var webhooks []models.Webhook
+	err := r.db.Where("owner_id = ? AND disabled_at IS NULL", ownerID).Find(&webhooks).Error
+	return webhooks, err
+}
+
+// SetDisabled desactiva el webhook o lo vuelve a activar
+func (r *webhookRepository) SetDisabled(ownerID, id uint, disabled bool) error {
+	var disabledAt *time.Time
+	if disabled {
+		now := time.Now()
+		disabledAt = &now
+	}
+	result := r.db.Model(&models.Webhook{}).
+		Where("owner_id = ? AND id = ?", ownerID, id).
+		Update("disabled_at", disabledAt)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *webhookRepository) CreateDeliveries(deliveries []models.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return r.db.Create(&deliveries).Error
+}
+
+// GetDeliveries devuelve los últimos envíos del webhook, del más nuevo al más viejo
+func (r *webhookRepository) GetDeliveries(webhookID uint, limit int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	err := r.db.Where("webhook_id = ?", webhookID).Order("id DESC").Limit(limit).Find(&deliveries).Error
+	return deliveries, err
+}
+
+// ClaimDueDeliveries toma los envíos pendientes cuyo intento ya venció, corriendo su próximo
+// intento lease hacia adelante. Cada envío lo toma una sola instancia del servidor: si el
+// intento no termina (ej: se detuvo la instancia), otra lo vuelve a tomar al vencer lease.
+func (r *webhookRepository) ClaimDueDeliveries(now time.Time, lease time.Duration, limit int) ([]models.WebhookDelivery, error) {
+	// La consulta se repite cada pocos segundos: solo se registran sus errores
+	quiet := r.db.Session(&gorm.Session{Logger: r.db.Logger.LogMode(logger.Warn)})
+
+	var due []models.WebhookDelivery
+	err := quiet.Where("status = ? AND next_attempt_at <= ?", models.DeliveryPending, now).
+		Order("next_attempt_at ASC").Limit(limit).Find(&due).Error
+	if err != nil {
+		return nil, err
+	}
+
+	leaseUntil := now.Add(lease)
+	claimed := due[:0]
+	for _, delivery := range due {
+		result := r.db.Model(&models.WebhookDelivery{}).
+			Where("id = ? AND status = ? AND next_attempt_at = ?", delivery.ID, models.DeliveryPending, delivery.NextAttemptAt).
+			UpdateColumn("next_attempt_at", leaseUntil)
+		if result.Error != nil {
+			return claimed, result.Error
+		}
+		if result.RowsAffected == 1 {
+			delivery.NextAttemptAt = &leaseUntil
+			claimed = append(claimed, delivery)
+		}
+	}
+	return claimed, nil
+}
+
+// SaveDelivery guarda el resultado de un intento
+func (r *webhookRepository) SaveDelivery(delivery *models.WebhookDelivery) error {
+	return r.db.Save(delivery).Error
+}
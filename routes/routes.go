@@ -7,25 +7,130 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func SetupRoutes(router *gin.Engine, eventController *handlers.EventController) {
-	// API v1 group
-	v1 := router.Group("/api/v1")
+func SetupRoutes(router *gin.Engine, authController *handlers.AuthController, eventController *handlers.EventController, settingsController *handlers.SettingsController, feedController *handlers.FeedController, appPasswordController *handlers.AppPasswordController, syncController *handlers.SyncController, webhookController *handlers.WebhookController, calendarController *handlers.CalendarController, notificationController *handlers.NotificationController, authMiddleware gin.HandlerFunc) {
+	// Public authentication endpoints
+	auth := router.Group("/api/v1/auth")
 	{
+		auth.POST("/register", authController.Register)
+		auth.POST("/login", authController.Login)
+		auth.POST("/refresh", authController.Refresh)
+	}
+
+	// Public subscription feeds, authenticated by the secret token in the URL (/feeds/{token}.ics)
+	router.GET("/feeds/:token", feedController.ServeFeed)
+
+	// API v1 group (requires an access token)
+	v1 := router.Group("/api/v1", authMiddleware)
+	{
+		v1.GET("/auth/me", authController.Me)
+
 		// Events endpoints
 		events := v1.Group("/events")
 		{
 			events.POST("/", eventController.CreateEvent)
+			events.POST("/batch", eventController.ApplyMutations)
+			events.POST("/import", eventController.ImportCalendar)
+			events.POST("/import/csv", eventController.ImportCSV)
 			events.GET("/", eventController.GetEvents)
+			events.GET("/search", eventController.SearchEvents)
 			events.GET("/:id", eventController.GetEvent)
 			events.PUT("/:id", eventController.UpdateEvent)
 			events.DELETE("/:id", eventController.DeleteEvent)
+			events.GET("/:id/ics", eventController.ExportEvent)
+		}
+
+		// iCalendar and CSV export of the whole calendar, a date range or a search
+		v1.GET("/calendar.ics", eventController.ExportCalendar)
+		v1.GET("/calendar.csv", eventController.ExportCSV)
+
+		// Owner settings endpoints
+		v1.GET("/settings", settingsController.GetSettings)
+		v1.PUT("/settings", settingsController.UpdateSettings)
+
+		// Subscription feed management
+		feeds := v1.Group("/feeds")
+		{
+			feeds.POST("/", feedController.CreateFeed)
+			feeds.GET("/", feedController.GetFeeds)
+			feeds.DELETE("/:id", feedController.RevokeFeed)
+		}
+
+		// App passwords for CalDAV clients
+		appPasswords := v1.Group("/app-passwords")
+		{
+			appPasswords.POST("/", appPasswordController.CreateAppPassword)
+			appPasswords.GET("/", appPasswordController.GetAppPasswords)
+			appPasswords.DELETE("/:id", appPasswordController.RevokeAppPassword)
+		}
+
+		// External calendar sync
+		syncAccounts := v1.Group("/sync/accounts")
+		{
+			syncAccounts.POST("/", syncController.ConnectAccount)
+			syncAccounts.GET("/", syncController.GetAccounts)
+			syncAccounts.DELETE("/:id", syncController.DisconnectAccount)
+			syncAccounts.POST("/:id/sync", syncController.SyncAccount)
+			syncAccounts.GET("/:id/audit", syncController.GetAudit)
+		}
+
+		// Outbound webhooks for event changes and reminders
+		webhooks := v1.Group("/webhooks")
+		{
+			webhooks.POST("/", webhookController.CreateWebhook)
+			webhooks.GET("/", webhookController.GetWebhooks)
+			webhooks.POST("/:id/test", webhookController.TestWebhook)
+			webhooks.POST("/:id/disable", webhookController.DisableWebhook)
+			webhooks.POST("/:id/enable", webhookController.EnableWebhook)
+			webhooks.GET("/:id/deliveries", webhookController.GetDeliveries)
+		}
+
+		// Reminder notifications that failed on a channel: retries and re-driving the failed ones
+		notifications := v1.Group("/notifications/deliveries")
+		{
+			notifications.GET("/", notificationController.GetDeliveries)
+			notifications.POST("/redrive", notificationController.RedriveDeliveries)
+			notifications.POST("/:id/redrive", notificationController.RedriveDelivery)
+		}
+
+		// Calendars and sharing them with other users
+		calendars := v1.Group("/calendars")
+		{
+			calendars.POST("/", calendarController.CreateCalendar)
+			calendars.GET("/", calendarController.GetCalendars)
+			calendars.GET("/:id", calendarController.GetCalendar)
+			calendars.PUT("/:id", calendarController.UpdateCalendar)
+			calendars.DELETE("/:id", calendarController.DeleteCalendar)
+			calendars.GET("/:id/shares", calendarController.GetShares)
+			calendars.PUT("/:id/shares", calendarController.ShareCalendar)
+			calendars.DELETE("/:id/shares/:userId", calendarController.UnshareCalendar)
 		}
 	}
 }
 
-func SetupMobileRoutes(router *gin.Engine, mobileHandler *handlers.MobileHandler) {
-	// Mobile API group
-	mobile := router.Group("/api/mobile")
+func SetupCalDAVRoutes(router *gin.Engine, caldavController *handlers.CalDAVController, basicAuthMiddleware gin.HandlerFunc) {
+	// Service discovery (RFC 6764)
+	router.GET("/.well-known/caldav", caldavController.WellKnown)
+	router.Handle("PROPFIND", "/.well-known/caldav", caldavController.WellKnown)
+
+	// CalDAV group (requires the email and an app password over HTTP Basic)
+	dav := router.Group("/dav", basicAuthMiddleware)
+	for _, method := range handlers.CalDAVMethods {
+		dav.Handle(method, "/*path", caldavController.ServeDAV)
+	}
+}
+
+// SetupStreamRoutes sets up the real-time change streams (Server-Sent Events and WebSocket)
+func SetupStreamRoutes(router *gin.Engine, streamController *handlers.StreamController, streamAuthMiddleware gin.HandlerFunc) {
+	stream := router.Group("/api/v1/stream", streamAuthMiddleware)
+	{
+		stream.GET("", streamController.Events)
+		stream.GET("/ws", streamController.WebSocket)
+	}
+}
+
+func SetupMobileRoutes(router *gin.Engine, mobileHandler *handlers.MobileHandler, authMiddleware gin.HandlerFunc) {
+	// Mobile API group (requires an access token)
+	mobile := router.Group("/api/mobile", authMiddleware)
 	{
 		// Mobile-optimized endpoints
 		mobile.GET("/events/today", mobileHandler.GetTodayEvents)
@@ -33,16 +138,23 @@ func SetupMobileRoutes(router *gin.Engine, mobileHandler *handlers.MobileHandler
 		mobile.GET("/events/range", mobileHandler.GetEventsForDateRange)
 		mobile.GET("/events/search", mobileHandler.SearchEvents)
 		mobile.GET("/stats", mobileHandler.GetEventStats)
+		mobile.GET("/sync", mobileHandler.Sync)
 	}
 }
 
-// SetupAllRoutes sets up both regular and mobile routes
-func SetupAllRoutes(router *gin.Engine, eventController *handlers.EventController, mobileHandler *handlers.MobileHandler) {
+// SetupAllRoutes sets up the regular, mobile and CalDAV routes
+func SetupAllRoutes(router *gin.Engine, authController *handlers.AuthController, eventController *handlers.EventController, settingsController *handlers.SettingsController, feedController *handlers.FeedController, appPasswordController *handlers.AppPasswordController, caldavController *handlers.CalDAVController, syncController *handlers.SyncController, webhookController *handlers.WebhookController, calendarController *handlers.CalendarController, notificationController *handlers.NotificationController, streamController *handlers.StreamController, mobileHandler *handlers.MobileHandler, authMiddleware, streamAuthMiddleware, basicAuthMiddleware gin.HandlerFunc) {
 	// Setup regular routes
-	SetupRoutes(router, eventController)
+	SetupRoutes(router, authController, eventController, settingsController, feedController, appPasswordController, syncController, webhookController, calendarController, notificationController, authMiddleware)
+
+	// Setup real-time change stream routes
+	SetupStreamRoutes(router, streamController, streamAuthMiddleware)
 
 	// Setup mobile routes
-	SetupMobileRoutes(router, mobileHandler)
+	SetupMobileRoutes(router, mobileHandler, authMiddleware)
+
+	// Setup CalDAV routes
+	SetupCalDAVRoutes(router, caldavController, basicAuthMiddleware)
 
 	// Health check endpoint
 	router.GET("/health", func(c *gin.Context) {
@@ -86,9 +198,18 @@ func SetupAllRoutes(router *gin.Engine, eventController *handlers.EventControlle
 			"message": "Welcome to Calendar API",
 			"version": "1.0.0",
 			"endpoints": gin.H{
-				"events": "/api/v1/events",
-				"mobile": "/api/mobile",
-				"health": "/health",
+				"auth":          "/api/v1/auth",
+				"events":        "/api/v1/events",
+				"calendars":     "/api/v1/calendars",
+				"settings":      "/api/v1/settings",
+				"feeds":         "/api/v1/feeds",
+				"caldav":        "/dav/",
+				"sync":          "/api/v1/sync/accounts",
+				"webhooks":      "/api/v1/webhooks",
+				"notifications": "/api/v1/notifications/deliveries",
+				"stream":        "/api/v1/stream",
+				"mobile":        "/api/mobile",
+				"health":        "/health",
 			},
 		})
 	})
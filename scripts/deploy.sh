@@ -12,7 +12,7 @@ echo "🚀 Desplegando $APP_NAME en $SERVER"
 
 # 1. Compilar la aplicación
 echo "📦 Compilando aplicación..."
-go build -o calendar-backend .
+go build -tags sqlite_fts5 -o calendar-backend .
 
 # 2. Crear archivo de configuración systemd
 echo "⚙️  Creando servicio systemd..."
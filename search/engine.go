@@ -0,0 +1,57 @@
+package search
+
+import (
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// Engine busca eventos con el índice de texto completo de una base
+type Engine interface {
+	// Name identifica el motor en los logs
+	Name() string
+	// Where agrega a una consulta sobre events la condición de que coincidan con la búsqueda
+	Where(query *gorm.DB, q *Query) *gorm.DB
+	// Rank devuelve los eventos de una consulta sobre events que coinciden con la búsqueda,
+	// de mayor a menor relevancia
+	Rank(query *gorm.DB, q *Query, limit int) ([]Match, error)
+}
+
+// Match es un evento que coincide con la búsqueda y su relevancia (mayor es más relevante)
+type Match struct {
+	ID    uint
+	Score float64
+}
+
+// Prepare deja la base lista para migrar: si SQLite no incluye FTS5, quita los triggers que
+// haya dejado un índice creado por un binario con FTS5. Se llama antes de migrar el esquema.
+func Prepare(db *gorm.DB) error {
+	if db.Dialector.Name() != "sqlite" {
+		return nil
+	}
+	enabled, err := hasFTS5(db)
+	if err != nil || enabled {
+		return err
+	}
+	return dropFTS5Triggers(db)
+}
+
+// Setup crea o actualiza el índice de búsqueda de la base y devuelve el motor que lo usa.
+// Si SQLite no incluye FTS5 (se habilita compilando con -tags sqlite_fts5) se busca con LIKE.
+func Setup(db *gorm.DB) (Engine, error) {
+	switch db.Dialector.Name() {
+	case "postgres":
+		return setupPostgres(db)
+	case "sqlite":
+		enabled, err := hasFTS5(db)
+		if err != nil {
+			return nil, err
+		}
+		if !enabled {
+			log.Printf("SQLite was built without FTS5, event search falls back to LIKE (build with -tags sqlite_fts5)")
+			return likeEngine{}, nil
+		}
+		return setupFTS5(db)
+	}
+	return likeEngine{}, nil
+}
@@ -0,0 +1,169 @@
+package search
+
+import (
+	"html"
+	"strings"
+	"unicode"
+)
+
+// Marcas que rodean las palabras que coinciden en los fragmentos
+const (
+	MarkStart = "<mark>"
+	MarkEnd   = "</mark>"
+)
+
+// Pesos de cada campo en la relevancia, como los de setweight en PostgreSQL (A, B, C)
+const (
+	titleWeight       = 1.0
+	placeWeight       = 0.4 // Ubicación y categoría
+	descriptionWeight = 0.2
+)
+
+// Fields son los campos de un evento que abarca la búsqueda
+type Fields struct {
+	Title       string
+	Description string
+	Location    string
+	Category    string
+}
+
+// Highlights devuelve, por campo, el fragmento resaltado de los campos que coinciden con la búsqueda
+func Highlights(q *Query, fields Fields, maxWords int) map[string]string {
+	highlights := make(map[string]string)
+	for name, text := range map[string]string{
+		"title":       fields.Title,
+		"description": fields.Description,
+		"location":    fields.Location,
+		"category":    fields.Category,
+	} {
+		if snippet := Highlight(text, q, maxWords); snippet != "" {
+			highlights[name] = snippet
+		}
+	}
+	return highlights
+}
+
+// Highlight devuelve un fragmento de hasta maxWords palabras alrededor de la primera
+// coincidencia, escapado para HTML y con las palabras que coinciden entre MarkStart y
+// MarkEnd. Devuelve "" si el texto no coincide.
+func Highlight(text string, q *Query, maxWords int) string {
+	spans := wordSpans(text)
+	matches := make([]bool, len(spans))
+	first := -1
+	for i, span := range spans {
+		if q.matchesWord(Fold(text[span[0]:span[1]])) {
+			matches[i] = true
+			if first < 0 {
+				first = i
+			}
+		}
+	}
+	if first < 0 {
+		return ""
+	}
+
+	// Ventana de palabras que empieza un poco antes de la primera coincidencia
+	from, to := 0, len(spans)
+	if maxWords > 0 && len(spans) > maxWords {
+		from = first - maxWords/4
+		if from < 0 {
+			from = 0
+		}
+		if from+maxWords > len(spans) {
+			from = len(spans) - maxWords
+		}
+		to = from + maxWords
+	}
+
+	var b strings.Builder
+	start := 0
+	if from > 0 {
+		b.WriteString("…")
+		start = spans[from][0]
+	}
+	for i := from; i < to; i++ {
+		b.WriteString(html.EscapeString(text[start:spans[i][0]]))
+		word := html.EscapeString(text[spans[i][0]:spans[i][1]])
+		if matches[i] {
+			word = MarkStart + word + MarkEnd
+		}
+		b.WriteString(word)
+		start = spans[i][1]
+	}
+	if to < len(spans) {
+		b.WriteString("…")
+	} else {
+		b.WriteString(html.EscapeString(text[start:]))
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// Score calcula la relevancia de los campos para la búsqueda cuando la base no la calcula:
+// suma, por término, el peso de los campos que lo contienen
+func Score(q *Query, fields Fields) float64 {
+	score := 0.0
+	for _, term := range q.Terms {
+		for _, field := range []struct {
+			text   string
+			weight float64
+		}{
+			{fields.Title, titleWeight},
+			{fields.Location, placeWeight},
+			{fields.Category, placeWeight},
+			{fields.Description, descriptionWeight},
+		} {
+			if term.matches(Fold(field.text)) {
+				score += field.weight
+			}
+		}
+	}
+	return score
+}
+
+// minStemLength es el largo mínimo de una palabra del texto para coincidir con una palabra
+// buscada más larga (ej: "reunion" con "reuniones")
+const minStemLength = 4
+
+// matchesWord indica si una palabra (sin acentos) coincide con alguna palabra de la búsqueda.
+// Basta con que una empiece con la otra, para aproximar la reducción a la raíz de los índices.
+func (q *Query) matchesWord(word string) bool {
+	for _, term := range q.Terms {
+		for _, w := range term.Words {
+			w = Fold(w)
+			if strings.HasPrefix(word, w) || (len(word) >= minStemLength && strings.HasPrefix(w, word)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matches indica si el texto (sin acentos) contiene el término
+func (t Term) matches(folded string) bool {
+	words := make([]string, len(t.Words))
+	for i, w := range t.Words {
+		words[i] = Fold(w)
+	}
+	phrase := strings.Join(words, " ")
+	return strings.Contains(" "+strings.Join(Words(folded), " "), " "+phrase)
+}
+
+// wordSpans devuelve el inicio y el fin (en bytes) de cada palabra del texto
+func wordSpans(text string) [][2]int {
+	var spans [][2]int
+	start := -1
+	for i, r := range text {
+		isWord := unicode.IsLetter(r) || unicode.IsNumber(r)
+		switch {
+		case isWord && start < 0:
+			start = i
+		case !isWord && start >= 0:
+			spans = append(spans, [2]int{start, i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		spans = append(spans, [2]int{start, len(text)})
+	}
+	return spans
+}
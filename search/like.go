@@ -0,0 +1,47 @@
+package search
+
+import (
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// likeEngine busca sin índice, con LIKE sobre cada campo. Compara en minúsculas pero no
+// ignora los acentos; la relevancia se calcula en memoria.
+type likeEngine struct{}
+
+func (likeEngine) Name() string {
+	return "LIKE (no full-text index)"
+}
+
+func (likeEngine) Where(query *gorm.DB, q *Query) *gorm.DB {
+	for _, term := range q.Terms {
+		pattern := "%" + strings.Join(term.Words, " ") + "%"
+		query = query.Where("LOWER(events.title) LIKE ? OR LOWER(events.description) LIKE ? OR LOWER(events.location) LIKE ? OR LOWER(events.category) LIKE ?",
+			pattern, pattern, pattern, pattern)
+	}
+	return query
+}
+
+func (e likeEngine) Rank(query *gorm.DB, q *Query, limit int) ([]Match, error) {
+	var rows []struct {
+		ID uint
+		Fields
+	}
+	err := e.Where(query, q).Select("events.id, events.title, events.description, events.location, events.category").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	matches := make([]Match, len(rows))
+	for i, row := range rows {
+		matches[i] = Match{ID: row.ID, Score: Score(q, row.Fields)}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Score > matches[j].Score })
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches, nil
+}
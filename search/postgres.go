@@ -0,0 +1,89 @@
+package search
+
+import (
+	"log"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// El documento de cada evento combina los análisis en español y en inglés, sin acentos.
+// El título pesa más (A) que la ubicación y la categoría (B) y la descripción (C).
+const postgresDocument = `CREATE OR REPLACE FUNCTION events_search_document(title text, description text, location text, category text)
+RETURNS tsvector AS $$
+	SELECT setweight(to_tsvector('spanish', events_search_fold(coalesce(title, ''))), 'A') ||
+		setweight(to_tsvector('english', events_search_fold(coalesce(title, ''))), 'A') ||
+		setweight(to_tsvector('spanish', events_search_fold(coalesce(location, '') || ' ' || coalesce(category, ''))), 'B') ||
+		setweight(to_tsvector('english', events_search_fold(coalesce(location, '') || ' ' || coalesce(category, ''))), 'B') ||
+		setweight(to_tsvector('spanish', events_search_fold(coalesce(description, ''))), 'C') ||
+		setweight(to_tsvector('english', events_search_fold(coalesce(description, ''))), 'C')
+$$ LANGUAGE sql IMMUTABLE PARALLEL SAFE`
+
+var postgresStatements = []string{
+	postgresDocument,
+	`ALTER TABLE events ADD COLUMN IF NOT EXISTS search_vector tsvector
+		GENERATED ALWAYS AS (events_search_document(title, description, location, category)) STORED`,
+	`CREATE INDEX IF NOT EXISTS idx_events_search_vector ON events USING GIN (search_vector)`,
+}
+
+// postgresQuery es la búsqueda como tsquery: coincide con el análisis en español o en inglés
+const postgresQuery = "(to_tsquery('spanish', events_search_fold(?)) || to_tsquery('english', events_search_fold(?)))"
+
+type postgresEngine struct{}
+
+func setupPostgres(db *gorm.DB) (Engine, error) {
+	// Sin la extensión unaccent (ej: sin permisos para crearla) no se ignoran los acentos
+	fold := "SELECT public.unaccent('public.unaccent', $1)"
+	if err := db.Exec("CREATE EXTENSION IF NOT EXISTS unaccent").Error; err != nil {
+		log.Printf("Could not enable the unaccent extension, event search will be accent sensitive: %v", err)
+		fold = "SELECT $1"
+	}
+	err := db.Exec("CREATE OR REPLACE FUNCTION events_search_fold(text) RETURNS text AS $$ " + fold +
+		" $$ LANGUAGE sql IMMUTABLE PARALLEL SAFE STRICT").Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, statement := range postgresStatements {
+		if err := db.Exec(statement).Error; err != nil {
+			return nil, err
+		}
+	}
+	return postgresEngine{}, nil
+}
+
+func (postgresEngine) Name() string {
+	return "PostgreSQL full-text search"
+}
+
+func (postgresEngine) Where(query *gorm.DB, q *Query) *gorm.DB {
+	expr := tsquery(q)
+	return query.Where("events.search_vector @@ "+postgresQuery, expr, expr)
+}
+
+func (postgresEngine) Rank(query *gorm.DB, q *Query, limit int) ([]Match, error) {
+	expr := tsquery(q)
+	var matches []Match
+	err := query.Where("events.search_vector @@ "+postgresQuery, expr, expr).
+		Select("events.id AS id, ts_rank_cd(events.search_vector, "+postgresQuery+") AS score", expr, expr).
+		Order("score DESC").Limit(limit).
+		Scan(&matches).Error
+	return matches, err
+}
+
+// tsquery traduce la búsqueda a la sintaxis de to_tsquery: las frases con <->, los prefijos
+// con :* y los términos con &. Las palabras solo tienen letras y números, así que no
+// pueden agregar operadores.
+func tsquery(q *Query) string {
+	terms := make([]string, len(q.Terms))
+	for i, term := range q.Terms {
+		terms[i] = strings.Join(term.Words, " <-> ")
+		if term.Prefix {
+			terms[i] += ":*"
+		}
+		if term.Phrase() {
+			terms[i] = "(" + terms[i] + ")"
+		}
+	}
+	return strings.Join(terms, " & ")
+}
@@ -0,0 +1,85 @@
+// Package search implementa la búsqueda de texto completo de eventos: sobre PostgreSQL
+// (tsvector con índice GIN), sobre SQLite (FTS5) o, si SQLite no tiene FTS5, con LIKE.
+// La búsqueda abarca título, descripción, ubicación y categoría, sin distinguir acentos.
+package search
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+)
+
+// ErrEmptyQuery indica que la búsqueda no tiene ninguna palabra
+var ErrEmptyQuery = errors.New("search query must contain at least one word")
+
+// maxTerms limita la cantidad de términos de una búsqueda
+const maxTerms = 10
+
+// Query es una búsqueda: coinciden los eventos que contienen todos sus términos
+type Query struct {
+	Terms []Term
+}
+
+// Term es una palabra, una frase ("reunión de equipo") o un prefijo (reun*)
+type Term struct {
+	Words  []string // Palabras en minúsculas, con sus acentos
+	Prefix bool     // La última palabra es un prefijo
+}
+
+// Phrase indica si el término son varias palabras seguidas
+func (t Term) Phrase() bool {
+	return len(t.Words) > 1
+}
+
+// Parse interpreta el texto de una búsqueda: las comillas agrupan frases y un * al final
+// de una palabra la vuelve prefijo. Los demás signos separan palabras.
+func Parse(text string) (*Query, error) {
+	query := &Query{}
+	for i, segment := range strings.Split(text, `"`) {
+		// Los segmentos impares están entre comillas
+		if i%2 == 1 {
+			query.add(segment, false)
+			continue
+		}
+		for _, token := range strings.Fields(segment) {
+			query.add(strings.TrimSuffix(token, "*"), strings.HasSuffix(token, "*"))
+		}
+	}
+
+	if len(query.Terms) == 0 {
+		return nil, ErrEmptyQuery
+	}
+	if len(query.Terms) > maxTerms {
+		query.Terms = query.Terms[:maxTerms]
+	}
+	return query, nil
+}
+
+func (q *Query) add(text string, prefix bool) {
+	words := Words(strings.ToLower(text))
+	if len(words) > 0 {
+		q.Terms = append(q.Terms, Term{Words: words, Prefix: prefix})
+	}
+}
+
+// Words divide el texto en palabras: secuencias de letras y números
+func Words(text string) []string {
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// Reemplazos de Fold: las letras con acento o diéresis y la ñ por su letra base
+var foldReplacer = strings.NewReplacer(
+	"á", "a", "à", "a", "ä", "a", "â", "a", "ã", "a",
+	"é", "e", "è", "e", "ë", "e", "ê", "e",
+	"í", "i", "ì", "i", "ï", "i", "î", "i",
+	"ó", "o", "ò", "o", "ö", "o", "ô", "o", "õ", "o",
+	"ú", "u", "ù", "u", "ü", "u", "û", "u",
+	"ñ", "n", "ç", "c",
+)
+
+// Fold pasa el texto a minúsculas y sin acentos, como lo comparan los índices
+func Fold(text string) string {
+	return foldReplacer.Replace(strings.ToLower(text))
+}
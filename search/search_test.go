@@ -0,0 +1,99 @@
+package search
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func mustParse(t *testing.T, text string) *Query {
+	t.Helper()
+	q, err := Parse(text)
+	if err != nil {
+		t.Fatalf("parse %q: %v", text, err)
+	}
+	return q
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		text string
+		want []Term
+	}{
+		{"Reunión", []Term{{Words: []string{"reunión"}}}},
+		{`reun* "Sala Norte" café`, []Term{{Words: []string{"reun"}, Prefix: true}, {Words: []string{"sala", "norte"}}, {Words: []string{"café"}}}},
+		{"dentista, 10:30", []Term{{Words: []string{"dentista"}}, {Words: []string{"10", "30"}}}},
+		{`"sin cerrar`, []Term{{Words: []string{"sin", "cerrar"}}}},
+	}
+	for _, tt := range tests {
+		if got := mustParse(t, tt.text).Terms; !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Parse(%q) = %+v, want %+v", tt.text, got, tt.want)
+		}
+	}
+	for _, text := range []string{"", "  ", `"" *`, "¿?"} {
+		if _, err := Parse(text); err != ErrEmptyQuery {
+			t.Errorf("Parse(%q): %v, want ErrEmptyQuery", text, err)
+		}
+	}
+	if q := mustParse(t, strings.Repeat("a ", 2*maxTerms)); len(q.Terms) != maxTerms {
+		t.Errorf("%d terms, want the first %d", len(q.Terms), maxTerms)
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	long := "Una dos tres cuatro cinco seis siete ocho nueve diez reunión once doce trece catorce quince"
+	tests := []struct {
+		name     string
+		text     string
+		query    string
+		maxWords int
+		want     string
+	}{
+		{"marks every match", "Reunión de equipo y reuniones", "reunion", 0, "<mark>Reunión</mark> de equipo y <mark>reuniones</mark>"},
+		{"ignores accents and case", "CAFÉ con Ñandú", "cafe nandu", 0, "<mark>CAFÉ</mark> con <mark>Ñandú</mark>"},
+		{"prefix", "Revisión de presupuesto", "presu*", 0, "Revisión de <mark>presupuesto</mark>"},
+		{"escapes html", `<b>Cena</b> & "vino"`, "cena", 0, "&lt;b&gt;<mark>Cena</mark>&lt;/b&gt; &amp; &#34;vino&#34;"},
+		{"window around the first match", long, "reunion", 8, "…nueve diez <mark>reunión</mark> once doce trece catorce quince"},
+		{"window at the start", long, "dos", 4, "Una <mark>dos</mark> tres cuatro…"},
+		{"no match", "Dentista", "reunion", 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Highlight(tt.text, mustParse(t, tt.query), tt.maxWords); got != tt.want {
+				t.Errorf("Highlight = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	highlights := Highlights(mustParse(t, "norte"), Fields{Title: "Reunión", Location: "Sala Norte", Description: "Piso norte"}, 0)
+	want := map[string]string{"location": "Sala <mark>Norte</mark>", "description": "Piso <mark>norte</mark>"}
+	if !reflect.DeepEqual(highlights, want) {
+		t.Errorf("Highlights = %v, want %v", highlights, want)
+	}
+}
+
+func TestScoreWeighsFields(t *testing.T) {
+	q := mustParse(t, `"sala norte"`)
+	title := Score(q, Fields{Title: "Sala Norte"})
+	location := Score(q, Fields{Location: "Sala Norte"})
+	description := Score(q, Fields{Description: "en la sala norte"})
+	if !(title > location && location > description && description > 0) {
+		t.Errorf("scores title %v, location %v, description %v, want them in that order", title, location, description)
+	}
+	if score := Score(q, Fields{Title: "Norte sala"}); score != 0 {
+		t.Errorf("words out of order score %v, want 0", score)
+	}
+	if both := Score(mustParse(t, "sala norte"), Fields{Title: "Sala", Description: "norte"}); both != titleWeight+descriptionWeight {
+		t.Errorf("two terms score %v, want the sum of their fields", both)
+	}
+}
+
+func TestEngineQuerySyntax(t *testing.T) {
+	q := mustParse(t, `reun* "sala norte" café`)
+	if got, want := fts5Match(q), `"reun"* "sala norte" "café"`; got != want {
+		t.Errorf("fts5Match = %q, want %q", got, want)
+	}
+	if got, want := tsquery(q), "reun:* & (sala <-> norte) & café"; got != want {
+		t.Errorf("tsquery = %q, want %q", got, want)
+	}
+}
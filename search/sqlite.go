@@ -0,0 +1,97 @@
+package search
+
+import (
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// El índice FTS5 toma el contenido de la tabla events (external content) y los triggers lo
+// mantienen al día. El tokenizer ignora los acentos y reduce las palabras a su raíz (porter).
+var fts5Statements = []string{
+	`CREATE VIRTUAL TABLE IF NOT EXISTS events_fts USING fts5(
+		title, description, location, category,
+		content='events', content_rowid='id',
+		tokenize='porter unicode61 remove_diacritics 2'
+	)`,
+	`CREATE TRIGGER IF NOT EXISTS events_fts_insert AFTER INSERT ON events BEGIN
+		INSERT INTO events_fts(rowid, title, description, location, category)
+		VALUES (new.id, new.title, new.description, new.location, new.category);
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS events_fts_delete AFTER DELETE ON events BEGIN
+		INSERT INTO events_fts(events_fts, rowid, title, description, location, category)
+		VALUES ('delete', old.id, old.title, old.description, old.location, old.category);
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS events_fts_update AFTER UPDATE OF title, description, location, category ON events BEGIN
+		INSERT INTO events_fts(events_fts, rowid, title, description, location, category)
+		VALUES ('delete', old.id, old.title, old.description, old.location, old.category);
+		INSERT INTO events_fts(rowid, title, description, location, category)
+		VALUES (new.id, new.title, new.description, new.location, new.category);
+	END`,
+	// Reconstruir el índice: los eventos pueden haber cambiado sin los triggers (ej: con un
+	// binario sin FTS5)
+	`INSERT INTO events_fts(events_fts) VALUES ('rebuild')`,
+}
+
+// Pesos de bm25 por columna: título, descripción, ubicación y categoría
+const fts5Rank = "bm25(events_fts, 10.0, 2.0, 4.0, 4.0)"
+
+type fts5Engine struct{}
+
+// hasFTS5 indica si el SQLite del binario incluye FTS5
+func hasFTS5(db *gorm.DB) (bool, error) {
+	var enabled bool
+	err := db.Raw("SELECT sqlite_compileoption_used('ENABLE_FTS5')").Scan(&enabled).Error
+	return enabled, err
+}
+
+func setupFTS5(db *gorm.DB) (Engine, error) {
+	for _, statement := range fts5Statements {
+		if err := db.Exec(statement).Error; err != nil {
+			return nil, err
+		}
+	}
+	return fts5Engine{}, nil
+}
+
+// dropFTS5Triggers quita los triggers del índice FTS5: sin el módulo, fallaría toda escritura
+// en events (y toda migración que la modifique)
+func dropFTS5Triggers(db *gorm.DB) error {
+	for _, trigger := range []string{"events_fts_insert", "events_fts_delete", "events_fts_update"} {
+		if err := db.Exec("DROP TRIGGER IF EXISTS " + trigger).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (fts5Engine) Name() string {
+	return "SQLite FTS5"
+}
+
+func (fts5Engine) Where(query *gorm.DB, q *Query) *gorm.DB {
+	return query.Where("events.id IN (SELECT rowid FROM events_fts WHERE events_fts MATCH ?)", fts5Match(q))
+}
+
+func (fts5Engine) Rank(query *gorm.DB, q *Query, limit int) ([]Match, error) {
+	var matches []Match
+	err := query.Joins("JOIN events_fts ON events_fts.rowid = events.id").
+		Where("events_fts MATCH ?", fts5Match(q)).
+		Select("events.id AS id, -" + fts5Rank + " AS score").
+		Order("score DESC").Limit(limit).
+		Scan(&matches).Error
+	return matches, err
+}
+
+// fts5Match traduce la búsqueda a la sintaxis de MATCH: cada término entre comillas
+// (una palabra o una frase) seguido de * si es prefijo; los términos se combinan con AND
+func fts5Match(q *Query) string {
+	terms := make([]string, len(q.Terms))
+	for i, term := range q.Terms {
+		terms[i] = `"` + strings.Join(term.Words, " ") + `"`
+		if term.Prefix {
+			terms[i] += "*"
+		}
+	}
+	return strings.Join(terms, " ")
+}
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"calendar-backend/models"
+	"calendar-backend/repositories"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Las contraseñas de aplicación son 16 letras minúsculas en grupos de 4 (ej: "abcd-efgh-ijkl-mnop"),
+// fáciles de escribir en un teléfono
+const (
+	appPasswordAlphabet = "abcdefghijklmnopqrstuvwxyz"
+	appPasswordLength   = 16
+)
+
+var ErrAppPasswordNotFound = errors.New("app password not found")
+
+// AppPasswordService maneja las contraseñas de aplicación de los clientes CalDAV
+type AppPasswordService struct {
+	passwordRepo repositories.AppPasswordRepository
+	userRepo     repositories.UserRepository
+}
+
+func NewAppPasswordService(passwordRepo repositories.AppPasswordRepository, userRepo repositories.UserRepository) *AppPasswordService {
+	return &AppPasswordService{passwordRepo: passwordRepo, userRepo: userRepo}
+}
+
+// CreateAppPassword genera una contraseña de aplicación; solo se devuelve aquí
+func (s *AppPasswordService) CreateAppPassword(userID uint, name string) (*models.AppPassword, string, error) {
+	// Se descartan los bytes que sesgarían la distribución de las letras
+	limit := byte(256 / len(appPasswordAlphabet) * len(appPasswordAlphabet))
+	var password strings.Builder
+	raw := make([]byte, 1)
+	for n := 0; n < appPasswordLength; {
+		if _, err := rand.Read(raw); err != nil {
+			return nil, "", err
+		}
+		if raw[0] >= limit {
+			continue
+		}
+		if n > 0 && n%4 == 0 {
+			password.WriteByte('-')
+		}
+		password.WriteByte(appPasswordAlphabet[int(raw[0])%len(appPasswordAlphabet)])
+		n++
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = "CalDAV"
+	}
+	appPassword := &models.AppPassword{
+		UserID:       userID,
+		Name:         name,
+		PasswordHash: hashAppPassword(password.String()),
+	}
+	if err := s.passwordRepo.Create(appPassword); err != nil {
+		return nil, "", err
+	}
+	return appPassword, password.String(), nil
+}
+
+// ListAppPasswords devuelve las contraseñas de aplicación del usuario
+func (s *AppPasswordService) ListAppPasswords(userID uint) ([]models.AppPassword, error) {
+	return s.passwordRepo.GetByUser(userID)
+}
+
+// RevokeAppPassword elimina una contraseña de aplicación
+func (s *AppPasswordService) RevokeAppPassword(userID, id uint) error {
+	err := s.passwordRepo.Delete(userID, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrAppPasswordNotFound
+	}
+	return err
+}
+
+// Authenticate verifica el email y la contraseña de aplicación enviados por HTTP Basic
+func (s *AppPasswordService) Authenticate(email, password string) (*models.User, error) {
+	user, err := s.userRepo.GetByEmail(strings.TrimSpace(strings.ToLower(email)))
+	if err != nil {
+		return nil, ErrInvalidCredentials
+	}
+	appPassword, err := s.passwordRepo.GetByHash(user.ID, hashAppPassword(password))
+	if err != nil {
+		return nil, ErrInvalidCredentials
+	}
+	if err := s.passwordRepo.TouchLastUsed(appPassword.ID, time.Now()); err != nil {
+		log.Printf("Error updating app password %d last use: %v", appPassword.ID, err)
+	}
+	return user, nil
+}
+
+// hashAppPassword devuelve el hash con el que se guarda la contraseña, ignorando
+// mayúsculas, guiones y espacios
+func hashAppPassword(password string) string {
+	normalized := strings.NewReplacer("-", "", " ", "").Replace(strings.ToLower(password))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"calendar-backend/models"
+	"calendar-backend/repositories"
+	"errors"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+var ErrInvalidCredentials = errors.New("invalid email or password")
+
+// AuthService maneja el registro, login y renovación de tokens de los usuarios
+type AuthService struct {
+	userRepo     repositories.UserRepository
+	tokenService *TokenService
+}
+
+func NewAuthService(userRepo repositories.UserRepository, tokenService *TokenService) *AuthService {
+	return &AuthService{
+		userRepo:     userRepo,
+		tokenService: tokenService,
+	}
+}
+
+// Register crea el usuario y devuelve sus tokens. Los eventos creados con su email antes de
+// las cuentas no pasan a ser suyos: registrarse no prueba que el email le pertenezca, así que
+// los asigna un administrador (calendar-backend users assign-legacy)
+func (s *AuthService) Register(email, password, name string) (*models.User, *TokenPair, error) {
+	email = strings.TrimSpace(strings.ToLower(email))
+	if _, err := s.userRepo.GetByEmail(email); err == nil {
+		return nil, nil, errors.New("email already registered")
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	user := &models.User{
+		Email:        email,
+		Name:         strings.TrimSpace(name),
+		PasswordHash: string(hash),
+	}
+	if err := s.userRepo.Create(user); err != nil {
+		return nil, nil, err
+	}
+
+	tokens, err := s.tokenService.IssuePair(user)
+	if err != nil {
+		return nil, nil, err
+	}
+	return user, tokens, nil
+}
+
+// Login verifica las credenciales y devuelve nuevos tokens
+func (s *AuthService) Login(email, password string) (*models.User, *TokenPair, error) {
+	user, err := s.userRepo.GetByEmail(strings.TrimSpace(strings.ToLower(email)))
+	if err != nil {
+		return nil, nil, ErrInvalidCredentials
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		return nil, nil, ErrInvalidCredentials
+	}
+
+	tokens, err := s.tokenService.IssuePair(user)
+	if err != nil {
+		return nil, nil, err
+	}
+	return user, tokens, nil
+}
+
+// Refresh emite un nuevo par de tokens a partir de un refresh token válido
+func (s *AuthService) Refresh(refreshToken string) (*TokenPair, error) {
+	claims, err := s.tokenService.Parse(refreshToken, TokenTypeRefresh)
+	if err != nil {
+		return nil, err
+	}
+
+	// El usuario tiene que seguir existiendo
+	user, err := s.userRepo.GetByID(claims.UserID())
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	return s.tokenService.IssuePair(user)
+}
+
+// GetUser devuelve el usuario autenticado
+func (s *AuthService) GetUser(id uint) (*models.User, error) {
+	user, err := s.userRepo.GetByID(id)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	return user, nil
+}
@@ -0,0 +1,54 @@
+package services
+
+import (
+	"calendar-backend/repositories"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNewTokenServiceRequiresSecret(t *testing.T) {
+	t.Setenv("JWT_SECRET", "")
+	t.Setenv("DEV_MODE", "")
+	if _, err := NewTokenService(); !errors.Is(err, ErrJWTSecretMissing) {
+		t.Fatalf("without JWT_SECRET: got %v, want ErrJWTSecretMissing", err)
+	}
+
+	t.Setenv("DEV_MODE", "true")
+	if _, err := NewTokenService(); err != nil {
+		t.Fatalf("DEV_MODE without JWT_SECRET: %v", err)
+	}
+}
+
+func TestRegisterDoesNotTakeLegacyEvents(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret-test-secret-test-secret")
+	db := newTestDB(t)
+	eventRepo := newTestEventRepository(t, db)
+	tokens, err := NewTokenService()
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth := NewAuthService(repositories.NewUserRepository(db), tokens)
+
+	legacy := newTestEvent(0, "Old appointment", time.Now().Add(24*time.Hour), "")
+	legacy.Email = "ana@example.com"
+	if err := db.Create(legacy).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	user, _, err := auth.Register("Ana@example.com", "password123", "Ana")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if own, err := eventRepo.ForUser(user.ID).GetAll(); err != nil || len(own) != 0 {
+		t.Fatalf("registering gave the user %d legacy events (err %v)", len(own), err)
+	}
+
+	// Un administrador los asigna después de comprobar que el email es del usuario
+	if assigned, err := eventRepo.AssignOwnerByEmail(user.ID, user.Email); err != nil || assigned != 1 {
+		t.Fatalf("AssignOwnerByEmail = %d, %v", assigned, err)
+	}
+	if own, err := eventRepo.ForUser(user.ID).GetAll(); err != nil || len(own) != 1 {
+		t.Errorf("after assigning: %d events (err %v), want the legacy one", len(own), err)
+	}
+}
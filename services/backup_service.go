@@ -0,0 +1,91 @@
+package services
+
+import (
+	"calendar-backend/backup"
+	"log"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// BackupService hace copias de seguridad periódicas de la base, sin detener el servidor,
+// y conserva solo las más nuevas
+type BackupService struct {
+	db        *gorm.DB
+	dir       string
+	interval  time.Duration
+	retention int
+
+	mu       sync.Mutex // Una copia a la vez
+	done     chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+func NewBackupService(db *gorm.DB, dir string, interval time.Duration, retention int) *BackupService {
+	return &BackupService{
+		db:        db,
+		dir:       dir,
+		interval:  interval,
+		retention: retention,
+		done:      make(chan struct{}),
+	}
+}
+
+// Start arranca las copias periódicas; con intervalo 0 no hace ninguna
+func (s *BackupService) Start() {
+	if s.interval <= 0 {
+		log.Println("Scheduled backups disabled (set BACKUP_INTERVAL to enable them)")
+		return
+	}
+	s.wg.Add(1)
+	go s.run()
+	log.Printf("Scheduled backups started (every %s into %s, keeping %d)", s.interval, s.dir, s.retention)
+}
+
+// Stop detiene las copias periódicas, esperando la que esté en curso
+func (s *BackupService) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.done)
+	})
+	s.wg.Wait()
+}
+
+func (s *BackupService) run() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if _, err := s.RunOnce(); err != nil {
+				log.Printf("❌ Error creating scheduled backup: %v", err)
+			}
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// RunOnce hace una copia y borra las que exceden la retención
+func (s *BackupService) RunOnce() (*backup.Manifest, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	manifest, err := backup.Create(s.db, s.dir)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Backup created: %s (%d bytes, sha256 %s)", manifest.File, manifest.Size, manifest.SHA256)
+
+	removed, err := backup.Prune(s.dir, s.retention)
+	if err != nil {
+		return manifest, err
+	}
+	if removed > 0 {
+		log.Printf("Removed %d old backups", removed)
+	}
+	return manifest, nil
+}
@@ -0,0 +1,267 @@
+package services
+
+import (
+	"calendar-backend/ical"
+	"calendar-backend/models"
+	"calendar-backend/repositories"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// syncTokenPrefix es la URI base de los sync-token (RFC 6578 exige que sean URIs)
+const syncTokenPrefix = "http://calendar-backend/ns/sync/"
+
+// syncTokenMargin retrasa el instante de cada sync-token para no perder cambios
+// guardados mientras se respondía o con relojes levemente desfasados; un cambio
+// puede informarse dos veces, lo que los clientes toleran
+const syncTokenMargin = time.Second
+
+var (
+	ErrResourceNotFound = errors.New("calendar resource not found")
+	ErrInvalidSyncToken = errors.New("invalid sync token")
+)
+
+// CalendarResource es un recurso .ics de la colección CalDAV: una serie (o evento simple)
+// junto con sus ocurrencias separadas, que comparten el UID
+type CalendarResource struct {
+	UID    string
+	Events []models.Event // La serie primero
+}
+
+// Overlaps indica si alguna ocurrencia del recurso se superpone con [start, end).
+// Los extremos cero no limitan el rango.
+func (r *CalendarResource) Overlaps(start, end time.Time) bool {
+	if end.IsZero() {
+		end = time.Now().AddDate(100, 0, 0)
+	}
+	for i := range r.Events {
+		event := &r.Events[i]
+		from := start
+		if from.IsZero() || from.Before(event.StartsAt) {
+			from = event.StartsAt
+		}
+		if !from.Before(end) {
+			continue
+		}
+		loc := event.Zone()
+		for _, occurrence := range event.Occurrences(models.CalendarDate(from, loc), models.CalendarDate(end, loc)) {
+			if occurrence.Overlaps(start, end) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// CalDAVService expone como una colección CalDAV los eventos que ve cada usuario: los de sus
+// calendarios y los de los compartidos con él
+type CalDAVService struct {
+	eventRepo    repositories.EventRepository
+	calendarRepo repositories.CalendarRepository
+	eventService EventService
+}
+
+func NewCalDAVService(eventRepo repositories.EventRepository, calendarRepo repositories.CalendarRepository, eventService EventService) *CalDAVService {
+	return &CalDAVService{eventRepo: eventRepo, calendarRepo: calendarRepo, eventService: eventService}
+}
+
+// ListResources devuelve todos los recursos del usuario
+func (s *CalDAVService) ListResources(userID uint) ([]CalendarResource, error) {
+	events, err := s.eventRepo.ForUser(userID).GetAll()
+	if err != nil {
+		return nil, err
+	}
+	return groupResources(events), nil
+}
+
+// GetResource devuelve el recurso del UID
+func (s *CalDAVService) GetResource(userID uint, uid string) (*CalendarResource, error) {
+	events, err := findSeries(s.eventRepo.ForUser(userID), uid)
+	if err != nil {
+		return nil, err
+	}
+	resources := groupResources(events)
+	if len(resources) == 0 {
+		return nil, ErrResourceNotFound
+	}
+	return &resources[0], nil
+}
+
+// CollectionTag devuelve el CTag de la colección, que cambia con cada modificación
+func (s *CalDAVService) CollectionTag(userID uint) (string, error) {
+	stamp, err := s.eventRepo.ForUser(userID).ChangeStamp()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(stamp))
+	return hex.EncodeToString(sum[:16]), nil
+}
+
+// NewSyncToken devuelve el sync-token que identifica el estado actual de la colección
+func (s *CalDAVService) NewSyncToken() string {
+	return syncTokenPrefix + strconv.FormatInt(time.Now().Add(-syncTokenMargin).UnixNano(), 10)
+}
+
+// ParseSyncToken devuelve el instante de un sync-token emitido por NewSyncToken
+func (s *CalDAVService) ParseSyncToken(token string) (time.Time, error) {
+	value, ok := strings.CutPrefix(token, syncTokenPrefix)
+	if !ok {
+		return time.Time{}, ErrInvalidSyncToken
+	}
+	nanos, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, ErrInvalidSyncToken
+	}
+	return time.Unix(0, nanos), nil
+}
+
+// Changes devuelve los recursos modificados después de since y los UID de los eliminados,
+// incluidos los que el usuario dejó de ver
+func (s *CalDAVService) Changes(userID uint, since time.Time) ([]CalendarResource, []string, error) {
+	repo := s.eventRepo.ForUser(userID)
+	events, err := repo.GetChangedSince(since)
+	if err != nil {
+		return nil, nil, err
+	}
+	removed, err := repo.GetRemovedSince(since)
+	if err != nil {
+		return nil, nil, err
+	}
+	gone := make(map[uint]bool, len(removed))
+	for _, event := range removed {
+		gone[event.ID] = true
+	}
+	events = append(events, removed...)
+
+	// Un cambio en una ocurrencia separada modifica el recurso de su serie
+	var seriesIDs []uint
+	rows := make(map[uint]models.Event)
+	for _, event := range events {
+		id := event.ID
+		if event.RecurrenceID != nil {
+			id = *event.RecurrenceID
+		}
+		if _, seen := rows[id]; !seen {
+			seriesIDs = append(seriesIDs, id)
+		}
+		if event.ID == id || rows[id].ID == 0 {
+			rows[id] = event
+		}
+	}
+
+	var changed []CalendarResource
+	var deleted []string
+	for _, id := range seriesIDs {
+		series, err := repo.GetSeries(id)
+		if err == nil {
+			changed = append(changed, groupResources(series)...)
+			continue
+		}
+		if row := rows[id]; row.ID == id && (row.DeletedAt.Valid || gone[id]) {
+			deleted = append(deleted, ical.SeriesUID(&row))
+		}
+	}
+	return changed, deleted, nil
+}
+
+// PutResource crea o reemplaza el recurso del UID con los VEVENT recibidos (la serie y sus
+// ocurrencias separadas). Las ocurrencias separadas que ya no vienen se eliminan. Un recurso
+// de un calendario compartido con el usuario requiere que sea editor. Todo se guarda en una
+// transacción y los cambios se avisan al confirmarla. Devuelve si el recurso es nuevo.
+func (s *CalDAVService) PutResource(userID uint, email, uid string, vevents []ical.Event) (bool, error) {
+	hasMaster := false
+	keep := make(map[string]bool)
+	for _, vevent := range vevents {
+		if vevent.Err != nil {
+			return false, vevent.Err
+		}
+		if vevent.UID != uid {
+			return false, errors.New("all VEVENTs of a resource must share its UID")
+		}
+		if vevent.RecurrenceID == nil {
+			hasMaster = true
+		} else if vevent.Status != "CANCELLED" {
+			keep[vevent.RecurrenceID.Format("2006-01-02")] = true
+		}
+	}
+	if !hasMaster {
+		return false, errors.New("resource has no VEVENT without RECURRENCE-ID")
+	}
+
+	repo := s.eventRepo.ForUser(userID)
+	existing, err := findSeries(repo, uid)
+	if err != nil {
+		return false, err
+	}
+	// Se importa como el dueño del recurso, para que se actualice en su calendario
+	importer := userID
+	if len(existing) > 0 {
+		if _, err := authorizeCalendar(s.calendarRepo, userID, existing[0].CalendarID, models.CalendarEditor); err != nil {
+			return false, err
+		}
+		importer = existing[0].OwnerID
+	}
+	// Las ocurrencias que ya no vienen se eliminan junto con la importación, en una sola
+	// transacción: un VEVENT inválido deja el recurso como estaba
+	err = s.eventService.ForUser(importer).Transaction(func(events EventService) error {
+		for _, event := range existing {
+			if event.RecurrenceID != nil && event.OriginalDate != nil && !keep[event.OriginalDate.Format("2006-01-02")] {
+				if err := events.DeleteEvent(event.ID); err != nil {
+					return err
+				}
+			}
+		}
+
+		report := events.ImportEvents(vevents, email)
+		for _, item := range report.Items {
+			if item.Status == ImportFailed {
+				return fmt.Errorf("line %d: %s", item.Line, item.Reason)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return len(existing) == 0, nil
+}
+
+// DeleteResource elimina la serie del UID junto con sus ocurrencias separadas
+func (s *CalDAVService) DeleteResource(userID uint, uid string) error {
+	resource, err := s.GetResource(userID, uid)
+	if err != nil {
+		return err
+	}
+	return s.eventService.ForUser(userID).DeleteEvent(resource.Events[0].ID)
+}
+
+// groupResources agrupa las series con sus ocurrencias separadas, ordenadas por ID de la serie.
+// Las ocurrencias cuya serie no está en la lista se descartan.
+func groupResources(events []models.Event) []CalendarResource {
+	byID := make(map[uint]int)
+	var resources []CalendarResource
+	for _, event := range events {
+		if event.RecurrenceID == nil {
+			byID[event.ID] = len(resources)
+			resources = append(resources, CalendarResource{UID: ical.SeriesUID(&event), Events: []models.Event{event}})
+		}
+	}
+	for _, event := range events {
+		if event.RecurrenceID == nil {
+			continue
+		}
+		if i, ok := byID[*event.RecurrenceID]; ok {
+			resources[i].Events = append(resources[i].Events, event)
+		}
+	}
+	sort.Slice(resources, func(i, j int) bool {
+		return resources[i].Events[0].ID < resources[j].Events[0].ID
+	})
+	return resources
+}
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"calendar-backend/eventbus"
+	"calendar-backend/ical"
+	"calendar-backend/models"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCalDAVSharedCalendar(t *testing.T) {
+	f := newSharingFixture(t)
+	caldav := NewCalDAVService(f.eventRepo, f.calendarRepo, f.events)
+	uid := ical.SeriesUID(f.event)
+
+	f.share(t, models.CalendarViewer)
+	resources, err := caldav.ListResources(f.bob.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resources) != 1 || resources[0].UID != uid {
+		t.Fatalf("bob lists %d resources, want the shared event %s", len(resources), uid)
+	}
+
+	vevent := ical.Event{UID: uid, Summary: "Planning v2", Start: f.event.StartsAt, End: f.event.EndsAt, TimeZone: "UTC"}
+	if _, err := caldav.PutResource(f.bob.ID, f.bob.Email, uid, []ical.Event{vevent}); !errors.Is(err, ErrCalendarForbidden) {
+		t.Errorf("viewer PUT: got %v, want ErrCalendarForbidden", err)
+	}
+
+	f.share(t, models.CalendarEditor)
+	created, err := caldav.PutResource(f.bob.ID, f.bob.Email, uid, []ical.Event{vevent})
+	if err != nil || created {
+		t.Fatalf("editor PUT: created %v, err %v", created, err)
+	}
+	event, err := f.eventRepo.GetByID(f.event.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.Title != "Planning v2" || event.CalendarID != f.work.ID {
+		t.Errorf("after the editor's PUT: %q in calendar %d, want %q in %d", event.Title, event.CalendarID, "Planning v2", f.work.ID)
+	}
+	if own, err := f.eventRepo.ForOwner(f.bob.ID).GetAll(); err != nil || len(own) != 0 {
+		t.Errorf("the editor's PUT created %d events of bob (err %v)", len(own), err)
+	}
+
+	since := time.Now().Add(-time.Second)
+	f.unshare(t)
+	changed, deleted, err := caldav.Changes(f.bob.ID, since)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changed) != 0 || len(deleted) != 1 || deleted[0] != uid {
+		t.Errorf("after unsharing: %d changed, deleted %v, want only %s deleted", len(changed), deleted, uid)
+	}
+}
+
+func TestCalDAVPutIsAtomic(t *testing.T) {
+	f := newSharingFixture(t)
+	caldav := NewCalDAVService(f.eventRepo, f.calendarRepo, f.events)
+
+	start := time.Now().Add(72 * time.Hour).UTC().Truncate(time.Hour)
+	moved := start.AddDate(0, 0, 7)
+	master := ical.Event{UID: "weekly@example.com", Summary: "Weekly", Start: start, End: start.Add(time.Hour), TimeZone: "UTC", RRule: "FREQ=WEEKLY"}
+	override := ical.Event{UID: master.UID, Summary: "Weekly (moved)", Start: moved.Add(2 * time.Hour), End: moved.Add(3 * time.Hour), TimeZone: "UTC", RecurrenceID: &moved}
+	if created, err := caldav.PutResource(f.alice.ID, f.alice.Email, master.UID, []ical.Event{master, override}); err != nil || !created {
+		t.Fatalf("first PUT: created %v, err %v", created, err)
+	}
+	resource, err := caldav.GetResource(f.alice.ID, master.UID)
+	if err != nil || len(resource.Events) != 2 {
+		t.Fatalf("resource after the first PUT: %+v, %v", resource, err)
+	}
+	overrideID := resource.Events[1].ID
+	f.changes.take()
+
+	// Un VEVENT que no se puede importar no elimina la ocurrencia separada que ya no viene
+	invalid := master
+	invalid.RRule = "FREQ=SECONDLY"
+	if _, err := caldav.PutResource(f.alice.ID, f.alice.Email, master.UID, []ical.Event{invalid}); err == nil {
+		t.Fatal("PUT with an unsupported RRULE succeeded")
+	}
+	if resource, err := caldav.GetResource(f.alice.ID, master.UID); err != nil || len(resource.Events) != 2 {
+		t.Fatalf("the failed PUT changed the resource: %+v, %v", resource, err)
+	}
+	if changes := f.changes.take(); len(changes) != 0 {
+		t.Errorf("the failed PUT published %+v", changes)
+	}
+
+	master.Summary = "Weekly v2"
+	if created, err := caldav.PutResource(f.alice.ID, f.alice.Email, master.UID, []ical.Event{master}); err != nil || created {
+		t.Fatalf("second PUT: created %v, err %v", created, err)
+	}
+	if resource, err := caldav.GetResource(f.alice.ID, master.UID); err != nil || len(resource.Events) != 1 || resource.Events[0].Title != "Weekly v2" {
+		t.Fatalf("resource after the second PUT: %+v, %v", resource, err)
+	}
+	deleted := false
+	for _, change := range f.changes.take() {
+		if change.Type == eventbus.Deleted && change.EventID == overrideID {
+			deleted = true
+		}
+	}
+	if !deleted {
+		t.Error("the removed occurrence was not published as deleted")
+	}
+}
@@ -0,0 +1,268 @@
+package services
+
+import (
+	"calendar-backend/eventbus"
+	"calendar-backend/models"
+	"calendar-backend/repositories"
+	"errors"
+	"log"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrCalendarNotFound  = errors.New("calendar not found")
+	ErrCalendarForbidden = errors.New("you don't have permission for this calendar")
+	ErrDefaultCalendar   = errors.New("the default calendar cannot be deleted")
+	ErrShareUserNotFound = errors.New("there is no user with that email")
+	ErrShareWithOwner    = errors.New("the calendar already belongs to that user")
+	ErrNotShared         = errors.New("the calendar is not shared with that user")
+)
+
+// CalendarService maneja los calendarios de cada usuario y con quién se comparten
+type CalendarService struct {
+	calendarRepo repositories.CalendarRepository
+	userRepo     repositories.UserRepository
+	reminders    ReminderScheduler
+	changes      ChangePublisher
+}
+
+func NewCalendarService(calendarRepo repositories.CalendarRepository, userRepo repositories.UserRepository, reminders ReminderScheduler, changes ChangePublisher) *CalendarService {
+	return &CalendarService{calendarRepo: calendarRepo, userRepo: userRepo, reminders: reminders, changes: changes}
+}
+
+// ListCalendars devuelve los calendarios del usuario, creando el predeterminado si todavía no
+// tiene, y los compartidos con él
+func (s *CalendarService) ListCalendars(userID uint) ([]models.Calendar, error) {
+	if _, err := s.calendarRepo.GetDefault(userID); err != nil {
+		return nil, err
+	}
+	return s.calendarRepo.GetAccessible(userID)
+}
+
+// GetCalendar devuelve el calendario con el rol del usuario si tiene al menos el rol required
+func (s *CalendarService) GetCalendar(userID, id uint, required string) (*models.Calendar, error) {
+	return authorizeCalendar(s.calendarRepo, userID, id, required)
+}
+
+// CreateCalendar crea un calendario del usuario
+func (s *CalendarService) CreateCalendar(userID uint, calendar *models.Calendar) error {
+	calendar.ID = 0
+	calendar.OwnerID = userID
+	calendar.IsDefault = false
+	if calendar.Color == "" {
+		calendar.Color = repositories.DefaultCalendarColor
+	}
+	if err := s.calendarRepo.Create(calendar); err != nil {
+		return err
+	}
+	calendar.Role = models.CalendarOwner
+	return nil
+}
+
+// UpdateCalendar cambia el nombre y el color; vacíos = no cambian
+func (s *CalendarService) UpdateCalendar(userID, id uint, changes *models.Calendar) (*models.Calendar, error) {
+	calendar, err := authorizeCalendar(s.calendarRepo, userID, id, models.CalendarManager)
+	if err != nil {
+		return nil, err
+	}
+	if changes.Name != "" {
+		calendar.Name = changes.Name
+	}
+	if changes.Color != "" {
+		calendar.Color = changes.Color
+	}
+	if err := s.calendarRepo.Update(calendar); err != nil {
+		return nil, err
+	}
+	return calendar, nil
+}
+
+// DeleteCalendar elimina el calendario con sus eventos. Solo puede hacerlo su dueño, y el
+// predeterminado no se puede eliminar.
+func (s *CalendarService) DeleteCalendar(userID, id uint) error {
+	calendar, err := authorizeCalendar(s.calendarRepo, userID, id, models.CalendarOwner)
+	if err != nil {
+		return err
+	}
+	if calendar.IsDefault {
+		return ErrDefaultCalendar
+	}
+
+	// Después de eliminarlo ya no se sabe con quién estaba compartido
+	viewers, err := s.calendarRepo.GetViewerIDs(id)
+	if err != nil {
+		return err
+	}
+	events, err := s.calendarRepo.Delete(id)
+	if err != nil {
+		return err
+	}
+	for i := range events {
+		publishChangeTo(s.changes, eventbus.Deleted, &events[i], viewers)
+	}
+	if len(events) > 0 && s.reminders != nil {
+		s.reminders.Reschedule()
+	}
+	return nil
+}
+
+// Shares devuelve con quién está compartido el calendario
+func (s *CalendarService) Shares(userID, id uint) ([]models.CalendarShare, error) {
+	if _, err := authorizeCalendar(s.calendarRepo, userID, id, models.CalendarManager); err != nil {
+		return nil, err
+	}
+	return s.calendarRepo.GetShares(id)
+}
+
+// ShareCalendar comparte el calendario con el usuario del email o, si ya lo estaba, le cambia
+// el rol. Devuelve cómo quedó compartido. Si es nuevo, se le avisan los eventos del calendario.
+func (s *CalendarService) ShareCalendar(userID, id uint, email, role string) (*models.CalendarShare, error) {
+	calendar, err := authorizeCalendar(s.calendarRepo, userID, id, models.CalendarManager)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.userRepo.GetByEmail(email)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrShareUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if user.ID == calendar.OwnerID {
+		return nil, ErrShareWithOwner
+	}
+	shares, err := s.calendarRepo.GetShares(id)
+	if err != nil {
+		return nil, err
+	}
+	shared := findShare(shares, user.ID) != nil
+
+	if err := s.calendarRepo.SaveShare(&models.CalendarShare{CalendarID: id, UserID: user.ID, Role: role}); err != nil {
+		return nil, err
+	}
+	if !shared {
+		s.publishAccess(eventbus.Created, id, user.ID)
+	}
+	if shares, err = s.calendarRepo.GetShares(id); err != nil {
+		return nil, err
+	}
+	if share := findShare(shares, user.ID); share != nil {
+		return share, nil
+	}
+	return nil, ErrShareUserNotFound
+}
+
+// UnshareCalendar deja de compartir el calendario con el usuario, al que se le avisa la baja de
+// sus eventos. Puede hacerlo quien lo administra o el propio usuario, para dejar un calendario
+// compartido con él.
+func (s *CalendarService) UnshareCalendar(userID, id, shareUserID uint) error {
+	required := models.CalendarManager
+	if shareUserID == userID {
+		required = models.CalendarViewer
+	}
+	if _, err := authorizeCalendar(s.calendarRepo, userID, id, required); err != nil {
+		return err
+	}
+	err := s.calendarRepo.DeleteShare(id, shareUserID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotShared
+	}
+	if err != nil {
+		return err
+	}
+	s.publishAccess(eventbus.Deleted, id, shareUserID)
+	return nil
+}
+
+// publishAccess avisa al usuario que empezó a ver (created) o dejó de ver (deleted) los eventos
+// del calendario. Un error solo se registra: la app igual los recibe al sincronizar.
+func (s *CalendarService) publishAccess(changeType string, calendarID, userID uint) {
+	if s.changes == nil {
+		return
+	}
+	events, err := s.calendarRepo.GetEvents(calendarID)
+	if err != nil {
+		log.Printf("❌ Error loading events of calendar %d: %v", calendarID, err)
+		return
+	}
+	for i := range events {
+		publishChangeTo(s.changes, changeType, &events[i], []uint{userID})
+	}
+}
+
+// findShare devuelve cómo está compartido el calendario con el usuario, o nil si no lo está
+func findShare(shares []models.CalendarShare, userID uint) *models.CalendarShare {
+	for i := range shares {
+		if shares[i].UserID == userID {
+			return &shares[i]
+		}
+	}
+	return nil
+}
+
+// CalendarAudience completa los destinatarios de cada cambio con los usuarios que ven el
+// calendario del evento, su dueño y con quienes está compartido, y lo pasa a next. Los cambios
+// que ya tienen destinatarios (ej: la baja para quienes dejan de ver un evento) pasan igual.
+type CalendarAudience struct {
+	calendarRepo repositories.CalendarRepository
+	next         ChangePublisher
+}
+
+func NewCalendarAudience(calendarRepo repositories.CalendarRepository, next ChangePublisher) *CalendarAudience {
+	return &CalendarAudience{calendarRepo: calendarRepo, next: next}
+}
+
+func (a *CalendarAudience) Publish(change eventbus.Change) {
+	if len(change.UserIDs) == 0 && change.CalendarID != 0 {
+		viewers, err := a.calendarRepo.GetViewerIDs(change.CalendarID)
+		if err != nil {
+			// Al menos se avisa al dueño
+			log.Printf("❌ Error loading users of calendar %d: %v", change.CalendarID, err)
+		}
+		change.UserIDs = viewers
+	}
+	a.next.Publish(change)
+}
+
+// lostViewers devuelve los usuarios que ven el calendario previous pero no el calendario current
+func lostViewers(calendarRepo repositories.CalendarRepository, previous, current uint) ([]uint, error) {
+	before, err := calendarRepo.GetViewerIDs(previous)
+	if err != nil {
+		return nil, err
+	}
+	after, err := calendarRepo.GetViewerIDs(current)
+	if err != nil {
+		return nil, err
+	}
+	kept := make(map[uint]bool, len(after))
+	for _, id := range after {
+		kept[id] = true
+	}
+	var lost []uint
+	for _, id := range before {
+		if !kept[id] {
+			lost = append(lost, id)
+		}
+	}
+	return lost, nil
+}
+
+// authorizeCalendar devuelve el calendario con el rol del usuario, o ErrCalendarNotFound si no
+// tiene acceso a él y ErrCalendarForbidden si su rol no alcanza
+func authorizeCalendar(calendarRepo repositories.CalendarRepository, userID, id uint, required string) (*models.Calendar, error) {
+	if id == 0 {
+		return nil, ErrCalendarNotFound
+	}
+	calendar, err := calendarRepo.GetAccessibleByID(userID, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrCalendarNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !models.RoleAllows(calendar.Role, required) {
+		return nil, ErrCalendarForbidden
+	}
+	return calendar, nil
+}
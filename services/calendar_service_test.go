@@ -0,0 +1,102 @@
+package services
+
+import (
+	"calendar-backend/eventbus"
+	"calendar-backend/models"
+	"errors"
+	"sort"
+	"testing"
+)
+
+func sortedRecipients(change eventbus.Change) []uint {
+	ids := append([]uint(nil), change.Recipients()...)
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+func TestChangesReachUsersOfSharedCalendar(t *testing.T) {
+	f := newSharingFixture(t)
+
+	f.share(t, models.CalendarEditor)
+	changes := f.changes.take()
+	if len(changes) != 1 || changes[0].Type != eventbus.Created || changes[0].EventID != f.event.ID {
+		t.Fatalf("sharing published %+v, want the event created for bob", changes)
+	}
+	if ids := changes[0].Recipients(); len(ids) != 1 || ids[0] != f.bob.ID {
+		t.Errorf("sharing notified %v, want only bob", ids)
+	}
+
+	if err := f.events.ForUser(f.bob.ID).UpdateEvent(f.event.ID, &models.Event{Title: "Planning v2"}); err != nil {
+		t.Fatal(err)
+	}
+	changes = f.changes.take()
+	if len(changes) != 1 || changes[0].Type != eventbus.Updated {
+		t.Fatalf("editing published %+v, want one update", changes)
+	}
+	if ids := sortedRecipients(changes[0]); len(ids) != 2 || ids[0] != f.alice.ID || ids[1] != f.bob.ID {
+		t.Errorf("editing notified %v, want alice and bob", ids)
+	}
+
+	f.unshare(t)
+	changes = f.changes.take()
+	if len(changes) != 1 || changes[0].Type != eventbus.Deleted {
+		t.Fatalf("unsharing published %+v, want the event deleted for bob", changes)
+	}
+	if ids := changes[0].Recipients(); len(ids) != 1 || ids[0] != f.bob.ID {
+		t.Errorf("unsharing notified %v, want only bob", ids)
+	}
+}
+
+func TestChangingRoleDoesNotRepublishEvents(t *testing.T) {
+	f := newSharingFixture(t)
+	f.share(t, models.CalendarViewer)
+	f.changes.take()
+
+	f.share(t, models.CalendarEditor)
+	if changes := f.changes.take(); len(changes) != 0 {
+		t.Errorf("changing the role published %+v", changes)
+	}
+}
+
+func TestSharedCalendarRoles(t *testing.T) {
+	f := newSharingFixture(t)
+	bob := f.events.ForUser(f.bob.ID)
+
+	if _, err := bob.GetEventByID(f.event.ID); err == nil {
+		t.Fatal("bob reads an event of a calendar not shared with him")
+	}
+
+	f.share(t, models.CalendarViewer)
+	if _, err := bob.GetEventByID(f.event.ID); err != nil {
+		t.Fatalf("viewer cannot read the event: %v", err)
+	}
+	if err := bob.UpdateEvent(f.event.ID, &models.Event{Title: "Nope"}); !errors.Is(err, ErrCalendarForbidden) {
+		t.Errorf("viewer update: got %v, want ErrCalendarForbidden", err)
+	}
+	if err := bob.DeleteEvent(f.event.ID); !errors.Is(err, ErrCalendarForbidden) {
+		t.Errorf("viewer delete: got %v, want ErrCalendarForbidden", err)
+	}
+	if _, err := f.calendars.ShareCalendar(f.bob.ID, f.work.ID, "carol@example.com", models.CalendarViewer); !errors.Is(err, ErrCalendarForbidden) {
+		t.Errorf("viewer sharing: got %v, want ErrCalendarForbidden", err)
+	}
+
+	f.share(t, models.CalendarEditor)
+	bobCalendar, err := f.calendarRepo.GetDefault(f.bob.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := bob.UpdateEvent(f.event.ID, &models.Event{CalendarID: bobCalendar.ID}); err == nil {
+		t.Error("editor moved the event to a calendar of another owner")
+	}
+	if err := bob.UpdateEvent(f.event.ID, &models.Event{Title: "Planning v2"}); err != nil {
+		t.Errorf("editor update: %v", err)
+	}
+	if err := f.calendars.DeleteCalendar(f.bob.ID, f.work.ID); !errors.Is(err, ErrCalendarForbidden) {
+		t.Errorf("editor deleting the calendar: got %v, want ErrCalendarForbidden", err)
+	}
+
+	f.unshare(t)
+	if _, err := bob.GetEventByID(f.event.ID); err == nil {
+		t.Error("bob still reads the event after unsharing")
+	}
+}
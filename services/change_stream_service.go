@@ -0,0 +1,134 @@
+package services
+
+import (
+	"calendar-backend/eventbus"
+	"context"
+	"errors"
+	"time"
+)
+
+// streamChangesLimit es la cantidad de cambios que el stream lee por consulta
+const streamChangesLimit = 200
+
+// defaultStreamHeartbeat es el intervalo de los heartbeats si no se configura otro
+const defaultStreamHeartbeat = 25 * time.Second
+
+// Mensajes del stream además de los cambios de eventbus
+const (
+	// StreamReady es el primer mensaje, con el token desde el que empieza el stream
+	StreamReady = "ready"
+	// StreamResync avisa que la app tiene que volver a sincronizar todo (con /api/mobile/sync sin token)
+	StreamResync = "resync"
+)
+
+// StreamMessage es un mensaje del stream de cambios
+type StreamMessage struct {
+	ID   string      // Token de sincronización hasta este mensaje; vacío si no es el último de un lote
+	Type string      // created, updated, deleted, ready o resync
+	Data interface{} // EventResponse, Tombstone, o nada en ready y resync
+}
+
+// ChangeStreamService envía en tiempo real los cambios de los eventos que ve un usuario. Los avisos
+// del bus solo indican que hay cambios: lo que cambió se lee de la sincronización incremental,
+// así que el ID de cada mensaje es un token con el que retomar el stream al reconectarse.
+type ChangeStreamService struct {
+	bus       *eventbus.Bus
+	deltaSync *DeltaSyncService
+	heartbeat time.Duration
+}
+
+func NewChangeStreamService(bus *eventbus.Bus, deltaSync *DeltaSyncService, heartbeat time.Duration) *ChangeStreamService {
+	if heartbeat <= 0 {
+		heartbeat = defaultStreamHeartbeat
+	}
+	return &ChangeStreamService{
+		bus:       bus,
+		deltaSync: deltaSync,
+		heartbeat: heartbeat,
+	}
+}
+
+// Follow envía a send los cambios de los eventos que ve el usuario posteriores a lastEventID (sin él,
+// los que ocurran desde ahora) hasta que se cancele ctx o falle un envío. En cada heartbeat
+// llama a ping y vuelve a buscar cambios, por si se perdió algún aviso del bus.
+func (s *ChangeStreamService) Follow(ctx context.Context, userID uint, lastEventID string, send func([]StreamMessage) error, ping func() error) error {
+	// La suscripción empieza antes de leer el token para no perder los cambios de ese intervalo
+	sub := s.bus.Subscribe(userID)
+	defer sub.Close()
+
+	token := lastEventID
+	if token == "" {
+		current, err := s.deltaSync.CurrentToken(userID)
+		if err != nil {
+			return err
+		}
+		token = current
+	}
+	// Con el token del primer mensaje la app puede reconectarse sin perder cambios, aunque
+	// todavía no haya recibido ninguno
+	if err := send([]StreamMessage{{ID: token, Type: StreamReady}}); err != nil {
+		return err
+	}
+
+	heartbeat := time.NewTicker(s.heartbeat)
+	defer heartbeat.Stop()
+	for {
+		var err error
+		if token, err = s.sendChanges(userID, token, send); err != nil {
+			return err
+		}
+
+		select {
+		case <-sub.C():
+		case <-heartbeat.C:
+			if err := ping(); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// sendChanges envía los cambios posteriores al token y devuelve el token nuevo. Si el token ya
+// no sirve (se borraron bajas posteriores o se restauró un backup) avisa que hay que volver a
+// sincronizar todo y sigue desde el último cambio.
+func (s *ChangeStreamService) sendChanges(userID uint, token string, send func([]StreamMessage) error) (string, error) {
+	for {
+		delta, err := s.deltaSync.Changes(userID, token, streamChangesLimit)
+		if errors.Is(err, ErrResyncRequired) || errors.Is(err, ErrInvalidSyncToken) {
+			current, err := s.deltaSync.CurrentToken(userID)
+			if err != nil {
+				return token, err
+			}
+			return current, send([]StreamMessage{{ID: current, Type: StreamResync}})
+		}
+		if err != nil {
+			return token, err
+		}
+
+		messages := make([]StreamMessage, 0, len(delta.Events)+len(delta.Deleted))
+		for _, event := range delta.Events {
+			// Las altas están en su primera versión: cada modificación la aumenta
+			changeType := eventbus.Updated
+			if event.Version == 1 {
+				changeType = eventbus.Created
+			}
+			messages = append(messages, StreamMessage{Type: changeType, Data: event})
+		}
+		for _, tombstone := range delta.Deleted {
+			messages = append(messages, StreamMessage{Type: eventbus.Deleted, Data: tombstone})
+		}
+		if len(messages) > 0 {
+			messages[len(messages)-1].ID = delta.SyncToken
+			if err := send(messages); err != nil {
+				return token, err
+			}
+		}
+
+		token = delta.SyncToken
+		if !delta.HasMore {
+			return token, nil
+		}
+	}
+}
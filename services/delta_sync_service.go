@@ -0,0 +1,215 @@
+package services
+
+import (
+	"calendar-backend/models"
+	"calendar-backend/repositories"
+	"errors"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// tombstonePurgeInterval es cada cuánto se borran las bajas más viejas que la retención
+const tombstonePurgeInterval = 24 * time.Hour
+
+// ErrResyncRequired indica que el token es demasiado viejo (ya se borraron bajas posteriores a
+// él) o no corresponde a esta base (ej: se restauró un backup): la app tiene que pedir todo
+var ErrResyncRequired = errors.New("sync token is too old, a full resync is required")
+
+// DeltaSyncService informa a las apps los cambios de los eventos posteriores a un token de
+// sincronización, incluidas las bajas, y borra las bajas más viejas que la retención
+type DeltaSyncService struct {
+	eventRepo repositories.EventRepository
+	retention time.Duration
+
+	done     chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+func NewDeltaSyncService(eventRepo repositories.EventRepository, retention time.Duration) *DeltaSyncService {
+	return &DeltaSyncService{
+		eventRepo: eventRepo,
+		retention: retention,
+		done:      make(chan struct{}),
+	}
+}
+
+// Changes devuelve hasta limit cambios de los eventos que ve el usuario (los de sus calendarios
+// y los compartidos con él) posteriores al token. Los eventos que dejó de ver se informan como
+// bajas. Sin token devuelve todos los eventos (FullSync) y el token desde el que pedir los
+// cambios siguientes.
+func (s *DeltaSyncService) Changes(userID uint, token string, limit int) (*models.SyncDelta, error) {
+	repo := s.eventRepo.ForUser(userID)
+	if token == "" {
+		// El token se lee antes que los eventos: un cambio guardado entre las dos lecturas
+		// vuelve a informarse en la próxima sincronización, pero no se pierde
+		current, err := s.CurrentToken(userID)
+		if err != nil {
+			return nil, err
+		}
+		events, err := repo.GetAll()
+		if err != nil {
+			return nil, err
+		}
+		responses := make([]models.EventResponse, len(events))
+		for i := range events {
+			responses[i] = events[i].ToResponse()
+		}
+		return &models.SyncDelta{
+			Events:    responses,
+			Deleted:   []models.Tombstone{},
+			SyncToken: current,
+			FullSync:  true,
+		}, nil
+	}
+
+	purged, current, err := repo.ChangeSeqBounds()
+	if err != nil {
+		return nil, err
+	}
+
+	since, err := strconv.ParseInt(token, 10, 64)
+	if err != nil || since < 0 {
+		return nil, ErrInvalidSyncToken
+	}
+	if since < purged || since > current {
+		return nil, ErrResyncRequired
+	}
+
+	changes, err := repo.GetChangesAfter(since, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	removals, err := repo.GetRemovalsAfter(since, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	entries, hasMore := mergeSyncEntries(changes, removals, limit)
+
+	delta := &models.SyncDelta{Events: []models.EventResponse{}, Deleted: []models.Tombstone{}, HasMore: hasMore}
+	for _, entry := range entries {
+		switch {
+		case entry.removal != nil:
+			delta.Deleted = append(delta.Deleted, models.Tombstone{ID: entry.removal.EventID, DeletedAt: entry.removal.RemovedAt})
+		case entry.event.DeletedAt.Valid:
+			delta.Deleted = append(delta.Deleted, models.Tombstone{ID: entry.event.ID, DeletedAt: entry.event.DeletedAt.Time})
+		default:
+			delta.Events = append(delta.Events, entry.event.ToResponse())
+		}
+	}
+	// El último cambio leído siempre queda: es lo último que le pasó a su evento
+	if len(entries) > 0 {
+		since = entries[len(entries)-1].seq
+	}
+	delta.SyncToken = strconv.FormatInt(since, 10)
+	return delta, nil
+}
+
+// syncEntry es un cambio de un evento o la baja de un evento que el usuario dejó de ver
+type syncEntry struct {
+	seq     int64
+	event   *models.Event
+	removal *models.EventRemoval
+}
+
+// mergeSyncEntries intercala los cambios y las bajas en el orden de sus números y devuelve los
+// primeros limit, e indica si quedan más. De cada evento queda solo lo último que le pasó (ej:
+// si dejó de verlo y se lo volvieron a compartir, el evento), en el orden de ese cambio.
+func mergeSyncEntries(changes []models.Event, removals []models.EventRemoval, limit int) ([]syncEntry, bool) {
+	merged := make([]syncEntry, 0, len(changes)+len(removals))
+	i, j := 0, 0
+	for len(merged) < limit && (i < len(changes) || j < len(removals)) {
+		if j == len(removals) || (i < len(changes) && changes[i].ChangeSeq < removals[j].ChangeSeq) {
+			merged = append(merged, syncEntry{seq: changes[i].ChangeSeq, event: &changes[i]})
+			i++
+		} else {
+			merged = append(merged, syncEntry{seq: removals[j].ChangeSeq, removal: &removals[j]})
+			j++
+		}
+	}
+	hasMore := i < len(changes) || j < len(removals)
+
+	latest := make(map[uint]int, len(merged))
+	for k, entry := range merged {
+		latest[entry.eventID()] = k
+	}
+	entries := merged[:0]
+	for k, entry := range merged {
+		if latest[entry.eventID()] == k {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, hasMore
+}
+
+func (e syncEntry) eventID() uint {
+	if e.removal != nil {
+		return e.removal.EventID
+	}
+	return e.event.ID
+}
+
+// CurrentToken devuelve el token desde el que pedir los cambios que ocurran a partir de ahora
+func (s *DeltaSyncService) CurrentToken(userID uint) (string, error) {
+	repo := s.eventRepo.ForUser(userID)
+	purged, _, err := repo.ChangeSeqBounds()
+	if err != nil {
+		return "", err
+	}
+	last, err := repo.LastChangeSeq()
+	if err != nil {
+		return "", err
+	}
+	if last < purged {
+		last = purged
+	}
+	return strconv.FormatInt(last, 10), nil
+}
+
+// Start arranca el borrado diario de las bajas más viejas que la retención; con retención 0
+// las bajas se conservan siempre
+func (s *DeltaSyncService) Start() {
+	if s.retention <= 0 {
+		return
+	}
+	s.wg.Add(1)
+	go s.run()
+	log.Printf("Tombstone purge started (keeping deleted events for %s)", s.retention)
+}
+
+// Stop detiene el borrado de las bajas, esperando el que esté en curso
+func (s *DeltaSyncService) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.done)
+	})
+	s.wg.Wait()
+}
+
+func (s *DeltaSyncService) run() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(tombstonePurgeInterval)
+	defer ticker.Stop()
+	for {
+		if _, err := s.PurgeTombstones(); err != nil {
+			log.Printf("❌ Error purging deleted events: %v", err)
+		}
+		select {
+		case <-ticker.C:
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// PurgeTombstones borra definitivamente los eventos eliminados hace más que la retención y
+// devuelve cuántos borró. Las apps con un token anterior tendrán que sincronizar todo.
+func (s *DeltaSyncService) PurgeTombstones() (int64, error) {
+	purged, err := s.eventRepo.PurgeDeletedBefore(time.Now().UTC().Add(-s.retention))
+	if err == nil && purged > 0 {
+		log.Printf("Purged %d deleted events older than %s", purged, s.retention)
+	}
+	return purged, err
+}
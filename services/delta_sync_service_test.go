@@ -0,0 +1,138 @@
+package services
+
+import (
+	"calendar-backend/models"
+	"testing"
+	"time"
+)
+
+// deltaIDs devuelve los IDs de los eventos y de las bajas del delta
+func deltaIDs(delta *models.SyncDelta) (events, deleted []uint) {
+	for _, event := range delta.Events {
+		events = append(events, event.ID)
+	}
+	for _, tombstone := range delta.Deleted {
+		deleted = append(deleted, tombstone.ID)
+	}
+	return events, deleted
+}
+
+func TestChangesFollowCalendarSharing(t *testing.T) {
+	f := newSharingFixture(t)
+	token := f.token(t, f.bob.ID)
+
+	f.share(t, models.CalendarViewer)
+	delta := f.changesSince(t, f.bob.ID, token)
+	if events, deleted := deltaIDs(delta); len(events) != 1 || events[0] != f.event.ID || len(deleted) != 0 {
+		t.Fatalf("after sharing: events %v, deleted %v, want event %d", events, deleted, f.event.ID)
+	}
+
+	token = delta.SyncToken
+	if err := f.events.ForUser(f.alice.ID).UpdateEvent(f.event.ID, &models.Event{Title: "Planning v2"}); err != nil {
+		t.Fatal(err)
+	}
+	delta = f.changesSince(t, f.bob.ID, token)
+	if len(delta.Events) != 1 || delta.Events[0].Title != "Planning v2" {
+		t.Fatalf("after the owner's edit: %+v, want the edited event", delta.Events)
+	}
+
+	token = delta.SyncToken
+	f.unshare(t)
+	delta = f.changesSince(t, f.bob.ID, token)
+	if events, deleted := deltaIDs(delta); len(events) != 0 || len(deleted) != 1 || deleted[0] != f.event.ID {
+		t.Fatalf("after unsharing: events %v, deleted %v, want only the tombstone of %d", events, deleted, f.event.ID)
+	}
+
+	if full := f.changesSince(t, f.bob.ID, ""); len(full.Events) != 0 {
+		t.Errorf("full sync after unsharing returned %d events", len(full.Events))
+	}
+	if delta := f.changesSince(t, f.bob.ID, delta.SyncToken); len(delta.Events) != 0 || len(delta.Deleted) != 0 {
+		t.Errorf("changes after the last token: %+v", delta)
+	}
+}
+
+func TestChangesReportEventMovedOutOfSharedCalendar(t *testing.T) {
+	f := newSharingFixture(t)
+	f.share(t, models.CalendarEditor)
+	bobToken, aliceToken := f.token(t, f.bob.ID), f.token(t, f.alice.ID)
+
+	personal, err := f.calendarRepo.GetDefault(f.alice.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.events.ForUser(f.alice.ID).UpdateEvent(f.event.ID, &models.Event{CalendarID: personal.ID}); err != nil {
+		t.Fatal(err)
+	}
+
+	if events, deleted := deltaIDs(f.changesSince(t, f.bob.ID, bobToken)); len(events) != 0 || len(deleted) != 1 || deleted[0] != f.event.ID {
+		t.Errorf("bob: events %v, deleted %v, want the tombstone of %d", events, deleted, f.event.ID)
+	}
+	if events, deleted := deltaIDs(f.changesSince(t, f.alice.ID, aliceToken)); len(events) != 1 || len(deleted) != 0 {
+		t.Errorf("alice: events %v, deleted %v, want the moved event", events, deleted)
+	}
+}
+
+func TestChangesReportEventsOfDeletedCalendar(t *testing.T) {
+	f := newSharingFixture(t)
+	f.share(t, models.CalendarViewer)
+	bobToken, aliceToken := f.token(t, f.bob.ID), f.token(t, f.alice.ID)
+
+	if err := f.calendars.DeleteCalendar(f.alice.ID, f.work.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, check := range map[string]struct {
+		userID uint
+		token  string
+	}{"alice": {f.alice.ID, aliceToken}, "bob": {f.bob.ID, bobToken}} {
+		if events, deleted := deltaIDs(f.changesSince(t, check.userID, check.token)); len(events) != 0 || len(deleted) != 1 || deleted[0] != f.event.ID {
+			t.Errorf("%s: events %v, deleted %v, want the tombstone of %d", name, events, deleted, f.event.ID)
+		}
+	}
+}
+
+func TestChangesKeepLatestStateOfEventSharedAgain(t *testing.T) {
+	f := newSharingFixture(t)
+	f.share(t, models.CalendarViewer)
+	token := f.token(t, f.bob.ID)
+
+	f.unshare(t)
+	f.share(t, models.CalendarViewer)
+
+	delta := f.changesSince(t, f.bob.ID, token)
+	if events, deleted := deltaIDs(delta); len(events) != 1 || len(deleted) != 0 {
+		t.Fatalf("events %v, deleted %v, want only the event shared again", events, deleted)
+	}
+	if delta.SyncToken != f.token(t, f.bob.ID) {
+		t.Errorf("sync token %s, want the current one %s", delta.SyncToken, f.token(t, f.bob.ID))
+	}
+}
+
+func TestChangesPageThroughRemovals(t *testing.T) {
+	f := newSharingFixture(t)
+	second := newTestEvent(f.alice.ID, "Review", f.event.StartsAt.Add(24*time.Hour), "")
+	second.CalendarID = f.work.ID
+	if err := f.events.ForUser(f.alice.ID).CreateEvent(second); err != nil {
+		t.Fatal(err)
+	}
+	f.share(t, models.CalendarViewer)
+	token := f.token(t, f.bob.ID)
+	f.unshare(t)
+
+	var deleted []uint
+	for page := 0; page < 3; page++ {
+		delta, err := f.deltaSync.Changes(f.bob.ID, token, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, ids := deltaIDs(delta)
+		deleted = append(deleted, ids...)
+		token = delta.SyncToken
+		if !delta.HasMore {
+			break
+		}
+	}
+	if len(deleted) != 2 || deleted[0] != f.event.ID || deleted[1] != second.ID {
+		t.Errorf("deleted across pages %v, want [%d %d]", deleted, f.event.ID, second.ID)
+	}
+}
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"calendar-backend/eventbus"
 	"calendar-backend/models"
 	"calendar-backend/repositories"
 	"errors"
@@ -10,26 +11,63 @@ import (
 // EventCreationService maneja la lógica específica de creación de eventos
 type EventCreationService struct {
 	eventRepo repositories.EventRepository
+	changes   ChangePublisher
 }
 
-func NewEventCreationService(eventRepo repositories.EventRepository) *EventCreationService {
+func NewEventCreationService(eventRepo repositories.EventRepository, changes ChangePublisher) *EventCreationService {
 	return &EventCreationService{
 		eventRepo: eventRepo,
+		changes:   changes,
 	}
 }
 
-// CreateEvent implementa la lógica de negocio para crear un evento
+// CreateEvent implementa la lógica de negocio para crear un evento. Si ya existe el evento con
+// su ClientID (la app reenvió el alta), no crea otro: lo carga en event y devuelve ErrAlreadyCreated.
 func (s *EventCreationService) CreateEvent(event *models.Event) error {
+	if event.ClientID != "" {
+		if existing, err := s.eventRepo.GetByClientID(event.ClientID); err == nil {
+			*event = *existing
+			return ErrAlreadyCreated
+		}
+	}
+
 	// 1. Validaciones de negocio
 	if err := s.validateEvent(event); err != nil {
 		return err
 	}
+	if err := s.validateNotPast(event); err != nil {
+		return err
+	}
 
 	// 2. Aplicar reglas de negocio
 	s.applyBusinessRules(event)
 
 	// 3. Delegar al repositorio
-	return s.eventRepo.Create(event)
+	err := s.eventRepo.Create(event)
+	if err != nil && event.ClientID != "" {
+		// Otro envío del mismo alta pudo crearlo mientras tanto (el índice único lo rechaza)
+		if existing, getErr := s.eventRepo.GetByClientID(event.ClientID); getErr == nil {
+			*event = *existing
+			return ErrAlreadyCreated
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	// 4. Avisar el alta en tiempo real
+	publishChange(s.changes, eventbus.Created, event)
+	return nil
+}
+
+// PrepareImport aplica a un evento importado las validaciones y reglas de CreateEvent,
+// salvo la de fecha pasada para poder importar el historial. No lo guarda.
+func (s *EventCreationService) PrepareImport(event *models.Event) error {
+	if err := s.validateEvent(event); err != nil {
+		return err
+	}
+	s.applyBusinessRules(event)
+	return nil
 }
 
 // validateEvent valida las reglas de negocio para un evento
@@ -38,22 +76,26 @@ func (s *EventCreationService) validateEvent(event *models.Event) error {
 	if event.Title == "" {
 		return errors.New("title is required")
 	}
-	if event.Date.IsZero() {
+	if event.Date.IsZero() && event.StartsAt.IsZero() {
 		return errors.New("date is required")
 	}
-	if event.Time == "" {
-		return errors.New("time is required")
-	}
 
-	// Validar formato de hora
-	_, err := time.Parse("15:04", event.Time)
-	if err != nil {
-		return errors.New("invalid time format, use HH:MM")
+	// Validar formato de hora solo si no es evento de todo el día
+	if !event.IsAllDay {
+		if event.Time == "" {
+			return errors.New("time is required for non-all-day events")
+		}
+
+		// Validar formato de hora
+		_, err := time.Parse("15:04", event.Time)
+		if err != nil {
+			return errors.New("invalid time format, use HH:MM")
+		}
 	}
 
-	// Validar que la fecha no sea en el pasado (opcional)
-	if event.Date.Before(time.Now().Truncate(24 * time.Hour)) {
-		return errors.New("cannot create events in the past")
+	// Validar que el evento no termine antes de empezar
+	if !event.EndsAt.IsZero() && event.EndsAt.Before(event.StartsAt) {
+		return errors.New("event cannot end before it starts")
 	}
 
 	// Validar email si se proporciona
@@ -82,6 +124,14 @@ func (s *EventCreationService) validateEvent(event *models.Event) error {
 	return nil
 }
 
+// validateNotPast valida que la fecha no sea en el pasado
+func (s *EventCreationService) validateNotPast(event *models.Event) error {
+	if event.Date.Before(time.Now().Truncate(24 * time.Hour)) {
+		return errors.New("cannot create events in the past")
+	}
+	return nil
+}
+
 // applyBusinessRules aplica reglas de negocio automáticas
 func (s *EventCreationService) applyBusinessRules(event *models.Event) {
 	// Establecer valores por defecto
@@ -92,19 +142,23 @@ func (s *EventCreationService) applyBusinessRules(event *models.Event) {
 		event.Priority = "medium"
 	}
 
-	// Si es evento de todo el día, limpiar la hora
-	if event.IsAllDay {
-		event.Time = ""
-	}
+	// Completar inicio/fin y mantener fecha/hora legacy alineadas
+	event.ApplyLegacySchedule()
+	event.SyncLegacyFields()
 
 	// Aplicar colores por categoría
 	s.applyCategoryColors(event)
 
-	// Configurar recordatorios por defecto
-	if !event.ReminderDay && !event.ReminderDayBefore {
-		event.ReminderDay = true
-		event.ReminderDayBefore = true
+	// Configurar recordatorios por defecto y mantener los flags legacy alineados
+	if event.Reminders == nil {
+		reminderDay, reminderDayBefore := event.ReminderDay, event.ReminderDayBefore
+		if !reminderDay && !reminderDayBefore {
+			reminderDay = true
+			reminderDayBefore = true
+		}
+		event.Reminders = models.LegacyReminders(reminderDay, reminderDayBefore, event.IsAllDay)
 	}
+	event.SyncReminderFlags()
 }
 
 // applyCategoryColors aplica colores automáticos por categoría
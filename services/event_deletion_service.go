@@ -1,18 +1,23 @@
 package services
 
 import (
+	"calendar-backend/eventbus"
+	"calendar-backend/models"
 	"calendar-backend/repositories"
 	"errors"
+	"time"
 )
 
 // EventDeletionService maneja la lógica específica de eliminación de eventos
 type EventDeletionService struct {
 	eventRepo repositories.EventRepository
+	changes   ChangePublisher
 }
 
-func NewEventDeletionService(eventRepo repositories.EventRepository) *EventDeletionService {
+func NewEventDeletionService(eventRepo repositories.EventRepository, changes ChangePublisher) *EventDeletionService {
 	return &EventDeletionService{
 		eventRepo: eventRepo,
+		changes:   changes,
 	}
 }
 
@@ -24,7 +29,7 @@ func (s *EventDeletionService) DeleteEvent(id uint) error {
 	}
 
 	// 2. Verificar que el evento existe
-	_, err := s.eventRepo.GetByID(id)
+	event, err := s.eventRepo.GetByID(id)
 	if err != nil {
 		return errors.New("event not found")
 	}
@@ -36,7 +41,67 @@ func (s *EventDeletionService) DeleteEvent(id uint) error {
 	// - Enviar notificaciones de cancelación
 
 	// 4. Delegar al repositorio
-	return s.eventRepo.Delete(id)
+	if err := s.eventRepo.Delete(id); err != nil {
+		return err
+	}
+
+	// 5. Avisar la baja en tiempo real
+	publishChange(s.changes, eventbus.Deleted, event)
+	return nil
+}
+
+// DeleteOccurrence elimina una serie recurrente según el alcance pedido:
+// solo la ocurrencia indicada, esa y las siguientes, o toda la serie.
+// Con version distinto de 0 devuelve un VersionConflictError si el evento ya no está en esa versión.
+func (s *EventDeletionService) DeleteOccurrence(id uint, occurrenceDate time.Time, scope string, version int) error {
+	if id == 0 {
+		return errors.New("invalid event ID")
+	}
+
+	master, err := s.eventRepo.GetByID(id)
+	if err != nil {
+		return errors.New("event not found")
+	}
+	if version != 0 && version != master.Version {
+		return &VersionConflictError{Current: master}
+	}
+	if scope == models.ScopeAll || !master.IsRecurring() {
+		return s.DeleteEvent(id)
+	}
+	if !master.HasOccurrenceOn(occurrenceDate) {
+		return errors.New("event has no occurrence on the given date")
+	}
+
+	switch scope {
+	case models.ScopeThis:
+		// Excluir la ocurrencia de la serie (EXDATE)
+		master.AddExDate(occurrenceDate)
+		return s.updateMaster(master)
+	case models.ScopeFollowing:
+		if master.StartsOn(occurrenceDate) {
+			return s.DeleteEvent(id)
+		}
+		// Cortar la serie el día anterior a la ocurrencia
+		rule, err := models.ParseRRule(master.RRule)
+		if err != nil {
+			return err
+		}
+		until := occurrenceDate.AddDate(0, 0, -1)
+		rule.Count = 0
+		rule.Until = &until
+		master.RRule = rule.String()
+		return s.updateMaster(master)
+	}
+	return errors.New("invalid scope, must be: this, following, or all")
+}
+
+// updateMaster guarda la serie después de quitarle ocurrencias
+func (s *EventDeletionService) updateMaster(master *models.Event) error {
+	if err := s.eventRepo.Update(master.ID, master); err != nil {
+		return versionConflict(s.eventRepo, master.ID, err)
+	}
+	publishChange(s.changes, eventbus.Updated, master)
+	return nil
 }
 
 // SoftDeleteEvent implementa eliminación lógica (marcar como eliminado)
@@ -0,0 +1,407 @@
+package services
+
+import (
+	"calendar-backend/eventbus"
+	"calendar-backend/ical"
+	"calendar-backend/models"
+	"calendar-backend/repositories"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Resultado de cada VEVENT en una importación
+const (
+	ImportCreated = "created"
+	ImportUpdated = "updated"
+	ImportSkipped = "skipped"
+	ImportFailed  = "failed"
+	ImportValid   = "valid" // Fila válida en una prueba (dry run), que no se guarda
+)
+
+// ImportItem es el resultado de importar un VEVENT
+type ImportItem struct {
+	Line    int    `json:"line"` // Línea del BEGIN:VEVENT o de la fila en el archivo
+	UID     string `json:"uid,omitempty"`
+	Title   string `json:"title,omitempty"`
+	Status  string `json:"status"` // created, updated, skipped, failed o valid
+	EventID uint   `json:"event_id,omitempty"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+// ImportReport resume una importación de un archivo .ics
+type ImportReport struct {
+	Created int          `json:"created"`
+	Updated int          `json:"updated"`
+	Skipped int          `json:"skipped"`
+	Failed  int          `json:"failed"`
+	Valid   int          `json:"valid,omitempty"`   // Filas válidas en una prueba
+	DryRun  bool         `json:"dry_run,omitempty"` // No se guardó ningún evento
+	Items   []ImportItem `json:"items"`
+}
+
+// ImportRow es una fila de un CSV ya convertida en evento, o el error que lo impidió
+type ImportRow struct {
+	Line  int
+	Title string
+	Event *models.Event
+	Err   error
+}
+
+// add registra el resultado de un VEVENT
+func (r *ImportReport) add(item ImportItem) {
+	switch item.Status {
+	case ImportCreated:
+		r.Created++
+	case ImportUpdated:
+		r.Updated++
+	case ImportSkipped:
+		r.Skipped++
+	case ImportFailed:
+		r.Failed++
+	case ImportValid:
+		r.Valid++
+	}
+	r.Items = append(r.Items, item)
+}
+
+// Prioridad del evento según el PRIORITY iCalendar (1-4 alta, 5 media, 6-9 baja)
+func importPriority(priority int) string {
+	switch {
+	case priority >= 1 && priority <= 4:
+		return "high"
+	case priority >= 6:
+		return "low"
+	}
+	return "medium"
+}
+
+// EventImportService maneja la importación de eventos desde archivos .ics.
+// El UID de cada VEVENT se guarda en el evento para actualizarlo al reimportar.
+type EventImportService struct {
+	eventRepo       repositories.EventRepository
+	creationService *EventCreationService
+	changes         ChangePublisher
+}
+
+func NewEventImportService(eventRepo repositories.EventRepository, creationService *EventCreationService, changes ChangePublisher) *EventImportService {
+	return &EventImportService{
+		eventRepo:       eventRepo,
+		creationService: creationService,
+		changes:         changes,
+	}
+}
+
+// ImportEvents crea o actualiza un evento por VEVENT. email es el destinatario de los
+// recordatorios de los eventos nuevos. Las series se importan antes que sus ocurrencias
+// separadas (RECURRENCE-ID), que necesitan la serie ya guardada.
+func (s *EventImportService) ImportEvents(vevents []ical.Event, email string) *ImportReport {
+	items := make([]ImportItem, len(vevents))
+
+	// Fechas reemplazadas por ocurrencias separadas o canceladas, que se excluyen de cada serie
+	overrides := make(map[string][]time.Time)
+	for _, vevent := range vevents {
+		if vevent.Err == nil && vevent.RecurrenceID != nil {
+			overrides[vevent.UID] = append(overrides[vevent.UID], *vevent.RecurrenceID)
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, pass := range []bool{false, true} {
+		for i := range vevents {
+			vevent := &vevents[i]
+			if (vevent.RecurrenceID != nil) != pass {
+				continue
+			}
+
+			item := ImportItem{Line: vevent.Line, UID: vevent.UID, Title: vevent.Summary}
+			key := vevent.UID
+			if vevent.RecurrenceID != nil {
+				key += "|" + vevent.RecurrenceID.Format("2006-01-02")
+			}
+
+			switch {
+			case vevent.Err != nil:
+				item.Status, item.Reason = ImportFailed, vevent.Err.Error()
+			case seen[key]:
+				item.Status, item.Reason = ImportSkipped, "duplicate UID in file"
+			case vevent.Status == "CANCELLED":
+				item.Status, item.Reason = ImportSkipped, "event is cancelled"
+			default:
+				id, status, err := s.importEvent(vevent, overrides[vevent.UID], email)
+				item.EventID, item.Status = id, status
+				if err != nil {
+					item.Reason = err.Error()
+				}
+			}
+			if vevent.UID != "" {
+				seen[key] = true
+			}
+			items[i] = item
+		}
+	}
+
+	report := &ImportReport{Items: make([]ImportItem, 0, len(items))}
+	for _, item := range items {
+		report.add(item)
+	}
+	return report
+}
+
+// ImportRows crea un evento por cada fila válida de un CSV. Con dryRun solo informa
+// qué filas son válidas.
+func (s *EventImportService) ImportRows(rows []ImportRow, dryRun bool) *ImportReport {
+	report := &ImportReport{DryRun: dryRun, Items: make([]ImportItem, 0, len(rows))}
+	for _, row := range rows {
+		item := ImportItem{Line: row.Line, Title: row.Title}
+		switch {
+		case row.Err != nil:
+			item.Status, item.Reason = ImportFailed, row.Err.Error()
+		case dryRun:
+			item.Status = ImportValid
+		default:
+			if err := s.creationService.CreateEvent(row.Event); err != nil {
+				item.Status, item.Reason = ImportFailed, err.Error()
+			} else {
+				item.Status, item.EventID = ImportCreated, row.Event.ID
+			}
+		}
+		report.add(item)
+	}
+	return report
+}
+
+// importEvent crea o actualiza el evento de un VEVENT y devuelve su ID y el resultado.
+// Los errores de ImportSkipped explican el motivo y los de ImportFailed el fallo.
+func (s *EventImportService) importEvent(vevent *ical.Event, overrideDates []time.Time, email string) (uint, string, error) {
+	event, err := toImportedEvent(vevent)
+	if err != nil {
+		return 0, ImportFailed, err
+	}
+
+	existing, err := findSeries(s.eventRepo, vevent.UID)
+	if err != nil {
+		return 0, ImportFailed, err
+	}
+	master, current := matchImported(existing, vevent.RecurrenceID)
+
+	if vevent.RecurrenceID != nil {
+		if master == nil || !master.IsRecurring() {
+			return 0, ImportSkipped, errors.New("recurring series not found")
+		}
+		event.RecurrenceID = &master.ID
+		event.OriginalDate = vevent.RecurrenceID
+		event.ExternalUID = master.ExternalUID
+		event.CalendarID = master.CalendarID
+	} else {
+		// Las ocurrencias separadas, del archivo o editadas en la app, siguen excluidas
+		for _, date := range overrideDates {
+			event.AddExDate(date)
+		}
+		for _, other := range existing {
+			if other.RecurrenceID != nil && other.OriginalDate != nil && current != nil && *other.RecurrenceID == current.ID {
+				event.AddExDate(*other.OriginalDate)
+			}
+		}
+	}
+
+	if current == nil {
+		event.Email = email
+		if err := s.creationService.PrepareImport(event); err != nil {
+			return 0, ImportFailed, err
+		}
+		if err := s.eventRepo.Create(event); err != nil {
+			return 0, ImportFailed, err
+		}
+		publishChange(s.changes, eventbus.Created, event)
+		if err := s.excludeFromSeries(master, event); err != nil {
+			return event.ID, ImportFailed, err
+		}
+		return event.ID, ImportCreated, nil
+	}
+
+	// Los campos que el .ics no trae se conservan; los eventos creados en la app siguen
+	// identificándose por su ID
+	event.ID = current.ID
+	event.ExternalUID = current.ExternalUID
+	event.OwnerID = current.OwnerID
+	event.CalendarID = current.CalendarID
+	event.Email = current.Email
+	event.Phone = current.Phone
+	event.Color = current.Color
+	event.CreatedAt = current.CreatedAt
+	if err := s.creationService.PrepareImport(event); err != nil {
+		return current.ID, ImportFailed, err
+	}
+	if sameImportedFields(current, event) {
+		return current.ID, ImportSkipped, errors.New("unchanged")
+	}
+	if err := s.eventRepo.Replace(event); err != nil {
+		return current.ID, ImportFailed, err
+	}
+	if err := s.eventRepo.ReplaceReminders(event); err != nil {
+		return current.ID, ImportFailed, err
+	}
+	publishChange(s.changes, eventbus.Updated, event)
+	return current.ID, ImportUpdated, nil
+}
+
+// excludeFromSeries agrega la fecha de una ocurrencia separada a las excepciones de su serie
+func (s *EventImportService) excludeFromSeries(master *models.Event, event *models.Event) error {
+	if master == nil || event.OriginalDate == nil {
+		return nil
+	}
+	exDates := master.ExDates
+	master.AddExDate(*event.OriginalDate)
+	if master.ExDates == exDates {
+		return nil
+	}
+	if err := s.eventRepo.Update(master.ID, master); err != nil {
+		return err
+	}
+	publishChange(s.changes, eventbus.Updated, master)
+	return nil
+}
+
+// findSeries obtiene la serie (o evento simple) con ese UID y sus ocurrencias separadas:
+// los eventos importados con ese UID o, para los UID generados al exportar, el evento original
+func findSeries(eventRepo repositories.EventRepository, uid string) ([]models.Event, error) {
+	events, err := eventRepo.GetByExternalUID(uid)
+	if err != nil || len(events) > 0 {
+		return events, err
+	}
+
+	id, ok := ical.ParseEventUID(uid)
+	if !ok {
+		return nil, nil
+	}
+	series, err := eventRepo.GetSeries(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, event := range series {
+		if event.ID == id && (event.ExternalUID != "" || event.RecurrenceID != nil) {
+			return nil, nil
+		}
+	}
+	return series, nil
+}
+
+// matchImported busca, entre los eventos con el UID, la serie (o evento simple) y el evento
+// que corresponde al VEVENT: la serie misma o su ocurrencia separada de esa fecha
+func matchImported(events []models.Event, recurrenceID *time.Time) (master *models.Event, current *models.Event) {
+	for i := range events {
+		if events[i].RecurrenceID == nil {
+			master = &events[i]
+			break
+		}
+	}
+	if recurrenceID == nil || master == nil {
+		return master, master
+	}
+	for i := range events {
+		event := &events[i]
+		if event.RecurrenceID != nil && *event.RecurrenceID == master.ID &&
+			event.OriginalDate != nil && event.OriginalDate.Format("2006-01-02") == recurrenceID.Format("2006-01-02") {
+			return master, event
+		}
+	}
+	return master, nil
+}
+
+// toImportedEvent convierte un VEVENT en evento, sin completar los valores por defecto
+func toImportedEvent(vevent *ical.Event) (*models.Event, error) {
+	event := &models.Event{
+		Title:       strings.TrimSpace(vevent.Summary),
+		Description: strings.TrimSpace(vevent.Description),
+		Location:    strings.TrimSpace(vevent.Location),
+		StartsAt:    vevent.Start,
+		EndsAt:      vevent.End,
+		TimeZone:    vevent.TimeZone,
+		IsAllDay:    vevent.AllDay,
+		Priority:    importPriority(vevent.Priority),
+		ExternalUID: vevent.UID,
+	}
+	if len(vevent.Categories) > 0 {
+		event.Category = strings.ToLower(vevent.Categories[0])
+	}
+
+	if vevent.RRule != "" && vevent.RecurrenceID == nil {
+		rule, err := models.ParseRRule(vevent.RRule)
+		if err != nil {
+			return nil, fmt.Errorf("unsupported rrule: %w", err)
+		}
+		event.RRule = rule.String()
+		for _, date := range vevent.ExDates {
+			event.AddExDate(date)
+		}
+	}
+
+	// Recordatorios: los VALARM antes del inicio y dentro de la anticipación permitida.
+	// Sin VALARM el evento queda sin recordatorios (lista vacía, no los por defecto).
+	var reminders []models.Reminder
+	for _, alarm := range vevent.Alarms {
+		minutes := int(alarm.Before / time.Minute)
+		if alarm.Before < 0 || minutes > models.MaxReminderOffsetMinutes {
+			continue
+		}
+		channel := ""
+		if alarm.Action == "EMAIL" {
+			channel = "email"
+		}
+		reminders = append(reminders, models.Reminder{OffsetMinutes: minutes, Channel: channel})
+	}
+	if len(reminders) > models.MaxRemindersPerEvent {
+		reminders = reminders[:models.MaxRemindersPerEvent]
+	}
+	normalized, err := models.NormalizeReminders(reminders)
+	if err != nil {
+		return nil, err
+	}
+	event.Reminders = normalized
+
+	// Fecha y hora legacy, que valida EventCreationService
+	event.SyncLegacyFields()
+	return event, nil
+}
+
+// sameImportedFields indica si reimportar el evento no cambiaría nada de lo que trae el .ics
+func sameImportedFields(existing, event *models.Event) bool {
+	return existing.Title == event.Title &&
+		existing.Description == event.Description &&
+		existing.Location == event.Location &&
+		existing.StartsAt.Equal(event.StartsAt) &&
+		existing.EndsAt.Equal(event.EndsAt) &&
+		existing.Zone().String() == event.Zone().String() &&
+		existing.IsAllDay == event.IsAllDay &&
+		existing.Priority == event.Priority &&
+		existing.Category == event.Category &&
+		existing.RRule == event.RRule &&
+		sortedExDates(existing) == sortedExDates(event) &&
+		reminderKeys(existing.Reminders) == reminderKeys(event.Reminders)
+}
+
+func sortedExDates(event *models.Event) string {
+	var dates []string
+	for _, date := range event.ExDateList() {
+		dates = append(dates, date.Format("2006-01-02"))
+	}
+	sort.Strings(dates)
+	return strings.Join(dates, ",")
+}
+
+func reminderKeys(reminders []models.Reminder) string {
+	keys := make([]string, len(reminders))
+	for i, reminder := range reminders {
+		keys[i] = fmt.Sprintf("%d|%s", reminder.OffsetMinutes, reminder.Channel)
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ",")
+}
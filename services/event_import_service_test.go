@@ -0,0 +1,257 @@
+package services
+
+import (
+	"bytes"
+	"calendar-backend/eventcsv"
+	"calendar-backend/handlers/dto"
+	"calendar-backend/ical"
+	"calendar-backend/models"
+	"calendar-backend/repositories"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestImportEventsUpdateKeepsCalendar(t *testing.T) {
+	db := newTestDB(t)
+	eventRepo := newTestEventRepository(t, db)
+	calendarRepo := repositories.NewCalendarRepository(db)
+	user := createTestUser(t, db, "ana@example.com")
+	service := NewEventService(eventRepo, calendarRepo, nil, nil).ForUser(user.ID)
+
+	start := time.Now().Add(48 * time.Hour).UTC().Truncate(time.Hour)
+	vevent := ical.Event{UID: "meeting@example.com", Summary: "Reunión", Start: start, End: start.Add(time.Hour), TimeZone: "UTC"}
+	if report := service.ImportEvents([]ical.Event{vevent}, user.Email); report.Created != 1 {
+		t.Fatalf("first import: %+v", report)
+	}
+
+	vevent.Summary = "Reunión movida"
+	if report := service.ImportEvents([]ical.Event{vevent}, user.Email); report.Updated != 1 {
+		t.Fatalf("second import: %+v", report)
+	}
+
+	events, err := service.GetAllEvents()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("user sees %d events after re-import, want 1", len(events))
+	}
+	calendar, err := calendarRepo.GetDefault(user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if events[0].CalendarID != calendar.ID || events[0].Title != "Reunión movida" {
+		t.Errorf("event = calendar %d %q, want calendar %d %q", events[0].CalendarID, events[0].Title, calendar.ID, "Reunión movida")
+	}
+}
+
+func TestImportEventsDeduplicatesByUID(t *testing.T) {
+	db := newTestDB(t)
+	eventRepo := newTestEventRepository(t, db)
+	user := createTestUser(t, db, "ana@example.com")
+	service := NewEventService(eventRepo, repositories.NewCalendarRepository(db), nil, nil).ForUser(user.ID)
+
+	start := time.Now().Add(48 * time.Hour).UTC().Truncate(time.Hour)
+	movedDate := time.Date(start.Year(), start.Month(), start.Day()+7, 0, 0, 0, 0, time.UTC)
+	series := ical.Event{UID: "standup@example.com", Summary: "Standup", Start: start, End: start.Add(time.Hour), TimeZone: "UTC", RRule: "FREQ=WEEKLY"}
+	moved := ical.Event{UID: series.UID, Summary: "Standup movido", Start: start.AddDate(0, 0, 7).Add(2 * time.Hour), End: start.AddDate(0, 0, 7).Add(3 * time.Hour), TimeZone: "UTC", RecurrenceID: &movedDate}
+	renamed := moved
+	renamed.Summary = "Standup en sala 2"
+	other := ical.Event{UID: "dentist@example.com", Summary: "Dentista", Start: start, End: start.Add(time.Hour), TimeZone: "UTC"}
+
+	tests := []struct {
+		name    string
+		vevents []ical.Event
+		want    ImportReport
+	}{
+		// La ocurrencia separada va antes que su serie en el archivo y se importa después
+		{"first import", []ical.Event{moved, series}, ImportReport{Created: 2}},
+		{"unchanged re-import", []ical.Event{series, moved}, ImportReport{Skipped: 2}},
+		{"duplicate UID in file", []ical.Event{series, series}, ImportReport{Skipped: 2}},
+		{"changed override", []ical.Event{series, renamed}, ImportReport{Updated: 1, Skipped: 1}},
+		{"another UID", []ical.Event{other}, ImportReport{Created: 1}},
+	}
+	for _, tt := range tests {
+		report := service.ImportEvents(tt.vevents, user.Email)
+		if report.Created != tt.want.Created || report.Updated != tt.want.Updated || report.Skipped != tt.want.Skipped || report.Failed != 0 {
+			t.Errorf("%s: report %+v, want %+v", tt.name, report, tt.want)
+		}
+	}
+
+	events, err := eventRepo.GetByExternalUID(series.UID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("%d events with UID %s, want the series and one override", len(events), series.UID)
+	}
+	master, override := events[0], events[1]
+	if master.RecurrenceID != nil {
+		master, override = override, master
+	}
+	if override.RecurrenceID == nil || *override.RecurrenceID != master.ID || override.Title != renamed.Summary {
+		t.Errorf("override %q of series %v, want %q of series %d", override.Title, override.RecurrenceID, renamed.Summary, master.ID)
+	}
+	if dates := sortedExDates(&master); dates != movedDate.Format("2006-01-02") {
+		t.Errorf("series exdates %q, want the overridden date only", dates)
+	}
+}
+
+func TestImportRowsDryRun(t *testing.T) {
+	start := time.Now().Add(48 * time.Hour).UTC().Truncate(time.Hour)
+	rows := []ImportRow{
+		{Line: 2, Title: "Reunión", Event: newTestEvent(0, "Reunión", start, "")},
+		{Line: 3, Title: "Sin fecha", Err: errors.New("date or starts_at is required")},
+		{Line: 4, Title: "Clase", Event: newTestEvent(0, "Clase", start.Add(time.Hour), "FREQ=WEEKLY")},
+	}
+
+	tests := []struct {
+		name       string
+		dryRun     bool
+		want       ImportReport
+		wantStatus []string
+		wantEvents int
+	}{
+		{"dry run", true, ImportReport{Valid: 2, Failed: 1, DryRun: true}, []string{ImportValid, ImportFailed, ImportValid}, 0},
+		{"import", false, ImportReport{Created: 2, Failed: 1}, []string{ImportCreated, ImportFailed, ImportCreated}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t)
+			user := createTestUser(t, db, "ana@example.com")
+			service := NewEventService(newTestEventRepository(t, db), repositories.NewCalendarRepository(db), nil, nil).ForUser(user.ID)
+
+			// Cada importación recibe sus propias copias de los eventos
+			imported := make([]ImportRow, len(rows))
+			for i, row := range rows {
+				imported[i] = row
+				if row.Event != nil {
+					event := *row.Event
+					imported[i].Event = &event
+				}
+			}
+			report := service.ImportRows(imported, tt.dryRun)
+			if report.Created != tt.want.Created || report.Valid != tt.want.Valid || report.Failed != tt.want.Failed || report.DryRun != tt.want.DryRun {
+				t.Errorf("report %+v, want %+v", report, tt.want)
+			}
+			for i, item := range report.Items {
+				if item.Line != rows[i].Line || item.Status != tt.wantStatus[i] || (item.Status == ImportCreated) != (item.EventID != 0) {
+					t.Errorf("row %d: %+v, want status %s", rows[i].Line, item, tt.wantStatus[i])
+				}
+			}
+			events, err := service.GetAllEvents()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(events) != tt.wantEvents {
+				t.Errorf("%d events saved, want %d", len(events), tt.wantEvents)
+			}
+		})
+	}
+}
+
+func TestCSVExportImportRoundTrip(t *testing.T) {
+	db := newTestDB(t)
+	eventRepo := newTestEventRepository(t, db)
+	events := NewEventService(eventRepo, repositories.NewCalendarRepository(db), nil, nil)
+	ana := createTestUser(t, db, "ana@example.com")
+	bob := createTestUser(t, db, "bob@example.com")
+
+	madrid := mustLoadLocation(t, "Europe/Madrid")
+	now := time.Now().In(madrid)
+	meeting := newTestEvent(ana.ID, "Reunión, planificación", time.Date(now.Year(), now.Month(), now.Day()+2, 9, 30, 0, 0, madrid), "")
+	meeting.Description = "Traer \"informe\";\nsegunda línea"
+	meeting.Location = "Sala 2"
+	meeting.Category = "work"
+	meeting.Color = "#FF3B30"
+	meeting.Priority = "high"
+	trip := newTestEvent(ana.ID, "Viaje", time.Date(now.Year(), now.Month(), now.Day()+3, 0, 0, 0, 0, madrid), "")
+	trip.IsAllDay = true
+	trip.EndsAt = time.Date(now.Year(), now.Month(), now.Day()+6, 0, 0, 0, 0, madrid).UTC()
+	class := newTestEvent(ana.ID, "Clase", time.Date(now.Year(), now.Month(), now.Day()+1, 18, 0, 0, 0, madrid), "FREQ=WEEKLY;COUNT=10")
+	class.AddExDate(class.StartsAt.AddDate(0, 0, 14))
+	for _, event := range []*models.Event{meeting, trip, class} {
+		event.SyncLegacyFields()
+		if err := events.ForUser(ana.ID).CreateEvent(event); err != nil {
+			t.Fatalf("create %q: %v", event.Title, err)
+		}
+	}
+
+	// Exportación de ana, como la descarga GET /api/v1/events/export.csv
+	var file bytes.Buffer
+	writer, err := eventcsv.NewWriter(&file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = events.ForUser(ana.ID).StreamEvents(&dto.GetEventsQueryRequest{}, func(batch []models.Event) error {
+		for i := range batch {
+			if err := writer.Write(&batch[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil || writer.Flush() != nil {
+		t.Fatalf("export: %v", err)
+	}
+
+	// bob importa el archivo sin mapeo, como POST /api/v1/events/import.csv
+	reader, err := eventcsv.NewReader(&file, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := dto.CSVImportRequest{DateFormat: "YYYY-MM-DD"}
+	columns, err := req.ResolveColumns(reader.Header())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rows []ImportRow
+	for {
+		record, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		row := ImportRow{Line: record.Line}
+		eventReq, err := req.EventRequest(record, columns)
+		if err == nil {
+			row.Event, err = eventReq.Prepare("UTC")
+		}
+		row.Err = err
+		rows = append(rows, row)
+	}
+	if report := events.ForUser(bob.ID).ImportRows(rows, false); report.Created != 3 || report.Failed != 0 {
+		t.Fatalf("import: %+v", report)
+	}
+
+	imported, err := events.ForUser(bob.ID).GetAllEvents()
+	if err != nil {
+		t.Fatal(err)
+	}
+	byTitle := map[string]models.Event{}
+	for _, event := range imported {
+		byTitle[event.Title] = event
+	}
+	for _, want := range []*models.Event{meeting, trip, class} {
+		got, ok := byTitle[want.Title]
+		if !ok {
+			t.Errorf("%q was not imported", want.Title)
+			continue
+		}
+		if !got.StartsAt.Equal(want.StartsAt) || !got.EndsAt.Equal(want.EndsAt) || got.TimeZone != want.TimeZone || got.IsAllDay != want.IsAllDay {
+			t.Errorf("%q: %s-%s in %s (all day %v), want %s-%s in %s (all day %v)", want.Title,
+				got.StartsAt, got.EndsAt, got.TimeZone, got.IsAllDay, want.StartsAt, want.EndsAt, want.TimeZone, want.IsAllDay)
+		}
+		if got.Description != want.Description || got.Location != want.Location || got.Category != want.Category ||
+			got.Color != want.Color || got.Priority != want.Priority || got.Email != want.Email || got.Phone != want.Phone {
+			t.Errorf("%q: imported %+v, want the exported fields of %+v", want.Title, got, *want)
+		}
+		if got.RRule != want.RRule || sortedExDates(&got) != sortedExDates(want) {
+			t.Errorf("%q: rrule %q exdates %q, want %q %q", want.Title, got.RRule, got.ExDates, want.RRule, want.ExDates)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+//go:build sqlite_fts5
+
+package services
+
+import (
+	"calendar-backend/models"
+	"calendar-backend/repositories"
+	"calendar-backend/search"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Estos tests necesitan el índice FTS5: go test -tags sqlite_fts5 ./services
+func TestSearchEventsWithFTS5(t *testing.T) {
+	db := newTestDB(t)
+	engine, err := search.Setup(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if engine.Name() != "SQLite FTS5" {
+		t.Fatalf("search engine %q, want SQLite FTS5", engine.Name())
+	}
+	user := createTestUser(t, db, "ana@example.com")
+	eventRepo := repositories.NewEventRepository(db, engine).ForOwner(user.ID)
+	service := NewEventService(repositories.NewEventRepository(db, engine), repositories.NewCalendarRepository(db), nil, nil).ForUser(user.ID)
+
+	start := time.Date(2030, 7, 1, 9, 0, 0, 0, time.UTC)
+	titles := map[string]uint{}
+	for i, f := range []struct{ title, description, category string }{
+		{"Reunión de planificación", "Objetivos del trimestre", "work"},
+		{"Team meetings", "Weekly sync", "work"},
+		{"Almuerzo", "Reunion informal con el equipo", "personal"},
+		{"Cumpleaños de Íñigo", "Comprar regalo", "family"},
+	} {
+		event := newTestEvent(user.ID, f.title, start.AddDate(0, 0, i), "")
+		event.Description, event.Category = f.description, f.category
+		if err := eventRepo.Create(event); err != nil {
+			t.Fatal(err)
+		}
+		titles[f.title] = event.ID
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		want  []string // De mayor a menor relevancia
+	}{
+		{"ignores accents, title ranks first", "reunion", []string{"Reunión de planificación", "Almuerzo"}},
+		{"accented query", "reunión", []string{"Reunión de planificación", "Almuerzo"}},
+		{"stems words", "meeting", []string{"Team meetings"}},
+		{"prefix", "planif*", []string{"Reunión de planificación"}},
+		{"accents in names", "inigo cumpleanos", []string{"Cumpleaños de Íñigo"}},
+		{"phrase in order", `"informal con"`, []string{"Almuerzo"}},
+		{"phrase out of order", `"con informal"`, nil},
+		{"category", "family", []string{"Cumpleaños de Íñigo"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := service.SearchEvents(tt.query, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, result := range results {
+				got = append(got, result.Title)
+				if len(result.Highlights) == 0 {
+					t.Errorf("%q has no highlights", result.Title)
+				}
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("search %q: %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+
+	results, err := service.SearchEvents("reunion", 10)
+	if err != nil || len(results) != 2 {
+		t.Fatalf("search: %d results, %v", len(results), err)
+	}
+	if results[0].Highlights["title"] != "<mark>Reunión</mark> de planificación" || results[1].Highlights["description"] != "<mark>Reunion</mark> informal con el equipo" {
+		t.Errorf("highlights %v and %v", results[0].Highlights, results[1].Highlights)
+	}
+
+	// Los triggers mantienen el índice al editar y eliminar
+	id := titles["Team meetings"]
+	if err := eventRepo.Update(id, &models.Event{Title: "Standup diario"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := eventRepo.Delete(titles["Almuerzo"]); err != nil {
+		t.Fatal(err)
+	}
+	for query, want := range map[string]int{"meeting": 0, "standup": 1, "informal": 0} {
+		if results, err := service.SearchEvents(query, 10); err != nil || len(results) != want {
+			t.Errorf("search %q after the changes: %d results (%v), want %d", query, len(results), err, want)
+		}
+	}
+}
@@ -1,13 +1,58 @@
 package services
 
 import (
+	"calendar-backend/eventbus"
 	"calendar-backend/handlers/dto"
+	"calendar-backend/ical"
 	"calendar-backend/models"
 	"calendar-backend/repositories"
+	"calendar-backend/search"
 	"errors"
+	"fmt"
 	"time"
 )
 
+// streamBatchSize es la cantidad de eventos que StreamEvents lee por consulta
+const streamBatchSize = 500
+
+// snippetWords es la cantidad de palabras de los fragmentos resaltados de la búsqueda
+const snippetWords = 20
+
+// SearchResult es un evento encontrado por la búsqueda, con su relevancia y, por campo,
+// el fragmento que coincide con las palabras buscadas entre <mark> y </mark>
+type SearchResult struct {
+	models.Event
+	Score      float64           `json:"score"`
+	Highlights map[string]string `json:"highlights"`
+}
+
+// ErrAlreadyCreated indica que ya existe el evento con el client_id del alta (la app la
+// reenvió): no se crea otro y el evento recibido se completa con el guardado
+var ErrAlreadyCreated = errors.New("event with this client_id was already created")
+
+// VersionConflictError indica que el cliente modificó una versión vieja del evento. Current es
+// la copia guardada, para que el cliente resuelva el conflicto y reintente con su versión.
+type VersionConflictError struct {
+	Current *models.Event
+}
+
+func (e *VersionConflictError) Error() string {
+	return fmt.Sprintf("event was modified by someone else, current version is %d", e.Current.Version)
+}
+
+// versionConflict convierte el ErrStaleVersion del repositorio en un VersionConflictError con
+// la copia actual del evento
+func versionConflict(eventRepo repositories.EventRepository, id uint, err error) error {
+	if !errors.Is(err, repositories.ErrStaleVersion) {
+		return err
+	}
+	current, getErr := eventRepo.GetByID(id)
+	if getErr != nil {
+		return err
+	}
+	return &VersionConflictError{Current: current}
+}
+
 // Interfaces específicas para cada operación
 type EventCreator interface {
 	CreateEvent(event *models.Event) error
@@ -15,59 +60,225 @@ type EventCreator interface {
 
 type EventReader interface {
 	GetEventByID(id uint) (*models.Event, error)
+	// GetEventByClientID obtiene el evento que creó la app con ese client_id
+	GetEventByClientID(clientID string) (*models.Event, error)
+	GetEventSeries(id uint) ([]models.Event, error)
 	GetAllEvents() ([]models.Event, error)
 	GetEventsByDate(date string) ([]models.Event, error)
 	GetTodayEvents() ([]models.Event, error)
-	GetUpcomingEvents() ([]models.Event, error)
+	GetUpcomingEvents(limit int) ([]models.Event, error)
 	GetEventsForDateRange(startDate, endDate string) ([]models.Event, error)
-	SearchEvents(query string) ([]models.Event, error)
+	SearchEvents(query string, limit int) ([]SearchResult, error)
 }
 
 type EventUpdater interface {
 	UpdateEvent(id uint, event *models.Event) error
+	UpdateOccurrence(id uint, occurrenceDate time.Time, scope string, event *models.Event) error
 }
 
 type EventDeleter interface {
 	DeleteEvent(id uint) error
+	// DeleteOccurrence con version distinto de 0 solo elimina si el evento sigue en esa versión
+	DeleteOccurrence(id uint, occurrenceDate time.Time, scope string, version int) error
+}
+
+// EventImporter crea o actualiza eventos a partir de los VEVENT de un archivo .ics
+// o de las filas de un CSV
+type EventImporter interface {
+	ImportEvents(events []ical.Event, email string) *ImportReport
+	ImportRows(rows []ImportRow, dryRun bool) *ImportReport
 }
 
 type EventStatsProvider interface {
 	GetEventStats() (map[string]interface{}, error)
 }
 
+// EventPage es una página de resultados de GetEvents
+type EventPage struct {
+	Events     []models.Event
+	NextCursor string // Cursor de la página siguiente; vacío si es la última
+}
+
 type EventQueryHandler interface {
-	GetEvents(queryReq interface{}) ([]models.Event, error)
+	GetEvents(queryReq interface{}) (*EventPage, error)
+	// StreamEvents recorre los resultados de GetEvents de a lotes, sin cargarlos todos en memoria
+	StreamEvents(queryReq interface{}, fn func(batch []models.Event) error) error
 }
 
 // Interface principal que combina todas las operaciones
 type EventService interface {
+	// ForUser devuelve el servicio limitado a los calendarios del usuario autenticado y a los
+	// compartidos con él, que controla su rol en cada calendario
+	ForUser(userID uint) EventService
+	// Transaction ejecuta fn con un servicio cuyas escrituras van en una misma transacción:
+	// si fn devuelve un error no se guarda ninguna. Los cambios se avisan al confirmarla.
+	Transaction(fn func(service EventService) error) error
 	EventCreator
 	EventReader
 	EventUpdater
 	EventDeleter
+	EventImporter
 	EventStatsProvider
 	EventQueryHandler
 }
 
+// ReminderScheduler recibe el aviso de que cambiaron eventos para recalcular sus recordatorios
+type ReminderScheduler interface {
+	Reschedule()
+}
+
+// ChangePublisher recibe cada cambio de un evento para avisarlo en tiempo real
+type ChangePublisher interface {
+	Publish(change eventbus.Change)
+}
+
+// ChangePublishers avisa cada cambio a varios publicadores (ej: el bus de eventos y los webhooks)
+type ChangePublishers []ChangePublisher
+
+func (p ChangePublishers) Publish(change eventbus.Change) {
+	for _, publisher := range p {
+		publisher.Publish(change)
+	}
+}
+
+// publishChange avisa el cambio del evento, si hay a quién avisarlo. CalendarAudience lo
+// avisa a los usuarios que ven el calendario del evento.
+func publishChange(changes ChangePublisher, changeType string, event *models.Event) {
+	publishChangeTo(changes, changeType, event, nil)
+}
+
+// publishChangeTo avisa el cambio del evento solo a los usuarios dados (ej: a los que dejaron
+// de verlo); sin usuarios lo avisa como publishChange
+func publishChangeTo(changes ChangePublisher, changeType string, event *models.Event, userIDs []uint) {
+	if changes != nil {
+		changes.Publish(eventbus.Change{
+			Type:       changeType,
+			OwnerID:    event.OwnerID,
+			CalendarID: event.CalendarID,
+			UserIDs:    userIDs,
+			EventID:    event.ID,
+		})
+	}
+}
+
+// pendingChanges guarda los cambios de una transacción para avisarlos recién al confirmarla
+type pendingChanges []eventbus.Change
+
+func (p *pendingChanges) Publish(change eventbus.Change) {
+	*p = append(*p, change)
+}
+
 type eventService struct {
 	eventRepo       repositories.EventRepository
+	calendarRepo    repositories.CalendarRepository
+	userID          uint // 0 = sin controlar permisos (procesos internos)
 	creationService *EventCreationService
 	updateService   *EventUpdateService
 	deletionService *EventDeletionService
+	importService   *EventImportService
+	reminders       ReminderScheduler
+	changes         ChangePublisher
 }
 
-func NewEventService(eventRepo repositories.EventRepository) EventService {
+func NewEventService(eventRepo repositories.EventRepository, calendarRepo repositories.CalendarRepository, reminders ReminderScheduler, changes ChangePublisher) EventService {
+	creationService := NewEventCreationService(eventRepo, changes)
 	return &eventService{
 		eventRepo:       eventRepo,
-		creationService: NewEventCreationService(eventRepo),
-		updateService:   NewEventUpdateService(eventRepo),
-		deletionService: NewEventDeletionService(eventRepo),
+		calendarRepo:    calendarRepo,
+		creationService: creationService,
+		updateService:   NewEventUpdateService(eventRepo, calendarRepo, changes),
+		deletionService: NewEventDeletionService(eventRepo, changes),
+		importService:   NewEventImportService(eventRepo, creationService, changes),
+		reminders:       reminders,
+		changes:         changes,
+	}
+}
+
+func (s *eventService) ForUser(userID uint) EventService {
+	scoped := NewEventService(s.eventRepo.ForUser(userID), s.calendarRepo, s.reminders, s.changes).(*eventService)
+	scoped.userID = userID
+	// Lo importado va a los calendarios propios del usuario, donde lo busca al reimportarlo
+	own := s.eventRepo.ForOwner(userID)
+	scoped.importService = NewEventImportService(own, NewEventCreationService(own, s.changes), s.changes)
+	return scoped
+}
+
+func (s *eventService) Transaction(fn func(service EventService) error) error {
+	var pending pendingChanges
+	err := s.eventRepo.Transaction(func(repo repositories.EventRepository) error {
+		// Sin motor de recordatorios: se recalculan una sola vez, al confirmar
+		var scoped EventService = NewEventService(repo, s.calendarRepo, nil, &pending)
+		if s.userID != 0 {
+			scoped = scoped.ForUser(s.userID)
+		}
+		return fn(scoped)
+	})
+	if err != nil {
+		return err
 	}
+
+	if s.changes != nil {
+		for _, change := range pending {
+			s.changes.Publish(change)
+		}
+	}
+	return s.rescheduled(nil)
 }
 
+// CreateEvent crea el evento en su calendario (0 = el predeterminado del usuario), que debe
+// poder editar. El dueño del evento es el del calendario.
 func (s *eventService) CreateEvent(event *models.Event) error {
+	if s.userID != 0 {
+		calendar, err := s.writableCalendar(event.CalendarID)
+		if err != nil {
+			return err
+		}
+		event.CalendarID, event.OwnerID = calendar.ID, calendar.OwnerID
+	}
 	// Delegar al servicio específico de creación
-	return s.creationService.CreateEvent(event)
+	return s.rescheduled(s.creationService.CreateEvent(event))
+}
+
+// writableCalendar devuelve el calendario en el que el usuario puede crear o mover eventos
+// (0 = su predeterminado)
+func (s *eventService) writableCalendar(id uint) (*models.Calendar, error) {
+	if id == 0 {
+		return s.calendarRepo.GetDefault(s.userID)
+	}
+	return authorizeCalendar(s.calendarRepo, s.userID, id, models.CalendarEditor)
+}
+
+// authorizeWrite devuelve el evento si el usuario puede modificarlo: debe ser editor de su
+// calendario. Si cambia de calendario, también del nuevo, que debe ser del mismo dueño.
+func (s *eventService) authorizeWrite(id uint, event *models.Event) (*models.Event, error) {
+	if id == 0 {
+		return nil, errors.New("invalid event ID")
+	}
+	existing, err := s.eventRepo.GetByID(id)
+	if err != nil {
+		return nil, errors.New("event not found")
+	}
+	if s.userID == 0 {
+		return existing, nil
+	}
+	if _, err := authorizeCalendar(s.calendarRepo, s.userID, existing.CalendarID, models.CalendarEditor); err != nil {
+		return nil, err
+	}
+
+	if event == nil || event.CalendarID == 0 || event.CalendarID == existing.CalendarID {
+		return existing, nil
+	}
+	if existing.RecurrenceID != nil {
+		return nil, errors.New("an occurrence moves to another calendar with its series")
+	}
+	target, err := authorizeCalendar(s.calendarRepo, s.userID, event.CalendarID, models.CalendarEditor)
+	if err != nil {
+		return nil, err
+	}
+	if target.OwnerID != existing.OwnerID {
+		return nil, errors.New("events can only be moved between calendars of the same owner")
+	}
+	return existing, nil
 }
 
 func (s *eventService) GetEventByID(id uint) (*models.Event, error) {
@@ -77,6 +288,22 @@ func (s *eventService) GetEventByID(id uint) (*models.Event, error) {
 	return s.eventRepo.GetByID(id)
 }
 
+func (s *eventService) GetEventByClientID(clientID string) (*models.Event, error) {
+	event, err := s.eventRepo.GetByClientID(clientID)
+	if err != nil || event.DeletedAt.Valid {
+		return nil, errors.New("event not found")
+	}
+	return event, nil
+}
+
+// GetEventSeries devuelve el evento y, si es una serie, las ocurrencias separadas de ella
+func (s *eventService) GetEventSeries(id uint) ([]models.Event, error) {
+	if id == 0 {
+		return nil, errors.New("invalid event ID")
+	}
+	return s.eventRepo.GetSeries(id)
+}
+
 func (s *eventService) GetAllEvents() ([]models.Event, error) {
 	return s.eventRepo.GetAll()
 }
@@ -91,21 +318,83 @@ func (s *eventService) GetEventsByDate(date string) ([]models.Event, error) {
 }
 
 func (s *eventService) UpdateEvent(id uint, event *models.Event) error {
+	if _, err := s.authorizeWrite(id, event); err != nil {
+		return err
+	}
 	// Delegar al servicio específico de actualización
-	return s.updateService.UpdateEvent(id, event)
+	return s.rescheduled(s.updateService.UpdateEvent(id, event))
+}
+
+func (s *eventService) UpdateOccurrence(id uint, occurrenceDate time.Time, scope string, event *models.Event) error {
+	existing, err := s.authorizeWrite(id, event)
+	if err != nil {
+		return err
+	}
+	if scope != models.ScopeAll && existing.IsRecurring() && event.CalendarID != 0 && event.CalendarID != existing.CalendarID {
+		return errors.New("a series moves to another calendar only with scope all")
+	}
+	if scope != models.ScopeAll && existing.IsRecurring() && event.ClearRecurrence {
+		return errors.New("a series stops repeating only with scope all")
+	}
+	if scope == models.ScopeAll {
+		// Delegar al servicio específico de actualización
+		return s.rescheduled(s.updateService.UpdateOccurrence(id, occurrenceDate, scope, event))
+	}
+	// Separar una ocurrencia crea un evento y modifica la serie: si la serie cambió mientras
+	// tanto (409) no queda el evento nuevo, y los cambios se avisan recién al confirmar
+	return s.Transaction(func(service EventService) error {
+		return service.(*eventService).updateService.UpdateOccurrence(id, occurrenceDate, scope, event)
+	})
 }
 
 func (s *eventService) DeleteEvent(id uint) error {
+	if _, err := s.authorizeWrite(id, nil); err != nil {
+		return err
+	}
+	// Delegar al servicio específico de eliminación
+	return s.rescheduled(s.deletionService.DeleteEvent(id))
+}
+
+func (s *eventService) DeleteOccurrence(id uint, occurrenceDate time.Time, scope string, version int) error {
+	if _, err := s.authorizeWrite(id, nil); err != nil {
+		return err
+	}
 	// Delegar al servicio específico de eliminación
-	return s.deletionService.DeleteEvent(id)
+	return s.rescheduled(s.deletionService.DeleteOccurrence(id, occurrenceDate, scope, version))
+}
+
+// ImportEvents importa los VEVENT y recalcula los recordatorios si cambió algún evento
+func (s *eventService) ImportEvents(events []ical.Event, email string) *ImportReport {
+	report := s.importService.ImportEvents(events, email)
+	if report.Created > 0 || report.Updated > 0 {
+		s.rescheduled(nil)
+	}
+	return report
+}
+
+// ImportRows crea los eventos de las filas válidas de un CSV y recalcula los recordatorios
+func (s *eventService) ImportRows(rows []ImportRow, dryRun bool) *ImportReport {
+	report := s.importService.ImportRows(rows, dryRun)
+	if report.Created > 0 {
+		s.rescheduled(nil)
+	}
+	return report
+}
+
+// rescheduled avisa al motor de recordatorios cuando una escritura tuvo éxito
+func (s *eventService) rescheduled(err error) error {
+	if err == nil && s.reminders != nil {
+		s.reminders.Reschedule()
+	}
+	return err
 }
 
 func (s *eventService) GetTodayEvents() ([]models.Event, error) {
 	return s.eventRepo.GetTodayEvents()
 }
 
-func (s *eventService) GetUpcomingEvents() ([]models.Event, error) {
-	return s.eventRepo.GetUpcomingEvents()
+func (s *eventService) GetUpcomingEvents(limit int) ([]models.Event, error) {
+	return s.eventRepo.GetUpcomingEvents(limit)
 }
 
 func (s *eventService) GetEventsForDateRange(startDate, endDate string) ([]models.Event, error) {
@@ -121,36 +410,89 @@ func (s *eventService) GetEventsForDateRange(startDate, endDate string) ([]model
 	return s.eventRepo.GetEventsForDateRange(startDate, endDate)
 }
 
-func (s *eventService) SearchEvents(query string) ([]models.Event, error) {
-	if query == "" {
-		return nil, errors.New("search query is required")
+// SearchEvents busca en el título, la descripción, la ubicación y la categoría; devuelve los
+// eventos de mayor a menor relevancia con los fragmentos que coinciden resaltados
+func (s *eventService) SearchEvents(query string, limit int) ([]SearchResult, error) {
+	q, err := search.Parse(query)
+	if err != nil {
+		return nil, err
+	}
+
+	hits, err := s.eventRepo.Search(q, limit)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]SearchResult, len(hits))
+	for i, hit := range hits {
+		results[i] = SearchResult{
+			Event: hit.Event,
+			Score: hit.Score,
+			Highlights: search.Highlights(q, search.Fields{
+				Title:       hit.Event.Title,
+				Description: hit.Event.Description,
+				Location:    hit.Event.Location,
+				Category:    hit.Event.Category,
+			}, snippetWords),
+		}
 	}
-	return s.eventRepo.SearchEvents(query)
+	return results, nil
 }
 
 func (s *eventService) GetEventStats() (map[string]interface{}, error) {
 	return s.eventRepo.GetEventStats()
 }
 
-// GetEvents maneja la lógica de consulta basada en query parameters
-func (s *eventService) GetEvents(queryReq interface{}) ([]models.Event, error) {
+// GetEvents devuelve una página de la consulta, con todos los filtros de los query parameters
+// combinados en una sola consulta
+func (s *eventService) GetEvents(queryReq interface{}) (*EventPage, error) {
 	// Type assertion para obtener el DTO
 	req, ok := queryReq.(*dto.GetEventsQueryRequest)
 	if !ok {
 		return nil, errors.New("invalid query request type")
 	}
 
-	// Lógica de decisión basada en los campos del DTO
-	if req.Search != "" {
-		return s.eventRepo.SearchEvents(req.Search)
+	query := eventQuery(req)
+	query.After = req.After
+	query.Limit = req.Limit
+
+	events, more, err := s.eventRepo.Find(query)
+	if err != nil {
+		return nil, err
 	}
-	if req.Date != "" {
-		return s.eventRepo.GetByDate(req.Date)
+	page := &EventPage{Events: events}
+	if more && len(events) > 0 {
+		page.NextCursor = models.NewEventCursor(&events[len(events)-1], query.Sort, query.Desc).Encode()
 	}
-	if req.StartDate != "" && req.EndDate != "" {
-		return s.eventRepo.GetEventsForDateRange(req.StartDate, req.EndDate)
+	return page, nil
+}
+
+// StreamEvents recorre todos los resultados de la consulta, sin paginar, ordenados por ID
+func (s *eventService) StreamEvents(queryReq interface{}, fn func(batch []models.Event) error) error {
+	req, ok := queryReq.(*dto.GetEventsQueryRequest)
+	if !ok {
+		return errors.New("invalid query request type")
 	}
-	
-	// Por defecto, obtener todos los eventos
-	return s.eventRepo.GetAll()
+	return s.eventRepo.Stream(eventQuery(req), streamBatchSize, fn)
+}
+
+// eventQuery traduce los filtros y el orden de los query parameters a una consulta del repositorio
+func eventQuery(req *dto.GetEventsQueryRequest) repositories.EventQuery {
+	query := repositories.EventQuery{
+		Search:       req.SearchQuery,
+		StartDate:    req.StartDate,
+		EndDate:      req.EndDate,
+		Categories:   req.Categories,
+		Priorities:   req.Priorities,
+		Colors:       req.Colors,
+		IsAllDay:     req.IsAllDay,
+		HasReminders: req.HasReminders,
+		Location:     req.Location,
+		Sort:         req.Sort,
+		Desc:         req.Desc(),
+	}
+	// Un día es un rango de un solo día
+	if req.Date != "" {
+		query.StartDate, query.EndDate = req.Date, req.Date
+	}
+	return query
 }
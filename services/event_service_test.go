@@ -0,0 +1,268 @@
+package services
+
+import (
+	"calendar-backend/handlers/dto"
+	"calendar-backend/models"
+	"calendar-backend/repositories"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUpcomingEventsExpandSeriesStartedInThePast(t *testing.T) {
+	db := newTestDB(t)
+	eventRepo := newTestEventRepository(t, db)
+	service := NewEventService(eventRepo, repositories.NewCalendarRepository(db), nil, nil)
+	user := createTestUser(t, db, "ana@example.com")
+
+	now := time.Now().UTC()
+	tomorrow := time.Date(now.Year(), now.Month(), now.Day()+1, 9, 0, 0, 0, time.UTC)
+	weekly := newTestEvent(user.ID, "Weekly", tomorrow.AddDate(0, 0, -7*104), "FREQ=WEEKLY")
+	yearly := newTestEvent(user.ID, "Birthday", tomorrow.AddDate(-3, 2, 0), "FREQ=YEARLY")
+	dentist := newTestEvent(user.ID, "Dentist", tomorrow.Add(time.Hour), "")
+	ended := newTestEvent(user.ID, "Last year", tomorrow.AddDate(-1, 0, 0), "")
+	for _, event := range []*models.Event{weekly, yearly, dentist, ended} {
+		if err := eventRepo.Create(event); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	events, err := service.GetUpcomingEvents(20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	count := map[string]int{}
+	for i, event := range events {
+		count[event.Title]++
+		if i > 0 && event.StartsAt.Before(events[i-1].StartsAt) {
+			t.Errorf("%q starts before the previous event", event.Title)
+		}
+		if !event.EndsAt.After(now.Add(-24 * time.Hour)) {
+			t.Errorf("%q ended at %s", event.Title, event.EndsAt)
+		}
+	}
+	if count["Weekly"] < 8 || count["Birthday"] != 1 || count["Dentist"] != 1 || count["Last year"] != 0 {
+		t.Errorf("upcoming events %v, want the next weekly occurrences, the next birthday and the dentist", count)
+	}
+	if events[0].Title != "Weekly" || !events[0].StartsAt.Equal(tomorrow) {
+		t.Errorf("first upcoming event %q at %s, want the weekly occurrence of tomorrow", events[0].Title, events[0].StartsAt)
+	}
+}
+
+// pageAll recorre todas las páginas de la consulta siguiendo el cursor de cada una
+func pageAll(t *testing.T, service EventService, query dto.GetEventsQueryRequest) []models.Event {
+	t.Helper()
+	var events []models.Event
+	for pages := 0; ; pages++ {
+		req := query
+		if err := req.ValidateFilters(); err != nil {
+			t.Fatal(err)
+		}
+		if err := req.ValidatePage(); err != nil {
+			t.Fatalf("page %d: %v", pages, err)
+		}
+		page, err := service.GetEvents(&req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page.Events) > req.Limit {
+			t.Fatalf("page %d has %d events, limit %d", pages, len(page.Events), req.Limit)
+		}
+		if pages > 50 {
+			t.Fatalf("the cursor did not reach the last page after %d pages", pages)
+		}
+		events = append(events, page.Events...)
+		if page.NextCursor == "" {
+			return events
+		}
+		query.Cursor = page.NextCursor
+	}
+}
+
+// positions describe el orden de los eventos como ID@inicio
+func positions(events []models.Event) []string {
+	result := make([]string, len(events))
+	for i, event := range events {
+		result[i] = fmt.Sprintf("%d@%s", event.ID, event.StartsAt.Format("01-02T15:04"))
+	}
+	return result
+}
+
+func TestGetEventsPagesEverySortWithCursor(t *testing.T) {
+	db := newTestDB(t)
+	user := createTestUser(t, db, "ana@example.com")
+	eventRepo := newTestEventRepository(t, db).ForOwner(user.ID)
+	service := NewEventService(newTestEventRepository(t, db), repositories.NewCalendarRepository(db), nil, nil).ForUser(user.ID)
+
+	// Títulos, prioridades e inicios repetidos, para que desempaten el inicio y el ID
+	start := time.Date(2030, 7, 1, 9, 0, 0, 0, time.UTC)
+	fields := []struct {
+		title, priority string
+		start           time.Time
+		rrule           string
+	}{
+		{"Reunión", "high", start, ""},
+		{"reunión", "high", start, ""},
+		{"Almuerzo", "low", start.Add(3 * time.Hour), ""},
+		{"Dentista", "medium", start.AddDate(0, 0, 1), ""},
+		{"Clase", "medium", start.Add(-time.Hour), "FREQ=DAILY;COUNT=3"},
+		{"Banco", "", start.AddDate(0, 0, 2), ""},
+		{"Zumba", "high", start.AddDate(0, 0, 1), ""},
+	}
+	for _, f := range fields {
+		event := newTestEvent(user.ID, f.title, f.start, f.rrule)
+		event.Priority = f.priority
+		if err := eventRepo.Create(event); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name  string
+		query dto.GetEventsQueryRequest
+	}{
+		{"events", dto.GetEventsQueryRequest{}},
+		{"occurrences of a range", dto.GetEventsQueryRequest{StartDate: "2030-07-01", EndDate: "2030-07-03"}},
+	}
+	for _, tt := range tests {
+		// El orden completo, en una sola página
+		all := tt.query
+		all.Limit = dto.MaxEventsPageSize
+		for _, sort := range models.EventSortFields {
+			for _, order := range []string{"asc", "desc"} {
+				all.Sort, all.Order = sort, order
+				want := pageAll(t, service, all)
+				if len(want) < len(fields) {
+					t.Fatalf("%s: %d events in one page, want at least %d", tt.name, len(want), len(fields))
+				}
+				expected := append([]models.Event(nil), want...)
+				models.SortEvents(expected, sort, order == "desc")
+				if got, exp := positions(want), positions(expected); strings.Join(got, " ") != strings.Join(exp, " ") {
+					t.Errorf("%s sorted by %s %s: %v, want %v", tt.name, sort, order, got, exp)
+				}
+
+				// Las páginas de un evento, con un cursor en cada posición, dan el mismo orden sin repetir ni saltear
+				paged := tt.query
+				paged.Sort, paged.Order, paged.Limit = sort, order, 1
+				if got, exp := positions(pageAll(t, service, paged)), positions(want); strings.Join(got, " ") != strings.Join(exp, " ") {
+					t.Errorf("%s paged by %s %s: %v, want %v", tt.name, sort, order, got, exp)
+				}
+			}
+		}
+	}
+
+	// Un cursor sirve solo para el orden en el que se generó
+	req := dto.GetEventsQueryRequest{Sort: models.EventSortTitle, Cursor: models.NewEventCursor(&models.Event{ID: 1}, models.EventSortDate, false).Encode()}
+	if err := req.ValidatePage(); err == nil {
+		t.Error("accepted a date cursor for a title sort")
+	}
+}
+
+func TestGetEventsCombinesFilters(t *testing.T) {
+	db := newTestDB(t)
+	user := createTestUser(t, db, "ana@example.com")
+	eventRepo := newTestEventRepository(t, db).ForOwner(user.ID)
+	service := NewEventService(newTestEventRepository(t, db), repositories.NewCalendarRepository(db), nil, nil).ForUser(user.ID)
+
+	start := time.Date(2030, 7, 1, 9, 0, 0, 0, time.UTC)
+	fields := []struct {
+		title, category, priority, color, location string
+		allDay, reminders                          bool
+	}{
+		{"Planning", "work", "high", "#FF3B30", "Sala Norte", false, true},
+		{"Retro", "work", "high", "#FF3B30", "Sala Sur", false, false},
+		{"Offsite", "work", "low", "#ff3b30", "Sala Norte", true, true},
+		{"Cumpleaños", "family", "high", "#FF9500", "Casa", true, true},
+		{"Dentista", "health", "medium", "#34C759", "Centro", false, true},
+	}
+	for i, f := range fields {
+		event := newTestEvent(user.ID, f.title, start.AddDate(0, 0, i), "")
+		event.Category, event.Priority, event.Color, event.Location, event.IsAllDay = f.category, f.priority, f.color, f.location, f.allDay
+		if f.reminders {
+			event.Reminders = []models.Reminder{{OffsetMinutes: 60}}
+		}
+		if err := eventRepo.Create(event); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	yes, no := true, false
+	tests := []struct {
+		name  string
+		query dto.GetEventsQueryRequest
+		want  []string
+	}{
+		{"category and priority", dto.GetEventsQueryRequest{Category: "Work", Priority: "high"}, []string{"Planning", "Retro"}},
+		{"several categories", dto.GetEventsQueryRequest{Category: "work,family", Priority: "high,low"}, []string{"Planning", "Retro", "Offsite", "Cumpleaños"}},
+		{"color ignores case", dto.GetEventsQueryRequest{Color: "#ff3b30", IsAllDay: &yes}, []string{"Offsite"}},
+		{"location and reminders", dto.GetEventsQueryRequest{Location: "norte", HasReminders: &yes}, []string{"Planning", "Offsite"}},
+		{"without reminders", dto.GetEventsQueryRequest{Category: "work", HasReminders: &no}, []string{"Retro"}},
+		{"filters and range", dto.GetEventsQueryRequest{Priority: "high", IsAllDay: &no, StartDate: "2030-07-02", EndDate: "2030-07-10"}, []string{"Retro"}},
+		{"no match", dto.GetEventsQueryRequest{Category: "family", Priority: "low"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, event := range pageAll(t, service, tt.query) {
+				got = append(got, event.Title)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("events %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchEventsRanksAndHighlights(t *testing.T) {
+	db := newTestDB(t)
+	user := createTestUser(t, db, "ana@example.com")
+	eventRepo := newTestEventRepository(t, db).ForOwner(user.ID)
+	service := NewEventService(newTestEventRepository(t, db), repositories.NewCalendarRepository(db), nil, nil).ForUser(user.ID)
+
+	start := time.Date(2030, 7, 1, 9, 0, 0, 0, time.UTC)
+	fields := []struct{ title, description, location string }{
+		{"Almuerzo", "Hablar del presupuesto anual", "Centro"},
+		{"Presupuesto anual", "Revisar gastos", "Sala Norte"},
+		{"Dentista", "Control", "Consultorio presupuesto"},
+		{"Cine", "Estreno", "Shopping"},
+	}
+	for i, f := range fields {
+		event := newTestEvent(user.ID, f.title, start.AddDate(0, 0, i), "")
+		event.Description, event.Location = f.description, f.location
+		if err := eventRepo.Create(event); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		query      string
+		want       []string // De mayor a menor relevancia
+		highlights map[string]string
+	}{
+		{"presupuesto", []string{"Presupuesto anual", "Dentista", "Almuerzo"}, map[string]string{"title": "<mark>Presupuesto</mark> anual"}},
+		{"anual gastos", []string{"Presupuesto anual"}, map[string]string{"title": "Presupuesto <mark>anual</mark>", "description": "Revisar <mark>gastos</mark>"}},
+		{`"sala norte"`, []string{"Presupuesto anual"}, map[string]string{"location": "<mark>Sala</mark> <mark>Norte</mark>"}},
+		{"teatro", nil, nil},
+	}
+	for _, tt := range tests {
+		results, err := service.SearchEvents(tt.query, 10)
+		if err != nil {
+			t.Fatalf("search %q: %v", tt.query, err)
+		}
+		var titles []string
+		for i, result := range results {
+			titles = append(titles, result.Title)
+			if i > 0 && result.Score > results[i-1].Score {
+				t.Errorf("search %q: %q scores %v, more than the previous result", tt.query, result.Title, result.Score)
+			}
+		}
+		if strings.Join(titles, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("search %q: %v, want %v", tt.query, titles, tt.want)
+			continue
+		}
+		if len(results) > 0 && fmt.Sprint(results[0].Highlights) != fmt.Sprint(tt.highlights) {
+			t.Errorf("search %q: highlights %v, want %v", tt.query, results[0].Highlights, tt.highlights)
+		}
+	}
+}
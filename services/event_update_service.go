@@ -1,24 +1,32 @@
 package services
 
 import (
+	"calendar-backend/eventbus"
 	"calendar-backend/models"
 	"calendar-backend/repositories"
 	"errors"
+	"log"
 	"time"
 )
 
 // EventUpdateService maneja la lógica específica de actualización de eventos
 type EventUpdateService struct {
-	eventRepo repositories.EventRepository
+	eventRepo    repositories.EventRepository
+	calendarRepo repositories.CalendarRepository
+	changes      ChangePublisher
 }
 
-func NewEventUpdateService(eventRepo repositories.EventRepository) *EventUpdateService {
+func NewEventUpdateService(eventRepo repositories.EventRepository, calendarRepo repositories.CalendarRepository, changes ChangePublisher) *EventUpdateService {
 	return &EventUpdateService{
-		eventRepo: eventRepo,
+		eventRepo:    eventRepo,
+		calendarRepo: calendarRepo,
+		changes:      changes,
 	}
 }
 
-// UpdateEvent implementa la lógica de negocio para actualizar un evento
+// UpdateEvent implementa la lógica de negocio para actualizar un evento. Si event.Version no
+// es 0 es la versión que modificó el cliente: si el evento cambió desde entonces, devuelve un
+// VersionConflictError con la copia guardada.
 func (s *EventUpdateService) UpdateEvent(id uint, event *models.Event) error {
 	// 1. Validar ID
 	if id == 0 {
@@ -30,6 +38,9 @@ func (s *EventUpdateService) UpdateEvent(id uint, event *models.Event) error {
 	if err != nil {
 		return errors.New("event not found")
 	}
+	if event.Version != 0 && event.Version != existingEvent.Version {
+		return &VersionConflictError{Current: existingEvent}
+	}
 
 	// 3. Aplicar validaciones de negocio
 	if err := s.validateUpdate(event); err != nil {
@@ -37,10 +48,205 @@ func (s *EventUpdateService) UpdateEvent(id uint, event *models.Event) error {
 	}
 
 	// 4. Aplicar reglas de negocio
-	s.applyUpdateRules(existingEvent, event)
+	calendarID := existingEvent.CalendarID
+	wasRecurring := existingEvent.IsRecurring()
+	remindersChanged := s.applyUpdateRules(existingEvent, event)
+	if err := s.validateSchedule(existingEvent); err != nil {
+		return err
+	}
+
+	// 5. Delegar al repositorio, que rechaza la escritura si el evento cambió desde que se leyó
+	if err := s.eventRepo.Update(id, existingEvent); err != nil {
+		return versionConflict(s.eventRepo, id, err)
+	}
+	if remindersChanged {
+		if err := s.eventRepo.ReplaceReminders(existingEvent); err != nil {
+			return err
+		}
+	}
+	// Una serie que deja de repetirse ya no tiene ocurrencias separadas
+	if wasRecurring && !existingEvent.IsRecurring() {
+		if err := s.deleteOccurrences(id); err != nil {
+			return err
+		}
+	}
+	// Las ocurrencias separadas de la serie la siguen al nuevo calendario
+	if existingEvent.CalendarID != calendarID && existingEvent.IsRecurring() {
+		if err := s.eventRepo.MoveOccurrences(id, existingEvent.CalendarID); err != nil {
+			return err
+		}
+	}
+
+	// 6. Avisar la modificación en tiempo real, y la baja a quienes dejan de ver el evento
+	publishChange(s.changes, eventbus.Updated, existingEvent)
+	if existingEvent.CalendarID != calendarID {
+		s.publishRemovals(calendarID, existingEvent)
+	}
+	return nil
+}
+
+// deleteOccurrences elimina las ocurrencias separadas de la serie y avisa sus bajas
+func (s *EventUpdateService) deleteOccurrences(seriesID uint) error {
+	series, err := s.eventRepo.GetSeries(seriesID)
+	if err != nil {
+		return err
+	}
+	for i := range series {
+		if series[i].ID == seriesID {
+			continue
+		}
+		if err := s.eventRepo.Delete(series[i].ID); err != nil {
+			return err
+		}
+		publishChange(s.changes, eventbus.Deleted, &series[i])
+	}
+	return nil
+}
+
+// publishRemovals avisa la baja de la serie (o evento simple) que cambió de calendario a los
+// usuarios que veían el calendario anterior y no ven el nuevo. Un error solo se registra: las
+// apps igual reciben la baja al sincronizar.
+func (s *EventUpdateService) publishRemovals(previousCalendarID uint, event *models.Event) {
+	if s.changes == nil || s.calendarRepo == nil {
+		return
+	}
+	lost, err := lostViewers(s.calendarRepo, previousCalendarID, event.CalendarID)
+	if err != nil || len(lost) == 0 {
+		if err != nil {
+			log.Printf("❌ Error loading users of calendar %d: %v", previousCalendarID, err)
+		}
+		return
+	}
+	series, err := s.eventRepo.GetSeries(event.ID)
+	if err != nil {
+		log.Printf("❌ Error loading series of event %d: %v", event.ID, err)
+		return
+	}
+	for i := range series {
+		publishChangeTo(s.changes, eventbus.Deleted, &series[i], lost)
+	}
+}
+
+// UpdateOccurrence actualiza una serie recurrente según el alcance pedido:
+// solo la ocurrencia indicada, esa y las siguientes, o toda la serie
+func (s *EventUpdateService) UpdateOccurrence(id uint, occurrenceDate time.Time, scope string, event *models.Event) error {
+	if scope == models.ScopeAll {
+		return s.UpdateEvent(id, event)
+	}
+
+	if id == 0 {
+		return errors.New("invalid event ID")
+	}
+
+	master, err := s.eventRepo.GetByID(id)
+	if err != nil {
+		return errors.New("event not found")
+	}
+	if event.Version != 0 && event.Version != master.Version {
+		return &VersionConflictError{Current: master}
+	}
+	if !master.IsRecurring() {
+		return s.UpdateEvent(id, event)
+	}
+	occurrence, ok := master.OccurrenceOn(occurrenceDate)
+	if !ok {
+		return errors.New("event has no occurrence on the given date")
+	}
+
+	if err := s.validateUpdate(event); err != nil {
+		return err
+	}
+
+	switch scope {
+	case models.ScopeThis:
+		return s.updateSingleOccurrence(master, occurrence, occurrenceDate, event)
+	case models.ScopeFollowing:
+		if master.StartsOn(occurrenceDate) {
+			return s.UpdateEvent(id, event)
+		}
+		return s.splitSeries(master, occurrence, occurrenceDate, event)
+	}
+	return errors.New("invalid scope, must be: this, following, or all")
+}
+
+// updateSingleOccurrence separa la ocurrencia en un evento propio y la excluye de la serie
+func (s *EventUpdateService) updateSingleOccurrence(master *models.Event, occurrence models.Event, occurrenceDate time.Time, event *models.Event) error {
+	override := occurrence
+	override.ID = 0
+	override.RRule = ""
+	override.ExDates = ""
+	override.RecurrenceID = &master.ID
+	override.OriginalDate = &occurrenceDate
+	override.CreatedAt = time.Time{}
+	override.UpdatedAt = time.Time{}
+	// El client_id y la versión son de la serie: la ocurrencia es un evento nuevo
+	override.ClientID = ""
+	override.Version = 0
+	override.Reminders = models.CopyReminders(occurrence.Reminders)
+	s.applyUpdateRules(&override, event)
+	override.RRule = ""
+	if err := s.validateSchedule(&override); err != nil {
+		return err
+	}
+
+	if err := s.eventRepo.Create(&override); err != nil {
+		return err
+	}
+	publishChange(s.changes, eventbus.Created, &override)
+
+	master.AddExDate(occurrenceDate)
+	return s.updateMaster(master)
+}
+
+// splitSeries corta la serie el día anterior a la ocurrencia y crea una nueva serie
+// desde esa fecha con los cambios aplicados
+func (s *EventUpdateService) splitSeries(master *models.Event, occurrence models.Event, occurrenceDate time.Time, event *models.Event) error {
+	rule, err := models.ParseRRule(master.RRule)
+	if err != nil {
+		return err
+	}
+
+	followingRule := *rule
+	if rule.Count > 0 {
+		firstDay := models.CalendarDate(master.StartsAt, master.Zone())
+		previous := len(rule.Dates(firstDay, firstDay, occurrenceDate.AddDate(0, 0, -1)))
+		followingRule.Count = rule.Count - previous
+	}
+
+	following := occurrence
+	following.ID = 0
+	following.RRule = followingRule.String()
+	following.CreatedAt = time.Time{}
+	following.UpdatedAt = time.Time{}
+	following.ClientID = ""
+	following.Version = 0
+	following.Reminders = models.CopyReminders(occurrence.Reminders)
+	// La serie nueva no corresponde al VEVENT importado, que sigue siendo la original
+	following.ExternalUID = ""
+	s.applyUpdateRules(&following, event)
+	if err := s.validateSchedule(&following); err != nil {
+		return err
+	}
+
+	if err := s.eventRepo.Create(&following); err != nil {
+		return err
+	}
+	publishChange(s.changes, eventbus.Created, &following)
 
-	// 5. Delegar al repositorio
-	return s.eventRepo.Update(id, existingEvent)
+	until := occurrenceDate.AddDate(0, 0, -1)
+	rule.Count = 0
+	rule.Until = &until
+	master.RRule = rule.String()
+	return s.updateMaster(master)
+}
+
+// updateMaster guarda la serie después de separarle ocurrencias
+func (s *EventUpdateService) updateMaster(master *models.Event) error {
+	if err := s.eventRepo.Update(master.ID, master); err != nil {
+		return versionConflict(s.eventRepo, master.ID, err)
+	}
+	publishChange(s.changes, eventbus.Updated, master)
+	return nil
 }
 
 // validateUpdate valida las reglas de negocio para una actualización
@@ -57,6 +263,9 @@ func (s *EventUpdateService) validateUpdate(event *models.Event) error {
 	if !event.Date.IsZero() && event.Date.Before(time.Now().Truncate(24*time.Hour)) {
 		return errors.New("cannot update events to past dates")
 	}
+	if !event.StartsAt.IsZero() && event.StartsAt.Before(time.Now().Truncate(24*time.Hour)) {
+		return errors.New("cannot update events to past dates")
+	}
 
 	// Validar email si se proporciona
 	if event.Email != "" && len(event.Email) < 5 {
@@ -81,8 +290,28 @@ func (s *EventUpdateService) validateUpdate(event *models.Event) error {
 	return nil
 }
 
-// applyUpdateRules aplica reglas de negocio para la actualización
-func (s *EventUpdateService) applyUpdateRules(existingEvent, newEvent *models.Event) {
+// validateSchedule valida que el evento resultante no termine antes de empezar
+func (s *EventUpdateService) validateSchedule(event *models.Event) error {
+	if event.EndsAt.Before(event.StartsAt) {
+		return errors.New("event cannot end before it starts")
+	}
+	return nil
+}
+
+// applyUpdateRules aplica reglas de negocio para la actualización.
+// Devuelve si cambiaron los recordatorios.
+func (s *EventUpdateService) applyUpdateRules(existingEvent, newEvent *models.Event) bool {
+	// Conservar la duración original al mover el evento
+	existingEvent.ApplyLegacySchedule()
+	duration := existingEvent.Duration()
+
+	// Cambiar de zona conserva el instante; fecha/hora legacy pasan a expresarse en la nueva zona
+	if newEvent.TimeZone != "" {
+		existingEvent.TimeZone = newEvent.TimeZone
+		existingEvent.SyncLegacyFields()
+	}
+	loc := existingEvent.Zone()
+
 	// Actualizar solo los campos proporcionados
 	if newEvent.Title != "" {
 		existingEvent.Title = newEvent.Title
@@ -114,17 +343,58 @@ func (s *EventUpdateService) applyUpdateRules(existingEvent, newEvent *models.Ev
 	if newEvent.Category != "" {
 		existingEvent.Category = newEvent.Category
 	}
+	if newEvent.ClearRecurrence {
+		existingEvent.RRule = ""
+		existingEvent.ExDates = ""
+	}
+	if newEvent.RRule != "" {
+		existingEvent.RRule = newEvent.RRule
+	}
+	if newEvent.ExDates != "" {
+		existingEvent.ExDates = newEvent.ExDates
+	}
+	if newEvent.CalendarID != 0 {
+		existingEvent.CalendarID = newEvent.CalendarID
+	}
+
+	// Recalcular inicio y fin: los instantes explícitos tienen prioridad sobre fecha/hora legacy
+	if !newEvent.StartsAt.IsZero() {
+		existingEvent.StartsAt = newEvent.StartsAt
+	} else if !newEvent.Date.IsZero() || newEvent.Time != "" {
+		existingEvent.StartsAt = models.ComposeInstant(existingEvent.Date, existingEvent.Time, loc)
+	}
+	if !newEvent.EndsAt.IsZero() {
+		existingEvent.EndsAt = newEvent.EndsAt
+	} else {
+		existingEvent.EndsAt = existingEvent.StartsAt.Add(duration)
+	}
 
 	// Aplicar reglas especiales
 	if newEvent.IsAllDay {
 		existingEvent.IsAllDay = true
 		existingEvent.Time = "" // Limpiar hora si es evento de todo el día
+		existingEvent.StartsAt = models.StartOfDay(models.CalendarDate(existingEvent.StartsAt, loc), loc)
+		if !existingEvent.EndsAt.After(existingEvent.StartsAt) {
+			existingEvent.EndsAt = models.DefaultEndsAt(existingEvent.StartsAt, true, loc)
+		} else if endDay := models.CalendarDate(existingEvent.EndsAt, loc); !models.StartOfDay(endDay, loc).Equal(existingEvent.EndsAt) {
+			existingEvent.EndsAt = models.StartOfDay(endDay.AddDate(0, 0, 1), loc)
+		}
 	}
+	existingEvent.SyncLegacyFields()
 
 	// Aplicar colores por categoría si se cambia la categoría
 	if newEvent.Category != "" && newEvent.Category != existingEvent.Category {
 		s.applyCategoryColors(existingEvent)
 	}
+
+	// Reemplazar recordatorios (nil = no se enviaron) y alinear los flags legacy con el nuevo inicio
+	remindersChanged := newEvent.Reminders != nil
+	if remindersChanged {
+		existingEvent.Reminders = newEvent.Reminders
+	}
+	existingEvent.SyncReminderFlags()
+
+	return remindersChanged
 }
 
 // applyCategoryColors aplica colores automáticos por categoría
@@ -0,0 +1,175 @@
+package services
+
+import (
+	"calendar-backend/eventbus"
+	"calendar-backend/models"
+	"calendar-backend/repositories"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestUpdateOccurrenceFollowingInZoneNearMidnight(t *testing.T) {
+	db := newTestDB(t)
+	eventRepo := newTestEventRepository(t, db)
+	service := NewEventService(eventRepo, repositories.NewCalendarRepository(db), nil, nil)
+	user := createTestUser(t, db, "ana@example.com")
+
+	// 22:30 en Buenos Aires es 01:30 UTC del día siguiente
+	loc := mustLoadLocation(t, "America/Argentina/Buenos_Aires")
+	series := newTestEvent(user.ID, "Guardia", time.Date(2027, 1, 10, 22, 30, 0, 0, loc), "FREQ=DAILY;COUNT=5")
+	if err := service.CreateEvent(series); err != nil {
+		t.Fatal(err)
+	}
+
+	// La tercera ocurrencia y las siguientes pasan a una serie nueva con las 3 restantes
+	if err := service.UpdateOccurrence(series.ID, day(t, "2027-01-12"), models.ScopeFollowing, &models.Event{Title: "Guardia nueva"}); err != nil {
+		t.Fatal(err)
+	}
+	events, err := service.GetAllEvents()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("got %d events after the split, want 2", len(events))
+	}
+	for _, event := range events {
+		want := "FREQ=DAILY;UNTIL=20270111"
+		if event.ID != series.ID {
+			want = "FREQ=DAILY;COUNT=3"
+		}
+		if event.RRule != want {
+			t.Errorf("event %q rrule = %q, want %q", event.Title, event.RRule, want)
+		}
+	}
+
+	// "Esta y las siguientes" desde la primera fecha local modifica la serie entera
+	if err := service.UpdateOccurrence(series.ID, day(t, "2027-01-10"), models.ScopeFollowing, &models.Event{Title: "Guardia vieja"}); err != nil {
+		t.Fatal(err)
+	}
+	master, err := service.GetEventByID(series.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if master.Title != "Guardia vieja" {
+		t.Errorf("series title = %q, want the whole series updated", master.Title)
+	}
+	if count, _ := eventRepo.GetAll(); len(count) != 2 {
+		t.Errorf("got %d events, want no new split", len(count))
+	}
+}
+
+func TestDeleteOccurrenceFollowingFromFirstLocalDay(t *testing.T) {
+	db := newTestDB(t)
+	service := NewEventService(newTestEventRepository(t, db), repositories.NewCalendarRepository(db), nil, nil)
+	user := createTestUser(t, db, "ana@example.com")
+
+	loc := mustLoadLocation(t, "America/Argentina/Buenos_Aires")
+	series := newTestEvent(user.ID, "Guardia", time.Date(2027, 1, 10, 22, 30, 0, 0, loc), "FREQ=DAILY")
+	if err := service.CreateEvent(series); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := service.DeleteOccurrence(series.ID, day(t, "2027-01-10"), models.ScopeFollowing, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.GetEventByID(series.ID); err == nil {
+		t.Error("series still exists after deleting it from its first occurrence")
+	}
+}
+
+func TestUpdateClearsRecurrence(t *testing.T) {
+	db := newTestDB(t)
+	eventRepo := newTestEventRepository(t, db)
+	changes := &recordedChanges{}
+	service := NewEventService(eventRepo, repositories.NewCalendarRepository(db), nil, changes)
+	user := createTestUser(t, db, "ana@example.com")
+	series := newTestEvent(user.ID, "Guardia", time.Date(2027, 1, 10, 9, 0, 0, 0, time.UTC), "FREQ=DAILY")
+	series.ExDates = "2027-01-11"
+	if err := service.CreateEvent(series); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.UpdateOccurrence(series.ID, day(t, "2027-01-12"), models.ScopeThis, &models.Event{Title: "Guardia movida"}); err != nil {
+		t.Fatal(err)
+	}
+
+	clear := &models.Event{ClearRecurrence: true}
+	if err := service.UpdateOccurrence(series.ID, day(t, "2027-01-13"), models.ScopeThis, clear); err == nil {
+		t.Error("cleared the recurrence of a single occurrence")
+	}
+	changes.take()
+	if err := service.UpdateEvent(series.ID, clear); err != nil {
+		t.Fatal(err)
+	}
+
+	events, err := eventRepo.GetAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].ID != series.ID {
+		t.Fatalf("got %d events, want only the former series", len(events))
+	}
+	if events[0].RRule != "" || events[0].ExDates != "" || events[0].Title != "Guardia" {
+		t.Errorf("event after clearing: rrule %q, exdates %q, title %q", events[0].RRule, events[0].ExDates, events[0].Title)
+	}
+	deleted := 0
+	for _, change := range changes.take() {
+		if change.Type == eventbus.Deleted {
+			deleted++
+		}
+	}
+	if deleted != 1 {
+		t.Errorf("published %d deletions, want the separated occurrence", deleted)
+	}
+}
+
+// staleUpdates simula que la serie cambió mientras se la editaba: toda escritura de Update
+// devuelve ErrStaleVersion, también dentro de una transacción
+type staleUpdates struct {
+	repositories.EventRepository
+}
+
+func (r staleUpdates) Update(id uint, event *models.Event) error {
+	return repositories.ErrStaleVersion
+}
+
+func (r staleUpdates) Transaction(fn func(repo repositories.EventRepository) error) error {
+	return r.EventRepository.Transaction(func(repo repositories.EventRepository) error {
+		return fn(staleUpdates{repo})
+	})
+}
+
+func TestUpdateOccurrenceConflictLeavesSeriesUntouched(t *testing.T) {
+	db := newTestDB(t)
+	eventRepo := newTestEventRepository(t, db)
+	user := createTestUser(t, db, "ana@example.com")
+	series := newTestEvent(user.ID, "Guardia", time.Date(2027, 1, 10, 9, 0, 0, 0, time.UTC), "FREQ=DAILY")
+	if err := eventRepo.Create(series); err != nil {
+		t.Fatal(err)
+	}
+
+	changes := &recordedChanges{}
+	service := NewEventService(staleUpdates{eventRepo}, repositories.NewCalendarRepository(db), nil, changes)
+	for _, scope := range []string{models.ScopeThis, models.ScopeFollowing} {
+		err := service.UpdateOccurrence(series.ID, day(t, "2027-01-12"), scope, &models.Event{Title: "Guardia nueva"})
+		var conflict *VersionConflictError
+		if !errors.As(err, &conflict) {
+			t.Fatalf("%s: got %v, want a VersionConflictError", scope, err)
+		}
+		if events, err := eventRepo.GetAll(); err != nil || len(events) != 1 {
+			t.Errorf("%s: %d events after the conflict (err %v), want only the series", scope, len(events), err)
+		}
+		if published := changes.take(); len(published) != 0 {
+			t.Errorf("%s: the conflict published %+v", scope, published)
+		}
+	}
+}
+
+func day(t *testing.T, value string) time.Time {
+	t.Helper()
+	d, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"calendar-backend/models"
+	"calendar-backend/repositories"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestUpdateRejectsStaleVersion(t *testing.T) {
+	f := newSharingFixture(t)
+	alice := f.events.ForUser(f.alice.ID)
+	read := f.event.Version
+
+	if err := alice.UpdateEvent(f.event.ID, &models.Event{Title: "Planning v2", Version: read}); err != nil {
+		t.Fatalf("update with the current version: %v", err)
+	}
+
+	// Otro cliente que leyó la misma versión no pisa el cambio
+	err := alice.UpdateEvent(f.event.ID, &models.Event{Title: "Planning v3", Version: read})
+	var conflict *VersionConflictError
+	if !errors.As(err, &conflict) {
+		t.Fatalf("update with a stale version: got %v, want a VersionConflictError", err)
+	}
+	if conflict.Current.Version != read+1 || conflict.Current.Title != "Planning v2" {
+		t.Errorf("conflict returned version %d %q, want %d %q", conflict.Current.Version, conflict.Current.Title, read+1, "Planning v2")
+	}
+
+	// Sin versión la escritura no se comprueba
+	if err := alice.UpdateEvent(f.event.ID, &models.Event{Title: "Planning v3"}); err != nil {
+		t.Fatalf("update without a version: %v", err)
+	}
+	event, err := f.eventRepo.GetByID(f.event.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.Title != "Planning v3" || event.Version != read+2 {
+		t.Errorf("stored %q at version %d, want %q at %d", event.Title, event.Version, "Planning v3", read+2)
+	}
+}
+
+func TestRepositoryRejectsWriteOverConcurrentChange(t *testing.T) {
+	f := newSharingFixture(t)
+	first, err := f.eventRepo.GetByID(f.event.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := f.eventRepo.GetByID(f.event.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Las dos copias pasaron la comprobación del servicio; la segunda escritura llega tarde
+	first.Title = "First"
+	if err := f.eventRepo.Update(first.ID, first); err != nil {
+		t.Fatalf("first write: %v", err)
+	}
+	second.Title = "Second"
+	if err := f.eventRepo.Update(second.ID, second); !errors.Is(err, repositories.ErrStaleVersion) {
+		t.Fatalf("second write: got %v, want ErrStaleVersion", err)
+	}
+	var conflict *VersionConflictError
+	if err := versionConflict(f.eventRepo, second.ID, repositories.ErrStaleVersion); !errors.As(err, &conflict) || conflict.Current.Title != "First" {
+		t.Errorf("conflict %v, want the copy of the first write", err)
+	}
+}
+
+func TestDeleteOccurrenceRejectsStaleVersion(t *testing.T) {
+	f := newSharingFixture(t)
+	alice := f.events.ForUser(f.alice.ID)
+	series := newTestEvent(f.alice.ID, "Standup", time.Now().Add(24*time.Hour).UTC().Truncate(time.Hour), "FREQ=DAILY")
+	if err := alice.CreateEvent(series); err != nil {
+		t.Fatal(err)
+	}
+	read := series.Version
+	if err := alice.UpdateEvent(series.ID, &models.Event{Title: "Daily standup"}); err != nil {
+		t.Fatal(err)
+	}
+
+	second := series.StartsAt.AddDate(0, 0, 1)
+	var conflict *VersionConflictError
+	if err := alice.DeleteOccurrence(series.ID, second, models.ScopeThis, read); !errors.As(err, &conflict) {
+		t.Fatalf("delete with a stale version: got %v, want a VersionConflictError", err)
+	}
+	event, err := f.eventRepo.GetByID(series.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !event.HasOccurrenceOn(second) {
+		t.Error("the rejected delete excluded the occurrence")
+	}
+
+	if err := alice.DeleteOccurrence(series.ID, second, models.ScopeThis, event.Version); err != nil {
+		t.Fatalf("delete with the current version: %v", err)
+	}
+	if event, err = f.eventRepo.GetByID(series.ID); err != nil || event.HasOccurrenceOn(second) {
+		t.Errorf("the occurrence is still in the series (err %v)", err)
+	}
+}
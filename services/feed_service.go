@@ -0,0 +1,109 @@
+package services
+
+import (
+	"calendar-backend/models"
+	"calendar-backend/repositories"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// FeedRefreshInterval es el intervalo de actualización sugerido a los clientes suscritos
+const FeedRefreshInterval = 15 * time.Minute
+
+var ErrFeedNotFound = errors.New("feed not found")
+
+// FeedService maneja las suscripciones webcal de cada usuario
+type FeedService struct {
+	feedRepo  repositories.FeedRepository
+	eventRepo repositories.EventRepository
+}
+
+func NewFeedService(feedRepo repositories.FeedRepository, eventRepo repositories.EventRepository) *FeedService {
+	return &FeedService{feedRepo: feedRepo, eventRepo: eventRepo}
+}
+
+// CreateFeed genera el token secreto de una nueva suscripción del usuario.
+// El token solo se devuelve aquí: en la base se guarda su hash
+func (s *FeedService) CreateFeed(ownerID uint, feed *models.Feed) (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+
+	feed.OwnerID = ownerID
+	feed.TokenHash = hashFeedToken(token)
+	feed.TokenHint = token[len(token)-4:]
+	if feed.Name == "" {
+		feed.Name = "Calendar"
+	}
+	if err := s.feedRepo.Create(feed); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// ListFeeds devuelve las suscripciones del usuario, incluidas las revocadas
+func (s *FeedService) ListFeeds(ownerID uint) ([]models.Feed, error) {
+	return s.feedRepo.GetByOwner(ownerID)
+}
+
+// RevokeFeed revoca una suscripción: su URL deja de responder
+func (s *FeedService) RevokeFeed(ownerID, id uint) error {
+	err := s.feedRepo.Revoke(ownerID, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrFeedNotFound
+	}
+	return err
+}
+
+// ResolveFeed devuelve la suscripción activa del token
+func (s *FeedService) ResolveFeed(token string) (*models.Feed, error) {
+	if token == "" {
+		return nil, ErrFeedNotFound
+	}
+	feed, err := s.feedRepo.GetByTokenHash(hashFeedToken(token))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrFeedNotFound
+	}
+	return feed, err
+}
+
+// FeedETag calcula el ETag del feed sin cargar sus eventos: cambia cuando cambian los
+// eventos del dueño o la suscripción, y cada año (período cubierto por las zonas horarias)
+func (s *FeedService) FeedETag(feed *models.Feed) (string, error) {
+	stamp, err := s.eventRepo.ForOwner(feed.OwnerID).ChangeStamp()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%d|%s|%s|%d|%s",
+		feed.ID, feed.UpdatedAt.UTC().Format(time.RFC3339Nano), stamp, time.Now().Year(), feed.Name)))
+	return `"` + hex.EncodeToString(sum[:16]) + `"`, nil
+}
+
+// FeedEvents devuelve los eventos del dueño que pasan los filtros de la suscripción
+// y registra la descarga
+func (s *FeedService) FeedEvents(feed *models.Feed) ([]models.Event, error) {
+	events, err := s.eventRepo.ForOwner(feed.OwnerID).GetFiltered(feed.CategoryList(), feed.PriorityList())
+	if err != nil {
+		return nil, err
+	}
+	if err := s.feedRepo.TouchLastUsed(feed.ID, time.Now()); err != nil {
+		log.Printf("Error updating feed %d last use: %v", feed.ID, err)
+	}
+	return events, nil
+}
+
+// hashFeedToken devuelve el hash SHA-256 (hex) con el que se guarda el token
+func hashFeedToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
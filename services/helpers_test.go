@@ -0,0 +1,184 @@
+package services
+
+import (
+	"calendar-backend/eventbus"
+	"calendar-backend/migrations"
+	"calendar-backend/models"
+	"calendar-backend/repositories"
+	"calendar-backend/search"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestDB abre una base SQLite vacía en un directorio temporal con el esquema migrado
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	migrator, err := migrations.New(db)
+	if err != nil {
+		t.Fatalf("prepare migrations: %v", err)
+	}
+	if _, err := migrator.Up(); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
+
+// newTestEventRepository devuelve el repositorio de eventos de la base, con su motor de búsqueda
+func newTestEventRepository(t *testing.T, db *gorm.DB) repositories.EventRepository {
+	t.Helper()
+	engine, err := search.Setup(db)
+	if err != nil {
+		t.Fatalf("setup search: %v", err)
+	}
+	return repositories.NewEventRepository(db, engine)
+}
+
+// createTestUser crea un usuario con el email
+func createTestUser(t *testing.T, db *gorm.DB, email string) *models.User {
+	t.Helper()
+	user := &models.User{Email: email, PasswordHash: "x"}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	return user
+}
+
+// newTestEvent arma un evento de una hora que empieza a la hora local dada en la zona
+func newTestEvent(ownerID uint, title string, start time.Time, rrule string) *models.Event {
+	event := &models.Event{
+		OwnerID:  ownerID,
+		Title:    title,
+		StartsAt: start.UTC(),
+		EndsAt:   start.Add(time.Hour).UTC(),
+		TimeZone: start.Location().String(),
+		Email:    "ana@example.com",
+		Phone:    "+5491122334455",
+		Priority: "medium",
+		RRule:    rrule,
+	}
+	event.SyncLegacyFields()
+	return event
+}
+
+// mustLoadLocation carga la zona IANA o termina el test
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("load location %s: %v", name, err)
+	}
+	return loc
+}
+
+// recordedChanges guarda los cambios publicados
+type recordedChanges struct {
+	mu      sync.Mutex
+	changes []eventbus.Change
+}
+
+func (r *recordedChanges) Publish(change eventbus.Change) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.changes = append(r.changes, change)
+}
+
+// take devuelve los cambios publicados hasta ahora y los olvida
+func (r *recordedChanges) take() []eventbus.Change {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	changes := r.changes
+	r.changes = nil
+	return changes
+}
+
+// sharingFixture es una base con dos usuarios: alice tiene un calendario "Work" con un evento,
+// que todavía no comparte con bob
+type sharingFixture struct {
+	db           *gorm.DB
+	eventRepo    repositories.EventRepository
+	calendarRepo repositories.CalendarRepository
+	events       EventService
+	calendars    *CalendarService
+	deltaSync    *DeltaSyncService
+	changes      *recordedChanges
+	alice, bob   *models.User
+	work         *models.Calendar
+	event        *models.Event
+}
+
+func newSharingFixture(t *testing.T) *sharingFixture {
+	t.Helper()
+	db := newTestDB(t)
+	f := &sharingFixture{
+		db:           db,
+		eventRepo:    newTestEventRepository(t, db),
+		calendarRepo: repositories.NewCalendarRepository(db),
+		changes:      &recordedChanges{},
+		alice:        createTestUser(t, db, "alice@example.com"),
+		bob:          createTestUser(t, db, "bob@example.com"),
+	}
+	changes := NewCalendarAudience(f.calendarRepo, f.changes)
+	f.events = NewEventService(f.eventRepo, f.calendarRepo, nil, changes)
+	f.calendars = NewCalendarService(f.calendarRepo, repositories.NewUserRepository(db), nil, changes)
+	f.deltaSync = NewDeltaSyncService(f.eventRepo, 0)
+
+	f.work = &models.Calendar{Name: "Work"}
+	if err := f.calendars.CreateCalendar(f.alice.ID, f.work); err != nil {
+		t.Fatalf("create calendar: %v", err)
+	}
+	f.event = newTestEvent(f.alice.ID, "Planning", time.Now().Add(48*time.Hour).UTC().Truncate(time.Hour), "")
+	f.event.CalendarID = f.work.ID
+	if err := f.events.ForUser(f.alice.ID).CreateEvent(f.event); err != nil {
+		t.Fatalf("create event: %v", err)
+	}
+	f.changes.take()
+	return f
+}
+
+// share comparte Work con bob con el rol
+func (f *sharingFixture) share(t *testing.T, role string) {
+	t.Helper()
+	if _, err := f.calendars.ShareCalendar(f.alice.ID, f.work.ID, f.bob.Email, role); err != nil {
+		t.Fatalf("share calendar: %v", err)
+	}
+}
+
+// unshare deja de compartir Work con bob
+func (f *sharingFixture) unshare(t *testing.T) {
+	t.Helper()
+	if err := f.calendars.UnshareCalendar(f.alice.ID, f.work.ID, f.bob.ID); err != nil {
+		t.Fatalf("unshare calendar: %v", err)
+	}
+}
+
+// token devuelve el token de sincronización actual del usuario
+func (f *sharingFixture) token(t *testing.T, userID uint) string {
+	t.Helper()
+	token, err := f.deltaSync.CurrentToken(userID)
+	if err != nil {
+		t.Fatalf("current token: %v", err)
+	}
+	return token
+}
+
+// changesSince devuelve los cambios que recibe el usuario después del token
+func (f *sharingFixture) changesSince(t *testing.T, userID uint, token string) *models.SyncDelta {
+	t.Helper()
+	delta, err := f.deltaSync.Changes(userID, token, 100)
+	if err != nil {
+		t.Fatalf("changes: %v", err)
+	}
+	return delta
+}
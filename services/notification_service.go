@@ -1,44 +1,280 @@
 package services
 
 import (
-	"calendar-backend/config"
 	"calendar-backend/models"
+	"calendar-backend/notifications"
+	"calendar-backend/repositories"
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"sync"
+	"time"
+)
 
-	"github.com/sendgrid/sendgrid-go"
-	"github.com/sendgrid/sendgrid-go/helpers/mail"
-	"github.com/twilio/twilio-go"
-	twilioApi "github.com/twilio/twilio-go/rest/api/v2010"
+const (
+	// notificationSendTimeout limita cuánto puede tardar un envío por canal
+	notificationSendTimeout = 30 * time.Second
+	// notificationPollInterval es cada cuánto se buscan reintentos pendientes, además de al registrarlos
+	notificationPollInterval = 5 * time.Second
+	// notificationBatchSize es la cantidad de reintentos que se toman a la vez
+	notificationBatchSize = 20
 )
 
+// ErrDeliveryNotRecorded indica que un envío fallido no se pudo registrar para reintentarlo:
+// nadie más lo reintenta
+var ErrDeliveryNotRecorded = errors.New("failed notification could not be recorded for retrying")
+
+// NotificationService envía los recordatorios por los canales habilitados. Un envío que falla
+// queda registrado y se reintenta con la política de reintentos del canal; si se agotan los
+// intentos o el error no se soluciona reintentando, queda fallido hasta que se reenvíe.
 type NotificationService struct {
-	cfg *config.Config
+	registry   *notifications.Registry
+	deliveries repositories.NotificationDeliveryRepository
+
+	wake     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
 }
 
-func NewNotificationService() *NotificationService {
+// NewNotificationService crea el servicio que envía los recordatorios por los canales habilitados
+func NewNotificationService(registry *notifications.Registry, deliveries repositories.NotificationDeliveryRepository) *NotificationService {
 	return &NotificationService{
-		cfg: config.LoadConfig(),
+		registry:   registry,
+		deliveries: deliveries,
+		wake:       make(chan struct{}, 1),
+		done:       make(chan struct{}),
+	}
+}
+
+// SendNotification sends the reminder for an event through every enabled channel
+// and returns the errors of the channels that failed
+func (s *NotificationService) SendNotification(event *models.Event, reminderType string) error {
+	return s.send(event, buildReminderMessage(event, reminderType), "")
+}
+
+// SendReminder envía un recordatorio del evento por su canal (o por todos si no tiene uno).
+// El texto depende de cuándo cae el recordatorio respecto del evento.
+func (s *NotificationService) SendReminder(event *models.Event, reminder models.Reminder) error {
+	reminderType := event.ReminderType(reminder.DueAt(event.StartsAt))
+	return s.send(event, buildReminderMessage(event, reminderType), reminder.Channel)
+}
+
+// Deliveries devuelve los últimos envíos registrados del usuario, filtrados por estado
+// (vacío para todos)
+func (s *NotificationService) Deliveries(ownerID uint, status string, limit int) ([]models.NotificationDelivery, error) {
+	return s.deliveries.GetByOwner(ownerID, status, limit)
+}
+
+// Redrive vuelve a encolar los envíos fallidos del usuario (uno si id no es cero, o todos)
+// con los intentos en cero, y devuelve cuántos se reencolaron
+func (s *NotificationService) Redrive(ownerID, id uint) (int64, error) {
+	redriven, err := s.deliveries.Redrive(ownerID, id, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	if redriven > 0 {
+		s.notify()
+	}
+	return redriven, nil
+}
+
+// send envía el mensaje por el canal indicado, o por todos si channelName está vacío;
+// un fallo en un canal no impide los demás: se registra para reintentarlo y se devuelven
+// todos los errores juntos (con ErrDeliveryNotRecorded los que no se pudieron registrar)
+func (s *NotificationService) send(event *models.Event, message notifications.Message, channelName string) error {
+	channels := s.registry.Channels()
+	if channelName != "" {
+		channel, ok := s.registry.Get(channelName)
+		if !ok {
+			return fmt.Errorf("notification channel %q is not enabled", channelName)
+		}
+		channels = []notifications.Channel{channel}
+	}
+
+	var errs []error
+	for _, channel := range channels {
+		delivery := &models.NotificationDelivery{
+			OwnerID: event.OwnerID,
+			EventID: event.ID,
+			Channel: channel.Name(),
+			Email:   event.Email,
+			Phone:   event.Phone,
+			Subject: message.Subject,
+			Body:    message.Body,
+			Text:    message.Text,
+		}
+		err := s.deliver(channel, delivery)
+		switch {
+		case errors.Is(err, notifications.ErrNoAddress):
+			continue
+		case err != nil:
+			if recordErr := s.record(delivery, err); recordErr != nil {
+				log.Printf("❌ Error recording failed %s notification of event %d: %v", channel.Name(), event.ID, recordErr)
+				err = fmt.Errorf("%w: %w", err, ErrDeliveryNotRecorded)
+			}
+			errs = append(errs, fmt.Errorf("%s: %w", channel.Name(), err))
+		default:
+			log.Printf("%s notification sent successfully to %s", channel.Name(), channel.Address(deliveryRecipient(delivery)))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// deliver hace un intento del envío por el canal
+func (s *NotificationService) deliver(channel notifications.Channel, delivery *models.NotificationDelivery) error {
+	ctx, cancel := context.WithTimeout(context.Background(), notificationSendTimeout)
+	defer cancel()
+
+	delivery.Attempts++
+	messageID, err := notifications.Deliver(ctx, channel, deliveryRecipient(delivery), deliveryMessage(delivery))
+	if err != nil {
+		return err
 	}
+	now := time.Now()
+	delivery.Status = models.DeliverySucceeded
+	delivery.NextAttemptAt = nil
+	delivery.LastError = ""
+	delivery.ProviderMessageID = messageID
+	delivery.DeliveredAt = &now
+	return nil
 }
 
-// SendEmailNotification sends an email reminder for an event
-func (s *NotificationService) SendEmailNotification(event *models.Event, reminderType string) error {
-	if s.cfg.SendGridAPIKey == "" {
-		log.Println("SendGrid API key not configured, skipping email notification")
+// record deja el envío fallido pendiente del próximo intento según la política del canal,
+// o fallido si se agotaron los intentos o el error no se soluciona reintentando
+func (s *NotificationService) record(delivery *models.NotificationDelivery, err error) error {
+	delivery.LastError = err.Error()
+	policy := s.registry.RetryPolicy(delivery.Channel)
+	if notifications.IsPermanent(err) || policy.Exhausted(delivery.Attempts) {
+		delivery.Status = models.DeliveryFailed
+		delivery.NextAttemptAt = nil
+		log.Printf("❌ %s notification %d of event %d failed after %d attempts: %v",
+			delivery.Channel, delivery.ID, delivery.EventID, delivery.Attempts, err)
+	} else {
+		next := time.Now().Add(policy.Backoff(delivery.Attempts))
+		delivery.Status = models.DeliveryPending
+		delivery.NextAttemptAt = &next
+		log.Printf("⚠️ %s notification of event %d failed (attempt %d), retrying at %s: %v",
+			delivery.Channel, delivery.EventID, delivery.Attempts, next.Format(time.RFC3339), err)
+	}
+
+	if delivery.ID == 0 {
+		if err := s.deliveries.Create(delivery); err != nil {
+			return err
+		}
+		if delivery.Status == models.DeliveryPending {
+			s.notify()
+		}
 		return nil
 	}
+	return s.deliveries.Save(delivery)
+}
+
+// Start arranca los reintentos de los envíos fallidos
+func (s *NotificationService) Start() {
+	s.wg.Add(1)
+	go s.run()
+	log.Println("Notification retries started")
+}
+
+// Stop detiene los reintentos, esperando el que esté en curso
+func (s *NotificationService) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.done)
+	})
+	s.wg.Wait()
+}
+
+// notify despierta a los reintentos sin bloquear
+func (s *NotificationService) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *NotificationService) run() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(notificationPollInterval)
+	defer ticker.Stop()
+	for {
+		s.retryDue()
+		select {
+		case <-ticker.C:
+		case <-s.wake:
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// retryDue reintenta los envíos pendientes cuyo próximo intento ya venció
+func (s *NotificationService) retryDue() {
+	for {
+		// Mientras se reintenta un envío ninguna otra instancia lo toma
+		lease := notificationBatchSize*notificationSendTimeout + time.Minute
+		due, err := s.deliveries.ClaimDue(time.Now(), lease, notificationBatchSize)
+		if err != nil {
+			log.Printf("❌ Error loading pending notification retries: %v", err)
+		}
+		if len(due) == 0 {
+			return
+		}
+
+		for i := range due {
+			if err := s.retry(&due[i]); err != nil {
+				log.Printf("❌ Error saving notification delivery %d: %v", due[i].ID, err)
+			}
+		}
+
+		if len(due) < notificationBatchSize {
+			return
+		}
+	}
+}
+
+// retry hace el próximo intento de un envío pendiente y guarda el resultado
+func (s *NotificationService) retry(delivery *models.NotificationDelivery) error {
+	channel, ok := s.registry.Get(delivery.Channel)
+	if !ok {
+		delivery.Status = models.DeliveryFailed
+		delivery.NextAttemptAt = nil
+		delivery.LastError = "notification channel is not enabled"
+		return s.deliveries.Save(delivery)
+	}
 
-	from := mail.NewEmail("Calendar Reminder", s.cfg.FromEmail)
-	to := mail.NewEmail("User", event.Email)
+	if err := s.deliver(channel, delivery); err != nil {
+		return s.record(delivery, err)
+	}
+	log.Printf("%s notification %d sent successfully after %d attempts", delivery.Channel, delivery.ID, delivery.Attempts)
+	return s.deliveries.Save(delivery)
+}
+
+// deliveryRecipient devuelve el destinatario guardado en el envío
+func deliveryRecipient(delivery *models.NotificationDelivery) notifications.Recipient {
+	return notifications.Recipient{Email: delivery.Email, Phone: delivery.Phone}
+}
+
+// deliveryMessage devuelve el mensaje guardado en el envío
+func deliveryMessage(delivery *models.NotificationDelivery) notifications.Message {
+	return notifications.Message{Subject: delivery.Subject, Body: delivery.Body, Text: delivery.Text}
+}
 
-	var subject string
-	var body string
+// buildReminderMessage arma el texto del recordatorio según el tipo
+func buildReminderMessage(event *models.Event, reminderType string) notifications.Message {
+	var message notifications.Message
+
+	location := ""
+	if event.Location != "" {
+		location = fmt.Sprintf("Ubicación: %s", event.Location)
+	}
 
 	switch reminderType {
 	case "day_before":
-		subject = fmt.Sprintf("Recordatorio: %s mañana", event.Title)
-		body = fmt.Sprintf(`
+		message.Subject = fmt.Sprintf("Recordatorio: %s mañana", event.Title)
+		message.Body = fmt.Sprintf(`
 			Hola!
 			
 			Te recordamos que mañana tenés:
@@ -49,16 +285,11 @@ func (s *NotificationService) SendEmailNotification(event *models.Event, reminde
 			%s
 			
 			¡No te lo pierdas!
-		`, event.Title, event.Date.Format("02/01/2006"), event.Time,
-			func() string {
-				if event.Location != "" {
-					return fmt.Sprintf("Ubicación: %s", event.Location)
-				}
-				return ""
-			}())
+		`, event.Title, event.Date.Format("02/01/2006"), event.Time, location)
+		message.Text = fmt.Sprintf("Recordatorio: Mañana tienes '%s' a las %s", event.Title, event.Time)
 	case "same_day":
-		subject = fmt.Sprintf("Recordatorio: %s hoy", event.Title)
-		body = fmt.Sprintf(`
+		message.Subject = fmt.Sprintf("Recordatorio: %s hoy", event.Title)
+		message.Body = fmt.Sprintf(`
 			Hola!
 			
 			Te recordamos que hoy tenés:
@@ -68,76 +299,28 @@ func (s *NotificationService) SendEmailNotification(event *models.Event, reminde
 			%s
 			
 			¡Que tengas un buen día!
-		`, event.Title, event.Time,
-			func() string {
-				if event.Location != "" {
-					return fmt.Sprintf("Ubicación: %s", event.Location)
-				}
-				return ""
-			}())
-	}
-
-	message := mail.NewSingleEmail(from, subject, to, body, body)
-	client := sendgrid.NewSendClient(s.cfg.SendGridAPIKey)
-
-	response, err := client.Send(message)
-	if err != nil {
-		return fmt.Errorf("failed to send email: %v", err)
-	}
-
-	log.Printf("Email sent successfully to %s, status: %d", event.Email, response.StatusCode)
-	return nil
-}
-
-// SendWhatsAppNotification sends a WhatsApp reminder for an event
-func (s *NotificationService) SendWhatsAppNotification(event *models.Event, reminderType string) error {
-	if s.cfg.TwilioAccountSID == "" || s.cfg.TwilioAuthToken == "" {
-		log.Println("Twilio credentials not configured, skipping WhatsApp notification")
-		return nil
-	}
-
-	client := twilio.NewRestClientWithParams(twilio.ClientParams{
-		Username: s.cfg.TwilioAccountSID,
-		Password: s.cfg.TwilioAuthToken,
-	})
-
-	var message string
-	switch reminderType {
-	case "day_before":
-		message = fmt.Sprintf("Recordatorio: Mañana tienes '%s' a las %s", event.Title, event.Time)
-	case "same_day":
-		message = fmt.Sprintf("Recordatorio: Hoy tienes '%s' a las %s", event.Title, event.Time)
+		`, event.Title, event.Time, location)
+		message.Text = fmt.Sprintf("Recordatorio: Hoy tienes '%s' a las %s", event.Title, event.Time)
+	case "upcoming":
+		message.Subject = fmt.Sprintf("Recordatorio: %s el %s", event.Title, event.Date.Format("02/01/2006"))
+		message.Body = fmt.Sprintf(`
+			Hola!
+			
+			Te recordamos que próximamente tenés:
+			
+			Evento: %s
+			Fecha: %s
+			Hora: %s
+			%s
+			
+			¡No te lo pierdas!
+		`, event.Title, event.Date.Format("02/01/2006"), event.Time, location)
+		message.Text = fmt.Sprintf("Recordatorio: El %s tienes '%s' a las %s", event.Date.Format("02/01/2006"), event.Title, event.Time)
 	}
 
 	if event.Location != "" {
-		message += fmt.Sprintf(" en %s", event.Location)
-	}
-
-	params := &twilioApi.CreateMessageParams{}
-	params.SetTo(event.Phone)
-	params.SetFrom(s.cfg.TwilioPhoneNumber)
-	params.SetBody(message)
-
-	_, err := client.Api.CreateMessage(params)
-	if err != nil {
-		return fmt.Errorf("failed to send WhatsApp message: %v", err)
-	}
-
-	log.Printf("WhatsApp message sent successfully to %s", event.Phone)
-	return nil
-}
-
-// SendNotification sends both email and WhatsApp notifications
-func (s *NotificationService) SendNotification(event *models.Event, reminderType string) error {
-	// Send email notification
-	if err := s.SendEmailNotification(event, reminderType); err != nil {
-		log.Printf("Failed to send email notification: %v", err)
+		message.Text += fmt.Sprintf(" en %s", event.Location)
 	}
 
-	// Send WhatsApp notification
-	if err := s.SendWhatsAppNotification(event, reminderType); err != nil {
-		log.Printf("Failed to send WhatsApp notification: %v", err)
-	}
-
-	return nil
+	return message
 }
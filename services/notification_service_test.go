@@ -0,0 +1,132 @@
+package services
+
+import (
+	"calendar-backend/models"
+	"calendar-backend/notifications"
+	"calendar-backend/repositories"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeChannel devuelve los errores de failures en orden y después envía bien
+type fakeChannel struct {
+	failures []error
+	sent     int
+}
+
+func (c *fakeChannel) Name() string { return "email" }
+
+func (c *fakeChannel) Address(recipient notifications.Recipient) string { return recipient.Email }
+
+func (c *fakeChannel) Send(ctx context.Context, recipient notifications.Recipient, message notifications.Message) error {
+	if recipient.Email == "" {
+		return notifications.ErrNoAddress
+	}
+	if len(c.failures) > 0 {
+		err := c.failures[0]
+		c.failures = c.failures[1:]
+		return err
+	}
+	c.sent++
+	return nil
+}
+
+// newTestNotificationService arma el servicio con el canal y una política sin espera entre intentos
+func newTestNotificationService(t *testing.T, channel *fakeChannel, maxAttempts int) (*NotificationService, repositories.NotificationDeliveryRepository, *models.Event) {
+	t.Helper()
+	db := newTestDB(t)
+	user := createTestUser(t, db, "alice@example.com")
+	event := newTestEvent(user.ID, "Dentist", time.Date(2026, 10, 20, 9, 0, 0, 0, time.UTC), "")
+	event.ID = 7
+	event.Email = user.Email
+
+	registry := notifications.NewRegistry(channel)
+	registry.SetRetryPolicy(channel.Name(), notifications.RetryPolicy{MaxAttempts: maxAttempts})
+	deliveries := repositories.NewNotificationDeliveryRepository(db)
+	return NewNotificationService(registry, deliveries), deliveries, event
+}
+
+func ownerDeliveries(t *testing.T, deliveries repositories.NotificationDeliveryRepository, ownerID uint) []models.NotificationDelivery {
+	t.Helper()
+	list, err := deliveries.GetByOwner(ownerID, "", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return list
+}
+
+func TestNotificationRetriedUntilSent(t *testing.T) {
+	channel := &fakeChannel{failures: []error{errors.New("503 from provider"), errors.New("timeout")}}
+	service, deliveries, event := newTestNotificationService(t, channel, 5)
+
+	if err := service.SendReminder(event, models.Reminder{OffsetMinutes: 30}); err == nil {
+		t.Fatal("SendReminder hid the failed send")
+	}
+	list := ownerDeliveries(t, deliveries, event.OwnerID)
+	if len(list) != 1 || list[0].Status != models.DeliveryPending || list[0].Attempts != 1 || list[0].EventID != event.ID {
+		t.Fatalf("after the failed send: %+v, want one pending delivery", list)
+	}
+
+	service.retryDue() // falla de nuevo y queda pendiente
+	service.retryDue()
+	list = ownerDeliveries(t, deliveries, event.OwnerID)
+	if len(list) != 1 || list[0].Status != models.DeliverySucceeded || list[0].Attempts != 3 || list[0].LastError != "" {
+		t.Fatalf("after retrying: %+v, want the delivery sent on the third attempt", list)
+	}
+	if channel.sent != 1 {
+		t.Errorf("sent %d messages, want 1", channel.sent)
+	}
+}
+
+func TestNotificationFailsWhenAttemptsExhausted(t *testing.T) {
+	channel := &fakeChannel{failures: []error{errors.New("503"), errors.New("503"), errors.New("503")}}
+	service, deliveries, event := newTestNotificationService(t, channel, 2)
+
+	service.SendReminder(event, models.Reminder{OffsetMinutes: 30})
+	service.retryDue()
+	service.retryDue()
+
+	list := ownerDeliveries(t, deliveries, event.OwnerID)
+	if len(list) != 1 || list[0].Status != models.DeliveryFailed || list[0].Attempts != 2 || list[0].LastError != "503" {
+		t.Fatalf("deliveries %+v, want one failed after 2 attempts", list)
+	}
+
+	redriven, err := service.Redrive(event.OwnerID, list[0].ID)
+	if err != nil || redriven != 1 {
+		t.Fatalf("Redrive = %d, %v", redriven, err)
+	}
+	service.retryDue() // tercer error: vuelve a fallar con los intentos en cero
+	service.retryDue()
+	if list := ownerDeliveries(t, deliveries, event.OwnerID); list[0].Status != models.DeliverySucceeded {
+		t.Errorf("after re-driving: %+v, want the delivery sent", list[0])
+	}
+}
+
+func TestPermanentNotificationErrorIsNotRetried(t *testing.T) {
+	channel := &fakeChannel{failures: []error{notifications.Permanent(errors.New("invalid address"))}}
+	service, deliveries, event := newTestNotificationService(t, channel, 5)
+
+	service.SendReminder(event, models.Reminder{OffsetMinutes: 30})
+	list := ownerDeliveries(t, deliveries, event.OwnerID)
+	if len(list) != 1 || list[0].Status != models.DeliveryFailed || list[0].Attempts != 1 {
+		t.Fatalf("deliveries %+v, want one failed without retries", list)
+	}
+
+	if redriven, err := service.Redrive(event.OwnerID+1, 0); err != nil || redriven != 0 {
+		t.Errorf("another user re-drove %d deliveries (err %v)", redriven, err)
+	}
+}
+
+func TestNotificationWithoutAddressIsNotRecorded(t *testing.T) {
+	service, deliveries, event := newTestNotificationService(t, &fakeChannel{}, 5)
+	event.Email = ""
+
+	if err := service.SendReminder(event, models.Reminder{OffsetMinutes: 30}); err != nil {
+		t.Fatalf("SendReminder: %v", err)
+	}
+	if list := ownerDeliveries(t, deliveries, event.OwnerID); len(list) != 0 {
+		t.Errorf("recorded %+v for a recipient without address", list)
+	}
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"calendar-backend/models"
+	"calendar-backend/reminders"
+	"calendar-backend/repositories"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ReminderService calcula el instante exacto de cada recordatorio y los envía
+// a través del motor de recordatorios
+type ReminderService struct {
+	db                  *gorm.DB
+	notificationService *NotificationService
+	webhooks            *WebhookService // Opcional: avisa los recordatorios a los webhooks
+	engine              *reminders.Engine
+}
+
+func NewReminderService(db *gorm.DB, notificationService *NotificationService, webhooks *WebhookService, checkpointRepo repositories.ReminderCheckpointRepository, catchUpWindow time.Duration) *ReminderService {
+	s := &ReminderService{
+		db:                  db,
+		notificationService: notificationService,
+		webhooks:            webhooks,
+	}
+	s.engine = reminders.NewEngine(s.dueReminders, checkpointRepo, catchUpWindow)
+	return s
+}
+
+// Start arranca el motor, recuperando los recordatorios perdidos mientras el proceso estaba detenido
+func (s *ReminderService) Start() {
+	s.engine.Start()
+}
+
+// Stop detiene el motor
+func (s *ReminderService) Stop() {
+	s.engine.Stop()
+}
+
+// Reschedule recalcula los recordatorios pendientes luego de crear, modificar o eliminar eventos
+func (s *ReminderService) Reschedule() {
+	s.engine.Reschedule()
+}
+
+// dueReminders devuelve los recordatorios que vencen en (from, to], uno por cada
+// ocurrencia y recordatorio configurado
+func (s *ReminderService) dueReminders(from, to time.Time) ([]reminders.Reminder, error) {
+	events, err := s.reminderCandidates(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	var due []reminders.Reminder
+	for _, event := range events {
+		maxOffset := time.Duration(0)
+		for _, reminder := range event.Reminders {
+			if reminder.Offset() > maxOffset {
+				maxOffset = reminder.Offset()
+			}
+		}
+
+		// Ocurrencias que empiezan entre from y el recordatorio más anticipado,
+		// con los días calculados en la zona horaria del evento
+		loc := event.Zone()
+		for _, occurrence := range event.Occurrences(models.CalendarDate(from, loc), models.CalendarDate(to.Add(maxOffset), loc)) {
+			for _, reminder := range occurrence.Reminders {
+				dueAt := reminder.DueAt(occurrence.StartsAt)
+				if !dueAt.After(from) || dueAt.After(to) {
+					continue
+				}
+				due = append(due, s.newReminder(occurrence, reminder, dueAt))
+			}
+		}
+	}
+	return due, nil
+}
+
+// newReminder arma el envío del recordatorio de una ocurrencia. Los canales que fallan quedan
+// registrados y los reintenta el servicio de notificaciones; solo un fallo que no se pudo
+// registrar vuelve al motor, que reintenta el recordatorio.
+func (s *ReminderService) newReminder(occurrence models.Event, reminder models.Reminder, dueAt time.Time) reminders.Reminder {
+	webhooksNotified := false
+	return reminders.Reminder{
+		DueAt:    dueAt,
+		StartsAt: occurrence.StartsAt,
+		Label:    fmt.Sprintf("event %d (%s)", occurrence.ID, occurrence.Title),
+		Send: func() error {
+			log.Printf("Sending %d-minute reminder for event: %s", reminder.OffsetMinutes, occurrence.Title)
+			// Los webhooks se avisan una sola vez, aunque falle la notificación
+			if s.webhooks != nil && !webhooksNotified {
+				s.webhooks.ReminderFired(&occurrence, reminder, dueAt)
+				webhooksNotified = true
+			}
+			err := s.notificationService.SendReminder(&occurrence, reminder)
+			if err != nil && !errors.Is(err, ErrDeliveryNotRecorded) {
+				log.Printf("❌ Reminder for %s failed, its notifications are retried on their own: %v", occurrence.Title, err)
+				return nil
+			}
+			return err
+		},
+	}
+}
+
+// reminderCandidates busca los eventos con recordatorios cuyas ocurrencias pueden empezar
+// entre from y la mayor anticipación posterior a to, incluidas las series recurrentes
+func (s *ReminderService) reminderCandidates(from, to time.Time) ([]models.Event, error) {
+	windowEnd := to.Add(time.Duration(models.MaxReminderOffsetMinutes) * time.Minute)
+
+	var events []models.Event
+	err := s.db.Preload("Reminders").
+		Where("EXISTS (SELECT 1 FROM reminders WHERE reminders.event_id = events.id)").
+		Where("((rrule = '' OR rrule IS NULL) AND starts_at > ? AND starts_at <= ?) OR (rrule <> '' AND starts_at <= ?)", from, windowEnd, windowEnd).
+		Order("starts_at ASC").
+		Find(&events).Error
+	return events, err
+}
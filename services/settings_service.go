@@ -0,0 +1,65 @@
+package services
+
+import (
+	"calendar-backend/config"
+	"calendar-backend/models"
+	"calendar-backend/repositories"
+	"errors"
+	"strings"
+)
+
+// SettingsService maneja las preferencias de los dueños de eventos
+type SettingsService struct {
+	settingsRepo    repositories.OwnerSettingsRepository
+	defaultTimeZone string
+}
+
+func NewSettingsService(settingsRepo repositories.OwnerSettingsRepository) *SettingsService {
+	defaultTimeZone := config.LoadConfig().DefaultTimeZone
+	if _, err := models.LoadLocation(defaultTimeZone); err != nil {
+		defaultTimeZone = "UTC"
+	}
+	return &SettingsService{
+		settingsRepo:    settingsRepo,
+		defaultTimeZone: defaultTimeZone,
+	}
+}
+
+// GetSettings devuelve las preferencias del dueño, con la zona por defecto si no tiene
+func (s *SettingsService) GetSettings(email string) (*models.OwnerSettings, error) {
+	email = strings.TrimSpace(strings.ToLower(email))
+	if email == "" {
+		return nil, errors.New("email is required")
+	}
+	settings, err := s.settingsRepo.GetByEmail(email)
+	if err != nil {
+		return &models.OwnerSettings{Email: email, TimeZone: s.defaultTimeZone}, nil
+	}
+	return settings, nil
+}
+
+// UpdateTimeZone valida y guarda la zona por defecto del dueño
+func (s *SettingsService) UpdateTimeZone(email, timeZone string) (*models.OwnerSettings, error) {
+	email = strings.TrimSpace(strings.ToLower(email))
+	if email == "" {
+		return nil, errors.New("email is required")
+	}
+	if _, err := models.LoadLocation(timeZone); err != nil {
+		return nil, err
+	}
+
+	settings := &models.OwnerSettings{Email: email, TimeZone: timeZone}
+	if err := s.settingsRepo.Upsert(settings); err != nil {
+		return nil, err
+	}
+	return s.settingsRepo.GetByEmail(email)
+}
+
+// DefaultTimeZone devuelve la zona a usar para los eventos nuevos de un dueño
+func (s *SettingsService) DefaultTimeZone(email string) string {
+	settings, err := s.GetSettings(email)
+	if err != nil {
+		return s.defaultTimeZone
+	}
+	return settings.TimeZone
+}
@@ -0,0 +1,578 @@
+package services
+
+import (
+	"calendar-backend/calsync"
+	"calendar-backend/eventbus"
+	"calendar-backend/models"
+	"calendar-backend/repositories"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// syncTimeout limita cuánto puede tardar la sincronización de una cuenta
+const syncTimeout = 2 * time.Minute
+
+// Ganador de un conflicto en la auditoría
+const (
+	syncWinnerLocal  = "local"
+	syncWinnerRemote = "remote"
+)
+
+var (
+	ErrSyncAccountNotFound = errors.New("sync account not found")
+	ErrSyncProviderUnknown = errors.New("sync provider is not enabled")
+)
+
+// SyncResult resume una sincronización de una cuenta
+type SyncResult struct {
+	Pulled    int `json:"pulled"`    // Cambios externos aplicados al calendario
+	Pushed    int `json:"pushed"`    // Cambios del calendario enviados al proveedor
+	Conflicts int `json:"conflicts"` // Eventos modificados de los dos lados
+	Failed    int `json:"failed"`    // Eventos externos que no pudieron copiarse
+}
+
+// SyncService sincroniza en ambos sentidos los eventos con calendarios externos.
+// Cada sincronización primero aplica los cambios externos (pull) y después envía los
+// locales (push). Si un evento cambió de los dos lados gana el último en modificarse
+// y el conflicto queda registrado en la auditoría. Las ocurrencias separadas de una
+// serie no se sincronizan.
+type SyncService struct {
+	syncRepo  repositories.SyncRepository
+	eventRepo repositories.EventRepository
+	userRepo  repositories.UserRepository
+	providers *calsync.Registry
+	reminders ReminderScheduler
+	changes   ChangePublisher
+	interval  time.Duration
+
+	mu       sync.Mutex // Una sincronización a la vez
+	ctx      context.Context
+	cancel   context.CancelFunc
+	done     chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+func NewSyncService(syncRepo repositories.SyncRepository, eventRepo repositories.EventRepository, userRepo repositories.UserRepository, providers *calsync.Registry, reminders ReminderScheduler, changes ChangePublisher, interval time.Duration) *SyncService {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &SyncService{
+		syncRepo:  syncRepo,
+		eventRepo: eventRepo,
+		userRepo:  userRepo,
+		providers: providers,
+		reminders: reminders,
+		changes:   changes,
+		interval:  interval,
+		ctx:       ctx,
+		cancel:    cancel,
+		done:      make(chan struct{}),
+	}
+}
+
+// Start arranca la sincronización periódica de todas las cuentas
+func (s *SyncService) Start() {
+	if len(s.providers.Names()) == 0 || s.interval <= 0 {
+		log.Println("Calendar sync disabled: no sync providers enabled")
+		return
+	}
+	s.wg.Add(1)
+	go s.run()
+	log.Printf("Calendar sync started (every %s)", s.interval)
+}
+
+// Stop detiene la sincronización periódica, cancelando la que esté en curso
+func (s *SyncService) Stop() {
+	s.stopOnce.Do(func() {
+		s.cancel()
+		close(s.done)
+	})
+	s.wg.Wait()
+}
+
+func (s *SyncService) run() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.syncAll()
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// syncAll sincroniza todas las cuentas; los errores quedan en cada cuenta
+func (s *SyncService) syncAll() {
+	accounts, err := s.syncRepo.GetAccounts()
+	if err != nil {
+		log.Printf("❌ Error loading sync accounts: %v", err)
+		return
+	}
+	for i := range accounts {
+		if s.ctx.Err() != nil {
+			return
+		}
+		if _, err := s.syncAccount(&accounts[i]); err != nil {
+			log.Printf("❌ Error syncing account %d (%s): %v", accounts[i].ID, accounts[i].Provider, err)
+		}
+	}
+}
+
+// ConnectAccount conecta un calendario externo del usuario. La primera sincronización
+// copia los eventos en ambos sentidos.
+func (s *SyncService) ConnectAccount(ownerID uint, provider, calendarID, credentials string) (*models.SyncAccount, error) {
+	if _, ok := s.providers.Get(provider); !ok {
+		return nil, ErrSyncProviderUnknown
+	}
+	account := &models.SyncAccount{
+		OwnerID:     ownerID,
+		Provider:    provider,
+		CalendarID:  calendarID,
+		Credentials: credentials,
+	}
+	if err := s.syncRepo.CreateAccount(account); err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+// ListAccounts devuelve las cuentas conectadas por el usuario
+func (s *SyncService) ListAccounts(ownerID uint) ([]models.SyncAccount, error) {
+	return s.syncRepo.GetAccountsByOwner(ownerID)
+}
+
+// DisconnectAccount desconecta la cuenta; los eventos ya copiados se conservan
+func (s *SyncService) DisconnectAccount(ownerID, id uint) error {
+	err := s.syncRepo.DeleteAccount(ownerID, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrSyncAccountNotFound
+	}
+	return err
+}
+
+// SyncNow sincroniza la cuenta del usuario en el momento
+func (s *SyncService) SyncNow(ownerID, id uint) (*models.SyncAccount, *SyncResult, error) {
+	account, err := s.account(ownerID, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	result, err := s.syncAccount(account)
+	return account, result, err
+}
+
+// Audit devuelve las últimas entradas de la auditoría de la cuenta
+func (s *SyncService) Audit(ownerID, id uint, limit int) ([]models.SyncAudit, error) {
+	if _, err := s.account(ownerID, id); err != nil {
+		return nil, err
+	}
+	return s.syncRepo.GetAudit(id, limit)
+}
+
+func (s *SyncService) account(ownerID, id uint) (*models.SyncAccount, error) {
+	account, err := s.syncRepo.GetAccount(ownerID, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrSyncAccountNotFound
+	}
+	return account, err
+}
+
+// syncAccount sincroniza una cuenta y guarda su estado. La próxima sincronización envía
+// los eventos modificados desde el inicio de la última que terminó sin errores.
+func (s *SyncService) syncAccount(account *models.SyncAccount) (*SyncResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	provider, ok := s.providers.Get(account.Provider)
+	if !ok {
+		return nil, ErrSyncProviderUnknown
+	}
+
+	ctx, cancel := context.WithTimeout(s.ctx, syncTimeout)
+	defer cancel()
+
+	startedAt := time.Now()
+	run := &syncRun{
+		service:   s,
+		ctx:       ctx,
+		provider:  provider,
+		account:   account,
+		remote:    calsync.Account{CalendarID: account.CalendarID, Credentials: account.Credentials},
+		events:    s.eventRepo.ForOwner(account.OwnerID),
+		result:    &SyncResult{},
+		localWins: make(map[uint]*models.SyncAudit),
+	}
+
+	err := run.pull()
+	if err == nil {
+		err = run.push()
+	}
+	if err != nil {
+		account.LastError = err.Error()
+	} else {
+		account.LastError = ""
+		account.LastSyncedAt = &startedAt
+	}
+	if saveErr := s.syncRepo.SaveAccountState(account); saveErr != nil && err == nil {
+		err = saveErr
+	}
+
+	if run.changedLocal && s.reminders != nil {
+		s.reminders.Reschedule()
+	}
+	return run.result, err
+}
+
+// syncRun es una sincronización en curso de una cuenta
+type syncRun struct {
+	service  *SyncService
+	ctx      context.Context
+	provider calsync.Provider
+	account  *models.SyncAccount
+	remote   calsync.Account
+	events   repositories.EventRepository
+	result   *SyncResult
+	email    string
+
+	// Conflictos ganados por el evento local, que se registran al enviarlo en el push
+	localWins    map[uint]*models.SyncAudit
+	changedLocal bool
+}
+
+// pull aplica los cambios externos desde el último token. Si el proveedor ya no acepta
+// el token se vuelven a leer todos los eventos.
+func (r *syncRun) pull() error {
+	changes, next, err := r.provider.Changes(r.ctx, r.remote, r.account.SyncToken)
+	if errors.Is(err, calsync.ErrTokenExpired) && r.account.SyncToken != "" {
+		changes, next, err = r.provider.Changes(r.ctx, r.remote, "")
+	}
+	if err != nil {
+		return fmt.Errorf("listing remote changes: %w", err)
+	}
+
+	for _, change := range changes {
+		if err := r.applyRemote(change); err != nil {
+			return err
+		}
+	}
+	r.account.SyncToken = next
+	return nil
+}
+
+// applyRemote aplica un cambio externo al calendario
+func (r *syncRun) applyRemote(change calsync.RemoteEvent) error {
+	link, err := r.service.syncRepo.GetLinkByRemoteID(r.account.ID, change.ID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	if link == nil {
+		if change.Deleted {
+			return nil
+		}
+		return r.createLocal(change, nil)
+	}
+	// El cambio es el que envió esta misma sincronización
+	if link.RemoteVersion == change.Version {
+		return nil
+	}
+
+	local, err := r.events.GetIncludingDeleted(link.EventID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		if err := r.service.syncRepo.DeleteLink(link.ID); err != nil {
+			return err
+		}
+		if change.Deleted {
+			return nil
+		}
+		return r.createLocal(change, nil)
+	}
+	if err != nil {
+		return err
+	}
+
+	audit := &models.SyncAudit{
+		AccountID:       r.account.ID,
+		EventID:         local.ID,
+		RemoteID:        change.ID,
+		RemoteUpdatedAt: timePtr(change.UpdatedAt),
+	}
+
+	// Conflicto: el evento también cambió en el calendario desde la última sincronización
+	if local.DeletedAt.Valid || local.UpdatedAt.After(link.LocalUpdatedAt) {
+		localAt := local.UpdatedAt
+		if local.DeletedAt.Valid {
+			localAt = local.DeletedAt.Time
+		}
+		audit.Conflict = true
+		audit.LocalUpdatedAt = timePtr(localAt)
+		r.result.Conflicts++
+
+		if localAt.After(change.UpdatedAt) {
+			// Gana el evento local, que se envía en el push. Si el externo se eliminó,
+			// se vuelve a crear.
+			audit.Winner = syncWinnerLocal
+			r.localWins[local.ID] = audit
+			if change.Deleted {
+				return r.service.syncRepo.DeleteLink(link.ID)
+			}
+			link.RemoteVersion = change.Version
+			return r.service.syncRepo.SaveLink(link)
+		}
+		audit.Winner = syncWinnerRemote
+	}
+
+	switch {
+	case change.Deleted:
+		if !local.DeletedAt.Valid {
+			if err := r.events.Delete(local.ID); err != nil {
+				return err
+			}
+			publishChange(r.service.changes, eventbus.Deleted, local)
+			r.changedLocal = true
+		}
+		if err := r.service.syncRepo.DeleteLink(link.ID); err != nil {
+			return err
+		}
+		audit.Action = models.SyncPulledDelete
+		r.result.Pulled++
+		return r.service.syncRepo.AddAudit(audit)
+	case local.DeletedAt.Valid:
+		// La edición externa es posterior a la eliminación local: se vuelve a crear
+		if err := r.service.syncRepo.DeleteLink(link.ID); err != nil {
+			return err
+		}
+		return r.createLocal(change, audit)
+	}
+
+	updated := *local
+	if err := r.fromRemote(&updated, change); err != nil {
+		return r.fail(audit, err)
+	}
+	if err := r.events.Replace(&updated); err != nil {
+		return err
+	}
+	saved, err := r.events.GetIncludingDeleted(local.ID)
+	if err != nil {
+		return err
+	}
+	publishChange(r.service.changes, eventbus.Updated, saved)
+	link.RemoteVersion = change.Version
+	link.LocalUpdatedAt = saved.UpdatedAt
+	if err := r.service.syncRepo.SaveLink(link); err != nil {
+		return err
+	}
+
+	r.changedLocal = true
+	audit.Action = models.SyncPulledUpdate
+	r.result.Pulled++
+	return r.service.syncRepo.AddAudit(audit)
+}
+
+// createLocal copia al calendario un evento externo sin copia local
+func (r *syncRun) createLocal(change calsync.RemoteEvent, audit *models.SyncAudit) error {
+	if audit == nil {
+		audit = &models.SyncAudit{AccountID: r.account.ID, RemoteID: change.ID, RemoteUpdatedAt: timePtr(change.UpdatedAt)}
+	}
+
+	email, err := r.ownerEmail()
+	if err != nil {
+		return err
+	}
+	event := &models.Event{Email: email}
+	if err := r.fromRemote(event, change); err != nil {
+		return r.fail(audit, err)
+	}
+	if err := r.events.Create(event); err != nil {
+		return err
+	}
+	saved, err := r.events.GetIncludingDeleted(event.ID)
+	if err != nil {
+		return err
+	}
+	publishChange(r.service.changes, eventbus.Created, saved)
+
+	link := &models.SyncLink{
+		AccountID:      r.account.ID,
+		EventID:        event.ID,
+		RemoteID:       change.ID,
+		RemoteVersion:  change.Version,
+		LocalUpdatedAt: saved.UpdatedAt,
+	}
+	if err := r.service.syncRepo.SaveLink(link); err != nil {
+		return err
+	}
+
+	r.changedLocal = true
+	audit.EventID = event.ID
+	audit.Action = models.SyncPulledCreate
+	r.result.Pulled++
+	return r.service.syncRepo.AddAudit(audit)
+}
+
+// push envía los eventos modificados o eliminados desde la última sincronización
+func (r *syncRun) push() error {
+	since := time.Time{}
+	if r.account.LastSyncedAt != nil {
+		since = *r.account.LastSyncedAt
+	}
+	changed, err := r.events.GetChangedSince(since)
+	if err != nil {
+		return err
+	}
+
+	for i := range changed {
+		event := &changed[i]
+		if event.RecurrenceID != nil {
+			continue
+		}
+		if err := r.pushEvent(event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pushEvent envía al proveedor un evento creado, modificado o eliminado
+func (r *syncRun) pushEvent(event *models.Event) error {
+	link, err := r.service.syncRepo.GetLinkByEvent(r.account.ID, event.ID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	audit := r.localWins[event.ID]
+	if audit == nil {
+		audit = &models.SyncAudit{AccountID: r.account.ID, EventID: event.ID}
+	}
+
+	if event.DeletedAt.Valid {
+		if link == nil {
+			return nil
+		}
+		if err := r.provider.Delete(r.ctx, r.remote, link.RemoteID); err != nil && !errors.Is(err, calsync.ErrNotFound) {
+			return fmt.Errorf("deleting remote event %s: %w", link.RemoteID, err)
+		}
+		if err := r.service.syncRepo.DeleteLink(link.ID); err != nil {
+			return err
+		}
+		audit.RemoteID = link.RemoteID
+		audit.LocalUpdatedAt = timePtr(event.DeletedAt.Time)
+		audit.Action = models.SyncPushedDelete
+		r.result.Pushed++
+		return r.service.syncRepo.AddAudit(audit)
+	}
+
+	if link != nil && !event.UpdatedAt.After(link.LocalUpdatedAt) {
+		return nil
+	}
+
+	remote := toRemoteEvent(event)
+	action := models.SyncPushedCreate
+	if link != nil {
+		remote.ID = link.RemoteID
+		action = models.SyncPushedUpdate
+	}
+	saved, err := r.provider.Upsert(r.ctx, r.remote, remote)
+	if errors.Is(err, calsync.ErrNotFound) && remote.ID != "" {
+		// El evento externo ya no existe: se vuelve a crear
+		remote.ID = ""
+		action = models.SyncPushedCreate
+		saved, err = r.provider.Upsert(r.ctx, r.remote, remote)
+	}
+	if err != nil {
+		return fmt.Errorf("saving remote event for event %d: %w", event.ID, err)
+	}
+
+	if link == nil {
+		link = &models.SyncLink{AccountID: r.account.ID, EventID: event.ID}
+	}
+	link.RemoteID = saved.ID
+	link.RemoteVersion = saved.Version
+	link.LocalUpdatedAt = event.UpdatedAt
+	if err := r.service.syncRepo.SaveLink(link); err != nil {
+		return err
+	}
+
+	audit.RemoteID = saved.ID
+	audit.LocalUpdatedAt = timePtr(event.UpdatedAt)
+	audit.Action = action
+	r.result.Pushed++
+	return r.service.syncRepo.AddAudit(audit)
+}
+
+// fromRemote copia los datos del evento externo al evento y aplica las reglas de creación.
+// Los campos que el proveedor no maneja (contacto, color, recordatorios, ...) se conservan.
+func (r *syncRun) fromRemote(event *models.Event, change calsync.RemoteEvent) error {
+	event.Title = strings.TrimSpace(change.Title)
+	event.Description = strings.TrimSpace(change.Description)
+	event.Location = strings.TrimSpace(change.Location)
+	event.StartsAt = change.StartsAt
+	event.EndsAt = change.EndsAt
+	event.IsAllDay = change.AllDay
+	event.TimeZone = change.TimeZone
+	if event.TimeZone == "" {
+		event.TimeZone = "UTC"
+	}
+	if _, err := models.LoadLocation(event.TimeZone); err != nil {
+		return err
+	}
+
+	event.RRule = ""
+	if change.RRule != "" {
+		rule, err := models.ParseRRule(change.RRule)
+		if err != nil {
+			return fmt.Errorf("unsupported rrule: %w", err)
+		}
+		event.RRule = rule.String()
+	}
+
+	event.SyncLegacyFields()
+	return NewEventCreationService(r.events, nil).PrepareImport(event)
+}
+
+// fail registra un evento externo que no pudo copiarse; la sincronización sigue
+func (r *syncRun) fail(audit *models.SyncAudit, err error) error {
+	audit.Action = models.SyncFailed
+	audit.Detail = err.Error()
+	r.result.Failed++
+	return r.service.syncRepo.AddAudit(audit)
+}
+
+// ownerEmail devuelve el email del dueño de la cuenta, destinatario de los recordatorios
+// de los eventos copiados
+func (r *syncRun) ownerEmail() (string, error) {
+	if r.email == "" {
+		user, err := r.service.userRepo.GetByID(r.account.OwnerID)
+		if err != nil {
+			return "", err
+		}
+		r.email = user.Email
+	}
+	return r.email, nil
+}
+
+// toRemoteEvent convierte un evento en su versión para el proveedor
+func toRemoteEvent(event *models.Event) calsync.RemoteEvent {
+	return calsync.RemoteEvent{
+		Title:       event.Title,
+		Description: event.Description,
+		Location:    event.Location,
+		StartsAt:    event.StartsAt,
+		EndsAt:      event.EndsAt,
+		TimeZone:    event.Zone().String(),
+		AllDay:      event.IsAllDay,
+		RRule:       event.RRule,
+	}
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
@@ -0,0 +1,226 @@
+package services
+
+import (
+	"calendar-backend/calsync"
+	"calendar-backend/models"
+	"calendar-backend/repositories"
+	"testing"
+	"time"
+)
+
+// syncFixture es un usuario con su calendario conectado a un proveedor en memoria
+type syncFixture struct {
+	events   repositories.EventRepository
+	syncRepo repositories.SyncRepository
+	provider *calsync.FakeProvider
+	service  *SyncService
+	account  *models.SyncAccount
+	user     *models.User
+}
+
+func newSyncFixture(t *testing.T) *syncFixture {
+	t.Helper()
+	db := newTestDB(t)
+	eventRepo := newTestEventRepository(t, db)
+	syncRepo := repositories.NewSyncRepository(db)
+	provider := calsync.NewFakeProvider()
+	service := NewSyncService(syncRepo, eventRepo, repositories.NewUserRepository(db), calsync.NewRegistry(provider), nil, &recordedChanges{}, 0)
+	user := createTestUser(t, db, "ana@example.com")
+	account, err := service.ConnectAccount(user.ID, provider.Name(), "primary", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &syncFixture{
+		events:   eventRepo.ForOwner(user.ID),
+		syncRepo: syncRepo,
+		provider: provider,
+		service:  service,
+		account:  account,
+		user:     user,
+	}
+}
+
+// sync sincroniza la cuenta y comprueba el resultado
+func (f *syncFixture) sync(t *testing.T, want SyncResult) {
+	t.Helper()
+	_, result, err := f.service.SyncNow(f.user.ID, f.account.ID)
+	if err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	if *result != want {
+		t.Errorf("sync result %+v, want %+v", *result, want)
+	}
+}
+
+// createLocal crea un evento en el calendario del usuario
+func (f *syncFixture) createLocal(t *testing.T, title string) *models.Event {
+	t.Helper()
+	event := newTestEvent(f.user.ID, title, time.Now().Add(48*time.Hour).UTC().Truncate(time.Hour), "")
+	if err := f.events.Create(event); err != nil {
+		t.Fatal(err)
+	}
+	return event
+}
+
+// putRemote crea o edita un evento en el calendario externo en el instante at
+func (f *syncFixture) putRemote(id, title string, at time.Time) calsync.RemoteEvent {
+	start := time.Now().Add(72 * time.Hour).UTC().Truncate(time.Hour)
+	return f.provider.Put(f.account.CalendarID, calsync.RemoteEvent{ID: id, Title: title, StartsAt: start, EndsAt: start.Add(time.Hour)}, at)
+}
+
+// titles devuelve los títulos de los eventos locales y de los externos
+func (f *syncFixture) titles(t *testing.T) (local, remote map[string]bool) {
+	t.Helper()
+	events, err := f.events.GetAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	local, remote = map[string]bool{}, map[string]bool{}
+	for _, event := range events {
+		local[event.Title] = true
+	}
+	for _, event := range f.provider.Events(f.account.CalendarID) {
+		remote[event.Title] = true
+	}
+	return local, remote
+}
+
+// remoteID devuelve el ID externo del evento local
+func (f *syncFixture) remoteID(t *testing.T, eventID uint) string {
+	t.Helper()
+	link, err := f.syncRepo.GetLinkByEvent(f.account.ID, eventID)
+	if err != nil {
+		t.Fatalf("link of event %d: %v", eventID, err)
+	}
+	return link.RemoteID
+}
+
+func TestSyncCopiesEventsBothWays(t *testing.T) {
+	f := newSyncFixture(t)
+	f.createLocal(t, "Reunión")
+	f.putRemote("", "Dentista", time.Now())
+
+	f.sync(t, SyncResult{Pulled: 1, Pushed: 1})
+	local, remote := f.titles(t)
+	for _, title := range []string{"Reunión", "Dentista"} {
+		if !local[title] || !remote[title] {
+			t.Errorf("%q in calendar %v and in provider %v, want in both", title, local[title], remote[title])
+		}
+	}
+	if len(local) != 2 || len(remote) != 2 {
+		t.Errorf("calendar %v, provider %v, want the two events on each side", local, remote)
+	}
+
+	// Sin cambios nuevos no se copia nada, ni los eventos enviados vuelven como externos
+	f.sync(t, SyncResult{})
+}
+
+func TestSyncPropagatesDeletions(t *testing.T) {
+	tests := []struct {
+		name   string
+		delete func(f *syncFixture, event *models.Event, remoteID string) error
+		want   SyncResult
+	}{
+		{"deleted in the provider", func(f *syncFixture, event *models.Event, remoteID string) error {
+			f.provider.Remove(f.account.CalendarID, remoteID, time.Now())
+			return nil
+		}, SyncResult{Pulled: 1}},
+		{"deleted in the calendar", func(f *syncFixture, event *models.Event, remoteID string) error {
+			return f.events.Delete(event.ID)
+		}, SyncResult{Pushed: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newSyncFixture(t)
+			event := f.createLocal(t, "Reunión")
+			f.sync(t, SyncResult{Pushed: 1})
+
+			if err := tt.delete(f, event, f.remoteID(t, event.ID)); err != nil {
+				t.Fatal(err)
+			}
+			f.sync(t, tt.want)
+			if local, remote := f.titles(t); len(local) != 0 || len(remote) != 0 {
+				t.Errorf("calendar %v, provider %v after the deletion, want both empty", local, remote)
+			}
+			if _, err := f.syncRepo.GetLinkByEvent(f.account.ID, event.ID); err == nil {
+				t.Error("the link of the deleted event was kept")
+			}
+			f.sync(t, SyncResult{})
+		})
+	}
+}
+
+func TestSyncResolvesConflictsByLastChange(t *testing.T) {
+	tests := []struct {
+		name       string
+		remoteAt   time.Duration // Edición externa respecto de la local
+		wantWinner string
+		wantTitle  string
+		want       SyncResult
+	}{
+		{"remote edited later", time.Hour, syncWinnerRemote, "Reunión (externa)", SyncResult{Pulled: 1, Conflicts: 1}},
+		{"local edited later", -time.Hour, syncWinnerLocal, "Reunión (local)", SyncResult{Pushed: 1, Conflicts: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newSyncFixture(t)
+			event := f.createLocal(t, "Reunión")
+			f.sync(t, SyncResult{Pushed: 1})
+
+			// El evento cambia de los dos lados entre dos sincronizaciones
+			if err := f.events.Update(event.ID, &models.Event{Title: "Reunión (local)"}); err != nil {
+				t.Fatal(err)
+			}
+			f.putRemote(f.remoteID(t, event.ID), "Reunión (externa)", time.Now().Add(tt.remoteAt))
+			f.sync(t, tt.want)
+
+			local, remote := f.titles(t)
+			if len(local) != 1 || !local[tt.wantTitle] || len(remote) != 1 || !remote[tt.wantTitle] {
+				t.Errorf("calendar %v, provider %v, want %q on both sides", local, remote, tt.wantTitle)
+			}
+			audit, err := f.syncRepo.GetAudit(f.account.ID, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(audit) != 1 || !audit[0].Conflict || audit[0].Winner != tt.wantWinner || audit[0].EventID != event.ID {
+				t.Errorf("last audit entry %+v, want a conflict of event %d won by %s", audit, event.ID, tt.wantWinner)
+			}
+			f.sync(t, SyncResult{})
+		})
+	}
+}
+
+func TestSyncConflictWithDeletionKeepsTheLastChange(t *testing.T) {
+	tests := []struct {
+		name      string
+		remoteAt  time.Duration // Edición externa respecto de la eliminación local
+		wantTitle string        // Vacío = el evento queda eliminado de los dos lados
+		want      SyncResult
+	}{
+		{"remote edited after the local deletion", time.Hour, "Reunión (externa)", SyncResult{Pulled: 1, Conflicts: 1}},
+		{"local deletion after the remote edit", -time.Hour, "", SyncResult{Pushed: 1, Conflicts: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newSyncFixture(t)
+			event := f.createLocal(t, "Reunión")
+			f.sync(t, SyncResult{Pushed: 1})
+
+			if err := f.events.Delete(event.ID); err != nil {
+				t.Fatal(err)
+			}
+			f.putRemote(f.remoteID(t, event.ID), "Reunión (externa)", time.Now().Add(tt.remoteAt))
+			f.sync(t, tt.want)
+
+			local, remote := f.titles(t)
+			wantCount := 0
+			if tt.wantTitle != "" {
+				wantCount = 1
+			}
+			if len(local) != wantCount || len(remote) != wantCount || (wantCount == 1 && (!local[tt.wantTitle] || !remote[tt.wantTitle])) {
+				t.Errorf("calendar %v, provider %v, want %d events titled %q on both sides", local, remote, wantCount, tt.wantTitle)
+			}
+			f.sync(t, SyncResult{})
+		})
+	}
+}
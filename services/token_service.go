@@ -0,0 +1,169 @@
+package services
+
+import (
+	"calendar-backend/config"
+	"calendar-backend/models"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Tipos de token emitidos
+const (
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
+)
+
+var ErrInvalidToken = errors.New("invalid or expired token")
+
+// ErrJWTSecretMissing indica que falta JWT_SECRET fuera del modo de desarrollo
+var ErrJWTSecretMissing = errors.New("JWT_SECRET is not set (only DEV_MODE=true allows a random secret)")
+
+// TokenClaims son los claims de los JWT emitidos por la API
+type TokenClaims struct {
+	Subject   string `json:"sub"`
+	Email     string `json:"email"`
+	Type      string `json:"typ"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// UserID devuelve el ID de usuario contenido en el claim sub
+func (c *TokenClaims) UserID() uint {
+	id, _ := strconv.ParseUint(c.Subject, 10, 64)
+	return uint(id)
+}
+
+// TokenPair es la respuesta de login/registro/refresh
+type TokenPair struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"` // Segundos de validez del access token
+}
+
+// TokenService firma y verifica JWT HS256
+type TokenService struct {
+	secret     []byte
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+}
+
+// NewTokenService crea el servicio con JWT_SECRET. Sin secreto devuelve ErrJWTSecretMissing,
+// salvo en modo de desarrollo
+func NewTokenService() (*TokenService, error) {
+	cfg := config.LoadConfig()
+
+	secret := []byte(cfg.JWTSecret)
+	if len(secret) == 0 {
+		// Con un secreto aleatorio los tokens dejan de ser válidos al reiniciar y cada instancia
+		// rechaza los de las demás: solo sirve para desarrollo
+		if !cfg.DevMode {
+			return nil, ErrJWTSecretMissing
+		}
+		log.Println("JWT_SECRET not set, using a random secret for this process (DEV_MODE)")
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			log.Fatal("Failed to generate JWT secret:", err)
+		}
+	}
+
+	return &TokenService{
+		secret:     secret,
+		accessTTL:  cfg.AccessTokenTTL,
+		refreshTTL: cfg.RefreshTokenTTL,
+	}, nil
+}
+
+// IssuePair emite un access token y un refresh token para el usuario
+func (s *TokenService) IssuePair(user *models.User) (*TokenPair, error) {
+	now := time.Now()
+
+	access, err := s.sign(user, TokenTypeAccess, now, s.accessTTL)
+	if err != nil {
+		return nil, err
+	}
+	refresh, err := s.sign(user, TokenTypeRefresh, now, s.refreshTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:  access,
+		RefreshToken: refresh,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(s.accessTTL.Seconds()),
+	}, nil
+}
+
+// Parse verifica firma, expiración y tipo del token y devuelve sus claims
+func (s *TokenService) Parse(token, tokenType string) (*TokenClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidToken
+	}
+
+	// Verificar la firma antes de mirar el contenido
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !hmac.Equal(signature, s.signature(parts[0]+"."+parts[1])) {
+		return nil, ErrInvalidToken
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil || header.Alg != "HS256" {
+		return nil, ErrInvalidToken
+	}
+
+	var claims TokenClaims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, ErrInvalidToken
+	}
+	if claims.Type != tokenType || claims.UserID() == 0 || time.Now().Unix() >= claims.ExpiresAt {
+		return nil, ErrInvalidToken
+	}
+
+	return &claims, nil
+}
+
+func (s *TokenService) sign(user *models.User, tokenType string, now time.Time, ttl time.Duration) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "HS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(TokenClaims{
+		Subject:   strconv.FormatUint(uint64(user.ID), 10),
+		Email:     user.Email,
+		Type:      tokenType,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(ttl).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(s.signature(unsigned)), nil
+}
+
+func (s *TokenService) signature(unsigned string) []byte {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(unsigned))
+	return mac.Sum(nil)
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
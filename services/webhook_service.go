@@ -0,0 +1,467 @@
+package services
+
+import (
+	"bytes"
+	"calendar-backend/eventbus"
+	"calendar-backend/models"
+	"calendar-backend/netguard"
+	"calendar-backend/notifications"
+	"calendar-backend/repositories"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Encabezados de los avisos que se envían a los webhooks
+const (
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookDeliveryHeader  = "X-Webhook-Delivery" // ID del envío: se repite en los reintentos
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	WebhookSignatureHeader = "X-Webhook-Signature"
+)
+
+const (
+	// webhookPollInterval es cada cuánto se buscan envíos pendientes, además de al encolarlos
+	webhookPollInterval = 5 * time.Second
+	// webhookBatchSize es la cantidad de envíos que se intentan a la vez
+	webhookBatchSize = 20
+)
+
+var ErrWebhookNotFound = errors.New("webhook not found")
+
+// WebhookPayload es el cuerpo JSON de un aviso
+type WebhookPayload struct {
+	Type      string      `json:"type"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// WebhookService envía a los webhooks de cada usuario los cambios de sus eventos y los
+// recordatorios que vencen. Cada aviso queda registrado como un envío que se reintenta con
+// espera exponencial hasta que el receptor responda 2xx o se agoten los intentos.
+type WebhookService struct {
+	webhookRepo repositories.WebhookRepository
+	eventRepo   repositories.EventRepository
+	client      *http.Client
+	retry       notifications.RetryPolicy
+
+	wake     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+func NewWebhookService(webhookRepo repositories.WebhookRepository, eventRepo repositories.EventRepository, retry notifications.RetryPolicy, timeout time.Duration) *WebhookService {
+	return &WebhookService{
+		webhookRepo: webhookRepo,
+		eventRepo:   eventRepo,
+		client:      newWebhookClient(timeout, netguard.Control),
+		retry:       retry,
+		wake:        make(chan struct{}, 1),
+		done:        make(chan struct{}),
+	}
+}
+
+// newWebhookClient crea el cliente que envía los avisos. control se aplica a la IP ya resuelta
+// de cada conexión: netguard.Control impide llegar a la red interna del servidor (los tests
+// lo omiten para usar receptores en loopback).
+func newWebhookClient(timeout time.Duration, control func(network, address string, c syscall.RawConn) error) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout, Control: control}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	// Con un proxy la IP controlada sería la del proxy y no la del receptor
+	transport.Proxy = nil
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		// Una redirección es una respuesta fallida: seguirla cambiaría el POST por un GET
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+}
+
+// CreateWebhook registra un webhook del usuario. Si no trae secreto se genera uno; el secreto
+// solo se devuelve aquí
+func (s *WebhookService) CreateWebhook(ownerID uint, webhook *models.Webhook) (string, error) {
+	if webhook.Secret == "" {
+		raw := make([]byte, 32)
+		if _, err := rand.Read(raw); err != nil {
+			return "", err
+		}
+		webhook.Secret = "whsec_" + base64.RawURLEncoding.EncodeToString(raw)
+	}
+
+	webhook.OwnerID = ownerID
+	if err := s.webhookRepo.Create(webhook); err != nil {
+		return "", err
+	}
+	return webhook.Secret, nil
+}
+
+// ListWebhooks devuelve los webhooks del usuario, incluidos los desactivados
+func (s *WebhookService) ListWebhooks(ownerID uint) ([]models.Webhook, error) {
+	return s.webhookRepo.GetByOwner(ownerID)
+}
+
+// GetWebhook devuelve un webhook del usuario
+func (s *WebhookService) GetWebhook(ownerID, id uint) (*models.Webhook, error) {
+	webhook, err := s.webhookRepo.GetByID(ownerID, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrWebhookNotFound
+	}
+	return webhook, err
+}
+
+// DisableWebhook deja de enviar avisos al webhook, incluidos los reintentos pendientes
+func (s *WebhookService) DisableWebhook(ownerID, id uint) (*models.Webhook, error) {
+	return s.setDisabled(ownerID, id, true)
+}
+
+// EnableWebhook vuelve a enviar avisos a un webhook desactivado
+func (s *WebhookService) EnableWebhook(ownerID, id uint) (*models.Webhook, error) {
+	return s.setDisabled(ownerID, id, false)
+}
+
+func (s *WebhookService) setDisabled(ownerID, id uint, disabled bool) (*models.Webhook, error) {
+	err := s.webhookRepo.SetDisabled(ownerID, id, disabled)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrWebhookNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.GetWebhook(ownerID, id)
+}
+
+// TestWebhook envía un aviso "ping" al webhook, aunque esté desactivado, y devuelve el resultado.
+// El envío queda en el registro pero no se reintenta.
+func (s *WebhookService) TestWebhook(ownerID, id uint) (*models.WebhookDelivery, error) {
+	webhook, err := s.GetWebhook(ownerID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries, err := s.newDeliveries([]models.Webhook{*webhook}, models.WebhookPing, time.Now().UTC(), map[string]interface{}{"webhook_id": webhook.ID})
+	if err != nil {
+		return nil, err
+	}
+	// Sin próximo intento el envío no lo toma la cola mientras se intenta aquí
+	deliveries[0].NextAttemptAt = nil
+	if err := s.webhookRepo.CreateDeliveries(deliveries); err != nil {
+		return nil, err
+	}
+	delivery := &deliveries[0]
+	if err := s.attempt(webhook, delivery, false); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// Deliveries devuelve los últimos limit envíos a un webhook del usuario
+func (s *WebhookService) Deliveries(ownerID, id uint, limit int) ([]models.WebhookDelivery, error) {
+	if _, err := s.GetWebhook(ownerID, id); err != nil {
+		return nil, err
+	}
+	return s.webhookRepo.GetDeliveries(id, limit)
+}
+
+// Publish encola el aviso del cambio de un evento para los webhooks suscritos a él de los
+// usuarios a los que se avisa el cambio. Implementa ChangePublisher; un error solo se registra,
+// como en el bus de eventos.
+func (s *WebhookService) Publish(change eventbus.Change) {
+	eventType := webhookEventType(change.Type)
+	if eventType == "" {
+		return
+	}
+	at := change.At
+	if at.IsZero() {
+		at = time.Now().UTC()
+	}
+
+	var webhooks []models.Webhook
+	for _, userID := range change.Recipients() {
+		subscribed, err := s.subscribed(userID, eventType)
+		if err != nil {
+			log.Printf("❌ Error loading webhooks of user %d: %v", userID, err)
+			continue
+		}
+		webhooks = append(webhooks, subscribed...)
+	}
+	if len(webhooks) == 0 {
+		return
+	}
+
+	data := map[string]interface{}{}
+	event, err := s.eventRepo.GetIncludingDeleted(change.EventID)
+	switch {
+	case err == nil && change.Type == eventbus.Deleted && !event.DeletedAt.Valid:
+		// El evento sigue existiendo, pero estos usuarios dejaron de verlo: solo se avisa cuál era
+		data["event"] = models.Tombstone{ID: change.EventID, DeletedAt: at}
+		data["deleted_at"] = at
+	case err == nil:
+		data["event"] = event.ToResponse()
+		if event.DeletedAt.Valid {
+			data["deleted_at"] = event.DeletedAt.Time.UTC()
+		}
+	case errors.Is(err, gorm.ErrRecordNotFound) && change.Type == eventbus.Deleted:
+		// El evento ya no existe: solo se avisa cuál era
+		data["event"] = models.Tombstone{ID: change.EventID, DeletedAt: at}
+		data["deleted_at"] = at
+	default:
+		log.Printf("❌ Error loading event %d for webhooks: %v", change.EventID, err)
+		return
+	}
+
+	if err := s.enqueue(webhooks, eventType, at, data); err != nil {
+		log.Printf("❌ Error queueing %s webhooks of event %d: %v", eventType, change.EventID, err)
+	}
+}
+
+// ReminderFired encola el aviso de un recordatorio que venció para los webhooks del dueño del evento
+func (s *WebhookService) ReminderFired(occurrence *models.Event, reminder models.Reminder, dueAt time.Time) {
+	webhooks, err := s.subscribed(occurrence.OwnerID, models.WebhookReminderFired)
+	if err != nil {
+		log.Printf("❌ Error loading webhooks of user %d: %v", occurrence.OwnerID, err)
+		return
+	}
+	if len(webhooks) == 0 {
+		return
+	}
+
+	data := map[string]interface{}{
+		"event":     occurrence.ToResponse(),
+		"reminder":  reminder,
+		"due_at":    dueAt.UTC(),
+		"starts_at": occurrence.StartsAt.UTC(),
+	}
+	if err := s.enqueue(webhooks, models.WebhookReminderFired, time.Now().UTC(), data); err != nil {
+		log.Printf("❌ Error queueing reminder webhooks of event %d: %v", occurrence.ID, err)
+	}
+}
+
+// Start arranca el envío de los avisos pendientes
+func (s *WebhookService) Start() {
+	s.wg.Add(1)
+	go s.run()
+	log.Printf("Webhook deliveries started (up to %d attempts)", s.retry.MaxAttempts)
+}
+
+// Stop detiene el envío de avisos, esperando los intentos en curso
+func (s *WebhookService) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.done)
+	})
+	s.wg.Wait()
+}
+
+func (s *WebhookService) run() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(webhookPollInterval)
+	defer ticker.Stop()
+	for {
+		s.deliverDue()
+		select {
+		case <-ticker.C:
+		case <-s.wake:
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// deliverDue intenta los envíos pendientes que vencieron, de a webhookBatchSize en paralelo
+func (s *WebhookService) deliverDue() {
+	for {
+		// Mientras se intenta un envío ninguna otra instancia lo toma
+		lease := 2*s.client.Timeout + time.Minute
+		due, err := s.webhookRepo.ClaimDueDeliveries(time.Now(), lease, webhookBatchSize)
+		if err != nil {
+			log.Printf("❌ Error loading pending webhook deliveries: %v", err)
+		}
+		if len(due) == 0 {
+			return
+		}
+
+		webhooks := make(map[uint]*models.Webhook)
+		var wg sync.WaitGroup
+		for i := range due {
+			delivery := &due[i]
+			webhook, ok := webhooks[delivery.WebhookID]
+			if !ok {
+				if webhook, err = s.webhookRepo.GetWebhook(delivery.WebhookID); err != nil {
+					log.Printf("❌ Error loading webhook %d: %v", delivery.WebhookID, err)
+					continue
+				}
+				webhooks[delivery.WebhookID] = webhook
+			}
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := s.attempt(webhook, delivery, true); err != nil {
+					log.Printf("❌ Error saving webhook delivery %d: %v", delivery.ID, err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		if len(due) < webhookBatchSize {
+			return
+		}
+	}
+}
+
+// attempt envía el aviso y guarda el resultado. Si falla y retry lo permite, programa el
+// próximo intento; si no, el envío queda fallido.
+func (s *WebhookService) attempt(webhook *models.Webhook, delivery *models.WebhookDelivery, retry bool) error {
+	if retry && webhook.IsDisabled() {
+		delivery.Status = models.DeliveryFailed
+		delivery.NextAttemptAt = nil
+		delivery.LastError = "webhook disabled"
+		return s.webhookRepo.SaveDelivery(delivery)
+	}
+
+	delivery.Attempts++
+	status, err := s.send(webhook, delivery)
+	delivery.ResponseStatus = status
+	if err == nil {
+		now := time.Now()
+		delivery.Status = models.DeliverySucceeded
+		delivery.NextAttemptAt = nil
+		delivery.LastError = ""
+		delivery.DeliveredAt = &now
+		return s.webhookRepo.SaveDelivery(delivery)
+	}
+
+	delivery.LastError = err.Error()
+	if !retry || s.retry.Exhausted(delivery.Attempts) {
+		delivery.Status = models.DeliveryFailed
+		delivery.NextAttemptAt = nil
+		log.Printf("❌ Webhook delivery %d to %s failed after %d attempts: %v", delivery.ID, webhook.URL, delivery.Attempts, err)
+	} else {
+		next := time.Now().Add(s.retry.Backoff(delivery.Attempts))
+		delivery.NextAttemptAt = &next
+		log.Printf("⚠️ Webhook delivery %d to %s failed (attempt %d), retrying at %s: %v",
+			delivery.ID, webhook.URL, delivery.Attempts, next.Format(time.RFC3339), err)
+	}
+	return s.webhookRepo.SaveDelivery(delivery)
+}
+
+// send hace el POST firmado del aviso y devuelve el status de la respuesta, si la hubo
+func (s *WebhookService) send(webhook *models.Webhook, delivery *models.WebhookDelivery) (int, error) {
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "calendar-backend-webhooks")
+	req.Header.Set(WebhookEventHeader, delivery.EventType)
+	req.Header.Set(WebhookDeliveryHeader, strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set(WebhookTimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(WebhookSignatureHeader, "sha256="+SignWebhookPayload(webhook.Secret, timestamp, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	// El cuerpo de la respuesta no se guarda: solo el status
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp.StatusCode, nil
+	}
+	return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+}
+
+// SignWebhookPayload firma un aviso: HMAC-SHA256 (hex) con el secreto del webhook de
+// "{timestamp}.{cuerpo}". Incluir el timestamp permite al receptor rechazar avisos repetidos.
+func SignWebhookPayload(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// subscribed devuelve los webhooks activos del usuario que reciben los avisos del tipo
+func (s *WebhookService) subscribed(ownerID uint, eventType string) ([]models.Webhook, error) {
+	webhooks, err := s.webhookRepo.GetActiveByOwner(ownerID)
+	if err != nil {
+		return nil, err
+	}
+	matching := webhooks[:0]
+	for _, webhook := range webhooks {
+		if webhook.Subscribes(eventType) {
+			matching = append(matching, webhook)
+		}
+	}
+	return matching, nil
+}
+
+// enqueue guarda un envío pendiente del aviso por cada webhook y despierta al que los envía
+func (s *WebhookService) enqueue(webhooks []models.Webhook, eventType string, at time.Time, data interface{}) error {
+	deliveries, err := s.newDeliveries(webhooks, eventType, at, data)
+	if err != nil {
+		return err
+	}
+	if err := s.webhookRepo.CreateDeliveries(deliveries); err != nil {
+		return err
+	}
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// newDeliveries arma los envíos pendientes del aviso, todos con el mismo cuerpo
+func (s *WebhookService) newDeliveries(webhooks []models.Webhook, eventType string, at time.Time, data interface{}) ([]models.WebhookDelivery, error) {
+	payload, err := json.Marshal(WebhookPayload{Type: eventType, CreatedAt: at, Data: data})
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	deliveries := make([]models.WebhookDelivery, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		deliveries = append(deliveries, models.WebhookDelivery{
+			WebhookID:     webhook.ID,
+			EventType:     eventType,
+			Payload:       string(payload),
+			Status:        models.DeliveryPending,
+			NextAttemptAt: &now,
+		})
+	}
+	return deliveries, nil
+}
+
+// webhookEventType devuelve el tipo de aviso de un cambio del bus de eventos
+func webhookEventType(changeType string) string {
+	switch changeType {
+	case eventbus.Created:
+		return models.WebhookEventCreated
+	case eventbus.Updated:
+		return models.WebhookEventUpdated
+	case eventbus.Deleted:
+		return models.WebhookEventDeleted
+	}
+	return ""
+}
@@ -0,0 +1,169 @@
+package services
+
+import (
+	"calendar-backend/eventbus"
+	"calendar-backend/models"
+	"calendar-backend/notifications"
+	"calendar-backend/repositories"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// webhookReceiver es un receptor de webhooks que verifica la firma de cada aviso y responde
+// los status de statuses en orden (el último se repite)
+type webhookReceiver struct {
+	*httptest.Server
+	t        *testing.T
+	secret   string
+	statuses []int
+
+	mu       sync.Mutex
+	received []string // Tipo de cada aviso recibido
+}
+
+func newWebhookReceiver(t *testing.T, statuses ...int) *webhookReceiver {
+	r := &webhookReceiver{t: t, statuses: statuses}
+	r.Server = httptest.NewServer(http.HandlerFunc(r.serve))
+	t.Cleanup(r.Close)
+	return r
+}
+
+func (r *webhookReceiver) serve(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	timestamp, err := strconv.ParseInt(req.Header.Get(WebhookTimestampHeader), 10, 64)
+	if err != nil {
+		r.t.Errorf("invalid timestamp header %q", req.Header.Get(WebhookTimestampHeader))
+	}
+	if got, want := req.Header.Get(WebhookSignatureHeader), "sha256="+SignWebhookPayload(r.secret, timestamp, body); got != want {
+		r.t.Errorf("signature %s, want %s", got, want)
+	}
+
+	r.mu.Lock()
+	r.received = append(r.received, req.Header.Get(WebhookEventHeader))
+	status := r.statuses[len(r.statuses)-1]
+	if len(r.received) <= len(r.statuses) {
+		status = r.statuses[len(r.received)-1]
+	}
+	r.mu.Unlock()
+
+	w.WriteHeader(status)
+	io.WriteString(w, "internal details of the receiver")
+}
+
+func (r *webhookReceiver) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.received)
+}
+
+// newTestWebhookService crea el servicio con un webhook de alice hacia el receptor. Sin
+// allowLoopback usa el cliente de producción, que no llega al receptor en loopback.
+func newTestWebhookService(t *testing.T, f *sharingFixture, receiver *webhookReceiver, maxAttempts int, allowLoopback bool) (*WebhookService, *models.Webhook) {
+	t.Helper()
+	service := NewWebhookService(repositories.NewWebhookRepository(f.db), f.eventRepo, notifications.RetryPolicy{MaxAttempts: maxAttempts}, time.Second)
+	if allowLoopback {
+		service.client = newWebhookClient(time.Second, nil)
+	}
+
+	webhook := &models.Webhook{URL: receiver.URL}
+	secret, err := service.CreateWebhook(f.alice.ID, webhook)
+	if err != nil {
+		t.Fatal(err)
+	}
+	receiver.secret = secret
+	return service, webhook
+}
+
+func TestWebhookDeliveriesAreSignedAndRetried(t *testing.T) {
+	f := newSharingFixture(t)
+	receiver := newWebhookReceiver(t, http.StatusInternalServerError, http.StatusNoContent)
+	service, webhook := newTestWebhookService(t, f, receiver, 3, true)
+
+	service.Publish(eventbus.Change{Type: eventbus.Updated, OwnerID: f.alice.ID, EventID: f.event.ID})
+	service.deliverDue()
+	deliveries, err := service.Deliveries(f.alice.ID, webhook.ID, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deliveries) != 1 || deliveries[0].Status != models.DeliveryPending || deliveries[0].Attempts != 1 {
+		t.Fatalf("after the first attempt: %+v, want one pending delivery", deliveries)
+	}
+	// Solo se guarda el status: el cuerpo de la respuesta puede tener datos del receptor
+	if delivery := deliveries[0]; delivery.ResponseStatus != http.StatusInternalServerError || delivery.LastError != "unexpected status 500" {
+		t.Errorf("first attempt recorded status %d, error %q", delivery.ResponseStatus, delivery.LastError)
+	}
+
+	service.deliverDue()
+	deliveries, err = service.Deliveries(f.alice.ID, webhook.ID, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if delivery := deliveries[0]; delivery.Status != models.DeliverySucceeded || delivery.Attempts != 2 || delivery.LastError != "" {
+		t.Fatalf("after the retry: %+v, want the delivery succeeded on the second attempt", delivery)
+	}
+	if receiver.count() != 2 || receiver.received[0] != models.WebhookEventUpdated {
+		t.Errorf("receiver got %v, want two %s notifications", receiver.received, models.WebhookEventUpdated)
+	}
+}
+
+func TestWebhookDeliveryFailsWhenAttemptsExhausted(t *testing.T) {
+	f := newSharingFixture(t)
+	receiver := newWebhookReceiver(t, http.StatusServiceUnavailable)
+	service, webhook := newTestWebhookService(t, f, receiver, 2, true)
+
+	service.Publish(eventbus.Change{Type: eventbus.Deleted, OwnerID: f.alice.ID, EventID: f.event.ID})
+	for i := 0; i < 3; i++ {
+		service.deliverDue()
+	}
+
+	deliveries, err := service.Deliveries(f.alice.ID, webhook.ID, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deliveries) != 1 || deliveries[0].Status != models.DeliveryFailed || deliveries[0].Attempts != 2 {
+		t.Fatalf("deliveries %+v, want one failed after 2 attempts", deliveries)
+	}
+	if receiver.count() != 2 {
+		t.Errorf("receiver got %d requests, want 2", receiver.count())
+	}
+}
+
+func TestWebhookTestRecordsOnlyStatus(t *testing.T) {
+	f := newSharingFixture(t)
+	receiver := newWebhookReceiver(t, http.StatusBadRequest)
+	service, webhook := newTestWebhookService(t, f, receiver, 3, true)
+
+	delivery, err := service.TestWebhook(f.alice.ID, webhook.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if delivery.Status != models.DeliveryFailed || delivery.ResponseStatus != http.StatusBadRequest || delivery.LastError != "unexpected status 400" {
+		t.Errorf("test delivery %+v, want failed with only the status recorded", delivery)
+	}
+	if receiver.received[0] != models.WebhookPing {
+		t.Errorf("receiver got %v, want a ping", receiver.received)
+	}
+}
+
+func TestWebhookClientDoesNotReachInternalAddresses(t *testing.T) {
+	f := newSharingFixture(t)
+	receiver := newWebhookReceiver(t, http.StatusNoContent)
+	service, webhook := newTestWebhookService(t, f, receiver, 3, false)
+
+	delivery, err := service.TestWebhook(f.alice.ID, webhook.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if delivery.Status != models.DeliveryFailed || !strings.Contains(delivery.LastError, "not allowed") {
+		t.Errorf("delivery to loopback %+v, want failed as not allowed", delivery)
+	}
+	if receiver.count() != 0 {
+		t.Errorf("the loopback receiver got %d requests", receiver.count())
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"calendar-backend/database"
+	"calendar-backend/repositories"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const usersUsage = `usage: calendar-backend users <command>
+
+commands:
+  assign-legacy EMAIL  give the registered user EMAIL the events created with that email before
+                       accounts existed. Run it only after checking that the account is really
+                       the owner of the address: registering does not verify it`
+
+// runUsers runs the users command against the database configured by DATABASE_URL
+func runUsers(args []string) error {
+	if len(args) != 2 || args[0] != "assign-legacy" {
+		return errors.New(usersUsage)
+	}
+	email := strings.TrimSpace(strings.ToLower(args[1]))
+
+	db, err := database.Connect()
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %v", err)
+	}
+	db = db.Session(&gorm.Session{Logger: logger.Default.LogMode(logger.Warn)})
+
+	user, err := repositories.NewUserRepository(db).GetByEmail(email)
+	if err != nil {
+		return fmt.Errorf("no user registered with %s", email)
+	}
+	// Assigning events does not use the full-text search
+	assigned, err := repositories.NewEventRepository(db, nil).AssignOwnerByEmail(user.ID, email)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%d legacy events assigned to user %d (%s)\n", assigned, user.ID, email)
+	return nil
+}